@@ -18,8 +18,14 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"gopkg.in/alecthomas/kingpin.v2"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
@@ -28,19 +34,34 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 	kustomizeapi "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
 
+	"github.com/crossplane/crossplane-runtime/pkg/event"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane/apis/packages"
 	"github.com/crossplane/crossplane/apis/packages/v1alpha1"
 
+	"github.com/crossplane/templating-controller/pkg/operations"
 	"github.com/crossplane/templating-controller/pkg/operations/helm3"
 	"github.com/crossplane/templating-controller/pkg/operations/kustomize"
+	"github.com/crossplane/templating-controller/pkg/resource"
 	"github.com/crossplane/templating-controller/pkg/templating"
 )
 
@@ -61,10 +82,204 @@ func main() {
 
 		stackDefinitionNameInput      = app.Flag("stack-definition-name", "Name of the StackDefinition custom resource.").Required().String()
 		stackDefinitionNamespaceInput = app.Flag("stack-definition-namespace", "Namespace of the StackDefinition custom resource").String()
-		resourceDirInput              = app.Flag("resources-dir", "Directory of the resources to be fetched as input to the templating engine").Required().ExistingDir()
+		resourceDirInput              = app.Flag("resources-dir", "Directory of the resources to be fetched as input to the templating engine").ExistingDir()
+		resourcesBundleInput          = app.Flag("resources-bundle", "Bundle written with --bundle to render the resources of instead of --resources-dir, for clusters that cannot fetch anything").ExistingFile()
+		bundleInput                   = app.Flag("bundle", "Write the resources of --resources-dir, with their remote Kustomize bases and Helm chart dependencies fetched, to the given file as a bundle for --resources-bundle and exit").String()
 		debugInput                    = app.Flag("debug", "Enable debug logging").Bool()
+		syncIntervalInput             = app.Flag("sync-interval", "How often child resources are re-rendered and re-applied to correct drift. Zero disables the periodic syncs.").Default("1m").Duration()
+		readinessTimeoutInput         = app.Flag("readiness-timeout", "How long child resources may stay not ready before the parent resource is marked as degraded. Zero waits forever.").Duration()
+		pausedInput                   = app.Flag("paused", "Pause the reconciliation of all parent resources").Bool()
+		pauseConfigMapInput           = app.Flag("pause-configmap", "ConfigMap, given as namespace/name, whose paused key pauses the reconciliation of all parent resources at runtime when set to true").String()
+		strictDecodingInput           = app.Flag("strict-decoding", "Reject rendered child resources with unknown fields or duplicate keys instead of silently dropping them").Bool()
+		compositeKindsInput           = app.Flag("composite-kind", "Kind of rendered objects that wrap the child resources to be applied, given as Kind.group=field where field is the dot-separated path of the list of wrapped objects, e.g. Template.template.openshift.io=objects. Lists like v1 List are always expanded.").StringMap()
+		generatedHistoryInput         = app.Flag("generated-history-limit", "Number of superseded hash-suffixed generated ConfigMaps and Secrets to keep for every generator. The rest are deleted. Negative disables the collection.").Default("-1").Int()
+		pruneConfirmationInput        = app.Flag("prune-confirmation", "Delete the superseded generated ConfigMaps and Secrets only once the parent resource is annotated with the token in its PruneConfirmation condition, which lists them, or --prune-grace-period has elapsed").Bool()
+		pruneGracePeriodInput         = app.Flag("prune-grace-period", "Time after which the candidates of --prune-confirmation are deleted without confirmation. Zero waits for the confirmation.").Default("0").Duration()
+		kustomizeNameSuffixInput      = app.Flag("kustomize-name-suffix", "Suffix to be appended to the names of all child resources rendered by Kustomize").String()
+		kustomizeNamespaceInput       = app.Flag("kustomize-namespace", "Namespace to be set on all namespaced child resources rendered by Kustomize").String()
+		kustomizeLabelsInput          = app.Flag("kustomize-common-label", "Label to be added to all child resources rendered by Kustomize, given as key=value").StringMap()
+		kustomizeAnnotationsInput     = app.Flag("kustomize-common-annotation", "Annotation to be added to all child resources rendered by Kustomize, given as key=value").StringMap()
+		kustomizeSkipTransformsInput  = app.Flag("kustomize-skip-transform", "Transforms that the child resources of a kind, and of a name if given, rendered by Kustomize skip, given as transform[,transform]=Kind.group[/name] where transform is name-prefix or labels, e.g. name-prefix=ClusterIssuer.cert-manager.io/letsencrypt").Strings()
+		kustomizeJSON6902Input        = app.Flag("kustomize-json6902-patches", "YAML file with the list of JSON6902 patches, whose operations can take their values from the fields of the parent resource, to be applied to the child resources rendered by Kustomize").ExistingFile()
+		auditConfigMapInput           = app.Flag("audit-configmap", "ConfigMap, given as namespace/name, to keep the latest records of the changes applied to child resources in").String()
+		auditRecordLimitInput         = app.Flag("audit-record-limit", "Number of latest records to keep in the audit ConfigMap").Default("100").Int()
+		auditWebhookInput             = app.Flag("audit-webhook", "URL to POST the records of the changes applied to child resources to").String()
+		snapshotNamespaceInput        = app.Flag("snapshot-namespace", "Namespace to keep the snapshots of child resources in, taken before they are deleted, e.g. to be pruned or recreated").String()
+		snapshotLimitInput            = app.Flag("snapshot-limit", "Number of latest snapshots to keep per child resource in the snapshot namespace").Default("5").Int()
+		snapshotURLInput              = app.Flag("snapshot-url", "Base URL to PUT the snapshots of child resources to, taken before they are deleted, e.g. an object storage bucket. The data of Secrets is redacted.").String()
+		notificationWebhookInput      = app.Flag("notification-webhook-url", "URL to POST Slack-compatible notifications about the outcomes of reconciles to, i.e. success, failure, corrected drift and pruned child resources").String()
+		eventThrottleWindowInput      = app.Flag("event-throttle-window", "Window in which the repeats of an event of a parent resource are dropped, unless its message changes. Zero disables the throttling.").Default("1h").Duration()
+		metricsParentLabelsInput      = app.Flag("metrics-parent-labels", "Label the render metrics with the namespace and name of every parent resource instead of summing them up per kind of parent resource").Bool()
+		metricsParentLimitInput       = app.Flag("metrics-parent-limit", "Maximum number of parent resources to label the render metrics with when --metrics-parent-labels is set. The others are summed up under the name _other.").Default("500").Int()
+		scheduleSlackInput            = app.Flag("schedule-slack", "Time past their requeue that the parent resources are reported behind schedule in the templating_controller_parents_behind_schedule metric.").Default("1m").Duration()
+		scopedCacheInput              = app.Flag("scoped-cache", "Cache only the kinds of child resources that the templates declare, reading the others from the API server, instead of caching every kind that the controller reads cluster-wide").Bool()
+		cacheNamespacesInput          = app.Flag("cache-namespace", "Namespace to cache with --scoped-cache, along with the namespaces that the templates declare. It must include the namespaces of the parent resources. All namespaces are cached if none is given.").Strings()
+		drainTimeoutInput             = app.Flag("drain-timeout", "Time to wait on shutdown for the in-flight reconciles to finish applying child resources and writing the status of their parent resources. Zero exits without waiting.").Default("30s").Duration()
+		rolloutBatchInput             = app.Flag("rollout-batch-size", "Number of parent resources to roll new templates out to at a time, pausing on failures. Zero rolls them out to all parent resources at once.").Int()
+		revisionPinningInput          = app.Flag("pack-version-pinning", "Let parent resources pin their child resources to a revision of the templates in spec.packVersion, leaving them untouched until the pin is changed to the current revision, and report the current revision in status.availableTemplateRevision").Bool()
+		resultAnnotationsInput        = app.Flag("result-annotations", "Annotate the parent resources with the hash of their child resources, the time of the last successful sync and the revision of the templates after every successful sync, for external automation to key off").Bool()
+		rerenderBatchInput            = app.Flag("rerender-batch-size", "Number of parent resources to enqueue per --rerender-batch-interval for re-render after a restart with new templates, instead of enqueueing all of them at once. Zero disables the batching.").Int()
+		rerenderIntervalInput         = app.Flag("rerender-batch-interval", "Interval between the batches of --rerender-batch-size").Default("10s").Duration()
+		applyBudgetInput              = app.Flag("apply-budget", "Maximum number of child resources that one reconcile of a parent resource applies, leaving the rest to the reconciles that follow. Zero applies all child resources in every reconcile.").Int()
+		maxReconcilesInput            = app.Flag("max-concurrent-reconciles", "Maximum number of parent resources to reconcile at the same time").Default("1").Int()
+		fairnessLimitInput            = app.Flag("fairness-limit", "Maximum number of parent resources of the same namespace, or of the same value of --fairness-label, to reconcile at the same time. Zero disables the limit.").Int()
+		fairnessLabelInput            = app.Flag("fairness-label", "Label of parent resources, e.g. the tenant, to group them by for --fairness-limit instead of their namespace").String()
+		scaleDownKindsInput           = app.Flag("scale-down-before-delete", "Kind of workload child resources, given as Kind.group e.g. Deployment.apps, to be scaled down to zero replicas before the child resources of a deleted parent resource are deleted").Strings()
+		conditionHistoryInput         = app.Flag("condition-history-limit", "Number of the latest condition transitions to keep in the status of parent resources. Zero disables the history.").Default("0").Int()
+		uidTokenInput                 = app.Flag("uid-token", "Expose a short identifier derived from the UID of the parent resource to the templates as parameters.uidToken and the Kustomize var UID_TOKEN, and replace $(UID_TOKEN) with it in the child resources").Bool()
+		fieldManagerInput             = app.Flag("field-manager", "Field manager to apply the child resources in the name of, which shows up in their managedFields").Default(templating.FieldOwner).String()
+		forceConflictsInput           = app.Flag("force-conflicts", "Force the conflicts with other field managers when applying child resources server-side. Use --no-force-conflicts to fail on conflicts instead.").Default("true").Bool()
+		parameterSchemaInput          = app.Flag("parameters-schema", "OpenAPI v3 schema of the spec of parent resources to validate them against before render. Defaults to schema.yaml in --resources-dir if it exists.").String()
+		statusFieldsInput             = app.Flag("status-fields", "YAML file with the fields of the status of parent resources to populate from the child resources and the render metadata. Defaults to status.yaml in --resources-dir if it exists.").String()
+		crdValidationInput            = app.Flag("crd-validation", "Print the validation of the CustomResourceDefinition of parent resources generated from the parameters schema and the status fields and exit").Bool()
+		rbacInput                     = app.Flag("rbac", "Print the ClusterRoles and Roles that grant the controller access to the parent resources and the kinds of child resources that the templates declare, split by whether they are namespaced or cluster-scoped, and exit").Bool()
+		rbacKindsInput                = app.Flag("rbac-kind", "Kind of child resources, given as Kind.version.group, to grant access to with --rbac in addition to the ones the templates declare, e.g. the ones whose templates render their kind").Strings()
+		rbacNamespacesInput           = app.Flag("rbac-namespace", "Namespace to generate a Role for the namespaced kinds in with --rbac. A ClusterRole is generated for them if none is given.").Strings()
+		rbacAggregateInput            = app.Flag("rbac-aggregation-label", "Label, given as key=value, to add to the ClusterRoles generated with --rbac so that they are aggregated into other ClusterRoles").Strings()
+		describeParametersInput       = app.Flag("describe-parameters", "Print the fields of the spec of parent resources that are described in the parameters schema and exit").Bool()
+		explainInput                  = app.Flag("explain", "Print which fields the patchers set on which child resources of the parent resource in the given YAML file, and which patchers matched nothing, then exit without reconciling").ExistingFile()
+		renderInputsInput             = app.Flag("render-inputs", "Print everything that feeds into the render of the parent resource in the given YAML file, i.e. the parent resource as the templates see it, the values of every values provider with the objects it read, the facts about the cluster and the revision of the templates, then exit without reconciling. The values include the ones read from Secrets.").ExistingFile()
+		explainMappingsInput          = app.Flag("explain-mappings", "Print which fields of which child resources every field of the parent resources is mapped to by the Kustomize overlays and JSON6902 patches, listing the fields of the parameters schema that are not mapped, and exit").Bool()
+		renderCacheDirInput           = app.Flag("render-cache-dir", "Directory, e.g. a volume shared with CI, to cache the digests of the child resources rendered for every input and revision of the templates in. Renders that differ from the cached ones fail.").String()
+		prerenderInput                = app.Flag("prerender", "Render the child resources of the parent resources in the given YAML file into --render-cache-dir, print their digests and exit without reconciling").ExistingFile()
+		diffInput                     = app.Flag("diff", "Print the changes that reconciling the parent resources in the given YAML file would make to their child resources in the cluster, with the fields that would change, then exit without reconciling").ExistingFile()
+		testInput                     = app.Flag("test", "Run the tests in the tests directory of --resources-dir, i.e. render their parent resources and check the expected child resources, print the results and exit with failure if any test fails").Bool()
+		testInClusterInput            = app.Flag("test-in-cluster", "Run the tests of --test by creating their parent resources in the cluster and checking the child resources that the installed controller applies, instead of rendering them").Bool()
+		testTimeoutInput              = app.Flag("test-timeout", "How long a test of --test-in-cluster waits for its parent resource to be synced, or ready if the test requires it").Default("5m").Duration()
+		selfTestJobInput              = app.Flag("self-test-job", "Print a Job that runs the tests of the pack in the cluster with the given image of the controller and exit").String()
+		selfTestServiceAccountInput   = app.Flag("self-test-service-account", "ServiceAccount for the Job of --self-test-job to run the tests in the name of").String()
+		resourceClassesInput          = app.Flag("resource-classes", "Expose the resource class that parent resources refer to in spec.classRef to the templates as parameters.class and merge its specTemplate into the spec of child resources as defaults").Bool()
+		classDefaultsKindsInput       = app.Flag("class-defaults-kind", "Kind of child resources, given as Kind.group, to merge the resource class defaults into. All kinds get the defaults if none is given.").Strings()
+		lookupsInput                  = app.Flag("lookup", "Object of the cluster to expose to the templates and patchers under spec.parameters.lookup, given as key=Kind.group:namespace/name or key=Kind.group:name for cluster-scoped objects, e.g. dns=ConfigMap:kube-system/cluster-dns. Secrets cannot be looked up.").StringMap()
+		namespaceOverlaysInput        = app.Flag("namespace-overlay-configmap", "Name of the ConfigMap in the namespace of parent resources whose entries are overlays, given as YAML with the apiVersion, kind and optional name of the child resources they apply to, to be merged into the rendered child resources").String()
+		maintenanceWindowsInput       = app.Flag("maintenance-window", "Window during which the existing child resources of all parent resources are not changed, given as days and times of the day, e.g. \"Mon-Fri 18:00-08:00 Europe/Berlin\"").Strings()
+		maintenanceCreateInput        = app.Flag("maintenance-allow-create", "Create the child resources that do not exist yet during maintenance windows").Bool()
+		maintenanceDeleteInput        = app.Flag("maintenance-allow-delete", "Delete the child resources of deleted parent resources during maintenance windows").Bool()
+		namespacePerParentInput       = app.Flag("namespace-per-parent", "Render a namespace of its own for every parent resource, named after the parent resource, and move the child resources that are rendered without a namespace or in the one of the parent resource into it").Bool()
+		namespacePrefixInput          = app.Flag("namespace-per-parent-prefix", "Prefix of the names of the namespaces of --namespace-per-parent").String()
+		namespaceTemplateInput        = app.Flag("namespace-per-parent-template", "YAML file with the objects, e.g. ResourceQuotas and NetworkPolicies, to render into every namespace of --namespace-per-parent").ExistingFile()
+		createNamespacesInput         = app.Flag("create-namespaces", "Create the namespaces that the child resources target if they do not exist before applying the child resources").Bool()
+		admissionSimulationInput      = app.Flag("admission-simulation", "Check before applying that the namespaces of the child resources exist, their labels and label selectors are valid and they have the --required-label labels, failing the apply of all child resources otherwise").Bool()
+		derivedLabelsInput            = app.Flag("derived-label", "Label to be set on all child resources to the value that a Go template renders from the parent resource, given as key=template, e.g. team={{ .spec.owner }}").StringMap()
+		labelSchemaInput              = app.Flag("label-schema", "YAML file with the labels that all child resources must have, given as a list of key, optional default that a Go template renders from the parent resource for the child resources that omit the label, and optional pattern that the value must match. Keys ending with /* require at least one label with the prefix.").ExistingFile()
+		derivedAnnotationsInput       = app.Flag("derived-annotation", "Annotation to be set on all child resources to the value that a Go template renders from the parent resource, given as key=template").StringMap()
+		quotaCheckInput               = app.Flag("quota-check", "Check that the pods of the new workloads would not exceed the ResourceQuotas of their namespaces before applying the child resources").Bool()
+		requiredLabelsInput           = app.Flag("required-label", "Label that all child resources need to have for --admission-simulation, given as key=pattern where pattern is a regular expression that the whole value has to match. An empty pattern only requires the label.").StringMap()
+		patchStrategiesInput          = app.Flag("patch-strategy", "Strategy to update the existing child resources of a kind with, given as Kind.group=strategy where strategy is one of merge, strategic, json, apply or replace").StringMap()
+		patchStrategyOverridesInput   = app.Flag("patch-strategy-overrides", "Let parent resources choose the strategy of all their child resources, and templates the one of a child resource, with the templatestacks.crossplane.io/patch-strategy annotation, e.g. to migrate a fleet to server-side apply one parent resource at a time").Bool()
+		gitopsRepoInput               = app.Flag("gitops-repository", "URL of the git repository to publish the manifests of child resources to instead of applying them").String()
+		gitopsBranchInput             = app.Flag("gitops-branch", "Branch of the GitOps repository to publish the manifests to, or to base the branches of parent resources on").Default("main").String()
+		gitopsPathInput               = app.Flag("gitops-path", "Directory in the GitOps repository to write the manifests under").Default(".").String()
+		gitopsDirInput                = app.Flag("gitops-dir", "Directory to keep the working copy of the GitOps repository in").Default(filepath.Join(os.TempDir(), "gitops")).String()
+		gitopsBranchPerParentInput    = app.Flag("gitops-branch-per-parent", "Push the manifests of every parent resource to a branch of its own instead of the GitOps branch").Bool()
+		gitopsGitHubRepoInput         = app.Flag("gitops-github-repository", "GitHub repository, given as owner/name, to open pull requests from the branches of parent resources in").String()
+		gitopsGitHubTokenInput        = app.Flag("gitops-github-token", "Token to open GitHub pull requests with").String()
+		gitopsGitHubAPIInput          = app.Flag("gitops-github-api", "URL of the GitHub API").Default("https://api.github.com").String()
+		renderServiceInput            = app.Flag("render-service-address", "Address to serve the render pipeline at over HTTP, e.g. :8090, so that other systems can render parent resources like the controller. Callers need a bearer token of a user that is allowed to get the parent resource, and the values that come from secrets are left out of the renders. Empty disables the service.").String()
+		sourceStatusInput             = app.Flag("source-status-configmap", "ConfigMap, given as namespace/name, to report the source and the revision of the templates in").String()
+		leaderElectionInput           = app.Flag("leader-election", "Elect a leader among the replicas of the controller to reconcile so that they can run for high availability").Bool()
+		leaderElectionNSInput         = app.Flag("leader-election-namespace", "Namespace to keep the leader election lock in. Defaults to the namespace the controller runs in.").String()
+		leaseDurationInput            = app.Flag("leader-election-lease-duration", "How long the other replicas wait before taking the lead over from a leader that stopped renewing").Duration()
+		renewDeadlineInput            = app.Flag("leader-election-renew-deadline", "How long the leader keeps trying to renew its lead before giving it up").Duration()
+		retryPeriodInput              = app.Flag("leader-election-retry-period", "How often the replicas try to acquire or renew the lead").Duration()
+		shardCountInput               = app.Flag("shard-count", "Number of shards to split the parent resources across, each reconciled by its own replicas. One disables sharding.").Default("1").Int()
+		shardIndexInput               = app.Flag("shard-index", "Index of the shard that this replica reconciles. Defaults to the ordinal at the end of the hostname, as StatefulSet pods have.").Default("-1").Int()
+		libraryDirsInput              = app.Flag("library-dir", "Directory of partials to be made available to all Helm templates, in addition to the lib and partials directories of the resources directory").ExistingDirs()
+		strictOwnershipInput          = app.Flag("strict-ownership", "Update only the existing child resources that their parent resource controls, or tracks by its UID if they have no controller, and report the others with an OwnershipConflict condition instead of taking them over").Bool()
+		reportOnlyInput               = app.Flag("report-only", "Only report what would be changed in the child resources without writing them").Bool()
+		controllerConfigInput         = app.Flag("controller-config", "Name of the cluster-scoped ControllerConfig whose waits, pruning, apply mode and concurrency override the ones given by the flags at runtime").String()
+		controllerConfigRefreshInput  = app.Flag("controller-config-refresh-interval", "How often to read the ControllerConfig").Default("30s").Duration()
+		renderWorkersInput            = app.Flag("render-workers", "Number of workers that render the child resources of all parent resources, sharing the caches of the engine. Zero renders in the workers of the controller.").Default("0").Int()
+		verifyRenderInput             = app.Flag("verify-render", "Render every parent resource twice and fail its reconcile if the renders differ, e.g. because the templates use timestamps or random values").Bool()
+		revisionNamespaceInput        = app.Flag("revision-namespace", "Namespace to store the applied revisions of cluster-scoped parent resources in. Rollbacks are enabled only if this is given.").String()
+		remoteClustersInput           = app.Flag("remote-cluster", "Kubeconfig file of a remote cluster that child resources can target, given as name=path").StringMap()
+		orphanSweepIntervalInput      = app.Flag("orphan-sweep-interval", "How often to look for child resources whose parent resource no longer exists, e.g. after a forced deletion, and delete them. Zero disables the sweep.").Default("0").Duration()
+		orphanSweepKindsInput         = app.Flag("orphan-sweep-kind", "Kind of child resources, given as Kind.group e.g. Deployment.apps, to look for orphans among").Strings()
+		orphanSweepReportOnlyInput    = app.Flag("orphan-sweep-report-only", "Only log the orphaned child resources instead of deleting them").Bool()
+		impersonationInput            = app.Flag("impersonation", "Apply and delete the child resources in the name of the ServiceAccount that the parent resource names in its templatestacks.crossplane.io/service-account annotation, given as name in its namespace or as namespace/name").Bool()
+		impersonationDefaultInput     = app.Flag("impersonation-default-service-account", "ServiceAccount in the namespace of the parent resource to impersonate if the parent resource does not name one. Empty requires every parent resource to name one.").String()
+		preferredVersionsInput        = app.Flag("convert-to-preferred-versions", "Convert the child resources whose API version the cluster no longer serves to the preferred version of their API group before applying them").Bool()
+		clusterCapabilitiesInput      = app.Flag("cluster-capabilities", "Expose the version and the API versions of the cluster to the templates as parameters.capabilities").Bool()
+		convertFieldsInput            = app.Flag("convert-field", "Field to be moved before render for the parent resources whose spec was written in an older version, given as version:from.path=to.path, e.g. v1alpha1:spec.size=spec.parameters.size").StringMap()
+		generatedValuesInput          = app.Flag("generated-value", "Value that is generated once per parent resource and exposed to the templates as parameters.generated.<name>, given as name=type[:length] where type is one of password, token or uuid").StringMap()
+		externalSecretsInput          = app.Flag("external-secret", "Value that is read from an external secret store at render time and exposed to the templates as parameters.externalSecrets.<name>, given as name=store:path#key where store is one of vault or files, and path can contain the {namespace} and {name} of the parent resource").StringMap()
+		externalSecretTTLInput        = app.Flag("external-secret-ttl", "How long the secrets read from the external secret stores are cached").Default("5m").Duration()
+		vaultAddressInput             = app.Flag("vault-address", "Address of the Vault server that the vault secret store reads from").String()
+		vaultTokenFileInput           = app.Flag("vault-token-file", "File with the token that the vault secret store authenticates with").String()
+		secretFilesDirInput           = app.Flag("secret-files-dir", "Directory that the files secret store reads from, e.g. the mount path of the Secrets Store CSI driver").String()
 	)
 	kingpin.MustParse(app.Parse(os.Args[1:]))
+	if *resourcesBundleInput != "" {
+		dir, err := ioutil.TempDir("", "resources")
+		kingpin.FatalIfError(err, "cannot create directory for the resources bundle")
+		f, err := os.Open(*resourcesBundleInput)
+		kingpin.FatalIfError(err, "cannot open the resources bundle")
+		kingpin.FatalIfError(templating.ExtractBundle(f, dir), "cannot extract the resources bundle")
+		_ = f.Close()
+		*resourceDirInput = dir
+	}
+	if *resourceDirInput == "" {
+		kingpin.Fatalf("either --resources-dir or --resources-bundle is required")
+	}
+	if *bundleInput != "" {
+		f, err := os.Create(*bundleInput)
+		kingpin.FatalIfError(err, "cannot create the bundle")
+		vendorers := templating.VendorerChain{
+			kustomize.NewRemoteBaseVendorer(operations.ExecGitRunner),
+			helm3.NewDependencyVendorer(),
+		}
+		kingpin.FatalIfError(templating.WriteBundle(context.Background(), *resourceDirInput, f, vendorers), "cannot write the bundle")
+		kingpin.FatalIfError(f.Close(), "cannot write the bundle")
+		os.Exit(0)
+	}
+	var paramSchema *templating.ParameterSchema
+	schemaPath := *parameterSchemaInput
+	if schemaPath == "" {
+		if _, err := os.Stat(filepath.Join(*resourceDirInput, templating.ParameterSchemaFile)); err == nil {
+			schemaPath = filepath.Join(*resourceDirInput, templating.ParameterSchemaFile)
+		}
+	}
+	if schemaPath != "" {
+		var err error
+		paramSchema, err = templating.LoadParameterSchema(schemaPath)
+		kingpin.FatalIfError(err, "cannot load parameters schema")
+	}
+	if *describeParametersInput {
+		if paramSchema == nil {
+			kingpin.Fatalf("the pack does not have a parameters schema")
+		}
+		fmt.Println(paramSchema.Help())
+		os.Exit(0)
+	}
+	var statusFields *templating.StatusFields
+	statusFieldsPath := *statusFieldsInput
+	if statusFieldsPath == "" {
+		if _, err := os.Stat(filepath.Join(*resourceDirInput, templating.StatusFieldsFile)); err == nil {
+			statusFieldsPath = filepath.Join(*resourceDirInput, templating.StatusFieldsFile)
+		}
+	}
+	if statusFieldsPath != "" {
+		var err error
+		statusFields, err = templating.LoadStatusFields(statusFieldsPath)
+		kingpin.FatalIfError(err, "cannot load status fields")
+	}
+	if *crdValidationInput {
+		b, err := yaml.Marshal(templating.CRDValidation(paramSchema, statusFields))
+		kingpin.FatalIfError(err, "cannot marshal the validation")
+		fmt.Print(string(b))
+		os.Exit(0)
+	}
+	if *selfTestJobInput != "" {
+		args := []string{"--stack-definition-name", *stackDefinitionNameInput, "--resources-dir", *resourceDirInput}
+		if *stackDefinitionNamespaceInput != "" {
+			args = append(args, "--stack-definition-namespace", *stackDefinitionNamespaceInput)
+		}
+		job := templating.SelfTestJob(*stackDefinitionNameInput+"-self-test", *stackDefinitionNamespaceInput, *selfTestJobInput, *selfTestServiceAccountInput, args)
+		b, err := yaml.Marshal(job)
+		kingpin.FatalIfError(err, "cannot marshal the self-test job")
+		fmt.Print(string(b))
+		os.Exit(0)
+	}
 	sd := &v1alpha1.StackDefinition{
 		ObjectMeta: v1.ObjectMeta{
 			Name:      *stackDefinitionNameInput,
@@ -73,6 +288,40 @@ func main() {
 	}
 	kingpin.FatalIfError(getStackDefinition(sd), "could not fetch the StackDefinition object")
 	gvk := schema.FromAPIVersionAndKind(sd.Spec.Behavior.CRD.APIVersion, sd.Spec.Behavior.CRD.Kind)
+	if *rbacInput {
+		scope, err := templating.ScanCacheScope(*resourceDirInput)
+		kingpin.FatalIfError(err, "cannot scan the templates")
+		kinds := scope.Kinds
+		for _, k := range *rbacKindsInput {
+			kind, _ := schema.ParseKindArg(k)
+			if kind == nil {
+				kingpin.Fatalf("cannot parse kind %s, which must be given as Kind.version.group", k)
+			}
+			kinds = append(kinds, *kind)
+		}
+		labels := map[string]string{}
+		for _, l := range *rbacAggregateInput {
+			kv := strings.SplitN(l, "=", 2)
+			if len(kv) != 2 {
+				kingpin.Fatalf("cannot parse aggregation label %s, which must be given as key=value", l)
+			}
+			labels[kv[0]] = kv[1]
+		}
+		mapper, err := apiutil.NewDiscoveryRESTMapper(ctrl.GetConfigOrDie())
+		kingpin.FatalIfError(err, "cannot discover the API resources of the cluster")
+		objs, err := templating.GenerateRBAC(templating.RBACOptions{
+			Name:              strings.ToLower(gvk.Kind + "." + gvk.Group),
+			Namespaces:        *rbacNamespacesInput,
+			AggregationLabels: labels,
+		}, mapper, gvk, kinds...)
+		kingpin.FatalIfError(err, "cannot generate RBAC")
+		for _, o := range objs {
+			b, err := yaml.Marshal(o)
+			kingpin.FatalIfError(err, "cannot marshal RBAC")
+			fmt.Printf("---\n%s", b)
+		}
+		os.Exit(0)
+	}
 
 	kingpin.FatalIfError(clientgoscheme.AddToScheme(scheme), "could not register client-go scheme")
 	kingpin.FatalIfError(packages.AddToScheme(scheme), "could not register stacks group scheme")
@@ -90,6 +339,46 @@ func main() {
 		}
 	}
 
+	if *scopedCacheInput {
+		scope, err := templating.ScanCacheScope(*resourceDirInput)
+		kingpin.FatalIfError(err, "cannot scope the cache")
+		scope.Kinds = append(scope.Kinds, gvk)
+		// The namespaces of the child resources are only cached along with
+		// the namespaces of the parent resources, which we cannot tell from
+		// the templates.
+		scope.Namespaces = append(scope.Namespaces, *cacheNamespacesInput...)
+		if len(*cacheNamespacesInput) == 0 {
+			scope.Namespaces = nil
+		}
+		templating.SetupScopedCache(&mgrOptions, scope)
+	}
+
+	if *leaderElectionInput {
+		kingpin.FatalIfError(templating.SetupHighAvailability(&mgrOptions, gvk, templating.HighAvailability{
+			Namespace:     *leaderElectionNSInput,
+			LeaseDuration: *leaseDurationInput,
+			RenewDeadline: *renewDeadlineInput,
+			RetryPeriod:   *retryPeriodInput,
+		}), "cannot set up leader election")
+	}
+	var shard *templating.Shard
+	if *shardCountInput > 1 {
+		index := *shardIndexInput
+		if index < 0 {
+			hostname, err := os.Hostname()
+			kingpin.FatalIfError(err, "cannot get the hostname")
+			index, err = templating.ShardIndexFromHostname(hostname)
+			kingpin.FatalIfError(err, "cannot tell the shard index")
+		}
+		var err error
+		shard, err = templating.NewShard(index, *shardCountInput)
+		kingpin.FatalIfError(err, "cannot set up sharding")
+		if mgrOptions.LeaderElection {
+			// The replicas of a shard elect a leader among themselves only.
+			mgrOptions.LeaderElectionID = fmt.Sprintf("%s-shard-%d", mgrOptions.LeaderElectionID, index)
+		}
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), mgrOptions)
 	kingpin.FatalIfError(err, "unable to start manager")
 
@@ -104,39 +393,603 @@ func main() {
 
 	options := []templating.ReconcilerOption{
 		templating.WithLogger(crLogger),
+		templating.WithSyncInterval(*syncIntervalInput),
+		templating.WithApplyBudget(*applyBudgetInput),
+		templating.WithReadinessTimeout(*readinessTimeoutInput),
+	}
+	var recorder event.Recorder = event.NewAPIRecorder(mgr.GetEventRecorderFor(gvk.GroupKind().String()))
+	if *eventThrottleWindowInput > 0 {
+		recorder = templating.NewThrottledRecorder(recorder, *eventThrottleWindowInput)
+	}
+	options = append(options, templating.WithRecorder(recorder))
+	options = append(options, templating.WithStatusSubresourceDetection(templating.NewStatusSubresourceDetector(mgr.GetAPIReader(), mgr.GetRESTMapper(), gvk)))
+	options = append(options, templating.WithStatusCoalescing())
+	var metricsOptions []templating.RenderMetricsOption
+	if *metricsParentLabelsInput {
+		metricsOptions = append(metricsOptions, templating.WithParentLabels(*metricsParentLimitInput))
+	}
+	renderMetrics := templating.NewRenderMetrics(gvk, metricsOptions...)
+	metrics.Registry.MustRegister(renderMetrics)
+	options = append(options, templating.WithRenderMetrics(renderMetrics))
+	controllerStatus := templating.NewControllerStatus(gvk, *scheduleSlackInput)
+	metrics.Registry.MustRegister(controllerStatus)
+	options = append(options, templating.WithControllerStatus(controllerStatus))
+	if shard != nil {
+		options = append(options, templating.WithShard(shard))
+	}
+	if *revisionNamespaceInput != "" {
+		options = append(options, templating.WithRevisionStore(templating.NewAPIConfigMapRevisionStore(mgr.GetClient(), *revisionNamespaceInput)))
+	}
+	switch {
+	case *pausedInput:
+		options = append(options, templating.WithPauseSwitch(templating.NewStaticPauseSwitch(true)))
+	case *pauseConfigMapInput != "":
+		ns, name, err := cache.SplitMetaNamespaceKey(*pauseConfigMapInput)
+		kingpin.FatalIfError(err, "cannot parse pause configmap")
+		if ns == "" {
+			ns = sd.GetNamespace()
+		}
+		options = append(options, templating.WithPauseSwitch(templating.NewConfigMapPauseSwitch(mgr.GetClient(), types.NamespacedName{Name: name, Namespace: ns})))
+	}
+	var notifier templating.Notifier
+	if *notificationWebhookInput != "" {
+		notifier = templating.NewWebhookNotifier(*notificationWebhookInput, nil)
+		options = append(options, templating.WithNotifier(notifier))
+	}
+	var snapshots templating.SnapshotStore
+	switch {
+	case *snapshotNamespaceInput != "" && *snapshotURLInput != "":
+		kingpin.Fatalf("--snapshot-namespace and --snapshot-url cannot be used together")
+	case *snapshotNamespaceInput != "":
+		snapshots = templating.NewConfigMapSnapshotStore(mgr.GetClient(), *snapshotNamespaceInput, *snapshotLimitInput)
+	case *snapshotURLInput != "":
+		snapshots = templating.NewHTTPSnapshotStore(*snapshotURLInput, nil)
+	}
+	if snapshots != nil {
+		options = append(options, templating.WithSnapshotStore(snapshots))
+	}
+	if *generatedHistoryInput >= 0 {
+		kube := mgr.GetClient()
+		if snapshots != nil {
+			kube = templating.NewSnapshotClient(kube, snapshots)
+		}
+		collector := templating.NewGeneratedObjectCollector(kube, *generatedHistoryInput)
+		if notifier != nil {
+			collector.SetNotifier(notifier)
+		}
+		if *pruneConfirmationInput {
+			collector.RequireConfirmation(*pruneGracePeriodInput)
+		}
+		collector.SetConditionHistory(*conditionHistoryInput)
+		options = append(options, templating.WithPostApplyHook(collector))
+	}
+	var audit templating.AuditSinkChain
+	if *auditConfigMapInput != "" {
+		ns, name, err := cache.SplitMetaNamespaceKey(*auditConfigMapInput)
+		kingpin.FatalIfError(err, "cannot parse audit configmap")
+		if ns == "" {
+			ns = sd.GetNamespace()
+		}
+		audit = append(audit, templating.NewConfigMapAuditSink(mgr.GetClient(), types.NamespacedName{Name: name, Namespace: ns}, *auditRecordLimitInput))
+	}
+	if *auditWebhookInput != "" {
+		audit = append(audit, templating.NewWebhookAuditSink(*auditWebhookInput, nil))
+	}
+	if len(audit) != 0 {
+		options = append(options, templating.WithAuditSink(audit))
+	}
+	if *prerenderInput != "" && *renderCacheDirInput == "" {
+		kingpin.Fatalf("--prerender needs --render-cache-dir to render into")
+	}
+	var revision string
+	if *rolloutBatchInput > 0 || *revisionPinningInput || *renderCacheDirInput != "" || *rerenderBatchInput > 0 || *resultAnnotationsInput || *renderInputsInput != "" {
+		rev, err := templating.HashDirectory(*resourceDirInput)
+		kingpin.FatalIfError(err, "cannot compute the revision of the templates")
+		revision = rev
+		options = append(options, templating.WithSourceDigest(rev))
+		if *renderCacheDirInput != "" {
+			options = append(options, templating.WithRenderCache(templating.NewDirectoryRenderCache(*renderCacheDirInput), rev))
+		}
+		if *rolloutBatchInput > 0 || *rerenderBatchInput > 0 {
+			// A batch size of zero only records the revision that the
+			// child resources are rendered with, which the batched
+			// re-renders need to tell the outdated parent resources.
+			options = append(options, templating.WithProgressiveRollout(rev, *rolloutBatchInput))
+		}
+		if *revisionPinningInput {
+			options = append(options, templating.WithRevisionPinning(rev))
+		}
+		if *resultAnnotationsInput {
+			options = append(options, templating.WithResultAnnotations(rev))
+		}
+	}
+	if *fairnessLimitInput > 0 {
+		key := templating.NamespaceFairnessKey
+		if *fairnessLabelInput != "" {
+			key = templating.LabelFairnessKey(*fairnessLabelInput)
+		}
+		options = append(options, templating.WithFairness(key, *fairnessLimitInput))
+	}
+	if len(*scaleDownKindsInput) != 0 {
+		kinds := make([]schema.GroupKind, len(*scaleDownKindsInput))
+		for i, k := range *scaleDownKindsInput {
+			kinds[i] = schema.ParseGroupKind(k)
+		}
+		options = append(options, templating.WithPreDeletionStep(templating.NewKindDeleter(templating.NewScaleToZeroDeleter(mgr.GetClient()), kinds...)))
+	}
+	if *conditionHistoryInput > 0 {
+		options = append(options, templating.WithConditionHistory(*conditionHistoryInput))
+	}
+	if *uidTokenInput {
+		options = append(options, templating.WithUIDToken())
+	}
+	if *fieldManagerInput != templating.FieldOwner || !*forceConflictsInput {
+		options = append(options, templating.WithFieldManager(*fieldManagerInput, *forceConflictsInput))
+	}
+	if *strictOwnershipInput {
+		options = append(options, templating.WithStrictOwnership())
+	}
+	if paramSchema != nil {
+		options = append(options, templating.WithPreRenderHook(paramSchema))
+	}
+	if statusFields != nil {
+		options = append(options, templating.WithPostApplyHook(statusFields))
+	}
+	if *resourceClassesInput {
+		kinds := make([]schema.GroupKind, len(*classDefaultsKindsInput))
+		for i, k := range *classDefaultsKindsInput {
+			kinds[i] = schema.ParseGroupKind(k)
+		}
+		options = append(options, templating.WithResourceClasses(kinds...))
+	}
+	if len(*lookupsInput) > 0 {
+		keys := make([]string, 0, len(*lookupsInput))
+		for key := range *lookupsInput {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		lookups := make([]templating.Lookup, len(keys))
+		for i, key := range keys {
+			l, err := templating.ParseLookup(key, (*lookupsInput)[key])
+			kingpin.FatalIfError(err, "cannot parse lookup %s", key)
+			m, err := mgr.GetRESTMapper().RESTMapping(l.GroupVersionKind.GroupKind())
+			kingpin.FatalIfError(err, "cannot find the kind of lookup %s", key)
+			l.GroupVersionKind = m.GroupVersionKind
+			lookups[i] = l
+		}
+		options = append(options, templating.WithLookups(lookups...))
+	}
+	if *namespaceOverlaysInput != "" {
+		options = append(options, templating.WithNamespaceOverlays(*namespaceOverlaysInput))
+	}
+	windows := make([]templating.MaintenanceWindow, len(*maintenanceWindowsInput))
+	for i, w := range *maintenanceWindowsInput {
+		var err error
+		windows[i], err = templating.ParseMaintenanceWindow(w)
+		kingpin.FatalIfError(err, "cannot parse maintenance window %s", w)
+	}
+	options = append(options, templating.WithMaintenanceWindows(templating.MaintenancePolicy{
+		AllowCreate: *maintenanceCreateInput,
+		AllowDelete: *maintenanceDeleteInput,
+	}, windows...))
+	if *createNamespacesInput {
+		options = append(options, templating.WithNamespaceCreation())
+	}
+	if *admissionSimulationInput {
+		keys := make([]string, 0, len(*requiredLabelsInput))
+		for key := range *requiredLabelsInput {
+			keys = append(keys, key)
+		}
+		// The rules are sorted so that the problems are reported in the same
+		// order in every reconcile.
+		sort.Strings(keys)
+		rules := make([]templating.LabelRule, len(keys))
+		for i, key := range keys {
+			rule, err := templating.NewLabelRule(key, (*requiredLabelsInput)[key])
+			kingpin.FatalIfError(err, "cannot parse required label %s", key)
+			rules[i] = rule
+		}
+		options = append(options, templating.WithAdmissionSimulation(rules...))
+	}
+	if *quotaCheckInput {
+		options = append(options, templating.WithQuotaCheck())
+	}
+	options = append(options, templating.WithMissingAPICheck(mgr.GetRESTMapper()))
+	options = append(options, templating.WithOptionalChildResources(mgr.GetRESTMapper()))
+	for kind, name := range *patchStrategiesInput {
+		s, err := templating.ParsePatchStrategy(name)
+		kingpin.FatalIfError(err, "cannot parse patch strategy of %s", kind)
+		options = append(options, templating.WithPatchStrategy(schema.ParseGroupKind(kind), s))
 	}
+	if *patchStrategyOverridesInput {
+		options = append(options, templating.WithPatchStrategyOverrides())
+	}
+	if *preferredVersionsInput {
+		options = append(options, templating.WithAdditionalChildResourcePatcher(templating.NewPreferredVersionConverter(mgr.GetRESTMapper())))
+	}
+	if len(*derivedLabelsInput) > 0 || len(*derivedAnnotationsInput) > 0 {
+		p, err := templating.NewDerivedMetadataPatcher(*derivedLabelsInput, *derivedAnnotationsInput)
+		kingpin.FatalIfError(err, "cannot parse derived labels and annotations")
+		options = append(options, templating.WithAdditionalChildResourcePatcher(p))
+	}
+	if *labelSchemaInput != "" {
+		ls, err := templating.LoadLabelSchema(*labelSchemaInput)
+		kingpin.FatalIfError(err, "cannot load label schema")
+		options = append(options, templating.WithAdditionalChildResourcePatcher(ls))
+	}
+	if len(*compositeKindsInput) > 0 {
+		kinds := resource.CompositeKinds{}
+		for kind, field := range *compositeKindsInput {
+			kinds[schema.ParseGroupKind(kind)] = field
+		}
+		options = append(options, templating.WithCompositeKinds(kinds))
+	}
+	if *strictDecodingInput {
+		options = append(options, templating.WithAdditionalChildResourcePatcher(templating.NewStrictSchemaValidator(scheme)))
+	}
+	if *gitopsRepoInput != "" {
+		var gitOpts []templating.GitPublisherOption
+		if *gitopsBranchPerParentInput {
+			var prs templating.PullRequestOpener
+			if *gitopsGitHubRepoInput != "" {
+				prs = templating.NewGitHubPullRequestOpener(*gitopsGitHubAPIInput, *gitopsGitHubRepoInput, *gitopsGitHubTokenInput, nil)
+			}
+			gitOpts = append(gitOpts, templating.WithBranchPerParent(prs))
+		}
+		options = append(options, templating.WithManifestPublisher(templating.NewGitPublisher(*gitopsRepoInput, *gitopsDirInput, *gitopsBranchInput, *gitopsPathInput, gitOpts...)))
+	}
+	if *reportOnlyInput {
+		options = append(options, templating.WithReportOnly())
+	}
+	if *verifyRenderInput {
+		options = append(options, templating.WithRenderVerification())
+	}
+	if *controllerConfigInput != "" {
+		store := templating.NewControllerConfigStore(mgr.GetClient(), *controllerConfigInput)
+		store.SetLogger(crLogger)
+		kingpin.FatalIfError(mgr.Add(manager.RunnableFunc(templating.NewControllerConfigRunnable(store, *controllerConfigRefreshInput))), "could not add controller config refresh")
+		options = append(options, templating.WithControllerConfig(store))
+	}
+	remotes := map[string]client.Client{}
+	for name, path := range *remoteClustersInput {
+		kube, err := newRemoteClient(path)
+		kingpin.FatalIfError(err, "cannot create client for remote cluster %s", name)
+		options = append(options, templating.WithRemoteCluster(name, kube))
+		remotes[name] = kube
+	}
+	if *impersonationInput {
+		options = append(options, templating.WithImpersonation(*impersonationDefaultInput, newImpersonatingClient(mgr.GetConfig())))
+	}
+	if *clusterCapabilitiesInput {
+		d, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+		kingpin.FatalIfError(err, "cannot create discovery client")
+		options = append(options, templating.WithValuesProvider(templating.NewDiscoveryCapabilities(d, 0)))
+	}
+	moves := map[string]map[string]string{}
+	for key, to := range *convertFieldsInput {
+		parts := strings.SplitN(key, ":", 2)
+		if len(parts) != 2 {
+			kingpin.FatalUsage("the field conversion %s is not in version:from.path=to.path form", key)
+		}
+		if moves[parts[0]] == nil {
+			moves[parts[0]] = map[string]string{}
+		}
+		moves[parts[0]][parts[1]] = to
+	}
+	for version, m := range moves {
+		options = append(options, templating.WithConverter(version, templating.NewFieldMoveConverter(m)))
+	}
+	if len(*generatedValuesInput) != 0 {
+		gvs := make([]templating.GeneratedValue, 0, len(*generatedValuesInput))
+		for name, spec := range *generatedValuesInput {
+			gv, err := templating.ParseGeneratedValue(name, spec)
+			kingpin.FatalIfError(err, "cannot parse generated value %s", name)
+			gvs = append(gvs, gv)
+		}
+		options = append(options, templating.WithValuesProvider(templating.NewAPISecretValueGenerator(mgr.GetClient(), sd.GetNamespace(), gvs...)))
+	}
+	if len(*externalSecretsInput) != 0 {
+		stores := map[string]templating.SecretStore{}
+		if *vaultAddressInput != "" {
+			stores["vault"] = templating.NewVaultSecretStore(*vaultAddressInput, *vaultTokenFileInput, nil)
+		}
+		if *secretFilesDirInput != "" {
+			stores["files"] = templating.NewFileSecretStore(*secretFilesDirInput)
+		}
+		ess := make([]templating.ExternalSecret, 0, len(*externalSecretsInput))
+		for name, spec := range *externalSecretsInput {
+			es, err := templating.ParseExternalSecret(name, spec)
+			kingpin.FatalIfError(err, "cannot parse external secret %s", name)
+			if _, ok := stores[es.Store]; !ok {
+				kingpin.Fatalf("external secret %s reads from the %s secret store, which is not configured", name, es.Store)
+			}
+			ess = append(ess, es)
+		}
+		options = append(options, templating.WithExternalSecrets(templating.NewExternalSecretValues(stores, *externalSecretTTLInput, ess...)))
+	}
+	var engine templating.Engine
+	var mappings []kustomize.FieldMapping
 	switch sd.Spec.Behavior.Engine.Type {
 	case KustomizeEngine:
-		kustOpts := []kustomize.Option{kustomize.WithResourcePath(*resourceDirInput)}
+		kustOpts := []kustomize.Option{
+			kustomize.WithResourcePath(*resourceDirInput),
+			kustomize.WithTransformers(kustomize.TransformerConfig{
+				NameSuffix:        *kustomizeNameSuffixInput,
+				Namespace:         *kustomizeNamespaceInput,
+				CommonLabels:      *kustomizeLabelsInput,
+				CommonAnnotations: *kustomizeAnnotationsInput,
+			}),
+		}
 		kustomization := &kustomizeapi.Kustomization{}
 		if sd.Spec.Behavior.Engine.Kustomize != nil {
-			kustOpts = append(kustOpts, kustomize.WithOverlayGenerator(kustomize.NewPatchOverlayGenerator(sd.Spec.Behavior.Engine.Kustomize.Overlays)))
+			overlays := kustomize.NewPatchOverlayGenerator(sd.Spec.Behavior.Engine.Kustomize.Overlays)
+			kustOpts = append(kustOpts, kustomize.WithOverlayGenerator(overlays))
+			mappings = append(mappings, overlays.Mappings()...)
 			if sd.Spec.Behavior.Engine.Kustomize.Kustomization != nil {
 				kingpin.FatalIfError(runtime.DefaultUnstructuredConverter.FromUnstructured(sd.Spec.Behavior.Engine.Kustomize.Kustomization.UnstructuredContent(), kustomization), "cannot unmarshal into kustomization object")
 			}
 		}
-		options = append(options,
-			templating.WithEngine(kustomize.NewKustomizeEngine(kustomization, kustOpts...)))
+		if *kustomizeJSON6902Input != "" {
+			data, err := ioutil.ReadFile(*kustomizeJSON6902Input)
+			kingpin.FatalIfError(err, "cannot read json6902 patches")
+			var patches []kustomize.JSON6902Patch
+			kingpin.FatalIfError(yaml.UnmarshalStrict(data, &patches), "cannot unmarshal json6902 patches")
+			json6902 := kustomize.NewJSON6902PatchGenerator(patches)
+			kustOpts = append(kustOpts, kustomize.AdditionalOverlayGenerator(json6902))
+			mappings = append(mappings, json6902.Mappings()...)
+		}
+		if *uidTokenInput {
+			kustOpts = append(kustOpts, kustomize.AdditionalPatcher(kustomize.NewUIDTokenVarPatcher()))
+		}
+		for _, t := range *kustomizeSkipTransformsInput {
+			e, err := kustomize.ParseTransformExclusion(t)
+			kingpin.FatalIfError(err, "cannot parse transforms to skip")
+			kustOpts = append(kustOpts, kustomize.WithTransformExclusions(e))
+		}
+		engine = kustomize.NewKustomizeEngine(kustomization, kustOpts...)
 	case Helm3Engine:
-		options = append(options,
-			templating.WithEngine(helm3.NewHelm3Engine(
-				helm3.WithResourcePath(*resourceDirInput),
-				helm3.WithLogger(crLogger)),
-			),
-		)
+		helmOpts := []helm3.Option{
+			helm3.WithResourcePath(*resourceDirInput),
+			helm3.WithLogger(crLogger),
+		}
+		if *strictDecodingInput {
+			helmOpts = append(helmOpts, helm3.WithStrictDecoding())
+		}
+		for _, dir := range *libraryDirsInput {
+			helmOpts = append(helmOpts, helm3.WithLibraryPath(dir))
+		}
+		engine = helm3.NewHelm3Engine(helmOpts...)
+		if *explainMappingsInput {
+			kingpin.Fatalf("the %s engine exposes the whole spec to the templates as values, so the mappings are only in the templates", Helm3Engine)
+		}
 	default:
 		kingpin.FatalUsage("the engine type %s is not supported", sd.Spec.Behavior.Engine.Type)
 	}
-	controller := templating.NewReconciler(mgr, gvk, options...)
+	if *explainMappingsInput {
+		var fields []string
+		if paramSchema != nil {
+			fields = paramSchema.Fields()
+		}
+		fmt.Print(kustomize.DescribeMappings(fields, mappings))
+		os.Exit(0)
+	}
+	if *namespacePerParentInput {
+		var template []resource.ChildResource
+		if *namespaceTemplateInput != "" {
+			b, err := ioutil.ReadFile(*namespaceTemplateInput)
+			kingpin.FatalIfError(err, "cannot read the namespace template")
+			template, err = resource.Decode(b)
+			kingpin.FatalIfError(err, "cannot parse the namespace template")
+		}
+		engine = templating.NewNamespaceIsolator(engine, mgr.GetRESTMapper(), *namespacePrefixInput, template...)
+	}
+	if *renderWorkersInput > 0 {
+		engine = operations.NewWorkerPool(engine, *renderWorkersInput)
+	}
+	options = append(options, templating.WithEngine(engine))
+	var drainer *templating.Drainer
+	if *drainTimeoutInput > 0 {
+		drainer = templating.NewDrainer()
+		kingpin.FatalIfError(mgr.Add(manager.RunnableFunc(templating.NewDrainerRunnable(drainer))), "could not add drainer")
+		options = append(options, templating.WithDrainer(drainer))
+	}
+	reconciler := templating.NewReconciler(mgr, gvk, options...)
+	if *prerenderInput != "" {
+		b, err := ioutil.ReadFile(*prerenderInput)
+		kingpin.FatalIfError(err, "cannot read the parent resources to pre-render")
+		parents, err := resource.Decode(b)
+		kingpin.FatalIfError(err, "cannot parse the parent resources to pre-render")
+		// Like the explanation, the pre-render needs the clients of the
+		// started manager for the ValuesProviders.
+		kingpin.FatalIfError(mgr.Add(manager.RunnableFunc(func(_ <-chan struct{}) error {
+			for _, p := range parents {
+				list, err := reconciler.Render(context.Background(), p.(*unstructured.Unstructured))
+				kingpin.FatalIfError(err, "cannot pre-render %s", p.GetName())
+				digest, err := templating.HashChildren(list)
+				kingpin.FatalIfError(err, "cannot compute the digest of %s", p.GetName())
+				fmt.Printf("%s/%s: %s\n", p.GetNamespace(), p.GetName(), digest)
+			}
+			os.Exit(0)
+			return nil
+		})), "could not add pre-render")
+		kingpin.FatalIfError(mgr.Start(ctrl.SetupSignalHandler()), "unable to run the manager")
+		return
+	}
+	if *testInput {
+		tests, err := templating.LoadPackTests(filepath.Join(*resourceDirInput, templating.PackTestsDir))
+		kingpin.FatalIfError(err, "cannot load the tests of the pack")
+		kingpin.FatalIfError(mgr.Add(manager.RunnableFunc(func(_ <-chan struct{}) error {
+			var results templating.PackTestResults
+			if *testInClusterInput {
+				results = templating.RunPackTestsInCluster(context.Background(), mgr.GetClient(), tests, *testTimeoutInput)
+			} else {
+				results = reconciler.RunPackTests(context.Background(), tests)
+			}
+			fmt.Print(results)
+			if results.Failed() > 0 {
+				os.Exit(1)
+			}
+			os.Exit(0)
+			return nil
+		})), "could not add tests")
+		kingpin.FatalIfError(mgr.Start(ctrl.SetupSignalHandler()), "unable to run the manager")
+		return
+	}
+	if *diffInput != "" {
+		b, err := ioutil.ReadFile(*diffInput)
+		kingpin.FatalIfError(err, "cannot read the parent resources to diff")
+		parents, err := resource.Decode(b)
+		kingpin.FatalIfError(err, "cannot parse the parent resources to diff")
+		kingpin.FatalIfError(mgr.Add(manager.RunnableFunc(func(_ <-chan struct{}) error {
+			for _, p := range parents {
+				plan, err := reconciler.Plan(context.Background(), p.(*unstructured.Unstructured))
+				kingpin.FatalIfError(err, "cannot plan the changes of %s", p.GetName())
+				fmt.Printf("%s/%s: %s", p.GetNamespace(), p.GetName(), plan)
+			}
+			os.Exit(0)
+			return nil
+		})), "could not add diff")
+		kingpin.FatalIfError(mgr.Start(ctrl.SetupSignalHandler()), "unable to run the manager")
+		return
+	}
+	if *explainInput != "" {
+		b, err := ioutil.ReadFile(*explainInput)
+		kingpin.FatalIfError(err, "cannot read the parent resource to explain")
+		parent := &unstructured.Unstructured{}
+		kingpin.FatalIfError(yaml.Unmarshal(b, &parent.Object), "cannot parse the parent resource to explain")
+		// The clients of the manager work only once it is started, so the
+		// explanation runs in place of the controller as a runnable of it.
+		kingpin.FatalIfError(mgr.Add(manager.RunnableFunc(func(_ <-chan struct{}) error {
+			e, err := reconciler.Explain(context.Background(), parent)
+			kingpin.FatalIfError(err, "cannot explain the parent resource")
+			fmt.Print(e)
+			os.Exit(0)
+			return nil
+		})), "could not add explanation")
+		kingpin.FatalIfError(mgr.Start(ctrl.SetupSignalHandler()), "unable to run the manager")
+		return
+	}
+	if *renderInputsInput != "" {
+		b, err := ioutil.ReadFile(*renderInputsInput)
+		kingpin.FatalIfError(err, "cannot read the parent resource to report the render inputs of")
+		parent := &unstructured.Unstructured{}
+		kingpin.FatalIfError(yaml.Unmarshal(b, &parent.Object), "cannot parse the parent resource to report the render inputs of")
+		kingpin.FatalIfError(mgr.Add(manager.RunnableFunc(func(_ <-chan struct{}) error {
+			in, err := reconciler.RenderInputs(context.Background(), parent)
+			kingpin.FatalIfError(err, "cannot resolve the render inputs of the parent resource")
+			out, err := yaml.Marshal(in)
+			kingpin.FatalIfError(err, "cannot print the render inputs")
+			fmt.Print(string(out))
+			os.Exit(0)
+			return nil
+		})), "could not add render inputs report")
+		kingpin.FatalIfError(mgr.Start(ctrl.SetupSignalHandler()), "unable to run the manager")
+		return
+	}
 	u := &unstructured.Unstructured{}
 	u.SetGroupVersionKind(gvk)
-	kingpin.FatalIfError(
-		ctrl.NewControllerManagedBy(mgr).
+	if *rerenderBatchInput > 0 {
+		c, err := controller.New(strings.ToLower(gvk.Kind), mgr, controller.Options{MaxConcurrentReconciles: *maxReconcilesInput, Reconciler: reconciler})
+		kingpin.FatalIfError(err, "could not create controller")
+		var predicates []predicate.Predicate
+		if shard != nil {
+			predicates = append(predicates, shard)
+		}
+		kingpin.FatalIfError(c.Watch(&source.Kind{Type: u}, templating.NewSpreadEnqueuer(revision, *rerenderBatchInput, *rerenderIntervalInput), predicates...), "could not watch parent resources")
+	} else {
+		b := ctrl.NewControllerManagedBy(mgr).
 			For(u).
-			Complete(controller),
-		"could not create controller",
-	)
-	kingpin.FatalIfError(mgr.Start(ctrl.SetupSignalHandler()), "unable to run the manager")
+			WithOptions(controller.Options{MaxConcurrentReconciles: *maxReconcilesInput})
+		if shard != nil {
+			b = b.WithEventFilter(shard)
+		}
+		kingpin.FatalIfError(b.Complete(reconciler), "could not create controller")
+	}
+	sourceMetrics := templating.NewSourceMetrics(gvk)
+	metrics.Registry.MustRegister(sourceMetrics)
+	sourceWriters := templating.SourceStatusWriterChain{sourceMetrics}
+	if *sourceStatusInput != "" {
+		ns, name, err := cache.SplitMetaNamespaceKey(*sourceStatusInput)
+		kingpin.FatalIfError(err, "cannot parse source status configmap")
+		if ns == "" {
+			ns = sd.GetNamespace()
+		}
+		sourceWriters = append(sourceWriters, templating.NewConfigMapSourceStatusWriter(mgr.GetClient(), types.NamespacedName{Name: name, Namespace: ns}))
+	}
+	kingpin.FatalIfError(mgr.Add(manager.RunnableFunc(func(_ <-chan struct{}) error {
+		rev, err := templating.HashDirectory(*resourceDirInput)
+		if err != nil {
+			err = &resource.FetchError{Source: *resourceDirInput, Err: err}
+		}
+		status := templating.SourceStatus{Source: *resourceDirInput, Revision: rev, LastFetchTime: time.Now(), LastError: err}
+		// Failing to report the status should not stop the controller.
+		if err := sourceWriters.Write(context.Background(), status); err != nil {
+			crLogger.Info("cannot report source status", "error", err)
+		}
+		return nil
+	})), "could not add source status reporter")
+	if *orphanSweepIntervalInput > 0 {
+		kinds := make([]schema.GroupVersionKind, len(*orphanSweepKindsInput))
+		for i, k := range *orphanSweepKindsInput {
+			m, err := mgr.GetRESTMapper().RESTMapping(schema.ParseGroupKind(k))
+			kingpin.FatalIfError(err, "cannot find the kind %s to sweep", k)
+			kinds[i] = m.GroupVersionKind
+		}
+		clusters := map[string]client.Client{templating.TargetClusterLocalValue: mgr.GetClient()}
+		for name, kube := range remotes {
+			clusters[name] = kube
+		}
+		for name, kube := range clusters {
+			if snapshots != nil {
+				kube = templating.NewSnapshotClient(kube, snapshots)
+			}
+			sweeper := templating.NewOrphanSweeper(mgr.GetClient(), kube, gvk, kinds...)
+			sweeper.SetReportOnly(*orphanSweepReportOnlyInput)
+			sweeper.SetLogger(crLogger.WithValues("cluster", name))
+			kingpin.FatalIfError(mgr.Add(manager.RunnableFunc(templating.NewOrphanSweeperRunnable(sweeper, *orphanSweepIntervalInput))), "could not add orphan sweeper")
+		}
+	}
+	if *renderServiceInput != "" {
+		m, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		kingpin.FatalIfError(err, "cannot get REST mapping of parent resource for render service")
+		svc := templating.NewRenderService(reconciler, sd.GetName()).RequireAuthorization(mgr.GetClient(), m.Resource.GroupResource())
+		srv := &http.Server{Addr: *renderServiceInput, Handler: svc}
+		kingpin.FatalIfError(mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+			go func() {
+				<-stop
+				_ = srv.Shutdown(context.Background())
+			}()
+			if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		})), "could not add render service")
+	}
+	err = mgr.Start(ctrl.SetupSignalHandler())
+	if drainer != nil {
+		// The manager returns without waiting for the workers, so the
+		// reconciles that are in flight are waited for here.
+		if err := drainer.Wait(*drainTimeoutInput); err != nil {
+			crLogger.Info("cannot drain in-flight reconciles", "error", err)
+		}
+	}
+	kingpin.FatalIfError(err, "unable to run the manager")
+}
+
+func newRemoteClient(kubeconfig string) (client.Client, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	return client.New(config, client.Options{Scheme: scheme})
+}
+
+func newImpersonatingClient(config *rest.Config) func(user string) (client.Client, error) {
+	return func(user string) (client.Client, error) {
+		c := rest.CopyConfig(config)
+		c.Impersonate = rest.ImpersonationConfig{UserName: user}
+		return client.New(c, client.Options{Scheme: scheme})
+	}
 }
 
 // TODO: Controller-runtime client doesn't work until manager is started, which
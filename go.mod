@@ -7,6 +7,7 @@ require (
 	github.com/crossplane/crossplane-runtime v0.9.0
 	github.com/google/go-cmp v0.4.0
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.1.0
 	golang.org/x/net v0.0.0-20200202094626-16171245cfb2
 	gopkg.in/alecthomas/kingpin.v2 v2.2.6
 	helm.sh/helm/v3 v3.2.0
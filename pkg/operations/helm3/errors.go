@@ -0,0 +1,72 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm3
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const errStrictDecoding = "rendered document is not valid in strict mode"
+
+var (
+	// Helm reports the location of template errors as
+	// "template: chart/templates/db.yaml:12:3: ..." and of parse errors as
+	// "parse error at (chart/templates/db.yaml:12): ...".
+	locationRegex = regexp.MustCompile(`([\w./-]+\.(?:yaml|yml|tpl|txt|json)):(\d+)`)
+	sourceRegex   = regexp.MustCompile(`(?m)^# Source: (.+)$`)
+)
+
+// renderError returns a *resource.RenderError with the location of the
+// template that Helm reported in the given error, if any.
+func renderError(err error) error {
+	re := &resource.RenderError{Engine: engineName, Err: err}
+	if m := locationRegex.FindStringSubmatch(err.Error()); m != nil {
+		re.File = m[1]
+		re.Line, _ = strconv.Atoi(m[2])
+	}
+	return re
+}
+
+// parseManifest parses every document of the given manifest of a release
+// separately so that the template a document is rendered from can be
+// reported, together with the index of the document, in case it cannot be
+// parsed. Documents with duplicate keys are rejected in strict mode.
+func parseManifest(manifest string, strict bool) ([]resource.ChildResource, error) {
+	var result []resource.ChildResource
+	for i, doc := range resource.SplitDocuments([]byte(manifest)) {
+		list, err := resource.DecodeDocument([]byte(doc))
+		if err == nil && strict && len(list) > 0 {
+			err = errors.Wrap(yaml.UnmarshalStrict([]byte(doc), &map[string]interface{}{}), errStrictDecoding)
+		}
+		if err != nil {
+			re := &resource.RenderError{Engine: engineName, Err: &resource.DecodeError{Document: i, Err: err}}
+			if m := sourceRegex.FindStringSubmatch(doc); m != nil {
+				re.File = strings.TrimSpace(m[1])
+			}
+			return nil, re
+		}
+		result = append(result, list...)
+	}
+	return result, nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm3
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+func TestRenderError(t *testing.T) {
+	err := errors.New(`template: wordpress/templates/db.yaml:12:3: executing "wordpress/templates/db.yaml" at <.Values.size>: nil pointer`)
+	re, ok := renderError(err).(*resource.RenderError)
+	if !ok {
+		t.Fatalf("renderError(...): want *resource.RenderError")
+	}
+	if diff := cmp.Diff("wordpress/templates/db.yaml", re.File); diff != "" {
+		t.Errorf("renderError(...): -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(12, re.Line); diff != "" {
+		t.Errorf("renderError(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	manifest := `---
+# Source: wordpress/templates/cm.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cool
+---
+# Source: wordpress/templates/broken.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata: [
+`
+	_, err := parseManifest(manifest, false)
+	re, ok := err.(*resource.RenderError)
+	if !ok {
+		t.Fatalf("parseManifest(...): want *resource.RenderError, got %v", err)
+	}
+	if diff := cmp.Diff("wordpress/templates/broken.yaml", re.File); diff != "" {
+		t.Errorf("parseManifest(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestParseManifestStrict(t *testing.T) {
+	manifest := `---
+# Source: wordpress/templates/cm.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cool
+  name: other
+`
+	if _, err := parseManifest(manifest, false); err != nil {
+		t.Errorf("parseManifest(...): unexpected error in lenient mode: %s", err)
+	}
+	if _, err := parseManifest(manifest, true); err == nil {
+		t.Errorf("parseManifest(...): want error for duplicate keys in strict mode")
+	}
+}
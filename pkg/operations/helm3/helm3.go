@@ -17,15 +17,17 @@ limitations under the License.
 package helm3
 
 import (
-	"bytes"
 	"fmt"
-	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/util/yaml"
 
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 
@@ -33,13 +35,27 @@ import (
 )
 
 const (
+	engineName      = "helm3"
 	defaultRootPath = "resources"
 
 	errSpecCast      = "parent resource spec could not be casted into a map[string]interface{}"
 	errParse         = "could not parse the generated YAMLs"
 	errHelm3Template = "helm3 template call failed"
+	errParameters    = "spec.parameters of parent resource is not an object"
+	errAPIVersions   = "API versions in the capabilities parameter are not a list of strings"
+	errLoadLibrary   = "cannot load template library"
+
+	// libraryTemplatePrefix is prepended to the names of the library files
+	// in the chart. Helm does not render the templates whose names start with
+	// an underscore, so only their definitions are made available.
+	libraryTemplatePrefix = "templates/_lib_"
 )
 
+// LibraryDirs are the directories in the resource path whose files are made
+// available to all templates as partials, e.g. to be used with include, so
+// that packs can share helper snippets without copying them into every chart.
+var LibraryDirs = []string{"lib", "partials"}
+
 // WithResourcePath returns an Option that changes the resource path of the Engine.
 func WithResourcePath(path string) Option {
 	return func(e *Engine) {
@@ -59,6 +75,24 @@ func WithLogger(l logging.Logger) Option {
 	}
 }
 
+// WithStrictDecoding returns an Option that makes the Engine reject the
+// rendered documents with duplicate keys instead of silently using the last
+// value.
+func WithStrictDecoding() Option {
+	return func(e *Engine) {
+		e.strict = true
+	}
+}
+
+// WithLibraryPath returns an Option that makes the files in the given
+// directory available to all templates as partials, in addition to the ones
+// in LibraryDirs of the resource path.
+func WithLibraryPath(path string) Option {
+	return func(e *Engine) {
+		e.libraries = append(e.libraries, path)
+	}
+}
+
 // NewHelm3Engine returns a new Helm3 Engine to be used as resource.TemplatingEngine.
 func NewHelm3Engine(o ...Option) *Engine {
 	h := &Engine{
@@ -78,29 +112,99 @@ type Engine struct {
 
 	// debugLog is used by helm library to debugLog the debugging level logs.
 	debugLog action.DebugLog
+
+	// strict makes the parsing of the rendered documents reject duplicate
+	// keys.
+	strict bool
+
+	// libraries are the additional directories of partials.
+	libraries []string
+
+	// cache is the chart loaded with its libraries, which is shared by the
+	// renders of all parent resources since the resource path does not change
+	// while the controller runs.
+	mu    sync.Mutex
+	cache *chart.Chart
 }
 
 // Run returns the result of the templating operation.
 func (e *Engine) Run(cr resource.ParentResource) ([]resource.ChildResource, error) {
-	values := map[string]interface{}{}
+	vals, err := values(cr)
+	if err != nil {
+		return nil, err
+	}
+	// The API versions of the cluster are given to Helm, too, so that the
+	// charts can use .Capabilities.APIVersions.Has as they usually do.
+	apiVersions, err := resource.GetAPIVersions(cr)
+	if err != nil {
+		return nil, errors.Wrap(err, errAPIVersions)
+	}
+	rawResult, err := e.template(cr.GetName(), vals, apiVersions)
+	if err != nil {
+		return nil, errors.Wrap(renderError(err), errHelm3Template)
+	}
+	resources, err := parseManifest(rawResult, e.strict)
+	return resources, errors.Wrap(err, errParse)
+}
+
+// values returns the spec of the parent resource to be used as values of the
+// chart. The free-form parameters in spec.parameters are exposed as top-level
+// values, too, unless there is a spec field with the same name.
+func values(cr resource.ParentResource) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
 	valuesMap, exists := cr.UnstructuredContent()["spec"]
 	if exists {
 		valuesCasted, ok := valuesMap.(map[string]interface{})
 		if !ok {
 			return nil, errors.New(errSpecCast)
 		}
-		values = valuesCasted
+		// The top-level fields are copied so that the parameters are not
+		// written to the spec of the parent resource.
+		for k, v := range valuesCasted {
+			result[k] = v
+		}
 	}
-	rawResult, err := e.template(cr.GetName(), values)
+	params, err := resource.GetParameters(cr)
 	if err != nil {
-		return nil, errors.Wrap(err, errHelm3Template)
+		return nil, errors.Wrap(err, errParameters)
 	}
-	resources, err := parse([]byte(rawResult))
-	return resources, errors.Wrap(err, errParse)
+	for k, v := range params {
+		if _, ok := result[k]; !ok {
+			result[k] = v
+		}
+	}
+	return result, nil
+}
+
+// chart returns a copy of the cached chart, loading it if it is not cached
+// yet. The files of the chart are shared between the copies, so they must not
+// be modified.
+func (e *Engine) chart() (*chart.Chart, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.cache == nil {
+		c, err := loader.Load(e.ResourcePath)
+		if err != nil {
+			return nil, err
+		}
+		dirs := make([]string, 0, len(LibraryDirs)+len(e.libraries))
+		for _, d := range LibraryDirs {
+			dirs = append(dirs, filepath.Join(e.ResourcePath, d))
+		}
+		if err := loadLibraries(c, append(dirs, e.libraries...)); err != nil {
+			return nil, errors.Wrap(err, errLoadLibrary)
+		}
+		e.cache = c
+	}
+	// Helm sets the values and the dependencies of the chart it installs, so
+	// every render gets a chart of its own.
+	c := *e.cache
+	c.Templates = append([]*chart.File(nil), e.cache.Templates...)
+	return &c, nil
 }
 
-func (e *Engine) template(releaseName string, values map[string]interface{}) (string, error) {
-	chart, err := loader.Load(e.ResourcePath)
+func (e *Engine) template(releaseName string, values map[string]interface{}, apiVersions []string) (string, error) {
+	c, err := e.chart()
 	if err != nil {
 		return "", err
 	}
@@ -119,32 +223,41 @@ func (e *Engine) template(releaseName string, values map[string]interface{}) (st
 	i.DryRun = true
 	i.Replace = true
 	i.ClientOnly = true
+	i.APIVersions = apiVersions
 
-	release, err := i.Run(chart, values)
+	release, err := i.Run(c, values)
 	if err != nil {
 		return "", err
 	}
 	return release.Manifest, nil
 }
 
-func parse(source []byte) ([]resource.ChildResource, error) {
-	dec := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(source), 4096)
-	var result []resource.ChildResource
-	for {
-		u := &unstructured.Unstructured{}
-		err := dec.Decode(u)
-		if err != nil && err != io.EOF {
-			return nil, errors.Wrap(err, errParse)
-		}
-		if err == io.EOF {
-			break
-		}
-		// Helm does not have any built-in validation like Kustomize, so, we
-		// have to do some basic sanity check to skip empty templates.
-		if u.GetName() == "" || u.GetAPIVersion() == "" || u.GetKind() == "" {
+// loadLibraries adds the files in the given directories that exist to the
+// templates of the given chart as partials.
+func loadLibraries(c *chart.Chart, dirs []string) error {
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
 			continue
 		}
-		result = append(result, u)
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			name := libraryTemplatePrefix + filepath.Base(dir) + "_" + strings.ReplaceAll(filepath.ToSlash(rel), "/", "_")
+			c.Templates = append(c.Templates, &chart.File{Name: name, Data: data})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
 	}
-	return result, nil
+	return nil
 }
@@ -19,6 +19,7 @@ package helm3
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -44,7 +45,7 @@ func TestRun(t *testing.T) {
 	if err != nil {
 		panic("test-cr.yaml is deleted")
 	}
-	res, err := parse(testYaml)
+	res, err := resource.Decode(testYaml)
 	if err != nil {
 		panic("cannot parse test-cr.yaml")
 	}
@@ -54,7 +55,7 @@ func TestRun(t *testing.T) {
 	if err != nil {
 		panic("want.yaml is deleted")
 	}
-	results, err := parse(resultYaml)
+	results, err := resource.Decode(resultYaml)
 	if err != nil {
 		panic("cannot parse want.yaml")
 	}
@@ -104,6 +105,22 @@ func TestRun(t *testing.T) {
 				errContains: nil,
 			},
 		},
+		"SuccessFromCache": {
+			args: args{
+				cr: parentCR,
+				e: func() *Engine {
+					e := NewHelm3Engine(WithResourcePath(filepath.Join(testYAMLDir, "helm-chart")))
+					// The chart of a previous render should be reused
+					// without being changed by it.
+					_, _ = e.Run(parentCR)
+					return e
+				}(),
+			},
+			want: want{
+				result:      results,
+				errContains: nil,
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -119,3 +136,110 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestValues(t *testing.T) {
+	type want struct {
+		values map[string]interface{}
+		err    error
+	}
+	cases := map[string]struct {
+		reason string
+		cr     resource.ParentResource
+		want
+	}{
+		"NoSpec": {
+			reason: "Values should be empty if there is no spec",
+			cr:     &unstructured.Unstructured{Object: map[string]interface{}{}},
+			want: want{
+				values: map[string]interface{}{},
+			},
+		},
+		"ParametersExposed": {
+			reason: "Parameters should be exposed as top-level values without overriding spec fields",
+			cr: &unstructured.Unstructured{Object: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"region": "us-east-1",
+					"parameters": map[string]interface{}{
+						"region":   "eu-west-1",
+						"replicas": int64(3),
+					},
+				},
+			}},
+			want: want{
+				values: map[string]interface{}{
+					"region":   "us-east-1",
+					"replicas": int64(3),
+					"parameters": map[string]interface{}{
+						"region":   "eu-west-1",
+						"replicas": int64(3),
+					},
+				},
+			},
+		},
+		"ParametersNotMap": {
+			reason: "It should return error if parameters is not an object",
+			cr: &unstructured.Unstructured{Object: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"parameters": "olala",
+				},
+			}},
+			want: want{
+				err: errors.New(errParameters),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := values(tc.cr)
+			if diff := cmp.Diff(tc.want.err, err, errContains); diff != "" {
+				t.Errorf("\nReason: %s\nvalues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.values, got); diff != "" {
+				t.Errorf("\nReason: %s\nvalues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestLibraries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "helm3-library")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint:errcheck
+	shared, err := ioutil.TempDir("", "helm3-shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(shared) // nolint:errcheck
+	files := map[string]string{
+		filepath.Join(dir, "Chart.yaml"):           "apiVersion: v2\nname: cool\nversion: 0.1.0\n",
+		filepath.Join(dir, "templates", "cm.yaml"): "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ include \"lib.name\" . }}\n  labels:\n    team: {{ include \"shared.team\" . }}\n",
+		filepath.Join(dir, "lib", "names.tpl"):     "{{- define \"lib.name\" -}}{{ .Release.Name }}-config{{- end -}}\n",
+		filepath.Join(shared, "team.tpl"):          "{{- define \"shared.team\" -}}platform{{- end -}}\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cr := &unstructured.Unstructured{}
+	cr.SetName("cool")
+	got, err := NewHelm3Engine(WithResourcePath(dir), WithLibraryPath(shared)).Run(cr)
+	if err != nil {
+		t.Fatalf("Run(...): %s", err)
+	}
+	// Only the templates should be rendered, not the library files.
+	if len(got) != 1 {
+		t.Fatalf("Run(...): want 1 child resource, got %d", len(got))
+	}
+	if diff := cmp.Diff("cool-config", got[0].GetName()); diff != "" {
+		t.Errorf("Run(...): -want name, +got name:\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"team": "platform"}, got[0].GetLabels()); diff != "" {
+		t.Errorf("Run(...): -want labels, +got labels:\n%s", diff)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm3
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+)
+
+const errVendorDependencies = "cannot fetch chart dependencies"
+
+// NewDependencyVendorer returns a new DependencyVendorer.
+func NewDependencyVendorer() DependencyVendorer {
+	return DependencyVendorer{}
+}
+
+// DependencyVendorer fetches the dependencies that the chart of a pack
+// declares in its Chart.yaml into the charts directory of the pack, the same
+// way helm dependency update does, so that rendering the chart does not need
+// the chart repositories. Packs without a chart or without dependencies are
+// left untouched. The repositories are configured with the usual Helm
+// environment variables, e.g. HELM_REPOSITORY_CONFIG.
+type DependencyVendorer struct{}
+
+// Vendor fetches the chart dependencies of the pack in the given directory.
+func (DependencyVendorer) Vendor(_ context.Context, dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, chartutil.ChartfileName)); os.IsNotExist(err) {
+		return nil
+	}
+	c, err := loader.Load(dir)
+	if err != nil {
+		return errors.Wrap(err, errVendorDependencies)
+	}
+	if len(c.Metadata.Dependencies) == 0 {
+		return nil
+	}
+	settings := cli.New()
+	m := &downloader.Manager{
+		Out:              ioutil.Discard,
+		ChartPath:        dir,
+		Getters:          getter.All(settings),
+		RepositoryConfig: settings.RepositoryConfig,
+		RepositoryCache:  settings.RepositoryCache,
+	}
+	return errors.Wrap(m.Update(), errVendorDependencies)
+}
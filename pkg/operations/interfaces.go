@@ -0,0 +1,238 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// Engine is used as main generation engine by the Pipeline.
+// Its input is typically a Custom Resource instance and output is various
+// Kubernetes objects generated by the given implementation of the Engine.
+type Engine interface {
+	Run(resource.ParentResource) ([]resource.ChildResource, error)
+}
+
+// EngineFunc used for supplying only one function as templating engine.
+type EngineFunc func(resource.ParentResource) ([]resource.ChildResource, error)
+
+// Run calls the EngineFunc function.
+func (t EngineFunc) Run(cr resource.ParentResource) ([]resource.ChildResource, error) {
+	return t(cr)
+}
+
+// ChildResourcePatcher operates on the resources rendered by the templating
+// engine.
+type ChildResourcePatcher interface {
+	Patch(resource.ParentResource, []resource.ChildResource) ([]resource.ChildResource, error)
+}
+
+// ChildResourcePatcherFunc makes it easier to provide only a function as
+// ChildResourcePatcher
+type ChildResourcePatcherFunc func(resource.ParentResource, []resource.ChildResource) ([]resource.ChildResource, error)
+
+// Patch calls the ChildResourcePatcherFunc function.
+func (pre ChildResourcePatcherFunc) Patch(cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	return pre(cr, list)
+}
+
+// ChildResourcePatcherChain makes it easier to provide a list of ChildResourcePatcher
+// to be called in order.
+type ChildResourcePatcherChain []ChildResourcePatcher
+
+// Patch calls the ChildResourcePatcherChain functions in order.
+func (pre ChildResourcePatcherChain) Patch(cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	currentList := list
+	var err error
+	for _, f := range pre {
+		currentList, err = f.Patch(cr, currentList)
+		if err != nil {
+			if _, ok := err.(*resource.PatchError); ok {
+				return nil, err
+			}
+			return nil, &resource.PatchError{Patcher: fmt.Sprintf("%T", f), Err: err}
+		}
+	}
+	return currentList, nil
+}
+
+// A Hook is run at a certain stage of the reconciliation, such as before the
+// render or after a successful apply of the child resources. The child
+// resources are given only to the hooks that run after the render.
+type Hook interface {
+	Run(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) error
+}
+
+// HookFunc makes it easier to provide only a function as Hook.
+type HookFunc func(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) error
+
+// Run calls the HookFunc function.
+func (h HookFunc) Run(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) error {
+	return h(ctx, cr, list)
+}
+
+// HookChain makes it easier to provide a list of Hook to be called in order.
+type HookChain []Hook
+
+// Run calls the HookChain functions in order and stops at the first error.
+func (hc HookChain) Run(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) error {
+	for _, h := range hc {
+		if err := h.Run(ctx, cr, list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A Stage of the reconciliation that a Middleware can wrap.
+type Stage string
+
+// Stages of the reconciliation.
+const (
+	// StageRender runs the engine with the render input as parent resource
+	// and returns the rendered child resources.
+	StageRender Stage = "Render"
+
+	// StagePatch runs the ChildResourcePatchers on the rendered child
+	// resources and returns the patched ones.
+	StagePatch Stage = "Patch"
+
+	// StageApply applies the child resources and returns them.
+	StageApply Stage = "Apply"
+)
+
+// A StageFunc runs a Stage of the reconciliation of the given parent resource
+// with the child resources of the previous Stage, if any, and returns the
+// child resources for the next one.
+type StageFunc func(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error)
+
+// A Middleware wraps the stages of the reconciliation with cross-cutting
+// behavior, e.g. metrics, policy checks or failures injected in tests. It
+// returns a StageFunc that runs the given next one, or fails without running
+// it, and can change the child resources that go into and come out of it.
+type Middleware interface {
+	Wrap(s Stage, next StageFunc) StageFunc
+}
+
+// MiddlewareFunc makes it easier to provide only a function as Middleware.
+type MiddlewareFunc func(s Stage, next StageFunc) StageFunc
+
+// Wrap calls the MiddlewareFunc function.
+func (m MiddlewareFunc) Wrap(s Stage, next StageFunc) StageFunc {
+	return m(s, next)
+}
+
+// MiddlewareChain makes it easier to provide a list of Middleware. The first
+// Middleware is the outermost one, i.e. it runs first before a Stage and last
+// after it.
+type MiddlewareChain []Middleware
+
+// Wrap wraps the given StageFunc with all Middleware of the chain.
+func (mc MiddlewareChain) Wrap(s Stage, next StageFunc) StageFunc {
+	for i := len(mc) - 1; i >= 0; i-- {
+		next = mc[i].Wrap(s, next)
+	}
+	return next
+}
+
+// A ValuesProvider supplies additional values to the render of a parent
+// resource, such as generated credentials. The values are exposed to the
+// engines and patchers under spec.parameters of an in-memory copy of the
+// parent resource; they are never written to the parent resource itself.
+type ValuesProvider interface {
+	Values(ctx context.Context, cr resource.ParentResource) (map[string]interface{}, error)
+}
+
+// ValuesProviderFunc makes it easier to provide only a function as
+// ValuesProvider.
+type ValuesProviderFunc func(ctx context.Context, cr resource.ParentResource) (map[string]interface{}, error)
+
+// Values calls the ValuesProviderFunc function.
+func (v ValuesProviderFunc) Values(ctx context.Context, cr resource.ParentResource) (map[string]interface{}, error) {
+	return v(ctx, cr)
+}
+
+// ValuesProviderChain makes it easier to provide a list of ValuesProvider to
+// be called in order. The values of the latter ones take precedence.
+type ValuesProviderChain []ValuesProvider
+
+// Values returns the union of the values of all ValuesProviders.
+func (vc ValuesProviderChain) Values(ctx context.Context, cr resource.ParentResource) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	for _, p := range vc {
+		vals, err := p.Values(ctx, cr)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range vals {
+			result[k] = v
+		}
+	}
+	return result, nil
+}
+
+// A SecretValuesProvider is a ValuesProvider that reads its values from, or
+// writes them to, Secrets. The ones that provide secrets are left out of the
+// renders served to callers outside of the controller.
+type SecretValuesProvider interface {
+	ValuesProvider
+	ProvidesSecrets() bool
+}
+
+// A ValuesInitializer is a ValuesProvider whose values have to be
+// initialized, e.g. persisted, for a parent resource before they are applied.
+// InitializeValues returns the initialized values, which are rendered with
+// instead of the ones of Values. They are initialized only before the renders
+// whose child resources are applied, so that the other renders do not write to
+// the cluster.
+type ValuesInitializer interface {
+	ValuesProvider
+	InitializeValues(ctx context.Context, cr resource.ParentResource) (map[string]interface{}, error)
+}
+
+// An InputPreparer prepares the render input of a parent resource, e.g. by
+// converting it into the version that the templates are written for. It
+// returns a copy of the parent resource if it changes it.
+type InputPreparer interface {
+	Prepare(ctx context.Context, cr resource.ParentResource) (resource.ParentResource, error)
+}
+
+// InputPreparerFunc makes it easier to provide only a function as
+// InputPreparer.
+type InputPreparerFunc func(ctx context.Context, cr resource.ParentResource) (resource.ParentResource, error)
+
+// Prepare calls the InputPreparerFunc function.
+func (f InputPreparerFunc) Prepare(ctx context.Context, cr resource.ParentResource) (resource.ParentResource, error) {
+	return f(ctx, cr)
+}
+
+// An Applier applies the rendered and patched child resources of a parent
+// resource to the cluster.
+type Applier interface {
+	Apply(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) (ApplyOutcome, error)
+}
+
+// ApplierFunc makes it easier to provide only a function as Applier.
+type ApplierFunc func(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) (ApplyOutcome, error)
+
+// Apply calls the ApplierFunc function.
+func (f ApplierFunc) Apply(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) (ApplyOutcome, error) {
+	return f(ctx, cr, list)
+}
@@ -17,10 +17,14 @@ limitations under the License.
 package kustomize
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/api/resid"
 	"sigs.k8s.io/kustomize/api/types"
 	"sigs.k8s.io/yaml"
 
@@ -29,6 +33,10 @@ import (
 	"github.com/crossplane/templating-controller/pkg/resource"
 )
 
+// ParametersConfigMapName is the name of the ConfigMap that ParametersPatcher
+// generates.
+const ParametersConfigMapName = "parameters"
+
 // NewNamePrefixer returns a new *NamePrefixer.
 func NewNamePrefixer() NamePrefixer {
 	return NamePrefixer{}
@@ -44,6 +52,166 @@ func (np NamePrefixer) Patch(cr resource.ParentResource, k *types.Kustomization)
 	return nil
 }
 
+// TransformerConfig is the configuration of the built-in transformers of
+// Kustomize that are applied to the child resources of every parent resource.
+type TransformerConfig struct {
+	// NameSuffix is appended to the names of all child resources.
+	NameSuffix string
+
+	// CommonLabels are added to all child resources and their selectors.
+	CommonLabels map[string]string
+
+	// CommonAnnotations are added to all child resources.
+	CommonAnnotations map[string]string
+
+	// Namespace overrides the namespace of all namespaced child resources.
+	Namespace string
+}
+
+// NewTransformerPatcher returns a new TransformerPatcher.
+func NewTransformerPatcher(cfg TransformerConfig) TransformerPatcher {
+	return TransformerPatcher{config: cfg}
+}
+
+// TransformerPatcher configures the nameSuffix, commonLabels,
+// commonAnnotations and namespace transformers of Kustomize. The labels and
+// annotations are merged into the ones that the Kustomization already has and
+// take precedence over them.
+type TransformerPatcher struct {
+	config TransformerConfig
+}
+
+// Patch patches the *types.Kustomization object with information from resource.ParentResource
+func (tp TransformerPatcher) Patch(_ resource.ParentResource, k *types.Kustomization) error {
+	if tp.config.NameSuffix != "" {
+		k.NameSuffix = tp.config.NameSuffix
+	}
+	if tp.config.Namespace != "" {
+		k.Namespace = tp.config.Namespace
+	}
+	if len(tp.config.CommonLabels) != 0 && k.CommonLabels == nil {
+		k.CommonLabels = map[string]string{}
+	}
+	for key, val := range tp.config.CommonLabels {
+		k.CommonLabels[key] = val
+	}
+	if len(tp.config.CommonAnnotations) != 0 && k.CommonAnnotations == nil {
+		k.CommonAnnotations = map[string]string{}
+	}
+	for key, val := range tp.config.CommonAnnotations {
+		k.CommonAnnotations[key] = val
+	}
+	return nil
+}
+
+// NewParametersPatcher returns a new ParametersPatcher.
+func NewParametersPatcher() ParametersPatcher {
+	return ParametersPatcher{}
+}
+
+// ParametersPatcher exposes the free-form parameters in spec.parameters of the
+// ParentResource to the templates through a ConfigMap generated by
+// Kustomize with name ParametersConfigMapName, so that they can be referred
+// to with vars. The values that are not strings are given in JSON form.
+type ParametersPatcher struct{}
+
+// Patch patches the *types.Kustomization object with information from resource.ParentResource
+func (pp ParametersPatcher) Patch(cr resource.ParentResource, k *types.Kustomization) error {
+	// The Kustomization object may have been patched by a previous render,
+	// so the generator of that render is removed.
+	for i, g := range k.ConfigMapGenerator {
+		if g.Name == ParametersConfigMapName {
+			k.ConfigMapGenerator = append(k.ConfigMapGenerator[:i], k.ConfigMapGenerator[i+1:]...)
+			break
+		}
+	}
+	params, err := resource.GetParameters(cr)
+	if err != nil || len(params) == 0 {
+		return err
+	}
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	// Map iteration order is random but the generated ConfigMap has to be
+	// the same for the same parameters.
+	sort.Strings(keys)
+	literals := make([]string, len(keys))
+	for i, key := range keys {
+		val, ok := params[key].(string)
+		if !ok {
+			b, err := json.Marshal(params[key])
+			if err != nil {
+				return err
+			}
+			val = string(b)
+		}
+		literals[i] = fmt.Sprintf("%s=%s", key, val)
+	}
+	k.ConfigMapGenerator = append(k.ConfigMapGenerator, types.ConfigMapArgs{
+		GeneratorArgs: types.GeneratorArgs{
+			Name:          ParametersConfigMapName,
+			KvPairSources: types.KvPairSources{LiteralSources: literals},
+		},
+	})
+	return nil
+}
+
+// UIDTokenVar is the name of the var that NewUIDTokenVarPatcher declares.
+const UIDTokenVar = "UID_TOKEN"
+
+// NewUIDTokenVarPatcher returns a new UIDTokenVarPatcher.
+func NewUIDTokenVarPatcher() UIDTokenVarPatcher {
+	return UIDTokenVarPatcher{}
+}
+
+// UIDTokenVarPatcher exposes the short identifier derived from the UID of the
+// ParentResource to the templates as the var UIDTokenVar, i.e. $(UID_TOKEN),
+// which refers to the uidToken key of the ConfigMap that ParametersPatcher
+// generates. It has to run after ParametersPatcher.
+type UIDTokenVarPatcher struct{}
+
+// Patch patches the *types.Kustomization object with information from resource.ParentResource
+func (up UIDTokenVarPatcher) Patch(cr resource.ParentResource, k *types.Kustomization) error {
+	literal := fmt.Sprintf("%s=%s", resource.UIDTokenParameter, resource.UIDToken(cr))
+	i := 0
+	for ; i < len(k.ConfigMapGenerator); i++ {
+		if k.ConfigMapGenerator[i].Name == ParametersConfigMapName {
+			break
+		}
+	}
+	if i == len(k.ConfigMapGenerator) {
+		k.ConfigMapGenerator = append(k.ConfigMapGenerator, types.ConfigMapArgs{
+			GeneratorArgs: types.GeneratorArgs{Name: ParametersConfigMapName},
+		})
+	}
+	// The parameters may already hold the token if it is supplied as value
+	// too.
+	if !hasLiteral(k.ConfigMapGenerator[i].LiteralSources, resource.UIDTokenParameter) {
+		k.ConfigMapGenerator[i].LiteralSources = append(k.ConfigMapGenerator[i].LiteralSources, literal)
+	}
+	for _, v := range k.Vars {
+		if v.Name == UIDTokenVar {
+			return nil
+		}
+	}
+	k.Vars = append(k.Vars, types.Var{
+		Name:     UIDTokenVar,
+		ObjRef:   types.Target{Gvk: resid.Gvk{Version: "v1", Kind: "ConfigMap"}, Name: ParametersConfigMapName},
+		FieldRef: types.FieldSelector{FieldPath: "data." + resource.UIDTokenParameter},
+	})
+	return nil
+}
+
+func hasLiteral(literals []string, key string) bool {
+	for _, l := range literals {
+		if strings.HasPrefix(l, key+"=") {
+			return true
+		}
+	}
+	return false
+}
+
 // NewPatchOverlayGenerator returns a new PatchOverlayGenerator.
 func NewPatchOverlayGenerator(overlays []v1alpha1.KustomizeEngineOverlay) PatchOverlayGenerator {
 	return PatchOverlayGenerator{
@@ -81,7 +249,13 @@ func (pog PatchOverlayGenerator) Generate(cr resource.ParentResource, k *types.K
 			if !exists {
 				continue
 			}
-			if err := unstructured.SetNestedField(obj.Object, val, strings.Split(binding.To, ".")...); err != nil {
+			// The overlays do not declare the types of the fields, so the
+			// values are converted as far as the types can be inferred.
+			path := strings.Split(binding.To, ".")
+			if val, err = ConvertValue(val, InferValueType(overlay.Kind, path)); err != nil {
+				return nil, errors.Wrapf(err, "cannot set %s", binding.To)
+			}
+			if err := unstructured.SetNestedField(obj.Object, val, path...); err != nil {
 				return nil, err
 			}
 		}
@@ -13,8 +13,170 @@ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 See the License for the specific language governing permissions and
 limitations under the License.
 */
+
 package kustomize
 
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/api/resid"
+	"sigs.k8s.io/kustomize/api/types"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
 var (
 	_ Patcher = NamePrefixer{}
+	_ Patcher = ParametersPatcher{}
+	_ Patcher = TransformerPatcher{}
+	_ Patcher = UIDTokenVarPatcher{}
 )
+
+func TestParametersPatcher(t *testing.T) {
+	type args struct {
+		cr resource.ParentResource
+		k  *types.Kustomization
+	}
+	type want struct {
+		k   *types.Kustomization
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"NoParameters": {
+			reason: "No generator should be added if there are no parameters",
+			args: args{
+				cr: &unstructured.Unstructured{Object: map[string]interface{}{}},
+				k:  &types.Kustomization{},
+			},
+			want: want{
+				k: &types.Kustomization{},
+			},
+		},
+		"ReplacePreviousGenerator": {
+			reason: "Generator of a previous render should be replaced with the one of the current parameters",
+			args: args{
+				cr: &unstructured.Unstructured{Object: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"parameters": map[string]interface{}{
+							"region": "us-east-1",
+							"zones":  []interface{}{"a", "b"},
+						},
+					},
+				}},
+				k: &types.Kustomization{
+					ConfigMapGenerator: []types.ConfigMapArgs{
+						{GeneratorArgs: types.GeneratorArgs{Name: ParametersConfigMapName}},
+					},
+				},
+			},
+			want: want{
+				k: &types.Kustomization{
+					ConfigMapGenerator: []types.ConfigMapArgs{
+						{GeneratorArgs: types.GeneratorArgs{
+							Name: ParametersConfigMapName,
+							KvPairSources: types.KvPairSources{
+								LiteralSources: []string{"region=us-east-1", `zones=["a","b"]`},
+							},
+						}},
+					},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := NewParametersPatcher().Patch(tc.args.cr, tc.args.k)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nPatch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.k, tc.args.k); diff != "" {
+				t.Errorf("\nReason: %s\nPatch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestUIDTokenVarPatcher(t *testing.T) {
+	cr := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	cr.SetUID("d8ba2a3c-4e2b-4b5a-9b4e-0d5c8a4f1e21")
+	literal := resource.UIDTokenParameter + "=" + resource.UIDToken(cr)
+	v := types.Var{
+		Name:     UIDTokenVar,
+		ObjRef:   types.Target{Gvk: resid.Gvk{Version: "v1", Kind: "ConfigMap"}, Name: ParametersConfigMapName},
+		FieldRef: types.FieldSelector{FieldPath: "data.uidToken"},
+	}
+	cases := map[string]struct {
+		reason string
+		k      *types.Kustomization
+		want   *types.Kustomization
+	}{
+		"NoParameters": {
+			reason: "The parameters generator should be added with the token if there are no parameters",
+			k:      &types.Kustomization{},
+			want: &types.Kustomization{
+				ConfigMapGenerator: []types.ConfigMapArgs{{GeneratorArgs: types.GeneratorArgs{
+					Name:          ParametersConfigMapName,
+					KvPairSources: types.KvPairSources{LiteralSources: []string{literal}},
+				}}},
+				Vars: []types.Var{v},
+			},
+		},
+		"Parameters": {
+			reason: "The token should be added to the parameters generator once",
+			k: &types.Kustomization{
+				ConfigMapGenerator: []types.ConfigMapArgs{{GeneratorArgs: types.GeneratorArgs{
+					Name:          ParametersConfigMapName,
+					KvPairSources: types.KvPairSources{LiteralSources: []string{"region=us-east-1", literal}},
+				}}},
+				Vars: []types.Var{v},
+			},
+			want: &types.Kustomization{
+				ConfigMapGenerator: []types.ConfigMapArgs{{GeneratorArgs: types.GeneratorArgs{
+					Name:          ParametersConfigMapName,
+					KvPairSources: types.KvPairSources{LiteralSources: []string{"region=us-east-1", literal}},
+				}}},
+				Vars: []types.Var{v},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := NewUIDTokenVarPatcher().Patch(cr, tc.k); err != nil {
+				t.Fatalf("\nReason: %s\nPatch(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, tc.k); diff != "" {
+				t.Errorf("\nReason: %s\nPatch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestTransformerPatcher(t *testing.T) {
+	k := &types.Kustomization{CommonLabels: map[string]string{"app": "db", "tier": "data"}}
+	cfg := TransformerConfig{
+		NameSuffix:        "-prod",
+		Namespace:         "tenant",
+		CommonLabels:      map[string]string{"tier": "storage"},
+		CommonAnnotations: map[string]string{"owner": "team-a"},
+	}
+	if err := NewTransformerPatcher(cfg).Patch(nil, k); err != nil {
+		t.Fatalf("Patch(...): unexpected error: %s", err)
+	}
+	want := &types.Kustomization{
+		NameSuffix:        "-prod",
+		Namespace:         "tenant",
+		CommonLabels:      map[string]string{"app": "db", "tier": "storage"},
+		CommonAnnotations: map[string]string{"owner": "team-a"},
+	}
+	if diff := cmp.Diff(want, k); diff != "" {
+		t.Errorf("Patch(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomize
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+const errConvertValue = "cannot convert value"
+
+// ValueType is the type that a value copied from the parent resource is
+// converted to before it is set on a field of a child resource.
+type ValueType string
+
+// The types that values can be converted to. An empty ValueType leaves the
+// value as it is, except for the numbers without fraction which are always
+// set as integers.
+const (
+	// ValueTypeString formats numbers and booleans as strings.
+	ValueTypeString ValueType = "string"
+
+	// ValueTypeInteger parses strings as integers and refuses numbers with
+	// a fraction.
+	ValueTypeInteger ValueType = "integer"
+
+	// ValueTypeNumber parses strings as numbers.
+	ValueTypeNumber ValueType = "number"
+
+	// ValueTypeBoolean parses strings as booleans.
+	ValueTypeBoolean ValueType = "boolean"
+
+	// ValueTypeIntOrString sets the strings that are integers, e.g. "8080",
+	// as integers and keeps the others, e.g. the named port "http", as
+	// strings.
+	ValueTypeIntOrString ValueType = "int-or-string"
+
+	// ValueTypeQuantity sets the canonical string form of a quantity, e.g.
+	// 0.5 becomes "500m" and "1Gi" stays "1Gi". Values that are not valid
+	// quantities are refused.
+	ValueTypeQuantity ValueType = "quantity"
+)
+
+var (
+	// intOrStringFields are the fields of the built-in kinds whose type is
+	// IntOrString, e.g. the targetPort of a Service.
+	intOrStringFields = map[string]bool{
+		"port":           true,
+		"targetPort":     true,
+		"servicePort":    true,
+		"maxSurge":       true,
+		"maxUnavailable": true,
+		"minAvailable":   true,
+	}
+
+	// integerFields are the fields of the built-in kinds whose type is an
+	// integer.
+	integerFields = map[string]bool{
+		"replicas":      true,
+		"minReplicas":   true,
+		"maxReplicas":   true,
+		"containerPort": true,
+		"hostPort":      true,
+		"nodePort":      true,
+	}
+
+	// quantityFields are the fields of the built-in kinds whose type is a
+	// quantity. The values of the quantityMaps, e.g. resources.limits.cpu,
+	// are quantities too.
+	quantityFields = map[string]bool{
+		"sizeLimit": true,
+		"storage":   true,
+	}
+	quantityMaps = map[string]bool{
+		"limits":      true,
+		"requests":    true,
+		"capacity":    true,
+		"hard":        true,
+		"allocatable": true,
+	}
+)
+
+// InferValueType returns the type of the field at the given path of a child
+// resource of given kind, as far as it can be told from the name of the field.
+// The labels and annotations, and the data of ConfigMaps and Secrets, are
+// always strings. An empty ValueType is returned for unknown fields.
+func InferValueType(kind string, path []string) ValueType {
+	if len(path) == 0 {
+		return ""
+	}
+	if len(path) == 3 && path[0] == "metadata" && (path[1] == "labels" || path[1] == "annotations") {
+		return ValueTypeString
+	}
+	if (kind == "ConfigMap" || kind == "Secret") && len(path) == 2 && (path[0] == "data" || path[0] == "stringData") {
+		return ValueTypeString
+	}
+	last := path[len(path)-1]
+	switch {
+	case intOrStringFields[last]:
+		return ValueTypeIntOrString
+	case integerFields[last]:
+		return ValueTypeInteger
+	case quantityFields[last]:
+		return ValueTypeQuantity
+	case len(path) > 1 && quantityMaps[path[len(path)-2]]:
+		return ValueTypeQuantity
+	}
+	return ""
+}
+
+// ConvertValue converts the given value of the parent resource to the given
+// type. Values that are objects or arrays are only accepted by the empty
+// ValueType.
+func ConvertValue(val interface{}, t ValueType) (interface{}, error) { // nolint:gocyclo
+	// The conversions are a flat list of cases for every type, splitting
+	// them would not make them any easier to follow.
+	if f, ok := val.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		val = int64(f)
+	}
+	switch t {
+	case "":
+		return val, nil
+	case ValueTypeString:
+		switch v := val.(type) {
+		case string:
+			return v, nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+	case ValueTypeInteger:
+		switch v := val.(type) {
+		case int64:
+			return v, nil
+		case string:
+			if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return i, nil
+			}
+		}
+	case ValueTypeNumber:
+		switch v := val.(type) {
+		case int64, float64:
+			return v, nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return ConvertValue(f, "")
+			}
+		}
+	case ValueTypeBoolean:
+		switch v := val.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+	case ValueTypeIntOrString:
+		switch v := val.(type) {
+		case int64:
+			return v, nil
+		case string:
+			if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return i, nil
+			}
+			return v, nil
+		}
+	case ValueTypeQuantity:
+		var s string
+		switch v := val.(type) {
+		case string:
+			s = strings.TrimSpace(v)
+		case int64:
+			s = strconv.FormatInt(v, 10)
+		case float64:
+			s = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		if s != "" {
+			if q, err := apiresource.ParseQuantity(s); err == nil {
+				return q.String(), nil
+			}
+		}
+	default:
+		return nil, errors.Errorf("%s: unknown type %s", errConvertValue, t)
+	}
+	return nil, errors.Errorf("%s %v to %s", errConvertValue, val, t)
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestInferValueType(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		kind   string
+		path   string
+		want   ValueType
+	}{
+		"TargetPort": {
+			reason: "Target ports can be names or numbers.",
+			kind:   "Service",
+			path:   "spec.ports.0.targetPort",
+			want:   ValueTypeIntOrString,
+		},
+		"Replicas": {
+			reason: "Replicas are integers.",
+			kind:   "Deployment",
+			path:   "spec.replicas",
+			want:   ValueTypeInteger,
+		},
+		"Limits": {
+			reason: "The values of resource limits are quantities.",
+			kind:   "Deployment",
+			path:   "spec.template.spec.containers.0.resources.limits.memory",
+			want:   ValueTypeQuantity,
+		},
+		"Label": {
+			reason: "Labels are strings even if their name is known to be a port.",
+			kind:   "Service",
+			path:   "metadata.labels.port",
+			want:   ValueTypeString,
+		},
+		"ConfigMapData": {
+			reason: "The data of ConfigMaps are strings.",
+			kind:   "ConfigMap",
+			path:   "data.replicas",
+			want:   ValueTypeString,
+		},
+		"Unknown": {
+			reason: "Unknown fields should not be converted.",
+			kind:   "Database",
+			path:   "spec.engineVersion",
+			want:   "",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := InferValueType(tc.kind, splitPath(tc.path))
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nInferValueType(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestConvertValue(t *testing.T) {
+	type want struct {
+		val interface{}
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		val    interface{}
+		t      ValueType
+		want   want
+	}{
+		"WholeFloat": {
+			reason: "Numbers without fraction should be set as integers.",
+			val:    float64(3),
+			want:   want{val: int64(3)},
+		},
+		"Object": {
+			reason: "Objects should be left as they are if no type is given.",
+			val:    map[string]interface{}{"a": "b"},
+			want:   want{val: map[string]interface{}{"a": "b"}},
+		},
+		"IntToString": {
+			reason: "Integers should be formatted as strings.",
+			val:    int64(8080),
+			t:      ValueTypeString,
+			want:   want{val: "8080"},
+		},
+		"BoolToString": {
+			reason: "Booleans should be formatted as strings.",
+			val:    true,
+			t:      ValueTypeString,
+			want:   want{val: "true"},
+		},
+		"StringToInteger": {
+			reason: "Strings that are integers should be parsed.",
+			val:    "3",
+			t:      ValueTypeInteger,
+			want:   want{val: int64(3)},
+		},
+		"FractionToInteger": {
+			reason: "Numbers with fraction should not be converted to integers.",
+			val:    1.5,
+			t:      ValueTypeInteger,
+			want:   want{err: errors.Errorf("%s %v to %s", errConvertValue, 1.5, ValueTypeInteger)},
+		},
+		"StringToBoolean": {
+			reason: "Strings that are booleans should be parsed.",
+			val:    "false",
+			t:      ValueTypeBoolean,
+			want:   want{val: false},
+		},
+		"NumericPort": {
+			reason: "Ports given as numeric strings should be set as integers.",
+			val:    "8080",
+			t:      ValueTypeIntOrString,
+			want:   want{val: int64(8080)},
+		},
+		"NamedPort": {
+			reason: "Named ports should stay strings.",
+			val:    "http",
+			t:      ValueTypeIntOrString,
+			want:   want{val: "http"},
+		},
+		"FractionQuantity": {
+			reason: "Numbers should be set in the canonical form of quantities.",
+			val:    0.5,
+			t:      ValueTypeQuantity,
+			want:   want{val: "500m"},
+		},
+		"IntQuantity": {
+			reason: "Integers should be set as quantity strings.",
+			val:    int64(2),
+			t:      ValueTypeQuantity,
+			want:   want{val: "2"},
+		},
+		"StringQuantity": {
+			reason: "Quantity strings should be kept.",
+			val:    "1Gi",
+			t:      ValueTypeQuantity,
+			want:   want{val: "1Gi"},
+		},
+		"InvalidQuantity": {
+			reason: "Strings that are not quantities should be refused.",
+			val:    "lots",
+			t:      ValueTypeQuantity,
+			want:   want{err: errors.Errorf("%s %v to %s", errConvertValue, "lots", ValueTypeQuantity)},
+		},
+		"UnknownType": {
+			reason: "Unknown types should be refused.",
+			val:    "a",
+			t:      ValueType("date"),
+			want:   want{err: errors.Errorf("%s: unknown type %s", errConvertValue, "date")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ConvertValue(tc.val, tc.t)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nConvertValue(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.val, got); diff != "" {
+				t.Errorf("\n%s\nConvertValue(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func splitPath(path string) []string {
+	return strings.Split(path, ".")
+}
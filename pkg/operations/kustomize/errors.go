@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomize
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	overlayDirName = "overlay"
+
+	// Shorter dumps are kept as they are since they are readable enough.
+	maxObjectDumpLength = 80
+)
+
+var (
+	lineRegex = regexp.MustCompile(`line (\d+)`)
+	kindRegex = regexp.MustCompile(`[\s\[]kind:([^\s\]]+)`)
+	nameRegex = regexp.MustCompile(`[\s\[]name:([^\s\]]+)`)
+)
+
+// renderError returns a *resource.RenderError whose message refers to the
+// files the way the pack author knows them, i.e. relative to the resources
+// folder, instead of the temporary overlay folder, and whose object dumps are
+// shortened to the kind and name of the object. The first file of the
+// resources folder or the overlay that the message mentions is reported as the
+// file of the error.
+func (o *Engine) renderError(err error, dir string) error {
+	absPath, _ := filepath.Abs(o.ResourcePath)
+	relPath, _ := filepath.Rel(dir, absPath)
+	msg := err.Error()
+	for _, p := range []string{absPath, dir, relPath} {
+		if p == "" || p == "." {
+			continue
+		}
+		replacement := ""
+		if p == dir {
+			replacement = overlayDirName + "/"
+		}
+		msg = strings.ReplaceAll(msg, p+string(filepath.Separator), replacement)
+	}
+	msg = shortenObjectDumps(msg)
+	re := &resource.RenderError{Engine: engineName, Err: errors.New(msg)}
+	re.File = mentionedFile(msg, append(listFiles(absPath, ""), listFiles(dir, overlayDirName)...))
+	if m := lineRegex.FindStringSubmatch(msg); re.File != "" && m != nil {
+		re.Line, _ = strconv.Atoi(m[1])
+	}
+	return re
+}
+
+// listFiles returns the paths of all files in the given folder relative to it
+// and prefixed with the given prefix.
+func listFiles(root, prefix string) []string {
+	var result []string
+	_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		result = append(result, filepath.Join(prefix, rel))
+		return nil
+	})
+	return result
+}
+
+// mentionedFile returns the longest one of the given files that the message
+// mentions, so that a.yaml is not reported when the message is about ba.yaml.
+func mentionedFile(msg string, files []string) string {
+	sort.Slice(files, func(i, j int) bool { return len(files[i]) > len(files[j]) })
+	for _, f := range files {
+		if strings.Contains(msg, f) {
+			return f
+		}
+	}
+	return ""
+}
+
+// shortenObjectDumps replaces the objects that are printed as Go maps, e.g.
+// map[apiVersion:v1 kind:ConfigMap metadata:map[name:cool]], with their
+// kind and name, e.g. ConfigMap/cool.
+func shortenObjectDumps(msg string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(msg, "map[")
+		if start == -1 {
+			b.WriteString(msg)
+			return b.String()
+		}
+		end := matchingBracket(msg, start+len("map"))
+		if end == -1 {
+			b.WriteString(msg)
+			return b.String()
+		}
+		b.WriteString(msg[:start])
+		b.WriteString(describeObject(msg[start : end+1]))
+		msg = msg[end+1:]
+	}
+}
+
+// matchingBracket returns the index of the bracket that closes the one at the
+// given index, or -1 if it is not closed.
+func matchingBracket(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func describeObject(dump string) string {
+	kind := kindRegex.FindStringSubmatch(dump)
+	name := nameRegex.FindStringSubmatch(dump)
+	switch {
+	case kind != nil && name != nil:
+		return kind[1] + "/" + name[1]
+	case kind != nil:
+		return kind[1]
+	case len(dump) <= maxObjectDumpLength:
+		return dump
+	}
+	return "<object>"
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomize
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestShortenObjectDumps(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		msg    string
+		want   string
+	}{
+		"NoDump": {
+			reason: "Messages without object dumps should not be changed",
+			msg:    "accumulating resources: cannot read file",
+			want:   "accumulating resources: cannot read file",
+		},
+		"Object": {
+			reason: "Object dumps should be replaced with the kind and name of the object",
+			msg:    "conflict between map[apiVersion:v1 kind:ConfigMap metadata:map[labels:map[app.kubernetes.io/name:other] name:cool]] and another",
+			want:   "conflict between ConfigMap/cool and another",
+		},
+		"ShortMap": {
+			reason: "Short dumps that are not objects should be kept",
+			msg:    "unexpected map[size:small]",
+			want:   "unexpected map[size:small]",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, shortenObjectDumps(tc.msg)); diff != "" {
+				t.Errorf("\nReason: %s\nshortenObjectDumps(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMentionedFile(t *testing.T) {
+	files := []string{"a.yaml", "ba.yaml", "overlay/kustomization.yaml"}
+	if diff := cmp.Diff("ba.yaml", mentionedFile("cannot parse ba.yaml: line 3", files)); diff != "" {
+		t.Errorf("mentionedFile(...): -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff("", mentionedFile("something else", files)); diff != "" {
+		t.Errorf("mentionedFile(...): -want, +got:\n%s", diff)
+	}
+}
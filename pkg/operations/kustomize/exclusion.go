@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomize
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kustomizeapi "sigs.k8s.io/kustomize/api/types"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const errParseTransformExclusion = "cannot parse transform exclusion"
+
+// A TransformExclusion makes the child resources of a kind, and of a name if
+// it is given, skip the given transforms as if their templates were annotated
+// with resource.SkipTransformsAnnotationKey, so that packs whose templates
+// cannot be changed can still exclude them.
+type TransformExclusion struct {
+	Kind       schema.GroupKind
+	Name       string
+	Transforms []resource.Transform
+}
+
+// ParseTransformExclusion parses a TransformExclusion given as
+// transform[,transform]=Kind.group[/name], e.g.
+// name-prefix=ClusterIssuer.cert-manager.io/letsencrypt.
+func ParseTransformExclusion(s string) (TransformExclusion, error) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return TransformExclusion{}, errors.Errorf("%s: %s", errParseTransformExclusion, s)
+	}
+	e := TransformExclusion{}
+	for _, t := range strings.Split(parts[0], ",") {
+		switch tr := resource.Transform(strings.TrimSpace(t)); tr {
+		case resource.TransformNamePrefix, resource.TransformLabels:
+			e.Transforms = append(e.Transforms, tr)
+		default:
+			return TransformExclusion{}, errors.Errorf("%s: unknown transform %s", errParseTransformExclusion, t)
+		}
+	}
+	target := strings.SplitN(parts[1], "/", 2)
+	e.Kind = schema.ParseGroupKind(target[0])
+	if len(target) == 2 {
+		e.Name = target[1]
+	}
+	return e, nil
+}
+
+// excludeTransforms annotates the objects that the given exclusions match to
+// skip their transforms and restores the names of the objects that skip
+// resource.TransformNamePrefix. The names in the exclusions are the ones in
+// the templates. Note that the references to a restored object that
+// Kustomize updated in the other objects keep the prefixed name.
+func excludeTransforms(k *kustomizeapi.Kustomization, objects []resource.ChildResource, exclusions []TransformExclusion) {
+	for _, o := range objects {
+		name := strings.TrimSuffix(strings.TrimPrefix(o.GetName(), k.NamePrefix), k.NameSuffix)
+		for _, e := range exclusions {
+			if e.Kind != o.GetObjectKind().GroupVersionKind().GroupKind() || (e.Name != "" && e.Name != name) {
+				continue
+			}
+			for _, t := range e.Transforms {
+				if !resource.SkipsTransform(o, t) {
+					skip := string(t)
+					if s := o.GetAnnotations()[resource.SkipTransformsAnnotationKey]; s != "" {
+						skip = s + "," + skip
+					}
+					meta.AddAnnotations(o, map[string]string{resource.SkipTransformsAnnotationKey: skip})
+				}
+			}
+		}
+		if resource.SkipsTransform(o, resource.TransformNamePrefix) {
+			o.SetName(name)
+		}
+	}
+}
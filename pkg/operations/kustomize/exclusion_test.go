@@ -0,0 +1,112 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomize
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/kustomize/api/types"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+func TestParseTransformExclusion(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		s      string
+		want   TransformExclusion
+		err    error
+	}{
+		"KindAndName": {
+			reason: "The transforms, the kind and the name should be parsed",
+			s:      "name-prefix,labels=ClusterIssuer.cert-manager.io/letsencrypt",
+			want: TransformExclusion{
+				Kind:       schema.GroupKind{Group: "cert-manager.io", Kind: "ClusterIssuer"},
+				Name:       "letsencrypt",
+				Transforms: []resource.Transform{resource.TransformNamePrefix, resource.TransformLabels},
+			},
+		},
+		"KindOnly": {
+			reason: "The name should be optional",
+			s:      "labels=Namespace",
+			want: TransformExclusion{
+				Kind:       schema.GroupKind{Kind: "Namespace"},
+				Transforms: []resource.Transform{resource.TransformLabels},
+			},
+		},
+		"UnknownTransform": {
+			reason: "Transforms that cannot be skipped should be rejected",
+			s:      "namespace=Namespace",
+			err:    errors.Errorf("%s: unknown transform namespace", errParseTransformExclusion),
+		},
+		"NoKind": {
+			reason: "An exclusion without a kind should be rejected",
+			s:      "labels",
+			err:    errors.Errorf("%s: labels", errParseTransformExclusion),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseTransformExclusion(tc.s)
+			if diff := cmp.Diff(tc.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nParseTransformExclusion(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nParseTransformExclusion(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestExcludeTransforms(t *testing.T) {
+	k := &types.Kustomization{NamePrefix: "cool-", NameSuffix: "-prod"}
+	object := func(apiVersion, kind, name string, annotations map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetName(name)
+		u.SetAnnotations(annotations)
+		return u
+	}
+	issuer := object("cert-manager.io/v1alpha2", "ClusterIssuer", "cool-letsencrypt-prod", nil)
+	otherIssuer := object("cert-manager.io/v1alpha2", "ClusterIssuer", "cool-selfsigned-prod", nil)
+	annotated := object("v1", "ConfigMap", "cool-config-prod", map[string]string{resource.SkipTransformsAnnotationKey: "name-prefix"})
+	excludeTransforms(k, []resource.ChildResource{issuer, otherIssuer, annotated}, []TransformExclusion{{
+		Kind:       schema.GroupKind{Group: "cert-manager.io", Kind: "ClusterIssuer"},
+		Name:       "letsencrypt",
+		Transforms: []resource.Transform{resource.TransformLabels, resource.TransformNamePrefix},
+	}})
+
+	if diff := cmp.Diff("letsencrypt", issuer.GetName()); diff != "" {
+		t.Errorf("excludeTransforms(...): the excluded object should keep its name: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{resource.SkipTransformsAnnotationKey: "labels,name-prefix"}, issuer.GetAnnotations()); diff != "" {
+		t.Errorf("excludeTransforms(...): the excluded object should be annotated: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff("cool-selfsigned-prod", otherIssuer.GetName()); diff != "" {
+		t.Errorf("excludeTransforms(...): objects of other names should be prefixed: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff("config", annotated.GetName()); diff != "" {
+		t.Errorf("excludeTransforms(...): the annotated object should keep its name: -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomize
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/kustomize/api/resid"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const json6902FilePrefix = "json6902patch-"
+
+// JSON6902Patch is a JSON6902 patch whose operations can take their values
+// from the fields of the parent resource.
+type JSON6902Patch struct {
+	// Target is the child resource to be patched. Its name is the one in the
+	// templates, i.e. without the name prefix of the parent resource.
+	Target JSON6902Target `json:"target"`
+
+	// Operations are applied to the target in order.
+	Operations []JSON6902Operation `json:"operations"`
+}
+
+// JSON6902Target refers to the child resource that a JSON6902Patch is applied
+// to.
+type JSON6902Target struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+// JSON6902Operation is a single operation of a JSON6902Patch.
+type JSON6902Operation struct {
+	// Op is one of add, remove, replace, move, copy or test.
+	Op string `json:"op"`
+
+	// Path is the JSON pointer to the field of the target.
+	Path string `json:"path"`
+
+	// From is the JSON pointer to the source field of move and copy
+	// operations.
+	From string `json:"from,omitempty"`
+
+	// Value is the static value of the operation.
+	Value interface{} `json:"value,omitempty"`
+
+	// ValueFrom is the path of the field of the parent resource, e.g.
+	// spec.parameters.port, whose value is used instead of Value. The
+	// operation is skipped if the parent resource does not have the field.
+	ValueFrom string `json:"valueFrom,omitempty"`
+
+	// Type is the type that the value of ValueFrom is converted to. If it
+	// is not given, the type is inferred from the path of the target field,
+	// e.g. the values of /spec/ports/0/targetPort are set as IntOrString.
+	Type ValueType `json:"type,omitempty"`
+}
+
+// NewJSON6902PatchGenerator returns a new JSON6902PatchGenerator.
+func NewJSON6902PatchGenerator(patches []JSON6902Patch) JSON6902PatchGenerator {
+	return JSON6902PatchGenerator{Patches: patches}
+}
+
+// JSON6902PatchGenerator generates a JSON6902 patch file for every given
+// patch after filling their values from the parent resource and refers to
+// them in the patchesJson6902 of the Kustomization.
+type JSON6902PatchGenerator struct {
+	Patches []JSON6902Patch
+}
+
+// Generate produces files to be written to the overlay folder of kustomization
+// process.
+func (jg JSON6902PatchGenerator) Generate(cr resource.ParentResource, k *types.Kustomization) ([]OverlayFile, error) {
+	// The Kustomization object is shared between the renders of different
+	// parent resources, so the patches of a previous render are removed as
+	// their files may not be generated for this one.
+	existing := k.PatchesJson6902[:0]
+	for _, p := range k.PatchesJson6902 {
+		if !strings.HasPrefix(p.Path, json6902FilePrefix) {
+			existing = append(existing, p)
+		}
+	}
+	k.PatchesJson6902 = existing
+	var files []OverlayFile
+	for i, patch := range jg.Patches {
+		ops, err := fillOperations(cr, patch.Target.Kind, patch.Operations)
+		if err != nil {
+			return nil, err
+		}
+		if len(ops) == 0 {
+			continue
+		}
+		data, err := yaml.Marshal(ops)
+		if err != nil {
+			return nil, err
+		}
+		fileName := fmt.Sprintf("%s%d.yaml", json6902FilePrefix, i)
+		gvk := schema.FromAPIVersionAndKind(patch.Target.APIVersion, patch.Target.Kind)
+		k.PatchesJson6902 = append(k.PatchesJson6902, types.PatchJson6902{
+			Target: &types.PatchTarget{
+				Gvk:       resid.Gvk{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+				Name:      patch.Target.Name,
+				Namespace: patch.Target.Namespace,
+			},
+			Path: fileName,
+		})
+		files = append(files, OverlayFile{Name: fileName, Data: data})
+	}
+	return files, nil
+}
+
+// fillOperations returns the operations with their values filled from the
+// parent resource, leaving out the ones whose fields are not set. The values
+// are converted to the types of the fields of the child resource of given
+// kind.
+func fillOperations(cr resource.ParentResource, kind string, ops []JSON6902Operation) ([]map[string]interface{}, error) {
+	result := make([]map[string]interface{}, 0, len(ops))
+	for _, op := range ops {
+		out := map[string]interface{}{"op": op.Op, "path": op.Path}
+		if op.From != "" {
+			out["from"] = op.From
+		}
+		if op.Value != nil {
+			out["value"] = op.Value
+		}
+		if op.ValueFrom != "" {
+			val, exists, err := unstructured.NestedFieldCopy(cr.UnstructuredContent(), strings.Split(op.ValueFrom, ".")...)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				continue
+			}
+			t := op.Type
+			if t == "" {
+				t = InferValueType(kind, strings.Split(pointerToPath(op.Path), "."))
+			}
+			if val, err = ConvertValue(val, t); err != nil {
+				return nil, errors.Wrapf(err, "cannot set %s", op.Path)
+			}
+			out["value"] = val
+		}
+		result = append(result, out)
+	}
+	return result, nil
+}
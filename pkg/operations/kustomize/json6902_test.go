@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomize
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/api/resid"
+	"sigs.k8s.io/kustomize/api/types"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+var (
+	_ OverlayGenerator = JSON6902PatchGenerator{}
+)
+
+func TestJSON6902PatchGenerator(t *testing.T) {
+	patches := []JSON6902Patch{
+		{
+			Target: JSON6902Target{APIVersion: "v1", Kind: "Service", Name: "db"},
+			Operations: []JSON6902Operation{
+				{Op: "replace", Path: "/spec/ports/0/port", ValueFrom: "spec.parameters.port"},
+				{Op: "add", Path: "/metadata/labels/tier", Value: "data"},
+			},
+		},
+		{
+			Target:     JSON6902Target{APIVersion: "apps/v1", Kind: "Deployment", Name: "db"},
+			Operations: []JSON6902Operation{{Op: "replace", Path: "/spec/replicas", ValueFrom: "spec.parameters.replicas"}},
+		},
+	}
+	cr := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"parameters": map[string]interface{}{"port": int64(5432)},
+		},
+	}}
+	// The patch of the previous render should be dropped since the replicas
+	// of this parent resource are not set.
+	k := &types.Kustomization{
+		PatchesJson6902: []types.PatchJson6902{
+			{Path: "custom.yaml"},
+			{Path: "json6902patch-1.yaml"},
+		},
+	}
+	files, err := NewJSON6902PatchGenerator(patches).Generate(cr, k)
+	if diff := cmp.Diff(nil, err, test.EquateErrors()); diff != "" {
+		t.Errorf("Generate(...): -want error, +got error:\n%s", diff)
+	}
+	wantFiles := []OverlayFile{{
+		Name: "json6902patch-0.yaml",
+		Data: []byte("- op: replace\n  path: /spec/ports/0/port\n  value: 5432\n- op: add\n  path: /metadata/labels/tier\n  value: data\n"),
+	}}
+	if diff := cmp.Diff(wantFiles, files); diff != "" {
+		t.Errorf("Generate(...): -want files, +got files:\n%s", diff)
+	}
+	wantK := &types.Kustomization{
+		PatchesJson6902: []types.PatchJson6902{
+			{Path: "custom.yaml"},
+			{
+				Target: &types.PatchTarget{Gvk: resid.Gvk{Version: "v1", Kind: "Service"}, Name: "db"},
+				Path:   "json6902patch-0.yaml",
+			},
+		},
+	}
+	if diff := cmp.Diff(wantK, k); diff != "" {
+		t.Errorf("Generate(...): -want kustomization, +got kustomization:\n%s", diff)
+	}
+}
@@ -17,21 +17,28 @@ limitations under the License.
 package kustomize
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/api/krusty"
 	kustomizeapi "sigs.k8s.io/kustomize/api/types"
 	"sigs.k8s.io/yaml"
 
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
 	"github.com/crossplane/templating-controller/pkg/resource"
 )
 
 const (
+	engineName            = "kustomize"
+	hashSuffixLength      = 10
 	defaultResourcesPath  = "resources"
 	kustomizationFileName = "kustomization.yaml"
 
@@ -56,6 +63,22 @@ func AdditionalPatcher(op ...Patcher) Option {
 	}
 }
 
+// WithTransformers allows you to configure the built-in transformers of
+// Kustomize for all renders.
+func WithTransformers(cfg TransformerConfig) Option {
+	return func(ko *Engine) {
+		ko.Patchers = append(ko.Patchers, NewTransformerPatcher(cfg))
+	}
+}
+
+// WithTransformExclusions allows you to make the child resources that the
+// given exclusions match skip transforms.
+func WithTransformExclusions(e ...TransformExclusion) Option {
+	return func(ko *Engine) {
+		ko.TransformExclusions = append(ko.TransformExclusions, e...)
+	}
+}
+
 // WithOverlayGenerator allows you to append OverlayGenerator objects
 // to the generation pipeline.
 func WithOverlayGenerator(op ...OverlayGenerator) Option {
@@ -64,6 +87,14 @@ func WithOverlayGenerator(op ...OverlayGenerator) Option {
 	}
 }
 
+// AdditionalOverlayGenerator allows you to append OverlayGenerator objects
+// to the generation pipeline without replacing the existing ones.
+func AdditionalOverlayGenerator(op ...OverlayGenerator) Option {
+	return func(ko *Engine) {
+		ko.OverlayGenerators = append(ko.OverlayGenerators, op...)
+	}
+}
+
 // NewKustomizeEngine returns a Engine object. rootPath should
 // point to the folder where your base kustomization.yaml resides and patcher
 // is the chain of Patcher that makes modifications of Kustomization
@@ -79,6 +110,7 @@ func NewKustomizeEngine(k *kustomizeapi.Kustomization, opt ...Option) *Engine {
 			// TODO(muvaf): think how this should work if name prefix is already
 			// given.
 			NewNamePrefixer(),
+			NewParametersPatcher(),
 		},
 	}
 
@@ -93,7 +125,9 @@ func NewKustomizeEngine(k *kustomizeapi.Kustomization, opt ...Option) *Engine {
 // calls Kustomize for doing the resource generation.
 type Engine struct {
 	// ResourcePath is the folder that the base resources reside in the
-	// filesystem. It should be given as absolute path.
+	// filesystem. It should be given as absolute path. The files that its
+	// kustomization.yaml does not refer to, such as the partials in the lib
+	// and partials directories shared with the other engines, are ignored.
 	ResourcePath string
 
 	// Kustomization is the content of kustomization.yaml file that contains
@@ -107,20 +141,30 @@ type Engine struct {
 	// OverlayGenerators contains the overlay generators that will be added
 	// to the file system alongside kustomization.yaml
 	OverlayGenerators OverlayGeneratorChain
+
+	// TransformExclusions contains the child resources that skip transforms
+	// in addition to the ones whose templates are annotated to skip them.
+	TransformExclusions []TransformExclusion
 }
 
 // Run is called to trigger kustomization operation and returns the generated
 // raw Kubernetes objects.
 func (o *Engine) Run(cr resource.ParentResource) ([]resource.ChildResource, error) {
-	if err := o.Patchers.Patch(cr, o.Kustomization); err != nil {
+	// The patchers modify the Kustomization, so every render gets a copy of
+	// its own to be safe for concurrent use.
+	k, err := copyKustomization(o.Kustomization)
+	if err != nil {
+		return nil, errors.Wrap(err, errPatch)
+	}
+	if err := o.Patchers.Patch(cr, k); err != nil {
 		return nil, errors.Wrap(err, errPatch)
 	}
-	extraFiles, err := o.OverlayGenerators.Generate(cr, o.Kustomization)
+	extraFiles, err := o.OverlayGenerators.Generate(cr, k)
 	if err != nil {
 		return nil, errors.Wrap(err, errOverlayGeneration)
 	}
 
-	dir, err := o.prepareOverlay(o.Kustomization, extraFiles)
+	dir, err := o.prepareOverlay(k, extraFiles)
 	defer func() {
 		_ = os.RemoveAll(dir)
 	}()
@@ -131,7 +175,7 @@ func (o *Engine) Run(cr resource.ParentResource) ([]resource.ChildResource, erro
 	kustomizer := krusty.MakeKustomizer(filesys.MakeFsOnDisk(), krusty.MakeDefaultOptions())
 	resMap, err := kustomizer.Run(dir)
 	if err != nil {
-		return nil, errors.Wrap(err, errKustomizeCall)
+		return nil, errors.Wrap(o.renderError(err, dir), errKustomizeCall)
 	}
 
 	objects := make([]resource.ChildResource, len(resMap.Resources()))
@@ -140,9 +184,45 @@ func (o *Engine) Run(cr resource.ParentResource) ([]resource.ChildResource, erro
 			Object: res.Map(),
 		}
 	}
+	labelGenerated(k, objects)
+	excludeTransforms(k, objects, o.TransformExclusions)
 	return objects, nil
 }
 
+func copyKustomization(k *kustomizeapi.Kustomization) (*kustomizeapi.Kustomization, error) {
+	b, err := json.Marshal(k)
+	if err != nil {
+		return nil, err
+	}
+	c := &kustomizeapi.Kustomization{}
+	return c, json.Unmarshal(b, c)
+}
+
+// labelGenerated labels the ConfigMaps and Secrets that are generated with
+// a hash suffix by the generators in the given Kustomization with the name
+// of their generator.
+func labelGenerated(k *kustomizeapi.Kustomization, objects []resource.ChildResource) {
+	generators := map[string]string{}
+	for _, g := range k.ConfigMapGenerator {
+		generators[k.NamePrefix+g.Name+k.NameSuffix] = "ConfigMap"
+	}
+	for _, g := range k.SecretGenerator {
+		generators[k.NamePrefix+g.Name+k.NameSuffix] = "Secret"
+	}
+	for _, obj := range objects {
+		i := strings.LastIndex(obj.GetName(), "-")
+		if i == -1 || len(obj.GetName())-i-1 != hashSuffixLength {
+			continue
+		}
+		name := obj.GetName()[:i]
+		kind, ok := generators[name]
+		if !ok || kind != obj.GetObjectKind().GroupVersionKind().Kind || len(validation.IsValidLabelValue(name)) != 0 {
+			continue
+		}
+		meta.AddLabels(obj, map[string]string{resource.GeneratedFromLabelKey: name})
+	}
+}
+
 func (o *Engine) prepareOverlay(k *kustomizeapi.Kustomization, extraFiles []OverlayFile) (string, error) {
 	// NOTE(muvaf): Kustomize does not work with symlinked paths, so, we're
 	// using their temp directory generation function that handles this instead
@@ -123,3 +123,32 @@ func parse(path string) *unstructured.Unstructured {
 	}
 	return u
 }
+
+func TestLabelGenerated(t *testing.T) {
+	k := &types.Kustomization{
+		NamePrefix: "cool-",
+		ConfigMapGenerator: []types.ConfigMapArgs{
+			{GeneratorArgs: types.GeneratorArgs{Name: "parameters"}},
+		},
+	}
+	object := func(kind, name string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind(kind)
+		u.SetName(name)
+		return u
+	}
+	generated := object("ConfigMap", "cool-parameters-5h7bm9kt2c")
+	notHashed := object("ConfigMap", "cool-parameters")
+	otherKind := object("Secret", "cool-parameters-5h7bm9kt2c")
+	labelGenerated(k, []resource.ChildResource{generated, notHashed, otherKind})
+
+	if diff := cmp.Diff(map[string]string{resource.GeneratedFromLabelKey: "cool-parameters"}, generated.GetLabels()); diff != "" {
+		t.Errorf("labelGenerated(...): -want, +got:\n%s", diff)
+	}
+	for _, o := range []*unstructured.Unstructured{notHashed, otherKind} {
+		if _, ok := o.GetLabels()[resource.GeneratedFromLabelKey]; ok {
+			t.Errorf("labelGenerated(...): %s %s should not be labeled", o.GetKind(), o.GetName())
+		}
+	}
+}
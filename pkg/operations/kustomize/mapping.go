@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomize
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldMapping is a field of the parent resource whose value is set on a
+// field of a child resource.
+type FieldMapping struct {
+	// From is the path of the field of the parent resource, e.g.
+	// spec.parameters.port.
+	From string
+
+	// Target is the child resource whose field is set. Its name is the one
+	// in the templates, i.e. without the name prefix of the parent resource.
+	Target JSON6902Target
+
+	// To is the path of the field of the child resource, e.g.
+	// spec.ports.0.port.
+	To string
+}
+
+// Mappings returns the fields of the parent resource that the bindings of the
+// overlays set on the child resources.
+func (pog PatchOverlayGenerator) Mappings() []FieldMapping {
+	var result []FieldMapping
+	for _, overlay := range pog.Overlays {
+		target := JSON6902Target{APIVersion: overlay.APIVersion, Kind: overlay.Kind, Name: overlay.Name}
+		for _, binding := range overlay.Bindings {
+			result = append(result, FieldMapping{From: binding.From, Target: target, To: binding.To})
+		}
+	}
+	return result
+}
+
+// Mappings returns the fields of the parent resource that the operations of
+// the patches take their values from. The operations with static values are
+// left out.
+func (jg JSON6902PatchGenerator) Mappings() []FieldMapping {
+	var result []FieldMapping
+	for _, patch := range jg.Patches {
+		for _, op := range patch.Operations {
+			if op.ValueFrom == "" {
+				continue
+			}
+			result = append(result, FieldMapping{From: op.ValueFrom, Target: patch.Target, To: pointerToPath(op.Path)})
+		}
+	}
+	return result
+}
+
+// pointerToPath converts a JSON pointer, e.g. /metadata/labels/app~1tier, to
+// the dotted form of the paths of the parent resource.
+func pointerToPath(pointer string) string {
+	segments := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, s := range segments {
+		segments[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(s)
+	}
+	return strings.Join(segments, ".")
+}
+
+// DescribeMappings returns the child resource fields that every field of the
+// parent resource is mapped to, one parent field per paragraph, sorted by
+// path, to be shown to the users of the pack. The given fields, e.g. the ones
+// of the parameters schema, are listed even if nothing is mapped from them so
+// that it is visible which fields do not influence the child resources
+// through the mappings.
+func DescribeMappings(fields []string, mappings []FieldMapping) string {
+	byField := map[string][]FieldMapping{}
+	for _, f := range fields {
+		byField[f] = nil
+	}
+	for _, m := range mappings {
+		byField[m.From] = append(byField[m.From], m)
+	}
+	paths := make([]string, 0, len(byField))
+	for p := range byField {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	b := &strings.Builder{}
+	for _, p := range paths {
+		if len(byField[p]) == 0 {
+			fmt.Fprintf(b, "%s: not mapped\n", p)
+			continue
+		}
+		fmt.Fprintf(b, "%s:\n", p)
+		for _, m := range byField[p] {
+			fmt.Fprintf(b, "  %s %s %s: %s\n", m.Target.Kind, m.Target.APIVersion, describeTarget(m.Target), m.To)
+		}
+	}
+	return b.String()
+}
+
+func describeTarget(t JSON6902Target) string {
+	if t.Namespace == "" {
+		return t.Name
+	}
+	return t.Namespace + "/" + t.Name
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomize
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane/apis/packages/v1alpha1"
+)
+
+func TestDescribeMappings(t *testing.T) {
+	overlays := NewPatchOverlayGenerator([]v1alpha1.KustomizeEngineOverlay{
+		{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       "db",
+			Bindings: []v1alpha1.FieldBinding{
+				{From: "spec.parameters.replicas", To: "spec.replicas"},
+			},
+		},
+	})
+	patches := NewJSON6902PatchGenerator([]JSON6902Patch{
+		{
+			Target: JSON6902Target{APIVersion: "v1", Kind: "Service", Name: "db", Namespace: "data"},
+			Operations: []JSON6902Operation{
+				{Op: "replace", Path: "/spec/ports/0/port", ValueFrom: "spec.parameters.port"},
+				{Op: "add", Path: "/metadata/labels/tier", Value: "data"},
+				{Op: "add", Path: "/metadata/annotations/example.org~1replicas", ValueFrom: "spec.parameters.replicas"},
+			},
+		},
+	})
+	mappings := append(overlays.Mappings(), patches.Mappings()...)
+	fields := []string{"spec.parameters.port", "spec.parameters.version"}
+	want := `spec.parameters.port:
+  Service v1 data/db: spec.ports.0.port
+spec.parameters.replicas:
+  Deployment apps/v1 db: spec.replicas
+  Service v1 data/db: metadata.annotations.example.org/replicas
+spec.parameters.version: not mapped
+`
+	if diff := cmp.Diff(want, DescribeMappings(fields, mappings)); diff != "" {
+		t.Errorf("DescribeMappings(...): -want, +got:\n%s", diff)
+	}
+}
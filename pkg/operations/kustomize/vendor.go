@@ -0,0 +1,248 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomize
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/templating-controller/pkg/operations"
+)
+
+const (
+	errReadKustomization  = "cannot read kustomization"
+	errWriteKustomization = "cannot write kustomization"
+	errFetchRemoteBase    = "cannot fetch remote base"
+
+	// RemoteBasesDir is the directory of the pack that the remote bases are
+	// fetched into by the RemoteBaseVendorer.
+	RemoteBasesDir = "_remote"
+)
+
+// kustomizationFileNames are the names that Kustomize recognizes as
+// kustomization files.
+var kustomizationFileNames = map[string]bool{
+	"kustomization.yaml": true,
+	"kustomization.yml":  true,
+	"Kustomization":      true,
+}
+
+// RemoteBase is a reference of a kustomization to a directory of a remote git
+// repository, e.g. github.com/org/repo//deploy/base?ref=v1.0.0.
+type RemoteBase struct {
+	// Repository is the URL to clone the repository from.
+	Repository string
+
+	// Path is the directory in the repository.
+	Path string
+
+	// Ref is the branch, tag or commit, if any.
+	Ref string
+}
+
+// IsRemoteBase returns true if the given entry of the resources or bases of
+// a kustomization refers to a remote git repository rather than a local
+// file or directory.
+func IsRemoteBase(s string) bool {
+	for _, p := range []string{"git::", "git@", "ssh://", "https://", "http://", "github.com/", "gitlab.com/", "bitbucket.org/"} {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRemoteBase parses a remote base in the forms that Kustomize accepts,
+// i.e. a repository URL followed by // and the path in the repository, or a
+// github.com, gitlab.com or bitbucket.org URL whose path follows the owner and
+// the name of the repository, with an optional ref query.
+func ParseRemoteBase(s string) RemoteBase {
+	rb := RemoteBase{}
+	s = strings.TrimPrefix(s, "git::")
+	if i := strings.Index(s, "?"); i >= 0 {
+		for _, kv := range strings.Split(s[i+1:], "&") {
+			if v := strings.TrimPrefix(kv, "ref="); v != kv {
+				rb.Ref = v
+			}
+			if v := strings.TrimPrefix(kv, "version="); v != kv {
+				rb.Ref = v
+			}
+		}
+		s = s[:i]
+	}
+	scheme := ""
+	if i := strings.Index(s, "://"); i >= 0 {
+		scheme, s = s[:i+3], s[i+3:]
+	}
+	switch {
+	case strings.Contains(s, "//"):
+		i := strings.Index(s, "//")
+		rb.Repository, rb.Path = s[:i], s[i+2:]
+	case strings.HasPrefix(s, "github.com/") || strings.HasPrefix(s, "gitlab.com/") || strings.HasPrefix(s, "bitbucket.org/"):
+		parts := strings.SplitN(s, "/", 4)
+		if len(parts) == 4 {
+			rb.Path = parts[3]
+			parts = parts[:3]
+		}
+		rb.Repository = strings.Join(parts, "/")
+	default:
+		rb.Repository = s
+	}
+	switch {
+	case scheme != "":
+		rb.Repository = scheme + rb.Repository
+	case !strings.HasPrefix(rb.Repository, "git@"):
+		rb.Repository = "https://" + rb.Repository
+	}
+	rb.Path = strings.Trim(rb.Path, "/")
+	return rb
+}
+
+// NewRemoteBaseVendorer returns a new *RemoteBaseVendorer that fetches the
+// remote bases with the given operations.GitRunner.
+func NewRemoteBaseVendorer(git operations.GitRunner) *RemoteBaseVendorer {
+	return &RemoteBaseVendorer{git: git}
+}
+
+// RemoteBaseVendorer fetches the remote bases that the kustomizations of a
+// pack refer to in their resources and bases into RemoteBasesDir of the pack
+// and replaces the references with the relative paths of the fetched
+// directories. The kustomizations of the fetched repositories are vendored
+// too, so that no remote reference is left.
+type RemoteBaseVendorer struct {
+	git operations.GitRunner
+}
+
+// Vendor fetches the remote bases of the pack in the given directory.
+func (v *RemoteBaseVendorer) Vendor(ctx context.Context, dir string) error {
+	fetched := map[string]string{}
+	pending := []string{dir}
+	for len(pending) > 0 {
+		root := pending[0]
+		pending = pending[1:]
+		var files []string
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			// The repositories fetched while vendoring root are walked on
+			// their own.
+			if info.IsDir() && path != root && filepath.Dir(path) == dir && info.Name() == RemoteBasesDir {
+				return filepath.SkipDir
+			}
+			if !info.IsDir() && kustomizationFileNames[info.Name()] {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			repos, err := v.vendorFile(ctx, dir, f, fetched)
+			if err != nil {
+				return err
+			}
+			pending = append(pending, repos...)
+		}
+	}
+	return nil
+}
+
+// vendorFile replaces the remote bases of the given kustomization file with
+// the relative paths of their fetched directories. It returns the
+// repositories that were fetched for the first time.
+func (v *RemoteBaseVendorer) vendorFile(ctx context.Context, dir, file string, fetched map[string]string) ([]string, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, errors.Wrap(err, errReadKustomization)
+	}
+	k := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &k); err != nil {
+		return nil, errors.Wrapf(err, "%s %s", errReadKustomization, file)
+	}
+	var repos []string
+	changed := false
+	for _, field := range []string{"resources", "bases"} {
+		entries, ok := k[field].([]interface{})
+		if !ok {
+			continue
+		}
+		for i, e := range entries {
+			s, ok := e.(string)
+			if !ok || !IsRemoteBase(s) {
+				continue
+			}
+			rb := ParseRemoteBase(s)
+			key := fmt.Sprintf("%x", sha256.Sum256([]byte(rb.Repository+"?ref="+rb.Ref)))[:16]
+			repo, ok := fetched[key]
+			if !ok {
+				repo = filepath.Join(dir, RemoteBasesDir, key)
+				if err := v.fetch(ctx, repo, rb); err != nil {
+					return nil, errors.Wrapf(err, "%s %s", errFetchRemoteBase, s)
+				}
+				fetched[key] = repo
+				repos = append(repos, repo)
+			}
+			rel, err := filepath.Rel(filepath.Dir(file), filepath.Join(repo, filepath.FromSlash(rb.Path)))
+			if err != nil {
+				return nil, err
+			}
+			entries[i] = filepath.ToSlash(rel)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, nil
+	}
+	out, err := yaml.Marshal(k)
+	if err != nil {
+		return nil, errors.Wrap(err, errWriteKustomization)
+	}
+	return repos, errors.Wrap(ioutil.WriteFile(file, out, 0600), errWriteKustomization)
+}
+
+// fetch fetches the given ref, or the default branch, of the repository into
+// the given directory without its history. Fetching the ref works for
+// branches, tags and commits alike.
+func (v *RemoteBaseVendorer) fetch(ctx context.Context, dir string, rb RemoteBase) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	ref := rb.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"fetch", "-q", "--depth", "1", rb.Repository, ref},
+		{"checkout", "-q", "FETCH_HEAD"},
+	} {
+		if _, err := v.git(ctx, dir, args...); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(filepath.Join(dir, ".git"))
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomize
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRemoteBase(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		s      string
+		want   RemoteBase
+	}{
+		"DoubleSlash": {
+			reason: "The path should follow the double slash.",
+			s:      "https://example.com/org/repo.git//deploy/base?ref=v1.0.0",
+			want:   RemoteBase{Repository: "https://example.com/org/repo.git", Path: "deploy/base", Ref: "v1.0.0"},
+		},
+		"GitHub": {
+			reason: "The path of GitHub repositories should follow the owner and the name.",
+			s:      "github.com/org/repo/deploy/base?ref=main",
+			want:   RemoteBase{Repository: "https://github.com/org/repo", Path: "deploy/base", Ref: "main"},
+		},
+		"SSH": {
+			reason: "SSH repositories should be kept as they are.",
+			s:      "git@github.com:org/repo//base",
+			want:   RemoteBase{Repository: "git@github.com:org/repo", Path: "base"},
+		},
+		"GitPrefix": {
+			reason: "The git:: prefix should be dropped.",
+			s:      "git::https://example.com/repo?version=abc123",
+			want:   RemoteBase{Repository: "https://example.com/repo", Ref: "abc123"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ParseRemoteBase(tc.s)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nParseRemoteBase(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRemoteBaseVendorer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint:errcheck
+	k := "resources:\n- deployment.yaml\n- github.com/org/repo/base?ref=v1\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte(k), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var fetched []string
+	git := func(_ context.Context, wd string, args ...string) (string, error) {
+		if args[0] != "checkout" {
+			return "", nil
+		}
+		fetched = append(fetched, wd)
+		// The fetched base refers to the same repository, which should not
+		// be fetched again.
+		base := filepath.Join(wd, "base")
+		if err := os.MkdirAll(base, 0755); err != nil {
+			return "", err
+		}
+		return "", ioutil.WriteFile(filepath.Join(base, "kustomization.yaml"), []byte("bases:\n- github.com/org/repo/common?ref=v1\n"), 0600)
+	}
+	if err := NewRemoteBaseVendorer(git).Vendor(context.Background(), dir); err != nil {
+		t.Fatalf("Vendor(...): %s", err)
+	}
+	if len(fetched) != 1 {
+		t.Fatalf("Vendor(...): want the repository to be fetched once, got %d times", len(fetched))
+	}
+	repo, _ := filepath.Rel(dir, fetched[0])
+	got, _ := ioutil.ReadFile(filepath.Join(dir, "kustomization.yaml"))
+	want := "resources:\n- deployment.yaml\n- " + filepath.ToSlash(repo) + "/base\n"
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("Vendor(...): -want kustomization, +got kustomization:\n%s", diff)
+	}
+	got, _ = ioutil.ReadFile(filepath.Join(fetched[0], "base", "kustomization.yaml"))
+	if diff := cmp.Diff("bases:\n- ../common\n", string(got)); diff != "" {
+		t.Errorf("Vendor(...): -want fetched kustomization, +got fetched kustomization:\n%s", diff)
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errPrepareInput     = "cannot prepare the input of the render"
+	errValuesProvider   = "cannot get values from values provider"
+	errExpandComposites = "cannot expand composite child resources"
+	errRender           = "templating operation failed"
+	errPatch            = "child resource patchers failed"
+	errPreApplyHook     = "pre-apply hook failed"
+	errPostApplyHook    = "post-apply hook failed"
+)
+
+// ApplyOutcome is what the apply of the child resources of a parent resource
+// left to be done.
+type ApplyOutcome struct {
+	// Waiting are the child resources that wait for their dependencies to
+	// become ready and were not applied.
+	Waiting []resource.ChildResource
+
+	// NotReady are the applied child resources that are not ready yet.
+	NotReady []resource.ChildResource
+
+	// Deferred are the child resources left to the next apply, e.g. since
+	// the apply budget is spent.
+	Deferred []resource.ChildResource
+
+	// RequeueAfter is the time after which to apply again on the readiness
+	// of the child resources, zero if unknown.
+	RequeueAfter time.Duration
+}
+
+// A PipelineOption configures a Pipeline.
+type PipelineOption func(*Pipeline)
+
+// WithEngine specifies the Engine that renders the child resources.
+func WithEngine(e Engine) PipelineOption {
+	return func(p *Pipeline) {
+		p.engine = e
+	}
+}
+
+// WithChildResourcePatchers specifies the ChildResourcePatchers that run on
+// the rendered child resources, in order.
+func WithChildResourcePatchers(pc ...ChildResourcePatcher) PipelineOption {
+	return func(p *Pipeline) {
+		p.patchers = append(p.patchers, pc...)
+	}
+}
+
+// WithMiddleware specifies the Middleware that wraps the stages, the first
+// one being the outermost.
+func WithMiddleware(m ...Middleware) PipelineOption {
+	return func(p *Pipeline) {
+		p.middleware = append(p.middleware, m...)
+	}
+}
+
+// WithValuesProviders specifies the ValuesProviders whose values are set on
+// the render input.
+func WithValuesProviders(v ...ValuesProvider) PipelineOption {
+	return func(p *Pipeline) {
+		p.values = append(p.values, v...)
+	}
+}
+
+// WithInputPreparer specifies the InputPreparer that prepares the render
+// input before the values are set on it.
+func WithInputPreparer(ip InputPreparer) PipelineOption {
+	return func(p *Pipeline) {
+		p.input = ip
+	}
+}
+
+// WithCompositeKinds specifies the kinds of the rendered child resources that
+// are expanded into the child resources they list.
+func WithCompositeKinds(kinds resource.CompositeKinds) PipelineOption {
+	return func(p *Pipeline) {
+		p.composites = kinds
+	}
+}
+
+// WithPreApplyHooks specifies the Hooks that run before the child resources
+// are applied.
+func WithPreApplyHooks(h ...Hook) PipelineOption {
+	return func(p *Pipeline) {
+		p.preApply = append(p.preApply, h...)
+	}
+}
+
+// WithPostApplyHooks specifies the Hooks that run once all child resources
+// are applied.
+func WithPostApplyHooks(h ...Hook) PipelineOption {
+	return func(p *Pipeline) {
+		p.postApply = append(p.postApply, h...)
+	}
+}
+
+// WithApplier specifies the Applier that applies the child resources.
+func WithApplier(a Applier) PipelineOption {
+	return func(p *Pipeline) {
+		p.applier = a
+	}
+}
+
+// NewPipeline returns a new *Pipeline configured with the given options. It
+// renders nothing and applies nothing unless given an Engine and an Applier.
+func NewPipeline(options ...PipelineOption) *Pipeline {
+	p := &Pipeline{
+		engine: EngineFunc(func(_ resource.ParentResource) ([]resource.ChildResource, error) {
+			return nil, nil
+		}),
+		input: InputPreparerFunc(func(_ context.Context, cr resource.ParentResource) (resource.ParentResource, error) {
+			return cr, nil
+		}),
+		applier: ApplierFunc(func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) (ApplyOutcome, error) {
+			return ApplyOutcome{}, nil
+		}),
+	}
+	for _, opt := range options {
+		opt(p)
+	}
+	return p
+}
+
+// Pipeline renders the child resources of a parent resource with an Engine,
+// patches them with ChildResourcePatchers and applies them with an Applier,
+// each stage within the Middleware. It neither fetches the parent resource nor
+// sets its conditions, which is left to its caller, e.g. a reconciler.
+type Pipeline struct {
+	engine     Engine
+	patchers   ChildResourcePatcherChain
+	middleware MiddlewareChain
+	values     ValuesProviderChain
+	input      InputPreparer
+	composites resource.CompositeKinds
+	preApply   HookChain
+	postApply  HookChain
+	applier    Applier
+}
+
+// Children returns the child resources of the given parent resource rendered
+// and patched, without applying them.
+func (p *Pipeline) Children(ctx context.Context, cr resource.ParentResource) ([]resource.ChildResource, error) {
+	input, err := p.Input(ctx, cr)
+	if err != nil {
+		return nil, errors.Wrap(err, errPrepareInput)
+	}
+	list, err := p.Render(ctx, input)
+	if err != nil {
+		if resource.Classify(err) == resource.FailureUnknown {
+			err = &resource.RenderError{Err: err}
+		}
+		return nil, errors.Wrap(err, errRender)
+	}
+	list, err = p.Patch(ctx, input, list)
+	return list, errors.Wrap(err, errPatch)
+}
+
+// Input returns the render input of the given parent resource, which is a
+// copy of it prepared by the InputPreparer and decorated with the values of
+// the ValuesProviders.
+func (p *Pipeline) Input(ctx context.Context, cr resource.ParentResource) (resource.ParentResource, error) {
+	return p.InputWith(ctx, cr, p.values)
+}
+
+// InputWith returns the render input of the given parent resource decorated
+// with the values of the given ValuesProviders instead of the configured ones.
+func (p *Pipeline) InputWith(ctx context.Context, cr resource.ParentResource, values ValuesProviderChain) (resource.ParentResource, error) {
+	cr, err := p.input.Prepare(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return cr, nil
+	}
+	vals, err := values.Values(ctx, cr)
+	if err != nil {
+		return nil, errors.Wrap(err, errValuesProvider)
+	}
+	return InputWithValues(cr, vals)
+}
+
+// Render runs the Engine on the given render input within the Middleware,
+// expands the composite child resources and sorts the rendered child
+// resources with resource.SortChildren.
+func (p *Pipeline) Render(ctx context.Context, input resource.ParentResource) ([]resource.ChildResource, error) {
+	return p.middleware.Wrap(StageRender, func(_ context.Context, input resource.ParentResource, _ []resource.ChildResource) ([]resource.ChildResource, error) {
+		list, err := p.engine.Run(input)
+		if err != nil {
+			return nil, err
+		}
+		list, err = resource.ExpandComposites(list, p.composites)
+		if err != nil {
+			return nil, &resource.RenderError{Err: errors.Wrap(err, errExpandComposites)}
+		}
+		resource.SortChildren(list)
+		return list, nil
+	})(ctx, input, nil)
+}
+
+// Patch runs the ChildResourcePatchers on the given child resources within
+// the Middleware.
+func (p *Pipeline) Patch(ctx context.Context, input resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	return p.middleware.Wrap(StagePatch, func(_ context.Context, input resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+		return p.patchers.Patch(input, list)
+	})(ctx, input, list)
+}
+
+// Apply runs the pre-apply hooks, applies the given child resources of the
+// given parent resource and runs the post-apply hooks once none of them is
+// waiting or deferred.
+func (p *Pipeline) Apply(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) (ApplyOutcome, error) {
+	if err := p.PreApply(ctx, cr, list); err != nil {
+		return ApplyOutcome{}, errors.Wrap(err, errPreApplyHook)
+	}
+	o, err := p.ApplyChildren(ctx, cr, list)
+	if err != nil || len(o.Waiting) > 0 || len(o.Deferred) > 0 {
+		return o, err
+	}
+	return o, errors.Wrap(p.PostApply(ctx, cr, list), errPostApplyHook)
+}
+
+// PreApply runs the pre-apply hooks.
+func (p *Pipeline) PreApply(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) error {
+	return p.preApply.Run(ctx, cr, list)
+}
+
+// ApplyChildren applies the given child resources with the Applier within the
+// Middleware, without running the hooks.
+func (p *Pipeline) ApplyChildren(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) (ApplyOutcome, error) {
+	o := ApplyOutcome{}
+	_, err := p.middleware.Wrap(StageApply, func(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+		var err error
+		o, err = p.applier.Apply(ctx, cr, list)
+		return list, err
+	})(ctx, cr, list)
+	return o, err
+}
+
+// PostApply runs the post-apply hooks.
+func (p *Pipeline) PostApply(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) error {
+	return p.postApply.Run(ctx, cr, list)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestPipelineChildren(t *testing.T) {
+	errBoom := errors.New("boom")
+	child := func(name string) resource.ChildResource {
+		return fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName(name, "ns"))
+	}
+	type want struct {
+		names []string
+		err   error
+	}
+	cases := map[string]struct {
+		reason string
+		opts   []PipelineOption
+		want   want
+	}{
+		"Rendered": {
+			reason: "The rendered child resources should be patched and sorted",
+			opts: []PipelineOption{
+				WithEngine(EngineFunc(func(_ resource.ParentResource) ([]resource.ChildResource, error) {
+					return []resource.ChildResource{child("b"), child("a")}, nil
+				})),
+				WithChildResourcePatchers(ChildResourcePatcherFunc(func(_ resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+					return append(list, child("c")), nil
+				})),
+			},
+			want: want{names: []string{"a", "b", "c"}},
+		},
+		"RenderFailed": {
+			reason: "A failed render should be reported as a render error",
+			opts: []PipelineOption{
+				WithEngine(EngineFunc(func(_ resource.ParentResource) ([]resource.ChildResource, error) {
+					return nil, errBoom
+				})),
+			},
+			want: want{err: errors.Wrap(&resource.RenderError{Err: errBoom}, errRender)},
+		},
+		"PatchStageWrapped": {
+			reason: "The patch stage should run within the Middleware",
+			opts: []PipelineOption{
+				WithMiddleware(MiddlewareFunc(func(s Stage, next StageFunc) StageFunc {
+					if s != StagePatch {
+						return next
+					}
+					return func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) ([]resource.ChildResource, error) {
+						return nil, errBoom
+					}
+				})),
+			},
+			want: want{err: errors.Wrap(errBoom, errPatch)},
+		},
+		"ValuesFailed": {
+			reason: "It should return error if the values cannot be provided",
+			opts: []PipelineOption{
+				WithValuesProviders(ValuesProviderFunc(func(_ context.Context, _ resource.ParentResource) (map[string]interface{}, error) {
+					return nil, errBoom
+				})),
+			},
+			want: want{err: errors.Wrap(errors.Wrap(errBoom, errValuesProvider), errPrepareInput)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			list, err := NewPipeline(tc.opts...).Children(context.Background(), fake.NewMockResource())
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nChildren(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			var names []string
+			for _, o := range list {
+				names = append(names, o.GetName())
+			}
+			if diff := cmp.Diff(tc.want.names, names); diff != "" {
+				t.Errorf("\nReason: %s\nChildren(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPipelineApply(t *testing.T) {
+	errBoom := errors.New("boom")
+	type want struct {
+		applied   bool
+		postApply bool
+		err       error
+	}
+	cases := map[string]struct {
+		reason   string
+		preApply error
+		outcome  ApplyOutcome
+		want     want
+	}{
+		"Applied": {
+			reason: "The post-apply hooks should run once the child resources are applied",
+			want:   want{applied: true, postApply: true},
+		},
+		"Waiting": {
+			reason:  "The post-apply hooks should not run while child resources wait for their dependencies",
+			outcome: ApplyOutcome{Waiting: []resource.ChildResource{fake.NewMockResource()}},
+			want:    want{applied: true},
+		},
+		"PreApplyFailed": {
+			reason:   "Nothing should be applied if a pre-apply hook fails",
+			preApply: errBoom,
+			want:     want{err: errors.Wrap(errBoom, errPreApplyHook)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := want{}
+			p := NewPipeline(
+				WithPreApplyHooks(HookFunc(func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) error {
+					return tc.preApply
+				})),
+				WithPostApplyHooks(HookFunc(func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) error {
+					got.postApply = true
+					return nil
+				})),
+				WithApplier(ApplierFunc(func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) (ApplyOutcome, error) {
+					got.applied = true
+					return tc.outcome, nil
+				})),
+			)
+			_, got.err = p.Apply(context.Background(), fake.NewMockResource(), []resource.ChildResource{fake.NewMockResource()})
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{}), test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nApply(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
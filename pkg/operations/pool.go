@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package operations contains the templating engines and what they share, and
+// the Pipeline that renders, patches and applies the child resources of a
+// parent resource with them. It does not depend on a controller manager so
+// that other controllers can run packs as a library.
+package operations
+
+import (
+	"sync"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+type job struct {
+	cr     resource.ParentResource
+	result chan<- result
+}
+
+type result struct {
+	list []resource.ChildResource
+	err  error
+}
+
+// NewWorkerPool returns a new *WorkerPool that runs the renders of the given
+// Engine in the given number of workers, at least one.
+func NewWorkerPool(e Engine, workers int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &WorkerPool{engine: e, jobs: make(chan job)}
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// WorkerPool is an Engine that runs the renders of another Engine in a fixed
+// number of workers, so that the number of renders that run at the same time,
+// and the memory they hold, is bounded by the pool rather than by the number
+// of workers of the controller. The renders of all parent resources share the
+// caches of the Engine, which has to be safe for concurrent use.
+type WorkerPool struct {
+	engine Engine
+	jobs   chan job
+	wg     sync.WaitGroup
+	close  sync.Once
+}
+
+// Run renders the child resources of the given parent resource once a worker
+// is free.
+func (p *WorkerPool) Run(cr resource.ParentResource) ([]resource.ChildResource, error) {
+	ch := make(chan result, 1)
+	p.jobs <- job{cr: cr, result: ch}
+	r := <-ch
+	return r.list, r.err
+}
+
+// Close stops the workers once they finish their renders. Run must not be
+// called after Close.
+func (p *WorkerPool) Close() {
+	p.close.Do(func() { close(p.jobs) })
+	p.wg.Wait()
+}
+
+func (p *WorkerPool) work() {
+	defer p.wg.Done()
+	for j := range p.jobs {
+		list, err := p.engine.Run(j.cr)
+		j.result <- result{list: list, err: err}
+	}
+}
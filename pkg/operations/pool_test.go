@@ -0,0 +1,78 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+func TestWorkerPool(t *testing.T) {
+	errBoom := errors.New("boom")
+	var mu sync.Mutex
+	active, max := 0, 0
+	release := make(chan struct{})
+	e := EngineFunc(func(cr resource.ParentResource) ([]resource.ChildResource, error) {
+		mu.Lock()
+		if active++; active > max {
+			max = active
+		}
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		active--
+		mu.Unlock()
+		if cr.GetName() == "failing" {
+			return nil, errBoom
+		}
+		return []resource.ChildResource{&unstructured.Unstructured{}}, nil
+	})
+	p := NewWorkerPool(e, 2)
+	defer p.Close()
+
+	names := []string{"a", "b", "c", "d", "failing"}
+	errs := make([]error, len(names))
+	wg := sync.WaitGroup{}
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			cr := &unstructured.Unstructured{}
+			cr.SetName(name)
+			_, errs[i] = p.Run(cr)
+		}(i, name)
+	}
+	for range names {
+		release <- struct{}{}
+	}
+	wg.Wait()
+
+	if max > 2 {
+		t.Errorf("Run(...): want at most 2 renders at the same time, got %d", max)
+	}
+	if diff := cmp.Diff([]error{nil, nil, nil, nil, errBoom}, errs, test.EquateErrors()); diff != "" {
+		t.Errorf("Run(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errInitializeValues = "cannot initialize values of values provider"
+	errSetValues        = "cannot set values on the render input"
+	errCopyParent       = "cannot copy parent resource"
+)
+
+// InputWithValues returns a copy of the given parent resource with the given
+// values set under spec.parameters. The values take precedence over the
+// parameters with the same name that the user supplied. The values must be
+// JSON compatible, i.e. strings, int64s, float64s, bools, maps and slices of
+// them.
+func InputWithValues(cr resource.ParentResource, vals map[string]interface{}) (resource.ParentResource, error) {
+	input, ok := cr.DeepCopyObject().(resource.ParentResource)
+	if !ok {
+		return nil, errors.New(errCopyParent)
+	}
+	for k, v := range vals {
+		if err := unstructured.SetNestedField(input.UnstructuredContent(), v, "spec", resource.ParametersField, k); err != nil {
+			return nil, errors.Wrap(err, errSetValues)
+		}
+	}
+	return input, nil
+}
+
+// WithoutSecrets returns the ValuesProviders of the chain without the
+// SecretValuesProviders that provide secrets, including the ones of the nested
+// ValuesProviderChains.
+func (vc ValuesProviderChain) WithoutSecrets() ValuesProviderChain {
+	result := ValuesProviderChain{}
+	for _, v := range vc {
+		if s, ok := v.(SecretValuesProvider); ok && s.ProvidesSecrets() {
+			continue
+		}
+		if nested, ok := v.(ValuesProviderChain); ok {
+			v = nested.WithoutSecrets()
+		}
+		result = append(result, v)
+	}
+	return result
+}
+
+// Initialize initializes the values of the ValuesInitializers of the chain,
+// including the ones of the nested ValuesProviderChains, for the given parent
+// resource. It returns the chain with the ValuesInitializers replaced by their
+// initialized values.
+func (vc ValuesProviderChain) Initialize(ctx context.Context, cr resource.ParentResource) (ValuesProviderChain, error) {
+	result := make(ValuesProviderChain, 0, len(vc))
+	for _, v := range vc {
+		switch p := v.(type) {
+		case ValuesInitializer:
+			vals, err := p.InitializeValues(ctx, cr)
+			if err != nil {
+				return nil, errors.Wrap(err, errInitializeValues)
+			}
+			v = ValuesProviderFunc(func(_ context.Context, _ resource.ParentResource) (map[string]interface{}, error) {
+				return vals, nil
+			})
+		case ValuesProviderChain:
+			nested, err := p.Initialize(ctx, cr)
+			if err != nil {
+				return nil, err
+			}
+			v = nested
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+// mockInitializer is a ValuesInitializer that initializes its values with a
+// function.
+type mockInitializer struct {
+	ValuesProviderFunc
+	initialize func(ctx context.Context, cr resource.ParentResource) (map[string]interface{}, error)
+}
+
+func (m mockInitializer) InitializeValues(ctx context.Context, cr resource.ParentResource) (map[string]interface{}, error) {
+	return m.initialize(ctx, cr)
+}
+
+func TestInputWithValues(t *testing.T) {
+	cr := fake.NewMockResource()
+	_ = unstructured.SetNestedField(cr.Object, map[string]interface{}{"size": "small", "generated": "user"}, "spec", resource.ParametersField)
+
+	input, err := InputWithValues(cr, map[string]interface{}{"generated": map[string]interface{}{"password": "secret"}})
+	if err != nil {
+		t.Fatalf("InputWithValues(...): unexpected error: %s", err)
+	}
+	want := map[string]interface{}{"size": "small", "generated": map[string]interface{}{"password": "secret"}}
+	got, _, _ := unstructured.NestedMap(input.UnstructuredContent(), "spec", resource.ParametersField)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("InputWithValues(...): -want, +got:\n%s", diff)
+	}
+	orig, _, _ := unstructured.NestedMap(cr.Object, "spec", resource.ParametersField)
+	if diff := cmp.Diff(map[string]interface{}{"size": "small", "generated": "user"}, orig); diff != "" {
+		t.Errorf("InputWithValues(...): the given parent resource should not be changed: -want, +got:\n%s", diff)
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	errBoom := errors.New("boom")
+	static := ValuesProviderFunc(func(_ context.Context, _ resource.ParentResource) (map[string]interface{}, error) {
+		return map[string]interface{}{"size": "small"}, nil
+	})
+	initializer := mockInitializer{
+		ValuesProviderFunc: func(_ context.Context, _ resource.ParentResource) (map[string]interface{}, error) {
+			return nil, errBoom
+		},
+		initialize: func(_ context.Context, _ resource.ParentResource) (map[string]interface{}, error) {
+			return map[string]interface{}{"generated": "initialized"}, nil
+		},
+	}
+	values, err := ValuesProviderChain{static, ValuesProviderChain{initializer}}.Initialize(context.Background(), fake.NewMockResource())
+	if diff := cmp.Diff(nil, err, test.EquateErrors()); diff != "" {
+		t.Fatalf("Initialize(...): -want error, +got error:\n%s", diff)
+	}
+	got, err := values.Values(context.Background(), fake.NewMockResource())
+	if diff := cmp.Diff(nil, err, test.EquateErrors()); diff != "" {
+		t.Fatalf("Values(...): the initialized values should be provided instead of the ones of Values: -want error, +got error:\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"size": "small", "generated": "initialized"}, got); diff != "" {
+		t.Errorf("Values(...): -want, +got:\n%s", diff)
+	}
+}
+
+type secretValues struct {
+	ValuesProviderFunc
+}
+
+func (secretValues) ProvidesSecrets() bool { return true }
+
+func TestWithoutSecrets(t *testing.T) {
+	static := ValuesProviderFunc(func(_ context.Context, _ resource.ParentResource) (map[string]interface{}, error) {
+		return map[string]interface{}{"size": "small"}, nil
+	})
+	secret := secretValues{func(_ context.Context, _ resource.ParentResource) (map[string]interface{}, error) {
+		return map[string]interface{}{"password": "secret"}, nil
+	}}
+	got, err := ValuesProviderChain{static, ValuesProviderChain{secret}}.WithoutSecrets().Values(context.Background(), fake.NewMockResource())
+	if diff := cmp.Diff(nil, err, test.EquateErrors()); diff != "" {
+		t.Fatalf("Values(...): -want error, +got error:\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"size": "small"}, got); diff != "" {
+		t.Errorf("WithoutSecrets(...): the values of the providers of secrets should be left out: -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+)
+
+// The fields under spec of a parent resource that ParentAccessor reads.
+const (
+	ProviderReferenceField                = "providerRef"
+	WriteConnectionSecretToReferenceField = "writeConnectionSecretToRef"
+	ClassReferenceField                   = "classRef"
+	DeletionPolicyField                   = "deletionPolicy"
+)
+
+const (
+	errParseParentSpec       = "cannot parse spec of parent resource"
+	errParseParentField      = "cannot parse field of parent resource"
+	errUnknownDeletionPolicy = "unknown deletion policy"
+)
+
+// DeletionPolicy decides what happens to the child resources when their
+// parent resource is deleted.
+type DeletionPolicy string
+
+// Deletion policies.
+const (
+	// DeletionDelete deletes the child resources with the parent resource. It
+	// is the default.
+	DeletionDelete DeletionPolicy = "Delete"
+
+	// DeletionOrphan leaves the child resources behind.
+	DeletionOrphan DeletionPolicy = "Orphan"
+)
+
+// Accessor returns the ParentAccessor of the given parent resource, which is
+// the parent resource itself if it implements ParentAccessor.
+func Accessor(cr ParentResource) ParentAccessor {
+	if a, ok := cr.(ParentAccessor); ok {
+		return a
+	}
+	return NewUnstructuredParentAccessor(cr.UnstructuredContent())
+}
+
+// NewUnstructuredParentAccessor returns a new UnstructuredParentAccessor that
+// reads the given unstructured content of a parent resource.
+func NewUnstructuredParentAccessor(content map[string]interface{}) UnstructuredParentAccessor {
+	return UnstructuredParentAccessor{content: content}
+}
+
+// UnstructuredParentAccessor is a ParentAccessor that reads every field from
+// the unstructured content of a parent resource when it is asked for. Each
+// field is parsed on its own, so a malformed field fails only its readers.
+type UnstructuredParentAccessor struct {
+	content map[string]interface{}
+}
+
+// GetParameters returns spec.parameters, or nil if there are none.
+func (a UnstructuredParentAccessor) GetParameters() (map[string]interface{}, error) {
+	p, _, err := unstructured.NestedMap(a.content, "spec", ParametersField)
+	return p, errors.Wrapf(err, "%s %s", errParseParentField, ParametersField)
+}
+
+// GetProviderReference returns spec.providerRef, or nil if there is none.
+func (a UnstructuredParentAccessor) GetProviderReference() (*v1alpha1.Reference, error) {
+	ref := &v1alpha1.Reference{}
+	ok, err := a.field(ProviderReferenceField, ref)
+	if !ok {
+		ref = nil
+	}
+	return ref, err
+}
+
+// GetWriteConnectionSecretToReference returns spec.writeConnectionSecretToRef,
+// or nil if there is none.
+func (a UnstructuredParentAccessor) GetWriteConnectionSecretToReference() (*v1alpha1.SecretReference, error) {
+	ref := &v1alpha1.SecretReference{}
+	ok, err := a.field(WriteConnectionSecretToReferenceField, ref)
+	if !ok {
+		ref = nil
+	}
+	return ref, err
+}
+
+// GetClassReference returns spec.classRef, or nil if there is none.
+func (a UnstructuredParentAccessor) GetClassReference() (*corev1.ObjectReference, error) {
+	ref := &corev1.ObjectReference{}
+	ok, err := a.field(ClassReferenceField, ref)
+	if !ok {
+		ref = nil
+	}
+	return ref, err
+}
+
+// GetDeletionPolicy returns spec.deletionPolicy, defaulting to DeletionDelete.
+func (a UnstructuredParentAccessor) GetDeletionPolicy() (DeletionPolicy, error) {
+	p, _, err := unstructured.NestedString(a.content, "spec", DeletionPolicyField)
+	if err != nil {
+		return "", errors.Wrapf(err, "%s %s", errParseParentField, DeletionPolicyField)
+	}
+	return parseDeletionPolicy(DeletionPolicy(p))
+}
+
+// field decodes the given field under spec into the given object and returns
+// false if the field is not set.
+func (a UnstructuredParentAccessor) field(name string, into interface{}) (bool, error) {
+	m, ok, err := unstructured.NestedMap(a.content, "spec", name)
+	if err != nil || !ok {
+		return false, errors.Wrapf(err, "%s %s", errParseParentField, name)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, into); err != nil {
+		return false, errors.Wrapf(err, "%s %s", errParseParentField, name)
+	}
+	return true, nil
+}
+
+// ParentSpec is the typed form of the fields under spec of a parent resource
+// that ParentAccessor reads.
+type ParentSpec struct {
+	Parameters                       map[string]interface{}    `json:"parameters,omitempty"`
+	ProviderReference                *v1alpha1.Reference       `json:"providerRef,omitempty"`
+	WriteConnectionSecretToReference *v1alpha1.SecretReference `json:"writeConnectionSecretToRef,omitempty"`
+	ClassReference                   *corev1.ObjectReference   `json:"classRef,omitempty"`
+	DeletionPolicy                   DeletionPolicy            `json:"deletionPolicy,omitempty"`
+}
+
+// NewTypedParentAccessor returns a new *TypedParentAccessor that parses the
+// spec of the given parent resource once.
+func NewTypedParentAccessor(cr interface{ UnstructuredContent() map[string]interface{} }) (*TypedParentAccessor, error) {
+	a := &TypedParentAccessor{}
+	spec, _, err := unstructured.NestedMap(cr.UnstructuredContent(), "spec")
+	if err != nil {
+		return nil, errors.Wrap(err, errParseParentSpec)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(spec, &a.Spec); err != nil {
+		return nil, errors.Wrap(err, errParseParentSpec)
+	}
+	if _, err := parseDeletionPolicy(a.Spec.DeletionPolicy); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// TypedParentAccessor is a ParentAccessor that serves the fields from a
+// ParentSpec, which makes it cheap for the patchers to read them many times
+// and lets the Go types of parent resources embed it.
+type TypedParentAccessor struct {
+	Spec ParentSpec
+}
+
+// GetParameters returns the parameters.
+func (a *TypedParentAccessor) GetParameters() (map[string]interface{}, error) {
+	return a.Spec.Parameters, nil
+}
+
+// GetProviderReference returns the provider reference.
+func (a *TypedParentAccessor) GetProviderReference() (*v1alpha1.Reference, error) {
+	return a.Spec.ProviderReference, nil
+}
+
+// GetWriteConnectionSecretToReference returns the connection secret
+// reference.
+func (a *TypedParentAccessor) GetWriteConnectionSecretToReference() (*v1alpha1.SecretReference, error) {
+	return a.Spec.WriteConnectionSecretToReference, nil
+}
+
+// GetClassReference returns the resource class reference.
+func (a *TypedParentAccessor) GetClassReference() (*corev1.ObjectReference, error) {
+	return a.Spec.ClassReference, nil
+}
+
+// GetDeletionPolicy returns the deletion policy, defaulting to
+// DeletionDelete.
+func (a *TypedParentAccessor) GetDeletionPolicy() (DeletionPolicy, error) {
+	return parseDeletionPolicy(a.Spec.DeletionPolicy)
+}
+
+func parseDeletionPolicy(p DeletionPolicy) (DeletionPolicy, error) {
+	switch p {
+	case "":
+		return DeletionDelete, nil
+	case DeletionDelete, DeletionOrphan:
+		return p, nil
+	}
+	return "", errors.Errorf("%s: %s", errUnknownDeletionPolicy, p)
+}
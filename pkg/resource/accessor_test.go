@@ -0,0 +1,142 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+)
+
+var _ ParentAccessor = UnstructuredParentAccessor{}
+var _ ParentAccessor = &TypedParentAccessor{}
+
+func parent(spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+}
+
+func TestParentAccessors(t *testing.T) {
+	full := map[string]interface{}{
+		ParametersField:                       map[string]interface{}{"size": "small"},
+		ProviderReferenceField:                map[string]interface{}{"name": "aws"},
+		WriteConnectionSecretToReferenceField: map[string]interface{}{"name": "db-conn", "namespace": "apps"},
+		ClassReferenceField:                   map[string]interface{}{"apiVersion": "database.example.org/v1alpha1", "kind": "MySQLClass", "name": "standard"},
+		DeletionPolicyField:                   "Orphan",
+	}
+	type want struct {
+		params map[string]interface{}
+		prov   *v1alpha1.Reference
+		secret *v1alpha1.SecretReference
+		class  *corev1.ObjectReference
+		policy DeletionPolicy
+	}
+	cases := map[string]struct {
+		reason string
+		spec   map[string]interface{}
+		want   want
+	}{
+		"AllFields": {
+			reason: "Every field under spec should be read into its type",
+			spec:   full,
+			want: want{
+				params: map[string]interface{}{"size": "small"},
+				prov:   &v1alpha1.Reference{Name: "aws"},
+				secret: &v1alpha1.SecretReference{Name: "db-conn", Namespace: "apps"},
+				class:  &corev1.ObjectReference{APIVersion: "database.example.org/v1alpha1", Kind: "MySQLClass", Name: "standard"},
+				policy: DeletionOrphan,
+			},
+		},
+		"NoFields": {
+			reason: "Missing fields should be nil and the deletion policy should default to Delete",
+			spec:   map[string]interface{}{},
+			want:   want{policy: DeletionDelete},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			typed, err := NewTypedParentAccessor(parent(tc.spec))
+			if err != nil {
+				t.Fatalf("\n%s\nNewTypedParentAccessor(...): %s", tc.reason, err)
+			}
+			accessors := map[string]ParentAccessor{
+				"Unstructured": NewUnstructuredParentAccessor(parent(tc.spec).UnstructuredContent()),
+				"Typed":        typed,
+			}
+			for kind, a := range accessors {
+				got := want{}
+				var errs [5]error
+				got.params, errs[0] = a.GetParameters()
+				got.prov, errs[1] = a.GetProviderReference()
+				got.secret, errs[2] = a.GetWriteConnectionSecretToReference()
+				got.class, errs[3] = a.GetClassReference()
+				got.policy, errs[4] = a.GetDeletionPolicy()
+				for _, err := range errs {
+					if err != nil {
+						t.Errorf("\n%s\n%s: unexpected error: %s", tc.reason, kind, err)
+					}
+				}
+				if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+					t.Errorf("\n%s\n%s: -want, +got:\n%s", tc.reason, kind, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestUnstructuredParentAccessorMalformedField(t *testing.T) {
+	a := NewUnstructuredParentAccessor(parent(map[string]interface{}{
+		ClassReferenceField:                   "standard",
+		WriteConnectionSecretToReferenceField: map[string]interface{}{"name": "db-conn"},
+	}).UnstructuredContent())
+	if _, err := a.GetClassReference(); err == nil {
+		t.Errorf("GetClassReference(...): want error for a class reference that is not an object")
+	}
+	ref, err := a.GetWriteConnectionSecretToReference()
+	if err != nil {
+		t.Errorf("GetWriteConnectionSecretToReference(...): a malformed sibling field should not fail: %s", err)
+	}
+	if diff := cmp.Diff(&v1alpha1.SecretReference{Name: "db-conn"}, ref); diff != "" {
+		t.Errorf("GetWriteConnectionSecretToReference(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestNewTypedParentAccessorUnknownDeletionPolicy(t *testing.T) {
+	if _, err := NewTypedParentAccessor(parent(map[string]interface{}{DeletionPolicyField: "Retain"})); err == nil {
+		t.Errorf("NewTypedParentAccessor(...): want error for an unknown deletion policy")
+	}
+}
+
+type accessorResource struct {
+	*unstructured.Unstructured
+	*TypedParentAccessor
+}
+
+func TestAccessor(t *testing.T) {
+	u := parent(map[string]interface{}{DeletionPolicyField: "Orphan"})
+	if _, ok := Accessor(u).(UnstructuredParentAccessor); !ok {
+		t.Errorf("Accessor(...): want an UnstructuredParentAccessor for a parent resource that is not an accessor")
+	}
+	typed := &TypedParentAccessor{Spec: ParentSpec{DeletionPolicy: DeletionOrphan}}
+	cr := accessorResource{Unstructured: parent(nil), TypedParentAccessor: typed}
+	if got := Accessor(cr); got != ParentAccessor(cr) {
+		t.Errorf("Accessor(...): want the parent resource itself when it implements ParentAccessor")
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	errCompositeItem   = "item %d of %s %s"
+	errCompositeField  = "cannot get the wrapped objects of composite"
+	errNotUnstructured = "object is not unstructured"
+)
+
+// CompositeKinds maps the kinds of composite objects, which wrap the objects
+// to be applied instead of being applied themselves, to the dot-separated
+// path of the field that holds the wrapped objects, e.g. objects for
+// Template.template.openshift.io.
+type CompositeKinds map[schema.GroupKind]string
+
+// ExpandComposites returns the given objects with the lists, i.e. the objects
+// whose kind ends with List and that have items like v1 List, and the objects
+// of the given composite kinds replaced by the objects they wrap, in their
+// order. Composites nested in composites are expanded as well. The wrapped
+// objects need to have an apiVersion, a kind and either a name or a
+// generateName.
+func ExpandComposites(list []ChildResource, kinds CompositeKinds) ([]ChildResource, error) {
+	result := make([]ChildResource, 0, len(list))
+	for _, o := range list {
+		gvk := o.GetObjectKind().GroupVersionKind()
+		if _, ok := kinds[gvk.GroupKind()]; !ok && !strings.HasSuffix(gvk.Kind, "List") {
+			result = append(result, o)
+			continue
+		}
+		u, ok := o.(interface{ UnstructuredContent() map[string]interface{} })
+		if !ok {
+			return nil, errors.New(errNotUnstructured)
+		}
+		wrap := func(i int, err error) error {
+			return errors.Wrapf(err, errCompositeItem, i, gvk.Kind, o.GetName())
+		}
+		expanded, err := expand(&unstructured.Unstructured{Object: u.UnstructuredContent()}, kinds, wrap)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, expanded...)
+	}
+	return result, nil
+}
+
+// expand returns the objects that the given object wraps if it is a list or
+// of one of the given composite kinds, or the object itself otherwise. The
+// errors of the wrapped objects are wrapped with their index.
+func expand(u *unstructured.Unstructured, kinds CompositeKinds, wrap func(i int, err error) error) ([]ChildResource, error) {
+	items, ok, err := compositeItems(u, kinds)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := checkName(u); err != nil {
+			return nil, err
+		}
+		return []ChildResource{u}, nil
+	}
+	result := make([]ChildResource, 0, len(items))
+	for i, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, wrap(i, errors.New(errNotAnObject))
+		}
+		if err := checkObject(m); err != nil {
+			return nil, wrap(i, err)
+		}
+		nested, err := expand(&unstructured.Unstructured{Object: m}, kinds, wrap)
+		if err != nil {
+			return nil, wrap(i, err)
+		}
+		result = append(result, nested...)
+	}
+	return result, nil
+}
+
+// compositeItems returns the objects that the given object wraps, and whether
+// it is a composite at all.
+func compositeItems(u *unstructured.Unstructured, kinds CompositeKinds) ([]interface{}, bool, error) {
+	gvk := u.GroupVersionKind()
+	if field, ok := kinds[gvk.GroupKind()]; ok {
+		items, _, err := unstructured.NestedSlice(u.Object, strings.Split(field, ".")...)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "%s %s", errCompositeField, gvk.Kind)
+		}
+		return items, true, nil
+	}
+	items, ok := u.Object["items"].([]interface{})
+	return items, ok && strings.HasSuffix(gvk.Kind, "List"), nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestExpandComposites(t *testing.T) {
+	configMap := func(name string) map[string]interface{} {
+		return map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": name}}
+	}
+	template := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "template.openshift.io/v1",
+		"kind":       "Template",
+		"metadata":   map[string]interface{}{"name": "cool"},
+		"spec":       map[string]interface{}{"objects": []interface{}{configMap("b"), configMap("c")}},
+	}}
+	kinds := CompositeKinds{schema.GroupKind{Group: "template.openshift.io", Kind: "Template"}: "spec.objects"}
+
+	type want struct {
+		objects []map[string]interface{}
+		err     error
+	}
+	cases := map[string]struct {
+		reason string
+		list   []ChildResource
+		kinds  CompositeKinds
+		want
+	}{
+		"Lists": {
+			reason: "Lists should be expanded into their items in place, keeping the order",
+			list: []ChildResource{
+				&unstructured.Unstructured{Object: configMap("a")},
+				&unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": []interface{}{configMap("b"), configMap("c")}}},
+				&unstructured.Unstructured{Object: configMap("d")},
+			},
+			want: want{objects: []map[string]interface{}{configMap("a"), configMap("b"), configMap("c"), configMap("d")}},
+		},
+		"Composite": {
+			reason: "Objects of the given composite kinds should be expanded into the objects they wrap",
+			list:   []ChildResource{template},
+			kinds:  kinds,
+			want:   want{objects: []map[string]interface{}{configMap("b"), configMap("c")}},
+		},
+		"UnknownComposite": {
+			reason: "Objects of kinds that are not given should be kept as they are",
+			list:   []ChildResource{template},
+			want:   want{objects: []map[string]interface{}{template.Object}},
+		},
+		"InvalidItem": {
+			reason: "An invalid wrapped object should fail with its index and the composite",
+			list: []ChildResource{&unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1", "kind": "List", "metadata": map[string]interface{}{"name": "l"},
+				"items": []interface{}{map[string]interface{}{"kind": "ConfigMap"}},
+			}}},
+			want: want{err: errors.Wrapf(errors.New(errMissingAPIGroup), errCompositeItem, 0, "List", "l")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			list, err := ExpandComposites(tc.list, tc.kinds)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nExpandComposites(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			var got []map[string]interface{}
+			for _, o := range list {
+				got = append(got, o.(*unstructured.Unstructured).Object)
+			}
+			if diff := cmp.Diff(tc.want.objects, got); diff != "" {
+				t.Errorf("\nReason: %s\nExpandComposites(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	errUTF16           = "UTF-16 encoded documents are not supported"
+	errNotAnObject     = "document is not an object"
+	errMissingAPIGroup = "object has no apiVersion"
+	errMissingKind     = "object has no kind"
+	errMissingName     = "object has neither a name nor a generateName"
+	errListItem        = "item %d of list"
+)
+
+var (
+	bomUTF8    = []byte("\xef\xbb\xbf")
+	bomUTF16BE = []byte("\xfe\xff")
+	bomUTF16LE = []byte("\xff\xfe")
+)
+
+// SplitDocuments splits the given YAML stream into its documents. A document
+// starts with a line of --- and ends with the next one or with a line of
+// ..., as YAML defines them, so that the content on the same line as the
+// --- and the empty documents are kept. The comments before the first
+// document are not a document of their own. The byte order marks of UTF-8 at
+// the start of the stream and of every line, e.g. of concatenated files, are
+// dropped.
+func SplitDocuments(data []byte) []string {
+	var (
+		docs   []string
+		cur    strings.Builder
+		ended  bool
+		marked bool
+	)
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		line = strings.TrimPrefix(line, string(bomUTF8))
+		content := strings.TrimRight(line, " \t\r\n")
+		switch {
+		case content == "---" || strings.HasPrefix(content, "--- ") || strings.HasPrefix(content, "---\t"):
+			if !ended && (marked || hasContent(cur.String())) {
+				docs = append(docs, cur.String())
+			}
+			cur.Reset()
+			ended, marked = false, true
+			cur.WriteString(strings.TrimLeft(strings.TrimPrefix(line, "---"), " \t"))
+		case content == "...":
+			if !ended && (marked || hasContent(cur.String())) {
+				docs = append(docs, cur.String())
+			}
+			cur.Reset()
+			ended = true
+		case ended:
+			// Only comments and directives may follow the end of a document
+			// until the next one starts, implicitly or with ---.
+			if !hasContent(content) {
+				continue
+			}
+			ended, marked = false, true
+			cur.WriteString(line)
+		default:
+			cur.WriteString(line)
+		}
+	}
+	if !ended && (marked || hasContent(cur.String())) {
+		docs = append(docs, cur.String())
+	}
+	return docs
+}
+
+// hasContent returns whether the given YAML has lines other than blank ones,
+// comments and directives.
+func hasContent(doc string) bool {
+	for _, l := range strings.Split(doc, "\n") {
+		if t := strings.TrimSpace(l); t != "" && !strings.HasPrefix(t, "#") && !strings.HasPrefix(t, "%") {
+			return true
+		}
+	}
+	return false
+}
+
+// DecodeDocument decodes a single YAML or JSON document into the objects it
+// contains. Anchors, aliases and merge keys are resolved, and the items of
+// lists like v1 List, including the ones of nested lists, are returned as
+// objects of their own. Documents that are empty, contain only comments, null
+// or an empty object have no objects. All objects need to have an apiVersion,
+// a kind and either a name or a generateName.
+func DecodeDocument(doc []byte) ([]ChildResource, error) {
+	doc = bytes.TrimPrefix(doc, bomUTF8)
+	if bytes.HasPrefix(doc, bomUTF16BE) || bytes.HasPrefix(doc, bomUTF16LE) {
+		return nil, errors.New(errUTF16)
+	}
+	j, err := yaml.YAMLToJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	if t := string(bytes.TrimSpace(j)); t == "null" || t == "{}" {
+		return nil, nil
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(j), []byte("{")) {
+		return nil, errors.New(errNotAnObject)
+	}
+	var probe map[string]interface{}
+	if err := json.Unmarshal(j, &probe); err != nil {
+		return nil, err
+	}
+	if err := checkObject(probe); err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{}
+	if err := u.UnmarshalJSON(j); err != nil {
+		return nil, err
+	}
+	return expand(u, nil, func(i int, err error) error { return errors.Wrapf(err, errListItem, i) })
+}
+
+func checkObject(m map[string]interface{}) error {
+	if v, _ := m["apiVersion"].(string); v == "" {
+		return errors.New(errMissingAPIGroup)
+	}
+	if k, _ := m["kind"].(string); k == "" {
+		return errors.New(errMissingKind)
+	}
+	return nil
+}
+
+func checkName(u *unstructured.Unstructured) error {
+	if u.GetName() == "" && u.GetGenerateName() == "" {
+		return errors.New(errMissingName)
+	}
+	return nil
+}
+
+// Decode decodes all documents of the given YAML stream into the objects
+// they contain, like DecodeDocument does. The error of a document that cannot
+// be decoded is a *DecodeError with the index of the document.
+func Decode(data []byte) ([]ChildResource, error) {
+	if bytes.HasPrefix(data, bomUTF16BE) || bytes.HasPrefix(data, bomUTF16LE) {
+		return nil, &DecodeError{Err: errors.New(errUTF16)}
+	}
+	var result []ChildResource
+	for i, doc := range SplitDocuments(data) {
+		objects, err := DecodeDocument([]byte(doc))
+		if err != nil {
+			return nil, &DecodeError{Document: i, Err: err}
+		}
+		result = append(result, objects...)
+	}
+	return result, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+const decodeStream = "\xef\xbb\xbf" + `# The comments before the first document are not a document.
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+---
+# A document with only comments.
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+  labels: &labels
+    app: cool
+  annotations:
+    <<: *labels
+    extra: "yes"
+...
+# Comments may follow the end of a document.
+--- {"apiVersion": "v1", "kind": "List", "items": [{"apiVersion": "v1", "kind": "Secret", "metadata": {"generateName": "c-"}}]}
+` + "\xef\xbb\xbf" + `---
+{}
+`
+
+func TestSplitDocuments(t *testing.T) {
+	docs := SplitDocuments([]byte(decodeStream))
+	if diff := cmp.Diff(5, len(docs)); diff != "" {
+		t.Errorf("SplitDocuments(...): -want documents, +got documents:\n%s\n%q", diff, docs)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	type want struct {
+		objects []map[string]interface{}
+		err     error
+	}
+	configMap := func(name string, meta map[string]interface{}) map[string]interface{} {
+		m := map[string]interface{}{"name": name}
+		for k, v := range meta {
+			m[k] = v
+		}
+		return map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "metadata": m}
+	}
+	cases := map[string]struct {
+		reason string
+		data   string
+		want   want
+	}{
+		"EdgeCases": {
+			reason: "Byte order marks, comments, empty documents, anchors, merge keys, document end markers and lists should be handled",
+			data:   decodeStream,
+			want: want{objects: []map[string]interface{}{
+				configMap("a", nil),
+				configMap("b", map[string]interface{}{
+					"labels":      map[string]interface{}{"app": "cool"},
+					"annotations": map[string]interface{}{"app": "cool", "extra": "yes"},
+				}),
+				{"apiVersion": "v1", "kind": "Secret", "metadata": map[string]interface{}{"generateName": "c-"}},
+			}},
+		},
+		"MissingKind": {
+			reason: "A document without a kind should fail with its index instead of being dropped",
+			data:   "---\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\napiVersion: v1\nmetadata:\n  name: b\n",
+			want:   want{err: &DecodeError{Document: 2, Err: errors.New(errMissingKind)}},
+		},
+		"MissingName": {
+			reason: "An object without a name should fail with its index instead of being dropped",
+			data:   "apiVersion: v1\nkind: ConfigMap\n",
+			want:   want{err: &DecodeError{Document: 0, Err: errors.New(errMissingName)}},
+		},
+		"ListItem": {
+			reason: "An invalid item of a list should fail with its index",
+			data:   "apiVersion: v1\nkind: List\nitems:\n- apiVersion: v1\n  kind: ConfigMap\n  metadata:\n    name: a\n- kind: ConfigMap\n",
+			want:   want{err: &DecodeError{Document: 0, Err: errors.Wrapf(errors.New(errMissingAPIGroup), errListItem, 1)}},
+		},
+		"NestedList": {
+			reason: "The items of lists nested in lists should be returned as objects of their own",
+			data:   "apiVersion: v1\nkind: List\nitems:\n- apiVersion: v1\n  kind: ConfigMapList\n  items:\n  - apiVersion: v1\n    kind: ConfigMap\n    metadata:\n      name: a\n",
+			want:   want{objects: []map[string]interface{}{configMap("a", nil)}},
+		},
+		"NotAnObject": {
+			reason: "A document that is not an object should fail",
+			data:   "- a\n- b\n",
+			want:   want{err: &DecodeError{Document: 0, Err: errors.New(errNotAnObject)}},
+		},
+		"UTF16": {
+			reason: "UTF-16 encoded streams should be rejected instead of decoded into garbage",
+			data:   "\xff\xfea\x00",
+			want:   want{err: &DecodeError{Err: errors.New(errUTF16)}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			list, err := Decode([]byte(tc.data))
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nDecode(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			var got []map[string]interface{}
+			for _, o := range list {
+				got = append(got, o.(*unstructured.Unstructured).Object)
+			}
+			if diff := cmp.Diff(tc.want.objects, got); diff != "" {
+				t.Errorf("\nReason: %s\nDecode(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
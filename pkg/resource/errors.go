@@ -0,0 +1,250 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// FailureKind classifies the failures of a reconciliation.
+type FailureKind string
+
+// Failure kinds.
+const (
+	FailureUnknown    FailureKind = "Unknown"
+	FailureFetch      FailureKind = "FetchFailed"
+	FailureRender     FailureKind = "RenderFailed"
+	FailurePatch      FailureKind = "PatchFailed"
+	FailureApply      FailureKind = "ApplyFailed"
+	FailureValidation FailureKind = "ValidationFailed"
+	FailureQuota      FailureKind = "QuotaExceeded"
+	FailureMissingAPI FailureKind = "InstallPrerequisitesMissing"
+	FailureOwnership  FailureKind = "OwnershipConflict"
+)
+
+// A FetchError is returned when the templates cannot be fetched from their
+// source, so that it can be told apart from a failed render.
+type FetchError struct {
+	Source   string
+	Revision string
+	Err      error
+}
+
+// Error returns the message of the error prefixed with the source.
+func (e *FetchError) Error() string {
+	if e.Revision != "" {
+		return fmt.Sprintf("%s@%s: %s", e.Source, e.Revision, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Source, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *FetchError) Unwrap() error { return e.Err }
+
+// Cause returns the underlying error.
+func (e *FetchError) Cause() error { return e.Err }
+
+// A RenderError is returned by the engines when the render fails. File and
+// Line point to the template that caused the failure, if known.
+type RenderError struct {
+	Engine string
+	File   string
+	Line   int
+	Err    error
+}
+
+// Error returns the message of the error prefixed with its location.
+func (e *RenderError) Error() string {
+	switch {
+	case e.File != "" && e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Err)
+	case e.File != "":
+		return fmt.Sprintf("%s: %s", e.File, e.Err)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *RenderError) Unwrap() error { return e.Err }
+
+// Cause returns the underlying error.
+func (e *RenderError) Cause() error { return e.Err }
+
+// A DecodeError is returned when a document of a YAML stream cannot be
+// decoded. Document is the index of the document in the stream, counting
+// from zero and including the empty documents.
+type DecodeError struct {
+	Document int
+	Err      error
+}
+
+// Error returns the message of the error prefixed with the document.
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("document %d: %s", e.Document, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// Cause returns the underlying error.
+func (e *DecodeError) Cause() error { return e.Err }
+
+// A PatchError is returned when a patcher fails. Target is the child resource
+// that the patcher failed on, if it failed on a specific one.
+type PatchError struct {
+	Patcher string
+	Target  *ChildReference
+	Err     error
+}
+
+// Error returns the message of the error prefixed with its target.
+func (e *PatchError) Error() string {
+	if e.Target == nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s/%s of type %s: %s", e.Target.Name, e.Target.Namespace, schema.FromAPIVersionAndKind(e.Target.APIVersion, e.Target.Kind).String(), e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *PatchError) Unwrap() error { return e.Err }
+
+// Cause returns the underlying error.
+func (e *PatchError) Cause() error { return e.Err }
+
+// An ApplyError is returned when a child resource cannot be applied.
+type ApplyError struct {
+	GroupVersionKind schema.GroupVersionKind
+	Name             string
+	Namespace        string
+	Err              error
+}
+
+// Error returns the message of the error prefixed with the child resource.
+func (e *ApplyError) Error() string {
+	return fmt.Sprintf("%s/%s of type %s: %s", e.Name, e.Namespace, e.GroupVersionKind.String(), e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ApplyError) Unwrap() error { return e.Err }
+
+// Cause returns the underlying error.
+func (e *ApplyError) Cause() error { return e.Err }
+
+// A ValidationError is returned when the rendered child resources fail the
+// checks that run before they are applied. It lists all problems found so
+// that they can be fixed at once.
+type ValidationError struct {
+	Problems []string
+}
+
+// Error returns the problems joined.
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// A QuotaError is returned when the rendered child resources would exceed the
+// ResourceQuotas of their namespaces. It lists all quotas that would be
+// exceeded.
+type QuotaError struct {
+	Problems []string
+}
+
+// Error returns the problems joined.
+func (e *QuotaError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// A MissingAPIError is returned when the cluster does not serve the kinds of
+// some of the rendered child resources, e.g. because a
+// CustomResourceDefinition is not installed. It lists all missing APIs and
+// the child resources that need them.
+type MissingAPIError struct {
+	Problems []string
+}
+
+// Error returns the problems joined.
+func (e *MissingAPIError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// An OwnershipError is returned when a child resource that exists is not
+// tracked by the parent resource that renders it, so that it is not updated.
+type OwnershipError struct {
+	// ParentUID is the UID of the parent resource that renders the child
+	// resource.
+	ParentUID string
+
+	// Controller is the controller of the child resource, e.g. a Deployment
+	// with its name and UID, empty if it has none.
+	Controller string
+
+	// TrackedUID is the UID of the parent resource that the tracking
+	// annotation of the child resource names, empty if it has none.
+	TrackedUID string
+}
+
+// Error returns who owns the child resource instead of the parent resource.
+func (e *OwnershipError) Error() string {
+	owner := "is not controlled by any resource"
+	if e.Controller != "" {
+		owner = "is controlled by " + e.Controller
+	}
+	tracked := "is not tracked by any parent resource"
+	if e.TrackedUID != "" {
+		tracked = "is tracked by parent resource " + e.TrackedUID
+	}
+	return fmt.Sprintf("not owned by parent resource %s: it %s and %s", e.ParentUID, owner, tracked)
+}
+
+// Classify returns the kind of the failure that caused the given error.
+func Classify(err error) FailureKind {
+	var (
+		fetch  *FetchError
+		render *RenderError
+		patch  *PatchError
+		apply  *ApplyError
+		valid  *ValidationError
+		quota  *QuotaError
+		api    *MissingAPIError
+		owner  *OwnershipError
+	)
+	switch {
+	// An ownership conflict is found while applying a child resource, so it
+	// is classified before the ApplyError that wraps it.
+	case errors.As(err, &owner):
+		return FailureOwnership
+	case errors.As(err, &fetch):
+		return FailureFetch
+	case errors.As(err, &render):
+		return FailureRender
+	case errors.As(err, &patch):
+		return FailurePatch
+	case errors.As(err, &apply):
+		return FailureApply
+	case errors.As(err, &valid):
+		return FailureValidation
+	case errors.As(err, &quota):
+		return FailureQuota
+	case errors.As(err, &api):
+		return FailureMissingAPI
+	}
+	return FailureUnknown
+}
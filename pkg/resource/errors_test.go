@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClassify(t *testing.T) {
+	errBoom := errors.New("boom")
+	cases := map[string]struct {
+		reason string
+		err    error
+		want   FailureKind
+		msg    string
+	}{
+		"Unknown": {
+			reason: "Errors that are not typed should be unknown",
+			err:    errBoom,
+			want:   FailureUnknown,
+			msg:    "boom",
+		},
+		"Fetch": {
+			reason: "Fetch errors should be classified apart from render errors with their source in the message",
+			err:    &RenderError{Err: &FetchError{Source: "https://example.org/pack.git", Revision: "v1.2.0", Err: errBoom}},
+			want:   FailureFetch,
+			msg:    "https://example.org/pack.git@v1.2.0: boom",
+		},
+		"Render": {
+			reason: "Wrapped render errors should be classified with their location in the message",
+			err:    errors.Wrap(&RenderError{Engine: "helm3", File: "templates/db.yaml", Line: 3, Err: errBoom}, "render failed"),
+			want:   FailureRender,
+			msg:    "render failed: templates/db.yaml:3: boom",
+		},
+		"Patch": {
+			reason: "Patch errors should be classified with their target in the message",
+			err:    &PatchError{Patcher: "labels", Target: &ChildReference{APIVersion: "v1", Kind: "ConfigMap", Name: "cool", Namespace: "ns"}, Err: errBoom},
+			want:   FailurePatch,
+			msg:    "cool/ns of type /v1, Kind=ConfigMap: boom",
+		},
+		"Apply": {
+			reason: "Apply errors should be classified",
+			err:    errors.Wrap(&ApplyError{GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, Name: "cool", Namespace: "ns", Err: errBoom}, "apply failed"),
+			want:   FailureApply,
+			msg:    "apply failed: cool/ns of type /v1, Kind=ConfigMap: boom",
+		},
+		"Validation": {
+			reason: "Validation errors should be classified with all their problems in the message",
+			err:    errors.Wrap(&ValidationError{Problems: []string{"a", "b"}}, "validation failed"),
+			want:   FailureValidation,
+			msg:    "validation failed: a; b",
+		},
+		"Quota": {
+			reason: "Quota errors should be classified with all their problems in the message",
+			err:    errors.Wrap(&QuotaError{Problems: []string{"a", "b"}}, "quota check failed"),
+			want:   FailureQuota,
+			msg:    "quota check failed: a; b",
+		},
+		"MissingAPI": {
+			reason: "Missing API errors should be classified with all their problems in the message",
+			err:    errors.Wrap(&MissingAPIError{Problems: []string{"a", "b"}}, "prerequisites missing"),
+			want:   FailureMissingAPI,
+			msg:    "prerequisites missing: a; b",
+		},
+		"Ownership": {
+			reason: "Ownership errors should be classified as such even if an apply error wraps them",
+			err:    &ApplyError{GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, Name: "cool", Namespace: "ns", Err: &OwnershipError{ParentUID: "a", Controller: "Deployment other (b)"}},
+			want:   FailureOwnership,
+			msg:    "cool/ns of type /v1, Kind=ConfigMap: not owned by parent resource a: it is controlled by Deployment other (b) and is not tracked by any parent resource",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, Classify(tc.err)); diff != "" {
+				t.Errorf("\nReason: %s\nClassify(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.msg, tc.err.Error()); diff != "" {
+				t.Errorf("\nReason: %s\nError(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -17,9 +17,12 @@ limitations under the License.
 package resource
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 )
 
 // ParentResource should be satisfied by the stack CRD that would like to use
@@ -38,3 +41,15 @@ type ChildResource interface {
 	runtime.Object
 	metav1.Object
 }
+
+// A ParentAccessor reads the fields of a parent resource that packs commonly
+// use, so that the patchers do not each dig them out of the parent resource.
+// Parent resources backed by a Go type can implement it themselves; the rest
+// are read with an UnstructuredParentAccessor.
+type ParentAccessor interface {
+	GetParameters() (map[string]interface{}, error)
+	GetProviderReference() (*v1alpha1.Reference, error)
+	GetWriteConnectionSecretToReference() (*v1alpha1.SecretReference, error)
+	GetClassReference() (*corev1.ObjectReference, error)
+	GetDeletionPolicy() (DeletionPolicy, error)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ApplyResult is the outcome of the last apply of a child resource.
+type ApplyResult string
+
+// The outcomes of applying a child resource.
+const (
+	ApplyCreated   ApplyResult = "Created"
+	ApplyUpdated   ApplyResult = "Updated"
+	ApplyUnchanged ApplyResult = "Unchanged"
+	ApplyFailed    ApplyResult = "Failed"
+	ApplyCompleted ApplyResult = "Completed"
+)
+
+// ChildStatus is the result of the last apply of a child resource, which is
+// kept in the inventory of the parent resource.
+type ChildStatus struct {
+	ChildReference `json:",inline"`
+
+	LastApplyTime metav1.Time `json:"lastApplyTime"`
+	LastOperation ApplyResult `json:"lastOperation"`
+	LastError     string      `json:"lastError,omitempty"`
+
+	// RunHash is the hash of the rendered form of a one-shot child resource
+	// that ran last.
+	RunHash string `json:"runHash,omitempty"`
+}
+
+// GetChildStatuses returns the results of the last apply of the child
+// resources that are recorded in status.children of the given parent
+// resource.
+func GetChildStatuses(cr interface{ UnstructuredContent() map[string]interface{} }) ([]ChildStatus, error) {
+	var result []ChildStatus
+	return result, getStatusField(cr, &result, "children")
+}
+
+// SetChildStatuses records the results of the last apply of the child
+// resources in status.children of the given parent resource.
+func SetChildStatuses(cr interface{ UnstructuredContent() map[string]interface{} }, statuses []ChildStatus) error {
+	return setStatusField(cr, statuses, "children")
+}
+
+// GeneratedNameKey returns the key of the given child resource, which uses
+// generateName, in the inventory of the generated names.
+func GeneratedNameKey(o ChildResource) string {
+	return fmt.Sprintf("%s/%s/%s", o.GetObjectKind().GroupVersionKind().GroupKind().String(), o.GetNamespace(), o.GetGenerateName())
+}
+
+// GetGeneratedNames returns the names that the API server generated for the
+// child resources of the parent resource that use generateName, keyed by
+// GeneratedNameKey.
+func GetGeneratedNames(cr interface{ UnstructuredContent() map[string]interface{} }) map[string]string {
+	names, _, _ := unstructured.NestedStringMap(cr.UnstructuredContent(), "status", "generatedNames")
+	return names
+}
+
+// SetGeneratedNames sets the names that the API server generated for the
+// child resources of the parent resource that use generateName.
+func SetGeneratedNames(cr interface{ UnstructuredContent() map[string]interface{} }, names map[string]string) error {
+	if len(names) == 0 {
+		unstructured.RemoveNestedField(cr.UnstructuredContent(), "status", "generatedNames")
+		return nil
+	}
+	return unstructured.SetNestedStringMap(cr.UnstructuredContent(), names, "status", "generatedNames")
+}
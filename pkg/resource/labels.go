@@ -0,0 +1,62 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GeneratedFromLabelKey is the label that the engines set on the child
+// resources whose names are generated with a content hash suffix, like the
+// output of the ConfigMap and Secret generators of Kustomize. Its value is the
+// name of the generator so that the objects that are superseded by a newer
+// hash can be found and collected.
+const GeneratedFromLabelKey = "templatestacks.crossplane.io/generated-from"
+
+// SkipTransformsAnnotationKey is the annotation of a template resource whose
+// value lists the transforms, separated by commas, that must not change the
+// child resources rendered from it, e.g. "name-prefix" for a ClusterIssuer
+// that has to keep a well-known name.
+const SkipTransformsAnnotationKey = "templatestacks.crossplane.io/skip-transforms"
+
+// A Transform is a change that is made to every child resource after render
+// unless the child resource skips it.
+type Transform string
+
+// Transforms that can be skipped.
+const (
+	// TransformNamePrefix is the name prefix and suffix that the Kustomize
+	// engine adds to the names of the child resources.
+	TransformNamePrefix Transform = "name-prefix"
+
+	// TransformLabels is the propagation of the labels of the parent
+	// resource to the child resources.
+	TransformLabels Transform = "labels"
+)
+
+// SkipsTransform returns true if the given object is annotated to skip the
+// given transform.
+func SkipsTransform(o metav1.Object, t Transform) bool {
+	for _, s := range strings.Split(o.GetAnnotations()[SkipTransformsAnnotationKey], ",") {
+		if Transform(strings.TrimSpace(s)) == t {
+			return true
+		}
+	}
+	return false
+}
@@ -51,9 +51,19 @@ func GetCondition(cr interface{ UnstructuredContent() map[string]interface{} },
 
 // SetConditions sets the supplied conditions, replacing any existing conditions
 // of the same type. This is a no-op if all supplied conditions are identical,
-// ignoring the last transition time, to those already set.
+// ignoring the last transition time, to those already set. The last transition
+// time of an existing condition is kept if its status does not change, e.g.
+// when only the message of an error changes.
 func SetConditions(cr interface{ UnstructuredContent() map[string]interface{} }, c ...v1alpha1.Condition) error {
-	conditioned := v1alpha1.ConditionedStatus{}
+	return SetConditionsWithHistory(cr, 0, c...)
+}
+
+// SetConditionsWithHistory sets the supplied conditions like SetConditions
+// does. If the given limit is greater than zero, the conditions whose status
+// or reason change are also appended to status.conditionHistory, which keeps
+// the latest limit transitions.
+func SetConditionsWithHistory(cr interface{ UnstructuredContent() map[string]interface{} }, limit int, c ...v1alpha1.Condition) error {
+	conditioned := conditionedStatus{}
 	fetched, exists, err := unstructured.NestedFieldCopy(cr.UnstructuredContent(), "status")
 	if err != nil {
 		return err
@@ -67,6 +77,17 @@ func SetConditions(cr interface{ UnstructuredContent() map[string]interface{} },
 			return err
 		}
 	}
+	c = append([]v1alpha1.Condition(nil), c...)
+	var transitions []v1alpha1.Condition
+	for i := range c {
+		existing := conditioned.GetCondition(c[i].Type)
+		if existing.Status == c[i].Status && !existing.LastTransitionTime.IsZero() {
+			c[i].LastTransitionTime = existing.LastTransitionTime
+		}
+		if existing.Status != c[i].Status || existing.Reason != c[i].Reason {
+			transitions = append(transitions, c[i])
+		}
+	}
 	conditioned.SetConditions(c...)
 	resultJSON, err := json.Marshal(conditioned.Conditions)
 	if err != nil {
@@ -76,5 +97,46 @@ func SetConditions(cr interface{ UnstructuredContent() map[string]interface{} },
 	if err := json.Unmarshal(resultJSON, &finalForm); err != nil {
 		return err
 	}
-	return unstructured.SetNestedSlice(cr.UnstructuredContent(), finalForm, "status", "conditions")
+	if err := unstructured.SetNestedSlice(cr.UnstructuredContent(), finalForm, "status", "conditions"); err != nil {
+		return err
+	}
+	if limit <= 0 || len(transitions) == 0 {
+		return nil
+	}
+	history := append(conditioned.ConditionHistory, transitions...)
+	if len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	historyJSON, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	historyForm := []interface{}{}
+	if err := json.Unmarshal(historyJSON, &historyForm); err != nil {
+		return err
+	}
+	return unstructured.SetNestedSlice(cr.UnstructuredContent(), historyForm, "status", "conditionHistory")
+}
+
+// GetConditionHistory returns the condition transitions that are kept in the
+// status of the resource, oldest first.
+func GetConditionHistory(cr interface{ UnstructuredContent() map[string]interface{} }) ([]v1alpha1.Condition, error) {
+	fetched, exists, err := unstructured.NestedFieldCopy(cr.UnstructuredContent(), "status")
+	if err != nil || !exists {
+		return nil, err
+	}
+	statusJSON, err := json.Marshal(fetched)
+	if err != nil {
+		return nil, err
+	}
+	conditioned := conditionedStatus{}
+	if err := json.Unmarshal(statusJSON, &conditioned); err != nil {
+		return nil, err
+	}
+	return conditioned.ConditionHistory, nil
+}
+
+type conditionedStatus struct {
+	v1alpha1.ConditionedStatus `json:",inline"`
+	ConditionHistory           []v1alpha1.Condition `json:"conditionHistory,omitempty"`
 }
@@ -101,6 +101,7 @@ func TestSetCondition(t *testing.T) {
 	}
 	type want struct {
 		err error
+		c   v1alpha1.Condition
 	}
 	cases := map[string]struct {
 		args
@@ -117,6 +118,15 @@ func TestSetCondition(t *testing.T) {
 					Message:            "",
 				},
 			},
+			want: want{
+				c: v1alpha1.Condition{
+					Type:               v1alpha1.TypeReady,
+					Status:             v1.ConditionTrue,
+					LastTransitionTime: metav1.Time{Time: ti},
+					Reason:             v1alpha1.ReasonReconcileSuccess,
+					Message:            "",
+				},
+			},
 		},
 		"SetExisting": {
 			args: args{
@@ -129,6 +139,36 @@ func TestSetCondition(t *testing.T) {
 					Message:            "i failed",
 				},
 			},
+			want: want{
+				c: v1alpha1.Condition{
+					Type:               v1alpha1.TypeSynced,
+					Status:             v1.ConditionTrue,
+					LastTransitionTime: metav1.Time{Time: ti},
+					Reason:             v1alpha1.ReasonReconcileError,
+					Message:            "i failed",
+				},
+			},
+		},
+		"KeepTransitionTime": {
+			args: args{
+				u: fake.NewMockResource(fake.FromYAML([]byte(conditionedUnstructured))),
+				c: v1alpha1.Condition{
+					Type:               v1alpha1.TypeSynced,
+					Status:             v1.ConditionTrue,
+					LastTransitionTime: metav1.Time{Time: ti.Add(time.Hour)},
+					Reason:             v1alpha1.ReasonReconcileSuccess,
+					Message:            "still fine",
+				},
+			},
+			want: want{
+				c: v1alpha1.Condition{
+					Type:               v1alpha1.TypeSynced,
+					Status:             v1.ConditionTrue,
+					LastTransitionTime: metav1.Time{Time: ti},
+					Reason:             v1alpha1.ReasonReconcileSuccess,
+					Message:            "still fine",
+				},
+			},
 		},
 	}
 	for name, tc := range cases {
@@ -138,9 +178,29 @@ func TestSetCondition(t *testing.T) {
 				t.Errorf("Reconcile(...): -want, +got:\n%s", diff)
 			}
 			setCondition, _ := GetCondition(tc.args.u, tc.args.c.Type)
-			if diff := cmp.Diff(tc.args.c, setCondition); diff != "" {
+			if diff := cmp.Diff(tc.want.c, setCondition); diff != "" {
 				t.Errorf("Reconcile(...): -want, +got:\n%s", diff)
 			}
 		})
 	}
 }
+
+func TestConditionHistory(t *testing.T) {
+	u := fake.NewMockResource(fake.FromYAML([]byte(conditionedUnstructured)))
+	failed := v1alpha1.Condition{Type: v1alpha1.TypeSynced, Status: v1.ConditionFalse, Reason: v1alpha1.ReasonReconcileError, Message: "i failed"}
+	failedAgain := v1alpha1.Condition{Type: v1alpha1.TypeSynced, Status: v1.ConditionFalse, Reason: v1alpha1.ReasonReconcileError, Message: "i failed again"}
+	ready := v1alpha1.Condition{Type: v1alpha1.TypeReady, Status: v1.ConditionTrue, Reason: v1alpha1.ReasonAvailable}
+	synced := v1alpha1.Condition{Type: v1alpha1.TypeSynced, Status: v1.ConditionTrue, Reason: v1alpha1.ReasonReconcileSuccess}
+	for _, c := range []v1alpha1.Condition{failed, failedAgain, ready, synced} {
+		if err := SetConditionsWithHistory(u, 2, c); err != nil {
+			t.Fatalf("SetConditionsWithHistory(...): %s", err)
+		}
+	}
+	got, err := GetConditionHistory(u)
+	if err != nil {
+		t.Fatalf("GetConditionHistory(...): %s", err)
+	}
+	if diff := cmp.Diff([]v1alpha1.Condition{ready, synced}, got); diff != "" {
+		t.Errorf("GetConditionHistory(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"sort"
+)
+
+// kindOrder is the order in which the kinds of child resources are sorted,
+// the same one that Helm installs them in, so that the kinds that others
+// depend on, like namespaces and CRDs, come first.
+var kindOrder = []string{
+	"Namespace",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"CustomResourceDefinition",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"Ingress",
+	"APIService",
+}
+
+var kindPriorities = func() map[string]int {
+	p := make(map[string]int, len(kindOrder))
+	for i, k := range kindOrder {
+		p[k] = i
+	}
+	return p
+}()
+
+// KindPriority returns the position of the given kind in the order that the
+// child resources are sorted in. The kinds that are not known, e.g. the
+// managed resources of providers, come after all known ones.
+func KindPriority(kind string) int {
+	if p, ok := kindPriorities[kind]; ok {
+		return p
+	}
+	return len(kindOrder)
+}
+
+// SortChildren sorts the given child resources by the priority of their kind,
+// then by their API group and kind, and then by their namespace and name, so
+// that the rendered child resources are in the same order on every reconcile
+// regardless of the order the engine rendered them in, e.g. the layout of the
+// files of a kustomization. The child resources that are equal in all of these,
+// e.g. the ones whose names are generated, keep their order.
+func SortChildren(list []ChildResource) {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i].GetObjectKind().GroupVersionKind(), list[j].GetObjectKind().GroupVersionKind()
+		if pa, pb := KindPriority(a.Kind), KindPriority(b.Kind); pa != pb {
+			return pa < pb
+		}
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if list[i].GetNamespace() != list[j].GetNamespace() {
+			return list[i].GetNamespace() < list[j].GetNamespace()
+		}
+		return list[i].GetName() < list[j].GetName()
+	})
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestSortChildren(t *testing.T) {
+	child := func(group, kind, ns, name string) ChildResource {
+		return fake.NewMockResource(fake.WithGVK(schema.GroupVersionKind{Group: group, Version: "v1", Kind: kind}), fake.WithNamespaceName(name, ns))
+	}
+	list := []ChildResource{
+		child("database.example.org", "MySQLInstance", "team", "db"),
+		child("apps", "Deployment", "team", "web"),
+		child("cache.example.org", "RedisCluster", "team", "cache"),
+		child("", "ConfigMap", "team", "b"),
+		child("", "ConfigMap", "other", "z"),
+		child("", "ConfigMap", "team", "a"),
+		child("", "Namespace", "", "team"),
+		child("apiextensions.k8s.io", "CustomResourceDefinition", "", "widgets.example.org"),
+	}
+	SortChildren(list)
+	got := make([]string, len(list))
+	for i, o := range list {
+		got[i] = o.GetObjectKind().GroupVersionKind().Kind + " " + o.GetNamespace() + "/" + o.GetName()
+	}
+	want := []string{
+		"Namespace /team",
+		"ConfigMap other/z",
+		"ConfigMap team/a",
+		"ConfigMap team/b",
+		"CustomResourceDefinition /widgets.example.org",
+		"Deployment team/web",
+		"RedisCluster team/cache",
+		"MySQLInstance team/db",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("SortChildren(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ParametersField is the field under spec of a parent resource that holds the
+// free-form parameters which the engines expose to the templates as values.
+const ParametersField = "parameters"
+
+// CapabilitiesParameter is the parameter that holds the facts about the
+// cluster, such as its version and the API versions it serves.
+const CapabilitiesParameter = "capabilities"
+
+// UIDTokenParameter is the parameter that holds the short identifier derived
+// from the UID of the parent resource.
+const UIDTokenParameter = "uidToken"
+
+// UIDTokenPlaceholder is replaced with the short identifier derived from the
+// UID of the parent resource in the child resources.
+const UIDTokenPlaceholder = "$(UID_TOKEN)"
+
+// uidTokenLength is the number of hex characters of the hash of the UID that
+// make up the token.
+const uidTokenLength = 8
+
+// UIDToken returns a short identifier that is derived from the UID of the
+// given object. It is the same for the lifetime of the object and consists of
+// lowercase alphanumerics only, so that it can be used in the names of cloud
+// resources, like S3 buckets, that have to be globally unique. Unlike the name
+// of the object, it differs between two objects that have the same name.
+func UIDToken(o metav1.Object) string {
+	sum := sha256.Sum256([]byte(o.GetUID()))
+	return hex.EncodeToString(sum[:])[:uidTokenLength]
+}
+
+// GetAPIVersions returns the API versions that are given in the capabilities
+// parameter of the parent resource. It returns nil if there are none.
+func GetAPIVersions(cr interface{ UnstructuredContent() map[string]interface{} }) ([]string, error) {
+	v, _, err := unstructured.NestedStringSlice(cr.UnstructuredContent(), "spec", ParametersField, CapabilitiesParameter, "apiVersions")
+	return v, err
+}
+
+// GetParameters returns the free-form parameters given in spec.parameters of
+// the parent resource. It returns nil if there are no parameters.
+func GetParameters(cr interface{ UnstructuredContent() map[string]interface{} }) (map[string]interface{}, error) {
+	return NewUnstructuredParentAccessor(cr.UnstructuredContent()).GetParameters()
+}
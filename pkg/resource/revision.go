@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ChildReference identifies a child resource in the cluster.
+type ChildReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+// ReferenceTo returns the ChildReference of the given child resource.
+func ReferenceTo(o ChildResource) ChildReference {
+	apiVersion, kind := o.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+	return ChildReference{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Name:       o.GetName(),
+		Namespace:  o.GetNamespace(),
+	}
+}
+
+// Revision is the record of a successfully applied render of a parent
+// resource.
+type Revision struct {
+	Revision  int64            `json:"revision"`
+	Hash      string           `json:"hash"`
+	AppliedAt metav1.Time      `json:"appliedAt"`
+	Children  []ChildReference `json:"children,omitempty"`
+}
+
+// GetRevisions returns the revision history recorded in the status of the
+// given parent resource, oldest first.
+func GetRevisions(cr interface{ UnstructuredContent() map[string]interface{} }) ([]Revision, error) {
+	var result []Revision
+	return result, getStatusField(cr, &result, "revisions")
+}
+
+// SetRevisions records the given revision history in the status of the given
+// parent resource.
+func SetRevisions(cr interface{ UnstructuredContent() map[string]interface{} }, revs []Revision) error {
+	return setStatusField(cr, revs, "revisions")
+}
+
+// getStatusField unmarshals the value of the given status field into the
+// given object. The object is untouched if the field does not exist.
+func getStatusField(cr interface{ UnstructuredContent() map[string]interface{} }, into interface{}, fields ...string) error {
+	val, exists, err := unstructured.NestedFieldCopy(cr.UnstructuredContent(), append([]string{"status"}, fields...)...)
+	if err != nil || !exists {
+		return err
+	}
+	b, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, into)
+}
+
+// setStatusField sets the given status field to the JSON representation of
+// the given object.
+func setStatusField(cr interface{ UnstructuredContent() map[string]interface{} }, val interface{}, fields ...string) error {
+	b, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return err
+	}
+	return unstructured.SetNestedField(cr.UnstructuredContent(), generic, append([]string{"status"}, fields...)...)
+}
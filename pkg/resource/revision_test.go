@@ -0,0 +1,69 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestRevisions(t *testing.T) {
+	ti, _ := time.Parse(time.RFC3339, "2020-02-18T15:07:11Z")
+	cases := map[string]struct {
+		reason string
+		revs   []Revision
+	}{
+		"Empty": {
+			reason: "A parent resource without revisions should return no revisions",
+		},
+		"RoundTrip": {
+			reason: "Revisions that are set should be returned as they are",
+			revs: []Revision{
+				{
+					Revision:  3,
+					Hash:      "olala",
+					AppliedAt: metav1.Time{Time: ti},
+					Children: []ChildReference{
+						{APIVersion: "v1", Kind: "ConfigMap", Name: "cool", Namespace: "ns"},
+					},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cr := fake.NewMockResource()
+			if tc.revs != nil {
+				if err := SetRevisions(cr, tc.revs); err != nil {
+					t.Errorf("\nReason: %s\nSetRevisions(...): unexpected error: %s", tc.reason, err)
+				}
+			}
+			got, err := GetRevisions(cr)
+			if err != nil {
+				t.Errorf("\nReason: %s\nGetRevisions(...): unexpected error: %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.revs, got); diff != "" {
+				t.Errorf("\nReason: %s\nGetRevisions(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
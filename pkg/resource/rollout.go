@@ -0,0 +1,47 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// GetTemplateRevision returns the revision of the templates that the child
+// resources of the parent resource were last rendered with successfully.
+func GetTemplateRevision(cr interface{ UnstructuredContent() map[string]interface{} }) string {
+	rev, _, _ := unstructured.NestedString(cr.UnstructuredContent(), "status", "templateRevision")
+	return rev
+}
+
+// SetTemplateRevision sets the revision of the templates that the child
+// resources of the parent resource were last rendered with successfully.
+func SetTemplateRevision(cr interface{ UnstructuredContent() map[string]interface{} }, rev string) error {
+	return unstructured.SetNestedField(cr.UnstructuredContent(), rev, "status", "templateRevision")
+}
+
+// GetPinnedTemplateRevision returns the revision of the templates that the
+// parent resource pins its child resources to in spec.packVersion.
+func GetPinnedTemplateRevision(cr interface{ UnstructuredContent() map[string]interface{} }) string {
+	rev, _, _ := unstructured.NestedString(cr.UnstructuredContent(), "spec", "packVersion")
+	return rev
+}
+
+// SetAvailableTemplateRevision sets the revision of the templates that the
+// controller would render the child resources of the parent resource with.
+func SetAvailableTemplateRevision(cr interface{ UnstructuredContent() map[string]interface{} }, rev string) error {
+	return unstructured.SetNestedField(cr.UnstructuredContent(), rev, "status", "availableTemplateRevision")
+}
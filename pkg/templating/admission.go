@@ -0,0 +1,206 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errParseLabelRule = "cannot parse required label pattern"
+)
+
+// A LabelRule requires the child resources to have the label with given key
+// whose value matches the given pattern.
+type LabelRule struct {
+	Key     string
+	Pattern *regexp.Regexp
+}
+
+// NewLabelRule returns a LabelRule that requires the label with given key to
+// match the given pattern. An empty pattern only requires the label to exist.
+func NewLabelRule(key, pattern string) (LabelRule, error) {
+	if pattern == "" {
+		return LabelRule{Key: key}, nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return LabelRule{}, errors.Wrap(err, errParseLabelRule)
+	}
+	return LabelRule{Key: key, Pattern: re}, nil
+}
+
+// NewAdmissionSimulator returns a new *AdmissionSimulator that looks the
+// namespaces up with the given reader and requires the given labels.
+func NewAdmissionSimulator(c client.Reader, rules ...LabelRule) *AdmissionSimulator {
+	return &AdmissionSimulator{kube: c, rules: rules}
+}
+
+// AdmissionSimulator is a pre-apply Hook that runs the cheap checks that the
+// API server or the admission webhooks would otherwise fail in the middle of
+// an apply, leaving the child resources half-applied. It checks that the
+// namespaces of the child resources exist or are rendered, that their labels
+// and label selectors are valid and that they have the required labels. All
+// problems are returned together as a resource.ValidationError.
+type AdmissionSimulator struct {
+	kube  client.Reader
+	rules []LabelRule
+}
+
+// Run checks the given child resources.
+func (s *AdmissionSimulator) Run(ctx context.Context, _ resource.ParentResource, list []resource.ChildResource) error {
+	rendered := map[string]bool{}
+	for _, o := range list {
+		if o.GetObjectKind().GroupVersionKind().GroupKind() == namespaceGroupKind {
+			rendered[o.GetName()] = true
+		}
+	}
+	missing := map[string]bool{}
+	var problems []string
+	for _, o := range list {
+		prefix := describeReference(resource.ReferenceTo(o))
+		ok, err := s.namespaceExists(ctx, o, rendered, missing)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: namespace %s does not exist", prefix, o.GetNamespace()))
+		}
+		for _, p := range checkLabels(o.GetLabels()) {
+			problems = append(problems, fmt.Sprintf("%s: label %s", prefix, p))
+		}
+		for _, p := range checkSelector(o) {
+			problems = append(problems, fmt.Sprintf("%s: selector %s", prefix, p))
+		}
+		for _, r := range s.rules {
+			val, ok := o.GetLabels()[r.Key]
+			switch {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("%s: required label %s is missing", prefix, r.Key))
+			case r.Pattern != nil && !r.Pattern.MatchString(val):
+				problems = append(problems, fmt.Sprintf("%s: label %s=%s does not match %s", prefix, r.Key, val, r.Pattern))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &resource.ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// namespaceExists returns false if the namespace of the given child resource
+// is neither rendered nor exists in the cluster it targets. The namespaces
+// that are found missing are recorded in the given map so that they are
+// looked up once.
+func (s *AdmissionSimulator) namespaceExists(ctx context.Context, o resource.ChildResource, rendered, missing map[string]bool) (bool, error) {
+	target := o.GetAnnotations()[TargetClusterAnnotationKey]
+	key := target + "/" + o.GetNamespace()
+	if o.GetNamespace() == "" || rendered[o.GetNamespace()] {
+		return true, nil
+	}
+	if gone, ok := missing[key]; ok {
+		return !gone, nil
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: o.GetNamespace()}}
+	// The annotation routes the calls to the cluster of the child resource.
+	if target != "" {
+		ns.SetAnnotations(map[string]string{TargetClusterAnnotationKey: target})
+	}
+	err := s.kube.Get(ctx, types.NamespacedName{Name: ns.GetName()}, ns)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return false, errors.Wrap(err, errGetNamespace)
+	}
+	missing[key] = err != nil
+	return err == nil, nil
+}
+
+// checkLabels returns the problems of the given labels.
+func checkLabels(l map[string]string) []string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var result []string
+	for _, k := range keys {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			result = append(result, fmt.Sprintf("key %q is invalid: %s", k, strings.Join(errs, ", ")))
+		}
+		if errs := validation.IsValidLabelValue(l[k]); len(errs) > 0 {
+			result = append(result, fmt.Sprintf("value %q of %s is invalid: %s", l[k], k, strings.Join(errs, ", ")))
+		}
+	}
+	return result
+}
+
+// checkSelector returns the problems of the label selector in spec.selector of
+// the given child resource, if it has one. The selectors of workloads, given
+// with matchLabels or matchExpressions, need to be valid and to match the
+// labels of their pod template. The selectors of Services, given as a map of
+// labels, need to have valid labels.
+func checkSelector(o resource.ChildResource) []string {
+	u, ok := o.(interface{ UnstructuredContent() map[string]interface{} })
+	if !ok {
+		return nil
+	}
+	sel, ok, err := unstructured.NestedMap(u.UnstructuredContent(), "spec", "selector")
+	if err != nil || !ok {
+		return nil
+	}
+	_, hasLabels := sel["matchLabels"]
+	_, hasExpressions := sel["matchExpressions"]
+	if !hasLabels && !hasExpressions {
+		flat, _, err := unstructured.NestedStringMap(u.UnstructuredContent(), "spec", "selector")
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return checkLabels(flat)
+	}
+	ls := &metav1.LabelSelector{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(sel, ls); err != nil {
+		return []string{err.Error()}
+	}
+	selector, err := metav1.LabelSelectorAsSelector(ls)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	template, ok, err := unstructured.NestedStringMap(u.UnstructuredContent(), "spec", "template", "metadata", "labels")
+	if err != nil || !ok {
+		return nil
+	}
+	if !selector.Matches(labels.Set(template)) {
+		return []string{"does not match the labels of the pod template"}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ Hook = &AdmissionSimulator{}
+
+func TestAdmissionSimulator(t *testing.T) {
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	team, _ := NewLabelRule("team", "[a-z]+")
+	cases := map[string]struct {
+		reason string
+		rules  []LabelRule
+		list   []resource.ChildResource
+		want   error
+	}{
+		"Valid": {
+			reason: "No error should be returned if all checks pass",
+			rules:  []LabelRule{team},
+			list: []resource.ChildResource{
+				fake.NewMockResource(fake.FromYAML([]byte(`
+spec:
+  selector:
+    matchLabels:
+      app: cool
+  template:
+    metadata:
+      labels:
+        app: cool`)), fake.WithGVK(deployment), fake.WithNamespaceName("cool", "existing"), fake.WithAdditionalLabels(map[string]string{"team": "core", "app": "cool"})),
+			},
+		},
+		"RenderedNamespace": {
+			reason: "Namespaces that are rendered as child resources should not be looked up",
+			list: []resource.ChildResource{
+				fake.NewMockResource(fake.WithGVK(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}), fake.WithNamespaceName("rendered", "")),
+				fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("cool", "rendered")),
+			},
+		},
+		"Problems": {
+			reason: "All problems should be returned together as a validation error",
+			rules:  []LabelRule{team},
+			list: []resource.ChildResource{
+				fake.NewMockResource(fake.FromYAML([]byte(`
+spec:
+  selector:
+    matchLabels:
+      app: cool
+  template:
+    metadata:
+      labels:
+        app: other`)), fake.WithGVK(deployment), fake.WithNamespaceName("cool", "missing"), fake.WithAdditionalLabels(map[string]string{"team": "Core"})),
+			},
+			want: &resource.ValidationError{Problems: []string{
+				"Deployment missing/cool: namespace missing does not exist",
+				"Deployment missing/cool: selector does not match the labels of the pod template",
+				"Deployment missing/cool: label team=Core does not match ^(?:[a-z]+)$",
+			}},
+		},
+		"MissingLabel": {
+			reason: "A missing required label should be reported",
+			rules:  []LabelRule{team},
+			list: []resource.ChildResource{
+				fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("cool", "")),
+			},
+			want: &resource.ValidationError{Problems: []string{
+				"MockChildResource cool: required label team is missing",
+			}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			kube := &test.MockClient{
+				MockGet: func(_ context.Context, key client.ObjectKey, _ runtime.Object) error {
+					if key.Name != "existing" {
+						return kerrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, key.Name)
+					}
+					return nil
+				},
+			}
+			err := NewAdmissionSimulator(kube, tc.rules...).Run(context.Background(), fake.NewMockResource(), tc.list)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nRun(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCheckLabels(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		labels map[string]string
+		want   int
+	}{
+		"Valid": {
+			reason: "Valid labels should have no problems",
+			labels: map[string]string{"example.org/team": "core"},
+		},
+		"Invalid": {
+			reason: "Every invalid key and value should be a problem",
+			labels: map[string]string{"in valid": "x", "team": "not valid"},
+			want:   2,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, len(checkLabels(tc.labels))); diff != "" {
+				t.Errorf("\nReason: %s\ncheckLabels(...): -want problems, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -18,12 +18,16 @@ package templating
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"strconv"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -40,6 +44,8 @@ const (
 	errDeleteChildResource = "cannot delete child resource"
 	errPriorityToInt       = "cannot convert deletion priority into integer"
 	errNotController       = "child resource is not controlled by given parent"
+	errHookJobFailed       = "hook job failed"
+	errHookJobNotCompleted = "hook job has not completed yet"
 )
 
 // Constants used for annotations.
@@ -48,6 +54,42 @@ const (
 	RemoveDefaultAnnotationsTrueValue   = "true"
 	DeletionPriorityAnnotationKey       = "templatestacks.crossplane.io/deletion-priority"
 	DeletionPriorityAnnotationZeroValue = "0"
+	TargetClusterAnnotationKey          = "templatestacks.crossplane.io/target-cluster"
+	TargetClusterLocalValue             = "local"
+	HookAnnotationKey                   = "templatestacks.crossplane.io/hook"
+	HookPostApplyValue                  = "post-apply"
+	HookPreRenderValue                  = "pre-render"
+	IDAnnotationKey                     = "templatestacks.crossplane.io/id"
+	DependsOnAnnotationKey              = "templatestacks.crossplane.io/depends-on"
+	RollbackToAnnotationKey             = "templatestacks.crossplane.io/rollback-to"
+
+	PropagateConnectionSecretAnnotationKey = "templatestacks.crossplane.io/propagate-connection-secret"
+	PropagateConnectionSecretTrueValue     = "true"
+	ReadinessTimeoutAnnotationKey          = "templatestacks.crossplane.io/readiness-timeout"
+	ApplyTimeoutAnnotationKey              = "templatestacks.crossplane.io/apply-timeout"
+	FieldManagerAnnotationKey              = "templatestacks.crossplane.io/field-manager"
+	ForceConflictsAnnotationKey            = "templatestacks.crossplane.io/force-conflicts"
+	RequeueAfterAnnotationKey              = "templatestacks.crossplane.io/requeue-after"
+	MigrateFromAnnotationKey               = "templatestacks.crossplane.io/migrate-from"
+	MaintenanceWindowAnnotationKey         = "templatestacks.crossplane.io/maintenance-window"
+	ServiceAccountAnnotationKey            = "templatestacks.crossplane.io/service-account"
+	ImpersonateAnnotationKey               = "templatestacks.crossplane.io/impersonate"
+	PatchStrategyAnnotationKey             = "templatestacks.crossplane.io/patch-strategy"
+	OrphanAnnotationKey                    = "templatestacks.crossplane.io/orphan"
+	OrphanTrueValue                        = "true"
+	OptionalAnnotationKey                  = "templatestacks.crossplane.io/optional"
+	OptionalTrueValue                      = "true"
+	LastAppliedHashAnnotationKey           = "templatestacks.crossplane.io/last-applied-hash"
+	LastSyncTimeAnnotationKey              = "templatestacks.crossplane.io/last-sync-time"
+	TemplateRevisionAnnotationKey          = "templatestacks.crossplane.io/template-revision"
+	PruneCandidateSinceAnnotationKey       = "templatestacks.crossplane.io/prune-candidate-since"
+	ConfirmPruneAnnotationKey              = "templatestacks.crossplane.io/confirm-prune"
+	OneShotAnnotationKey                   = "templatestacks.crossplane.io/one-shot"
+	OneShotTrueValue                       = "true"
+	OneShotRerunOnChangeValue              = "rerun-on-change"
+	OneShotHashAnnotationKey               = "templatestacks.crossplane.io/one-shot-hash"
+	SnapshotOfAnnotationKey                = "templatestacks.crossplane.io/snapshot-of"
+	ParentUIDAnnotationKey                 = "templatestacks.crossplane.io/parent-uid"
 )
 
 // NopEngine is a no-op templating engine.
@@ -65,7 +107,11 @@ func NewOwnerReferenceAdder() OwnerReferenceAdder {
 
 // OwnerReferenceAdder adds owner reference of resource.ParentResource to all resource.ChildResources
 // except the Providers since their deletion should be delayed until all resources
-// refer to them are deleted.
+// refer to them are deleted. The child resources that target a remote cluster
+// are annotated with the UID of the parent resource instead, since the garbage
+// collector of that cluster would delete them as their owner does not exist
+// there. The orphaned child resources are skipped so that they outlive the
+// parent resource.
 type OwnerReferenceAdder struct{}
 
 // Patch patches the child resources with information in resource.ParentResource.
@@ -74,6 +120,13 @@ func (lo OwnerReferenceAdder) Patch(cr resource.ParentResource, list []resource.
 	trueVal := true
 	ref.BlockOwnerDeletion = &trueVal
 	for _, o := range list {
+		if IsOrphaned(o) {
+			continue
+		}
+		if IsRemote(o) {
+			meta.AddAnnotations(o, map[string]string{ParentUIDAnnotationKey: string(cr.GetUID())})
+			continue
+		}
 		meta.AddOwnerReference(o, ref)
 	}
 	return list, nil
@@ -129,12 +182,15 @@ func NewLabelPropagator() LabelPropagator {
 }
 
 // LabelPropagator propagates all the labels that the parent resource has down
-// to all child resources.
+// to all child resources, except the ones that skip resource.TransformLabels.
 type LabelPropagator struct{}
 
 // Patch patches the child resources with information in resource.ParentResource.
 func (lo LabelPropagator) Patch(cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
 	for _, o := range list {
+		if resource.SkipsTransform(o, resource.TransformLabels) {
+			continue
+		}
 		meta.AddLabels(o, cr.GetLabels())
 	}
 	return list, nil
@@ -157,6 +213,110 @@ func (lo ParentLabelSetAdder) Patch(cr resource.ParentResource, list []resource.
 	return list, nil
 }
 
+// IsPostApplyHook returns true if the given child resource is annotated to be
+// run as post-apply hook.
+func IsPostApplyHook(o resource.ChildResource) bool {
+	return o.GetAnnotations()[HookAnnotationKey] == HookPostApplyValue
+}
+
+// IsPreRenderHook returns true if the given child resource is annotated to be
+// run as pre-render hook.
+func IsPreRenderHook(o resource.ChildResource) bool {
+	return o.GetAnnotations()[HookAnnotationKey] == HookPreRenderValue
+}
+
+// A HookJobPendingError is returned when a hook Job has not completed yet. It
+// is not a failure; the parent resource waits for the Job instead.
+type HookJobPendingError struct {
+	Name      string
+	Namespace string
+}
+
+// Error returns the message of the error with the Job that is waited for.
+func (e *HookJobPendingError) Error() string {
+	return fmt.Sprintf("%s: %s/%s", errHookJobNotCompleted, e.Namespace, e.Name)
+}
+
+// IsHookJobPending returns true if the given error, or one that it wraps, is a
+// *HookJobPendingError.
+func IsHookJobPending(err error) bool {
+	var pending *HookJobPendingError
+	return errors.As(err, &pending)
+}
+
+// NewJobCompletionHook returns a new JobCompletionHook that waits for the
+// post-apply hook Jobs.
+func NewJobCompletionHook() JobCompletionHook {
+	return JobCompletionHook{}
+}
+
+// NewPreRenderJobCompletionHook returns a new JobCompletionHook that waits for
+// the pre-render hook Jobs.
+func NewPreRenderJobCompletionHook() JobCompletionHook {
+	return JobCompletionHook{preRender: true}
+}
+
+// JobCompletionHook is a Hook that waits for all Jobs annotated as post-apply
+// hooks, or as pre-render hooks, to complete. The reconciler applies the
+// post-apply Jobs after all other child resources and it reports the parent
+// resource as ready only after they have completed. It applies the pre-render
+// Jobs before all other child resources and holds the rest until they have
+// completed, so that the child resources it applies are rendered after them.
+// Note that this hook relies on the applied child resources having their
+// status populated from the cluster.
+type JobCompletionHook struct {
+	preRender bool
+}
+
+// Hooks returns true if the given child resource is one of the hook Jobs
+// that the JobCompletionHook waits for.
+func (h JobCompletionHook) Hooks(o resource.ChildResource) bool {
+	if o.GetObjectKind().GroupVersionKind().GroupKind() != jobGroupKind {
+		return false
+	}
+	if h.preRender {
+		return IsPreRenderHook(o)
+	}
+	return IsPostApplyHook(o)
+}
+
+// Run returns error if any of the hook Jobs has failed, or a
+// *HookJobPendingError if any of them has not completed yet.
+func (h JobCompletionHook) Run(_ context.Context, _ resource.ParentResource, list []resource.ChildResource) error {
+	for _, o := range list {
+		if !h.Hooks(o) {
+			continue
+		}
+		u, ok := o.(interface{ UnstructuredContent() map[string]interface{} })
+		if !ok {
+			continue
+		}
+		switch {
+		case jobConditionTrue(u.UnstructuredContent(), "Failed"):
+			return errors.Errorf("%s: %s/%s", errHookJobFailed, o.GetNamespace(), o.GetName())
+		case !jobConditionTrue(u.UnstructuredContent(), "Complete"):
+			return &HookJobPendingError{Name: o.GetName(), Namespace: o.GetNamespace()}
+		}
+	}
+	return nil
+}
+
+var jobGroupKind = schema.GroupKind{Group: "batch", Kind: "Job"}
+
+func jobConditionTrue(content map[string]interface{}, ct string) bool {
+	conditions, _, _ := unstructured.NestedSlice(content, "status", "conditions")
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if m["type"] == ct && m["status"] == string(corev1.ConditionTrue) {
+			return true
+		}
+	}
+	return false
+}
+
 // NewAPIOrderedDeleter returns a new *APIOrderedDeleter.
 func NewAPIOrderedDeleter(c client.Client) *APIOrderedDeleter {
 	return &APIOrderedDeleter{kube: c}
@@ -165,7 +325,8 @@ func NewAPIOrderedDeleter(c client.Client) *APIOrderedDeleter {
 // APIOrderedDeleter deletes the child resources in an order that is determined
 // by their priority noted in the child resource annotation. The child resources
 // with higher priority will be deleted first and their deletion will block
-// the lower priority ones.
+// the lower priority ones. The child resources in remote clusters are deleted
+// only if they are annotated with the UID of the parent resource.
 type APIOrderedDeleter struct {
 	kube client.Client
 }
@@ -189,6 +350,9 @@ func (d *APIOrderedDeleter) Delete(ctx context.Context, cr resource.ParentResour
 			return nil, errors.Wrap(err, errPriorityToInt)
 		}
 
+		// Whether the resource is remote is told before it is overwritten
+		// with the object in the cluster, which may not be ours.
+		remote := IsRemote(res)
 		nn := types.NamespacedName{Name: res.GetName(), Namespace: res.GetNamespace()}
 		err = d.kube.Get(ctx, nn, res)
 		if client.IgnoreNotFound(err) != nil {
@@ -199,6 +363,11 @@ func (d *APIOrderedDeleter) Delete(ctx context.Context, cr resource.ParentResour
 		if kerrors.IsNotFound(err) {
 			continue
 		}
+		// The remote resources have no owner reference, so the ones that are
+		// not annotated with our UID were not created by us.
+		if remote && res.GetAnnotations()[ParentUIDAnnotationKey] != string(cr.GetUID()) {
+			return nil, errors.New(errNotController)
+		}
 		// A new high should reset the deletion list and set the new highest.
 		// If the resource is on the same priority level, then it should be added
 		// to the deletion list. If it's neither same or higher, then it should
@@ -24,6 +24,7 @@ import (
 	"github.com/pkg/errors"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -49,6 +50,8 @@ var (
 	_ ChildResourcePatcher = ParentLabelSetAdder{}
 
 	_ ChildResourceDeleter = &APIOrderedDeleter{}
+
+	_ Hook = JobCompletionHook{}
 )
 
 type args struct {
@@ -136,6 +139,36 @@ func TestOwnerReferenceAdder(t *testing.T) {
 				},
 			},
 		},
+		"AnnotateRemote": {
+			args: args{
+				cr: parent,
+				list: []resource.ChildResource{
+					fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{TargetClusterAnnotationKey: "remote"})),
+					fake.NewMockResource(),
+				},
+			},
+			want: want{
+				result: []resource.ChildResource{
+					fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{TargetClusterAnnotationKey: "remote", ParentUIDAnnotationKey: name})),
+					fake.NewMockResource(fake.WithControllerRef(parent, parent.GroupVersionKind())),
+				},
+			},
+		},
+		"SkipOrphaned": {
+			args: args{
+				cr: parent,
+				list: []resource.ChildResource{
+					fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{OrphanAnnotationKey: OrphanTrueValue})),
+					fake.NewMockResource(),
+				},
+			},
+			want: want{
+				result: []resource.ChildResource{
+					fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{OrphanAnnotationKey: OrphanTrueValue})),
+					fake.NewMockResource(fake.WithControllerRef(parent, parent.GroupVersionKind())),
+				},
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -225,6 +258,19 @@ func TestLabelPropagator(t *testing.T) {
 				},
 			},
 		},
+		"Skipped": {
+			args: args{
+				cr: fake.NewMockResource(fake.WithAdditionalLabels(labels)),
+				list: []resource.ChildResource{
+					fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{resource.SkipTransformsAnnotationKey: "name-prefix,labels"})),
+				},
+			},
+			want: want{
+				result: []resource.ChildResource{
+					fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{resource.SkipTransformsAnnotationKey: "name-prefix,labels"})),
+				},
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -415,6 +461,46 @@ func TestAPIOrderedDeleter_Delete(t *testing.T) {
 				err: errors.New(errNotController),
 			},
 		},
+		"DeletionFailedIfRemoteNotTracked": {
+			reason: "It should return error if the remote object is not annotated with the UID of the given parent",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+						obj.(metav1.Object).SetAnnotations(map[string]string{ParentUIDAnnotationKey: "bar"})
+						return nil
+					},
+					MockDelete: func(_ context.Context, _ runtime.Object, _ ...client.DeleteOption) error {
+						t.Errorf("unexpected delete call is made")
+						return nil
+					},
+				},
+				cr: fake.NewMockResource(fake.WithUID("foo")),
+				list: []resource.ChildResource{
+					fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{TargetClusterAnnotationKey: "remote"})),
+				},
+			},
+			want: want{
+				err: errors.New(errNotController),
+			},
+		},
+		"ShouldDeleteTrackedRemote": {
+			reason: "Deletion should be called for the remote objects that are annotated with the UID of the given parent",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    test.NewMockGetFn(nil),
+					MockDelete: test.NewMockDeleteFn(nil),
+				},
+				cr: fake.NewMockResource(fake.WithUID("foo")),
+				list: []resource.ChildResource{
+					fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{TargetClusterAnnotationKey: "remote", ParentUIDAnnotationKey: "foo"})),
+				},
+			},
+			want: want{
+				deleting: []resource.ChildResource{
+					fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{TargetClusterAnnotationKey: "remote", ParentUIDAnnotationKey: "foo"})),
+				},
+			},
+		},
 		"DeletionFailed": {
 			reason: "It should return error if deletion has failed",
 			args: args{
@@ -480,3 +566,77 @@ func TestAPIOrderedDeleter_Delete(t *testing.T) {
 	}
 
 }
+
+func TestJobCompletionHook(t *testing.T) {
+	jobGVK := schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}
+	hook := fake.WithAdditionalAnnotations(map[string]string{HookAnnotationKey: HookPostApplyValue})
+	withJobCondition := func(ct string) fake.MockResourceOption {
+		return func(r *fake.MockResource) {
+			_ = unstructured.SetNestedSlice(r.Object, []interface{}{
+				map[string]interface{}{"type": ct, "status": "True"},
+			}, "status", "conditions")
+		}
+	}
+	cases := map[string]struct {
+		reason  string
+		hook    JobCompletionHook
+		list    []resource.ChildResource
+		err     error
+		pending bool
+	}{
+		"NotAHook": {
+			reason: "Jobs that are not annotated as hooks should not be waited for",
+			list: []resource.ChildResource{
+				fake.NewMockResource(fake.WithGVK(jobGVK)),
+			},
+		},
+		"Completed": {
+			reason: "No error should be returned if all hook jobs have completed",
+			list: []resource.ChildResource{
+				fake.NewMockResource(fake.WithGVK(jobGVK), hook, withJobCondition("Complete")),
+			},
+		},
+		"NotCompleted": {
+			reason: "It should return error if a hook job has not completed yet",
+			list: []resource.ChildResource{
+				fake.NewMockResource(fake.WithGVK(jobGVK), hook, fake.WithNamespaceName(name, namespace)),
+			},
+			err:     errors.Errorf("%s: %s/%s", errHookJobNotCompleted, namespace, name),
+			pending: true,
+		},
+		"PreRenderNotWaitedAfterApply": {
+			reason: "The post-apply hook should not wait for the pre-render hook jobs",
+			list: []resource.ChildResource{
+				fake.NewMockResource(fake.WithGVK(jobGVK), fake.WithAdditionalAnnotations(map[string]string{HookAnnotationKey: HookPreRenderValue})),
+			},
+		},
+		"PreRenderNotCompleted": {
+			reason: "The pre-render hook should report a pre-render hook job that has not completed yet as pending",
+			hook:   NewPreRenderJobCompletionHook(),
+			list: []resource.ChildResource{
+				fake.NewMockResource(fake.WithGVK(jobGVK), fake.WithAdditionalAnnotations(map[string]string{HookAnnotationKey: HookPreRenderValue}), fake.WithNamespaceName(name, namespace)),
+				fake.NewMockResource(fake.WithGVK(jobGVK), hook, withJobCondition("Failed")),
+			},
+			err:     errors.Errorf("%s: %s/%s", errHookJobNotCompleted, namespace, name),
+			pending: true,
+		},
+		"Failed": {
+			reason: "It should return error if a hook job has failed",
+			list: []resource.ChildResource{
+				fake.NewMockResource(fake.WithGVK(jobGVK), hook, fake.WithNamespaceName(name, namespace), withJobCondition("Failed")),
+			},
+			err: errors.Errorf("%s: %s/%s", errHookJobFailed, namespace, name),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.hook.Run(context.Background(), fake.NewMockResource(), tc.list)
+			if diff := cmp.Diff(tc.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nRun(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if IsHookJobPending(err) != tc.pending {
+				t.Errorf("\nReason: %s\nIsHookJobPending(...): want %t, got %t", tc.reason, tc.pending, !tc.pending)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rresource "github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	// AuditConfigMapKey is the key of the audit ConfigMap that holds the
+	// records as a JSON array, the oldest first.
+	AuditConfigMapKey = "records"
+
+	defaultAuditRecordLimit = 100
+	maxChangedFields        = 20
+
+	errGetAuditConfigMap    = "cannot get audit configmap"
+	errWriteAuditConfigMap  = "cannot write audit configmap"
+	errParseAuditRecords    = "cannot parse audit records"
+	errMarshalAuditRecord   = "cannot marshal audit record"
+	errSendAuditRecord      = "cannot send audit record to webhook"
+	errAuditWebhookResponse = "audit webhook responded with an unexpected status"
+	errRecordAudit          = "cannot record audit of child resource"
+)
+
+// AuditRecord is the record of a change that is applied to a child resource.
+type AuditRecord struct {
+	// Parent is the parent resource that the child resource is rendered for.
+	Parent resource.ChildReference `json:"parent"`
+
+	// Child is the child resource that is changed.
+	Child resource.ChildReference `json:"child"`
+
+	// Operation is either Create or Update.
+	Operation ChildOperation `json:"operation"`
+
+	// Hash is the hash of the desired state of the child resource.
+	Hash string `json:"hash"`
+
+	// ChangedFields are the paths of the fields whose values are changed,
+	// truncated to the first few.
+	ChangedFields []string `json:"changedFields,omitempty"`
+
+	// Actor is the field manager that last changed the spec of the parent
+	// resource, i.e. who caused this change.
+	Actor string `json:"actor,omitempty"`
+
+	// Time is when the change is applied.
+	Time metav1.Time `json:"time"`
+}
+
+// newAuditRecord returns the record of applying the given desired state of a
+// child resource over its current state, or nil if it does not change it.
+func newAuditRecord(cr resource.ParentResource, desired, current resource.ChildResource) (*AuditRecord, error) {
+	rec := &AuditRecord{
+		Parent:    resource.ReferenceTo(cr),
+		Child:     resource.ReferenceTo(desired),
+		Operation: OperationCreate,
+		Time:      metav1.Now(),
+	}
+	if mf, ok := latestSpecEntry(cr, cr.GroupVersionKind().Group); ok {
+		rec.Actor = mf.Manager
+	}
+	b, err := json.Marshal(desired)
+	if err != nil {
+		return nil, errors.Wrap(err, errMarshalAuditRecord)
+	}
+	rec.Hash = fmt.Sprintf("%x", sha256.Sum256(b))
+	if current == nil {
+		return rec, nil
+	}
+	d, dok := desired.(interface{ UnstructuredContent() map[string]interface{} })
+	c, cok := current.(interface{ UnstructuredContent() map[string]interface{} })
+	if !dok || !cok {
+		rec.Operation = OperationUpdate
+		return rec, nil
+	}
+	rec.ChangedFields = changedFields("", d.UnstructuredContent(), c.UnstructuredContent())
+	if len(rec.ChangedFields) == 0 {
+		return nil, nil
+	}
+	rec.Operation = OperationUpdate
+	if len(rec.ChangedFields) > maxChangedFields {
+		rec.ChangedFields = rec.ChangedFields[:maxChangedFields]
+	}
+	return rec, nil
+}
+
+// changedFields returns the sorted paths of the fields in desired whose values
+// are different in current. Fields that exist only in current are ignored
+// like IsSubset does.
+func changedFields(prefix string, desired, current map[string]interface{}) []string {
+	var result []string
+	for k, dv := range desired {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		dm, dok := dv.(map[string]interface{})
+		cm, cok := current[k].(map[string]interface{})
+		if dok && cok {
+			result = append(result, changedFields(path, dm, cm)...)
+			continue
+		}
+		if !IsSubset(dv, current[k]) {
+			result = append(result, path)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// applyChild applies the given child resource, records the change in the
+// AuditSink of the reconciler, if there is one, runs the lifecycle hooks and
+// returns the outcome of the apply. Failing to record an audit or to run the
+// hooks does not fail the apply; it is only logged.
+func (r *Reconciler) applyChild(ctx context.Context, cr resource.ParentResource, o resource.ChildResource) (resource.ApplyResult, error) {
+	res, err := r.applyAndAudit(ctx, cr, o)
+	switch res {
+	case resource.ApplyCreated:
+		r.childChanged(ctx, cr, o, ChildCreated)
+	case resource.ApplyUpdated:
+		r.childChanged(ctx, cr, o, ChildUpdated)
+	}
+	return res, err
+}
+
+func (r *Reconciler) applyAndAudit(ctx context.Context, cr resource.ParentResource, o resource.ChildResource) (resource.ApplyResult, error) {
+	if usesGenerateName(o) {
+		if err := r.createGenerated(ctx, cr, o); err != nil {
+			return resource.ApplyFailed, err
+		}
+		if r.audit != nil {
+			r.recordAudit(ctx, cr, o, nil)
+		}
+		return resource.ApplyCreated, nil
+	}
+	var current resource.ChildResource
+	obj, ok := o.DeepCopyObject().(resource.ChildResource)
+	if ok {
+		err := r.client.Get(ctx, types.NamespacedName{Name: o.GetName(), Namespace: o.GetNamespace()}, obj)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return resource.ApplyFailed, errors.Wrap(err, errGetChildResource)
+		}
+		if err == nil {
+			current = obj
+		}
+	}
+	opts := []rresource.ApplyOption{rresource.MustBeControllableBy(cr.GetUID())}
+	// The remote child resources have no owner reference, so the objects that
+	// are not annotated with our UID are not taken over.
+	if r.strictOwnership || IsRemote(o) {
+		// The check that names the owner runs first so that it is the one
+		// that fails.
+		opts = append([]rresource.ApplyOption{MustBeTrackedBy(cr.GetUID())}, opts...)
+	}
+	if err := r.client.Apply(ctx, o, opts...); err != nil {
+		return resource.ApplyFailed, err
+	}
+	if r.audit != nil {
+		r.recordAudit(ctx, cr, o, current)
+	}
+	switch {
+	case current == nil:
+		return resource.ApplyCreated, nil
+	case current.GetResourceVersion() == o.GetResourceVersion():
+		return resource.ApplyUnchanged, nil
+	default:
+		return resource.ApplyUpdated, nil
+	}
+}
+
+// recordAudit records the change from the current state of the child
+// resource, nil if it did not exist, to the given one.
+func (r *Reconciler) recordAudit(ctx context.Context, cr resource.ParentResource, o, current resource.ChildResource) {
+	rec, err := newAuditRecord(cr, o, current)
+	if err == nil && rec != nil {
+		err = r.audit.Record(ctx, *rec)
+	}
+	if err != nil {
+		r.log.Info(errRecordAudit, "error", err, "name", o.GetName(), "namespace", o.GetNamespace())
+	}
+}
+
+// NewConfigMapAuditSink returns a new *ConfigMapAuditSink that keeps the
+// given number of latest records in the ConfigMap with given name. A limit of
+// zero uses the default.
+func NewConfigMapAuditSink(c client.Client, nn types.NamespacedName, limit int) *ConfigMapAuditSink {
+	if limit <= 0 {
+		limit = defaultAuditRecordLimit
+	}
+	return &ConfigMapAuditSink{kube: c, name: nn, limit: limit}
+}
+
+// ConfigMapAuditSink is an AuditSink that keeps the latest records in a
+// ConfigMap as a ring buffer.
+type ConfigMapAuditSink struct {
+	kube  client.Client
+	name  types.NamespacedName
+	limit int
+}
+
+// Record appends the given record to the ConfigMap, dropping the oldest
+// records beyond the limit.
+func (s *ConfigMapAuditSink) Record(ctx context.Context, rec AuditRecord) error {
+	cm := &corev1.ConfigMap{}
+	err := s.kube.Get(ctx, s.name, cm)
+	create := kerrors.IsNotFound(err)
+	if err != nil && !create {
+		return errors.Wrap(err, errGetAuditConfigMap)
+	}
+	var records []AuditRecord
+	if raw := cm.Data[AuditConfigMapKey]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &records); err != nil {
+			return errors.Wrap(err, errParseAuditRecords)
+		}
+	}
+	records = append(records, rec)
+	if len(records) > s.limit {
+		records = records[len(records)-s.limit:]
+	}
+	b, err := json.Marshal(records)
+	if err != nil {
+		return errors.Wrap(err, errMarshalAuditRecord)
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[AuditConfigMapKey] = string(b)
+	if create {
+		cm.SetName(s.name.Name)
+		cm.SetNamespace(s.name.Namespace)
+		return errors.Wrap(s.kube.Create(ctx, cm), errWriteAuditConfigMap)
+	}
+	// The update fails with a conflict if another record is appended in the
+	// meantime, so that no record is silently overwritten.
+	return errors.Wrap(s.kube.Update(ctx, cm), errWriteAuditConfigMap)
+}
+
+// NewWebhookAuditSink returns a new *WebhookAuditSink that sends the records
+// to the given URL.
+func NewWebhookAuditSink(url string, c *http.Client) *WebhookAuditSink {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return &WebhookAuditSink{url: url, client: c}
+}
+
+// WebhookAuditSink is an AuditSink that POSTs every record as JSON to an
+// external webhook.
+type WebhookAuditSink struct {
+	url    string
+	client *http.Client
+}
+
+// Record sends the given record to the webhook.
+func (s *WebhookAuditSink) Record(ctx context.Context, rec AuditRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, errMarshalAuditRecord)
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrap(err, errSendAuditRecord)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, errSendAuditRecord)
+	}
+	defer resp.Body.Close() // nolint:errcheck
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("%s: %d", errAuditWebhookResponse, resp.StatusCode)
+	}
+	return nil
+}
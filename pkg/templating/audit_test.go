@@ -0,0 +1,154 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ AuditSink = &ConfigMapAuditSink{}
+	_ AuditSink = &WebhookAuditSink{}
+	_ AuditSink = AuditSinkChain{}
+)
+
+func TestNewAuditRecord(t *testing.T) {
+	desired := func(replicas int64) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "cool"},
+			"spec":       map[string]interface{}{"replicas": replicas},
+		}}
+	}
+	withStatus := desired(3)
+	withStatus.Object["status"] = map[string]interface{}{"ready": true}
+	cases := map[string]struct {
+		reason  string
+		current resource.ChildResource
+		op      ChildOperation
+		changed []string
+		none    bool
+	}{
+		"Create": {
+			reason: "Child resources that do not exist should be recorded as created",
+			op:     OperationCreate,
+		},
+		"Update": {
+			reason:  "The changed fields of updated child resources should be recorded",
+			current: desired(1),
+			op:      OperationUpdate,
+			changed: []string{"spec.replicas"},
+		},
+		"NoChange": {
+			reason:  "Child resources that are not changed should not be recorded",
+			current: withStatus,
+			none:    true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rec, err := newAuditRecord(fake.NewMockResource(), desired(3), tc.current)
+			if err != nil {
+				t.Fatalf("\nReason: %s\nnewAuditRecord(...): unexpected error: %s", tc.reason, err)
+			}
+			if tc.none {
+				if rec != nil {
+					t.Errorf("\nReason: %s\nnewAuditRecord(...): want no record, got %v", tc.reason, rec)
+				}
+				return
+			}
+			if diff := cmp.Diff(tc.op, rec.Operation); diff != "" {
+				t.Errorf("\nReason: %s\nnewAuditRecord(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.changed, rec.ChangedFields); diff != "" {
+				t.Errorf("\nReason: %s\nnewAuditRecord(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestConfigMapAuditSink(t *testing.T) {
+	var written []AuditRecord
+	kube := &test.MockClient{
+		MockGet: func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+			b, _ := json.Marshal([]AuditRecord{{Hash: "first"}, {Hash: "second"}})
+			obj.(*corev1.ConfigMap).Data = map[string]string{AuditConfigMapKey: string(b)}
+			return nil
+		},
+		MockUpdate: func(_ context.Context, obj runtime.Object, _ ...client.UpdateOption) error {
+			return json.Unmarshal([]byte(obj.(*corev1.ConfigMap).Data[AuditConfigMapKey]), &written)
+		},
+	}
+	if err := NewConfigMapAuditSink(kube, types.NamespacedName{Name: "audit"}, 2).Record(context.Background(), AuditRecord{Hash: "third"}); err != nil {
+		t.Fatalf("Record(...): unexpected error: %s", err)
+	}
+	got := make([]string, len(written))
+	for i, rec := range written {
+		got[i] = rec.Hash
+	}
+	if diff := cmp.Diff([]string{"second", "third"}, got); diff != "" {
+		t.Errorf("Record(...): the oldest record should be dropped: -want, +got:\n%s", diff)
+	}
+}
+
+func TestConfigMapAuditSinkCreate(t *testing.T) {
+	created := false
+	kube := &test.MockClient{
+		MockGet:    test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "audit")),
+		MockCreate: test.NewMockCreateFn(nil, func(_ runtime.Object) error { created = true; return nil }),
+	}
+	if err := NewConfigMapAuditSink(kube, types.NamespacedName{Name: "audit"}, 0).Record(context.Background(), AuditRecord{}); err != nil {
+		t.Fatalf("Record(...): unexpected error: %s", err)
+	}
+	if !created {
+		t.Errorf("Record(...): the audit configmap should be created if it does not exist")
+	}
+}
+
+func TestWebhookAuditSink(t *testing.T) {
+	var got AuditRecord
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+	if err := NewWebhookAuditSink(srv.URL, srv.Client()).Record(context.Background(), AuditRecord{Hash: "cool"}); err != nil {
+		t.Fatalf("Record(...): unexpected error: %s", err)
+	}
+	if diff := cmp.Diff("cool", got.Hash); diff != "" {
+		t.Errorf("Record(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	runtimefake "github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+// benchmarkChildren is the number of child resources that the benchmarks
+// render, patch and apply for their parent resource.
+const benchmarkChildren = 100
+
+func benchmarkEngine(children int) Engine {
+	return EngineFunc(func(cr resource.ParentResource) ([]resource.ChildResource, error) {
+		list := make([]resource.ChildResource, children)
+		for i := range list {
+			u := &unstructured.Unstructured{}
+			u.SetAPIVersion("v1")
+			u.SetKind("ConfigMap")
+			u.SetName(fmt.Sprintf("%s-%d", cr.GetName(), i))
+			u.SetLabels(map[string]string{"app": "cool"})
+			_ = unstructured.SetNestedField(u.Object, "value", "data", "key")
+			list[i] = u
+		}
+		return list, nil
+	})
+}
+
+func benchmarkReconciler(kube *test.MockClient, o ...ReconcilerOption) *Reconciler {
+	mgr := &runtimefake.Manager{
+		Client: kube,
+		Scheme: runtimefake.SchemeWith(&fake.MockResource{}),
+	}
+	return NewReconciler(mgr, fake.MockParentGVK, append([]ReconcilerOption{WithEngine(benchmarkEngine(benchmarkChildren))}, o...)...)
+}
+
+func benchmarkParent() resource.ParentResource {
+	return fake.NewMockResource(fake.WithGVK(fake.MockParentGVK), fake.WithNamespaceName("cool", namespace), fake.WithUID("cool-uid"))
+}
+
+func BenchmarkRender(b *testing.B) {
+	r := benchmarkReconciler(&test.MockClient{})
+	cr := benchmarkParent()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.Render(context.Background(), cr); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPatch(b *testing.B) {
+	cr := benchmarkParent()
+	list, err := benchmarkEngine(benchmarkChildren).Run(cr)
+	if err != nil {
+		b.Fatal(err)
+	}
+	patchers := defaultCRChildren(&test.MockClient{})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := patchers.Patch(cr, list); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkApply(b *testing.B) {
+	r := benchmarkReconciler(&test.MockClient{
+		MockGet:   test.NewMockGetFn(nil),
+		MockPatch: test.NewMockPatchFn(nil),
+	})
+	cr := benchmarkParent()
+	list, err := r.Render(context.Background(), cr)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, _, _, err := r.applyChildren(context.Background(), cr, list); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	msgApplyBudgetSpent = "applied as many child resources as one reconcile may, continuing with the rest"
+)
+
+// newApplyBudget returns an *applyBudget that lets every reconcile of a
+// parent resource apply at most the given number of child resources.
+func newApplyBudget(limit int) *applyBudget {
+	return &applyBudget{limit: limit, rounds: map[types.UID]map[string]bool{}}
+}
+
+// applyBudget limits the number of child resources that a reconcile applies,
+// so that a parent resource with thousands of child resources makes steady
+// progress over several reconciles instead of holding a worker until the
+// reconcile times out, only to start over. A round of reconciles applies
+// every child resource once. Its reconciles skip the child resources that
+// the earlier reconciles of the round applied and take their readiness from
+// then, so that the child resources that depend on them can be applied.
+type applyBudget struct {
+	limit int
+
+	mu sync.Mutex
+	// rounds holds the readiness of the child resources applied in the
+	// current round of every parent resource, by ChildKey.
+	rounds map[types.UID]map[string]bool
+}
+
+// start returns the pass of the given parent resource. A nil budget returns a
+// pass without limit.
+func (b *applyBudget) start(cr resource.ParentResource) *budgetPass {
+	if b == nil || b.limit <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	applied := map[string]bool{}
+	for key, ok := range b.rounds[cr.GetUID()] {
+		applied[key] = ok
+	}
+	return &budgetPass{budget: b, uid: cr.GetUID(), left: b.limit, applied: applied}
+}
+
+// Forget removes the round of the given parent resource.
+func (b *applyBudget) Forget(cr resource.ParentResource) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.rounds, cr.GetUID())
+}
+
+// budgetPass is a reconcile of a parent resource that spends the budget. The
+// methods of a nil pass do not limit anything.
+type budgetPass struct {
+	budget  *applyBudget
+	uid     types.UID
+	left    int
+	applied map[string]bool
+}
+
+// done returns whether the child resource with given ChildKey was applied
+// earlier in the round and whether it was ready then.
+func (p *budgetPass) done(key string) (ready, ok bool) {
+	if p == nil {
+		return false, false
+	}
+	ready, ok = p.applied[key]
+	return ready, ok
+}
+
+// spent returns true if the pass cannot apply any more child resources.
+func (p *budgetPass) spent() bool {
+	return p != nil && p.left <= 0
+}
+
+// record records that the child resource with given ChildKey was applied.
+func (p *budgetPass) record(key string, ready bool) {
+	if p == nil {
+		return
+	}
+	p.left--
+	p.applied[key] = ready
+}
+
+// finish keeps the child resources applied by the pass for the next pass of
+// the round, unless the round is over.
+func (p *budgetPass) finish(over bool) {
+	if p == nil {
+		return
+	}
+	p.budget.mu.Lock()
+	defer p.budget.mu.Unlock()
+	if over {
+		delete(p.budget.rounds, p.uid)
+		return
+	}
+	p.budget.rounds[p.uid] = p.applied
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestApplyBudget(t *testing.T) {
+	patched := 0
+	r := benchmarkReconciler(&test.MockClient{
+		MockGet: test.NewMockGetFn(nil),
+		MockPatch: func(_ context.Context, _ runtime.Object, _ client.Patch, _ ...client.PatchOption) error {
+			patched++
+			return nil
+		},
+	}, WithApplyBudget(40))
+	cr := benchmarkParent()
+	list, err := r.Render(context.Background(), cr)
+	if err != nil {
+		t.Fatalf("Render(...): %s", err)
+	}
+
+	// A round of passes should apply every child resource once, at most 40 per
+	// pass, and the pass after the round should start a new round.
+	for i, want := range []struct{ patched, deferred int }{{40, 60}, {40, 20}, {20, 0}, {40, 60}} {
+		patched = 0
+		_, _, deferred, _, err := r.applyChildren(context.Background(), cr, list)
+		if err != nil {
+			t.Fatalf("pass %d: applyChildren(...): %s", i, err)
+		}
+		if patched != want.patched || len(deferred) != want.deferred {
+			t.Errorf("pass %d: applyChildren(...): applied %d and deferred %d child resources, want %d and %d", i, patched, len(deferred), want.patched, want.deferred)
+		}
+	}
+
+	// A forgotten parent resource should start a new round.
+	r.budget.Forget(cr)
+	patched = 0
+	if _, _, _, _, err := r.applyChildren(context.Background(), cr, list); err != nil {
+		t.Fatalf("applyChildren(...): %s", err)
+	}
+	if patched != 40 {
+		t.Errorf("applyChildren(...): applied %d child resources after Forget, want 40", patched)
+	}
+}
+
+func TestApplyBudgetSharedName(t *testing.T) {
+	patched := 0
+	r := benchmarkReconciler(&test.MockClient{
+		MockGet: test.NewMockGetFn(nil),
+		MockPatch: func(_ context.Context, _ runtime.Object, _ client.Patch, _ ...client.PatchOption) error {
+			patched++
+			return nil
+		},
+	}, WithApplyBudget(1))
+	cr := benchmarkParent()
+	list := []resource.ChildResource{
+		fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("app", namespace)),
+		fake.NewMockResource(fake.WithGVK(fake.MockParentGVK), fake.WithNamespaceName("app", namespace)),
+	}
+
+	// Child resources of different kinds that share a name should each be
+	// applied once in the round.
+	for i, want := range []struct{ patched, deferred int }{{1, 1}, {1, 0}} {
+		patched = 0
+		_, _, deferred, _, err := r.applyChildren(context.Background(), cr, list)
+		if err != nil {
+			t.Fatalf("pass %d: applyChildren(...): %s", i, err)
+		}
+		if patched != want.patched || len(deferred) != want.deferred {
+			t.Errorf("pass %d: applyChildren(...): applied %d and deferred %d child resources, want %d and %d", i, patched, len(deferred), want.patched, want.deferred)
+		}
+	}
+}
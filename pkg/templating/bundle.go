@@ -0,0 +1,189 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	errBundleCopy     = "cannot copy the pack"
+	errBundleVendor   = "cannot vendor the remote references of the pack"
+	errBundleWrite    = "cannot write bundle"
+	errBundleExtract  = "cannot extract bundle"
+	errBundleFileType = "unsupported file type"
+	errBundlePath     = "path is outside of the bundle"
+)
+
+// WriteBundle writes the pack in the given directory to the given writer as
+// a gzipped tarball, after fetching its remote references with the given
+// Vendorer into a copy of the directory, so that the bundle can be rendered
+// with ExtractBundle in clusters that cannot fetch anything. The directory
+// itself is left untouched. The bundle does not depend on the time or the
+// owner of the files, so the same pack always results in the same bundle.
+func WriteBundle(ctx context.Context, dir string, w io.Writer, v Vendorer) error {
+	tmp, err := ioutil.TempDir("", "bundle")
+	if err != nil {
+		return errors.Wrap(err, errBundleCopy)
+	}
+	defer os.RemoveAll(tmp) // nolint:errcheck
+	if err := copyDirectory(dir, tmp); err != nil {
+		return errors.Wrap(err, errBundleCopy)
+	}
+	if v != nil {
+		if err := v.Vendor(ctx, tmp); err != nil {
+			return errors.Wrap(err, errBundleVendor)
+		}
+	}
+	return errors.Wrap(writeTarball(tmp, w), errBundleWrite)
+}
+
+// ExtractBundle extracts the bundle that WriteBundle wrote to the given
+// reader into the given directory.
+func ExtractBundle(r io.Reader, dir string) error {
+	return errors.Wrap(extractTarball(r, dir), errBundleExtract)
+}
+
+func writeTarball(dir string, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return errors.Errorf("%s: %s", errBundleFileType, path)
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		hdr.ModTime = time.Unix(0, 0)
+		hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+		defer f.Close() // nolint:errcheck
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func extractTarball(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// The names are not trusted, a bundle must not be able to write
+		// outside of the directory it is extracted into.
+		rel := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return errors.Errorf("%s: %s", errBundlePath, hdr.Name)
+		}
+		path := filepath.Join(dir, rel)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeFile(path, tr, os.FileMode(hdr.Mode).Perm()); err != nil {
+				return err
+			}
+		default:
+			return errors.Errorf("%s: %s", errBundleFileType, hdr.Name)
+		}
+	}
+}
+
+func copyDirectory(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !info.Mode().IsRegular() {
+			return errors.Errorf("%s: %s", errBundleFileType, path)
+		}
+		f, err := os.Open(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+		defer f.Close() // nolint:errcheck
+		return writeFile(target, f, info.Mode().Perm())
+	})
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Clean(path), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
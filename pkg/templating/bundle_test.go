@@ -0,0 +1,105 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+var (
+	_ Vendorer = VendorerFunc(nil)
+	_ Vendorer = VendorerChain{}
+)
+
+func TestBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint:errcheck
+	if err := writeFile(filepath.Join(dir, "base", "kustomization.yaml"), bytes.NewBufferString("resources: []\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	vendor := VendorerFunc(func(_ context.Context, copied string) error {
+		if copied == dir {
+			return errors.New("the pack should be vendored in a copy")
+		}
+		return ioutil.WriteFile(filepath.Join(copied, "remote.yaml"), []byte("kind: Remote\n"), 0600)
+	})
+
+	first, second := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := WriteBundle(context.Background(), dir, first, vendor); err != nil {
+		t.Fatalf("WriteBundle(...): %s", err)
+	}
+	if err := WriteBundle(context.Background(), dir, second, vendor); err != nil {
+		t.Fatalf("WriteBundle(...): %s", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("WriteBundle(...): the same pack should result in the same bundle")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "remote.yaml")); !os.IsNotExist(err) {
+		t.Errorf("WriteBundle(...): the pack should be left untouched")
+	}
+
+	out, err := ioutil.TempDir("", "extracted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out) // nolint:errcheck
+	if err := ExtractBundle(first, out); err != nil {
+		t.Fatalf("ExtractBundle(...): %s", err)
+	}
+	want, _ := HashDirectory(dir)
+	if err := os.Remove(filepath.Join(out, "remote.yaml")); err != nil {
+		t.Errorf("ExtractBundle(...): the vendored files should be extracted: %s", err)
+	}
+	got, _ := HashDirectory(out)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ExtractBundle(...): -want hash, +got hash:\n%s", diff)
+	}
+}
+
+func TestExtractBundleOutside(t *testing.T) {
+	b := &bytes.Buffer{}
+	gz := gzip.NewWriter(b)
+	tw := tar.NewWriter(gz)
+	_ = tw.WriteHeader(&tar.Header{Name: "../escape.yaml", Typeflag: tar.TypeReg, Mode: 0600})
+	_ = tw.Close()
+	_ = gz.Close()
+
+	out, err := ioutil.TempDir("", "extracted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out) // nolint:errcheck
+	want := errors.Wrap(errors.Errorf("%s: %s", errBundlePath, "../escape.yaml"), errBundleExtract)
+	if diff := cmp.Diff(want, ExtractBundle(b, out), test.EquateErrors()); diff != "" {
+		t.Errorf("ExtractBundle(...): -want error, +got error:\n%s", diff)
+	}
+}
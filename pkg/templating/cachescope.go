@@ -0,0 +1,194 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	errScanTemplates = "cannot scan the templates for the kinds of child resources"
+)
+
+var (
+	documentSeparator = regexp.MustCompile(`(?m)^---.*$`)
+	apiVersionLine    = regexp.MustCompile(`(?m)^apiVersion:\s*["']?([^"'\s]+)["']?\s*$`)
+	kindLine          = regexp.MustCompile(`(?m)^kind:\s*["']?([^"'\s]+)["']?\s*$`)
+	namespaceLine     = regexp.MustCompile(`(?m)^  namespace:\s*["']?([^"'\s]+)["']?\s*$`)
+)
+
+// CacheScope is the kinds and namespaces of the objects that the cache of the
+// manager keeps informers for. The reads of all other objects go to the API
+// server, so that the controller does not hold every object of a kind in the
+// cluster in memory just because the pack reads some of them.
+type CacheScope struct {
+	// Kinds of the unstructured objects that are read from the cache. The
+	// typed objects, e.g. the ConfigMaps that configure the controller, are
+	// always read from the cache.
+	Kinds []schema.GroupVersionKind
+
+	// Namespaces that the cache watches. All namespaces are watched if none
+	// is given. They must include the namespaces of the parent resources,
+	// since the parent resources are only watched through the cache.
+	Namespaces []string
+}
+
+// ScanCacheScope returns the CacheScope of the child resources that the
+// templates in the given directory declare with a literal apiVersion and kind,
+// along with the literal namespaces of their metadata. The child resources
+// whose kind is templated are not in the scope, so they are read from the API
+// server, which is slower but still correct.
+func ScanCacheScope(dir string) (CacheScope, error) {
+	kinds := map[schema.GroupVersionKind]bool{}
+	namespaces := map[string]bool{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".tpl":
+		default:
+			return nil
+		}
+		b, err := ioutil.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+		for _, doc := range documentSeparator.Split(string(b), -1) {
+			av, k := apiVersionLine.FindStringSubmatch(doc), kindLine.FindStringSubmatch(doc)
+			if av == nil || k == nil || isTemplated(av[1]) || isTemplated(k[1]) {
+				continue
+			}
+			kinds[schema.FromAPIVersionAndKind(av[1], k[1])] = true
+			if ns := namespaceLine.FindStringSubmatch(doc); ns != nil && !isTemplated(ns[1]) {
+				namespaces[ns[1]] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return CacheScope{}, errors.Wrap(err, errScanTemplates)
+	}
+	s := CacheScope{}
+	for gvk := range kinds {
+		s.Kinds = append(s.Kinds, gvk)
+	}
+	sort.Slice(s.Kinds, func(i, j int) bool { return s.Kinds[i].String() < s.Kinds[j].String() })
+	for ns := range namespaces {
+		s.Namespaces = append(s.Namespaces, ns)
+	}
+	sort.Strings(s.Namespaces)
+	return s, nil
+}
+
+func isTemplated(v string) bool {
+	return strings.Contains(v, "{{") || strings.Contains(v, "$")
+}
+
+// SetupScopedCache configures the given manager options so that the cache
+// of the manager only keeps informers for the kinds and namespaces of the
+// given scope. The manager options that already restrict the cache to a
+// single namespace keep watching that namespace only.
+func SetupScopedCache(o *ctrl.Options, s CacheScope) {
+	if len(s.Namespaces) > 0 && o.Namespace == "" {
+		o.NewCache = cache.MultiNamespacedCacheBuilder(s.Namespaces)
+	}
+	kinds := map[schema.GroupVersionKind]bool{}
+	for _, gvk := range s.Kinds {
+		kinds[gvk] = true
+	}
+	namespaces := map[string]bool{}
+	for _, ns := range s.Namespaces {
+		namespaces[ns] = true
+	}
+	if o.Namespace != "" {
+		namespaces = map[string]bool{o.Namespace: true}
+	}
+	o.NewClient = func(c cache.Cache, config *rest.Config, co client.Options) (client.Client, error) {
+		kube, err := client.New(config, co)
+		if err != nil {
+			return nil, err
+		}
+		return &client.DelegatingClient{
+			Reader:       &scopedReader{cache: c, direct: kube, kinds: kinds, namespaces: namespaces},
+			Writer:       kube,
+			StatusClient: kube,
+		}, nil
+	}
+}
+
+// scopedReader reads the objects in its scope from the cache and all others
+// from the API server.
+type scopedReader struct {
+	cache      client.Reader
+	direct     client.Reader
+	kinds      map[schema.GroupVersionKind]bool
+	namespaces map[string]bool
+}
+
+func (r *scopedReader) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if r.inScope(obj, key.Namespace) {
+		return r.cache.Get(ctx, key, obj)
+	}
+	return r.direct.Get(ctx, key, obj)
+}
+
+func (r *scopedReader) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	lo := &client.ListOptions{}
+	lo.ApplyOptions(opts)
+	if r.inScope(list, lo.Namespace) {
+		return r.cache.List(ctx, list, opts...)
+	}
+	return r.direct.List(ctx, list, opts...)
+}
+
+func (r *scopedReader) inScope(obj runtime.Object, namespace string) bool {
+	if len(r.namespaces) > 0 && !r.namespaces[namespace] {
+		// The cache of a set of namespaces cannot get the cluster scoped
+		// objects, nor the objects of the other namespaces.
+		return false
+	}
+	u, ok := obj.(runtime.Unstructured)
+	if !ok {
+		return true
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if u.IsList() {
+		gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	}
+	return r.kinds[gvk]
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ client.Reader = &scopedReader{}
+
+func TestScanCacheScope(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"kustomization.yaml": "resources:\n- deployment.yaml\n",
+		"deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cool
+  namespace: apps
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: cool
+  namespace: "{{ .Release.Namespace }}"
+`,
+		"templates/templated.tpl": "apiVersion: {{ .Values.apiVersion }}\nkind: Ingress\n",
+		".git/config.yaml":        "apiVersion: v1\nkind: Secret\n",
+		"README.md":               "apiVersion: v1\nkind: Pod\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ScanCacheScope(dir)
+	if err != nil {
+		t.Fatalf("ScanCacheScope(...): %s", err)
+	}
+	want := CacheScope{
+		Kinds: []schema.GroupVersionKind{
+			{Version: "v1", Kind: "Service"},
+			{Group: "apps", Version: "v1", Kind: "Deployment"},
+		},
+		Namespaces: []string{"apps"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ScanCacheScope(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestScopedReader(t *testing.T) {
+	errCache := errors.New("cache")
+	errDirect := errors.New("direct")
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(fake.MockChildGVK.GroupVersion().WithKind(fake.MockChildGVK.Kind + "List"))
+
+	type args struct {
+		namespaces map[string]bool
+		obj        runtime.Object
+		namespace  string
+		list       bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   error
+	}{
+		"InScope": {
+			reason: "Unstructured objects of a kind in the scope should be read from the cache",
+			args: args{
+				obj: fake.NewMockResource(fake.WithGVK(fake.MockChildGVK)),
+			},
+			want: errCache,
+		},
+		"KindNotInScope": {
+			reason: "Unstructured objects of a kind that is not in the scope should be read from the API server",
+			args: args{
+				obj: fake.NewMockResource(fake.WithGVK(fake.MockParentGVK)),
+			},
+			want: errDirect,
+		},
+		"Typed": {
+			reason: "Typed objects should be read from the cache",
+			args: args{
+				obj: &corev1.ConfigMap{},
+			},
+			want: errCache,
+		},
+		"NamespaceNotInScope": {
+			reason: "Objects of a namespace that is not in the scope should be read from the API server",
+			args: args{
+				namespaces: map[string]bool{"apps": true},
+				obj:        &corev1.ConfigMap{},
+				namespace:  "other",
+			},
+			want: errDirect,
+		},
+		"ListInScope": {
+			reason: "Lists of a kind in the scope should be read from the cache",
+			args: args{
+				namespaces: map[string]bool{"apps": true},
+				obj:        list,
+				namespace:  "apps",
+				list:       true,
+			},
+			want: errCache,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &scopedReader{
+				cache:      &test.MockClient{MockGet: test.NewMockGetFn(errCache), MockList: test.NewMockListFn(errCache)},
+				direct:     &test.MockClient{MockGet: test.NewMockGetFn(errDirect), MockList: test.NewMockListFn(errDirect)},
+				kinds:      map[schema.GroupVersionKind]bool{fake.MockChildGVK: true},
+				namespaces: tc.args.namespaces,
+			}
+			var err error
+			if tc.args.list {
+				err = r.List(context.TODO(), tc.args.obj, client.InNamespace(tc.args.namespace))
+			} else {
+				err = r.Get(context.TODO(), client.ObjectKey{Namespace: tc.args.namespace, Name: "cool"}, tc.args.obj)
+			}
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nscopedReader: -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
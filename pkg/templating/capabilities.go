@@ -0,0 +1,128 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/discovery"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	defaultCapabilitiesRefresh = 10 * time.Minute
+
+	errServerVersion   = "cannot discover the version of the cluster"
+	errServerResources = "cannot discover the API resources of the cluster"
+)
+
+// NewDiscoveryCapabilities returns a new *DiscoveryCapabilities whose facts
+// are discovered again once the given refresh interval elapses. A refresh
+// interval of zero uses the default.
+func NewDiscoveryCapabilities(d discovery.DiscoveryInterface, refresh time.Duration) *DiscoveryCapabilities {
+	if refresh == 0 {
+		refresh = defaultCapabilitiesRefresh
+	}
+	return &DiscoveryCapabilities{
+		discovery: d,
+		refresh:   refresh,
+		now:       time.Now,
+	}
+}
+
+// DiscoveryCapabilities is a ValuesProvider that exposes the facts about the
+// cluster under the capabilities parameter so that the templates and patchers
+// can render differently depending on the cluster, e.g. choose the API
+// version of an Ingress. The facts are the kubeVersion of the API server with
+// its major, minor and version fields, the platform, i.e. OS and architecture,
+// the API server runs on and apiVersions, the list of every group/version and
+// group/version/kind that the cluster serves.
+type DiscoveryCapabilities struct {
+	discovery discovery.DiscoveryInterface
+	refresh   time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	cached    map[string]interface{}
+	fetchedAt time.Time
+}
+
+// Values returns the capabilities of the cluster.
+func (c *DiscoveryCapabilities) Values(_ context.Context, _ resource.ParentResource) (map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached == nil || c.now().Sub(c.fetchedAt) >= c.refresh {
+		caps, err := c.discover()
+		if err != nil {
+			return nil, err
+		}
+		c.cached = caps
+		c.fetchedAt = c.now()
+	}
+	return map[string]interface{}{resource.CapabilitiesParameter: c.cached}, nil
+}
+
+func (c *DiscoveryCapabilities) discover() (map[string]interface{}, error) {
+	v, err := c.discovery.ServerVersion()
+	if err != nil {
+		return nil, errors.Wrap(err, errServerVersion)
+	}
+	_, lists, err := c.discovery.ServerGroupsAndResources()
+	// Some aggregated API servers may be unavailable at any time, which
+	// should not block rendering with the groups that are served.
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, errors.Wrap(err, errServerResources)
+	}
+	set := map[string]bool{}
+	for _, l := range lists {
+		if l == nil {
+			continue
+		}
+		set[l.GroupVersion] = true
+		for _, r := range l.APIResources {
+			// Subresources, like deployments/status, are not kinds of their own.
+			if strings.Contains(r.Name, "/") {
+				continue
+			}
+			set[l.GroupVersion+"/"+r.Kind] = true
+		}
+	}
+	versions := make([]string, 0, len(set))
+	for gv := range set {
+		versions = append(versions, gv)
+	}
+	sort.Strings(versions)
+	apiVersions := make([]interface{}, len(versions))
+	for i, gv := range versions {
+		apiVersions[i] = gv
+	}
+	return map[string]interface{}{
+		"kubeVersion": map[string]interface{}{
+			"major":   v.Major,
+			"minor":   v.Minor,
+			"version": v.GitVersion,
+		},
+		"platform":    v.Platform,
+		"apiVersions": apiVersions,
+	}, nil
+}
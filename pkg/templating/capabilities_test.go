@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ ValuesProvider = &DiscoveryCapabilities{}
+)
+
+func TestDiscoveryCapabilities(t *testing.T) {
+	d := &fakediscovery.FakeDiscovery{
+		Fake: &kubetesting.Fake{
+			Resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "networking.k8s.io/v1beta1",
+					APIResources: []metav1.APIResource{{Name: "ingresses", Kind: "Ingress"}, {Name: "ingresses/status", Kind: "Ingress"}},
+				},
+				{
+					GroupVersion: "v1",
+					APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap"}},
+				},
+			},
+		},
+		FakedServerVersion: &version.Info{Major: "1", Minor: "18", GitVersion: "v1.18.2", Platform: "linux/amd64"},
+	}
+	want := map[string]interface{}{
+		resource.CapabilitiesParameter: map[string]interface{}{
+			"kubeVersion": map[string]interface{}{"major": "1", "minor": "18", "version": "v1.18.2"},
+			"platform":    "linux/amd64",
+			"apiVersions": []interface{}{"networking.k8s.io/v1beta1", "networking.k8s.io/v1beta1/Ingress", "v1", "v1/ConfigMap"},
+		},
+	}
+	c := NewDiscoveryCapabilities(d, time.Hour)
+	got, err := c.Values(context.Background(), fake.NewMockResource())
+	if err != nil {
+		t.Fatalf("Values(...): unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Values(...): -want, +got:\n%s", diff)
+	}
+
+	// The discovered facts should be cached until the refresh interval elapses.
+	d.FakedServerVersion = &version.Info{Major: "1", Minor: "19"}
+	got, _ = c.Values(context.Background(), fake.NewMockResource())
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Values(...): cached facts: -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errChildCleanup         = "cannot clean up child resources"
+	errRemoveChildFinalizer = "cannot remove finalizer from child resource"
+)
+
+// NewChildFinalizer returns a new *ChildFinalizer that adds the given
+// finalizer to the child resources of the given kinds and runs the given
+// cleanup Hook before removing it.
+func NewChildFinalizer(c client.Client, finalizer string, cleanup Hook, kinds ...schema.GroupKind) *ChildFinalizer {
+	return &ChildFinalizer{kube: c, finalizer: finalizer, cleanup: cleanup, kinds: kinds}
+}
+
+// ChildFinalizer keeps the child resources that represent external systems
+// from disappearing before their teardown logic is run. As a
+// ChildResourcePatcher, it adds its finalizer to the child resources of the
+// configured kinds. As a ChildResourceDeleter, it runs the cleanup Hook with
+// the ones that are being deleted and then removes its finalizer so that
+// their deletion can complete. It never blocks the deleters that come after
+// it since the child resources keep being reported as being deleted by them
+// until the finalizer is removed.
+type ChildFinalizer struct {
+	kube      client.Client
+	finalizer string
+	cleanup   Hook
+	kinds     []schema.GroupKind
+}
+
+// Patch adds the finalizer to the child resources of the configured kinds.
+func (f *ChildFinalizer) Patch(_ resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	for _, o := range list {
+		if f.selects(o) {
+			meta.AddFinalizer(o, f.finalizer)
+		}
+	}
+	return list, nil
+}
+
+// Delete runs the cleanup Hook with the child resources that have the
+// finalizer and are being deleted, then removes the finalizer from them.
+func (f *ChildFinalizer) Delete(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	var finalizing []resource.ChildResource
+	for _, o := range list {
+		if !f.selects(o) {
+			continue
+		}
+		err := f.kube.Get(ctx, types.NamespacedName{Name: o.GetName(), Namespace: o.GetNamespace()}, o)
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, errGetChildResource)
+		}
+		if o.GetDeletionTimestamp() == nil || !hasFinalizer(o, f.finalizer) {
+			continue
+		}
+		finalizing = append(finalizing, o)
+	}
+	if len(finalizing) == 0 {
+		return nil, nil
+	}
+	if err := f.cleanup.Run(ctx, cr, finalizing); err != nil {
+		return nil, errors.Wrap(err, errChildCleanup)
+	}
+	for _, o := range finalizing {
+		meta.RemoveFinalizer(o, f.finalizer)
+		if err := f.kube.Update(ctx, o); client.IgnoreNotFound(err) != nil {
+			return nil, errors.Wrap(err, errRemoveChildFinalizer)
+		}
+	}
+	return nil, nil
+}
+
+func (f *ChildFinalizer) selects(o resource.ChildResource) bool {
+	// The orphaned child resources are not deleted with the parent resource,
+	// so there is nothing to clean up before.
+	if IsOrphaned(o) {
+		return false
+	}
+	gk := o.GetObjectKind().GroupVersionKind().GroupKind()
+	for _, k := range f.kinds {
+		if gk == k {
+			return true
+		}
+	}
+	return false
+}
+
+func hasFinalizer(o metav1.Object, finalizer string) bool {
+	for _, f := range o.GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
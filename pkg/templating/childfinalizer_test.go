@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+const childFinalizer = "cleanup.example.org"
+
+var (
+	_ ChildResourcePatcher = &ChildFinalizer{}
+	_ ChildResourceDeleter = &ChildFinalizer{}
+)
+
+func TestChildFinalizer_Patch(t *testing.T) {
+	external := fake.NewMockResource(fake.WithGVK(schema.GroupVersionKind{Group: "database.example.org", Version: "v1", Kind: "Instance"}))
+	other := fake.NewMockResource(fake.WithGVK(fake.MockChildGVK))
+	f := NewChildFinalizer(nil, childFinalizer, nil, schema.GroupKind{Group: "database.example.org", Kind: "Instance"})
+	if _, err := f.Patch(fake.NewMockResource(), []resource.ChildResource{external, other}); err != nil {
+		t.Fatalf("Patch(...): %s", err)
+	}
+	if diff := cmp.Diff([]string{childFinalizer}, external.GetFinalizers()); diff != "" {
+		t.Errorf("Patch(...): -want selected finalizers, +got selected finalizers:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string(nil), other.GetFinalizers()); diff != "" {
+		t.Errorf("Patch(...): -want other finalizers, +got other finalizers:\n%s", diff)
+	}
+}
+
+func TestChildFinalizer_Delete(t *testing.T) {
+	deleting := func(obj runtime.Object) error {
+		o := obj.(metav1.Object)
+		now := metav1.Now()
+		o.SetDeletionTimestamp(&now)
+		o.SetFinalizers([]string{childFinalizer})
+		return nil
+	}
+	type want struct {
+		err     error
+		cleaned int
+		updated bool
+	}
+	cases := map[string]struct {
+		reason  string
+		get     test.MockGetFn
+		cleanup error
+		update  error
+		want    want
+	}{
+		"NotDeleting": {
+			reason: "Child resources that are not being deleted should not be cleaned up",
+			get:    test.NewMockGetFn(nil),
+		},
+		"Finalize": {
+			reason: "Child resources that are being deleted should be cleaned up before their finalizer is removed",
+			get:    test.NewMockGetFn(nil, deleting),
+			want:   want{cleaned: 1, updated: true},
+		},
+		"CleanupFailed": {
+			reason:  "The finalizer should not be removed if the cleanup fails",
+			get:     test.NewMockGetFn(nil, deleting),
+			cleanup: errBoom,
+			want:    want{err: errors.Wrap(errBoom, errChildCleanup), cleaned: 1},
+		},
+		"UpdateFailed": {
+			reason: "The error should be returned if the finalizer cannot be removed",
+			get:    test.NewMockGetFn(nil, deleting),
+			update: errBoom,
+			want:   want{err: errors.Wrap(errBoom, errRemoveChildFinalizer), cleaned: 1, updated: true},
+		},
+		"GetFailed": {
+			reason: "The error should be returned if the child resource cannot be fetched",
+			get:    test.NewMockGetFn(errBoom),
+			want:   want{err: errors.Wrap(errBoom, errGetChildResource)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cleaned, updated := 0, false
+			kube := &test.MockClient{
+				MockGet: tc.get,
+				MockUpdate: func(_ context.Context, obj runtime.Object, _ ...client.UpdateOption) error {
+					updated = true
+					if len(obj.(metav1.Object).GetFinalizers()) != 0 {
+						t.Errorf("\nReason: %s\nUpdate(...): finalizer is not removed", tc.reason)
+					}
+					return tc.update
+				},
+			}
+			cleanup := HookFunc(func(_ context.Context, _ resource.ParentResource, list []resource.ChildResource) error {
+				cleaned = len(list)
+				return tc.cleanup
+			})
+			f := NewChildFinalizer(kube, childFinalizer, cleanup, fake.MockChildGVK.GroupKind())
+			list := []resource.ChildResource{fake.NewMockResource(fake.WithGVK(fake.MockChildGVK))}
+			result, err := f.Delete(context.Background(), fake.NewMockResource(), list)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nDelete(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff([]resource.ChildResource(nil), result); diff != "" {
+				t.Errorf("\nReason: %s\nDelete(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cleaned, cleaned); diff != "" {
+				t.Errorf("\nReason: %s\nDelete(...): -want cleaned, +got cleaned:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.updated, updated); diff != "" {
+				t.Errorf("\nReason: %s\nDelete(...): -want updated, +got updated:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// ClassValuesKey is the key under spec.parameters of the render input that
+// the resource class of the parent resource is exposed with.
+const ClassValuesKey = "class"
+
+const (
+	errParseClassRef = "cannot parse class reference of parent resource"
+	errGetClass      = "cannot get resource class of parent resource"
+	errMergeClass    = "cannot merge resource class defaults into child resource"
+)
+
+// WithResourceClasses returns a ReconcilerOption that makes the parent
+// resources take part in the claim and class model of Crossplane. The resource
+// class that a parent resource refers to is exposed to the templates, and its
+// specTemplate is merged into the spec of the child resources of the given
+// kinds as defaults. All kinds get the defaults if none is given.
+func WithResourceClasses(kinds ...schema.GroupKind) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		WithValuesProvider(NewClassResolver(reconciler.client))(reconciler)
+		WithAdditionalChildResourcePatcher(NewClassDefaulter(kinds...))(reconciler)
+	}
+}
+
+// NewClassResolver returns a new *ClassResolver.
+func NewClassResolver(c client.Reader) *ClassResolver {
+	return &ClassResolver{kube: c}
+}
+
+// ClassResolver is a ValuesProvider that fetches the resource class that the
+// parent resource refers to in spec.classRef, like the resource claims of
+// Crossplane do, and exposes it under the class parameter. Parent resources
+// without a class reference get no values.
+type ClassResolver struct {
+	kube client.Reader
+}
+
+// Values returns the resource class of the given parent resource.
+func (c *ClassResolver) Values(ctx context.Context, cr resource.ParentResource) (map[string]interface{}, error) {
+	ref, err := resource.Accessor(cr).GetClassReference()
+	if err != nil {
+		return nil, errors.Wrap(err, errParseClassRef)
+	}
+	if ref == nil || ref.Name == "" {
+		return nil, nil
+	}
+	class := &unstructured.Unstructured{}
+	class.SetAPIVersion(ref.APIVersion)
+	class.SetKind(ref.Kind)
+	// Resource classes are cluster-scoped, but the namespace is kept for the
+	// ones that are not.
+	if err := c.kube.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, class); err != nil {
+		return nil, errors.Wrap(err, errGetClass)
+	}
+	return map[string]interface{}{ClassValuesKey: class.UnstructuredContent()}, nil
+}
+
+// NewClassDefaulter returns a new ClassDefaulter that merges the defaults into
+// the child resources of the given kinds, or into all of them if no kind is
+// given.
+func NewClassDefaulter(kinds ...schema.GroupKind) ClassDefaulter {
+	selected := map[schema.GroupKind]bool{}
+	for _, gk := range kinds {
+		selected[gk] = true
+	}
+	return ClassDefaulter{kinds: selected}
+}
+
+// ClassDefaulter is a ChildResourcePatcher that merges the specTemplate of the
+// resource class that ClassResolver exposed into the spec of the child
+// resources. The fields that the templates set take precedence over the ones
+// of the class, so that the class only supplies defaults like the
+// providerRef or the reclaimPolicy of managed resources.
+type ClassDefaulter struct {
+	kinds map[schema.GroupKind]bool
+}
+
+// Patch patches the child resources with information in resource.ParentResource.
+func (d ClassDefaulter) Patch(cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	defaults, ok, err := unstructured.NestedMap(cr.UnstructuredContent(), "spec", resource.ParametersField, ClassValuesKey, "specTemplate")
+	if err != nil || !ok {
+		return list, errors.Wrap(err, errMergeClass)
+	}
+	for _, o := range list {
+		if len(d.kinds) != 0 && !d.kinds[o.GetObjectKind().GroupVersionKind().GroupKind()] {
+			continue
+		}
+		u, ok := o.(interface{ UnstructuredContent() map[string]interface{} })
+		if !ok {
+			continue
+		}
+		spec, _, err := unstructured.NestedMap(u.UnstructuredContent(), "spec")
+		if err != nil {
+			return nil, errors.Wrap(err, errMergeClass)
+		}
+		if err := unstructured.SetNestedMap(u.UnstructuredContent(), mergeDefaults(spec, defaults), "spec"); err != nil {
+			return nil, errors.Wrap(err, errMergeClass)
+		}
+	}
+	return list, nil
+}
+
+// mergeDefaults returns the given values with the given defaults set for the
+// fields that the values do not have, merging the objects recursively.
+func mergeDefaults(vals, defaults map[string]interface{}) map[string]interface{} {
+	if vals == nil {
+		vals = map[string]interface{}{}
+	}
+	for k, dv := range defaults {
+		v, ok := vals[k]
+		if !ok {
+			// The defaults are shared by all child resources.
+			vals[k] = runtime.DeepCopyJSONValue(dv)
+			continue
+		}
+		vm, vok := v.(map[string]interface{})
+		dm, dok := dv.(map[string]interface{})
+		if vok && dok {
+			vals[k] = mergeDefaults(vm, dm)
+		}
+	}
+	return vals
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ ValuesProvider       = &ClassResolver{}
+	_ ChildResourcePatcher = ClassDefaulter{}
+)
+
+const classedParent = `
+apiVersion: mock.crossplane.io/v1alpha1
+kind: MockKind
+spec:
+  classRef:
+    apiVersion: database.example.org/v1beta1
+    kind: PostgreSQLInstanceClass
+    name: standard
+`
+
+func TestClassResolver(t *testing.T) {
+	specTemplate := map[string]interface{}{"reclaimPolicy": "Delete"}
+	type want struct {
+		vals map[string]interface{}
+		err  error
+	}
+	cases := map[string]struct {
+		reason string
+		parent string
+		get    test.MockGetFn
+		want   want
+	}{
+		"NoClassRef": {
+			reason: "Parent resources without a class reference should get no values",
+			parent: "apiVersion: mock.crossplane.io/v1alpha1\nkind: MockKind\n",
+		},
+		"Resolved": {
+			reason: "The referred resource class should be exposed",
+			parent: classedParent,
+			get: func(_ context.Context, key client.ObjectKey, obj runtime.Object) error {
+				u := obj.(*unstructured.Unstructured)
+				if u.GetKind() != "PostgreSQLInstanceClass" || key.Name != "standard" {
+					return errors.Errorf("unexpected class %s %s", u.GetKind(), key.Name)
+				}
+				u.Object["specTemplate"] = specTemplate
+				return nil
+			},
+			want: want{vals: map[string]interface{}{ClassValuesKey: map[string]interface{}{
+				"apiVersion":   "database.example.org/v1beta1",
+				"kind":         "PostgreSQLInstanceClass",
+				"specTemplate": specTemplate,
+			}}},
+		},
+		"GetFailed": {
+			reason: "Errors getting the resource class should be returned",
+			parent: classedParent,
+			get:    test.NewMockGetFn(errBoom),
+			want:   want{err: errors.Wrap(errBoom, errGetClass)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cr := fake.NewMockResource(fake.FromYAML([]byte(tc.parent)))
+			vals, err := NewClassResolver(&test.MockClient{MockGet: tc.get}).Values(context.TODO(), cr)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.vals, vals); diff != "" {
+				t.Errorf("\nReason: %s\nValues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestClassDefaulter(t *testing.T) {
+	cr := fake.NewMockResource(fake.FromYAML([]byte(`
+apiVersion: mock.crossplane.io/v1alpha1
+kind: MockKind
+spec:
+  parameters:
+    class:
+      specTemplate:
+        reclaimPolicy: Delete
+        forProvider:
+          storageGB: 20
+          version: "11"
+`)))
+	managed := fake.NewMockResource(fake.WithGVK(schema.GroupVersionKind{Group: "database.example.org", Version: "v1beta1", Kind: "PostgreSQLInstance"}), fake.FromYAML([]byte(`
+apiVersion: database.example.org/v1beta1
+kind: PostgreSQLInstance
+spec:
+  forProvider:
+    storageGB: 50
+`)))
+	other := fake.NewMockResource(fake.WithGVK(fake.MockChildGVK))
+	d := NewClassDefaulter(schema.GroupKind{Group: "database.example.org", Kind: "PostgreSQLInstance"})
+	if _, err := d.Patch(cr, []resource.ChildResource{managed, other}); err != nil {
+		t.Fatalf("Patch(...): %s", err)
+	}
+	want := map[string]interface{}{
+		"reclaimPolicy": "Delete",
+		"forProvider":   map[string]interface{}{"storageGB": int64(50), "version": "11"},
+	}
+	if diff := cmp.Diff(want, managed.UnstructuredContent()["spec"]); diff != "" {
+		t.Errorf("Patch(...): -want spec, +got spec:\n%s", diff)
+	}
+	if _, ok := other.UnstructuredContent()["spec"]; ok {
+		t.Errorf("Patch(...): child resources of other kinds should not get the defaults")
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	errUnknownCluster = "child resource targets an unknown cluster"
+)
+
+// IsRemote returns true if the given object is annotated to be deployed to a
+// cluster other than the local one.
+func IsRemote(o metav1.Object) bool {
+	name, ok := o.GetAnnotations()[TargetClusterAnnotationKey]
+	return ok && name != "" && name != TargetClusterLocalValue
+}
+
+// NewClusterRouter returns a new *ClusterRouter that sends all calls to the
+// given local client until remote clusters are registered.
+func NewClusterRouter(local client.Client) *ClusterRouter {
+	return &ClusterRouter{
+		Client:  local,
+		remotes: map[string]client.Client{},
+	}
+}
+
+// ClusterRouter is a client.Client that sends the calls about objects that are
+// annotated with a remote target cluster to the client of that cluster. The
+// calls about the local objects that are annotated with a user to impersonate
+// go to a local client impersonating that user, if impersonation is enabled.
+// All other calls, including the ones about the parent resource, go to the
+// local client.
+type ClusterRouter struct {
+	client.Client
+	remotes       map[string]client.Client
+	impersonation *impersonatedClients
+	snapshots     SnapshotStore
+}
+
+// Register makes the cluster with given name available as target to the
+// child resources. It is not safe to call Register once the reconciler
+// started.
+func (c *ClusterRouter) Register(name string, kube client.Client) {
+	c.remotes[name] = kube
+}
+
+// Impersonate makes the calls about the local objects that are annotated with
+// ImpersonateAnnotationKey go to the client that the given function returns
+// for the user in the annotation. The clients are created once per user. It
+// is not safe to call Impersonate once the reconciler started.
+func (c *ClusterRouter) Impersonate(newClient func(user string) (client.Client, error)) {
+	c.impersonation = &impersonatedClients{newClient: newClient, clients: map[string]client.Client{}}
+}
+
+// SnapshotBeforeDelete makes the calls that delete an object save its live
+// manifest to the given SnapshotStore first. It is not safe to call
+// SnapshotBeforeDelete once the reconciler started.
+func (c *ClusterRouter) SnapshotBeforeDelete(s SnapshotStore) {
+	c.snapshots = s
+}
+
+// Get retrieves the object from the cluster it targets, recording the read if
+// the render inputs are being reported.
+func (c *ClusterRouter) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	kube, err := c.clientFor(obj)
+	if err != nil {
+		return err
+	}
+	err = kube.Get(ctx, key, obj)
+	if rec := readRecorderFrom(ctx); rec != nil {
+		rec.record(key, obj, err)
+	}
+	return err
+}
+
+// Create creates the object in the cluster it targets.
+func (c *ClusterRouter) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	kube, err := c.clientFor(obj)
+	if err != nil {
+		return err
+	}
+	return kube.Create(ctx, obj, opts...)
+}
+
+// Delete deletes the object from the cluster it targets, after saving its
+// snapshot if a SnapshotStore is configured.
+func (c *ClusterRouter) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	kube, err := c.clientFor(obj)
+	if err != nil {
+		return err
+	}
+	if c.snapshots != nil {
+		if err := saveSnapshot(ctx, kube, c.snapshots, obj); err != nil {
+			return err
+		}
+	}
+	return kube.Delete(ctx, obj, opts...)
+}
+
+// Update updates the object in the cluster it targets.
+func (c *ClusterRouter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	kube, err := c.clientFor(obj)
+	if err != nil {
+		return err
+	}
+	return kube.Update(ctx, obj, opts...)
+}
+
+// Patch patches the object in the cluster it targets.
+func (c *ClusterRouter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	kube, err := c.clientFor(obj)
+	if err != nil {
+		return err
+	}
+	return kube.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *ClusterRouter) clientFor(obj runtime.Object) (client.Client, error) {
+	mobj, ok := obj.(metav1.Object)
+	if !ok {
+		return c.Client, nil
+	}
+	if !IsRemote(mobj) {
+		return c.localClientFor(mobj)
+	}
+	name := mobj.GetAnnotations()[TargetClusterAnnotationKey]
+	kube, ok := c.remotes[name]
+	if !ok {
+		return nil, errors.Errorf("%s: %s", errUnknownCluster, name)
+	}
+	return kube, nil
+}
+
+func (c *ClusterRouter) localClientFor(obj metav1.Object) (client.Client, error) {
+	user := obj.GetAnnotations()[ImpersonateAnnotationKey]
+	if user == "" {
+		return c.Client, nil
+	}
+	if c.impersonation == nil {
+		return nil, errors.New(errImpersonationNotEnabled)
+	}
+	return c.impersonation.clientFor(user)
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ client.Client = &ClusterRouter{}
+)
+
+func TestClusterRouter_Get(t *testing.T) {
+	errLocal := errors.New("local")
+	errRemote := errors.New("remote")
+	errImpersonated := errors.New("impersonated")
+	type args struct {
+		remotes     map[string]client.Client
+		impersonate bool
+		obj         resource.ChildResource
+	}
+	type want struct {
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"NoAnnotation": {
+			reason: "Objects without target cluster annotation should go to the local cluster",
+			args: args{
+				obj: fake.NewMockResource(),
+			},
+			want: want{
+				err: errLocal,
+			},
+		},
+		"LocalValue": {
+			reason: "Objects that explicitly target the local cluster should go to the local cluster",
+			args: args{
+				remotes: map[string]client.Client{"remote": &test.MockClient{MockGet: test.NewMockGetFn(errRemote)}},
+				obj:     fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{TargetClusterAnnotationKey: TargetClusterLocalValue})),
+			},
+			want: want{
+				err: errLocal,
+			},
+		},
+		"Remote": {
+			reason: "Objects that target a registered remote cluster should go to that cluster",
+			args: args{
+				remotes: map[string]client.Client{"remote": &test.MockClient{MockGet: test.NewMockGetFn(errRemote)}},
+				obj:     fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{TargetClusterAnnotationKey: "remote"})),
+			},
+			want: want{
+				err: errRemote,
+			},
+		},
+		"UnknownRemote": {
+			reason: "It should return error if the object targets a cluster that is not registered",
+			args: args{
+				obj: fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{TargetClusterAnnotationKey: "olala"})),
+			},
+			want: want{
+				err: errors.Errorf("%s: %s", errUnknownCluster, "olala"),
+			},
+		},
+		"Impersonated": {
+			reason: "Local objects that are annotated with a user should go to the client impersonating that user",
+			args: args{
+				impersonate: true,
+				obj:         fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{ImpersonateAnnotationKey: ServiceAccountUser("ns", "tenant")})),
+			},
+			want: want{
+				err: errImpersonated,
+			},
+		},
+		"ImpersonationNotEnabled": {
+			reason: "It should return error if the object requests impersonation but it is not enabled",
+			args: args{
+				obj: fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{ImpersonateAnnotationKey: ServiceAccountUser("ns", "tenant")})),
+			},
+			want: want{
+				err: errors.New(errImpersonationNotEnabled),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewClusterRouter(&test.MockClient{
+				MockGet: func(_ context.Context, _ client.ObjectKey, _ runtime.Object) error {
+					return errLocal
+				},
+			})
+			for n, c := range tc.args.remotes {
+				r.Register(n, c)
+			}
+			if tc.args.impersonate {
+				r.Impersonate(func(_ string) (client.Client, error) {
+					return &test.MockClient{MockGet: test.NewMockGetFn(errImpersonated)}, nil
+				})
+			}
+			err := r.Get(context.Background(), client.ObjectKey{}, tc.args.obj)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nGet(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errListGenerated   = "cannot list generated objects"
+	errDeleteGenerated = "cannot delete superseded generated object"
+
+	msgSuperseded = "superseded generated objects are deleted"
+)
+
+type generatedGroup struct {
+	gvk       schema.GroupVersionKind
+	namespace string
+	generator string
+}
+
+// NewGeneratedObjectCollector returns a new *GeneratedObjectCollector that
+// keeps the given number of superseded generated objects of every generator.
+func NewGeneratedObjectCollector(c client.Client, keep int) *GeneratedObjectCollector {
+	return &GeneratedObjectCollector{kube: c, keep: keep}
+}
+
+// GeneratedObjectCollector is a post-apply Hook that deletes the generated
+// objects, i.e. the ones labeled with resource.GeneratedFromLabelKey, that
+// were superseded by an object with a different hash suffix in the latest
+// render. The latest superseded ones are kept so that the workloads which
+// still refer to them, e.g. during a rolling update, keep working.
+type GeneratedObjectCollector struct {
+	kube         client.Client
+	keep         int
+	notifier     Notifier
+	confirmation *pruneConfirmation
+	history      int
+}
+
+// SetNotifier makes the collector notify the given Notifier about the
+// generated objects it deletes. Failing to send a notification does not fail
+// the collection.
+func (c *GeneratedObjectCollector) SetNotifier(n Notifier) {
+	c.notifier = n
+}
+
+// SetConditionHistory makes the collector keep the given number of the latest
+// transitions of the PruneConfirmation condition in the condition history of
+// the parent resource, like the reconciler does with WithConditionHistory.
+func (c *GeneratedObjectCollector) SetConditionHistory(limit int) {
+	c.history = limit
+}
+
+// RequireConfirmation makes the collector delete the superseded generated
+// objects in two phases to protect against the mass deletions that a bad
+// change of the templates would cause. The candidates are first annotated
+// with the time they became candidates and listed in the PruneConfirmation
+// condition of the parent resource, and only deleted once the parent resource
+// is annotated with the token in the condition or the given grace period has
+// elapsed. A grace period of zero deletes them only once confirmed.
+func (c *GeneratedObjectCollector) RequireConfirmation(grace time.Duration) {
+	c.confirmation = &pruneConfirmation{grace: grace, now: time.Now}
+}
+
+// Run deletes the superseded generated objects of the given parent resource.
+func (c *GeneratedObjectCollector) Run(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) error {
+	var pruned []resource.ChildReference
+	defer func() {
+		if c.notifier != nil && len(pruned) > 0 {
+			_ = c.notifier.Notify(ctx, newNotification(cr, OutcomePruned, msgSuperseded, pruned))
+		}
+	}()
+	superseded, err := c.superseded(ctx, cr, list)
+	if err != nil {
+		return err
+	}
+	if c.confirmation != nil {
+		var pending []*unstructured.Unstructured
+		superseded, pending = c.confirmation.confirmed(cr, superseded)
+		if err := c.confirmation.mark(ctx, c.kube, cr, pending, c.history); err != nil {
+			return err
+		}
+	}
+	for _, o := range superseded {
+		if err := c.kube.Delete(ctx, o); client.IgnoreNotFound(err) != nil {
+			return errors.Wrap(err, errDeleteGenerated)
+		}
+		pruned = append(pruned, resource.ReferenceTo(o))
+	}
+	return nil
+}
+
+// PlanDeletion returns the superseded generated objects that Run would
+// delete, leaving out the ones that wait for confirmation.
+func (c *GeneratedObjectCollector) PlanDeletion(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	superseded, err := c.superseded(ctx, cr, list)
+	if err != nil {
+		return nil, err
+	}
+	if c.confirmation != nil {
+		superseded, _ = c.confirmation.confirmed(cr, superseded)
+	}
+	result := make([]resource.ChildResource, len(superseded))
+	for i, o := range superseded {
+		result[i] = o
+	}
+	return result, nil
+}
+
+// superseded returns the superseded generated objects of the given parent
+// resource that are not among the latest ones to keep.
+func (c *GeneratedObjectCollector) superseded(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) ([]*unstructured.Unstructured, error) {
+	current := map[generatedGroup]map[string]bool{}
+	for _, o := range list {
+		gen, ok := o.GetLabels()[resource.GeneratedFromLabelKey]
+		if !ok {
+			continue
+		}
+		g := generatedGroup{gvk: o.GetObjectKind().GroupVersionKind(), namespace: o.GetNamespace(), generator: gen}
+		if current[g] == nil {
+			current[g] = map[string]bool{}
+		}
+		current[g][o.GetName()] = true
+	}
+	var result []*unstructured.Unstructured
+	for g, names := range current {
+		l := &unstructured.UnstructuredList{}
+		l.SetGroupVersionKind(g.gvk.GroupVersion().WithKind(g.gvk.Kind + "List"))
+		if err := c.kube.List(ctx, l, client.InNamespace(g.namespace), client.MatchingLabels{resource.GeneratedFromLabelKey: g.generator}); err != nil {
+			return nil, errors.Wrap(err, errListGenerated)
+		}
+		var superseded []*unstructured.Unstructured
+		for i := range l.Items {
+			o := &l.Items[i]
+			if names[o.GetName()] {
+				continue
+			}
+			if ref := metav1.GetControllerOf(o); ref == nil || ref.UID != cr.GetUID() {
+				continue
+			}
+			superseded = append(superseded, o)
+		}
+		if len(superseded) <= c.keep {
+			continue
+		}
+		sort.SliceStable(superseded, func(i, j int) bool {
+			ti, tj := superseded[i].GetCreationTimestamp(), superseded[j].GetCreationTimestamp()
+			return tj.Before(&ti)
+		})
+		result = append(result, superseded[c.keep:]...)
+	}
+	return result, nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ Hook            = &GeneratedObjectCollector{}
+	_ DeletionPlanner = &GeneratedObjectCollector{}
+)
+
+func TestGeneratedObjectCollector(t *testing.T) {
+	cr := fake.NewMockResource(fake.WithUID("parent"))
+	now := time.Now()
+	generated := func(name string, age time.Duration) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+		u.SetName(name)
+		u.SetLabels(map[string]string{resource.GeneratedFromLabelKey: "cool-parameters"})
+		u.SetCreationTimestamp(metav1.NewTime(now.Add(-age)))
+		meta.AddOwnerReference(&u, meta.AsController(meta.ReferenceTo(cr, cr.GroupVersionKind())))
+		return u
+	}
+	latest := generated("cool-parameters-aaaaaaaaaa", 0)
+	existing := []unstructured.Unstructured{
+		latest,
+		generated("cool-parameters-bbbbbbbbbb", time.Minute),
+		generated("cool-parameters-cccccccccc", time.Hour),
+		generated("cool-parameters-dddddddddd", 2*time.Hour),
+	}
+	cases := map[string]struct {
+		reason string
+		keep   int
+		want   []string
+	}{
+		"KeepOne": {
+			reason: "All superseded objects but the latest one should be deleted",
+			keep:   1,
+			want:   []string{"cool-parameters-cccccccccc", "cool-parameters-dddddddddd"},
+		},
+		"KeepMore": {
+			reason: "Nothing should be deleted if there are fewer superseded objects than the limit",
+			keep:   5,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var deleted []string
+			kube := &test.MockClient{
+				MockList: test.NewMockListFn(nil, func(obj runtime.Object) error {
+					obj.(*unstructured.UnstructuredList).Items = existing
+					return nil
+				}),
+				MockDelete: func(_ context.Context, obj runtime.Object, _ ...client.DeleteOption) error {
+					deleted = append(deleted, obj.(metav1.Object).GetName())
+					return nil
+				},
+			}
+			current := latest.DeepCopy()
+			if err := NewGeneratedObjectCollector(kube, tc.keep).Run(context.Background(), cr, []resource.ChildResource{current}); err != nil {
+				t.Errorf("\nReason: %s\nRun(...): unexpected error: %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, deleted); diff != "" {
+				t.Errorf("\nReason: %s\nRun(...): -want deleted, +got deleted:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// ControllerConfigGroupVersionKind is the GroupVersionKind of the
+// cluster-scoped ControllerConfig objects that tune the controller at runtime.
+var ControllerConfigGroupVersionKind = schema.GroupVersionKind{
+	Group:   "templatestacks.crossplane.io",
+	Version: "v1alpha1",
+	Kind:    "ControllerConfig",
+}
+
+const (
+	errGetControllerConfig     = "cannot get controller config"
+	errParseControllerConfig   = "cannot parse spec of controller config"
+	errInvalidControllerConfig = "invalid controller config"
+)
+
+// ApplyMode decides whether the child resources are written.
+type ApplyMode string
+
+// Apply modes.
+const (
+	// ApplyModeApply applies the child resources. It is the default.
+	ApplyModeApply ApplyMode = "Apply"
+
+	// ApplyModeReportOnly only reports what would be changed in the child
+	// resources, like WithReportOnly does.
+	ApplyModeReportOnly ApplyMode = "ReportOnly"
+)
+
+// ControllerConfigSpec is the spec of a ControllerConfig. The fields that are
+// not set keep the behavior that the options of the reconciler configured.
+type ControllerConfigSpec struct {
+	// ShortWait is how long to wait before retrying a failed reconcile.
+	ShortWait *metav1.Duration `json:"shortWait,omitempty"`
+
+	// LongWait is how long to wait before reconciling a parent resource
+	// whose reconcile succeeded.
+	LongWait *metav1.Duration `json:"longWait,omitempty"`
+
+	// Prune decides whether the superseded generated objects are deleted.
+	Prune *bool `json:"prune,omitempty"`
+
+	// ApplyMode decides whether the child resources are written.
+	ApplyMode ApplyMode `json:"applyMode,omitempty"`
+
+	// MaxConcurrentReconciles caps the number of parent resources that are
+	// reconciled at the same time, below the number of workers of the
+	// controller. Zero means no cap.
+	MaxConcurrentReconciles *int `json:"maxConcurrentReconciles,omitempty"`
+}
+
+func (s ControllerConfigSpec) validate() error {
+	switch {
+	case s.ShortWait != nil && s.ShortWait.Duration <= 0:
+		return errors.Errorf("%s: shortWait must be positive", errInvalidControllerConfig)
+	case s.LongWait != nil && s.LongWait.Duration <= 0:
+		return errors.Errorf("%s: longWait must be positive", errInvalidControllerConfig)
+	case s.MaxConcurrentReconciles != nil && *s.MaxConcurrentReconciles < 0:
+		return errors.Errorf("%s: maxConcurrentReconciles must not be negative", errInvalidControllerConfig)
+	}
+	switch s.ApplyMode {
+	case "", ApplyModeApply, ApplyModeReportOnly:
+		return nil
+	}
+	return errors.Errorf("%s: unknown apply mode %s", errInvalidControllerConfig, s.ApplyMode)
+}
+
+// NewControllerConfigStore returns a new *ControllerConfigStore that reads the
+// ControllerConfig with the given name.
+func NewControllerConfigStore(c client.Reader, name string) *ControllerConfigStore {
+	return &ControllerConfigStore{
+		kube:    c,
+		name:    name,
+		log:     logging.NewNopLogger(),
+		limiter: newConcurrencyLimiter(func(_ resource.ParentResource) string { return "" }, 0),
+	}
+}
+
+// ControllerConfigStore keeps the spec of a ControllerConfig so that the
+// operators can tune the waits, the pruning, the apply mode and the
+// concurrency of a running controller without redeploying it. The templates
+// and their source are given to the engine once at start and cannot be
+// changed by a ControllerConfig.
+type ControllerConfigStore struct {
+	kube client.Reader
+	name string
+	log  logging.Logger
+
+	mu      sync.RWMutex
+	spec    ControllerConfigSpec
+	limiter *concurrencyLimiter
+}
+
+// SetLogger makes the store log the ControllerConfigs it fails to read.
+func (s *ControllerConfigStore) SetLogger(l logging.Logger) {
+	s.log = l
+}
+
+// Refresh reads the ControllerConfig. A missing ControllerConfig resets the
+// spec, while an invalid one keeps the spec that was read last.
+func (s *ControllerConfigStore) Refresh(ctx context.Context) error {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(ControllerConfigGroupVersionKind)
+	spec := ControllerConfigSpec{}
+	err := s.kube.Get(ctx, types.NamespacedName{Name: s.name}, u)
+	if client.IgnoreNotFound(err) != nil {
+		return errors.Wrap(err, errGetControllerConfig)
+	}
+	if !kerrors.IsNotFound(err) {
+		content, _, err := unstructured.NestedMap(u.UnstructuredContent(), "spec")
+		if err != nil {
+			return errors.Wrap(err, errParseControllerConfig)
+		}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, &spec); err != nil {
+			return errors.Wrap(err, errParseControllerConfig)
+		}
+		if err := spec.validate(); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spec = spec
+	max := 0
+	if spec.MaxConcurrentReconciles != nil {
+		max = *spec.MaxConcurrentReconciles
+	}
+	s.limiter.setLimit(max)
+	return nil
+}
+
+// Spec returns the spec that was read last.
+func (s *ControllerConfigStore) Spec() ControllerConfigSpec {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.spec
+}
+
+// NewControllerConfigRunnable returns a function that reads the
+// ControllerConfig of the given store once and then in the given interval
+// until the given channel is closed. It can be added to a manager as a
+// manager.RunnableFunc.
+func NewControllerConfigRunnable(s *ControllerConfigStore, interval time.Duration) func(stop <-chan struct{}) error {
+	return func(stop <-chan struct{}) error {
+		refresh := func() {
+			ctx, cancel := context.WithTimeout(context.Background(), reconcileTimeout)
+			defer cancel()
+			if err := s.Refresh(ctx); err != nil {
+				s.log.Info("Cannot read the controller config", "name", s.name, "error", err)
+			}
+		}
+		refresh()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return nil
+			case <-t.C:
+				refresh()
+			}
+		}
+	}
+}
+
+// configured returns a copy of the reconciler with the settings of the given
+// spec, so that a reconcile uses the same settings from start to end while
+// the spec changes.
+func (r *Reconciler) configured(spec ControllerConfigSpec) *Reconciler {
+	c := *r
+	if spec.ShortWait != nil {
+		c.shortWait = spec.ShortWait.Duration
+	}
+	if spec.LongWait != nil {
+		c.longWait = spec.LongWait.Duration
+	}
+	switch spec.ApplyMode {
+	case ApplyModeApply:
+		c.reportOnly = false
+	case ApplyModeReportOnly:
+		c.reportOnly = true
+	}
+	if spec.Prune != nil && !*spec.Prune {
+		c.hooks.PostApply = make(HookChain, 0, len(r.hooks.PostApply))
+		for _, h := range r.hooks.PostApply {
+			if _, ok := h.(*GeneratedObjectCollector); !ok {
+				c.hooks.PostApply = append(c.hooks.PostApply, h)
+			}
+		}
+	}
+	return &c
+}
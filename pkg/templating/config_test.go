@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestControllerConfigStoreRefresh(t *testing.T) {
+	withSpec := func(spec map[string]interface{}) test.MockGetFn {
+		return func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+			obj.(*unstructured.Unstructured).Object["spec"] = spec
+			return nil
+		}
+	}
+	short := ControllerConfigSpec{ApplyMode: ApplyModeReportOnly}
+	prune, max := false, 2
+	cases := map[string]struct {
+		reason string
+		get    test.MockGetFn
+		want   ControllerConfigSpec
+		err    error
+	}{
+		"Valid": {
+			reason: "A valid ControllerConfig should replace the spec",
+			get:    withSpec(map[string]interface{}{"shortWait": "5s", "prune": false, "maxConcurrentReconciles": int64(2)}),
+			want:   ControllerConfigSpec{ShortWait: &metav1.Duration{Duration: 5 * time.Second}, Prune: &prune, MaxConcurrentReconciles: &max},
+		},
+		"NotFound": {
+			reason: "A missing ControllerConfig should reset the spec",
+			get:    test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+			want:   ControllerConfigSpec{},
+		},
+		"Invalid": {
+			reason: "An invalid ControllerConfig should keep the spec that was read last",
+			get:    withSpec(map[string]interface{}{"applyMode": "Sometimes"}),
+			want:   short,
+			err:    errors.Errorf("%s: unknown apply mode Sometimes", errInvalidControllerConfig),
+		},
+		"GetFailed": {
+			reason: "Errors getting the ControllerConfig should be returned",
+			get:    test.NewMockGetFn(errBoom),
+			want:   short,
+			err:    errors.Wrap(errBoom, errGetControllerConfig),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := NewControllerConfigStore(&test.MockClient{MockGet: tc.get}, "default")
+			s.spec = short
+			err := s.Refresh(context.TODO())
+			if diff := cmp.Diff(tc.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRefresh(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want, s.Spec()); diff != "" {
+				t.Errorf("\n%s\nSpec(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestControllerConfigStoreConcurrency(t *testing.T) {
+	s := NewControllerConfigStore(&test.MockClient{MockGet: func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+		obj.(*unstructured.Unstructured).Object["spec"] = map[string]interface{}{"maxConcurrentReconciles": int64(1)}
+		return nil
+	}}, "default")
+	if err := s.Refresh(context.TODO()); err != nil {
+		t.Fatalf("Refresh(...): %s", err)
+	}
+	release, ok := s.limiter.Acquire(nil)
+	if !ok {
+		t.Fatalf("Acquire(...): want the first reconcile to be admitted")
+	}
+	if _, ok := s.limiter.Acquire(nil); ok {
+		t.Errorf("Acquire(...): want the second reconcile to wait for the first")
+	}
+	release()
+	if _, ok := s.limiter.Acquire(nil); !ok {
+		t.Errorf("Acquire(...): want a reconcile to be admitted once the first is done")
+	}
+}
+
+func TestConfigured(t *testing.T) {
+	collector := NewGeneratedObjectCollector(nil, 1)
+	r := &Reconciler{shortWait: time.Minute, longWait: time.Hour, reportOnly: true, hooks: crHooks{PostApply: HookChain{NewJobCompletionHook(), collector}}}
+
+	spec := ControllerConfigSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(map[string]interface{}{
+		"shortWait": "5s",
+		"prune":     false,
+		"applyMode": string(ApplyModeApply),
+	}, &spec); err != nil {
+		t.Fatalf("FromUnstructured(...): %s", err)
+	}
+	c := r.configured(spec)
+	if c.shortWait != 5*time.Second || c.longWait != time.Hour || c.reportOnly {
+		t.Errorf("configured(...): want shortWait 5s, longWait 1h and no report-only, got %s, %s and %t", c.shortWait, c.longWait, c.reportOnly)
+	}
+	if _, ok := c.hooks.PostApply[0].(JobCompletionHook); len(c.hooks.PostApply) != 1 || !ok {
+		t.Errorf("configured(...): want only the collector to be removed from the post-apply hooks when pruning is disabled")
+	}
+	if len(r.hooks.PostApply) != 2 || r.shortWait != time.Minute {
+		t.Errorf("configured(...): want the reconciler itself to be unchanged")
+	}
+}
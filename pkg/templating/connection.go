@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	rresource "github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errGetChildSecret       = "cannot get connection secret of child resource"
+	errApplyParentSecret    = "cannot apply connection secret of parent resource"
+	errNoSecretNamespace    = "connection secret of cluster-scoped parent resource must have a namespace"
+	errSecretRefNotAnObject = "writeConnectionSecretToRef is not an object"
+)
+
+// ConnectionSecretRef returns the reference to the connection secret that the
+// object with given content writes to, i.e. its spec.writeConnectionSecretToRef,
+// defaulting the namespace to the given one. The returned bool is false if
+// the object does not write a connection secret.
+func ConnectionSecretRef(content map[string]interface{}, namespace string) (types.NamespacedName, bool, error) {
+	ref, err := resource.NewUnstructuredParentAccessor(content).GetWriteConnectionSecretToReference()
+	if err != nil {
+		return types.NamespacedName{}, false, errors.Wrap(err, errSecretRefNotAnObject)
+	}
+	if ref == nil || ref.Name == "" {
+		return types.NamespacedName{}, false, nil
+	}
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+	return types.NamespacedName{Name: ref.Name, Namespace: namespace}, true, nil
+}
+
+// NewConnectionSecretPropagator returns a new *ConnectionSecretPropagator.
+func NewConnectionSecretPropagator(c client.Client) *ConnectionSecretPropagator {
+	return &ConnectionSecretPropagator{kube: c}
+}
+
+// ConnectionSecretPropagator is a post-apply Hook that merges the connection
+// secrets of the child resources annotated with
+// PropagateConnectionSecretAnnotationKey into the connection secret of the
+// parent resource. Since the child resources can be parent resources of other
+// packs, connection details flow up in a hierarchy of nested packs.
+type ConnectionSecretPropagator struct {
+	kube client.Client
+}
+
+// Run propagates the connection secrets. The child resources whose connection
+// secret is not published yet are skipped; they will be picked up once the
+// hook runs again.
+func (p *ConnectionSecretPropagator) Run(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) error {
+	target, ok, err := ConnectionSecretRef(cr.UnstructuredContent(), cr.GetNamespace())
+	if err != nil || !ok {
+		return err
+	}
+	if target.Namespace == "" {
+		return errors.New(errNoSecretNamespace)
+	}
+	data := map[string][]byte{}
+	for _, o := range list {
+		if o.GetAnnotations()[PropagateConnectionSecretAnnotationKey] != PropagateConnectionSecretTrueValue {
+			continue
+		}
+		u, ok := o.(interface{ UnstructuredContent() map[string]interface{} })
+		if !ok {
+			continue
+		}
+		ref, ok, err := ConnectionSecretRef(u.UnstructuredContent(), o.GetNamespace())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		s := &corev1.Secret{}
+		err = p.kube.Get(ctx, ref, s)
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, errGetChildSecret)
+		}
+		for k, v := range s.Data {
+			data[k] = v
+		}
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	s := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: target.Name, Namespace: target.Namespace},
+		Data:       data,
+	}
+	meta.AddOwnerReference(s, meta.AsController(meta.ReferenceTo(cr, cr.GroupVersionKind())))
+	return errors.Wrap(rresource.NewAPIPatchingApplicator(p.kube).Apply(ctx, s), errApplyParentSecret)
+}
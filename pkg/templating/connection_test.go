@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ Hook = &ConnectionSecretPropagator{}
+)
+
+func withSecretRef(name, ns string) fake.MockResourceOption {
+	return func(r *fake.MockResource) {
+		_ = unstructured.SetNestedStringMap(r.Object, map[string]string{"name": name, "namespace": ns}, "spec", "writeConnectionSecretToRef")
+	}
+}
+
+func TestConnectionSecretPropagator(t *testing.T) {
+	propagate := fake.WithAdditionalAnnotations(map[string]string{PropagateConnectionSecretAnnotationKey: PropagateConnectionSecretTrueValue})
+	childSecrets := func(_ context.Context, key client.ObjectKey, obj runtime.Object) error {
+		switch key.Name {
+		case "first":
+			obj.(*corev1.Secret).Data = map[string][]byte{"user": []byte("cool")}
+		case "second":
+			obj.(*corev1.Secret).Data = map[string][]byte{"password": []byte("secret")}
+		default:
+			return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+		}
+		return nil
+	}
+	type args struct {
+		kube client.Client
+		cr   resource.ParentResource
+		list []resource.ChildResource
+	}
+	cases := map[string]struct {
+		reason string
+		args
+		err error
+	}{
+		"ParentWithoutSecret": {
+			reason: "Nothing should be done if the parent resource does not write a connection secret",
+			args: args{
+				cr:   fake.NewMockResource(),
+				list: []resource.ChildResource{fake.NewMockResource(propagate, withSecretRef("first", "ns"))},
+			},
+		},
+		"ClusterScopedWithoutNamespace": {
+			reason: "It should return error if the connection secret of the parent resource has no namespace",
+			args: args{
+				cr: fake.NewMockResource(withSecretRef("parent", "")),
+			},
+			err: errors.New(errNoSecretNamespace),
+		},
+		"Merge": {
+			reason: "Connection secrets of the annotated child resources should be merged into the one of the parent resource",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: childSecrets,
+					MockCreate: test.NewMockCreateFn(nil, func(obj runtime.Object) error {
+						want := map[string][]byte{"user": []byte("cool"), "password": []byte("secret")}
+						if diff := cmp.Diff(want, obj.(*corev1.Secret).Data); diff != "" {
+							t.Errorf("Run(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+				},
+				cr: fake.NewMockResource(withSecretRef("parent", "ns")),
+				list: []resource.ChildResource{
+					fake.NewMockResource(propagate, withSecretRef("first", "ns")),
+					fake.NewMockResource(propagate, withSecretRef("second", "ns")),
+					fake.NewMockResource(withSecretRef("ignored", "ns")),
+					fake.NewMockResource(propagate, withSecretRef("notpublished", "ns")),
+				},
+			},
+		},
+		"GetFailed": {
+			reason: "It should return error if the connection secret of a child resource cannot be fetched",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				cr:   fake.NewMockResource(withSecretRef("parent", "ns")),
+				list: []resource.ChildResource{fake.NewMockResource(propagate, withSecretRef("first", "ns"))},
+			},
+			err: errors.Wrap(errBoom, errGetChildSecret),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := NewConnectionSecretPropagator(tc.args.kube).Run(context.Background(), tc.args.cr, tc.args.list)
+			if diff := cmp.Diff(tc.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nRun(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
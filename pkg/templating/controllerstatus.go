@@ -0,0 +1,143 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// NewControllerStatus returns a new *ControllerStatus whose metrics are
+// labeled with the given GroupVersionKind of the parent resources. A parent
+// resource is behind schedule once it has not been reconciled for the given
+// slack past the time its last reconcile asked to be requeued at.
+func NewControllerStatus(gvk schema.GroupVersionKind, slack time.Duration) *ControllerStatus {
+	l := parentGVKLabels(gvk)
+	return &ControllerStatus{
+		slack: slack,
+		now:   time.Now,
+		due:   map[types.NamespacedName]time.Time{},
+		inFlightDesc: prometheus.NewDesc(
+			"templating_controller_reconciles_in_flight",
+			"Number of parent resources that are being reconciled.",
+			nil, l,
+		),
+		behindDesc: prometheus.NewDesc(
+			"templating_controller_parents_behind_schedule",
+			"Number of parent resources that were due to be reconciled but were not.",
+			nil, l,
+		),
+		reconcilesDesc: prometheus.NewDesc(
+			"templating_controller_reconciles_total",
+			"Number of reconciles of the parent resources.",
+			nil, l,
+		),
+		errorsDesc: prometheus.NewDesc(
+			"templating_controller_reconcile_errors_total",
+			"Number of reconciles of the parent resources that failed.",
+			nil, l,
+		),
+	}
+}
+
+// ControllerStatus is a prometheus.Collector that exports how well the
+// controller keeps up with its parent resources, so that operators can alert
+// when it falls behind before the users notice their stale packs. The depth
+// of the queue of the controller is exported by controller-runtime as the
+// workqueue_depth metric whose name label is the lowercase kind of the parent
+// resources.
+type ControllerStatus struct {
+	slack time.Duration
+	now   func() time.Time
+
+	inFlightDesc   *prometheus.Desc
+	behindDesc     *prometheus.Desc
+	reconcilesDesc *prometheus.Desc
+	errorsDesc     *prometheus.Desc
+
+	mu         sync.Mutex
+	inFlight   int
+	reconciles int
+	errors     int
+	due        map[types.NamespacedName]time.Time
+}
+
+// Start records the start of the reconcile of the parent resource with given
+// key. The returned function must be called with the parent resource and the
+// outcome of the reconcile once it is done. A reconcile fails if it returns an
+// error or leaves the parent resource with a failed Synced condition. The
+// parent resources that no longer exist, are being deleted or are not
+// requeued after a delay are not expected to be reconciled again.
+func (s *ControllerStatus) Start(key types.NamespacedName) func(cr resource.ParentResource, result ctrl.Result, err error) {
+	s.mu.Lock()
+	s.inFlight++
+	s.mu.Unlock()
+	return func(cr resource.ParentResource, result ctrl.Result, err error) {
+		failed := err != nil
+		if c, cerr := resource.GetCondition(cr, v1alpha1.TypeSynced); cerr == nil && c.Reason == v1alpha1.ReasonReconcileError {
+			failed = true
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.inFlight--
+		s.reconciles++
+		if failed {
+			s.errors++
+		}
+		if cr.GetUID() == "" || cr.GetDeletionTimestamp() != nil || err != nil || result.RequeueAfter <= 0 {
+			// Failed reconciles are requeued with a backoff that we cannot
+			// know, so they are only counted as errors.
+			delete(s.due, key)
+			return
+		}
+		s.due[key] = s.now().Add(result.RequeueAfter)
+	}
+}
+
+// Describe sends the descriptors of the metrics.
+func (s *ControllerStatus) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.inFlightDesc
+	ch <- s.behindDesc
+	ch <- s.reconcilesDesc
+	ch <- s.errorsDesc
+}
+
+// Collect sends the current values of the metrics.
+func (s *ControllerStatus) Collect(ch chan<- prometheus.Metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	behind := 0
+	for _, due := range s.due {
+		if now.After(due.Add(s.slack)) {
+			behind++
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(s.inFlightDesc, prometheus.GaugeValue, float64(s.inFlight))
+	ch <- prometheus.MustNewConstMetric(s.behindDesc, prometheus.GaugeValue, float64(behind))
+	ch <- prometheus.MustNewConstMetric(s.reconcilesDesc, prometheus.CounterValue, float64(s.reconciles))
+	ch <- prometheus.MustNewConstMetric(s.errorsDesc, prometheus.CounterValue, float64(s.errors))
+}
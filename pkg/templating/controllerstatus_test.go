@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ prometheus.Collector = &ControllerStatus{}
+
+func TestControllerStatus(t *testing.T) {
+	start := time.Unix(1591000000, 0)
+	now := start
+	s := NewControllerStatus(fake.MockParentGVK, time.Minute)
+	s.now = func() time.Time { return now }
+
+	parent := func(name string, c ...v1alpha1.Condition) resource.ParentResource {
+		cr := fake.NewMockResource(fake.WithNamespaceName(name, "apps"), fake.WithUID(types.UID(name)))
+		_ = resource.SetConditions(cr, c...)
+		return cr
+	}
+	key := func(name string) types.NamespacedName {
+		return types.NamespacedName{Namespace: "apps", Name: name}
+	}
+
+	// A parent resource that is reconciled every minute.
+	s.Start(key("cool"))(parent("cool", v1alpha1.ReconcileSuccess()), ctrl.Result{RequeueAfter: time.Minute}, nil)
+	// A parent resource whose reconcile left a failed Synced condition.
+	s.Start(key("broken"))(parent("broken", v1alpha1.ReconcileError(errBoom)), ctrl.Result{RequeueAfter: time.Minute}, nil)
+	// A parent resource whose reconcile returned an error is requeued with a
+	// backoff, so it is not expected at a time.
+	s.Start(key("failing"))(parent("failing"), ctrl.Result{}, errBoom)
+	// A parent resource that no longer exists.
+	s.Start(key("gone"))(fake.NewMockResource(), ctrl.Result{}, nil)
+	// A parent resource whose reconcile did not finish.
+	s.Start(key("slow"))
+
+	now = start.Add(2*time.Minute + time.Second)
+	// The parent resource is reconciled again, in time.
+	s.Start(key("cool"))(parent("cool", v1alpha1.ReconcileSuccess()), ctrl.Result{RequeueAfter: time.Minute}, nil)
+
+	want := `
+# HELP templating_controller_parents_behind_schedule Number of parent resources that were due to be reconciled but were not.
+# TYPE templating_controller_parents_behind_schedule gauge
+templating_controller_parents_behind_schedule{group="mock.parent.crossplane.io",kind="MockResource",version="v1alpha1"} 1
+# HELP templating_controller_reconcile_errors_total Number of reconciles of the parent resources that failed.
+# TYPE templating_controller_reconcile_errors_total counter
+templating_controller_reconcile_errors_total{group="mock.parent.crossplane.io",kind="MockResource",version="v1alpha1"} 2
+# HELP templating_controller_reconciles_in_flight Number of parent resources that are being reconciled.
+# TYPE templating_controller_reconciles_in_flight gauge
+templating_controller_reconciles_in_flight{group="mock.parent.crossplane.io",kind="MockResource",version="v1alpha1"} 1
+# HELP templating_controller_reconciles_total Number of reconciles of the parent resources.
+# TYPE templating_controller_reconciles_total counter
+templating_controller_reconciles_total{group="mock.parent.crossplane.io",kind="MockResource",version="v1alpha1"} 5
+`
+	if err := testutil.CollectAndCompare(s, strings.NewReader(want)); err != nil {
+		t.Errorf("Collect(...): %s", err)
+	}
+}
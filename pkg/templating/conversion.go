@@ -0,0 +1,105 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errConvert   = "cannot convert parent resource"
+	errMoveField = "cannot move field"
+)
+
+// SpecVersion returns the version of the parent API that the spec of the given
+// parent resource was last written in, as recorded in its managed fields. It
+// returns the version of the given object if there is no such record.
+func SpecVersion(o metav1.Object, served schema.GroupVersionKind) string {
+	mf, ok := latestSpecEntry(o, served.Group)
+	if !ok {
+		return served.Version
+	}
+	gv, _ := schema.ParseGroupVersion(mf.APIVersion)
+	return gv.Version
+}
+
+// latestSpecEntry returns the managed fields entry of the latest write to the
+// spec of the given object through the given API group.
+func latestSpecEntry(o metav1.Object, group string) (metav1.ManagedFieldsEntry, bool) {
+	var result metav1.ManagedFieldsEntry
+	found := false
+	for _, mf := range o.GetManagedFields() {
+		if mf.FieldsV1 == nil || !bytes.Contains(mf.FieldsV1.Raw, []byte(`"f:spec"`)) {
+			continue
+		}
+		if found && result.Time != nil && mf.Time != nil && mf.Time.Before(result.Time) {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(mf.APIVersion)
+		if err != nil || gv.Group != group {
+			continue
+		}
+		result, found = mf, true
+	}
+	return result, found
+}
+
+// convert returns a copy of the given parent resource that is converted from
+// the version its spec was written in, if there is a Converter registered
+// for that version. Otherwise, the given parent resource is returned.
+func (r *Reconciler) convert(ctx context.Context, cr resource.ParentResource) (resource.ParentResource, error) {
+	c, ok := r.conversions[SpecVersion(cr, cr.GroupVersionKind())]
+	if !ok {
+		return cr, nil
+	}
+	input, ok := cr.DeepCopyObject().(resource.ParentResource)
+	if !ok {
+		return nil, errors.New(errCopyParent)
+	}
+	return input, errors.Wrap(c.Convert(ctx, input), errConvert)
+}
+
+// NewFieldMoveConverter returns a Converter that moves the value at each of
+// the given paths to the path it is mapped to, e.g. spec.size to
+// spec.parameters.size. Paths are given as dot-separated field names.
+func NewFieldMoveConverter(moves map[string]string) ConverterFunc {
+	return func(_ context.Context, cr resource.ParentResource) error {
+		for from, to := range moves {
+			val, ok, err := unstructured.NestedFieldNoCopy(cr.UnstructuredContent(), strings.Split(from, ".")...)
+			if err != nil {
+				return errors.Wrap(err, errMoveField)
+			}
+			if !ok {
+				continue
+			}
+			if err := unstructured.SetNestedField(cr.UnstructuredContent(), val, strings.Split(to, ".")...); err != nil {
+				return errors.Wrap(err, errMoveField)
+			}
+			unstructured.RemoveNestedField(cr.UnstructuredContent(), strings.Split(from, ".")...)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func withManagedFields(mf ...metav1.ManagedFieldsEntry) fake.MockResourceOption {
+	return func(r *fake.MockResource) {
+		r.SetManagedFields(mf)
+	}
+}
+
+func TestSpecVersion(t *testing.T) {
+	served := schema.GroupVersionKind{Group: "example.org", Version: "v1beta1", Kind: "Pack"}
+	earlier := metav1.NewTime(time.Now().Add(-time.Hour))
+	later := metav1.NewTime(time.Now())
+	spec := &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:size":{}}}`)}
+	status := &metav1.FieldsV1{Raw: []byte(`{"f:status":{"f:conditions":{}}}`)}
+	cases := map[string]struct {
+		reason string
+		mf     []metav1.ManagedFieldsEntry
+		want   string
+	}{
+		"NoManagedFields": {
+			reason: "The served version should be returned if there is no record",
+			want:   "v1beta1",
+		},
+		"OldSpec": {
+			reason: "The version the spec was written in should be returned",
+			mf: []metav1.ManagedFieldsEntry{
+				{APIVersion: "example.org/v1alpha1", Time: &earlier, FieldsV1: spec},
+				{APIVersion: "example.org/v1beta1", Time: &later, FieldsV1: status},
+			},
+			want: "v1alpha1",
+		},
+		"LatestSpec": {
+			reason: "The version the spec was last written in should be returned",
+			mf: []metav1.ManagedFieldsEntry{
+				{APIVersion: "example.org/v1beta1", Time: &later, FieldsV1: spec},
+				{APIVersion: "example.org/v1alpha1", Time: &earlier, FieldsV1: spec},
+			},
+			want: "v1beta1",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := SpecVersion(fake.NewMockResource(withManagedFields(tc.mf...)), served)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\nReason: %s\nSpecVersion(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestFieldMoveConverter(t *testing.T) {
+	cr := fake.NewMockResource()
+	_ = unstructured.SetNestedField(cr.Object, "small", "spec", "size")
+	if err := NewFieldMoveConverter(map[string]string{"spec.size": "spec.parameters.size", "spec.missing": "spec.other"}).Convert(context.Background(), cr); err != nil {
+		t.Fatalf("Convert(...): unexpected error: %s", err)
+	}
+	want := map[string]interface{}{"parameters": map[string]interface{}{"size": "small"}}
+	if diff := cmp.Diff(want, cr.Object["spec"]); diff != "" {
+		t.Errorf("Convert(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errScaleDown = "cannot scale down child resource"
+)
+
+// NewKindDeleter returns a ChildResourceDeleter that calls the given
+// ChildResourceDeleter only with the child resources of the given kinds.
+func NewKindDeleter(d ChildResourceDeleter, kinds ...schema.GroupKind) ChildResourceDeleterFunc {
+	return func(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+		var filtered []resource.ChildResource
+		for _, o := range list {
+			gk := o.GetObjectKind().GroupVersionKind().GroupKind()
+			for _, k := range kinds {
+				if gk == k {
+					filtered = append(filtered, o)
+					break
+				}
+			}
+		}
+		if len(filtered) == 0 {
+			return nil, nil
+		}
+		return d.Delete(ctx, cr, filtered)
+	}
+}
+
+// NewScaleToZeroDeleter returns a new *ScaleToZeroDeleter.
+func NewScaleToZeroDeleter(c client.Client) *ScaleToZeroDeleter {
+	return &ScaleToZeroDeleter{kube: c}
+}
+
+// ScaleToZeroDeleter is a ChildResourceDeleter that scales the workloads, i.e.
+// the child resources with spec.replicas, down to zero and reports them as
+// being deleted until none of their replicas is left. It is meant to be
+// chained before the ChildResourceDeleter that actually deletes them so that
+// the workloads can shut down gracefully before their dependencies go away.
+type ScaleToZeroDeleter struct {
+	kube client.Client
+}
+
+// Delete scales the given workloads down and returns the ones that still
+// have replicas.
+func (d *ScaleToZeroDeleter) Delete(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	var scaling []resource.ChildResource
+	for _, o := range list {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(o.GetObjectKind().GroupVersionKind())
+		err := d.kube.Get(ctx, types.NamespacedName{Name: o.GetName(), Namespace: o.GetNamespace()}, u)
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, errGetChildResource)
+		}
+		// Only the workloads that are controlled by the parent are scaled
+		// down since the rest are not going to be deleted anyway.
+		if !metav1.IsControlledBy(u, cr) {
+			continue
+		}
+		replicas, ok, err := unstructured.NestedInt64(u.Object, "spec", "replicas")
+		if err != nil || !ok {
+			continue
+		}
+		if replicas != 0 {
+			patch := client.MergeFrom(u.DeepCopy())
+			_ = unstructured.SetNestedField(u.Object, int64(0), "spec", "replicas")
+			if err := d.kube.Patch(ctx, u, patch); err != nil {
+				return nil, errors.Wrap(err, errScaleDown)
+			}
+			scaling = append(scaling, o)
+			continue
+		}
+		if current, _, _ := unstructured.NestedInt64(u.Object, "status", "replicas"); current > 0 {
+			scaling = append(scaling, o)
+		}
+	}
+	return scaling, nil
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ ChildResourceDeleter = ChildResourceDeleterChain{}
+	_ ChildResourceDeleter = &ScaleToZeroDeleter{}
+)
+
+func TestChildResourceDeleterChain(t *testing.T) {
+	waiting := []resource.ChildResource{fake.NewMockResource(fake.WithNamespaceName("workload", namespace))}
+	type want struct {
+		deleting []resource.ChildResource
+		err      error
+		called   int
+	}
+	cases := map[string]struct {
+		reason string
+		first  ChildResourceDeleter
+		want   want
+	}{
+		"StepDone": {
+			reason: "The next deleter should be called once the step does not report anything that is still being deleted",
+			first: ChildResourceDeleterFunc(func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) ([]resource.ChildResource, error) {
+				return nil, nil
+			}),
+			want: want{called: 1},
+		},
+		"StepInProgress": {
+			reason: "The next deleter should not be called as long as the step reports child resources that are still being deleted",
+			first: ChildResourceDeleterFunc(func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) ([]resource.ChildResource, error) {
+				return waiting, nil
+			}),
+			want: want{deleting: waiting},
+		},
+		"StepFailed": {
+			reason: "The error of the step should be returned without calling the next deleter",
+			first: ChildResourceDeleterFunc(func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) ([]resource.ChildResource, error) {
+				return nil, errBoom
+			}),
+			want: want{err: errBoom},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			called := 0
+			next := ChildResourceDeleterFunc(func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) ([]resource.ChildResource, error) {
+				called++
+				return nil, nil
+			})
+			deleting, err := ChildResourceDeleterChain{tc.first, next}.Delete(context.Background(), fake.NewMockResource(), waiting)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nDelete(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.deleting, deleting); diff != "" {
+				t.Errorf("\nReason: %s\nDelete(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.called, called); diff != "" {
+				t.Errorf("\nReason: %s\nDelete(...): -want calls, +got calls:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestKindDeleter(t *testing.T) {
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	workload := fake.NewMockResource(fake.WithGVK(deployment))
+	list := []resource.ChildResource{workload, fake.NewMockResource(fake.WithGVK(fake.MockChildGVK))}
+
+	var got []resource.ChildResource
+	d := NewKindDeleter(ChildResourceDeleterFunc(func(_ context.Context, _ resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+		got = list
+		return list, nil
+	}), deployment.GroupKind())
+	deleting, err := d.Delete(context.Background(), fake.NewMockResource(), list)
+	if err != nil {
+		t.Fatalf("Delete(...): %s", err)
+	}
+	want := []resource.ChildResource{workload}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Delete(...): -want given, +got given:\n%s", diff)
+	}
+	if diff := cmp.Diff(want, deleting); diff != "" {
+		t.Errorf("Delete(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestScaleToZeroDeleter(t *testing.T) {
+	cr := fake.NewMockResource(fake.WithUID("parent"), fake.WithGVK(fake.MockParentGVK))
+	workload := func(spec, status int64) func(obj runtime.Object) error {
+		return func(obj runtime.Object) error {
+			u := obj.(*unstructured.Unstructured)
+			u.SetName("workload")
+			u.SetNamespace(namespace)
+			_ = unstructured.SetNestedField(u.Object, spec, "spec", "replicas")
+			_ = unstructured.SetNestedField(u.Object, status, "status", "replicas")
+			meta.AddOwnerReference(u, meta.AsController(meta.ReferenceTo(cr, fake.MockParentGVK)))
+			return nil
+		}
+	}
+	list := []resource.ChildResource{fake.NewMockResource(fake.WithNamespaceName("workload", namespace))}
+
+	type want struct {
+		deleting []resource.ChildResource
+		err      error
+		patched  bool
+	}
+	cases := map[string]struct {
+		reason string
+		get    test.MockGetFn
+		patch  error
+		want   want
+	}{
+		"ScaleDown": {
+			reason: "A workload with replicas should be scaled down to zero and reported as being deleted",
+			get:    test.NewMockGetFn(nil, workload(3, 3)),
+			want:   want{deleting: list, patched: true},
+		},
+		"ScalingDown": {
+			reason: "A workload that is scaled down should be reported as being deleted until its replicas are gone",
+			get:    test.NewMockGetFn(nil, workload(0, 1)),
+			want:   want{deleting: list},
+		},
+		"ScaledDown": {
+			reason: "A workload without any replicas left should not block its deletion",
+			get:    test.NewMockGetFn(nil, workload(0, 0)),
+		},
+		"PatchFailed": {
+			reason: "The error should be returned if the workload cannot be scaled down",
+			get:    test.NewMockGetFn(nil, workload(3, 3)),
+			patch:  errBoom,
+			want:   want{err: errors.Wrap(errBoom, errScaleDown), patched: true},
+		},
+		"GetFailed": {
+			reason: "The error should be returned if the workload cannot be fetched",
+			get:    test.NewMockGetFn(errBoom),
+			want:   want{err: errors.Wrap(errBoom, errGetChildResource)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			patched := false
+			kube := &test.MockClient{
+				MockGet: tc.get,
+				MockPatch: func(_ context.Context, obj runtime.Object, _ client.Patch, _ ...client.PatchOption) error {
+					patched = true
+					if r, _, _ := unstructured.NestedInt64(obj.(*unstructured.Unstructured).Object, "spec", "replicas"); r != 0 {
+						t.Errorf("\nReason: %s\nPatch(...): want zero replicas, got %d", tc.reason, r)
+					}
+					return tc.patch
+				},
+			}
+			deleting, err := NewScaleToZeroDeleter(kube).Delete(context.Background(), cr, list)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nDelete(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.deleting, deleting); diff != "" {
+				t.Errorf("\nReason: %s\nDelete(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.patched, patched); diff != "" {
+				t.Errorf("\nReason: %s\nDelete(...): -want patched, +got patched:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
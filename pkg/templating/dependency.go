@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errUnknownDependency   = "child resource depends on an unknown child resource"
+	errAmbiguousDependency = "child resource depends on an identifier that several child resources share"
+	errDependencyCycle     = "dependencies of child resources form a cycle"
+)
+
+// ChildID returns the identifier of the child resource that other child
+// resources can use to declare a dependency on it. It is the value of the
+// IDAnnotationKey annotation if given, otherwise the name of the resource.
+// Note that the engines might change the name of the resource, like kustomize
+// adding a name prefix, so using the annotation is more reliable. The
+// identifier is not unique, e.g. a Service and a Deployment of the same name
+// share it, so the child resources that others depend on have to be the only
+// ones with their identifier. Use ChildKey to tell child resources apart.
+func ChildID(o resource.ChildResource) string {
+	if id := o.GetAnnotations()[IDAnnotationKey]; id != "" {
+		return id
+	}
+	return o.GetName()
+}
+
+// ChildKey returns the key that tells the given child resource apart from the
+// other rendered ones, which is made of its API group, kind, namespace and
+// name.
+func ChildKey(o resource.ChildResource) string {
+	return fmt.Sprintf("%s/%s/%s", o.GetObjectKind().GroupVersionKind().GroupKind().String(), o.GetNamespace(), o.GetName())
+}
+
+// Dependencies returns the identifiers of the child resources that given child
+// resource depends on.
+func Dependencies(o resource.ChildResource) []string {
+	var result []string
+	for _, d := range strings.Split(o.GetAnnotations()[DependsOnAnnotationKey], ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// SortByDependencies returns the child resources ordered so that every child
+// resource comes after the ones it depends on. The original order is kept for
+// the child resources that do not depend on each other. It returns error if
+// a child resource depends on an identifier that no child resource, or more
+// than one, has.
+func SortByDependencies(list []resource.ChildResource) ([]resource.ChildResource, error) {
+	ids := map[string]int{}
+	for _, o := range list {
+		ids[ChildID(o)]++
+	}
+	for _, o := range list {
+		for _, d := range Dependencies(o) {
+			if ids[d] == 0 {
+				return nil, errors.Errorf("%s: %s depends on %s", errUnknownDependency, ChildID(o), d)
+			}
+			if ids[d] > 1 {
+				return nil, errors.Errorf("%s: %s depends on %s", errAmbiguousDependency, ChildID(o), d)
+			}
+		}
+	}
+	result := make([]resource.ChildResource, 0, len(list))
+	placed := map[string]bool{}
+	remaining := list
+	for len(remaining) > 0 {
+		var next []resource.ChildResource
+		for _, o := range remaining {
+			if dependenciesIn(o, placed) {
+				result = append(result, o)
+				placed[ChildID(o)] = true
+				continue
+			}
+			next = append(next, o)
+		}
+		if len(next) == len(remaining) {
+			return nil, errors.New(errDependencyCycle)
+		}
+		remaining = next
+	}
+	return result, nil
+}
+
+// dependenciesIn returns true if all dependencies of the given child resource
+// are marked as true in the given set.
+func dependenciesIn(o resource.ChildResource, set map[string]bool) bool {
+	for _, d := range Dependencies(o) {
+		if !set[d] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func withID(id string, dependsOn string) fake.MockResourceOption {
+	return fake.WithAdditionalAnnotations(map[string]string{
+		IDAnnotationKey:        id,
+		DependsOnAnnotationKey: dependsOn,
+	})
+}
+
+func TestSortByDependencies(t *testing.T) {
+	type want struct {
+		result []resource.ChildResource
+		err    error
+	}
+	cases := map[string]struct {
+		reason string
+		list   []resource.ChildResource
+		want
+	}{
+		"KeepOrder": {
+			reason: "Order of the child resources without dependencies should be kept",
+			list: []resource.ChildResource{
+				fake.NewMockResource(withID("a", "")),
+				fake.NewMockResource(withID("b", "")),
+			},
+			want: want{
+				result: []resource.ChildResource{
+					fake.NewMockResource(withID("a", "")),
+					fake.NewMockResource(withID("b", "")),
+				},
+			},
+		},
+		"DependenciesFirst": {
+			reason: "Child resources should come after the ones they depend on",
+			list: []resource.ChildResource{
+				fake.NewMockResource(withID("a", "b, c")),
+				fake.NewMockResource(withID("b", "c")),
+				fake.NewMockResource(withID("c", "")),
+			},
+			want: want{
+				result: []resource.ChildResource{
+					fake.NewMockResource(withID("c", "")),
+					fake.NewMockResource(withID("b", "c")),
+					fake.NewMockResource(withID("a", "b, c")),
+				},
+			},
+		},
+		"NameAsID": {
+			reason: "Name of the child resource should be used if it does not have the id annotation",
+			list: []resource.ChildResource{
+				fake.NewMockResource(withID("a", "db")),
+				fake.NewMockResource(fake.WithNamespaceName("db", "")),
+			},
+			want: want{
+				result: []resource.ChildResource{
+					fake.NewMockResource(fake.WithNamespaceName("db", "")),
+					fake.NewMockResource(withID("a", "db")),
+				},
+			},
+		},
+		"AmbiguousDependency": {
+			reason: "It should return error if a dependency names child resources of different kinds that share a name",
+			list: []resource.ChildResource{
+				fake.NewMockResource(withID("a", "app")),
+				fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("app", "")),
+				fake.NewMockResource(fake.WithGVK(fake.MockParentGVK), fake.WithNamespaceName("app", "")),
+			},
+			want: want{
+				err: errors.Errorf("%s: %s depends on %s", errAmbiguousDependency, "a", "app"),
+			},
+		},
+		"SharedNameNotDependedOn": {
+			reason: "Child resources of different kinds may share a name that nothing depends on",
+			list: []resource.ChildResource{
+				fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("app", "")),
+				fake.NewMockResource(fake.WithGVK(fake.MockParentGVK), fake.WithNamespaceName("app", "")),
+			},
+			want: want{
+				result: []resource.ChildResource{
+					fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("app", "")),
+					fake.NewMockResource(fake.WithGVK(fake.MockParentGVK), fake.WithNamespaceName("app", "")),
+				},
+			},
+		},
+		"UnknownDependency": {
+			reason: "It should return error if a dependency does not exist",
+			list: []resource.ChildResource{
+				fake.NewMockResource(withID("a", "olala")),
+			},
+			want: want{
+				err: errors.Errorf("%s: %s depends on %s", errUnknownDependency, "a", "olala"),
+			},
+		},
+		"Cycle": {
+			reason: "It should return error if dependencies form a cycle",
+			list: []resource.ChildResource{
+				fake.NewMockResource(withID("a", "b")),
+				fake.NewMockResource(withID("b", "a")),
+			},
+			want: want{
+				err: errors.New(errDependencyCycle),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := SortByDependencies(tc.list)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nSortByDependencies(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.result, got); diff != "" {
+				t.Errorf("\nReason: %s\nSortByDependencies(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errParseDerivedMetadata  = "cannot parse template of derived"
+	errRenderDerivedMetadata = "cannot render template of derived"
+	errInvalidDerivedLabel   = "derived label value is invalid"
+)
+
+// NewDerivedMetadataPatcher returns a new *DerivedMetadataPatcher that sets
+// the labels and annotations with the given keys to the values that their
+// templates render.
+func NewDerivedMetadataPatcher(labels, annotations map[string]string) (*DerivedMetadataPatcher, error) {
+	l, err := parseMetadataTemplates(labels, "label")
+	if err != nil {
+		return nil, err
+	}
+	a, err := parseMetadataTemplates(annotations, "annotation")
+	if err != nil {
+		return nil, err
+	}
+	return &DerivedMetadataPatcher{labels: l, annotations: a}, nil
+}
+
+// DerivedMetadataPatcher sets labels and annotations of all child resources
+// to values that are rendered from the fields of the parent resource with Go
+// templates, e.g. {{ .spec.owner }}, so that the labels used for cost and
+// routing can be derived from the parent resource while LabelPropagator only
+// copies its labels. A template that refers to a field that the parent
+// resource does not have fails the patch.
+type DerivedMetadataPatcher struct {
+	labels      map[string]*template.Template
+	annotations map[string]*template.Template
+}
+
+// Patch patches the child resources with information in resource.ParentResource.
+func (p *DerivedMetadataPatcher) Patch(cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	labels, err := renderMetadataTemplates(cr, p.labels, "label")
+	if err != nil {
+		return nil, err
+	}
+	for key, val := range labels {
+		if problems := validation.IsValidLabelValue(val); len(problems) > 0 {
+			return nil, errors.Errorf("%s: %s: %s", errInvalidDerivedLabel, key, strings.Join(problems, "; "))
+		}
+	}
+	annotations, err := renderMetadataTemplates(cr, p.annotations, "annotation")
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range list {
+		meta.AddLabels(o, labels)
+		meta.AddAnnotations(o, annotations)
+	}
+	return list, nil
+}
+
+func parseMetadataTemplates(texts map[string]string, kind string) (map[string]*template.Template, error) {
+	result := make(map[string]*template.Template, len(texts))
+	for key, text := range texts {
+		t, err := template.New(key).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s %s %s", errParseDerivedMetadata, kind, key)
+		}
+		result[key] = t
+	}
+	return result, nil
+}
+
+func renderMetadataTemplates(cr resource.ParentResource, templates map[string]*template.Template, kind string) (map[string]string, error) {
+	result := make(map[string]string, len(templates))
+	for key, t := range templates {
+		b := &bytes.Buffer{}
+		if err := t.Execute(b, cr.UnstructuredContent()); err != nil {
+			return nil, errors.Wrapf(err, "%s %s %s", errRenderDerivedMetadata, kind, key)
+		}
+		result[key] = b.String()
+	}
+	return result, nil
+}
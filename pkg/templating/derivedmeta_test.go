@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ ChildResourcePatcher = &DerivedMetadataPatcher{}
+
+func TestDerivedMetadataPatcher(t *testing.T) {
+	owned := func(owner interface{}) *fake.MockResource {
+		cr := fake.NewMockResource(fake.WithNamespaceName("cool", "apps"))
+		cr.Object["spec"] = map[string]interface{}{"owner": owner}
+		return cr
+	}
+	type params struct {
+		labels      map[string]string
+		annotations map[string]string
+	}
+	cases := map[string]struct {
+		reason string
+		params params
+		args
+		result []resource.ChildResource
+		failed bool
+	}{
+		"Rendered": {
+			reason: "The labels and annotations should be set to the values their templates render from the parent resource",
+			params: params{
+				labels:      map[string]string{"team": "{{ .spec.owner }}"},
+				annotations: map[string]string{"example.org/route": "{{ .metadata.namespace }}/{{ .metadata.name }}"},
+			},
+			args: args{
+				cr:   owned("payments"),
+				list: []resource.ChildResource{fake.NewMockResource()},
+			},
+			result: []resource.ChildResource{fake.NewMockResource(
+				fake.WithAdditionalLabels(map[string]string{"team": "payments"}),
+				fake.WithAdditionalAnnotations(map[string]string{"example.org/route": "apps/cool"}),
+			)},
+		},
+		"MissingField": {
+			reason: "A template that refers to a field the parent resource does not have should fail the patch",
+			params: params{labels: map[string]string{"cost-center": "{{ .spec.costCenter }}"}},
+			args: args{
+				cr:   owned("payments"),
+				list: []resource.ChildResource{fake.NewMockResource()},
+			},
+			failed: true,
+		},
+		"InvalidLabelValue": {
+			reason: "A rendered label value that is not a valid label value should fail the patch",
+			params: params{labels: map[string]string{"team": "{{ .spec.owner }}"}},
+			args: args{
+				cr:   owned("payments and billing"),
+				list: []resource.ChildResource{fake.NewMockResource()},
+			},
+			failed: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p, err := NewDerivedMetadataPatcher(tc.params.labels, tc.params.annotations)
+			if err != nil {
+				t.Fatalf("\n%s\nNewDerivedMetadataPatcher(...): %s", tc.reason, err)
+			}
+			got, err := p.Patch(tc.args.cr, tc.args.list)
+			if failed := err != nil; failed != tc.failed {
+				t.Errorf("\n%s\nPatch(...): want failure %t, got error %v", tc.reason, tc.failed, err)
+			}
+			if diff := cmp.Diff(tc.result, got); diff != "" {
+				t.Errorf("\n%s\nPatch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestNewDerivedMetadataPatcher(t *testing.T) {
+	_, err := NewDerivedMetadataPatcher(map[string]string{"team": "{{ .spec.owner"}, nil)
+	if err == nil {
+		t.Errorf("NewDerivedMetadataPatcher(...): want error for a template that cannot be parsed")
+	}
+}
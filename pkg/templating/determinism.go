@@ -0,0 +1,65 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errVerifyRender        = "cannot verify that the templates render deterministically"
+	errNonDeterministic    = "templates render different child resources every time"
+	maxNonDeterministicRef = 5
+)
+
+// verifyRender runs the templating engine once more with the given input and
+// returns an error naming the child resources and fields that differ from the
+// given first render of it. Templates that render timestamps or random values
+// would otherwise make every reconcile patch the child resources again.
+func (r *Reconciler) verifyRender(input resource.ParentResource, first []resource.ChildResource) error {
+	before, err := snapshot(first)
+	if err != nil {
+		return err
+	}
+	second, err := r.templating.Run(input)
+	if err != nil {
+		return errors.Wrap(err, errTemplatingOperation)
+	}
+	resource.SortChildren(second)
+	after, err := snapshot(second)
+	if err != nil {
+		return err
+	}
+	changes := compareSnapshots(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	diffs := make([]string, 0, maxNonDeterministicRef+1)
+	for i, c := range changes {
+		if i == maxNonDeterministicRef {
+			diffs = append(diffs, fmt.Sprintf("and %d more", len(changes)-i))
+			break
+		}
+		diffs = append(diffs, fmt.Sprintf("%s (%s)", describeReference(c.Child), strings.Join(c.Fields, ", ")))
+	}
+	return &resource.RenderError{Err: errors.Errorf("%s: %s", errNonDeterministic, strings.Join(diffs, "; "))}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	errDrainTimeout = "in-flight reconciles did not finish before the drain timeout"
+)
+
+// NewDrainer returns a new *Drainer.
+func NewDrainer() *Drainer {
+	return &Drainer{}
+}
+
+// A Drainer keeps track of the reconciles that are in flight so that the
+// controller can wait for them to finish applying the child resources and
+// writing the status of their parent resources before it exits. Once it is
+// stopped, the reconciler requeues the parent resources instead of starting
+// new reconciles, which are picked up by the next controller.
+type Drainer struct {
+	mu       sync.Mutex
+	stopped  bool
+	inFlight sync.WaitGroup
+}
+
+// start records a reconcile that is starting. It returns false if the Drainer
+// is stopped, in which case the reconcile should not start.
+func (d *Drainer) start() (done func(), ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return nil, false
+	}
+	d.inFlight.Add(1)
+	return d.inFlight.Done, true
+}
+
+// Stop makes the reconciler stop starting new reconciles.
+func (d *Drainer) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stopped = true
+}
+
+// Wait waits for the reconciles that are in flight to finish, up to the
+// given timeout. It returns an error if they did not finish in time. Wait
+// should be called after Stop, otherwise new reconciles may keep it waiting.
+func (d *Drainer) Wait(timeout time.Duration) error {
+	finished := make(chan struct{})
+	go func() {
+		d.inFlight.Wait()
+		close(finished)
+	}()
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-finished:
+		return nil
+	case <-t.C:
+		return errors.New(errDrainTimeout)
+	}
+}
+
+// NewDrainerRunnable returns a manager.Runnable that stops the given Drainer
+// as soon as the manager is told to stop, so that the reconciles of the
+// parent resources that are still in the queue do not start while the
+// manager shuts down.
+func NewDrainerRunnable(d *Drainer) func(stop <-chan struct{}) error {
+	return func(stop <-chan struct{}) error {
+		<-stop
+		d.Stop()
+		return nil
+	}
+}
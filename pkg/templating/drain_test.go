@@ -0,0 +1,69 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	runtimefake "github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestDrainer(t *testing.T) {
+	d := NewDrainer()
+	done, ok := d.start()
+	if !ok {
+		t.Fatalf("start(...): reconciles should start before the Drainer is stopped")
+	}
+	d.Stop()
+	if _, ok := d.start(); ok {
+		t.Errorf("start(...): reconciles should not start once the Drainer is stopped")
+	}
+	if diff := cmp.Diff(errors.New(errDrainTimeout), d.Wait(time.Millisecond), test.EquateErrors()); diff != "" {
+		t.Errorf("Wait(...): in-flight reconciles should keep the Drainer waiting until the timeout: -want, +got:\n%s", diff)
+	}
+	done()
+	if diff := cmp.Diff(nil, d.Wait(time.Second), test.EquateErrors()); diff != "" {
+		t.Errorf("Wait(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestReconcileDraining(t *testing.T) {
+	d := NewDrainer()
+	d.Stop()
+	mgr := &runtimefake.Manager{
+		// The parent resource should not even be fetched.
+		Client: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+		Scheme: runtimefake.SchemeWith(&fake.MockResource{}),
+	}
+	r := NewReconciler(mgr, fake.MockParentGVK, WithDrainer(d))
+	got, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "cool"}})
+	if diff := cmp.Diff(nil, err, test.EquateErrors()); diff != "" {
+		t.Errorf("Reconcile(...): -want error, +got error:\n%s", diff)
+	}
+	if diff := cmp.Diff(reconcile.Result{Requeue: true}, got); diff != "" {
+		t.Errorf("Reconcile(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// NewSpreadEnqueuer returns a new *SpreadEnqueuer that enqueues at most batch
+// parent resources per interval for re-render with the given revision of the
+// templates.
+func NewSpreadEnqueuer(revision string, batch int, interval time.Duration) *SpreadEnqueuer {
+	return &SpreadEnqueuer{
+		revision: revision,
+		batch:    batch,
+		interval: interval,
+		now:      time.Now,
+		pending:  map[types.NamespacedName]time.Time{},
+	}
+}
+
+// SpreadEnqueuer is a handler.EventHandler that spreads the re-renders that a
+// new revision of the templates triggers over time instead of enqueueing all
+// parent resources at once. The new templates always come with a restart of
+// the controller, which lists and enqueues every parent resource. The parent
+// resources that have been reconciled before but not with the current
+// revision are enqueued in batches, one batch per interval, so that rolling a
+// pack version out to thousands of parent resources does not saturate the
+// API server or the controller. Every parent resource is waiting for at most
+// one batch; the triggers of a parent resource that is already waiting are
+// dropped. The changes of parent resources, including their deletion, and new
+// parent resources are enqueued right away.
+type SpreadEnqueuer struct {
+	revision string
+	batch    int
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	slot    time.Time
+	inSlot  int
+	pending map[types.NamespacedName]time.Time
+}
+
+// Create enqueues the created parent resource, spreading it if it was
+// rendered with an older revision of the templates.
+func (e *SpreadEnqueuer) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	if evt.Meta == nil {
+		return
+	}
+	nn := types.NamespacedName{Namespace: evt.Meta.GetNamespace(), Name: evt.Meta.GetName()}
+	u, ok := evt.Object.(*unstructured.Unstructured)
+	if !ok || !e.outdated(u) {
+		q.Add(reconcile.Request{NamespacedName: nn})
+		return
+	}
+	e.spread(nn, q)
+}
+
+// Update enqueues the updated parent resource right away.
+func (e *SpreadEnqueuer) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	if evt.MetaNew == nil {
+		return
+	}
+	q.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: evt.MetaNew.GetNamespace(), Name: evt.MetaNew.GetName()}})
+}
+
+// Delete enqueues the deleted parent resource right away.
+func (e *SpreadEnqueuer) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	if evt.Meta == nil {
+		return
+	}
+	nn := types.NamespacedName{Namespace: evt.Meta.GetNamespace(), Name: evt.Meta.GetName()}
+	e.mu.Lock()
+	delete(e.pending, nn)
+	e.mu.Unlock()
+	q.Add(reconcile.Request{NamespacedName: nn})
+}
+
+// Generic spreads the parent resource of the generic event, which external
+// sources send to trigger a re-render.
+func (e *SpreadEnqueuer) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	if evt.Meta == nil {
+		return
+	}
+	e.spread(types.NamespacedName{Namespace: evt.Meta.GetNamespace(), Name: evt.Meta.GetName()}, q)
+}
+
+// outdated returns true if the given parent resource has been reconciled
+// before, but its child resources are not known to be rendered with the
+// current revision of the templates.
+func (e *SpreadEnqueuer) outdated(u *unstructured.Unstructured) bool {
+	synced, err := resource.GetCondition(u, v1alpha1.TypeSynced)
+	if err != nil || synced.Status == corev1.ConditionUnknown {
+		return false
+	}
+	return e.revision == "" || resource.GetTemplateRevision(u) != e.revision
+}
+
+// spread enqueues the parent resource with the given name in the next batch
+// that is not full yet, unless it is waiting for a batch already.
+func (e *SpreadEnqueuer) spread(nn types.NamespacedName, q workqueue.RateLimitingInterface) {
+	if e.batch <= 0 {
+		q.Add(reconcile.Request{NamespacedName: nn})
+		return
+	}
+	e.mu.Lock()
+	now := e.now()
+	if due, ok := e.pending[nn]; ok && now.Before(due) {
+		e.mu.Unlock()
+		return
+	}
+	if now.Sub(e.slot) >= e.interval {
+		// The batches that are over do not count against the new ones.
+		e.slot, e.inSlot = now, 0
+	}
+	if e.inSlot >= e.batch {
+		e.slot, e.inSlot = e.slot.Add(e.interval), 0
+	}
+	e.inSlot++
+	due := e.slot
+	e.pending[nn] = due
+	e.mu.Unlock()
+	if delay := due.Sub(now); delay > 0 {
+		q.AddAfter(reconcile.Request{NamespacedName: nn}, delay)
+		return
+	}
+	q.Add(reconcile.Request{NamespacedName: nn})
+}
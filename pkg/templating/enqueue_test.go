@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+var (
+	_ handler.EventHandler = &SpreadEnqueuer{}
+)
+
+type enqueued struct {
+	name  string
+	delay time.Duration
+}
+
+type mockQueue struct {
+	workqueue.RateLimitingInterface
+	enqueued []enqueued
+}
+
+func (q *mockQueue) Add(item interface{}) {
+	q.AddAfter(item, 0)
+}
+
+func (q *mockQueue) AddAfter(item interface{}, d time.Duration) {
+	q.enqueued = append(q.enqueued, enqueued{name: item.(reconcile.Request).Name, delay: d})
+}
+
+func TestSpreadEnqueuer(t *testing.T) {
+	parent := func(name string, synced bool, rev string) event.CreateEvent {
+		u := &unstructured.Unstructured{}
+		u.SetName(name)
+		if synced {
+			if err := resource.SetConditions(u, v1alpha1.ReconcileSuccess()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if rev != "" {
+			if err := resource.SetTemplateRevision(u, rev); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return event.CreateEvent{Meta: u, Object: u}
+	}
+
+	cases := map[string]struct {
+		reason string
+		events []event.CreateEvent
+		want   []enqueued
+	}{
+		"NewParents": {
+			reason: "Parent resources that have never been reconciled should be enqueued right away",
+			events: []event.CreateEvent{parent("a", false, ""), parent("b", false, ""), parent("c", false, "")},
+			want:   []enqueued{{name: "a"}, {name: "b"}, {name: "c"}},
+		},
+		"CurrentRevision": {
+			reason: "Parent resources rendered with the current revision should be enqueued right away",
+			events: []event.CreateEvent{parent("a", true, "new"), parent("b", true, "new"), parent("c", true, "new")},
+			want:   []enqueued{{name: "a"}, {name: "b"}, {name: "c"}},
+		},
+		"OldRevision": {
+			reason: "Parent resources rendered with an older revision should be enqueued in batches",
+			events: []event.CreateEvent{parent("a", true, "old"), parent("b", true, "old"), parent("c", true, ""), parent("d", true, "old"), parent("e", true, "old")},
+			want:   []enqueued{{name: "a"}, {name: "b"}, {name: "c", delay: time.Minute}, {name: "d", delay: time.Minute}, {name: "e", delay: 2 * time.Minute}},
+		},
+		"Duplicate": {
+			reason: "The triggers of a parent resource that waits for its batch should be dropped",
+			events: []event.CreateEvent{parent("a", true, "old"), parent("b", true, "old"), parent("c", true, "old"), parent("c", true, "old")},
+			want:   []enqueued{{name: "a"}, {name: "b"}, {name: "c", delay: time.Minute}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			e := NewSpreadEnqueuer("new", 2, time.Minute)
+			e.now = func() time.Time { return now }
+			q := &mockQueue{}
+			for _, evt := range tc.events {
+				e.Create(evt, q)
+			}
+			if diff := cmp.Diff(tc.want, q.enqueued, cmp.AllowUnexported(enqueued{})); diff != "" {
+				t.Errorf("\nReason: %s\nCreate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSpreadEnqueuerUpdate(t *testing.T) {
+	e := NewSpreadEnqueuer("new", 1, time.Minute)
+	q := &mockQueue{}
+	u := &unstructured.Unstructured{}
+	u.SetName("a")
+	e.Update(event.UpdateEvent{MetaOld: u, ObjectOld: u, MetaNew: u, ObjectNew: u}, q)
+	want := []enqueued{{name: "a"}}
+	if diff := cmp.Diff(want, q.enqueued, cmp.AllowUnexported(enqueued{})); diff != "" {
+		t.Errorf("\nUpdate(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	reasonApplied event.Reason = "AppliedChildResources"
+)
+
+type throttleKey struct {
+	uid    types.UID
+	typ    event.Type
+	reason event.Reason
+}
+
+type throttleRecord struct {
+	message    string
+	time       time.Time
+	suppressed int
+}
+
+type throttleState struct {
+	mu      sync.Mutex
+	records map[throttleKey]*throttleRecord
+	swept   time.Time
+}
+
+// NewThrottledRecorder returns a new *ThrottledRecorder that records the
+// events with the given recorder, dropping the ones that repeat within the
+// given window.
+func NewThrottledRecorder(rec event.Recorder, window time.Duration) *ThrottledRecorder {
+	return &ThrottledRecorder{
+		rec:    rec,
+		window: window,
+		state:  &throttleState{records: map[throttleKey]*throttleRecord{}},
+		now:    time.Now,
+	}
+}
+
+// ThrottledRecorder is an event.Recorder that records an event of an object
+// only if its message differs from the last event of the same type and reason
+// of that object, i.e. the state of the object has changed, or the window has
+// elapsed since that event. A parent resource that keeps failing with the
+// same error gets a single event per window, noting how many were dropped,
+// instead of one per reconcile.
+type ThrottledRecorder struct {
+	rec    event.Recorder
+	window time.Duration
+	state  *throttleState
+	now    func() time.Time
+}
+
+// Event records the given event unless it repeats within the window.
+func (t *ThrottledRecorder) Event(obj runtime.Object, e event.Event) {
+	e, ok := t.admit(obj, e)
+	if !ok {
+		return
+	}
+	t.rec.Event(obj, e)
+}
+
+// WithAnnotations returns a ThrottledRecorder that records the events with
+// the given annotations. The events are throttled together with the ones of
+// this recorder.
+func (t *ThrottledRecorder) WithAnnotations(keysAndValues ...string) event.Recorder {
+	return &ThrottledRecorder{
+		rec:    t.rec.WithAnnotations(keysAndValues...),
+		window: t.window,
+		state:  t.state,
+		now:    t.now,
+	}
+}
+
+// admit returns the given event and true if it should be recorded. The
+// message of an admitted repeat notes the number of dropped ones.
+func (t *ThrottledRecorder) admit(obj runtime.Object, e event.Event) (event.Event, bool) {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return e, true
+	}
+	key := throttleKey{uid: m.GetUID(), typ: e.Type, reason: e.Reason}
+	now := t.now()
+
+	t.state.mu.Lock()
+	defer t.state.mu.Unlock()
+	rec, ok := t.state.records[key]
+	switch {
+	case ok && rec.message == e.Message && now.Sub(rec.time) < t.window:
+		rec.suppressed++
+		return e, false
+	case ok && rec.message == e.Message && rec.suppressed > 0:
+		t.state.records[key] = &throttleRecord{message: e.Message, time: now}
+		e.Message = fmt.Sprintf("%s (%d similar events suppressed)", e.Message, rec.suppressed)
+	default:
+		t.state.records[key] = &throttleRecord{message: e.Message, time: now}
+	}
+	t.sweep(now)
+	return e, true
+}
+
+// sweep removes the records that are not repeated for two windows so that the
+// records of deleted objects do not pile up. It runs at most once per window.
+func (t *ThrottledRecorder) sweep(now time.Time) {
+	if now.Sub(t.state.swept) < t.window {
+		return
+	}
+	for k, rec := range t.state.records {
+		if now.Sub(rec.time) >= 2*t.window {
+			delete(t.state.records, k)
+		}
+	}
+	t.state.swept = now
+}
+
+// recordApplySummary records a single event with the number of child
+// resources that are created and updated by the apply the given inventory
+// belongs to, instead of one per child resource. Nothing is recorded if no
+// child resource is changed.
+func (r *Reconciler) recordApplySummary(cr resource.ParentResource, inv *childInventory) {
+	created, updated := inv.applied[resource.ApplyCreated], inv.applied[resource.ApplyUpdated]
+	if created+updated == 0 {
+		return
+	}
+	r.record.Event(cr, event.Normal(reasonApplied, fmt.Sprintf("created %d, updated %d and left %d child resources unchanged", created, updated, inv.applied[resource.ApplyUnchanged])))
+}
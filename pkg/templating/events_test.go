@@ -0,0 +1,145 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ event.Recorder = &ThrottledRecorder{}
+
+type captureRecorder struct {
+	events []event.Event
+}
+
+func (c *captureRecorder) Event(_ runtime.Object, e event.Event) {
+	c.events = append(c.events, e)
+}
+
+func (c *captureRecorder) WithAnnotations(_ ...string) event.Recorder {
+	return c
+}
+
+func TestThrottledRecorder(t *testing.T) {
+	boom := event.Warning("ApplyFailed", errBoom)
+	other := event.Warning("ApplyFailed", errors.New("other"))
+
+	type step struct {
+		uid   types.UID
+		e     event.Event
+		after time.Duration
+	}
+	cases := map[string]struct {
+		reason string
+		steps  []step
+		want   []string
+	}{
+		"Repeat": {
+			reason: "Repeats of the same event within the window should be dropped",
+			steps:  []step{{uid: "a", e: boom}, {uid: "a", e: boom, after: time.Minute}},
+			want:   []string{boom.Message},
+		},
+		"Changed": {
+			reason: "An event with a different message should be recorded",
+			steps:  []step{{uid: "a", e: boom}, {uid: "a", e: other, after: time.Minute}},
+			want:   []string{boom.Message, "other"},
+		},
+		"OtherObject": {
+			reason: "The events of different objects should be throttled separately",
+			steps:  []step{{uid: "a", e: boom}, {uid: "b", e: boom}},
+			want:   []string{boom.Message, boom.Message},
+		},
+		"WindowElapsed": {
+			reason: "A repeat after the window should be recorded with the number of dropped ones",
+			steps: []step{
+				{uid: "a", e: boom},
+				{uid: "a", e: boom, after: 30 * time.Minute},
+				{uid: "a", e: boom, after: 31 * time.Minute},
+			},
+			want: []string{boom.Message, boom.Message + " (1 similar events suppressed)"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			capture := &captureRecorder{}
+			now := time.Now()
+			r := NewThrottledRecorder(capture, time.Hour)
+			r.now = func() time.Time { return now }
+			for _, s := range tc.steps {
+				now = now.Add(s.after)
+				r.Event(fake.NewMockResource(fake.WithUID(s.uid)), s.e)
+			}
+			got := make([]string, len(capture.events))
+			for i, e := range capture.events {
+				got[i] = e.Message
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\nReason: %s\nEvent(...): -want messages, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRecordApplySummary(t *testing.T) {
+	a := fake.NewMockResource(fake.WithNamespaceName("a", "default"), fake.WithGVK(fake.MockChildGVK))
+	b := fake.NewMockResource(fake.WithNamespaceName("b", "default"), fake.WithGVK(fake.MockChildGVK))
+	cases := map[string]struct {
+		reason  string
+		results []resource.ApplyResult
+		want    []string
+	}{
+		"Changed": {
+			reason:  "A single event should summarize the changed child resources",
+			results: []resource.ApplyResult{resource.ApplyCreated, resource.ApplyUnchanged},
+			want:    []string{"created 1, updated 0 and left 1 child resources unchanged"},
+		},
+		"Unchanged": {
+			reason:  "No event should be recorded if no child resource is changed",
+			results: []resource.ApplyResult{resource.ApplyUnchanged, resource.ApplyUnchanged},
+			want:    []string{},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			capture := &captureRecorder{}
+			r := &Reconciler{record: capture}
+			cr := fake.NewMockResource()
+			inv := newChildInventory(cr, []resource.ChildResource{a, b})
+			inv.record(a, tc.results[0], nil)
+			inv.record(b, tc.results[1], nil)
+			r.recordApplySummary(cr, inv)
+			got := make([]string, len(capture.events))
+			for i, e := range capture.events {
+				got[i] = e.Message
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\nReason: %s\nrecordApplySummary(...): -want messages, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
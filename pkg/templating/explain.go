@@ -0,0 +1,193 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errExplainPatcher = "cannot run patcher"
+	errExplainObject  = "cannot convert child resource"
+
+	fieldsAdded   = "(added)"
+	fieldsRemoved = "(removed)"
+)
+
+// ChildChange is the list of fields that a patcher changed in a child
+// resource.
+type ChildChange struct {
+	Child  resource.ChildReference
+	Fields []string
+}
+
+// PatcherExplanation is what a ChildResourcePatcher changed in the rendered
+// child resources. A patcher without changes matched none of them.
+type PatcherExplanation struct {
+	Patcher string
+	Changes []ChildChange
+}
+
+// Explanation is the explanation of how the child resources of a parent
+// resource came to be, i.e. what the templating engine rendered and what each
+// of the patchers changed afterwards.
+type Explanation struct {
+	Rendered []resource.ChildReference
+	Patchers []PatcherExplanation
+}
+
+// String returns the explanation in a human-readable form.
+func (e *Explanation) String() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "Rendered %d child resources:\n", len(e.Rendered))
+	for _, ref := range e.Rendered {
+		fmt.Fprintf(b, "  %s\n", describeReference(ref))
+	}
+	for _, p := range e.Patchers {
+		if len(p.Changes) == 0 {
+			fmt.Fprintf(b, "%s: matched nothing\n", p.Patcher)
+			continue
+		}
+		fmt.Fprintf(b, "%s:\n", p.Patcher)
+		for _, c := range p.Changes {
+			fmt.Fprintf(b, "  %s: %s\n", describeReference(c.Child), strings.Join(c.Fields, ", "))
+		}
+	}
+	return b.String()
+}
+
+func describeReference(ref resource.ChildReference) string {
+	if ref.Namespace == "" {
+		return fmt.Sprintf("%s %s", ref.Kind, ref.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", ref.Kind, ref.Namespace, ref.Name)
+}
+
+// Explain renders the child resources of the given parent resource like
+// Render does and reports which fields every child resource patcher set on
+// which child resources, so that it can be traced why a value of the parent
+// resource did or did not get propagated. The patches applied by the
+// templating engine itself, e.g. the Kustomize variables, are part of the
+// rendered child resources.
+func (r *Reconciler) Explain(ctx context.Context, cr resource.ParentResource) (*Explanation, error) {
+	input, err := r.renderInput(ctx, cr)
+	if err != nil {
+		return nil, errors.Wrap(err, errRenderInput)
+	}
+	list, err := r.templating.Run(input)
+	if err != nil {
+		return nil, errors.Wrap(err, errTemplatingOperation)
+	}
+	resource.SortChildren(list)
+	e := &Explanation{Rendered: references(list)}
+	for _, p := range r.children.ChildResourcePatcherChain {
+		name := strings.TrimPrefix(fmt.Sprintf("%T", p), "*")
+		before, err := snapshot(list)
+		if err != nil {
+			return nil, err
+		}
+		list, err = p.Patch(input, list)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s %s", errExplainPatcher, name)
+		}
+		after, err := snapshot(list)
+		if err != nil {
+			return nil, err
+		}
+		e.Patchers = append(e.Patchers, PatcherExplanation{Patcher: name, Changes: compareSnapshots(before, after)})
+	}
+	return e, nil
+}
+
+type childSnapshot struct {
+	ref     resource.ChildReference
+	content map[string]interface{}
+}
+
+// snapshot returns copies of the contents of the given child resources since
+// the patchers modify them in place.
+func snapshot(list []resource.ChildResource) ([]childSnapshot, error) {
+	result := make([]childSnapshot, len(list))
+	for i, o := range list {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o.DeepCopyObject())
+		if err != nil {
+			return nil, errors.Wrap(err, errExplainObject)
+		}
+		result[i] = childSnapshot{ref: resource.ReferenceTo(o), content: content}
+	}
+	return result, nil
+}
+
+// compareSnapshots returns the changes between the given snapshots of the
+// child resources. The child resources are matched by their position if a
+// patcher kept their number, so that renames are reported as changes, and by
+// their references otherwise.
+func compareSnapshots(before, after []childSnapshot) []ChildChange {
+	var result []ChildChange
+	if len(before) == len(after) {
+		for i := range after {
+			if fields := diffFields(before[i].content, after[i].content); len(fields) > 0 {
+				result = append(result, ChildChange{Child: after[i].ref, Fields: fields})
+			}
+		}
+		return result
+	}
+	previous := map[resource.ChildReference]map[string]interface{}{}
+	for _, s := range before {
+		previous[s.ref] = s.content
+	}
+	for _, s := range after {
+		content, ok := previous[s.ref]
+		delete(previous, s.ref)
+		if !ok {
+			result = append(result, ChildChange{Child: s.ref, Fields: []string{fieldsAdded}})
+			continue
+		}
+		if fields := diffFields(content, s.content); len(fields) > 0 {
+			result = append(result, ChildChange{Child: s.ref, Fields: fields})
+		}
+	}
+	for _, s := range before {
+		if _, ok := previous[s.ref]; ok {
+			result = append(result, ChildChange{Child: s.ref, Fields: []string{fieldsRemoved}})
+		}
+	}
+	return result
+}
+
+// diffFields returns the paths of the fields that are set, changed or removed
+// between the given contents.
+func diffFields(before, after map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, f := range append(changedFields("", after, before), changedFields("", before, after)...) {
+		if !seen[f] {
+			seen[f] = true
+			result = append(result, f)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
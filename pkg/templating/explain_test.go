@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestExplain(t *testing.T) {
+	child := fake.NewMockResource(fake.WithNamespaceName("cool", "default"), fake.WithGVK(fake.MockChildGVK))
+	ref := resource.ReferenceTo(child)
+	labeler := ChildResourcePatcherFunc(func(_ resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+		for _, o := range list {
+			o.SetLabels(map[string]string{"region": "eu"})
+		}
+		return list, nil
+	})
+	nop := ChildResourcePatcherFunc(func(_ resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+		return list, nil
+	})
+	r := &Reconciler{
+		templating: EngineFunc(func(_ resource.ParentResource) ([]resource.ChildResource, error) {
+			return []resource.ChildResource{child}, nil
+		}),
+		children: crChildren{ChildResourcePatcherChain: ChildResourcePatcherChain{labeler, nop}},
+	}
+	got, err := r.Explain(context.Background(), fake.NewMockResource())
+	if err != nil {
+		t.Fatalf("Explain(...): unexpected error: %s", err)
+	}
+	want := &Explanation{
+		Rendered: []resource.ChildReference{ref},
+		Patchers: []PatcherExplanation{
+			{Patcher: "templating.ChildResourcePatcherFunc", Changes: []ChildChange{{Child: ref, Fields: []string{"metadata.labels.region"}}}},
+			{Patcher: "templating.ChildResourcePatcherFunc"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Explain(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestCompareSnapshots(t *testing.T) {
+	a := resource.ChildReference{Kind: "ConfigMap", Name: "a"}
+	b := resource.ChildReference{Kind: "ConfigMap", Name: "b"}
+	cases := map[string]struct {
+		reason string
+		before []childSnapshot
+		after  []childSnapshot
+		want   []ChildChange
+	}{
+		"Unchanged": {
+			reason: "No changes should be reported if the contents are the same",
+			before: []childSnapshot{{ref: a, content: map[string]interface{}{"data": map[string]interface{}{"k": "v"}}}},
+			after:  []childSnapshot{{ref: a, content: map[string]interface{}{"data": map[string]interface{}{"k": "v"}}}},
+		},
+		"Removed": {
+			reason: "Removed fields should be reported as changes",
+			before: []childSnapshot{{ref: a, content: map[string]interface{}{"data": map[string]interface{}{"k": "v"}}}},
+			after:  []childSnapshot{{ref: a, content: map[string]interface{}{"data": map[string]interface{}{}}}},
+			want:   []ChildChange{{Child: a, Fields: []string{"data.k"}}},
+		},
+		"AddedChild": {
+			reason: "Child resources added by a patcher should be reported",
+			before: []childSnapshot{{ref: a, content: map[string]interface{}{}}},
+			after:  []childSnapshot{{ref: a, content: map[string]interface{}{}}, {ref: b, content: map[string]interface{}{}}},
+			want:   []ChildChange{{Child: b, Fields: []string{fieldsAdded}}},
+		},
+		"RemovedChild": {
+			reason: "Child resources removed by a patcher should be reported",
+			before: []childSnapshot{{ref: a, content: map[string]interface{}{}}, {ref: b, content: map[string]interface{}{}}},
+			after:  []childSnapshot{{ref: a, content: map[string]interface{}{}}},
+			want:   []ChildChange{{Child: b, Fields: []string{fieldsRemoved}}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, compareSnapshots(tc.before, tc.after)); diff != "" {
+				t.Errorf("\nReason: %s\ncompareSnapshots(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// ExternalSecretsValuesKey is the key under spec.parameters of the render
+// input that the values of the external secrets are exposed with.
+const ExternalSecretsValuesKey = "externalSecrets"
+
+const (
+	errParseExternalSecret = "cannot parse external secret"
+	errUnknownSecretStore  = "unknown secret store"
+	errGetExternalSecret   = "cannot get external secret"
+	errMissingSecretKey    = "external secret does not have the key"
+	errPlaintextSecret     = "child resource contains the value of an external secret in plaintext"
+)
+
+// ExternalSecret is the declaration of a value that is read from a key of a
+// secret in a SecretStore at render time.
+type ExternalSecret struct {
+	// Name is the name that the value is exposed with.
+	Name string
+
+	// Store is the name of the SecretStore.
+	Store string
+
+	// Path is the path of the secret in the store. The {namespace} and
+	// {name} placeholders are replaced with the ones of the parent resource,
+	// so that every parent resource can get a secret of its own.
+	Path string
+
+	// Key is the key of the value in the secret.
+	Key string
+}
+
+// ParseExternalSecret parses the declaration of an external secret given as
+// store:path#key, e.g. vault:secret/data/{namespace}/db#password.
+func ParseExternalSecret(name, spec string) (ExternalSecret, error) {
+	i := strings.Index(spec, ":")
+	j := strings.LastIndex(spec, "#")
+	if i <= 0 || j < i+2 || j == len(spec)-1 {
+		return ExternalSecret{}, errors.Errorf("%s %s: want store:path#key, got %s", errParseExternalSecret, name, spec)
+	}
+	return ExternalSecret{Name: name, Store: spec[:i], Path: spec[i+1 : j], Key: spec[j+1:]}, nil
+}
+
+// pathFor returns the path of the secret of the given parent resource.
+func (s ExternalSecret) pathFor(cr resource.ParentResource) string {
+	return strings.NewReplacer("{namespace}", cr.GetNamespace(), "{name}", cr.GetName()).Replace(s.Path)
+}
+
+// WithExternalSecrets returns a ReconcilerOption that exposes the values of
+// the given ExternalSecretValues to the templates and the patchers, and fails
+// the render of the child resources that would contain any of them in
+// plaintext, i.e. anywhere but in the data of a Secret.
+func WithExternalSecrets(v *ExternalSecretValues) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		WithValuesProvider(v)(reconciler)
+		WithMiddleware(NewExternalSecretGuard())(reconciler)
+	}
+}
+
+type cachedSecret struct {
+	data    map[string]string
+	expires time.Time
+}
+
+// NewExternalSecretValues returns a new *ExternalSecretValues that reads the
+// given external secrets from the given stores and caches the secrets for the
+// given TTL.
+func NewExternalSecretValues(stores map[string]SecretStore, ttl time.Duration, secrets ...ExternalSecret) *ExternalSecretValues {
+	return &ExternalSecretValues{
+		stores:  stores,
+		ttl:     ttl,
+		secrets: secrets,
+		now:     time.Now,
+		cache:   map[string]cachedSecret{},
+	}
+}
+
+// ExternalSecretValues is a ValuesProvider that exposes the values of the
+// declared external secrets under ExternalSecretsValuesKey. The values are
+// only set on the render input, which is never written back to the cluster,
+// so they do not end up in the parent resource. The secrets are cached so that
+// the secret backends are not called in every reconcile of every parent
+// resource.
+type ExternalSecretValues struct {
+	stores  map[string]SecretStore
+	ttl     time.Duration
+	secrets []ExternalSecret
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedSecret
+}
+
+// ProvidesSecrets returns true since the values are read from the secret
+// stores.
+func (v *ExternalSecretValues) ProvidesSecrets() bool { return true }
+
+// Values returns the values of the external secrets for the given parent
+// resource.
+func (v *ExternalSecretValues) Values(ctx context.Context, cr resource.ParentResource) (map[string]interface{}, error) {
+	if len(v.secrets) == 0 {
+		return nil, nil
+	}
+	vals := make(map[string]interface{}, len(v.secrets))
+	for _, s := range v.secrets {
+		data, err := v.get(ctx, s.Store, s.pathFor(cr))
+		if err != nil {
+			return nil, err
+		}
+		val, ok := data[s.Key]
+		if !ok {
+			return nil, errors.Errorf("%s %s: %s", errMissingSecretKey, s.Key, s.Name)
+		}
+		vals[s.Name] = val
+	}
+	return map[string]interface{}{ExternalSecretsValuesKey: vals}, nil
+}
+
+func (v *ExternalSecretValues) get(ctx context.Context, store, path string) (map[string]string, error) {
+	key := store + ":" + path
+	v.mu.Lock()
+	c, ok := v.cache[key]
+	v.mu.Unlock()
+	if ok && v.now().Before(c.expires) {
+		return c.data, nil
+	}
+	s, ok := v.stores[store]
+	if !ok {
+		return nil, errors.Errorf("%s: %s", errUnknownSecretStore, store)
+	}
+	data, err := s.GetSecret(ctx, path)
+	if err != nil {
+		// The path is not secret, the error of the store may well be, e.g.
+		// if it echoes the response.
+		return nil, errors.Wrapf(err, "%s %s", errGetExternalSecret, key)
+	}
+	v.mu.Lock()
+	v.cache[key] = cachedSecret{data: data, expires: v.now().Add(v.ttl)}
+	v.mu.Unlock()
+	return data, nil
+}
+
+// NewExternalSecretGuard returns a new ExternalSecretGuard.
+func NewExternalSecretGuard() ExternalSecretGuard {
+	return ExternalSecretGuard{}
+}
+
+// ExternalSecretGuard is a Middleware that checks the patched child resources
+// for the values of the external secrets of the render input. The stringData
+// of the Secrets is moved into their data, which is base64 encoded, and a
+// child resource that contains a value anywhere else fails the patch stage,
+// without the value in the error.
+type ExternalSecretGuard struct{}
+
+// Wrap checks the output of the patch stage.
+func (ExternalSecretGuard) Wrap(s Stage, next StageFunc) StageFunc {
+	if s != StagePatch {
+		return next
+	}
+	return func(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+		list, err := next(ctx, cr, list)
+		if err != nil {
+			return nil, err
+		}
+		vals, _, _ := unstructured.NestedStringMap(cr.UnstructuredContent(), "spec", resource.ParametersField, ExternalSecretsValuesKey)
+		if len(vals) == 0 {
+			return list, nil
+		}
+		for _, o := range list {
+			u, ok := o.(interface{ UnstructuredContent() map[string]interface{} })
+			if !ok {
+				continue
+			}
+			content := u.UnstructuredContent()
+			gvk := o.GetObjectKind().GroupVersionKind()
+			if gvk.Group == "" && gvk.Kind == "Secret" {
+				encodeStringData(content)
+				content = withoutField(content, "data")
+			}
+			for _, val := range vals {
+				if val != "" && containsString(content, val) {
+					return nil, errors.Errorf("%s: %s", errPlaintextSecret, describeReference(resource.ReferenceTo(o)))
+				}
+			}
+		}
+		return list, nil
+	}
+}
+
+// encodeStringData moves the stringData of the given Secret into its data.
+func encodeStringData(content map[string]interface{}) {
+	sd, ok, _ := unstructured.NestedStringMap(content, "stringData")
+	if !ok {
+		return
+	}
+	data, _, _ := unstructured.NestedMap(content, "data")
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	for k, v := range sd {
+		data[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+	_ = unstructured.SetNestedMap(content, data, "data")
+	unstructured.RemoveNestedField(content, "stringData")
+}
+
+// withoutField returns a shallow copy of the given content without the given
+// top-level field.
+func withoutField(content map[string]interface{}, field string) map[string]interface{} {
+	result := make(map[string]interface{}, len(content))
+	for k, v := range content {
+		if k != field {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// containsString returns true if any key or string value in the given JSON
+// value contains s.
+func containsString(v interface{}, s string) bool {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			if strings.Contains(k, s) || containsString(e, s) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, e := range t {
+			if containsString(e, s) {
+				return true
+			}
+		}
+	case string:
+		return strings.Contains(t, s)
+	default:
+		return strings.Contains(fmt.Sprint(t), s)
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/operations"
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ SecretValuesProvider = &ExternalSecretValues{}
+	_ Middleware           = ExternalSecretGuard{}
+	_ SecretStore          = &VaultSecretStore{}
+	_ SecretStore          = FileSecretStore{}
+)
+
+func TestParseExternalSecret(t *testing.T) {
+	type want struct {
+		es  ExternalSecret
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		spec   string
+		want
+	}{
+		"Valid": {
+			reason: "Store, path and key should be parsed",
+			spec:   "vault:secret/data/{namespace}/db#password",
+			want:   want{es: ExternalSecret{Name: "db", Store: "vault", Path: "secret/data/{namespace}/db", Key: "password"}},
+		},
+		"NoKey": {
+			reason: "It should return error if the key is missing",
+			spec:   "vault:secret/data/db",
+			want:   want{err: errors.Errorf("%s %s: want store:path#key, got %s", errParseExternalSecret, "db", "vault:secret/data/db")},
+		},
+		"NoStore": {
+			reason: "It should return error if the store is missing",
+			spec:   "secret/data/db#password",
+			want:   want{err: errors.Errorf("%s %s: want store:path#key, got %s", errParseExternalSecret, "db", "secret/data/db#password")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			es, err := ParseExternalSecret("db", tc.spec)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nParseExternalSecret(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.es, es); diff != "" {
+				t.Errorf("\nReason: %s\nParseExternalSecret(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestExternalSecretValues(t *testing.T) {
+	errBoom := errors.New("boom")
+	type want struct {
+		vals map[string]interface{}
+		err  error
+	}
+	cases := map[string]struct {
+		reason  string
+		store   SecretStore
+		secrets []ExternalSecret
+		want
+	}{
+		"Success": {
+			reason: "The values should be exposed under their names, with the placeholders of the path replaced",
+			store: SecretStoreFunc(func(_ context.Context, path string) (map[string]string, error) {
+				if path != "secret/ns/cool" {
+					return nil, errors.Errorf("unexpected path %s", path)
+				}
+				return map[string]string{"password": "s3cr3t"}, nil
+			}),
+			secrets: []ExternalSecret{{Name: "db", Store: "vault", Path: "secret/{namespace}/{name}", Key: "password"}},
+			want: want{vals: map[string]interface{}{
+				ExternalSecretsValuesKey: map[string]interface{}{"db": "s3cr3t"},
+			}},
+		},
+		"MissingKey": {
+			reason: "It should return error if the secret does not have the key",
+			store: SecretStoreFunc(func(_ context.Context, _ string) (map[string]string, error) {
+				return map[string]string{}, nil
+			}),
+			secrets: []ExternalSecret{{Name: "db", Store: "vault", Path: "secret", Key: "password"}},
+			want:    want{err: errors.Errorf("%s %s: %s", errMissingSecretKey, "password", "db")},
+		},
+		"UnknownStore": {
+			reason:  "It should return error if the store is unknown",
+			secrets: []ExternalSecret{{Name: "db", Store: "aws", Path: "secret", Key: "password"}},
+			want:    want{err: errors.Errorf("%s: %s", errUnknownSecretStore, "aws")},
+		},
+		"StoreError": {
+			reason: "It should return the error of the store",
+			store: SecretStoreFunc(func(_ context.Context, _ string) (map[string]string, error) {
+				return nil, errBoom
+			}),
+			secrets: []ExternalSecret{{Name: "db", Store: "vault", Path: "secret", Key: "password"}},
+			want:    want{err: errors.Wrapf(errBoom, "%s %s", errGetExternalSecret, "vault:secret")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			stores := map[string]SecretStore{}
+			if tc.store != nil {
+				stores["vault"] = tc.store
+			}
+			v := NewExternalSecretValues(stores, time.Minute, tc.secrets...)
+			vals, err := v.Values(context.Background(), fake.NewMockResource(fake.WithNamespaceName("cool", "ns")))
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.vals, vals); diff != "" {
+				t.Errorf("\nReason: %s\nValues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestExternalSecretValuesCache(t *testing.T) {
+	calls := 0
+	store := SecretStoreFunc(func(_ context.Context, _ string) (map[string]string, error) {
+		calls++
+		return map[string]string{"password": "s3cr3t"}, nil
+	})
+	now := time.Now()
+	v := NewExternalSecretValues(map[string]SecretStore{"vault": store}, time.Minute, ExternalSecret{Name: "db", Store: "vault", Path: "secret", Key: "password"})
+	v.now = func() time.Time { return now }
+	cr := fake.NewMockResource()
+	for i := 0; i < 2; i++ {
+		if _, err := v.Values(context.Background(), cr); err != nil {
+			t.Fatalf("Values(...): %s", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Values(...): want 1 call to the store within the TTL, got %d", calls)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := v.Values(context.Background(), cr); err != nil {
+		t.Fatalf("Values(...): %s", err)
+	}
+	if calls != 2 {
+		t.Errorf("Values(...): want 2 calls to the store after the TTL, got %d", calls)
+	}
+}
+
+func TestExternalSecretGuard(t *testing.T) {
+	secretGVK := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	newSecret := func() resource.ChildResource {
+		s := fake.NewMockResource(fake.WithGVK(secretGVK), fake.WithNamespaceName("db", "ns"))
+		s.Object["stringData"] = map[string]interface{}{"password": "s3cr3t"}
+		return s
+	}
+	newConfigMap := func() resource.ChildResource {
+		c := fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("db", "ns"))
+		c.Object["data"] = map[string]interface{}{"url": "postgres://admin:s3cr3t@db"}
+		return c
+	}
+	type want struct {
+		data interface{}
+		err  error
+	}
+	cases := map[string]struct {
+		reason string
+		list   []resource.ChildResource
+		want
+	}{
+		"SecretData": {
+			reason: "The stringData of a Secret should be encoded into its data",
+			list:   []resource.ChildResource{newSecret()},
+			want:   want{data: map[string]interface{}{"password": "czNjcjN0"}},
+		},
+		"Plaintext": {
+			reason: "It should return error if a child resource other than a Secret contains the value",
+			list:   []resource.ChildResource{newConfigMap()},
+			want:   want{err: errors.Errorf("%s: %s", errPlaintextSecret, describeReference(resource.ReferenceTo(newConfigMap())))},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cr, err := operations.InputWithValues(fake.NewMockResource(), map[string]interface{}{
+				ExternalSecretsValuesKey: map[string]interface{}{"db": "s3cr3t"},
+			})
+			if err != nil {
+				t.Fatalf("InputWithValues(...): %s", err)
+			}
+			next := StageFunc(func(_ context.Context, _ resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+				return list, nil
+			})
+			list, err := NewExternalSecretGuard().Wrap(StagePatch, next)(context.Background(), cr, tc.list)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nWrap(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if tc.want.err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want.data, list[0].(*fake.MockResource).Object["data"]); diff != "" {
+				t.Errorf("\nReason: %s\nWrap(...): -want data, +got data:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
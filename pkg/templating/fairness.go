@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"sync"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// A FairnessKeyFunc returns the key of the group, e.g. a tenant, that the
+// given parent resource belongs to for the fairness of reconciliation.
+type FairnessKeyFunc func(cr resource.ParentResource) string
+
+// NamespaceFairnessKey groups the parent resources by their namespace.
+func NamespaceFairnessKey(cr resource.ParentResource) string {
+	return cr.GetNamespace()
+}
+
+// LabelFairnessKey groups the parent resources by the value of the given
+// label. Parent resources without the label are grouped by their name so that
+// each of them is a group of its own.
+func LabelFairnessKey(label string) FairnessKeyFunc {
+	return func(cr resource.ParentResource) string {
+		if val, ok := cr.GetLabels()[label]; ok {
+			return "label/" + val
+		}
+		return "name/" + cr.GetName()
+	}
+}
+
+func newConcurrencyLimiter(key FairnessKeyFunc, limit int) *concurrencyLimiter {
+	return &concurrencyLimiter{
+		key:    key,
+		limit:  limit,
+		active: map[string]int{},
+	}
+}
+
+// concurrencyLimiter caps the number of parent resources of the same group
+// that are reconciled at the same time so that a group with many slow or
+// failing parent resources cannot occupy all workers and starve the others.
+type concurrencyLimiter struct {
+	key FairnessKeyFunc
+
+	mu     sync.Mutex
+	limit  int
+	active map[string]int
+}
+
+// setLimit changes the limit of the groups. The parent resources that are
+// being reconciled are not affected by a lower limit.
+func (l *concurrencyLimiter) setLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+}
+
+// Acquire returns false if the group of the given parent resource is already
+// at its limit. Otherwise, it returns true and the function to be called once
+// the reconciliation of the parent resource is done.
+func (l *concurrencyLimiter) Acquire(cr resource.ParentResource) (func(), bool) {
+	if l == nil {
+		return func() {}, true
+	}
+	k := l.key(cr)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit <= 0 {
+		return func() {}, true
+	}
+	if l.active[k] >= l.limit {
+		return nil, false
+	}
+	l.active[k]++
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.active[k]--; l.active[k] <= 0 {
+			delete(l.active, k)
+		}
+	}, true
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	l := newConcurrencyLimiter(NamespaceFairnessKey, 1)
+	tenantA := fake.NewMockResource(fake.WithNamespaceName("first", "a"))
+	release, ok := l.Acquire(tenantA)
+	if !ok {
+		t.Fatalf("Acquire(...): the first parent resource of a group should be admitted")
+	}
+	if _, ok := l.Acquire(fake.NewMockResource(fake.WithNamespaceName("second", "a"))); ok {
+		t.Errorf("Acquire(...): parent resources beyond the limit of their group should not be admitted")
+	}
+	if _, ok := l.Acquire(fake.NewMockResource(fake.WithNamespaceName("first", "b"))); !ok {
+		t.Errorf("Acquire(...): parent resources of other groups should be admitted")
+	}
+	release()
+	if _, ok := l.Acquire(fake.NewMockResource(fake.WithNamespaceName("second", "a"))); !ok {
+		t.Errorf("Acquire(...): parent resources should be admitted once their group is below the limit")
+	}
+}
+
+func TestLabelFairnessKey(t *testing.T) {
+	key := LabelFairnessKey("tenant")
+	labeled := fake.NewMockResource(fake.WithNamespaceName("first", ""), fake.WithAdditionalLabels(map[string]string{"tenant": "a"}))
+	other := fake.NewMockResource(fake.WithNamespaceName("second", ""), fake.WithAdditionalLabels(map[string]string{"tenant": "a"}))
+	unlabeled := fake.NewMockResource(fake.WithNamespaceName("third", ""))
+	if key(labeled) != key(other) {
+		t.Errorf("LabelFairnessKey(...): parent resources with the same label value should be in the same group")
+	}
+	if key(labeled) == key(unlabeled) {
+		t.Errorf("LabelFairnessKey(...): parent resources without the label should be in groups of their own")
+	}
+}
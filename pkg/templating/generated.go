@@ -0,0 +1,249 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	rresource "github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// GeneratedValuesKey is the key under spec.parameters of the render input that
+// the generated values are exposed with.
+const GeneratedValuesKey = "generated"
+
+const (
+	defaultGeneratedValueLength = 24
+	passwordCharacters          = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	placeholderUUID             = "00000000-0000-0000-0000-000000000000"
+
+	errUnknownGeneratedValueType   = "unknown generated value type"
+	errParseGeneratedValue         = "cannot parse generated value length"
+	errGetGeneratedValues          = "cannot get secret of generated values"
+	errApplyGeneratedValues        = "cannot apply secret of generated values"
+	errGenerateValue               = "cannot generate value"
+	errNoGeneratedValueNamespace   = "generated values of cluster-scoped parent resources need a namespace"
+	errUncontrolledGeneratedValues = "secret of generated values is not controlled by the parent resource"
+)
+
+// GeneratedValueType is the type of a generated value.
+type GeneratedValueType string
+
+// Generated value types.
+const (
+	GeneratedPassword GeneratedValueType = "password"
+	GeneratedToken    GeneratedValueType = "token"
+	GeneratedUUID     GeneratedValueType = "uuid"
+)
+
+// GeneratedValue is the declaration of a value that is generated once per
+// parent resource and reused in all subsequent renders.
+type GeneratedValue struct {
+	Name   string
+	Type   GeneratedValueType
+	Length int
+}
+
+// ParseGeneratedValue parses the declaration of a generated value given in
+// type[:length] form, e.g. password:32.
+func ParseGeneratedValue(name, spec string) (GeneratedValue, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	gv := GeneratedValue{Name: name, Type: GeneratedValueType(parts[0])}
+	switch gv.Type {
+	case GeneratedPassword, GeneratedToken, GeneratedUUID:
+	default:
+		return GeneratedValue{}, errors.Errorf("%s: %s", errUnknownGeneratedValueType, parts[0])
+	}
+	if len(parts) == 2 {
+		l, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return GeneratedValue{}, errors.Wrap(err, errParseGeneratedValue)
+		}
+		gv.Length = l
+	}
+	return gv, nil
+}
+
+// Generate returns a new random value.
+func (gv GeneratedValue) Generate() (string, error) {
+	l := gv.Length
+	if l <= 0 {
+		l = defaultGeneratedValueLength
+	}
+	switch gv.Type {
+	case GeneratedUUID:
+		return string(uuid.NewUUID()), nil
+	case GeneratedToken:
+		b := make([]byte, (l+1)/2)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(b)[:l], nil
+	case GeneratedPassword:
+		result := make([]byte, l)
+		max := big.NewInt(int64(len(passwordCharacters)))
+		for i := range result {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			result[i] = passwordCharacters[n.Int64()]
+		}
+		return string(result), nil
+	}
+	return "", errors.Errorf("%s: %s", errUnknownGeneratedValueType, gv.Type)
+}
+
+// Placeholder returns the value that stands in for the generated value in the
+// renders that do not store it. It is the same in every render, i.e. the nil
+// UUID for uuids and as many zeros as the length otherwise.
+func (gv GeneratedValue) Placeholder() string {
+	if gv.Type == GeneratedUUID {
+		return placeholderUUID
+	}
+	l := gv.Length
+	if l <= 0 {
+		l = defaultGeneratedValueLength
+	}
+	return strings.Repeat("0", l)
+}
+
+// NewAPISecretValueGenerator returns a new *APISecretValueGenerator. The
+// generated values of cluster-scoped parent resources are stored in the given
+// namespace.
+func NewAPISecretValueGenerator(c client.Client, namespace string, values ...GeneratedValue) *APISecretValueGenerator {
+	return &APISecretValueGenerator{kube: c, namespace: namespace, values: values}
+}
+
+// APISecretValueGenerator is a ValuesProvider that generates the declared
+// values once per parent resource and persists them in a Secret controlled by
+// the parent resource, so that the same values are used in every render. The
+// Secret is written only when the values are initialized, which the Reconciler
+// does only before the renders that it applies.
+type APISecretValueGenerator struct {
+	kube      client.Client
+	namespace string
+	values    []GeneratedValue
+}
+
+// ProvidesSecrets returns true since the generated values are kept in a
+// Secret.
+func (g *APISecretValueGenerator) ProvidesSecrets() bool { return true }
+
+// InitializeValues generates the declared values that are not stored yet and
+// stores them in the Secret of the given parent resource, creating the Secret
+// if it does not exist. An existing Secret has to be controlled by the parent
+// resource. It returns all values under GeneratedValuesKey.
+func (g *APISecretValueGenerator) InitializeValues(ctx context.Context, cr resource.ParentResource) (map[string]interface{}, error) {
+	if len(g.values) == 0 {
+		return nil, nil
+	}
+	s, err := g.secret(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+	if s.GetUID() == "" {
+		meta.AddOwnerReference(s, meta.AsController(meta.ReferenceTo(cr, cr.GroupVersionKind())))
+	}
+	if s.Data == nil {
+		s.Data = map[string][]byte{}
+	}
+	changed := false
+	generated := map[string]interface{}{}
+	for _, gv := range g.values {
+		if _, ok := s.Data[gv.Name]; !ok {
+			val, err := gv.Generate()
+			if err != nil {
+				return nil, errors.Wrap(err, errGenerateValue)
+			}
+			s.Data[gv.Name] = []byte(val)
+			changed = true
+		}
+		generated[gv.Name] = string(s.Data[gv.Name])
+	}
+	if changed {
+		if err := rresource.NewAPIPatchingApplicator(g.kube).Apply(ctx, s, rresource.MustBeControllableBy(cr.GetUID())); err != nil {
+			return nil, errors.Wrap(err, errApplyGeneratedValues)
+		}
+	}
+	return map[string]interface{}{GeneratedValuesKey: generated}, nil
+}
+
+// Values returns the generated values under GeneratedValuesKey without
+// writing them. The values that are not stored yet, e.g. because the parent
+// resource was never applied, are given as their Placeholder so that the
+// renders that do not apply, like the ones of RenderService, stay
+// deterministic.
+func (g *APISecretValueGenerator) Values(ctx context.Context, cr resource.ParentResource) (map[string]interface{}, error) {
+	if len(g.values) == 0 {
+		return nil, nil
+	}
+	s, err := g.secret(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+	generated := map[string]interface{}{}
+	for _, gv := range g.values {
+		if v, ok := s.Data[gv.Name]; ok {
+			generated[gv.Name] = string(v)
+			continue
+		}
+		generated[gv.Name] = gv.Placeholder()
+	}
+	return map[string]interface{}{GeneratedValuesKey: generated}, nil
+}
+
+// secret returns the Secret of the generated values of the given parent
+// resource, or a new one without a UID if it does not exist yet.
+func (g *APISecretValueGenerator) secret(ctx context.Context, cr resource.ParentResource) (*corev1.Secret, error) {
+	nn := types.NamespacedName{Name: fmt.Sprintf("%s-generated-values", cr.GetName()), Namespace: cr.GetNamespace()}
+	if nn.Namespace == "" {
+		nn.Namespace = g.namespace
+	}
+	if nn.Namespace == "" {
+		return nil, errors.New(errNoGeneratedValueNamespace)
+	}
+	s := &corev1.Secret{}
+	err := g.kube.Get(ctx, nn, s)
+	if kerrors.IsNotFound(err) {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: nn.Name, Namespace: nn.Namespace}}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errGetGeneratedValues)
+	}
+	if !metav1.IsControlledBy(s, cr) {
+		return nil, errors.Errorf("%s: %s/%s", errUncontrolledGeneratedValues, nn.Namespace, nn.Name)
+	}
+	return s, nil
+}
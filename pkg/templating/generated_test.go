@@ -0,0 +1,273 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ SecretValuesProvider = &APISecretValueGenerator{}
+	_ ValuesInitializer    = &APISecretValueGenerator{}
+)
+
+func TestParseGeneratedValue(t *testing.T) {
+	type want struct {
+		gv  GeneratedValue
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		spec   string
+		want
+	}{
+		"TypeOnly": {
+			reason: "Length should be left to the default if it is not given",
+			spec:   "password",
+			want:   want{gv: GeneratedValue{Name: "pw", Type: GeneratedPassword}},
+		},
+		"WithLength": {
+			reason: "Length should be parsed if it is given",
+			spec:   "token:32",
+			want:   want{gv: GeneratedValue{Name: "pw", Type: GeneratedToken, Length: 32}},
+		},
+		"UnknownType": {
+			reason: "It should return error if the type is unknown",
+			spec:   "cert",
+			want:   want{err: errors.Errorf("%s: %s", errUnknownGeneratedValueType, "cert")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			gv, err := ParseGeneratedValue("pw", tc.spec)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nParseGeneratedValue(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.gv, gv); diff != "" {
+				t.Errorf("\nReason: %s\nParseGeneratedValue(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	for _, gv := range []GeneratedValue{
+		{Type: GeneratedPassword, Length: 16},
+		{Type: GeneratedToken, Length: 15},
+	} {
+		v, err := gv.Generate()
+		if err != nil {
+			t.Errorf("Generate(...): unexpected error: %s", err)
+		}
+		if len(v) != gv.Length {
+			t.Errorf("Generate(...): want length %d, got %d", gv.Length, len(v))
+		}
+	}
+}
+
+func TestAPISecretValueGenerator(t *testing.T) {
+	password := GeneratedValue{Name: "password", Type: GeneratedPassword}
+	controller := meta.AsController(meta.ReferenceTo(fake.NewMockResource(fake.WithUID("cool-uid")), fake.MockParentGVK))
+	existing := func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+		s := obj.(*corev1.Secret)
+		meta.AddOwnerReference(s, controller)
+		s.Data = map[string][]byte{"password": []byte("stable")}
+		return nil
+	}
+	type args struct {
+		kube   client.Client
+		ns     string
+		values []GeneratedValue
+		cr     resource.ParentResource
+	}
+	type want struct {
+		vals map[string]interface{}
+		err  error
+	}
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"NoValues": {
+			reason: "Nothing should be done if no value is declared",
+			args: args{
+				cr: fake.NewMockResource(fake.WithNamespaceName("cool", "ns")),
+			},
+		},
+		"Existing": {
+			reason: "Values that are already stored should be reused",
+			args: args{
+				kube:   &test.MockClient{MockGet: existing},
+				values: []GeneratedValue{password},
+				cr:     fake.NewMockResource(fake.WithNamespaceName("cool", "ns"), fake.WithUID("cool-uid")),
+			},
+			want: want{vals: map[string]interface{}{GeneratedValuesKey: map[string]interface{}{"password": "stable"}}},
+		},
+		"NotStored": {
+			reason: "Values that are not stored yet should be given as placeholders without storing them",
+			args: args{
+				kube:   &test.MockClient{MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, ""))},
+				values: []GeneratedValue{password, {Name: "id", Type: GeneratedUUID}},
+				cr:     fake.NewMockResource(fake.WithNamespaceName("cool", "ns"), fake.WithUID("cool-uid")),
+			},
+			want: want{vals: map[string]interface{}{GeneratedValuesKey: map[string]interface{}{
+				"password": strings.Repeat("0", defaultGeneratedValueLength),
+				"id":       placeholderUUID,
+			}}},
+		},
+		"Uncontrolled": {
+			reason: "It should return error if the secret is not controlled by the parent resource",
+			args: args{
+				kube:   &test.MockClient{MockGet: existing},
+				values: []GeneratedValue{password},
+				cr:     fake.NewMockResource(fake.WithNamespaceName("cool", "ns"), fake.WithUID("other-uid")),
+			},
+			want: want{err: errors.Errorf("%s: %s", errUncontrolledGeneratedValues, "ns/cool-generated-values")},
+		},
+		"ClusterScopedWithoutNamespace": {
+			reason: "It should return error if there is no namespace to store the values of a cluster-scoped parent resource",
+			args: args{
+				values: []GeneratedValue{password},
+				cr:     fake.NewMockResource(fake.WithNamespaceName("cool", "")),
+			},
+			want: want{err: errors.New(errNoGeneratedValueNamespace)},
+		},
+		"GetFailed": {
+			reason: "It should return error if the secret cannot be fetched",
+			args: args{
+				kube:   &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				ns:     "ns",
+				values: []GeneratedValue{password},
+				cr:     fake.NewMockResource(fake.WithNamespaceName("cool", "")),
+			},
+			want: want{err: errors.Wrap(errBoom, errGetGeneratedValues)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			vals, err := NewAPISecretValueGenerator(tc.args.kube, tc.args.ns, tc.args.values...).Values(context.Background(), tc.args.cr)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.vals, vals); diff != "" {
+				t.Errorf("\nReason: %s\nValues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAPISecretValueGeneratorInitializeValues(t *testing.T) {
+	password := GeneratedValue{Name: "password", Type: GeneratedPassword}
+	controller := meta.AsController(meta.ReferenceTo(fake.NewMockResource(fake.WithUID("cool-uid")), fake.MockParentGVK))
+	type args struct {
+		kube   client.Client
+		values []GeneratedValue
+		cr     resource.ParentResource
+	}
+	type want struct {
+		vals map[string]interface{}
+		err  error
+	}
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"Existing": {
+			reason: "Values that are already stored should be returned without writing the secret",
+			args: args{
+				kube: &test.MockClient{MockGet: func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+					s := obj.(*corev1.Secret)
+					meta.AddOwnerReference(s, controller)
+					s.Data = map[string][]byte{"password": []byte("stable")}
+					return nil
+				}},
+				values: []GeneratedValue{password},
+				cr:     fake.NewMockResource(fake.WithNamespaceName("cool", "ns"), fake.WithUID("cool-uid")),
+			},
+			want: want{vals: map[string]interface{}{GeneratedValuesKey: map[string]interface{}{"password": "stable"}}},
+		},
+		"Generate": {
+			reason: "Values that are not stored yet should be generated and stored in a secret controlled by the parent resource",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+					MockCreate: test.NewMockCreateFn(nil, func(obj runtime.Object) error {
+						s := obj.(*corev1.Secret)
+						if s.GetName() != "cool-generated-values" || s.GetNamespace() != "ns" {
+							t.Errorf("InitializeValues(...): unexpected secret %s/%s", s.GetNamespace(), s.GetName())
+						}
+						if c := metav1.GetControllerOf(s); c == nil || c.UID != "cool-uid" {
+							t.Errorf("InitializeValues(...): secret is not controlled by the parent resource")
+						}
+						return nil
+					}),
+				},
+				values: []GeneratedValue{password},
+				cr:     fake.NewMockResource(fake.WithNamespaceName("cool", "ns"), fake.WithUID("cool-uid")),
+			},
+		},
+		"Uncontrolled": {
+			reason: "It should return error instead of adopting a secret that the parent resource does not control",
+			args: args{
+				kube: &test.MockClient{MockGet: func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+					obj.(*corev1.Secret).Data = map[string][]byte{}
+					return nil
+				}},
+				values: []GeneratedValue{password},
+				cr:     fake.NewMockResource(fake.WithNamespaceName("cool", "ns"), fake.WithUID("cool-uid")),
+			},
+			want: want{err: errors.Errorf("%s: %s", errUncontrolledGeneratedValues, "ns/cool-generated-values")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			vals, err := NewAPISecretValueGenerator(tc.args.kube, "", tc.args.values...).InitializeValues(context.Background(), tc.args.cr)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nInitializeValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if name == "Generate" {
+				// The generated value is random, so only its presence is checked.
+				if g, ok := vals[GeneratedValuesKey].(map[string]interface{}); !ok || g["password"] == "" {
+					t.Errorf("\nReason: %s\nInitializeValues(...): no generated password in %v", tc.reason, vals)
+				}
+				return
+			}
+			if diff := cmp.Diff(tc.want.vals, vals); diff != "" {
+				t.Errorf("\nReason: %s\nInitializeValues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errRecordGeneratedName = "cannot record generated name of child resource"
+)
+
+// usesGenerateName returns true if the name of the given child resource is
+// to be generated by the API server.
+func usesGenerateName(o resource.ChildResource) bool {
+	return o.GetName() == "" && o.GetGenerateName() != ""
+}
+
+// ResolveGeneratedNames sets the names of the child resources that use
+// generateName to the ones that were generated for them in the previous
+// reconciles, so that they are updated instead of created again. The
+// inventory of the parent resource is trimmed down to the given child
+// resources.
+func ResolveGeneratedNames(cr resource.ParentResource, list []resource.ChildResource) error {
+	known := resource.GetGeneratedNames(cr)
+	kept := map[string]string{}
+	for _, o := range list {
+		if !usesGenerateName(o) {
+			continue
+		}
+		key := resource.GeneratedNameKey(o)
+		if name, ok := known[key]; ok {
+			o.SetName(name)
+			kept[key] = name
+		}
+	}
+	return resource.SetGeneratedNames(cr, kept)
+}
+
+// WithoutUngenerated returns the given child resources except the ones that
+// use generateName and were never created.
+func WithoutUngenerated(list []resource.ChildResource) []resource.ChildResource {
+	result := make([]resource.ChildResource, 0, len(list))
+	for _, o := range list {
+		if !usesGenerateName(o) {
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+// createGenerated creates the given child resource, whose name is generated
+// by the API server, and records the generated name in the inventory of the
+// parent resource. Note that the inventory is persisted with the status of the
+// parent resource, so another child resource is created if that update fails.
+func (r *Reconciler) createGenerated(ctx context.Context, cr resource.ParentResource, o resource.ChildResource) error {
+	key := resource.GeneratedNameKey(o)
+	if err := r.client.Create(ctx, o); err != nil {
+		return errors.Wrap(err, errCreateChildResource)
+	}
+	names := resource.GetGeneratedNames(cr)
+	if names == nil {
+		names = map[string]string{}
+	}
+	names[key] = o.GetName()
+	return errors.Wrap(resource.SetGeneratedNames(cr, names), errRecordGeneratedName)
+}
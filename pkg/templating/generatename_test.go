@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rresource "github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func generateNamed(prefix string) *fake.MockResource {
+	o := fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("", namespace))
+	o.SetGenerateName(prefix)
+	return o
+}
+
+func TestResolveGeneratedNames(t *testing.T) {
+	migrate := generateNamed("migrate-")
+	backup := generateNamed("backup-")
+	named := fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("db", namespace))
+
+	cr := fake.NewMockResource()
+	if err := resource.SetGeneratedNames(cr, map[string]string{
+		resource.GeneratedNameKey(migrate):               "migrate-x7k2p",
+		resource.GeneratedNameKey(generateNamed("old-")): "old-a1b2c",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ResolveGeneratedNames(cr, []resource.ChildResource{migrate, backup, named}); err != nil {
+		t.Fatalf("ResolveGeneratedNames(...): %s", err)
+	}
+	if diff := cmp.Diff("migrate-x7k2p", migrate.GetName()); diff != "" {
+		t.Errorf("ResolveGeneratedNames(...): -want known name, +got known name:\n%s", diff)
+	}
+	if diff := cmp.Diff("", backup.GetName()); diff != "" {
+		t.Errorf("ResolveGeneratedNames(...): -want new name, +got new name:\n%s", diff)
+	}
+	// The entries of the child resources that are not rendered anymore should
+	// be dropped.
+	want := map[string]string{resource.GeneratedNameKey(migrate): "migrate-x7k2p"}
+	if diff := cmp.Diff(want, resource.GetGeneratedNames(cr)); diff != "" {
+		t.Errorf("ResolveGeneratedNames(...): -want inventory, +got inventory:\n%s", diff)
+	}
+	if diff := cmp.Diff([]resource.ChildResource{migrate, named}, WithoutUngenerated([]resource.ChildResource{migrate, backup, named})); diff != "" {
+		t.Errorf("WithoutUngenerated(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestCreateGenerated(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		create error
+		want   map[string]string
+		err    error
+	}{
+		"Created": {
+			reason: "The generated name should be recorded in the inventory of the parent resource",
+			want:   map[string]string{resource.GeneratedNameKey(generateNamed("migrate-")): "migrate-x7k2p"},
+		},
+		"CreateFailed": {
+			reason: "Nothing should be recorded if the child resource cannot be created",
+			create: errBoom,
+			err:    errors.Wrap(errBoom, errCreateChildResource),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			kube := &test.MockClient{
+				MockCreate: func(_ context.Context, obj runtime.Object, _ ...client.CreateOption) error {
+					o := obj.(metav1.Object)
+					o.SetName(o.GetGenerateName() + "x7k2p")
+					return tc.create
+				},
+			}
+			r := &Reconciler{client: rresource.ClientApplicator{Client: kube}}
+			cr := fake.NewMockResource()
+			err := r.createGenerated(context.Background(), cr, generateNamed("migrate-"))
+			if diff := cmp.Diff(tc.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\ncreateGenerated(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want, resource.GetGeneratedNames(cr)); diff != "" {
+				t.Errorf("\nReason: %s\ncreateGenerated(...): -want inventory, +got inventory:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/templating-controller/pkg/operations"
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errWriteManifests   = "cannot write manifests of child resources"
+	errOpenPullRequest  = "cannot open pull request"
+	errPullRequestState = "unexpected response to pull request"
+
+	gitAuthorName  = "templating-controller"
+	gitAuthorEmail = "templating-controller@crossplane.io"
+
+	// clusterScopedDir is the directory that the manifests of cluster-scoped
+	// parent resources are written to.
+	clusterScopedDir = "_cluster"
+)
+
+// A PullRequestOpener opens a pull request from the given head branch to the
+// given base branch. It succeeds if such a pull request is already open.
+type PullRequestOpener interface {
+	Open(ctx context.Context, head, base, title string) error
+}
+
+// GitPublisherOption configures a *GitPublisher.
+type GitPublisherOption func(*GitPublisher)
+
+// WithBranchPerParent makes the GitPublisher push the manifests of every
+// parent resource to a branch of its own, based on the configured branch,
+// instead of committing to the configured branch directly. The given
+// PullRequestOpener, if not nil, is used to open a pull request from that
+// branch.
+func WithBranchPerParent(o PullRequestOpener) GitPublisherOption {
+	return func(p *GitPublisher) {
+		p.branchPerParent = true
+		p.pullRequests = o
+	}
+}
+
+// WithGitRunner changes how the GitPublisher runs git.
+func WithGitRunner(g operations.GitRunner) GitPublisherOption {
+	return func(p *GitPublisher) {
+		p.git = g
+	}
+}
+
+// NewGitPublisher returns a new *GitPublisher that clones the repository at
+// given URL into the given directory and writes the manifests under the given
+// path of the given branch.
+func NewGitPublisher(url, dir, branch, path string, o ...GitPublisherOption) *GitPublisher {
+	p := &GitPublisher{
+		url:    url,
+		dir:    dir,
+		branch: branch,
+		path:   path,
+		git:    operations.ExecGitRunner,
+	}
+	for _, f := range o {
+		f(p)
+	}
+	return p
+}
+
+// GitPublisher is a ManifestPublisher that writes the manifests of the child
+// resources of every parent resource to a file in a git repository, named
+// after the namespace and the name of the parent resource, commits and pushes
+// it. The revision it returns is the commit that contains the manifests. The
+// credentials are expected to be configured for git, e.g. in the URL or with
+// a credential helper.
+type GitPublisher struct {
+	url             string
+	dir             string
+	branch          string
+	path            string
+	branchPerParent bool
+	pullRequests    PullRequestOpener
+	git             operations.GitRunner
+
+	// The working copy is shared by all parent resources.
+	mu sync.Mutex
+}
+
+// Publish commits the manifests of the given child resources and pushes them.
+func (p *GitPublisher) Publish(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.checkout(ctx); err != nil {
+		return "", err
+	}
+	branch := p.branch
+	if p.branchPerParent {
+		branch = parentBranch(cr)
+		if _, err := p.git(ctx, p.dir, "checkout", "-B", branch, "origin/"+p.branch); err != nil {
+			return "", err
+		}
+	}
+	file := filepath.Join(p.path, parentDir(cr), cr.GetName()+".yaml")
+	if err := writeManifests(filepath.Join(p.dir, file), list); err != nil {
+		return "", errors.Wrap(err, errWriteManifests)
+	}
+	if _, err := p.git(ctx, p.dir, "add", "--all"); err != nil {
+		return "", err
+	}
+	// diff exits with an error only if there are changes to be committed.
+	if _, err := p.git(ctx, p.dir, "diff", "--cached", "--quiet"); err != nil {
+		title := fmt.Sprintf("Render %s/%s", parentDir(cr), cr.GetName())
+		if _, err := p.git(ctx, p.dir, "-c", "user.name="+gitAuthorName, "-c", "user.email="+gitAuthorEmail, "commit", "-m", title); err != nil {
+			return "", err
+		}
+		args := []string{"push", "origin", branch}
+		if p.branchPerParent {
+			args = []string{"push", "--force", "origin", branch}
+		}
+		if _, err := p.git(ctx, p.dir, args...); err != nil {
+			return "", err
+		}
+		if p.branchPerParent && p.pullRequests != nil {
+			if err := p.pullRequests.Open(ctx, branch, p.branch, title); err != nil {
+				return "", errors.Wrap(err, errOpenPullRequest)
+			}
+		}
+	}
+	return p.git(ctx, p.dir, "rev-parse", "HEAD")
+}
+
+// checkout makes sure the working copy exists and has the latest commit of
+// the configured branch checked out, without any local changes.
+func (p *GitPublisher) checkout(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(p.dir, ".git")); os.IsNotExist(err) {
+		if _, err := p.git(ctx, filepath.Dir(p.dir), "clone", p.url, p.dir); err != nil {
+			return err
+		}
+	}
+	if _, err := p.git(ctx, p.dir, "fetch", "origin", p.branch); err != nil {
+		return err
+	}
+	_, err := p.git(ctx, p.dir, "checkout", "-B", p.branch, "origin/"+p.branch)
+	return err
+}
+
+func parentDir(cr resource.ParentResource) string {
+	if cr.GetNamespace() == "" {
+		return clusterScopedDir
+	}
+	return cr.GetNamespace()
+}
+
+func parentBranch(cr resource.ParentResource) string {
+	return fmt.Sprintf("templating/%s/%s", parentDir(cr), cr.GetName())
+}
+
+// writeManifests writes the given child resources as a multi-document YAML
+// file, or removes the file if there are none.
+func writeManifests(file string, list []resource.ChildResource) error {
+	if len(list) == 0 {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	buf := &bytes.Buffer{}
+	for _, o := range list {
+		b, err := yaml.Marshal(o)
+		if err != nil {
+			return err
+		}
+		buf.WriteString("---\n")
+		buf.Write(b)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, buf.Bytes(), 0644)
+}
+
+// NewGitHubPullRequestOpener returns a new *GitHubPullRequestOpener for the
+// repository with given owner/name that authenticates with the given token.
+// A nil *http.Client means http.DefaultClient.
+func NewGitHubPullRequestOpener(apiURL, repository, token string, c *http.Client) *GitHubPullRequestOpener {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return &GitHubPullRequestOpener{apiURL: strings.TrimSuffix(apiURL, "/"), repository: repository, token: token, client: c}
+}
+
+// GitHubPullRequestOpener opens pull requests with the GitHub API.
+type GitHubPullRequestOpener struct {
+	apiURL     string
+	repository string
+	token      string
+	client     *http.Client
+}
+
+// Open opens a pull request from the given head branch to the given base
+// branch unless one is already open.
+func (o *GitHubPullRequestOpener) Open(ctx context.Context, head, base, title string) error {
+	body, err := json.Marshal(map[string]string{"title": title, "head": head, "base": base})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/repos/%s/pulls", o.apiURL, o.repository), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if o.token != "" {
+		req.Header.Set("Authorization", "token "+o.token)
+	}
+	resp, err := o.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return nil
+	case http.StatusUnprocessableEntity:
+		// GitHub responds with 422 if a pull request for the branch is
+		// already open. Since the branch is force-pushed, that pull
+		// request already has the latest manifests.
+		msg, _ := ioutil.ReadAll(resp.Body)
+		if bytes.Contains(msg, []byte("already exists")) {
+			return nil
+		}
+		return errors.Errorf("%s: %s: %s", errPullRequestState, resp.Status, strings.TrimSpace(string(msg)))
+	default:
+		return errors.Errorf("%s: %s", errPullRequestState, resp.Status)
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ ManifestPublisher = &GitPublisher{}
+	_ PullRequestOpener = &GitHubPullRequestOpener{}
+)
+
+type pullRequestOpenerFn func(ctx context.Context, head, base, title string) error
+
+func (fn pullRequestOpenerFn) Open(ctx context.Context, head, base, title string) error {
+	return fn(ctx, head, base, title)
+}
+
+func TestGitPublisher(t *testing.T) {
+	cr := fake.NewMockResource(fake.WithNamespaceName("cool", "team"))
+	child := fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("db", "team"))
+
+	cases := map[string]struct {
+		reason    string
+		perParent bool
+		list      []resource.ChildResource
+		changed   bool
+		want      []string
+		wantFile  bool
+	}{
+		"Commit": {
+			reason:   "Changed manifests should be committed and pushed to the configured branch",
+			list:     []resource.ChildResource{child},
+			changed:  true,
+			wantFile: true,
+			want: []string{
+				"fetch origin main",
+				"checkout -B main origin/main",
+				"add --all",
+				"diff --cached --quiet",
+				"-c user.name=templating-controller -c user.email=templating-controller@crossplane.io commit -m Render team/cool",
+				"push origin main",
+				"rev-parse HEAD",
+			},
+		},
+		"Unchanged": {
+			reason:   "Nothing should be committed if the manifests did not change",
+			list:     []resource.ChildResource{child},
+			wantFile: true,
+			want: []string{
+				"fetch origin main",
+				"checkout -B main origin/main",
+				"add --all",
+				"diff --cached --quiet",
+				"rev-parse HEAD",
+			},
+		},
+		"BranchPerParent": {
+			reason:    "Manifests should be force-pushed to the branch of the parent resource and a pull request should be opened",
+			perParent: true,
+			list:      []resource.ChildResource{child},
+			changed:   true,
+			wantFile:  true,
+			want: []string{
+				"fetch origin main",
+				"checkout -B main origin/main",
+				"checkout -B templating/team/cool origin/main",
+				"add --all",
+				"diff --cached --quiet",
+				"-c user.name=templating-controller -c user.email=templating-controller@crossplane.io commit -m Render team/cool",
+				"push --force origin templating/team/cool",
+				"pull-request templating/team/cool main",
+				"rev-parse HEAD",
+			},
+		},
+		"Unpublish": {
+			reason:  "The manifests should be removed if there are no child resources",
+			changed: true,
+			want: []string{
+				"fetch origin main",
+				"checkout -B main origin/main",
+				"add --all",
+				"diff --cached --quiet",
+				"-c user.name=templating-controller -c user.email=templating-controller@crossplane.io commit -m Render team/cool",
+				"push origin main",
+				"rev-parse HEAD",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "gitops")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir) // nolint:errcheck
+			if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			git := func(_ context.Context, _ string, args ...string) (string, error) {
+				cmd := strings.Join(args, " ")
+				got = append(got, cmd)
+				if cmd == "diff --cached --quiet" && tc.changed {
+					return "", errBoom
+				}
+				return "cafe", nil
+			}
+			opts := []GitPublisherOption{WithGitRunner(git)}
+			if tc.perParent {
+				opts = append(opts, WithBranchPerParent(pullRequestOpenerFn(func(_ context.Context, head, base, _ string) error {
+					got = append(got, "pull-request "+head+" "+base)
+					return nil
+				})))
+			}
+			rev, err := NewGitPublisher("https://example.org/repo.git", dir, "main", "deploy", opts...).Publish(context.Background(), cr, tc.list)
+			if err != nil {
+				t.Fatalf("\nReason: %s\nPublish(...): %s", tc.reason, err)
+			}
+			if rev != "cafe" {
+				t.Errorf("\nReason: %s\nPublish(...): want revision cafe, got %s", tc.reason, rev)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\nReason: %s\nPublish(...): -want commands, +got commands:\n%s", tc.reason, diff)
+			}
+			_, err = os.Stat(filepath.Join(dir, "deploy", "team", "cool.yaml"))
+			if diff := cmp.Diff(tc.wantFile, err == nil); diff != "" {
+				t.Errorf("\nReason: %s\nPublish(...): -want file, +got file:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestGitHubPullRequestOpener(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		status int
+		body   string
+		want   error
+	}{
+		"Created": {
+			reason: "No error should be returned if the pull request is opened",
+			status: http.StatusCreated,
+		},
+		"AlreadyOpen": {
+			reason: "No error should be returned if the pull request is already open",
+			status: http.StatusUnprocessableEntity,
+			body:   `{"errors":[{"message":"A pull request already exists for org:templating/team/cool."}]}`,
+		},
+		"Failed": {
+			reason: "The error should be returned if the pull request cannot be opened",
+			status: http.StatusUnauthorized,
+			want:   errors.Errorf("%s: %s", errPullRequestState, "401 Unauthorized"),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/repos/org/repo/pulls" || r.Header.Get("Authorization") != "token s3cr3t" {
+					t.Errorf("\nReason: %s\nOpen(...): unexpected request to %s", tc.reason, r.URL.Path)
+				}
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+			err := NewGitHubPullRequestOpener(srv.URL, "org/repo", "s3cr3t", nil).Open(context.Background(), "templating/team/cool", "main", "Render team/cool")
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nOpen(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
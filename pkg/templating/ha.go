@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	errLeaseTimings = "leader election timings must satisfy retry period < renew deadline < lease duration"
+
+	defaultLeaseDuration = 15 * time.Second
+	defaultRenewDeadline = 10 * time.Second
+	defaultRetryPeriod   = 2 * time.Second
+)
+
+// HighAvailability configures the leader election of the replicas of a
+// controller. Only the leader reconciles, so the child resources are never
+// applied by two replicas at the same time. The zero values of the durations
+// mean the defaults.
+type HighAvailability struct {
+	// Namespace is where the leader election lock is kept. It is required if
+	// the controller runs outside of a cluster.
+	Namespace string
+
+	// LeaseDuration is how long the other replicas wait before they take the
+	// lead over from a leader that stopped renewing.
+	LeaseDuration time.Duration
+
+	// RenewDeadline is how long the leader keeps trying to renew its lead
+	// before giving it up.
+	RenewDeadline time.Duration
+
+	// RetryPeriod is how often the replicas try to acquire or renew the lead.
+	RetryPeriod time.Duration
+}
+
+// LeaderElectionID returns the name of the leader election lock of the
+// controller of parent resources of the given kind, which is shared by its
+// replicas only.
+func LeaderElectionID(gvk schema.GroupVersionKind) string {
+	return strings.ToLower(fmt.Sprintf("%s.%s-templating-controller", gvk.Kind, gvk.Group))
+}
+
+// SetupHighAvailability configures the given manager options so that the
+// replicas of the controller of parent resources of the given kind elect a
+// leader to reconcile. When the leader loses its lead, the manager stops with
+// an error instead of reconciling further, and its state, such as the
+// schedule of the syncs, is rebuilt by the next leader by re-rendering every
+// parent resource once, which is safe since applying is idempotent.
+func SetupHighAvailability(o *ctrl.Options, gvk schema.GroupVersionKind, ha HighAvailability) error {
+	lease, renew, retry := ha.LeaseDuration, ha.RenewDeadline, ha.RetryPeriod
+	if lease == 0 {
+		lease = defaultLeaseDuration
+	}
+	if renew == 0 {
+		renew = defaultRenewDeadline
+	}
+	if retry == 0 {
+		retry = defaultRetryPeriod
+	}
+	if !(retry < renew && renew < lease) {
+		return errors.New(errLeaseTimings)
+	}
+	o.LeaderElection = true
+	o.LeaderElectionID = LeaderElectionID(gvk)
+	o.LeaderElectionNamespace = ha.Namespace
+	o.LeaseDuration = &lease
+	o.RenewDeadline = &renew
+	o.RetryPeriod = &retry
+	return nil
+}
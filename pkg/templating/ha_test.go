@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestSetupHighAvailability(t *testing.T) {
+	duration := func(d time.Duration) *time.Duration { return &d }
+	type want struct {
+		o   ctrl.Options
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		ha     HighAvailability
+		want   want
+	}{
+		"Defaults": {
+			reason: "Leader election should be enabled with the default timings",
+			ha:     HighAvailability{Namespace: namespace},
+			want: want{o: ctrl.Options{
+				LeaderElection:          true,
+				LeaderElectionID:        "mockresource.mock.parent.crossplane.io-templating-controller",
+				LeaderElectionNamespace: namespace,
+				LeaseDuration:           duration(defaultLeaseDuration),
+				RenewDeadline:           duration(defaultRenewDeadline),
+				RetryPeriod:             duration(defaultRetryPeriod),
+			}},
+		},
+		"InvalidTimings": {
+			reason: "Timings that would make the leader lose its lead before renewing it should be rejected",
+			ha:     HighAvailability{LeaseDuration: 5 * time.Second},
+			want:   want{err: errors.New(errLeaseTimings)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			o := ctrl.Options{}
+			err := SetupHighAvailability(&o, fake.MockParentGVK, tc.ha)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nSetupHighAvailability(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			// Options have unexported fields, so only the ones about the
+			// leader election are compared.
+			got := ctrl.Options{
+				LeaderElection:          o.LeaderElection,
+				LeaderElectionID:        o.LeaderElectionID,
+				LeaderElectionNamespace: o.LeaderElectionNamespace,
+				LeaseDuration:           o.LeaseDuration,
+				RenewDeadline:           o.RenewDeadline,
+				RetryPeriod:             o.RetryPeriod,
+			}
+			if diff := cmp.Diff(tc.want.o, got, cmpopts.IgnoreUnexported(ctrl.Options{})); diff != "" {
+				t.Errorf("\nReason: %s\nSetupHighAvailability(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	serviceAccountUserPrefix = "system:serviceaccount:"
+
+	errNoServiceAccount        = "parent resource does not name a service account to impersonate"
+	errParseServiceAccount     = "cannot parse service account, expected name or namespace/name"
+	errForeignServiceAccount   = "namespaced parent resource cannot name a service account in another namespace"
+	errImpersonationClient     = "cannot create impersonating client"
+	errImpersonationNotEnabled = "child resource requests impersonation but it is not enabled"
+)
+
+// ServiceAccountUser returns the name of the user that the ServiceAccount with
+// given namespace and name authenticates as.
+func ServiceAccountUser(namespace, name string) string {
+	return serviceAccountUserPrefix + namespace + ":" + name
+}
+
+// NewImpersonationPatcher returns a new ImpersonationPatcher that falls back
+// to the ServiceAccount with given name in the namespace of the parent
+// resource if the parent resource does not name one. An empty name requires
+// every parent resource to name its ServiceAccount.
+func NewImpersonationPatcher(defaultName string) ImpersonationPatcher {
+	return ImpersonationPatcher{defaultName: defaultName}
+}
+
+// ImpersonationPatcher annotates all child resources with the user of the
+// ServiceAccount that the parent resource names in its
+// ServiceAccountAnnotationKey annotation, given as name in its namespace or,
+// for cluster-scoped parent resources, as namespace/name, so that a
+// ClusterRouter with impersonation applies them in the name of that
+// ServiceAccount instead of the controller. The annotations
+// rendered by the templates are overwritten so that they cannot escalate to
+// another ServiceAccount.
+type ImpersonationPatcher struct {
+	defaultName string
+}
+
+// Patch annotates the child resources with the user to impersonate.
+func (p ImpersonationPatcher) Patch(cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	ns, name, err := p.serviceAccount(cr)
+	if err != nil {
+		return nil, err
+	}
+	user := ServiceAccountUser(ns, name)
+	for _, o := range list {
+		meta.AddAnnotations(o, map[string]string{ImpersonateAnnotationKey: user})
+	}
+	return list, nil
+}
+
+// serviceAccount returns the namespace and the name of the ServiceAccount to
+// impersonate for the given parent resource. A namespaced parent resource can
+// name only a ServiceAccount in its own namespace so that it cannot escalate
+// to the ServiceAccounts of other namespaces; only the default given by the
+// operator can be in another one.
+func (p ImpersonationPatcher) serviceAccount(cr resource.ParentResource) (string, string, error) {
+	val := cr.GetAnnotations()[ServiceAccountAnnotationKey]
+	annotated := val != ""
+	if !annotated {
+		val = p.defaultName
+	}
+	parts := strings.Split(val, "/")
+	switch {
+	case val == "":
+		return "", "", errors.New(errNoServiceAccount)
+	case len(parts) == 1 && cr.GetNamespace() != "":
+		return cr.GetNamespace(), val, nil
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		if annotated && cr.GetNamespace() != "" && parts[0] != cr.GetNamespace() {
+			return "", "", errors.Errorf("%s: %s", errForeignServiceAccount, val)
+		}
+		return parts[0], parts[1], nil
+	default:
+		return "", "", errors.Errorf("%s: %s", errParseServiceAccount, val)
+	}
+}
+
+// impersonatedClients creates and keeps the clients that impersonate a user.
+type impersonatedClients struct {
+	newClient func(user string) (client.Client, error)
+	mu        sync.Mutex
+	clients   map[string]client.Client
+}
+
+func (i *impersonatedClients) clientFor(user string) (client.Client, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if kube, ok := i.clients[user]; ok {
+		return kube, nil
+	}
+	kube, err := i.newClient(user)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s for %s", errImpersonationClient, user)
+	}
+	i.clients[user] = kube
+	return kube, nil
+}
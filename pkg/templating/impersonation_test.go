@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ ChildResourcePatcher = ImpersonationPatcher{}
+
+func TestImpersonationPatcher(t *testing.T) {
+	type args struct {
+		defaultName string
+		cr          resource.ParentResource
+	}
+	type want struct {
+		user string
+		err  error
+	}
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"Named": {
+			reason: "The ServiceAccount that the parent resource names should be impersonated in its namespace",
+			args: args{
+				defaultName: "default",
+				cr:          fake.NewMockResource(fake.WithNamespaceName("cool", "tenant"), fake.WithAdditionalAnnotations(map[string]string{ServiceAccountAnnotationKey: "deployer"})),
+			},
+			want: want{user: "system:serviceaccount:tenant:deployer"},
+		},
+		"NamespacedName": {
+			reason: "The ServiceAccount that the parent resource names with its namespace should be impersonated",
+			args: args{
+				cr: fake.NewMockResource(fake.WithNamespaceName("cool", ""), fake.WithAdditionalAnnotations(map[string]string{ServiceAccountAnnotationKey: "team/deployer"})),
+			},
+			want: want{user: "system:serviceaccount:team:deployer"},
+		},
+		"OwnNamespace": {
+			reason: "A namespaced parent resource should be able to name a ServiceAccount with its own namespace",
+			args: args{
+				cr: fake.NewMockResource(fake.WithNamespaceName("cool", "tenant"), fake.WithAdditionalAnnotations(map[string]string{ServiceAccountAnnotationKey: "tenant/deployer"})),
+			},
+			want: want{user: "system:serviceaccount:tenant:deployer"},
+		},
+		"ForeignNamespace": {
+			reason: "It should return error if a namespaced parent resource names a ServiceAccount in another namespace",
+			args: args{
+				cr: fake.NewMockResource(fake.WithNamespaceName("cool", "tenant"), fake.WithAdditionalAnnotations(map[string]string{ServiceAccountAnnotationKey: "kube-system/admin"})),
+			},
+			want: want{err: errors.Errorf("%s: %s", errForeignServiceAccount, "kube-system/admin")},
+		},
+		"DefaultInOtherNamespace": {
+			reason: "The default ServiceAccount given by the operator should be impersonated even if it is in another namespace",
+			args: args{
+				defaultName: "deployers/deployer",
+				cr:          fake.NewMockResource(fake.WithNamespaceName("cool", "tenant")),
+			},
+			want: want{user: "system:serviceaccount:deployers:deployer"},
+		},
+		"Default": {
+			reason: "The default ServiceAccount in the namespace of the parent resource should be impersonated if it names none",
+			args: args{
+				defaultName: "default",
+				cr:          fake.NewMockResource(fake.WithNamespaceName("cool", "tenant")),
+			},
+			want: want{user: "system:serviceaccount:tenant:default"},
+		},
+		"NoServiceAccount": {
+			reason: "It should return error if there is neither a named nor a default ServiceAccount",
+			args: args{
+				cr: fake.NewMockResource(fake.WithNamespaceName("cool", "tenant")),
+			},
+			want: want{err: errors.New(errNoServiceAccount)},
+		},
+		"ClusterScopedWithoutNamespace": {
+			reason: "It should return error if the ServiceAccount of a cluster-scoped parent resource has no namespace",
+			args: args{
+				defaultName: "default",
+				cr:          fake.NewMockResource(fake.WithNamespaceName("cool", "")),
+			},
+			want: want{err: errors.Errorf("%s: %s", errParseServiceAccount, "default")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			child := fake.NewMockResource()
+			_, err := NewImpersonationPatcher(tc.args.defaultName).Patch(tc.args.cr, []resource.ChildResource{child})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nPatch(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.user, child.GetAnnotations()[ImpersonateAnnotationKey]); diff != "" {
+				t.Errorf("\nReason: %s\nPatch(...): -want user, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -19,55 +19,27 @@ package templating
 import (
 	"context"
 
+	"github.com/crossplane/templating-controller/pkg/operations"
 	"github.com/crossplane/templating-controller/pkg/resource"
 )
 
 // Engine is used as main generation engine by the templating reconciler.
-// Its input is typically a Custom Resource instance and output is various
-// Kubernetes objects generated by the given implementation of the Engine.
-type Engine interface {
-	Run(resource.ParentResource) ([]resource.ChildResource, error)
-}
+type Engine = operations.Engine
 
 // EngineFunc used for supplying only one function as templating engine.
-type EngineFunc func(resource.ParentResource) ([]resource.ChildResource, error)
-
-// Run calls the EngineFunc function.
-func (t EngineFunc) Run(cr resource.ParentResource) ([]resource.ChildResource, error) {
-	return t(cr)
-}
+type EngineFunc = operations.EngineFunc
 
 // ChildResourcePatcher operates on the resources rendered by the templating
 // engine.
-type ChildResourcePatcher interface {
-	Patch(resource.ParentResource, []resource.ChildResource) ([]resource.ChildResource, error)
-}
+type ChildResourcePatcher = operations.ChildResourcePatcher
 
 // ChildResourcePatcherFunc makes it easier to provide only a function as
 // ChildResourcePatcher
-type ChildResourcePatcherFunc func(resource.ParentResource, []resource.ChildResource) ([]resource.ChildResource, error)
-
-// Patch calls the ChildResourcePatcherFunc function.
-func (pre ChildResourcePatcherFunc) Patch(cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
-	return pre(cr, list)
-}
+type ChildResourcePatcherFunc = operations.ChildResourcePatcherFunc
 
 // ChildResourcePatcherChain makes it easier to provide a list of ChildResourcePatcher
 // to be called in order.
-type ChildResourcePatcherChain []ChildResourcePatcher
-
-// Patch calls the ChildResourcePatcherChain functions in order.
-func (pre ChildResourcePatcherChain) Patch(cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
-	currentList := list
-	var err error
-	for _, f := range pre {
-		currentList, err = f.Patch(cr, currentList)
-		if err != nil {
-			return nil, err
-		}
-	}
-	return currentList, nil
-}
+type ChildResourcePatcherChain = operations.ChildResourcePatcherChain
 
 // ChildResourceDeleter deletes the child resources.
 type ChildResourceDeleter interface {
@@ -82,3 +54,362 @@ type ChildResourceDeleterFunc func(ctx context.Context, cr resource.ParentResour
 func (pre ChildResourceDeleterFunc) Delete(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
 	return pre(ctx, cr, list)
 }
+
+// ChildResourceDeleterChain makes it easier to provide a list of
+// ChildResourceDeleter to be called in order. A ChildResourceDeleter that
+// returns child resources which are still being deleted blocks the ones after
+// it, so that steps like scaling a workload down can complete before the
+// child resources are actually deleted.
+type ChildResourceDeleterChain []ChildResourceDeleter
+
+// Delete calls the ChildResourceDeleterChain functions in order until one of
+// them reports child resources that are still being deleted.
+func (dc ChildResourceDeleterChain) Delete(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	for _, d := range dc {
+		deleting, err := d.Delete(ctx, cr, list)
+		if err != nil || len(deleting) > 0 {
+			return deleting, err
+		}
+	}
+	return nil, nil
+}
+
+// A ChildEvent is a change that the reconciler made to a child resource.
+type ChildEvent string
+
+// The changes that the reconciler makes to child resources.
+const (
+	ChildCreated ChildEvent = "Created"
+	ChildUpdated ChildEvent = "Updated"
+	ChildDeleted ChildEvent = "Deleted"
+)
+
+// A ChildLifecycleHook is called after a child resource was created, updated
+// or deleted, e.g. to register the child resources in an external inventory.
+type ChildLifecycleHook interface {
+	Run(ctx context.Context, cr resource.ParentResource, o resource.ChildResource, e ChildEvent) error
+}
+
+// ChildLifecycleHookFunc makes it easier to provide only a function as
+// ChildLifecycleHook.
+type ChildLifecycleHookFunc func(ctx context.Context, cr resource.ParentResource, o resource.ChildResource, e ChildEvent) error
+
+// Run calls the ChildLifecycleHookFunc function.
+func (h ChildLifecycleHookFunc) Run(ctx context.Context, cr resource.ParentResource, o resource.ChildResource, e ChildEvent) error {
+	return h(ctx, cr, o, e)
+}
+
+// ChildLifecycleHookChain makes it easier to provide a list of
+// ChildLifecycleHook to be called in order.
+type ChildLifecycleHookChain []ChildLifecycleHook
+
+// Run calls the ChildLifecycleHookChain functions in order and stops at the
+// first error.
+func (hc ChildLifecycleHookChain) Run(ctx context.Context, cr resource.ParentResource, o resource.ChildResource, e ChildEvent) error {
+	for _, h := range hc {
+		if err := h.Run(ctx, cr, o, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A Hook is run at a certain stage of the reconciliation, such as before the
+// render or after a successful apply of the child resources.
+type Hook = operations.Hook
+
+// HookFunc makes it easier to provide only a function as Hook.
+type HookFunc = operations.HookFunc
+
+// HookChain makes it easier to provide a list of Hook to be called in order.
+type HookChain = operations.HookChain
+
+// A Stage of the reconciliation that a Middleware can wrap.
+type Stage = operations.Stage
+
+// Stages of the reconciliation.
+const (
+	StageRender = operations.StageRender
+	StagePatch  = operations.StagePatch
+	StageApply  = operations.StageApply
+)
+
+// A StageFunc runs a Stage of the reconciliation.
+type StageFunc = operations.StageFunc
+
+// A Middleware wraps the stages of the reconciliation with cross-cutting
+// behavior.
+type Middleware = operations.Middleware
+
+// MiddlewareFunc makes it easier to provide only a function as Middleware.
+type MiddlewareFunc = operations.MiddlewareFunc
+
+// MiddlewareChain makes it easier to provide a list of Middleware.
+type MiddlewareChain = operations.MiddlewareChain
+
+// A ReadinessChecker tells whether the given child resource that has just been
+// applied is ready to be depended on.
+type ReadinessChecker interface {
+	IsReady(ctx context.Context, o resource.ChildResource) (bool, error)
+}
+
+// ReadinessCheckerFunc makes it easier to provide only a function as
+// ReadinessChecker.
+type ReadinessCheckerFunc func(ctx context.Context, o resource.ChildResource) (bool, error)
+
+// IsReady calls the ReadinessCheckerFunc function.
+func (r ReadinessCheckerFunc) IsReady(ctx context.Context, o resource.ChildResource) (bool, error) {
+	return r(ctx, o)
+}
+
+// A RevisionStore stores the child resources of applied revisions of parent
+// resources so that a parent resource can be rolled back to one of them.
+type RevisionStore interface {
+	Save(ctx context.Context, cr resource.ParentResource, rev int64, list []resource.ChildResource) error
+	Load(ctx context.Context, cr resource.ParentResource, rev int64) ([]resource.ChildResource, error)
+	Delete(ctx context.Context, cr resource.ParentResource, rev int64) error
+}
+
+// A RenderCache stores the digest of the child resources rendered for the key
+// of a render, i.e. of the templates and the input, so that renders made
+// elsewhere, e.g. in CI, can be verified against the ones of the controller.
+type RenderCache interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Put(ctx context.Context, key string, list []resource.ChildResource) (string, error)
+}
+
+// A ValuesProvider supplies additional values to the render of a parent
+// resource.
+type ValuesProvider = operations.ValuesProvider
+
+// ValuesProviderFunc makes it easier to provide only a function as
+// ValuesProvider.
+type ValuesProviderFunc = operations.ValuesProviderFunc
+
+// ValuesProviderChain makes it easier to provide a list of ValuesProvider to
+// be called in order.
+type ValuesProviderChain = operations.ValuesProviderChain
+
+// A SecretValuesProvider is a ValuesProvider that reads its values from, or
+// writes them to, Secrets. The ones that provide secrets are left out of the
+// renders served to callers outside of the controller.
+type SecretValuesProvider = operations.SecretValuesProvider
+
+// A ValuesInitializer is a ValuesProvider whose values have to be
+// initialized before they are applied. The Reconciler initializes them only
+// before the renders that it applies.
+type ValuesInitializer = operations.ValuesInitializer
+
+// A PauseSwitch tells whether the reconciliation of all parent resources is
+// paused, e.g. during an emergency change freeze.
+type PauseSwitch interface {
+	Paused(ctx context.Context) (bool, error)
+}
+
+// PauseSwitchFunc makes it easier to provide only a function as PauseSwitch.
+type PauseSwitchFunc func(ctx context.Context) (bool, error)
+
+// Paused calls the PauseSwitchFunc function.
+func (p PauseSwitchFunc) Paused(ctx context.Context) (bool, error) {
+	return p(ctx)
+}
+
+// A Converter converts the given parent resource, whose spec was written in
+// an older version of the parent API, into the shape of the version that the
+// reconciler serves. It is called with a copy of the parent resource so the
+// changes are never written to the API server.
+type Converter interface {
+	Convert(ctx context.Context, cr resource.ParentResource) error
+}
+
+// ConverterFunc makes it easier to provide only a function as Converter.
+type ConverterFunc func(ctx context.Context, cr resource.ParentResource) error
+
+// Convert calls the ConverterFunc function.
+func (c ConverterFunc) Convert(ctx context.Context, cr resource.ParentResource) error {
+	return c(ctx, cr)
+}
+
+// ConverterChain makes it easier to provide a list of Converter to be called
+// in order.
+type ConverterChain []Converter
+
+// Convert calls the ConverterChain functions in order and stops at the first
+// error.
+func (cc ConverterChain) Convert(ctx context.Context, cr resource.ParentResource) error {
+	for _, c := range cc {
+		if err := c.Convert(ctx, cr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// An AuditSink records the changes that are applied to the child resources.
+type AuditSink interface {
+	Record(ctx context.Context, rec AuditRecord) error
+}
+
+// AuditSinkFunc makes it easier to provide only a function as AuditSink.
+type AuditSinkFunc func(ctx context.Context, rec AuditRecord) error
+
+// Record calls the AuditSinkFunc function.
+func (a AuditSinkFunc) Record(ctx context.Context, rec AuditRecord) error {
+	return a(ctx, rec)
+}
+
+// AuditSinkChain makes it easier to provide a list of AuditSink to record the
+// changes in.
+type AuditSinkChain []AuditSink
+
+// Record records the change in every AuditSink and stops at the first error.
+func (ac AuditSinkChain) Record(ctx context.Context, rec AuditRecord) error {
+	for _, a := range ac {
+		if err := a.Record(ctx, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A SnapshotStore keeps the live manifests of the child resources that are
+// about to be deleted, so that they can be recovered.
+type SnapshotStore interface {
+	Save(ctx context.Context, o resource.ChildResource) error
+}
+
+// SnapshotStoreFunc makes it easier to provide only a function as
+// SnapshotStore.
+type SnapshotStoreFunc func(ctx context.Context, o resource.ChildResource) error
+
+// Save calls the SnapshotStoreFunc function.
+func (s SnapshotStoreFunc) Save(ctx context.Context, o resource.ChildResource) error {
+	return s(ctx, o)
+}
+
+// A ManifestPublisher publishes the rendered child resources of a parent
+// resource somewhere other than the cluster, e.g. a git repository that a
+// GitOps pipeline applies them from, and returns the revision they are
+// published at. An empty list unpublishes the child resources of the parent
+// resource.
+type ManifestPublisher interface {
+	Publish(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) (string, error)
+}
+
+// ManifestPublisherFunc makes it easier to provide only a function as
+// ManifestPublisher.
+type ManifestPublisherFunc func(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) (string, error)
+
+// Publish calls the ManifestPublisherFunc function.
+func (p ManifestPublisherFunc) Publish(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) (string, error) {
+	return p(ctx, cr, list)
+}
+
+// A SourceStatusWriter reports the SourceStatus of the templates, e.g. to
+// operators or to a monitoring system.
+type SourceStatusWriter interface {
+	Write(ctx context.Context, s SourceStatus) error
+}
+
+// SourceStatusWriterFunc makes it easier to provide only a function as
+// SourceStatusWriter.
+type SourceStatusWriterFunc func(ctx context.Context, s SourceStatus) error
+
+// Write calls the SourceStatusWriterFunc function.
+func (w SourceStatusWriterFunc) Write(ctx context.Context, s SourceStatus) error {
+	return w(ctx, s)
+}
+
+// SourceStatusWriterChain makes it easier to provide a list of
+// SourceStatusWriter to report the status to.
+type SourceStatusWriterChain []SourceStatusWriter
+
+// Write writes the status with every SourceStatusWriter and stops at the first
+// error.
+func (wc SourceStatusWriterChain) Write(ctx context.Context, s SourceStatus) error {
+	for _, w := range wc {
+		if err := w.Write(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A Notifier sends the outcome of a reconcile to the operators, e.g. to a chat
+// channel.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NotifierFunc makes it easier to provide only a function as Notifier.
+type NotifierFunc func(ctx context.Context, n Notification) error
+
+// Notify calls the NotifierFunc function.
+func (f NotifierFunc) Notify(ctx context.Context, n Notification) error {
+	return f(ctx, n)
+}
+
+// NotifierChain makes it easier to provide a list of Notifier to send the
+// notifications to.
+type NotifierChain []Notifier
+
+// Notify sends the notification with every Notifier and stops at the first
+// error.
+func (nc NotifierChain) Notify(ctx context.Context, n Notification) error {
+	for _, no := range nc {
+		if err := no.Notify(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A Vendorer fetches the remote references of the pack in the given
+// directory, e.g. remote bases or chart dependencies, into the directory so
+// that the pack can be rendered without network access.
+type Vendorer interface {
+	Vendor(ctx context.Context, dir string) error
+}
+
+// VendorerFunc makes it easier to provide only a function as Vendorer.
+type VendorerFunc func(ctx context.Context, dir string) error
+
+// Vendor calls the VendorerFunc function.
+func (f VendorerFunc) Vendor(ctx context.Context, dir string) error {
+	return f(ctx, dir)
+}
+
+// VendorerChain makes it easier to provide a list of Vendorer to run on the
+// same pack.
+type VendorerChain []Vendorer
+
+// Vendor runs every Vendorer and stops at the first error.
+func (vc VendorerChain) Vendor(ctx context.Context, dir string) error {
+	for _, v := range vc {
+		if err := v.Vendor(ctx, dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A DeletionPlanner is a Hook that can tell which objects it would delete if
+// it ran on the given child resources, without deleting them, so that the
+// deletions show up in the ApplyPlan.
+type DeletionPlanner interface {
+	PlanDeletion(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error)
+}
+
+// A SecretStore reads the key-value pairs of a secret at the given path of an
+// external secret backend, e.g. Vault.
+type SecretStore interface {
+	GetSecret(ctx context.Context, path string) (map[string]string, error)
+}
+
+// SecretStoreFunc makes it easier to provide only a function as SecretStore.
+type SecretStoreFunc func(ctx context.Context, path string) (map[string]string, error)
+
+// GetSecret calls the SecretStoreFunc function.
+func (f SecretStoreFunc) GetSecret(ctx context.Context, path string) (map[string]string, error) {
+	return f(ctx, path)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// childInventory keeps the results of the last apply of the rendered child
+// resources so that a failed apply can be traced back to the child resource
+// that caused it from the status of the parent resource.
+type childInventory struct {
+	list     []resource.ChildResource
+	statuses map[resource.ChildReference]resource.ChildStatus
+	applied  map[resource.ApplyResult]int
+}
+
+// newChildInventory returns the inventory of the given rendered child
+// resources with the results that are recorded in the given parent resource.
+// The results of the child resources that are not rendered anymore are
+// dropped. An inventory that cannot be parsed is started over.
+func newChildInventory(cr resource.ParentResource, list []resource.ChildResource) *childInventory {
+	inv := &childInventory{
+		list:     list,
+		statuses: map[resource.ChildReference]resource.ChildStatus{},
+		applied:  map[resource.ApplyResult]int{},
+	}
+	previous, err := resource.GetChildStatuses(cr)
+	if err != nil {
+		return inv
+	}
+	for _, s := range previous {
+		inv.statuses[s.ChildReference] = s
+	}
+	return inv
+}
+
+// record records the result of applying the given child resource. The time
+// of the previous result is kept if the result did not change, so that the
+// status of a parent resource whose child resources are unchanged stays the
+// same and does not have to be written.
+func (i *childInventory) record(o resource.ChildResource, res resource.ApplyResult, err error) {
+	s := resource.ChildStatus{
+		ChildReference: resource.ReferenceTo(o),
+		LastApplyTime:  metav1.Now(),
+		LastOperation:  res,
+	}
+	if err != nil {
+		s.LastError = err.Error()
+	}
+	if prev, ok := i.statuses[s.ChildReference]; ok && prev.LastOperation == s.LastOperation && prev.LastError == s.LastError {
+		s.LastApplyTime = prev.LastApplyTime
+	}
+	i.statuses[s.ChildReference] = s
+	i.applied[res]++
+}
+
+// recordRun records the result of running the given one-shot child resource
+// whose rendered form has the given hash.
+func (i *childInventory) recordRun(o resource.ChildResource, res resource.ApplyResult, err error, hash string) {
+	i.record(o, res, err)
+	ref := resource.ReferenceTo(o)
+	s := i.statuses[ref]
+	s.RunHash = hash
+	i.statuses[ref] = s
+}
+
+// write records the inventory in the status of the given parent resource, in
+// the order of the rendered child resources. The references are taken at
+// write time so that the names generated during apply are used.
+func (i *childInventory) write(cr resource.ParentResource) error {
+	result := make([]resource.ChildStatus, 0, len(i.list))
+	for _, o := range i.list {
+		if s, ok := i.statuses[resource.ReferenceTo(o)]; ok {
+			result = append(result, s)
+		}
+	}
+	return resource.SetChildStatuses(cr, result)
+}
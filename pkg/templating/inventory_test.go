@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestChildInventory(t *testing.T) {
+	kept := fake.NewMockResource(fake.WithNamespaceName("kept", "default"), fake.WithGVK(fake.MockChildGVK))
+	failed := fake.NewMockResource(fake.WithNamespaceName("failed", "default"), fake.WithGVK(fake.MockChildGVK))
+	gone := fake.NewMockResource(fake.WithNamespaceName("gone", "default"), fake.WithGVK(fake.MockChildGVK))
+	cr := fake.NewMockResource()
+	applied := metav1.NewTime(time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC))
+	if err := resource.SetChildStatuses(cr, []resource.ChildStatus{
+		{ChildReference: resource.ReferenceTo(kept), LastApplyTime: applied, LastOperation: resource.ApplyCreated},
+		{ChildReference: resource.ReferenceTo(gone), LastOperation: resource.ApplyCreated},
+	}); err != nil {
+		t.Fatalf("SetChildStatuses(...): %s", err)
+	}
+
+	inv := newChildInventory(cr, []resource.ChildResource{kept, failed})
+	inv.record(kept, resource.ApplyCreated, nil)
+	inv.record(failed, resource.ApplyFailed, errBoom)
+	if err := inv.write(cr); err != nil {
+		t.Fatalf("write(...): %s", err)
+	}
+	got, err := resource.GetChildStatuses(cr)
+	if err != nil {
+		t.Fatalf("GetChildStatuses(...): %s", err)
+	}
+	want := []resource.ChildStatus{
+		{ChildReference: resource.ReferenceTo(kept), LastOperation: resource.ApplyCreated},
+		{ChildReference: resource.ReferenceTo(failed), LastOperation: resource.ApplyFailed, LastError: errBoom.Error()},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(resource.ChildStatus{}, "LastApplyTime")); diff != "" {
+		t.Errorf("write(...): -want, +got:\n%s", diff)
+	}
+	if len(got) == 0 || !got[0].LastApplyTime.Equal(&applied) {
+		t.Errorf("record(...): the time of an unchanged result should be kept, got %v", got)
+	}
+}
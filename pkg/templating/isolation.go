@@ -0,0 +1,161 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errNamespaceTemplate = "cannot copy the namespace template object"
+
+	// maxNamespaceLength is the maximum length of a namespace name, which has
+	// to be a DNS label.
+	maxNamespaceLength = 63
+
+	// namespaceHashLength is the length of the hash that makes the names of
+	// the isolated namespaces unique.
+	namespaceHashLength = 8
+)
+
+// IsolatedNamespaceName returns the name of the namespace dedicated to the
+// given parent resource, which is the given prefix followed by its namespace,
+// if any, its name and a short hash of its namespace and name. Since a
+// namespace name has to be a DNS label, the characters that are not allowed in
+// one, e.g. the dots of the parent name, are replaced with dashes and names
+// that are too long for a namespace are cut. The hash keeps the names unique
+// regardless, e.g. the ones of foo/bar-baz and foo-bar/baz, or of a.b and a-b.
+func IsolatedNamespaceName(prefix string, cr resource.ParentResource) string {
+	name := prefix + cr.GetName()
+	if cr.GetNamespace() != "" {
+		name = prefix + cr.GetNamespace() + "-" + cr.GetName()
+	}
+	label := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, name)
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(cr.GetNamespace()+"/"+cr.GetName())))[:namespaceHashLength]
+	if len(label) > maxNamespaceLength-namespaceHashLength-1 {
+		label = label[:maxNamespaceLength-namespaceHashLength-1]
+	}
+	if label = strings.Trim(label, "-"); label == "" {
+		return hash
+	}
+	return label + "-" + hash
+}
+
+// NewNamespaceIsolator returns a new *NamespaceIsolator that renders with the
+// given Engine, looks the scope of the kinds up in the given RESTMapper and
+// names the namespaces with the given prefix. The given objects, e.g.
+// ResourceQuotas and NetworkPolicies, are rendered into every namespace.
+func NewNamespaceIsolator(e Engine, m meta.RESTMapper, prefix string, template ...resource.ChildResource) *NamespaceIsolator {
+	return &NamespaceIsolator{engine: e, mapper: m, prefix: prefix, template: template}
+}
+
+// NamespaceIsolator is an Engine that gives every parent resource a namespace
+// of its own, the standard pattern of tenant onboarding packs. It renders the
+// namespace, named by IsolatedNamespaceName, and the template objects in it as
+// child resources, in front of the ones of the wrapped Engine, and moves the
+// child resources of namespaced kinds that are rendered without a namespace,
+// or in the one of the parent resource, into it. Since the namespace is a
+// child resource, it gets the parent labels, is tracked like the other child
+// resources and deleted with the parent resource. The child resources that are
+// rendered in other namespaces, and the ones of cluster-scoped kinds, are left
+// as they are. The kinds that are not served yet, e.g. the ones whose
+// CustomResourceDefinitions are rendered along with them, are assumed to be
+// namespaced.
+type NamespaceIsolator struct {
+	engine   Engine
+	mapper   meta.RESTMapper
+	prefix   string
+	template []resource.ChildResource
+}
+
+// Run renders the child resources of the given parent resource into its
+// namespace.
+func (n *NamespaceIsolator) Run(cr resource.ParentResource) ([]resource.ChildResource, error) {
+	list, err := n.engine.Run(cr)
+	if err != nil {
+		return nil, err
+	}
+	name := IsolatedNamespaceName(n.prefix, cr)
+	ns := &unstructured.Unstructured{}
+	ns.SetAPIVersion(corev1.SchemeGroupVersion.String())
+	ns.SetKind("Namespace")
+	ns.SetName(name)
+
+	result := make([]resource.ChildResource, 0, len(list)+len(n.template)+1)
+	result = append(result, ns)
+	for _, t := range n.template {
+		o, ok := t.DeepCopyObject().(resource.ChildResource)
+		if !ok {
+			return nil, errors.New(errNamespaceTemplate)
+		}
+		namespaced, err := n.namespaced(o.GetObjectKind().GroupVersionKind())
+		if err != nil {
+			return nil, err
+		}
+		if namespaced {
+			o.SetNamespace(name)
+		}
+		result = append(result, o)
+	}
+	for _, o := range list {
+		if o.GetNamespace() != "" && o.GetNamespace() != cr.GetNamespace() {
+			result = append(result, o)
+			continue
+		}
+		namespaced, err := n.namespaced(o.GetObjectKind().GroupVersionKind())
+		if err != nil {
+			return nil, err
+		}
+		if namespaced {
+			o.SetNamespace(name)
+		}
+		result = append(result, o)
+	}
+	return result, nil
+}
+
+func (n *NamespaceIsolator) namespaced(gvk schema.GroupVersionKind) (bool, error) {
+	if gvk.GroupKind() == namespaceGroupKind {
+		return false, nil
+	}
+	mapping, err := n.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "%s: %s", errMapKind, gvk)
+	}
+	return mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
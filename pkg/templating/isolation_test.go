@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ Engine = &NamespaceIsolator{}
+)
+
+func TestIsolatedNamespaceName(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		parent resource.ParentResource
+		want   string
+	}{
+		"Namespaced": {
+			reason: "The namespace of a namespaced parent resource should be part of the name",
+			parent: fake.NewMockResource(fake.WithNamespaceName("cool", "team")),
+			want:   "tenant-team-cool-365bddec",
+		},
+		"ClusterScoped": {
+			reason: "The name of a cluster-scoped parent resource should follow the prefix",
+			parent: fake.NewMockResource(fake.WithNamespaceName("cool", "")),
+			want:   "tenant-cool-f3d7a0d1",
+		},
+		"Dotted": {
+			reason: "The dots of the parent name should be replaced so that the name is a DNS label",
+			parent: fake.NewMockResource(fake.WithNamespaceName("cool.example.org", "team")),
+			want:   "tenant-team-cool-example-org-279c4d41",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, IsolatedNamespaceName("tenant-", tc.parent)); diff != "" {
+				t.Errorf("\nReason: %s\nIsolatedNamespaceName(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+
+	long := IsolatedNamespaceName("tenant-", fake.NewMockResource(fake.WithNamespaceName(strings.Repeat("a", 70), "team")))
+	other := IsolatedNamespaceName("tenant-", fake.NewMockResource(fake.WithNamespaceName(strings.Repeat("a", 71), "team")))
+	if len(long) != maxNamespaceLength || long == other {
+		t.Errorf("IsolatedNamespaceName(...): long names should be cut to unique names of %d characters, got %s and %s", maxNamespaceLength, long, other)
+	}
+	// The names that are the same once sanitized should still be unique.
+	for _, pair := range [][2]resource.ParentResource{
+		{fake.NewMockResource(fake.WithNamespaceName("bar-baz", "foo")), fake.NewMockResource(fake.WithNamespaceName("baz", "foo-bar"))},
+		{fake.NewMockResource(fake.WithNamespaceName("a.b", "")), fake.NewMockResource(fake.WithNamespaceName("a-b", ""))},
+	} {
+		a, b := IsolatedNamespaceName("tenant-", pair[0]), IsolatedNamespaceName("tenant-", pair[1])
+		if a == b {
+			t.Errorf("IsolatedNamespaceName(...): %s/%s and %s/%s should get different names, got %s", pair[0].GetNamespace(), pair[0].GetName(), pair[1].GetNamespace(), pair[1].GetName(), a)
+		}
+	}
+	dotted := IsolatedNamespaceName("tenant-", fake.NewMockResource(fake.WithNamespaceName(strings.Repeat("a.", 40), "team")))
+	if errs := validation.IsDNS1123Label(dotted); len(errs) > 0 {
+		t.Errorf("IsolatedNamespaceName(...): long dotted names should be cut to DNS labels, got %s: %s", dotted, strings.Join(errs, ", "))
+	}
+}
+
+func TestNamespaceIsolator(t *testing.T) {
+	type ref struct {
+		Kind      string
+		Namespace string
+		Name      string
+	}
+	clusterRole := schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}
+	unserved := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Unserved"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}, fake.MockChildGVK.GroupVersion(), clusterRole.GroupVersion()})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ResourceQuota"}, meta.RESTScopeNamespace)
+	mapper.Add(fake.MockChildGVK, meta.RESTScopeNamespace)
+	mapper.Add(clusterRole, meta.RESTScopeRoot)
+
+	cr := fake.NewMockResource(fake.WithNamespaceName("cool", "team"))
+	engine := EngineFunc(func(_ resource.ParentResource) ([]resource.ChildResource, error) {
+		return []resource.ChildResource{
+			fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("unset", "")),
+			fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("parent", "team")),
+			fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("other", "kube-system")),
+			fake.NewMockResource(fake.WithGVK(clusterRole), fake.WithNamespaceName("role", "")),
+			fake.NewMockResource(fake.WithGVK(unserved), fake.WithNamespaceName("unserved", "")),
+		}, nil
+	})
+	quota := fake.NewMockResource(fake.FromYAML([]byte("apiVersion: v1\nkind: ResourceQuota\nmetadata:\n  name: quota\n")))
+	list, err := NewNamespaceIsolator(engine, mapper, "tenant-", quota).Run(cr)
+	if diff := cmp.Diff(nil, err, test.EquateErrors()); diff != "" {
+		t.Errorf("Run(...): -want error, +got error:\n%s", diff)
+	}
+	got := make([]ref, len(list))
+	for i, o := range list {
+		got[i] = ref{Kind: o.GetObjectKind().GroupVersionKind().Kind, Namespace: o.GetNamespace(), Name: o.GetName()}
+	}
+	want := []ref{
+		{Kind: "Namespace", Name: "tenant-team-cool-365bddec"},
+		{Kind: "ResourceQuota", Namespace: "tenant-team-cool-365bddec", Name: "quota"},
+		{Kind: fake.MockChildGVK.Kind, Namespace: "tenant-team-cool-365bddec", Name: "unset"},
+		{Kind: fake.MockChildGVK.Kind, Namespace: "tenant-team-cool-365bddec", Name: "parent"},
+		{Kind: fake.MockChildGVK.Kind, Namespace: "kube-system", Name: "other"},
+		{Kind: clusterRole.Kind, Name: "role"},
+		{Kind: unserved.Kind, Namespace: "tenant-team-cool-365bddec", Name: "unserved"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Run(...): -want, +got:\n%s", diff)
+	}
+	if quota.GetNamespace() != "" {
+		t.Errorf("Run(...): the template objects should not be changed")
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"bytes"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errReadLabelSchema     = "cannot read label schema"
+	errParseLabelSchema    = "cannot parse label schema"
+	errInvalidLabelRule    = "invalid label schema rule"
+	errWildcardDefault     = "label with a wildcard key cannot have a default"
+	errMissingLabels       = "child resource is missing the labels that the label schema requires"
+	errLabelPattern        = "child resource has labels whose values do not match the label schema"
+	errRenderLabelDefault  = "cannot render default of label"
+	errInvalidLabelDefault = "default of label renders an invalid label value"
+)
+
+// LabelSchemaRule is a label that the label schema requires every child
+// resource to have.
+type LabelSchemaRule struct {
+	// Key of the label. A key that ends with /* requires at least one label
+	// with the same prefix, e.g. app.kubernetes.io/*.
+	Key string `json:"key"`
+
+	// Default is a Go template that renders the value of the label from the
+	// parent resource, e.g. {{ .metadata.name }}, for the child resources
+	// whose templates omit the label. The child resources that omit a label
+	// without a default fail the render.
+	Default string `json:"default,omitempty"`
+
+	// Pattern is a regular expression that the whole value of the label must
+	// match.
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// LabelSchemaSpec is the content of a label schema file.
+type LabelSchemaSpec struct {
+	Labels []LabelSchemaRule `json:"labels"`
+}
+
+// LoadLabelSchema returns the *LabelSchema in the given YAML file.
+func LoadLabelSchema(path string) (*LabelSchema, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, errReadLabelSchema)
+	}
+	spec := LabelSchemaSpec{}
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, errors.Wrap(err, errParseLabelSchema)
+	}
+	return NewLabelSchema(spec.Labels...)
+}
+
+type labelRule struct {
+	LabelSchemaRule
+	prefix   string
+	fallback *template.Template
+	pattern  *regexp.Regexp
+}
+
+// NewLabelSchema returns a new *LabelSchema with the given rules.
+func NewLabelSchema(rules ...LabelSchemaRule) (*LabelSchema, error) {
+	s := &LabelSchema{rules: make([]labelRule, len(rules))}
+	for i, r := range rules {
+		lr := labelRule{LabelSchemaRule: r}
+		if strings.HasSuffix(r.Key, "/*") {
+			lr.prefix = strings.TrimSuffix(r.Key, "*")
+			if r.Default != "" {
+				return nil, errors.Errorf("%s %s: %s", errInvalidLabelRule, r.Key, errWildcardDefault)
+			}
+		} else if problems := validation.IsQualifiedName(r.Key); len(problems) > 0 {
+			return nil, errors.Errorf("%s %s: %s", errInvalidLabelRule, r.Key, strings.Join(problems, "; "))
+		}
+		if r.Default != "" {
+			t, err := template.New(r.Key).Option("missingkey=error").Parse(r.Default)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s %s", errInvalidLabelRule, r.Key)
+			}
+			lr.fallback = t
+		}
+		if r.Pattern != "" {
+			p, err := regexp.Compile("^(?:" + r.Pattern + ")$")
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s %s", errInvalidLabelRule, r.Key)
+			}
+			lr.pattern = p
+		}
+		s.rules[i] = lr
+	}
+	return s, nil
+}
+
+// LabelSchema is a ChildResourcePatcher that enforces a set of labels on all
+// child resources, e.g. the app.kubernetes.io ones and a team label, that
+// operators require for cost allocation and routing regardless of what the
+// templates of the pack set. The labels that a child resource omits are
+// filled in from their defaults, which are rendered from the parent resource.
+// The child resources that omit labels without defaults, or whose labels do
+// not match their patterns, fail the render with the names of the labels.
+type LabelSchema struct {
+	rules []labelRule
+}
+
+// Patch fills in the missing labels of the child resources and returns error
+// if any of them does not satisfy the label schema.
+func (s *LabelSchema) Patch(cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	defaults := map[string]string{}
+	for _, o := range list {
+		var missing, mismatched []string
+		for _, r := range s.rules {
+			if r.prefix != "" {
+				vals := prefixedValues(o.GetLabels(), r.prefix)
+				if len(vals) == 0 {
+					missing = append(missing, r.Key)
+				}
+				for k, v := range vals {
+					if r.pattern != nil && !r.pattern.MatchString(v) {
+						mismatched = append(mismatched, k)
+					}
+				}
+				continue
+			}
+			val, ok := o.GetLabels()[r.Key]
+			if !ok && r.fallback != nil {
+				d, err := renderLabelDefault(cr, r, defaults)
+				if err != nil {
+					return nil, err
+				}
+				meta.AddLabels(o, map[string]string{r.Key: d})
+				val, ok = d, true
+			}
+			if !ok {
+				missing = append(missing, r.Key)
+				continue
+			}
+			if r.pattern != nil && !r.pattern.MatchString(val) {
+				mismatched = append(mismatched, r.Key)
+			}
+		}
+		ref := resource.ReferenceTo(o)
+		if len(missing) > 0 {
+			return nil, &resource.PatchError{Patcher: "LabelSchema", Target: &ref, Err: errors.Errorf("%s: %s", errMissingLabels, strings.Join(missing, ", "))}
+		}
+		if len(mismatched) > 0 {
+			sort.Strings(mismatched)
+			return nil, &resource.PatchError{Patcher: "LabelSchema", Target: &ref, Err: errors.Errorf("%s: %s", errLabelPattern, strings.Join(mismatched, ", "))}
+		}
+	}
+	return list, nil
+}
+
+// renderLabelDefault returns the rendered default of the given rule, rendering
+// it only once per parent resource.
+func renderLabelDefault(cr resource.ParentResource, r labelRule, rendered map[string]string) (string, error) {
+	if v, ok := rendered[r.Key]; ok {
+		return v, nil
+	}
+	b := &bytes.Buffer{}
+	if err := r.fallback.Execute(b, cr.UnstructuredContent()); err != nil {
+		return "", errors.Wrapf(err, "%s %s", errRenderLabelDefault, r.Key)
+	}
+	v := b.String()
+	if problems := validation.IsValidLabelValue(v); len(problems) > 0 {
+		return "", errors.Errorf("%s %s: %s", errInvalidLabelDefault, r.Key, strings.Join(problems, "; "))
+	}
+	rendered[r.Key] = v
+	return v, nil
+}
+
+func prefixedValues(labels map[string]string, prefix string) map[string]string {
+	result := map[string]string{}
+	for k, v := range labels {
+		if strings.HasPrefix(k, prefix) {
+			result[k] = v
+		}
+	}
+	return result
+}
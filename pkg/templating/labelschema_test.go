@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ ChildResourcePatcher = &LabelSchema{}
+
+func TestLabelSchema(t *testing.T) {
+	parent := fake.NewMockResource(fake.WithNamespaceName("cool", "apps"))
+	parent.Object["spec"] = map[string]interface{}{"owner": "payments"}
+	child := func(labels map[string]string) *fake.MockResource {
+		return fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("db", "apps"), fake.WithAdditionalLabels(labels))
+	}
+	ref := resource.ReferenceTo(child(nil))
+
+	type want struct {
+		result []resource.ChildResource
+		err    error
+	}
+	cases := map[string]struct {
+		reason string
+		rules  []LabelSchemaRule
+		list   []resource.ChildResource
+		want
+	}{
+		"Satisfied": {
+			reason: "Child resources that have all required labels should be left untouched",
+			rules:  []LabelSchemaRule{{Key: "team"}, {Key: "app.kubernetes.io/*"}},
+			list:   []resource.ChildResource{child(map[string]string{"team": "payments", "app.kubernetes.io/name": "db"})},
+			want:   want{result: []resource.ChildResource{child(map[string]string{"team": "payments", "app.kubernetes.io/name": "db"})}},
+		},
+		"Default": {
+			reason: "The labels that child resources omit should be filled in from their defaults",
+			rules:  []LabelSchemaRule{{Key: "team", Default: "{{ .spec.owner }}"}, {Key: "app.kubernetes.io/instance", Default: "{{ .metadata.name }}"}},
+			list:   []resource.ChildResource{child(map[string]string{"team": "billing"})},
+			want:   want{result: []resource.ChildResource{child(map[string]string{"team": "billing", "app.kubernetes.io/instance": "cool"})}},
+		},
+		"Missing": {
+			reason: "Child resources that omit labels without defaults should fail with the names of the labels",
+			rules:  []LabelSchemaRule{{Key: "team"}, {Key: "app.kubernetes.io/*"}},
+			list:   []resource.ChildResource{child(nil)},
+			want: want{err: &resource.PatchError{Patcher: "LabelSchema", Target: &ref,
+				Err: errors.Errorf("%s: %s", errMissingLabels, "team, app.kubernetes.io/*")}},
+		},
+		"Pattern": {
+			reason: "Child resources whose labels do not match their patterns should fail",
+			rules:  []LabelSchemaRule{{Key: "team", Pattern: "[a-z]+"}},
+			list:   []resource.ChildResource{child(map[string]string{"team": "Payments"})},
+			want: want{err: &resource.PatchError{Patcher: "LabelSchema", Target: &ref,
+				Err: errors.Errorf("%s: %s", errLabelPattern, "team")}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, err := NewLabelSchema(tc.rules...)
+			if err != nil {
+				t.Fatalf("\nReason: %s\nNewLabelSchema(...): %s", tc.reason, err)
+			}
+			got, err := s.Patch(parent, tc.list)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nPatch(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.result, got); diff != "" {
+				t.Errorf("\nReason: %s\nPatch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestNewLabelSchema(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		rule   LabelSchemaRule
+	}{
+		"WildcardDefault": {
+			reason: "A wildcard key cannot have a default",
+			rule:   LabelSchemaRule{Key: "app.kubernetes.io/*", Default: "x"},
+		},
+		"InvalidKey": {
+			reason: "A key that is not a valid label key should be rejected",
+			rule:   LabelSchemaRule{Key: "team name"},
+		},
+		"InvalidPattern": {
+			reason: "A pattern that is not a valid regular expression should be rejected",
+			rule:   LabelSchemaRule{Key: "team", Pattern: "("},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewLabelSchema(tc.rule); err == nil {
+				t.Errorf("\nReason: %s\nNewLabelSchema(...): want error", tc.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const errChildLifecycleHook = "cannot run lifecycle hook of child resource"
+
+// childChanged runs the lifecycle hooks of the reconciler, if there are any,
+// for the given change of the given child resource.
+func (r *Reconciler) childChanged(ctx context.Context, cr resource.ParentResource, o resource.ChildResource, e ChildEvent) {
+	if len(r.lifecycle) == 0 {
+		return
+	}
+	if err := r.lifecycle.Run(ctx, cr, o, e); err != nil {
+		r.log.Info(errChildLifecycleHook, "error", err, "event", e, "name", o.GetName(), "namespace", o.GetNamespace())
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	rresource "github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ ChildLifecycleHook = ChildLifecycleHookChain{}
+
+func TestChildLifecycleHooks(t *testing.T) {
+	cases := map[string]struct {
+		reason  string
+		kube    *test.MockClient
+		want    []ChildEvent
+		wantRes resource.ApplyResult
+	}{
+		"Created": {
+			reason: "The hooks should be called for the child resources that are created",
+			kube: &test.MockClient{
+				MockGet:    test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+				MockCreate: test.NewMockCreateFn(nil),
+			},
+			want:    []ChildEvent{ChildCreated},
+			wantRes: resource.ApplyCreated,
+		},
+		"Updated": {
+			reason: "The hooks should be called for the child resources that are changed",
+			kube: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj runtime.Object) error {
+					obj.(metav1.Object).SetResourceVersion("1")
+					return nil
+				}),
+				MockPatch: func(_ context.Context, obj runtime.Object, _ client.Patch, _ ...client.PatchOption) error {
+					obj.(metav1.Object).SetResourceVersion("2")
+					return nil
+				},
+			},
+			want:    []ChildEvent{ChildUpdated},
+			wantRes: resource.ApplyUpdated,
+		},
+		"Unchanged": {
+			reason: "The hooks should not be called for the child resources that the apply did not change",
+			kube: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj runtime.Object) error {
+					obj.(metav1.Object).SetResourceVersion("1")
+					return nil
+				}),
+				MockPatch: test.NewMockPatchFn(nil),
+			},
+			wantRes: resource.ApplyUnchanged,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var got []ChildEvent
+			r := &Reconciler{
+				client: rresource.ClientApplicator{Client: tc.kube, Applicator: rresource.NewAPIPatchingApplicator(tc.kube)},
+				log:    logging.NewNopLogger(),
+				lifecycle: ChildLifecycleHookChain{ChildLifecycleHookFunc(func(_ context.Context, _ resource.ParentResource, _ resource.ChildResource, e ChildEvent) error {
+					got = append(got, e)
+					return errBoom
+				})},
+			}
+			child := fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("db", namespace))
+			res, err := r.applyChild(context.Background(), fake.NewMockResource(), child)
+			if err != nil {
+				t.Fatalf("\nReason: %s\napplyChild(...): errors of the hooks should not fail the apply: %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.wantRes, res); diff != "" {
+				t.Errorf("\nReason: %s\napplyChild(...): -want result, +got result:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\nReason: %s\napplyChild(...): -want events, +got events:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
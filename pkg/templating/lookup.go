@@ -0,0 +1,128 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// LookupValuesKey is the key under spec.parameters of the render input that
+// the looked up objects are exposed with.
+const LookupValuesKey = "lookup"
+
+const (
+	errParseLookup  = "cannot parse lookup, expected Kind.group:namespace/name or Kind.group:name"
+	errLookupSecret = "secrets cannot be looked up"
+	errLookup       = "cannot look up object"
+)
+
+// A Lookup is an object of the cluster that the templates and patchers may
+// read at render time.
+type Lookup struct {
+	// Key is the key under the lookup parameter that the object is exposed
+	// with.
+	Key string
+
+	// GroupVersionKind is the kind of the object.
+	GroupVersionKind schema.GroupVersionKind
+
+	// Namespace and Name identify the object. The namespace of
+	// cluster-scoped objects is empty.
+	Namespace string
+	Name      string
+}
+
+// ParseLookup returns the Lookup exposed with the given key of the object given
+// as Kind.group:namespace/name, or as Kind.group:name if it is cluster-scoped.
+// The version of its kind is left empty to be resolved, e.g. with a RESTMapper.
+// Secrets cannot be looked up since their data would end up in the render
+// input, and with it in the render cache, the audit records and the like.
+func ParseLookup(key, val string) (Lookup, error) {
+	parts := strings.SplitN(val, ":", 2)
+	if key == "" || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Lookup{}, errors.Errorf("%s: %s=%s", errParseLookup, key, val)
+	}
+	gk := schema.ParseGroupKind(parts[0])
+	if gk == (schema.GroupKind{Kind: "Secret"}) {
+		return Lookup{}, errors.Errorf("%s: %s=%s", errLookupSecret, key, val)
+	}
+	l := Lookup{Key: key, GroupVersionKind: gk.WithVersion(""), Name: parts[1]}
+	if ns := strings.SplitN(parts[1], "/", 2); len(ns) == 2 {
+		if ns[0] == "" || ns[1] == "" {
+			return Lookup{}, errors.Errorf("%s: %s=%s", errParseLookup, key, val)
+		}
+		l.Namespace, l.Name = ns[0], ns[1]
+	}
+	return l, nil
+}
+
+// WithLookups returns a ReconcilerOption that exposes the given objects of the
+// cluster to the templates and patchers under spec.parameters.lookup, like the
+// lookup function of Helm but limited to the given objects. Since they are
+// part of the render input, a change of the objects changes the key of the
+// render in the RenderCache, and is picked up by the next sync.
+func WithLookups(l ...Lookup) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		WithValuesProvider(NewLookupResolver(reconciler.client, l...))(reconciler)
+	}
+}
+
+// NewLookupResolver returns a new *LookupResolver that reads the given objects.
+func NewLookupResolver(c client.Reader, l ...Lookup) *LookupResolver {
+	return &LookupResolver{kube: c, lookups: l}
+}
+
+// LookupResolver is a ValuesProvider that exposes an allowlist of objects of
+// the cluster under the lookup parameter, keyed by the key of their Lookup.
+// The objects that do not exist are left out, so that the templates can fall
+// back to defaults. Their managed fields are dropped.
+type LookupResolver struct {
+	kube    client.Reader
+	lookups []Lookup
+}
+
+// Values returns the looked up objects.
+func (r *LookupResolver) Values(ctx context.Context, _ resource.ParentResource) (map[string]interface{}, error) {
+	objs := map[string]interface{}{}
+	for _, l := range r.lookups {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(l.GroupVersionKind)
+		err := r.kube.Get(ctx, types.NamespacedName{Namespace: l.Namespace, Name: l.Name}, u)
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s %s", errLookup, l.Key)
+		}
+		unstructured.RemoveNestedField(u.Object, "metadata", "managedFields")
+		objs[l.Key] = u.Object
+	}
+	if len(objs) == 0 {
+		return nil, nil
+	}
+	return map[string]interface{}{LookupValuesKey: objs}, nil
+}
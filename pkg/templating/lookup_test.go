@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ ValuesProvider = &LookupResolver{}
+)
+
+func TestParseLookup(t *testing.T) {
+	type want struct {
+		lookup Lookup
+		err    error
+	}
+	cases := map[string]struct {
+		reason string
+		val    string
+		want   want
+	}{
+		"Namespaced": {
+			reason: "A namespaced object should be parsed",
+			val:    "ConfigMap:kube-system/cluster-dns",
+			want: want{lookup: Lookup{
+				Key:              "dns",
+				GroupVersionKind: schema.GroupVersionKind{Kind: "ConfigMap"},
+				Namespace:        "kube-system",
+				Name:             "cluster-dns",
+			}},
+		},
+		"ClusterScoped": {
+			reason: "A cluster-scoped object should be parsed",
+			val:    "ClusterIssuer.cert-manager.io:letsencrypt",
+			want: want{lookup: Lookup{
+				Key:              "dns",
+				GroupVersionKind: schema.GroupVersionKind{Group: "cert-manager.io", Kind: "ClusterIssuer"},
+				Name:             "letsencrypt",
+			}},
+		},
+		"Secret": {
+			reason: "Secrets should not be looked up",
+			val:    "Secret:kube-system/token",
+			want:   want{err: errors.Errorf("%s: %s", errLookupSecret, "dns=Secret:kube-system/token")},
+		},
+		"Malformed": {
+			reason: "An object without a name should fail",
+			val:    "ConfigMap:kube-system/",
+			want:   want{err: errors.Errorf("%s: %s", errParseLookup, "dns=ConfigMap:kube-system/")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			l, err := ParseLookup("dns", tc.val)
+			if diff := cmp.Diff(tc.want, want{lookup: l, err: err}, test.EquateErrors(), cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\nReason: %s\nParseLookup(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestLookupResolver(t *testing.T) {
+	lookup := Lookup{Key: "dns", GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, Namespace: "kube-system", Name: "cluster-dns"}
+	type want struct {
+		vals map[string]interface{}
+		err  error
+	}
+	cases := map[string]struct {
+		reason string
+		get    test.MockGetFn
+		want   want
+	}{
+		"Found": {
+			reason: "The looked up object should be exposed without its managed fields",
+			get: test.NewMockGetFn(nil, func(obj runtime.Object) error {
+				u := obj.(*unstructured.Unstructured)
+				u.SetName("cluster-dns")
+				_ = unstructured.SetNestedField(u.Object, []interface{}{}, "metadata", "managedFields")
+				_ = unstructured.SetNestedField(u.Object, "cool.example.org", "data", "domain")
+				return nil
+			}),
+			want: want{vals: map[string]interface{}{LookupValuesKey: map[string]interface{}{
+				"dns": map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata":   map[string]interface{}{"name": "cluster-dns"},
+					"data":       map[string]interface{}{"domain": "cool.example.org"},
+				},
+			}}},
+		},
+		"NotFound": {
+			reason: "Objects that do not exist should be left out",
+			get:    test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+		},
+		"GetFailed": {
+			reason: "Errors other than not found should be returned",
+			get:    test.NewMockGetFn(errBoom),
+			want:   want{err: errors.Wrapf(errBoom, "%s %s", errLookup, "dns")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewLookupResolver(&test.MockClient{MockGet: tc.get}, lookup)
+			vals, err := r.Values(context.Background(), fake.NewMockResource())
+			if diff := cmp.Diff(tc.want, want{vals: vals, err: err}, test.EquateErrors(), cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\nReason: %s\nValues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
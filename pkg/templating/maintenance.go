@@ -0,0 +1,255 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errParseMaintenanceWindow = "cannot parse maintenance window"
+	errMaintenanceWindow      = "cannot check maintenance windows of parent resource"
+
+	msgMaintenanceWindow = "child resources are not changed until the maintenance window ends at "
+)
+
+// ReasonMaintenanceWindow is the reason of the Synced condition of a parent
+// resource whose child resources are not changed since it is in a
+// maintenance window.
+const ReasonMaintenanceWindow v1alpha1.ConditionReason = "InMaintenanceWindow"
+
+// InMaintenanceWindow returns a condition that indicates the child resources
+// of the parent resource are not changed until the given time since it is in
+// a maintenance window.
+func InMaintenanceWindow(end time.Time) v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:               v1alpha1.TypeSynced,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonMaintenanceWindow,
+		Message:            msgMaintenanceWindow + end.UTC().Format(time.RFC3339),
+	}
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// MaintenanceWindow is a recurring period of time, like a change freeze,
+// during which the existing child resources are not changed.
+type MaintenanceWindow struct {
+	days     map[time.Weekday]bool
+	start    time.Duration
+	end      time.Duration
+	location *time.Location
+}
+
+// ParseMaintenanceWindow parses a maintenance window that is given as the days
+// of the week, the time of the day it starts and ends at and optionally the
+// time zone, e.g. "Mon-Fri 18:00-08:00 Europe/Berlin" or "* 00:00-06:00".
+// The days are either * for every day or a comma separated list of days and
+// ranges of days, like Mon-Wed,Fri. A window that ends before it starts ends
+// on the following day. The time zone is UTC if it is not given.
+func ParseMaintenanceWindow(s string) (MaintenanceWindow, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 && len(fields) != 3 {
+		return MaintenanceWindow{}, errors.Errorf("%s: %s", errParseMaintenanceWindow, s)
+	}
+	w := MaintenanceWindow{days: map[time.Weekday]bool{}, location: time.UTC}
+	if err := w.parseDays(fields[0]); err != nil {
+		return MaintenanceWindow{}, errors.Wrap(err, errParseMaintenanceWindow)
+	}
+	times := strings.Split(fields[1], "-")
+	if len(times) != 2 {
+		return MaintenanceWindow{}, errors.Errorf("%s: %s", errParseMaintenanceWindow, s)
+	}
+	var err error
+	if w.start, err = parseTimeOfDay(times[0]); err != nil {
+		return MaintenanceWindow{}, errors.Wrap(err, errParseMaintenanceWindow)
+	}
+	if w.end, err = parseTimeOfDay(times[1]); err != nil {
+		return MaintenanceWindow{}, errors.Wrap(err, errParseMaintenanceWindow)
+	}
+	if len(fields) == 3 {
+		if w.location, err = time.LoadLocation(fields[2]); err != nil {
+			return MaintenanceWindow{}, errors.Wrap(err, errParseMaintenanceWindow)
+		}
+	}
+	return w, nil
+}
+
+func (w *MaintenanceWindow) parseDays(s string) error {
+	if s == "*" {
+		for _, d := range weekdays {
+			w.days[d] = true
+		}
+		return nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, ok := weekdays[strings.ToLower(bounds[0])]
+		if !ok {
+			return errors.Errorf("unknown day %s", bounds[0])
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = weekdays[strings.ToLower(bounds[1])]; !ok {
+				return errors.Errorf("unknown day %s", bounds[1])
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			w.days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ActiveUntil returns when the occurrence of the window that the given time is
+// in ends, and false if the given time is not in the window.
+func (w MaintenanceWindow) ActiveUntil(t time.Time) (time.Time, bool) {
+	t = t.In(w.location)
+	today := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, w.location)
+	// An occurrence that starts on the previous day may not have ended yet.
+	for _, day := range []time.Time{today, today.AddDate(0, 0, -1)} {
+		if !w.days[day.Weekday()] {
+			continue
+		}
+		start, end := day.Add(w.start), day.Add(w.end)
+		if w.end <= w.start {
+			end = end.AddDate(0, 0, 1)
+		}
+		if !t.Before(start) && t.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// MaintenancePolicy decides what the reconciler may still do with the child
+// resources of a parent resource that is in a maintenance window. The
+// existing child resources are never updated.
+type MaintenancePolicy struct {
+	// AllowCreate lets the child resources that do not exist yet be created.
+	AllowCreate bool
+
+	// AllowDelete lets the child resources of a deleted parent resource be
+	// deleted.
+	AllowDelete bool
+}
+
+// maintenanceSchedule is the set of maintenance windows that apply to all
+// parent resources, and the policy to follow during them.
+type maintenanceSchedule struct {
+	windows []MaintenanceWindow
+	policy  MaintenancePolicy
+}
+
+// activeUntil returns when the maintenance window, among the ones of the
+// schedule and the ones that the given parent resource declares with
+// MaintenanceWindowAnnotationKey, that the given time is in ends, and false if
+// the given time is not in one. The windows in the annotation are separated
+// by semicolons.
+func (m maintenanceSchedule) activeUntil(cr resource.ParentResource, t time.Time) (time.Time, bool, error) {
+	windows := m.windows
+	if val := cr.GetAnnotations()[MaintenanceWindowAnnotationKey]; val != "" {
+		for _, s := range strings.Split(val, ";") {
+			w, err := ParseMaintenanceWindow(s)
+			if err != nil {
+				return time.Time{}, false, err
+			}
+			windows = append(windows, w)
+		}
+	}
+	var end time.Time
+	for _, w := range windows {
+		if e, ok := w.ActiveUntil(t); ok && e.After(end) {
+			end = e
+		}
+	}
+	return end, !end.IsZero(), nil
+}
+
+// applyInMaintenance creates the child resources that do not exist yet, if the
+// maintenance policy allows, and leaves the existing ones untouched until the
+// maintenance window ends at the given time.
+func (r *Reconciler) applyInMaintenance(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource, end time.Time) (ctrl.Result, error) {
+	log := r.log.WithValues("parent-resource", cr.GetName())
+	for _, o := range list {
+		if !r.maintenance.policy.AllowCreate {
+			break
+		}
+		if IsOneShot(o) {
+			// The one-shot child resources that completed may have been
+			// cleaned up, so they wait for the maintenance window to end.
+			continue
+		}
+		exists, err := r.exists(ctx, o)
+		if err == nil && !exists {
+			_, err = r.applyChild(ctx, cr, o)
+		}
+		if err != nil {
+			log.Info(errApply, "error", err)
+			omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errApply))))
+			return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+		}
+	}
+	log.Debug("Child resources are not changed during maintenance window", "until", end)
+	omitError(log, r.setConditions(cr, InMaintenanceWindow(end)))
+	return ctrl.Result{RequeueAfter: time.Until(end) + tinyWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+}
+
+// exists returns whether the given child resource exists. The child resources
+// that use generateName exist only if their name is resolved.
+func (r *Reconciler) exists(ctx context.Context, o resource.ChildResource) (bool, error) {
+	if usesGenerateName(o) {
+		return false, nil
+	}
+	current, ok := o.DeepCopyObject().(resource.ChildResource)
+	if !ok {
+		return true, nil
+	}
+	err := r.client.Get(ctx, types.NamespacedName{Name: o.GetName(), Namespace: o.GetNamespace()}, current)
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, errors.Wrap(err, errGetChildResource)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestMaintenanceWindow(t *testing.T) {
+	// 2020-06-05 is a Friday.
+	at := func(day int, hour int) time.Time { return time.Date(2020, 6, day, hour, 30, 0, 0, time.UTC) }
+	type want struct {
+		end    time.Time
+		active bool
+	}
+	cases := map[string]struct {
+		reason string
+		window string
+		t      time.Time
+		want   want
+	}{
+		"Inside": {
+			reason: "A time inside the window should be active until the end of the window",
+			window: "Mon-Fri 09:00-17:00",
+			t:      at(5, 10),
+			want:   want{end: time.Date(2020, 6, 5, 17, 0, 0, 0, time.UTC), active: true},
+		},
+		"OtherDay": {
+			reason: "A time on a day that is not in the window should not be active",
+			window: "Mon-Fri 09:00-17:00",
+			t:      at(6, 10),
+		},
+		"Overnight": {
+			reason: "A window that ends before it starts should end on the following day",
+			window: "Fri 22:00-06:00",
+			t:      at(6, 2),
+			want:   want{end: time.Date(2020, 6, 6, 6, 0, 0, 0, time.UTC), active: true},
+		},
+		"EveryDay": {
+			reason: "A window on every day should be active on any day",
+			window: "* 00:00-01:00",
+			t:      at(7, 0),
+			want:   want{end: time.Date(2020, 6, 7, 1, 0, 0, 0, time.UTC), active: true},
+		},
+		"WrappingDays": {
+			reason: "A range of days may wrap around the end of the week",
+			window: "Sat-Mon 09:00-17:00",
+			t:      at(7, 10),
+			want:   want{end: time.Date(2020, 6, 7, 17, 0, 0, 0, time.UTC), active: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			w, err := ParseMaintenanceWindow(tc.window)
+			if err != nil {
+				t.Fatalf("\nReason: %s\nParseMaintenanceWindow(...): %s", tc.reason, err)
+			}
+			end, active := w.ActiveUntil(tc.t)
+			if diff := cmp.Diff(tc.want, want{end: end, active: active}, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\nReason: %s\nActiveUntil(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestParseMaintenanceWindowInvalid(t *testing.T) {
+	for _, s := range []string{"Mon", "Someday 09:00-17:00", "Mon 09:00", "Mon 9am-5pm", "Mon 09:00-17:00 Nowhere/City"} {
+		if _, err := ParseMaintenanceWindow(s); err == nil {
+			t.Errorf("ParseMaintenanceWindow(%q): expected an error", s)
+		}
+	}
+}
+
+func TestMaintenanceScheduleAnnotation(t *testing.T) {
+	now := time.Date(2020, 6, 5, 10, 0, 0, 0, time.UTC)
+	cr := fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{MaintenanceWindowAnnotationKey: "Sat 00:00-06:00; Fri 09:00-12:00"}))
+	end, active, err := maintenanceSchedule{}.activeUntil(cr, now)
+	if err != nil {
+		t.Fatalf("activeUntil(...): %s", err)
+	}
+	if !active || !end.Equal(time.Date(2020, 6, 5, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("activeUntil(...): want active until 12:00, got %t until %s", active, end)
+	}
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// OtherParentsLabelValue is the name label of the render metrics that sum up
+// the parent resources that are over the limit of WithParentLabels. It cannot
+// collide with the name of a parent resource.
+const OtherParentsLabelValue = "_other"
+
+// parentGVKLabels are the labels that tell the metrics of the controllers of
+// different packs apart.
+func parentGVKLabels(gvk schema.GroupVersionKind) prometheus.Labels {
+	return prometheus.Labels{"group": gvk.Group, "version": gvk.Version, "kind": gvk.Kind}
+}
+
+type sourceFreshness struct {
+	revision    string
+	since       time.Time
+	lastSuccess time.Time
+}
+
+// NewSourceMetrics returns a new *SourceMetrics whose metrics are labeled
+// with the given GroupVersionKind of the parent resources.
+func NewSourceMetrics(gvk schema.GroupVersionKind) *SourceMetrics {
+	l := parentGVKLabels(gvk)
+	return &SourceMetrics{
+		now:     time.Now,
+		sources: map[string]sourceFreshness{},
+		revisionAge: prometheus.NewDesc(
+			"templating_controller_source_revision_age_seconds",
+			"Seconds since the revision of the templates that is in use was fetched from its source.",
+			[]string{"source", "revision"}, l,
+		),
+		lastSuccess: prometheus.NewDesc(
+			"templating_controller_source_last_successful_fetch_timestamp_seconds",
+			"Unix time of the last successful fetch of the templates from their source.",
+			[]string{"source"}, l,
+		),
+	}
+}
+
+// SourceMetrics is a SourceStatusWriter and a prometheus.Collector that
+// exports how fresh the templates of every source are, so that operators can
+// alert when the packs keep rendering stale templates after failed fetches.
+// The age of the revision in use grows until a fetch brings a new revision.
+type SourceMetrics struct {
+	now         func() time.Time
+	revisionAge *prometheus.Desc
+	lastSuccess *prometheus.Desc
+
+	mu      sync.Mutex
+	sources map[string]sourceFreshness
+}
+
+// Write records the given SourceStatus. A failed fetch keeps the revision in
+// use and the time of the last successful fetch.
+func (m *SourceMetrics) Write(_ context.Context, s SourceStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f := m.sources[s.Source]
+	if s.LastError == nil {
+		f.lastSuccess = s.LastFetchTime
+	}
+	if s.Revision != "" && s.Revision != f.revision {
+		f.revision = s.Revision
+		f.since = s.LastFetchTime
+	}
+	m.sources[s.Source] = f
+	return nil
+}
+
+// Describe sends the descriptors of the metrics.
+func (m *SourceMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.revisionAge
+	ch <- m.lastSuccess
+}
+
+// Collect sends the current values of the metrics.
+func (m *SourceMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for source, f := range m.sources {
+		if f.revision != "" {
+			ch <- prometheus.MustNewConstMetric(m.revisionAge, prometheus.GaugeValue, now.Sub(f.since).Seconds(), source, f.revision)
+		}
+		if !f.lastSuccess.IsZero() {
+			ch <- prometheus.MustNewConstMetric(m.lastSuccess, prometheus.GaugeValue, float64(f.lastSuccess.Unix()), source)
+		}
+	}
+}
+
+type renderSize struct {
+	objects      int
+	bytes        int
+	objectsDelta int
+	bytesDelta   int
+}
+
+func (s renderSize) add(o renderSize) renderSize {
+	return renderSize{
+		objects:      s.objects + o.objects,
+		bytes:        s.bytes + o.bytes,
+		objectsDelta: s.objectsDelta + o.objectsDelta,
+		bytesDelta:   s.bytesDelta + o.bytesDelta,
+	}
+}
+
+// RenderMetricsOption configures a *RenderMetrics.
+type RenderMetricsOption func(*RenderMetrics)
+
+// WithParentLabels makes the RenderMetrics export the metrics of every parent
+// resource with its namespace and name as labels, for up to the given number
+// of parent resources. The parent resources that are observed once the limit
+// is reached are summed up in the metrics whose name label is
+// OtherParentsLabelValue, so that the number of series stays bounded however
+// many parent resources there are. A parent resource that is forgotten frees
+// its place for the next one.
+func WithParentLabels(limit int) RenderMetricsOption {
+	return func(m *RenderMetrics) {
+		m.parentLabels = true
+		m.limit = limit
+	}
+}
+
+// NewRenderMetrics returns a new *RenderMetrics whose metrics are labeled with
+// the given GroupVersionKind of the parent resources.
+func NewRenderMetrics(gvk schema.GroupVersionKind, o ...RenderMetricsOption) *RenderMetrics {
+	m := &RenderMetrics{
+		parents: map[types.NamespacedName]renderSize{},
+		labeled: map[types.NamespacedName]bool{},
+	}
+	for _, f := range o {
+		f(m)
+	}
+	var variable []string
+	if m.parentLabels {
+		variable = []string{"namespace", "name"}
+	}
+	l := parentGVKLabels(gvk)
+	m.objects = prometheus.NewDesc(
+		"templating_controller_rendered_objects",
+		"Number of child resources rendered for the parent resources in their last reconcile.",
+		variable, l,
+	)
+	m.bytes = prometheus.NewDesc(
+		"templating_controller_rendered_bytes",
+		"Total size in bytes of the JSON of the child resources rendered for the parent resources in their last reconcile.",
+		variable, l,
+	)
+	m.objectsDelta = prometheus.NewDesc(
+		"templating_controller_rendered_objects_delta",
+		"Change in the number of rendered child resources between the last two reconciles of the parent resources.",
+		variable, l,
+	)
+	m.bytesDelta = prometheus.NewDesc(
+		"templating_controller_rendered_bytes_delta",
+		"Change in the total size in bytes of the rendered child resources between the last two reconciles of the parent resources.",
+		variable, l,
+	)
+	return m
+}
+
+// RenderMetrics is a prometheus.Collector that exports the number and the
+// total size of the child resources rendered for the parent resources, and
+// how much they changed since the previous reconcile, so that operators can
+// spot the packs that grow unexpectedly or render differently every time.
+// The metrics are summed up over all parent resources unless WithParentLabels
+// is used.
+type RenderMetrics struct {
+	parentLabels bool
+	limit        int
+
+	objects      *prometheus.Desc
+	bytes        *prometheus.Desc
+	objectsDelta *prometheus.Desc
+	bytesDelta   *prometheus.Desc
+
+	mu      sync.Mutex
+	parents map[types.NamespacedName]renderSize
+	labeled map[types.NamespacedName]bool
+}
+
+// Observe records the child resources rendered for the given parent resource.
+// The deltas are zero for the first render of a parent resource.
+func (m *RenderMetrics) Observe(cr resource.ParentResource, list []resource.ChildResource) {
+	size := renderSize{objects: len(list)}
+	for _, o := range list {
+		// The size is only an indication, so the objects that cannot be
+		// serialized are counted without their bytes.
+		if b, err := json.Marshal(o); err == nil {
+			size.bytes += len(b)
+		}
+	}
+	key := types.NamespacedName{Namespace: cr.GetNamespace(), Name: cr.GetName()}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if prev, ok := m.parents[key]; ok {
+		size.objectsDelta = size.objects - prev.objects
+		size.bytesDelta = size.bytes - prev.bytes
+	}
+	m.parents[key] = size
+	if m.parentLabels && !m.labeled[key] && len(m.labeled) < m.limit {
+		m.labeled[key] = true
+	}
+}
+
+// Forget stops exporting the metrics of the given parent resource.
+func (m *RenderMetrics) Forget(cr resource.ParentResource) {
+	key := types.NamespacedName{Namespace: cr.GetNamespace(), Name: cr.GetName()}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.parents, key)
+	delete(m.labeled, key)
+}
+
+// Describe sends the descriptors of the metrics.
+func (m *RenderMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.objects
+	ch <- m.bytes
+	ch <- m.objectsDelta
+	ch <- m.bytesDelta
+}
+
+// Collect sends the current values of the metrics.
+func (m *RenderMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.parentLabels {
+		var sum renderSize
+		for _, s := range m.parents {
+			sum = sum.add(s)
+		}
+		m.collect(ch, sum)
+		return
+	}
+	var other renderSize
+	others := false
+	for key, s := range m.parents {
+		if !m.labeled[key] {
+			other = other.add(s)
+			others = true
+			continue
+		}
+		m.collect(ch, s, key.Namespace, key.Name)
+	}
+	if others {
+		m.collect(ch, other, "", OtherParentsLabelValue)
+	}
+}
+
+func (m *RenderMetrics) collect(ch chan<- prometheus.Metric, s renderSize, labels ...string) {
+	ch <- prometheus.MustNewConstMetric(m.objects, prometheus.GaugeValue, float64(s.objects), labels...)
+	ch <- prometheus.MustNewConstMetric(m.bytes, prometheus.GaugeValue, float64(s.bytes), labels...)
+	ch <- prometheus.MustNewConstMetric(m.objectsDelta, prometheus.GaugeValue, float64(s.objectsDelta), labels...)
+	ch <- prometheus.MustNewConstMetric(m.bytesDelta, prometheus.GaugeValue, float64(s.bytesDelta), labels...)
+}
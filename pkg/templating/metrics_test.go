@@ -0,0 +1,133 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ SourceStatusWriter   = &SourceMetrics{}
+	_ prometheus.Collector = &SourceMetrics{}
+	_ prometheus.Collector = &RenderMetrics{}
+)
+
+func TestSourceMetrics(t *testing.T) {
+	fetched := time.Unix(1591000000, 0)
+	m := NewSourceMetrics(fake.MockParentGVK)
+	m.now = func() time.Time { return fetched.Add(time.Hour) }
+	_ = m.Write(context.TODO(), SourceStatus{Source: "/resources", Revision: "abc", LastFetchTime: fetched})
+	// A failed fetch should keep the revision in use and the last successful
+	// fetch time.
+	_ = m.Write(context.TODO(), SourceStatus{Source: "/resources", LastFetchTime: fetched.Add(30 * time.Minute), LastError: errBoom})
+
+	want := `
+# HELP templating_controller_source_last_successful_fetch_timestamp_seconds Unix time of the last successful fetch of the templates from their source.
+# TYPE templating_controller_source_last_successful_fetch_timestamp_seconds gauge
+templating_controller_source_last_successful_fetch_timestamp_seconds{group="mock.parent.crossplane.io",kind="MockResource",source="/resources",version="v1alpha1"} 1.591e+09
+# HELP templating_controller_source_revision_age_seconds Seconds since the revision of the templates that is in use was fetched from its source.
+# TYPE templating_controller_source_revision_age_seconds gauge
+templating_controller_source_revision_age_seconds{group="mock.parent.crossplane.io",kind="MockResource",revision="abc",source="/resources",version="v1alpha1"} 3600
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want)); err != nil {
+		t.Errorf("Collect(...): %s", err)
+	}
+}
+
+func TestRenderMetrics(t *testing.T) {
+	child := fake.NewMockResource(fake.WithNamespaceName("child", "apps"))
+	b, _ := json.Marshal(child)
+	size := len(b)
+	parent := func(name string) resource.ParentResource {
+		return fake.NewMockResource(fake.WithNamespaceName(name, "apps"))
+	}
+	observe := func(m *RenderMetrics) {
+		m.Observe(parent("cool"), []resource.ChildResource{child})
+		m.Observe(parent("cool"), []resource.ChildResource{child, child, child})
+		m.Observe(parent("gone"), []resource.ChildResource{child})
+		m.Forget(parent("gone"))
+		m.Observe(parent("other"), []resource.ChildResource{child, child})
+		m.Observe(parent("another"), []resource.ChildResource{child})
+	}
+	series := func(labels string, objects, bytes, objectsDelta, bytesDelta int) string {
+		return fmt.Sprintf(`templating_controller_rendered_bytes{%[1]s} %[3]d
+templating_controller_rendered_bytes_delta{%[1]s} %[5]d
+templating_controller_rendered_objects{%[1]s} %[2]d
+templating_controller_rendered_objects_delta{%[1]s} %[4]d
+`, labels, objects, bytes, objectsDelta, bytesDelta)
+	}
+
+	cases := map[string]struct {
+		reason string
+		o      []RenderMetricsOption
+		want   string
+	}{
+		"Summed": {
+			reason: "The metrics of all parent resources should be summed up without parent labels.",
+			want:   series(`GVK,version="v1alpha1"`, 6, 6*size, 2, 2*size),
+		},
+		"ParentLabels": {
+			reason: "Every parent resource should have its own series and a forgotten one should free its place.",
+			o:      []RenderMetricsOption{WithParentLabels(2)},
+			want: series(`GVK,name="cool",namespace="apps",version="v1alpha1"`, 3, 3*size, 2, 2*size) +
+				series(`GVK,name="other",namespace="apps",version="v1alpha1"`, 2, 2*size, 0, 0) +
+				series(`GVK,name="_other",namespace="",version="v1alpha1"`, 1, size, 0, 0),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := NewRenderMetrics(fake.MockParentGVK, tc.o...)
+			observe(m)
+			want := strings.ReplaceAll(tc.want, "GVK", `group="mock.parent.crossplane.io",kind="MockResource"`)
+			if err := testutil.CollectAndCompare(m, strings.NewReader(withRenderHelp(want))); err != nil {
+				t.Errorf("\n%s\nCollect(...): %s", tc.reason, err)
+			}
+		})
+	}
+}
+
+// withRenderHelp adds the HELP and TYPE lines of the render metrics to the
+// given series, in the order of the metric names.
+func withRenderHelp(series string) string {
+	help := map[string]string{
+		"templating_controller_rendered_bytes":         "Total size in bytes of the JSON of the child resources rendered for the parent resources in their last reconcile.",
+		"templating_controller_rendered_bytes_delta":   "Change in the total size in bytes of the rendered child resources between the last two reconciles of the parent resources.",
+		"templating_controller_rendered_objects":       "Number of child resources rendered for the parent resources in their last reconcile.",
+		"templating_controller_rendered_objects_delta": "Change in the number of rendered child resources between the last two reconciles of the parent resources.",
+	}
+	byName := map[string][]string{}
+	for _, l := range strings.Split(strings.TrimSpace(series), "\n") {
+		name := l[:strings.Index(l, "{")]
+		byName[name] = append(byName[name], l)
+	}
+	out := "\n"
+	for _, name := range []string{"templating_controller_rendered_bytes", "templating_controller_rendered_bytes_delta", "templating_controller_rendered_objects", "templating_controller_rendered_objects_delta"} {
+		out += fmt.Sprintf("# HELP %s %s\n# TYPE %s gauge\n%s\n", name, help[name], name, strings.Join(byName[name], "\n"))
+	}
+	return out
+}
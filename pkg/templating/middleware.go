@@ -0,0 +1,36 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// render runs the engine with the given render input within the Middleware,
+// expands the rendered lists and composites into the objects they wrap and
+// sorts the rendered child resources with resource.SortChildren.
+func (r *Reconciler) render(ctx context.Context, input resource.ParentResource) ([]resource.ChildResource, error) {
+	return r.pipeline().Render(ctx, input)
+}
+
+// patch runs the ChildResourcePatchers on the given child resources within the
+// Middleware.
+func (r *Reconciler) patch(ctx context.Context, input resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	return r.pipeline().Patch(ctx, input, list)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	runtimefake "github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ Middleware = MiddlewareFunc(nil)
+	_ Middleware = MiddlewareChain{}
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	var calls []string
+	named := func(name string) Middleware {
+		return MiddlewareFunc(func(s Stage, next StageFunc) StageFunc {
+			return func(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+				calls = append(calls, "pre-"+string(s)+"-"+name)
+				list, err := next(ctx, cr, list)
+				calls = append(calls, "post-"+string(s)+"-"+name)
+				return list, err
+			}
+		})
+	}
+	stage := func(_ context.Context, _ resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+		calls = append(calls, "stage")
+		return list, nil
+	}
+	if _, err := (MiddlewareChain{named("outer"), named("inner")}).Wrap(StageApply, stage)(context.Background(), fake.NewMockResource(), nil); err != nil {
+		t.Fatalf("Wrap(...): %s", err)
+	}
+	want := []string{"pre-Apply-outer", "pre-Apply-inner", "stage", "post-Apply-inner", "post-Apply-outer"}
+	if diff := cmp.Diff(want, calls); diff != "" {
+		t.Errorf("Wrap(...): -want calls, +got calls:\n%s", diff)
+	}
+}
+
+func TestRenderMiddleware(t *testing.T) {
+	type want struct {
+		children int
+		err      error
+	}
+	cases := map[string]struct {
+		reason     string
+		middleware Middleware
+		want       want
+	}{
+		"PostRender": {
+			reason: "A Middleware should be able to change the rendered child resources before they are patched",
+			middleware: MiddlewareFunc(func(s Stage, next StageFunc) StageFunc {
+				if s != StageRender {
+					return next
+				}
+				return func(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+					list, err := next(ctx, cr, list)
+					return append(list, fake.NewMockResource(fake.WithGVK(fake.MockChildGVK))), err
+				}
+			}),
+			want: want{children: 2},
+		},
+		"PrePatch": {
+			reason: "A Middleware should be able to fail a stage without running it",
+			middleware: MiddlewareFunc(func(s Stage, next StageFunc) StageFunc {
+				if s != StagePatch {
+					return next
+				}
+				return func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) ([]resource.ChildResource, error) {
+					return nil, errBoom
+				}
+			}),
+			want: want{err: errors.Wrap(errBoom, errChildResourcePatchers)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mgr := &runtimefake.Manager{
+				Client: &test.MockClient{},
+				Scheme: runtimefake.SchemeWith(&fake.MockResource{}),
+			}
+			r := NewReconciler(mgr, fake.MockParentGVK,
+				WithEngine(EngineFunc(func(_ resource.ParentResource) ([]resource.ChildResource, error) {
+					return []resource.ChildResource{fake.NewMockResource(fake.WithGVK(fake.MockChildGVK))}, nil
+				})),
+				WithChildResourcePatcher(),
+				WithMiddleware(tc.middleware),
+			)
+			list, err := r.Render(context.Background(), fake.NewMockResource(fake.WithGVK(fake.MockParentGVK)))
+			if diff := cmp.Diff(tc.want, want{children: len(list), err: err}, test.EquateErrors(), cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\nReason: %s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"strings"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// NewNameMigrator returns a new NameMigrator.
+func NewNameMigrator() NameMigrator {
+	return NameMigrator{}
+}
+
+// NameMigrator is a ChildResourcePatcher that lets a new version of a pack
+// rename its child resources without creating duplicates of the existing ones
+// and orphaning them. A child resource declares the names it had in the
+// previous versions of the pack as a comma separated list with
+// MigrateFromAnnotationKey. If the parent resource has a child resource of
+// the same kind and namespace with one of those names in its inventory, or in
+// its latest revision, and none with the new name, the rendered child
+// resource takes the previous name so that the existing child resource is
+// patched in place, including its new labels, instead. Since the inventory
+// keeps the previous name, it is kept in the following reconciles too.
+type NameMigrator struct{}
+
+// Patch patches the child resources with information in resource.ParentResource.
+func (m NameMigrator) Patch(cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	known := knownChildren(cr)
+	if len(known) == 0 {
+		return list, nil
+	}
+	for _, o := range list {
+		val := o.GetAnnotations()[MigrateFromAnnotationKey]
+		if val == "" || known[resource.ReferenceTo(o)] {
+			continue
+		}
+		for _, name := range strings.Split(val, ",") {
+			ref := resource.ReferenceTo(o)
+			ref.Name = strings.TrimSpace(name)
+			if ref.Name != "" && known[ref] {
+				o.SetName(ref.Name)
+				break
+			}
+		}
+	}
+	return list, nil
+}
+
+// knownChildren returns the references of the child resources that are
+// recorded in the inventory and the latest revision of the given parent
+// resource.
+func knownChildren(cr resource.ParentResource) map[resource.ChildReference]bool {
+	known := map[resource.ChildReference]bool{}
+	if statuses, err := resource.GetChildStatuses(cr); err == nil {
+		for _, s := range statuses {
+			known[s.ChildReference] = true
+		}
+	}
+	if revs, err := resource.GetRevisions(cr); err == nil && len(revs) > 0 {
+		for _, ref := range revs[len(revs)-1].Children {
+			known[ref] = true
+		}
+	}
+	return known
+}
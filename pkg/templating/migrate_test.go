@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ ChildResourcePatcher = NameMigrator{}
+
+func TestNameMigrator(t *testing.T) {
+	old := fake.NewMockResource(fake.WithNamespaceName("db", "default"), fake.WithGVK(fake.MockChildGVK))
+	cases := map[string]struct {
+		reason      string
+		name        string
+		annotations map[string]string
+		known       []resource.ChildReference
+		want        string
+	}{
+		"NotAnnotated": {
+			reason: "Child resources that do not declare previous names should keep their names",
+			name:   "database",
+			known:  []resource.ChildReference{resource.ReferenceTo(old)},
+			want:   "database",
+		},
+		"Migrated": {
+			reason:      "Child resources should take their previous name if the parent resource has a child resource with it",
+			name:        "database",
+			annotations: map[string]string{MigrateFromAnnotationKey: "database-v1, db"},
+			known:       []resource.ChildReference{resource.ReferenceTo(old)},
+			want:        "db",
+		},
+		"AlreadyNew": {
+			reason:      "Child resources should keep their new name if the parent resource has a child resource with it",
+			name:        "database",
+			annotations: map[string]string{MigrateFromAnnotationKey: "db"},
+			known: []resource.ChildReference{
+				resource.ReferenceTo(old),
+				resource.ReferenceTo(fake.NewMockResource(fake.WithNamespaceName("database", "default"), fake.WithGVK(fake.MockChildGVK))),
+			},
+			want: "database",
+		},
+		"Unknown": {
+			reason:      "Child resources should keep their new name if the parent resource has no child resource with a previous one",
+			name:        "database",
+			annotations: map[string]string{MigrateFromAnnotationKey: "database-v1"},
+			known:       []resource.ChildReference{resource.ReferenceTo(old)},
+			want:        "database",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cr := fake.NewMockResource()
+			statuses := make([]resource.ChildStatus, len(tc.known))
+			for i, ref := range tc.known {
+				statuses[i] = resource.ChildStatus{ChildReference: ref, LastOperation: resource.ApplyCreated}
+			}
+			if err := resource.SetChildStatuses(cr, statuses); err != nil {
+				t.Fatalf("SetChildStatuses(...): %s", err)
+			}
+			o := fake.NewMockResource(fake.WithNamespaceName(tc.name, "default"), fake.WithGVK(fake.MockChildGVK), fake.WithAdditionalAnnotations(tc.annotations))
+			if _, err := NewNameMigrator().Patch(cr, []resource.ChildResource{o}); err != nil {
+				t.Fatalf("\nReason: %s\nPatch(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, o.GetName()); diff != "" {
+				t.Errorf("\nReason: %s\nPatch(...): -want name, +got name:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
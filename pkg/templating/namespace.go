@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane/pkg/packages"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errGetNamespace    = "cannot get target namespace of child resource"
+	errCreateNamespace = "cannot create target namespace of child resource"
+)
+
+var namespaceGroupKind = schema.GroupKind{Kind: "Namespace"}
+
+// NewNamespaceCreator returns a new *NamespaceCreator.
+func NewNamespaceCreator(c client.Client) *NamespaceCreator {
+	return &NamespaceCreator{kube: c}
+}
+
+// NamespaceCreator is a pre-apply Hook that creates the namespaces that the
+// child resources target if they do not exist yet, e.g. the ones derived from
+// the name of the parent resource by tenant onboarding packs. The namespaces
+// are created in the cluster that the child resources target and labeled with
+// the parent labels so that they can be tracked back to the parent resource.
+// They are not deleted with the parent resource since they may hold more than
+// its child resources. The namespaces that are child resources themselves are
+// left to be applied.
+type NamespaceCreator struct {
+	kube client.Client
+}
+
+// Run creates the missing target namespaces of the given child resources.
+func (n *NamespaceCreator) Run(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) error {
+	rendered := map[string]bool{}
+	for _, o := range list {
+		if o.GetObjectKind().GroupVersionKind().GroupKind() == namespaceGroupKind {
+			rendered[o.GetName()] = true
+		}
+	}
+	seen := map[string]bool{}
+	for _, o := range list {
+		target := o.GetAnnotations()[TargetClusterAnnotationKey]
+		key := target + "/" + o.GetNamespace()
+		if o.GetNamespace() == "" || rendered[o.GetNamespace()] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: o.GetNamespace()}}
+		// The annotation routes the calls to the cluster of the child
+		// resource.
+		if target != "" {
+			ns.SetAnnotations(map[string]string{TargetClusterAnnotationKey: target})
+		}
+		err := n.kube.Get(ctx, types.NamespacedName{Name: ns.GetName()}, ns)
+		if err == nil {
+			continue
+		}
+		if !kerrors.IsNotFound(err) {
+			return errors.Wrap(err, errGetNamespace)
+		}
+		ns.SetLabels(packages.ParentLabels(cr))
+		if err := n.kube.Create(ctx, ns); err != nil && !kerrors.IsAlreadyExists(err) {
+			return errors.Wrap(err, errCreateNamespace)
+		}
+	}
+	return nil
+}
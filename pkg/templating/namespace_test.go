@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+	"github.com/crossplane/crossplane/pkg/packages"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ Hook = &NamespaceCreator{}
+
+func TestNamespaceCreator(t *testing.T) {
+	parent := fake.NewMockResource(fake.WithNamespaceName("parent", "default"))
+	notFound := kerrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "tenant")
+	type want struct {
+		err     error
+		created []string
+	}
+	cases := map[string]struct {
+		reason string
+		list   []resource.ChildResource
+		get    test.MockGetFn
+		create error
+		want   want
+	}{
+		"Exists": {
+			reason: "Namespaces that exist should not be created",
+			list:   []resource.ChildResource{fake.NewMockResource(fake.WithNamespaceName("child", "tenant"))},
+			get:    test.NewMockGetFn(nil),
+		},
+		"Missing": {
+			reason: "Missing namespaces should be created once",
+			list: []resource.ChildResource{
+				fake.NewMockResource(fake.WithNamespaceName("one", "tenant")),
+				fake.NewMockResource(fake.WithNamespaceName("two", "tenant")),
+				fake.NewMockResource(fake.WithNamespaceName("cluster-scoped", "")),
+			},
+			get:  test.NewMockGetFn(notFound),
+			want: want{created: []string{"tenant"}},
+		},
+		"Rendered": {
+			reason: "Namespaces that are child resources themselves should not be created",
+			list: []resource.ChildResource{
+				fake.NewMockResource(fake.WithNamespaceName("tenant", ""), fake.WithGVK(corev1.SchemeGroupVersion.WithKind("Namespace"))),
+				fake.NewMockResource(fake.WithNamespaceName("child", "tenant")),
+			},
+			get: test.NewMockGetFn(notFound),
+		},
+		"GetFailed": {
+			reason: "Errors getting a namespace should be returned",
+			list:   []resource.ChildResource{fake.NewMockResource(fake.WithNamespaceName("child", "tenant"))},
+			get:    test.NewMockGetFn(errBoom),
+			want:   want{err: errors.Wrap(errBoom, errGetNamespace)},
+		},
+		"CreateFailed": {
+			reason: "Errors creating a namespace should be returned",
+			list:   []resource.ChildResource{fake.NewMockResource(fake.WithNamespaceName("child", "tenant"))},
+			get:    test.NewMockGetFn(notFound),
+			create: errBoom,
+			want:   want{err: errors.Wrap(errBoom, errCreateNamespace), created: []string{"tenant"}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var created []string
+			kube := &test.MockClient{
+				MockGet: tc.get,
+				MockCreate: func(_ context.Context, obj runtime.Object, _ ...client.CreateOption) error {
+					ns := obj.(*corev1.Namespace)
+					if diff := cmp.Diff(packages.ParentLabels(parent), ns.GetLabels()); diff != "" {
+						t.Errorf("\nReason: %s\nCreate(...): -want labels, +got labels:\n%s", tc.reason, diff)
+					}
+					created = append(created, ns.GetName())
+					return tc.create
+				},
+			}
+			err := NewNamespaceCreator(kube).Run(context.TODO(), parent, tc.list)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nRun(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.created, created); diff != "" {
+				t.Errorf("\nReason: %s\nRun(...): -want created, +got created:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// NamespaceOverlaysValuesKey is the key under spec.parameters of the render
+// input that the overlays of the namespace of the parent resource are exposed
+// with.
+const NamespaceOverlaysValuesKey = "namespaceOverlays"
+
+const (
+	errGetNamespaceOverlays   = "cannot get namespace overlays"
+	errParseNamespaceOverlay  = "cannot parse namespace overlay"
+	errApplyNamespaceOverlays = "cannot apply namespace overlays"
+)
+
+// WithNamespaceOverlays returns a ReconcilerOption that patches the rendered
+// child resources of the parent resources with the overlays in the ConfigMap
+// with the given name in their namespace, so that the operators of a cluster
+// can adjust the render per tenant namespace without changing the pack.
+func WithNamespaceOverlays(configMap string) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		WithValuesProvider(NewNamespaceOverlayResolver(reconciler.client, configMap))(reconciler)
+		WithAdditionalChildResourcePatcher(NewNamespaceOverlayPatcher())(reconciler)
+	}
+}
+
+// NewNamespaceOverlayResolver returns a new *NamespaceOverlayResolver that
+// reads the overlays from the ConfigMap with the given name.
+func NewNamespaceOverlayResolver(c client.Reader, configMap string) *NamespaceOverlayResolver {
+	return &NamespaceOverlayResolver{kube: c, name: configMap}
+}
+
+// NamespaceOverlayResolver is a ValuesProvider that reads the overlays of the
+// namespace of the parent resource and exposes them under the
+// namespaceOverlays parameter. Every entry of the data of the ConfigMap is an
+// overlay in YAML form with the apiVersion and kind of the child resources it
+// applies to, and optionally their metadata.name. The overlays are exposed in
+// the order of their keys. Cluster-scoped parent resources and the namespaces
+// without the ConfigMap get no values.
+type NamespaceOverlayResolver struct {
+	kube client.Reader
+	name string
+}
+
+// Values returns the overlays of the namespace of the given parent resource.
+func (n *NamespaceOverlayResolver) Values(ctx context.Context, cr resource.ParentResource) (map[string]interface{}, error) {
+	if cr.GetNamespace() == "" {
+		return nil, nil
+	}
+	cm := &corev1.ConfigMap{}
+	err := n.kube.Get(ctx, types.NamespacedName{Name: n.name, Namespace: cr.GetNamespace()}, cm)
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errGetNamespaceOverlays)
+	}
+	keys := make([]string, 0, len(cm.Data))
+	for key := range cm.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	overlays := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		j, err := yaml.YAMLToJSON([]byte(cm.Data[key]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s %s", errParseNamespaceOverlay, key)
+		}
+		o := &unstructured.Unstructured{}
+		if err := o.UnmarshalJSON(j); err != nil {
+			return nil, errors.Wrapf(err, "%s %s", errParseNamespaceOverlay, key)
+		}
+		overlays = append(overlays, o.UnstructuredContent())
+	}
+	return map[string]interface{}{NamespaceOverlaysValuesKey: overlays}, nil
+}
+
+// NewNamespaceOverlayPatcher returns a new NamespaceOverlayPatcher.
+func NewNamespaceOverlayPatcher() NamespaceOverlayPatcher {
+	return NamespaceOverlayPatcher{}
+}
+
+// NamespaceOverlayPatcher is a ChildResourcePatcher that merges the overlays
+// that NamespaceOverlayResolver exposed into the child resources they apply
+// to, like a JSON merge patch does: objects are merged, null removes a field
+// and all other values replace the ones of the child resource. An overlay
+// with a name applies to the child resource with that name either as it is
+// rendered or without the name prefix of the parent resource.
+type NamespaceOverlayPatcher struct{}
+
+// Patch patches the child resources with information in resource.ParentResource.
+func (NamespaceOverlayPatcher) Patch(cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	overlays, ok, err := unstructured.NestedSlice(cr.UnstructuredContent(), "spec", resource.ParametersField, NamespaceOverlaysValuesKey)
+	if err != nil || !ok {
+		return list, errors.Wrap(err, errApplyNamespaceOverlays)
+	}
+	for _, ov := range overlays {
+		overlay, ok := ov.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		target := &unstructured.Unstructured{Object: overlay}
+		patch := runtime.DeepCopyJSON(overlay)
+		delete(patch, "apiVersion")
+		delete(patch, "kind")
+		unstructured.RemoveNestedField(patch, "metadata", "name")
+		for _, o := range list {
+			if !overlayApplies(cr, target, o) {
+				continue
+			}
+			u, ok := o.(interface{ UnstructuredContent() map[string]interface{} })
+			if !ok {
+				continue
+			}
+			// The patch is shared by all child resources it applies to.
+			mergePatch(u.UnstructuredContent(), runtime.DeepCopyJSON(patch))
+		}
+	}
+	return list, nil
+}
+
+func overlayApplies(cr resource.ParentResource, overlay *unstructured.Unstructured, o resource.ChildResource) bool {
+	if overlay.GroupVersionKind() != o.GetObjectKind().GroupVersionKind() {
+		return false
+	}
+	name := overlay.GetName()
+	return name == "" || o.GetName() == name || o.GetName() == cr.GetName()+"-"+name
+}
+
+// mergePatch merges the given patch into the given object following RFC 7386.
+func mergePatch(obj, patch map[string]interface{}) {
+	for k, pv := range patch {
+		if pv == nil {
+			delete(obj, k)
+			continue
+		}
+		pm, pok := pv.(map[string]interface{})
+		om, ook := obj[k].(map[string]interface{})
+		if pok && ook {
+			mergePatch(om, pm)
+			continue
+		}
+		if pok {
+			om = map[string]interface{}{}
+			mergePatch(om, pm)
+			obj[k] = om
+			continue
+		}
+		obj[k] = pv
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ ValuesProvider       = &NamespaceOverlayResolver{}
+	_ ChildResourcePatcher = NamespaceOverlayPatcher{}
+)
+
+func TestNamespaceOverlayResolver(t *testing.T) {
+	type want struct {
+		vals map[string]interface{}
+		err  error
+	}
+	cases := map[string]struct {
+		reason    string
+		namespace string
+		get       test.MockGetFn
+		want      want
+	}{
+		"ClusterScoped": {
+			reason: "Cluster-scoped parent resources should get no values",
+		},
+		"NoConfigMap": {
+			reason:    "Namespaces without the ConfigMap should get no values",
+			namespace: "tenant",
+			get:       test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+		},
+		"Resolved": {
+			reason:    "The overlays should be exposed in the order of their keys",
+			namespace: "tenant",
+			get: test.NewMockGetFn(nil, func(obj runtime.Object) error {
+				obj.(*corev1.ConfigMap).Data = map[string]string{
+					"b-replicas": "apiVersion: apps/v1\nkind: Deployment\nspec:\n  replicas: 3\n",
+					"a-labels":   "apiVersion: v1\nkind: Service\nmetadata:\n  name: db\n  labels:\n    tier: gold\n",
+				}
+				return nil
+			}),
+			want: want{vals: map[string]interface{}{NamespaceOverlaysValuesKey: []interface{}{
+				map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "Service",
+					"metadata":   map[string]interface{}{"name": "db", "labels": map[string]interface{}{"tier": "gold"}},
+				},
+				map[string]interface{}{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"spec":       map[string]interface{}{"replicas": int64(3)},
+				},
+			}}},
+		},
+		"GetFailed": {
+			reason:    "Errors getting the ConfigMap should be returned",
+			namespace: "tenant",
+			get:       test.NewMockGetFn(errBoom),
+			want:      want{err: errors.Wrap(errBoom, errGetNamespaceOverlays)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cr := fake.NewMockResource(fake.WithNamespaceName("cool", tc.namespace))
+			vals, err := NewNamespaceOverlayResolver(&test.MockClient{MockGet: tc.get}, "overlays").Values(context.TODO(), cr)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.vals, vals); diff != "" {
+				t.Errorf("\nReason: %s\nValues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestNamespaceOverlayPatcher(t *testing.T) {
+	cr := fake.NewMockResource(fake.FromYAML([]byte(`
+apiVersion: mock.crossplane.io/v1alpha1
+kind: MockKind
+metadata:
+  name: cool
+spec:
+  parameters:
+    namespaceOverlays:
+    - apiVersion: mock.child.crossplane.io/v1alpha1
+      kind: MockChildKind
+      metadata:
+        name: db
+      spec:
+        size: large
+        debug: null
+`)))
+	matched := fake.NewMockResource(fake.FromYAML([]byte(`
+apiVersion: mock.child.crossplane.io/v1alpha1
+kind: MockChildKind
+metadata:
+  name: cool-db
+spec:
+  size: small
+  debug: true
+  zone: a
+`)))
+	other := fake.NewMockResource(fake.FromYAML([]byte(`
+apiVersion: mock.child.crossplane.io/v1alpha1
+kind: MockChildKind
+metadata:
+  name: cool-cache
+spec:
+  size: small
+`)))
+	if _, err := NewNamespaceOverlayPatcher().Patch(cr, []resource.ChildResource{matched, other}); err != nil {
+		t.Fatalf("Patch(...): %s", err)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"size": "large", "zone": "a"}, matched.UnstructuredContent()["spec"]); diff != "" {
+		t.Errorf("Patch(...): -want spec, +got spec:\n%s", diff)
+	}
+	if diff := cmp.Diff("cool-db", matched.GetName()); diff != "" {
+		t.Errorf("Patch(...): the overlay should not rename the child resource: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"size": "small"}, other.UnstructuredContent()["spec"]); diff != "" {
+		t.Errorf("Patch(...): child resources with other names should not be patched: -want spec, +got spec:\n%s", diff)
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errMarshalNotification   = "cannot marshal notification"
+	errSendNotification      = "cannot send notification to webhook"
+	errNotificationResponse  = "notification webhook responded with an unexpected status"
+	errNotify                = "cannot send notification"
+	maxNotificationReference = 10
+)
+
+// NotificationOutcome is the outcome of a reconcile that operators are
+// notified about.
+type NotificationOutcome string
+
+// The outcomes of a reconcile that operators are notified about.
+const (
+	// OutcomeSucceeded is sent when the parent resource becomes available.
+	OutcomeSucceeded NotificationOutcome = "Succeeded"
+	// OutcomeFailed is sent when the reconcile of the parent resource starts
+	// failing or fails with a different error.
+	OutcomeFailed NotificationOutcome = "Failed"
+	// OutcomeDriftCorrected is sent when child resources are updated although
+	// the parent resource has not changed since its last successful sync.
+	OutcomeDriftCorrected NotificationOutcome = "DriftCorrected"
+	// OutcomePruned is sent when child resources are deleted, either because
+	// the parent resource is deleted or they are superseded.
+	OutcomePruned NotificationOutcome = "Pruned"
+)
+
+// Notification is the outcome of a reconcile that operators are notified
+// about.
+type Notification struct {
+	// Parent is the parent resource that is reconciled.
+	Parent resource.ChildReference `json:"parent"`
+
+	// Outcome is the outcome of the reconcile.
+	Outcome NotificationOutcome `json:"outcome"`
+
+	// Message is the human-readable detail of the outcome, e.g. the error
+	// that the reconcile failed with.
+	Message string `json:"message,omitempty"`
+
+	// Objects are the child resources that are affected, e.g. the ones whose
+	// drift is corrected or the ones that are pruned.
+	Objects []resource.ChildReference `json:"objects,omitempty"`
+
+	// Time is when the outcome happened.
+	Time metav1.Time `json:"time"`
+}
+
+// Text returns a single line summary of the notification.
+func (n Notification) Text() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s %s", n.Parent.Kind, n.Parent.Name)
+	if n.Parent.Namespace != "" {
+		fmt.Fprintf(b, " in %s", n.Parent.Namespace)
+	}
+	fmt.Fprintf(b, ": %s", n.Outcome)
+	if n.Message != "" {
+		fmt.Fprintf(b, ": %s", n.Message)
+	}
+	if len(n.Objects) == 0 {
+		return b.String()
+	}
+	names := make([]string, 0, len(n.Objects))
+	for i, o := range n.Objects {
+		if i == maxNotificationReference {
+			names = append(names, fmt.Sprintf("%d more", len(n.Objects)-i))
+			break
+		}
+		names = append(names, o.Kind+"/"+o.Name)
+	}
+	fmt.Fprintf(b, " (%s)", strings.Join(names, ", "))
+	return b.String()
+}
+
+// newNotification returns a Notification of the given parent resource.
+func newNotification(cr resource.ParentResource, outcome NotificationOutcome, msg string, objs []resource.ChildReference) Notification {
+	return Notification{
+		Parent:  resource.ReferenceTo(cr),
+		Outcome: outcome,
+		Message: msg,
+		Objects: objs,
+		Time:    metav1.Now(),
+	}
+}
+
+// references returns the references of the given child resources.
+func references(list []resource.ChildResource) []resource.ChildReference {
+	result := make([]resource.ChildReference, len(list))
+	for i, o := range list {
+		result[i] = resource.ReferenceTo(o)
+	}
+	return result
+}
+
+// notify sends the given notification to the Notifier of the reconciler, if
+// there is one. Failing to send a notification does not fail the reconcile; it
+// is only logged.
+func (r *Reconciler) notify(ctx context.Context, n Notification) {
+	if r.notifier == nil {
+		return
+	}
+	if err := r.notifier.Notify(ctx, n); err != nil {
+		r.log.Info(errNotify, "error", err, "outcome", n.Outcome)
+	}
+}
+
+// notifyFailure notifies about the given error unless the parent resource has
+// already been reported to fail with it, so that a parent resource that keeps
+// failing does not flood the operators.
+func (r *Reconciler) notifyFailure(ctx context.Context, cr resource.ParentResource, err error) {
+	if r.notifier == nil {
+		return
+	}
+	prev, gerr := resource.GetCondition(cr, v1alpha1.TypeSynced)
+	if gerr == nil && prev.Reason == v1alpha1.ReasonReconcileError && strings.Contains(prev.Message, err.Error()) {
+		return
+	}
+	r.notify(ctx, newNotification(cr, OutcomeFailed, err.Error(), nil))
+}
+
+// notifySuccess notifies about the parent resource becoming available and
+// about the drift of child resources corrected since the given time. It needs
+// to be called before the conditions of the successful reconcile are set.
+func (r *Reconciler) notifySuccess(ctx context.Context, cr resource.ParentResource, resync bool, since time.Time) {
+	if r.notifier == nil {
+		return
+	}
+	if resync {
+		if drifted := updatedSince(cr, since); len(drifted) > 0 {
+			r.notify(ctx, newNotification(cr, OutcomeDriftCorrected, "", drifted))
+		}
+	}
+	prev, err := resource.GetCondition(cr, v1alpha1.TypeReady)
+	if err == nil && prev.Reason == v1alpha1.ReasonAvailable {
+		return
+	}
+	r.notify(ctx, newNotification(cr, OutcomeSucceeded, "", nil))
+}
+
+// updatedSince returns the child resources that are recorded in the
+// inventory of the given parent resource as updated at or after the given
+// time.
+func updatedSince(cr resource.ParentResource, t time.Time) []resource.ChildReference {
+	statuses, err := resource.GetChildStatuses(cr)
+	if err != nil {
+		return nil
+	}
+	// NOTE: The apply times are recorded with a precision of a second.
+	t = t.Truncate(time.Second)
+	var result []resource.ChildReference
+	for _, s := range statuses {
+		if s.LastOperation == resource.ApplyUpdated && !s.LastApplyTime.Time.Before(t) {
+			result = append(result, s.ChildReference)
+		}
+	}
+	return result
+}
+
+// NewWebhookNotifier returns a new *WebhookNotifier that sends the
+// notifications to the given URL.
+func NewWebhookNotifier(url string, c *http.Client) *WebhookNotifier {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return &WebhookNotifier{url: url, client: c}
+}
+
+// WebhookNotifier is a Notifier that POSTs every notification as JSON to an
+// external webhook. The payload is compatible with Slack incoming webhooks;
+// the summary is in its text field and the notification itself is in its
+// notification field for the webhooks that process them further.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+type webhookNotification struct {
+	Text         string       `json:"text"`
+	Notification Notification `json:"notification"`
+}
+
+// Notify sends the given notification to the webhook.
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	b, err := json.Marshal(webhookNotification{Text: n.Text(), Notification: n})
+	if err != nil {
+		return errors.Wrap(err, errMarshalNotification)
+	}
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrap(err, errSendNotification)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, errSendNotification)
+	}
+	defer resp.Body.Close() // nolint:errcheck
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("%s: %d", errNotificationResponse, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ Notifier = &WebhookNotifier{}
+var _ Notifier = NotifierFunc(nil)
+var _ Notifier = NotifierChain{}
+
+func TestNotificationText(t *testing.T) {
+	parent := resource.ChildReference{Kind: "App", Name: "cool", Namespace: "ns"}
+	cases := map[string]struct {
+		reason string
+		n      Notification
+		want   string
+	}{
+		"OutcomeOnly": {
+			reason: "The summary should contain the parent resource and the outcome",
+			n:      Notification{Parent: parent, Outcome: OutcomeSucceeded},
+			want:   "App cool in ns: Succeeded",
+		},
+		"WithMessage": {
+			reason: "The message should be appended to the summary",
+			n:      Notification{Parent: resource.ChildReference{Kind: "App", Name: "cool"}, Outcome: OutcomeFailed, Message: "boom"},
+			want:   "App cool: Failed: boom",
+		},
+		"WithObjects": {
+			reason: "The affected objects should be listed in the summary",
+			n: Notification{Parent: parent, Outcome: OutcomePruned, Objects: []resource.ChildReference{
+				{Kind: "ConfigMap", Name: "a"},
+				{Kind: "Secret", Name: "b"},
+			}},
+			want: "App cool in ns: Pruned (ConfigMap/a, Secret/b)",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, tc.n.Text()); diff != "" {
+				t.Errorf("\nReason: %s\nText(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	n := Notification{Parent: resource.ChildReference{Kind: "App", Name: "cool"}, Outcome: OutcomeSucceeded}
+	if err := NewWebhookNotifier(srv.URL, srv.Client()).Notify(context.Background(), n); err != nil {
+		t.Fatalf("Notify(...): unexpected error: %s", err)
+	}
+	if diff := cmp.Diff("App cool: Succeeded", got["text"]); diff != "" {
+		t.Errorf("Notify(...): -want, +got:\n%s", diff)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+	err := NewWebhookNotifier(failing.URL, failing.Client()).Notify(context.Background(), n)
+	if diff := cmp.Diff(errors.Errorf("%s: %d", errNotificationResponse, http.StatusNotFound), err, test.EquateErrors()); diff != "" {
+		t.Errorf("Notify(...): -want error, +got error:\n%s", diff)
+	}
+}
+
+func TestNotifyFailure(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		synced v1alpha1.Condition
+		want   int
+	}{
+		"FirstFailure": {
+			reason: "The first failure should be notified",
+			synced: v1alpha1.ReconcileSuccess(),
+			want:   1,
+		},
+		"SameFailure": {
+			reason: "A failure that is already reported should not be notified again",
+			synced: v1alpha1.ReconcileError(errors.Wrap(errBoom, "wrapped")),
+			want:   0,
+		},
+		"DifferentFailure": {
+			reason: "A failure with a different error should be notified",
+			synced: v1alpha1.ReconcileError(errors.New("other")),
+			want:   1,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cr := fake.NewMockResource()
+			if err := resource.SetConditions(cr, tc.synced); err != nil {
+				t.Fatal(err)
+			}
+			got := 0
+			r := &Reconciler{log: logging.NewNopLogger(), notifier: NotifierFunc(func(_ context.Context, _ Notification) error {
+				got++
+				return nil
+			})}
+			r.notifyFailure(context.Background(), cr, errBoom)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\nReason: %s\nnotifyFailure(...): -want notifications, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errOneShotHash   = "cannot hash one-shot child resource"
+	errOneShotFailed = "one-shot child resource failed"
+	errGetOneShot    = "cannot get one-shot child resource"
+	errDeleteOneShot = "cannot delete one-shot child resource to run it again"
+)
+
+// IsOneShot returns true if the given child resource is annotated to run
+// once, e.g. a Job that migrates a database or takes a backup.
+func IsOneShot(o resource.ChildResource) bool {
+	switch o.GetAnnotations()[OneShotAnnotationKey] {
+	case OneShotTrueValue, OneShotRerunOnChangeValue:
+		return true
+	}
+	return false
+}
+
+// oneShotHash returns the hash of the rendered form of the given child
+// resource.
+func oneShotHash(o resource.ChildResource) (string, error) {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return "", errors.Wrap(err, errOneShotHash)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// runOneShot runs the given one-shot child resource once, instead of applying
+// it in every reconcile, and returns whether it completed. It is created if it
+// has not run yet and left alone while it runs, so that the immutable fields
+// of Jobs are not fought over. The child resources that completed are not
+// created again once they are cleaned up, e.g. by the TTL of finished Jobs,
+// unless they are annotated to run again when their rendered form changes. A
+// failed child resource fails the reconciles until it runs again. Jobs
+// complete with their Complete condition, the other kinds once they are
+// ready.
+func (r *Reconciler) runOneShot(ctx context.Context, cr resource.ParentResource, o resource.ChildResource, inv *childInventory) (bool, error) { // nolint:gocyclo
+	hash, err := oneShotHash(o)
+	if err != nil {
+		return false, err
+	}
+	rerun := o.GetAnnotations()[OneShotAnnotationKey] == OneShotRerunOnChangeValue
+	prev, ran := inv.statuses[resource.ReferenceTo(o)]
+	ran = ran && prev.RunHash != "" && (!rerun || prev.RunHash == hash)
+	if ran && prev.LastOperation == resource.ApplyCompleted {
+		return true, nil
+	}
+
+	current, ok := o.DeepCopyObject().(resource.ChildResource)
+	if !ok {
+		return false, errors.New(errGetOneShot)
+	}
+	err = r.client.Get(ctx, types.NamespacedName{Name: o.GetName(), Namespace: o.GetNamespace()}, current)
+	switch {
+	case kerrors.IsNotFound(err) && ran && prev.LastOperation == resource.ApplyFailed:
+		return false, errors.New(prev.LastError)
+	case kerrors.IsNotFound(err):
+		meta.AddAnnotations(o, map[string]string{OneShotHashAnnotationKey: hash})
+		res, err := r.applyChild(ctx, cr, o)
+		inv.recordRun(o, res, err, hash)
+		return false, err
+	case err != nil:
+		return false, errors.Wrap(err, errGetOneShot)
+	}
+
+	if h := current.GetAnnotations()[OneShotHashAnnotationKey]; rerun && h != hash {
+		// The child resource is deleted once it is not running anymore, and
+		// created with its new form in the next reconcile.
+		if done, _ := r.oneShotDone(ctx, current); !done {
+			return false, nil
+		}
+		err := r.client.Delete(ctx, current, client.PropagationPolicy("Background"))
+		return false, errors.Wrap(client.IgnoreNotFound(err), errDeleteOneShot)
+	}
+	copyInto(o, current)
+	done, err := r.oneShotDone(ctx, current)
+	switch {
+	case err != nil:
+		inv.recordRun(o, resource.ApplyFailed, err, hash)
+		return false, err
+	case done:
+		inv.recordRun(o, resource.ApplyCompleted, nil, hash)
+	}
+	return done, nil
+}
+
+// oneShotDone returns true if the given one-shot child resource is not running
+// anymore. A failed Job returns an error.
+func (r *Reconciler) oneShotDone(ctx context.Context, o resource.ChildResource) (bool, error) {
+	if o.GetObjectKind().GroupVersionKind().GroupKind() != jobGroupKind {
+		return r.readiness.IsReady(ctx, o)
+	}
+	u, ok := o.(interface{ UnstructuredContent() map[string]interface{} })
+	if !ok {
+		return false, nil
+	}
+	switch {
+	case jobConditionTrue(u.UnstructuredContent(), "Failed"):
+		return true, errors.Errorf("%s: %s/%s", errOneShotFailed, o.GetNamespace(), o.GetName())
+	case jobConditionTrue(u.UnstructuredContent(), "Complete"):
+		return true, nil
+	}
+	return false, nil
+}
+
+// copyInto makes the given rendered child resource show the state of the
+// current one in the cluster.
+func copyInto(o, current resource.ChildResource) {
+	dst, ok := o.(runtime.Unstructured)
+	src, sok := current.(runtime.Unstructured)
+	if ok && sok {
+		dst.SetUnstructuredContent(runtime.DeepCopyJSON(src.UnstructuredContent()))
+	}
+}
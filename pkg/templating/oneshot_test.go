@@ -0,0 +1,193 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+func TestRunOneShot(t *testing.T) {
+	errNotFound := kerrors.NewNotFound(schema.GroupResource{Group: "batch", Resource: "jobs"}, "migrate")
+	job := func(mode string, annotations map[string]string, conditions ...string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("batch/v1")
+		u.SetKind("Job")
+		u.SetNamespace(namespace)
+		u.SetName("migrate")
+		a := map[string]string{OneShotAnnotationKey: mode}
+		for k, v := range annotations {
+			a[k] = v
+		}
+		u.SetAnnotations(a)
+		var c []interface{}
+		for _, ct := range conditions {
+			c = append(c, map[string]interface{}{"type": ct, "status": "True"})
+		}
+		if len(c) > 0 {
+			_ = unstructured.SetNestedSlice(u.Object, c, "status", "conditions")
+		}
+		return u
+	}
+	hash, _ := oneShotHash(job(OneShotTrueValue, nil))
+	rerunHash, _ := oneShotHash(job(OneShotRerunOnChangeValue, nil))
+	inCluster := func(u *unstructured.Unstructured) test.MockGetFn {
+		return func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+			u.DeepCopyInto(obj.(*unstructured.Unstructured))
+			return nil
+		}
+	}
+
+	type args struct {
+		kube *test.MockClient
+		prev *resource.ChildStatus
+		o    *unstructured.Unstructured
+	}
+	type want struct {
+		done bool
+		err  error
+		last resource.ApplyResult
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NotRunYet": {
+			reason: "One-shot child resources that have not run yet should be created",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    test.NewMockGetFn(errNotFound),
+					MockCreate: test.NewMockCreateFn(nil),
+				},
+				o: job(OneShotTrueValue, nil),
+			},
+			want: want{last: resource.ApplyCreated},
+		},
+		"Running": {
+			reason: "One-shot child resources that are running should be left alone",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:   inCluster(job(OneShotTrueValue, map[string]string{OneShotHashAnnotationKey: hash})),
+					MockPatch: test.NewMockPatchFn(errBoom),
+				},
+				o: job(OneShotTrueValue, nil),
+			},
+			want: want{},
+		},
+		"Completed": {
+			reason: "One-shot child resources that completed in the cluster should be recorded as completed",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: inCluster(job(OneShotTrueValue, map[string]string{OneShotHashAnnotationKey: hash}, "Complete")),
+				},
+				o: job(OneShotTrueValue, nil),
+			},
+			want: want{done: true, last: resource.ApplyCompleted},
+		},
+		"CompletedAndCleanedUp": {
+			reason: "One-shot child resources that completed before should not be created again once they are cleaned up",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				prev: &resource.ChildStatus{LastOperation: resource.ApplyCompleted, RunHash: hash},
+				o:    job(OneShotTrueValue, nil),
+			},
+			want: want{done: true, last: resource.ApplyCompleted},
+		},
+		"Failed": {
+			reason: "One-shot Jobs that failed should fail",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: inCluster(job(OneShotTrueValue, map[string]string{OneShotHashAnnotationKey: hash}, "Failed")),
+				},
+				o: job(OneShotTrueValue, nil),
+			},
+			want: want{
+				err:  errors.Errorf("%s: %s/%s", errOneShotFailed, namespace, "migrate"),
+				last: resource.ApplyFailed,
+			},
+		},
+		"FailedAndCleanedUp": {
+			reason: "One-shot child resources that failed before should keep failing once they are cleaned up",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(errNotFound)},
+				prev: &resource.ChildStatus{LastOperation: resource.ApplyFailed, LastError: "boom", RunHash: hash},
+				o:    job(OneShotTrueValue, nil),
+			},
+			want: want{
+				err:  errors.New("boom"),
+				last: resource.ApplyFailed,
+			},
+		},
+		"RerunOnChange": {
+			reason: "One-shot child resources whose rendered form changed should be deleted to run again",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    inCluster(job(OneShotRerunOnChangeValue, map[string]string{OneShotHashAnnotationKey: "old"}, "Complete")),
+					MockDelete: test.NewMockDeleteFn(nil),
+				},
+				prev: &resource.ChildStatus{LastOperation: resource.ApplyCompleted, RunHash: "old"},
+				o:    job(OneShotRerunOnChangeValue, nil),
+			},
+			want: want{last: resource.ApplyCompleted},
+		},
+		"RerunUnchanged": {
+			reason: "One-shot child resources that run again on change should not run again if they did not change",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				prev: &resource.ChildStatus{LastOperation: resource.ApplyCompleted, RunHash: rerunHash},
+				o:    job(OneShotRerunOnChangeValue, nil),
+			},
+			want: want{done: true, last: resource.ApplyCompleted},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := benchmarkReconciler(tc.args.kube)
+			cr := benchmarkParent()
+			if tc.args.prev != nil {
+				prev := *tc.args.prev
+				prev.ChildReference = resource.ReferenceTo(tc.args.o)
+				_ = resource.SetChildStatuses(cr, []resource.ChildStatus{prev})
+			}
+			inv := newChildInventory(cr, []resource.ChildResource{tc.args.o})
+			done, err := r.runOneShot(context.TODO(), cr, tc.args.o, inv)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nrunOneShot(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.done, done); diff != "" {
+				t.Errorf("\n%s\nrunOneShot(...): -want done, +got done:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.last, inv.statuses[resource.ReferenceTo(tc.args.o)].LastOperation); diff != "" {
+				t.Errorf("\n%s\nrunOneShot(...): -want last operation, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
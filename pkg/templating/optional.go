@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const errFilterOptional = "cannot filter optional child resources"
+
+// TypeOptionalChildResources is the type of the condition that tells which
+// optional child resources of a parent resource were skipped because the
+// cluster does not serve their APIs.
+const TypeOptionalChildResources v1alpha1.ConditionType = "OptionalChildResources"
+
+// Reasons of the OptionalChildResources condition.
+const (
+	ReasonOptionalAPIsUnavailable v1alpha1.ConditionReason = "APIsUnavailable"
+	ReasonOptionalAPIsAvailable   v1alpha1.ConditionReason = "APIsAvailable"
+)
+
+// OptionalChildResourcesSkipped returns a condition that indicates the given
+// optional child resources were not applied because the cluster does not
+// serve their APIs.
+func OptionalChildResourcesSkipped(skipped []resource.ChildResource) v1alpha1.Condition {
+	names := make([]string, len(skipped))
+	for i, o := range skipped {
+		gvk := o.GetObjectKind().GroupVersionKind()
+		names[i] = fmt.Sprintf("%s %s/%s", gvk.GroupVersion(), gvk.Kind, o.GetName())
+	}
+	sort.Strings(names)
+	return v1alpha1.Condition{
+		Type:               TypeOptionalChildResources,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonOptionalAPIsUnavailable,
+		Message:            fmt.Sprintf("skipped because their APIs are not served: %s", strings.Join(names, ", ")),
+	}
+}
+
+// OptionalChildResourcesApplied returns a condition that indicates no
+// optional child resource was skipped.
+func OptionalChildResourcesApplied() v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:               TypeOptionalChildResources,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonOptionalAPIsAvailable,
+	}
+}
+
+// IsOptional returns true if the given child resource is annotated to be
+// skipped when the cluster does not serve its API.
+func IsOptional(o metav1.Object) bool {
+	return o.GetAnnotations()[OptionalAnnotationKey] == OptionalTrueValue
+}
+
+// WithOptionalChildResources returns a ReconcilerOption that makes the
+// reconciler skip the optional child resources, as told by IsOptional, whose
+// APIs are not served according to the given RESTMapper instead of failing
+// to apply them, e.g. a ServiceMonitor in a cluster without the Prometheus
+// operator. The skipped child resources are reported in the
+// OptionalChildResources condition of the parent resource. They are not
+// skipped by Render, so that the renders do not depend on the cluster.
+func WithOptionalChildResources(m meta.RESTMapper) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.optional = NewOptionalChildResourceFilter(m)
+	}
+}
+
+// NewOptionalChildResourceFilter returns a new *OptionalChildResourceFilter
+// that looks the kinds up in the given RESTMapper, which is usually backed by
+// the discovery of the cluster.
+func NewOptionalChildResourceFilter(m meta.RESTMapper) *OptionalChildResourceFilter {
+	return &OptionalChildResourceFilter{mapper: m}
+}
+
+// OptionalChildResourceFilter leaves out the optional child resources whose
+// APIs the cluster does not serve. The child resources that target remote
+// clusters are never left out.
+type OptionalChildResourceFilter struct {
+	mapper meta.RESTMapper
+}
+
+// Filter returns the child resources to apply and the optional ones that are
+// left out. It returns an error only if the APIs cannot be looked up.
+func (f *OptionalChildResourceFilter) Filter(list []resource.ChildResource) (kept, skipped []resource.ChildResource, err error) {
+	kept = make([]resource.ChildResource, 0, len(list))
+	for _, o := range list {
+		if !IsOptional(o) || IsRemote(o) {
+			kept = append(kept, o)
+			continue
+		}
+		gvk := o.GetObjectKind().GroupVersionKind()
+		_, err := f.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if meta.IsNoMatchError(err) {
+			skipped = append(skipped, o)
+			continue
+		}
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "%s %s", errMapChildKind, gvk.GroupKind())
+		}
+		kept = append(kept, o)
+	}
+	return kept, skipped, nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+func TestOptionalChildResourceFilter(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+
+	object := func(apiVersion, kind, name string, annotations map[string]string) resource.ChildResource {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetName(name)
+		u.SetAnnotations(annotations)
+		return u
+	}
+	optional := map[string]string{OptionalAnnotationKey: OptionalTrueValue}
+	config := object("v1", "ConfigMap", "config", optional)
+	monitor := object("monitoring.coreos.com/v1", "ServiceMonitor", "web", optional)
+	required := object("example.org/v1", "App", "web", nil)
+	remote := object("monitoring.coreos.com/v1", "ServiceMonitor", "edge", map[string]string{OptionalAnnotationKey: OptionalTrueValue, TargetClusterAnnotationKey: "edge"})
+
+	type want struct {
+		kept    []resource.ChildResource
+		skipped []resource.ChildResource
+		err     error
+	}
+	cases := map[string]struct {
+		reason string
+		list   []resource.ChildResource
+		want   want
+	}{
+		"Served": {
+			reason: "Optional child resources of served kinds should be kept.",
+			list:   []resource.ChildResource{config},
+			want:   want{kept: []resource.ChildResource{config}},
+		},
+		"NotServed": {
+			reason: "Only the optional child resources of kinds that are not served should be skipped.",
+			list:   []resource.ChildResource{config, monitor, required, remote},
+			want: want{
+				kept:    []resource.ChildResource{config, required, remote},
+				skipped: []resource.ChildResource{monitor},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			kept, skipped, err := NewOptionalChildResourceFilter(mapper).Filter(tc.list)
+			if diff := cmp.Diff(tc.want, want{kept: kept, skipped: skipped, err: err}, test.EquateErrors(), cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nFilter(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestOptionalChildResourcesSkipped(t *testing.T) {
+	b := &unstructured.Unstructured{}
+	b.SetAPIVersion("monitoring.coreos.com/v1")
+	b.SetKind("ServiceMonitor")
+	b.SetName("web")
+	a := &unstructured.Unstructured{}
+	a.SetAPIVersion("policy/v1beta1")
+	a.SetKind("PodDisruptionBudget")
+	a.SetName("web")
+	got := OptionalChildResourcesSkipped([]resource.ChildResource{b, a}).Message
+	want := "skipped because their APIs are not served: monitoring.coreos.com/v1 ServiceMonitor/web, policy/v1beta1 PodDisruptionBudget/web"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("OptionalChildResourcesSkipped(...): -want, +got:\n%s", diff)
+	}
+}
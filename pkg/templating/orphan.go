@@ -0,0 +1,43 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// IsOrphaned returns true if the given child resource is annotated to outlive
+// its parent resource, e.g. a volume whose data has to be kept. Such child
+// resources get no owner reference, so that the garbage collector leaves them
+// alone, and are neither deleted with the parent resource nor swept as
+// orphans once it is gone.
+func IsOrphaned(o metav1.Object) bool {
+	return o.GetAnnotations()[OrphanAnnotationKey] == OrphanTrueValue
+}
+
+// WithoutOrphaned returns the given child resources except the orphaned ones.
+func WithoutOrphaned(list []resource.ChildResource) []resource.ChildResource {
+	result := make([]resource.ChildResource, 0, len(list))
+	for _, o := range list {
+		if !IsOrphaned(o) {
+			result = append(result, o)
+		}
+	}
+	return result
+}
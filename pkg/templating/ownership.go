@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	rresource "github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// ReasonOwnershipConflict is the reason of the Synced condition of a parent
+// resource whose child resources exist but are not tracked by it.
+const ReasonOwnershipConflict v1alpha1.ConditionReason = "OwnershipConflict"
+
+// OwnershipConflict returns a condition that indicates a child resource of the
+// parent resource was not updated because it is owned by something else.
+func OwnershipConflict(err error) v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:               v1alpha1.TypeSynced,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonOwnershipConflict,
+		Message:            err.Error(),
+	}
+}
+
+// NewParentUIDAnnotator returns a new ParentUIDAnnotator.
+func NewParentUIDAnnotator() ParentUIDAnnotator {
+	return ParentUIDAnnotator{}
+}
+
+// ParentUIDAnnotator annotates the child resources with the UID of their
+// parent resource so that the ones without its owner reference, e.g. the
+// ones in remote clusters and the orphaned ones, can be told apart from the
+// objects with the same name that it does not own.
+type ParentUIDAnnotator struct{}
+
+// Patch annotates the child resources with ParentUIDAnnotationKey.
+func (a ParentUIDAnnotator) Patch(cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	for _, o := range list {
+		meta.AddAnnotations(o, map[string]string{ParentUIDAnnotationKey: string(cr.GetUID())})
+	}
+	return list, nil
+}
+
+// MustBeTrackedBy returns a rresource.ApplyOption that fails with a
+// *resource.OwnershipError unless the existing object is controlled by the
+// parent resource with the given UID or, if it has no controller, annotated
+// with ParentUIDAnnotationKey naming it. Unlike
+// rresource.MustBeControllableBy, the objects that nothing controls are not
+// taken over.
+func MustBeTrackedBy(u types.UID) rresource.ApplyOption {
+	return func(_ context.Context, current, _ runtime.Object) error {
+		m, ok := current.(metav1.Object)
+		if !ok {
+			return errors.New(errNotObject)
+		}
+		tracked := m.GetAnnotations()[ParentUIDAnnotationKey]
+		c := metav1.GetControllerOf(m)
+		switch {
+		case c != nil && c.UID == u:
+			return nil
+		case c == nil && tracked == string(u):
+			return nil
+		}
+		e := &resource.OwnershipError{ParentUID: string(u), TrackedUID: tracked}
+		if c != nil {
+			e.Controller = fmt.Sprintf("%s %s (%s)", c.Kind, c.Name, c.UID)
+		}
+		return e
+	}
+}
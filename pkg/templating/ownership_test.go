@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+var _ ChildResourcePatcher = ParentUIDAnnotator{}
+
+func TestMustBeTrackedBy(t *testing.T) {
+	truth := true
+	controlledBy := func(uid types.UID) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetOwnerReferences([]metav1.OwnerReference{{Kind: "Deployment", Name: "other", UID: uid, Controller: &truth}})
+		return u
+	}
+	annotated := func(u *unstructured.Unstructured, uid string) *unstructured.Unstructured {
+		u.SetAnnotations(map[string]string{ParentUIDAnnotationKey: uid})
+		return u
+	}
+	cases := map[string]struct {
+		reason  string
+		current *unstructured.Unstructured
+		want    error
+	}{
+		"Controlled": {
+			reason:  "An object that the parent resource controls should be updated.",
+			current: controlledBy("cool-uid"),
+		},
+		"Tracked": {
+			reason:  "An object without a controller that is annotated with the UID of the parent resource should be updated.",
+			current: annotated(&unstructured.Unstructured{}, "cool-uid"),
+		},
+		"ControlledByOther": {
+			reason:  "An object that something else controls should not be updated even if it is annotated with the UID of the parent resource.",
+			current: annotated(controlledBy("other-uid"), "cool-uid"),
+			want:    &resource.OwnershipError{ParentUID: "cool-uid", Controller: "Deployment other (other-uid)", TrackedUID: "cool-uid"},
+		},
+		"TrackedByOther": {
+			reason:  "An object annotated with the UID of another parent resource should not be updated.",
+			current: annotated(&unstructured.Unstructured{}, "other-uid"),
+			want:    &resource.OwnershipError{ParentUID: "cool-uid", TrackedUID: "other-uid"},
+		},
+		"Untracked": {
+			reason:  "An object that nothing controls or tracks should not be taken over.",
+			current: &unstructured.Unstructured{},
+			want:    &resource.OwnershipError{ParentUID: "cool-uid"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := MustBeTrackedBy("cool-uid")(context.Background(), tc.current, nil)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nMustBeTrackedBy(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestParentUIDAnnotator(t *testing.T) {
+	child := &unstructured.Unstructured{}
+	list, err := NewParentUIDAnnotator().Patch(benchmarkParent(), []resource.ChildResource{child})
+	if err != nil {
+		t.Fatalf("Patch(...): unexpected error: %s", err)
+	}
+	if diff := cmp.Diff("cool-uid", list[0].GetAnnotations()[ParentUIDAnnotationKey]); diff != "" {
+		t.Errorf("Patch(...): -want, +got:\n%s", diff)
+	}
+}
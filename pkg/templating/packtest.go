@@ -0,0 +1,325 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// PackTestsDir is the directory in the resources directory of a pack that
+// holds its test fixtures, one YAML file per test.
+const PackTestsDir = "tests"
+
+const (
+	errReadPackTests    = "cannot read pack tests"
+	errParsePackTest    = "cannot parse pack test"
+	errPackTestParent   = "pack test has no parent resource"
+	errRenderPackTest   = "cannot render the parent resource"
+	errCreatePackTest   = "cannot create the parent resource"
+	errGetPackTest      = "cannot get the parent resource"
+	errPackTestTimeout  = "timed out waiting for the parent resource to become"
+	errGetPackTestChild = "cannot get child resource"
+
+	msgChildMissing  = "child resource does not exist"
+	msgFieldMismatch = "field %s is %s instead of %s"
+)
+
+// PackTestChild is a child resource that a pack test expects to exist, with
+// the values of the given fields.
+type PackTestChild struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+
+	// Fields maps the dot-separated paths of fields of the child resource,
+	// e.g. spec.replicas, to their expected values.
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// PackTest is an example parent resource of a pack with the assertions about
+// the child resources that it should result in.
+type PackTest struct {
+	// Name of the test, which is the name of its file without the extension.
+	Name string `json:"-"`
+
+	// Parent is the parent resource to render or create.
+	Parent *unstructured.Unstructured `json:"parent"`
+
+	// Children are the child resources that are expected to be rendered.
+	Children []PackTestChild `json:"children,omitempty"`
+
+	// Ready requires the parent resource to become ready when the test runs
+	// in a cluster. It is not checked when the test only renders.
+	Ready bool `json:"ready,omitempty"`
+}
+
+// LoadPackTests returns the pack tests in the YAML files of the given
+// directory, in the order of their names. A directory that does not exist
+// has no tests.
+func LoadPackTests(dir string) ([]PackTest, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, errors.Wrap(err, errReadPackTests)
+	}
+	sort.Strings(files)
+	result := make([]PackTest, 0, len(files))
+	for _, f := range files {
+		data, err := ioutil.ReadFile(filepath.Clean(f))
+		if err != nil {
+			return nil, errors.Wrap(err, errReadPackTests)
+		}
+		t := PackTest{}
+		if err := yaml.UnmarshalStrict(data, &t); err != nil {
+			return nil, errors.Wrapf(err, "%s %s", errParsePackTest, filepath.Base(f))
+		}
+		if t.Parent == nil {
+			return nil, errors.Errorf("%s: %s", errPackTestParent, filepath.Base(f))
+		}
+		t.Name = strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// PackTestResult is the result of a pack test.
+type PackTestResult struct {
+	Name string
+
+	// Failures are the assertions that failed.
+	Failures []string
+
+	// Err is the error that kept the test from running to the end.
+	Err error
+}
+
+// Passed returns true if the test ran to the end without failures.
+func (r PackTestResult) Passed() bool {
+	return r.Err == nil && len(r.Failures) == 0
+}
+
+// PackTestResults are the results of the tests of a pack.
+type PackTestResults []PackTestResult
+
+// Failed returns the number of tests that did not pass.
+func (rs PackTestResults) Failed() int {
+	n := 0
+	for _, r := range rs {
+		if !r.Passed() {
+			n++
+		}
+	}
+	return n
+}
+
+// String returns a line per test, followed by its failures.
+func (rs PackTestResults) String() string {
+	b := &strings.Builder{}
+	for _, r := range rs {
+		if r.Passed() {
+			fmt.Fprintf(b, "PASS %s\n", r.Name)
+			continue
+		}
+		fmt.Fprintf(b, "FAIL %s\n", r.Name)
+		if r.Err != nil {
+			fmt.Fprintf(b, "  %s\n", r.Err)
+		}
+		for _, f := range r.Failures {
+			fmt.Fprintf(b, "  %s\n", f)
+		}
+	}
+	fmt.Fprintf(b, "%d passed, %d failed\n", len(rs)-rs.Failed(), rs.Failed())
+	return b.String()
+}
+
+// RunPackTests renders the parent resources of the given tests like Render
+// does and checks that the expected child resources are rendered.
+func (r *Reconciler) RunPackTests(ctx context.Context, tests []PackTest) PackTestResults {
+	results := make(PackTestResults, len(tests))
+	for i, t := range tests {
+		results[i] = PackTestResult{Name: t.Name}
+		list, err := r.Render(ctx, t.Parent.DeepCopy())
+		if err != nil {
+			results[i].Err = errors.Wrap(err, errRenderPackTest)
+			continue
+		}
+		rendered := map[resource.ChildReference]map[string]interface{}{}
+		for _, o := range list {
+			if u, ok := o.(interface{ UnstructuredContent() map[string]interface{} }); ok {
+				rendered[resource.ReferenceTo(o)] = u.UnstructuredContent()
+			}
+		}
+		for _, c := range t.Children {
+			ref := resource.ChildReference{APIVersion: c.APIVersion, Kind: c.Kind, Name: c.Name, Namespace: c.Namespace}
+			results[i].Failures = append(results[i].Failures, c.check(rendered[ref])...)
+		}
+	}
+	return results
+}
+
+// RunPackTestsInCluster creates the parent resources of the given tests in
+// the cluster of the given client, waits for the installed controller to sync
+// them, or to make them ready if the test requires it, and checks that the
+// expected child resources exist. The parent resources are deleted once their
+// test has finished.
+func RunPackTestsInCluster(ctx context.Context, kube client.Client, tests []PackTest, timeout time.Duration) PackTestResults {
+	results := make(PackTestResults, len(tests))
+	for i, t := range tests {
+		results[i] = PackTestResult{Name: t.Name}
+		results[i].Failures, results[i].Err = runPackTestInCluster(ctx, kube, t, timeout)
+	}
+	return results
+}
+
+func runPackTestInCluster(ctx context.Context, kube client.Client, t PackTest, timeout time.Duration) ([]string, error) {
+	cr := t.Parent.DeepCopy()
+	if err := kube.Create(ctx, cr); err != nil {
+		return nil, errors.Wrap(err, errCreatePackTest)
+	}
+	defer func() {
+		_ = kube.Delete(ctx, cr)
+	}()
+	ct, want := v1alpha1.TypeSynced, "synced"
+	if t.Ready {
+		ct, want = v1alpha1.TypeReady, "ready"
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		if err := kube.Get(ctx, types.NamespacedName{Namespace: cr.GetNamespace(), Name: cr.GetName()}, cr); err != nil {
+			return nil, errors.Wrap(err, errGetPackTest)
+		}
+		if c, err := resource.GetCondition(cr, ct); err == nil && c.Status == corev1.ConditionTrue {
+			break
+		}
+		if !time.Now().Before(deadline) {
+			return nil, errors.Errorf("%s %s", errPackTestTimeout, want)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+	var failures []string
+	for _, c := range t.Children {
+		o := &unstructured.Unstructured{}
+		o.SetGroupVersionKind(schema.FromAPIVersionAndKind(c.APIVersion, c.Kind))
+		err := kube.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, o)
+		if client.IgnoreNotFound(err) != nil {
+			return failures, errors.Wrapf(err, "%s %s", errGetPackTestChild, c.describe())
+		}
+		var content map[string]interface{}
+		if err == nil {
+			content = o.Object
+		}
+		failures = append(failures, c.check(content)...)
+	}
+	return failures, nil
+}
+
+// check returns the failed assertions about the given content of the child
+// resource, which is nil if the child resource does not exist.
+func (c PackTestChild) check(content map[string]interface{}) []string {
+	if content == nil {
+		return []string{fmt.Sprintf("%s: %s", c.describe(), msgChildMissing)}
+	}
+	paths := make([]string, 0, len(c.Fields))
+	for p := range c.Fields {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	var failures []string
+	for _, p := range paths {
+		got, _, _ := unstructured.NestedFieldNoCopy(content, strings.Split(p, ".")...)
+		if !jsonEqual(c.Fields[p], got) {
+			failures = append(failures, fmt.Sprintf("%s: "+msgFieldMismatch, c.describe(), p, jsonString(got), jsonString(c.Fields[p])))
+		}
+	}
+	return failures
+}
+
+func (c PackTestChild) describe() string {
+	return describeReference(resource.ChildReference{APIVersion: c.APIVersion, Kind: c.Kind, Name: c.Name, Namespace: c.Namespace})
+}
+
+// jsonEqual compares the given values as JSON, so that the numbers of the
+// test files and of the child resources compare equal regardless of their Go
+// types.
+func jsonEqual(a, b interface{}) bool {
+	var ja, jb interface{}
+	_ = json.Unmarshal([]byte(jsonString(a)), &ja)
+	_ = json.Unmarshal([]byte(jsonString(b)), &jb)
+	return reflect.DeepEqual(ja, jb)
+}
+
+func jsonString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// SelfTestJob returns a Job that runs the pack tests in the cluster with the
+// given image of the controller, in the name of the given ServiceAccount,
+// which needs to be allowed to create and delete the parent resources and to
+// read the child resources. The given arguments are passed to the controller
+// in addition to the ones that select the in-cluster tests, i.e. they need to
+// select the StackDefinition and the resources of the pack.
+func SelfTestJob(name, namespace, image, serviceAccount string, args []string) *batchv1.Job {
+	backoff := int32(0)
+	return &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: batchv1.SchemeGroupVersion.String(), Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoff,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					ServiceAccountName: serviceAccount,
+					RestartPolicy:      corev1.RestartPolicyNever,
+					Containers: []corev1.Container{{
+						Name:  "test",
+						Image: image,
+						Args:  append(append([]string{}, args...), "--test", "--test-in-cluster"),
+					}},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	runtimefake "github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+const packTestYAML = `parent:
+  apiVersion: templatestacks.crossplane.io/v1alpha1
+  kind: MockResource
+  metadata:
+    name: cool
+children:
+- apiVersion: apps/v1
+  kind: Deployment
+  name: cool-app
+  namespace: default
+  fields:
+    spec.replicas: 3
+- apiVersion: v1
+  kind: Service
+  name: cool-app
+  namespace: default
+`
+
+func TestLoadPackTests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packtests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint:errcheck
+	if err := ioutil.WriteFile(filepath.Join(dir, "basic.yaml"), []byte(packTestYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests, err := LoadPackTests(dir)
+	if err != nil {
+		t.Fatalf("LoadPackTests(...): %s", err)
+	}
+	if diff := cmp.Diff(1, len(tests)); diff != "" {
+		t.Fatalf("LoadPackTests(...): -want tests, +got tests:\n%s", diff)
+	}
+	if diff := cmp.Diff("basic", tests[0].Name); diff != "" {
+		t.Errorf("LoadPackTests(...): -want name, +got name:\n%s", diff)
+	}
+	if diff := cmp.Diff("cool", tests[0].Parent.GetName()); diff != "" {
+		t.Errorf("LoadPackTests(...): -want parent name, +got parent name:\n%s", diff)
+	}
+
+	missing, err := LoadPackTests(filepath.Join(dir, "missing"))
+	if err != nil || len(missing) != 0 {
+		t.Errorf("LoadPackTests(...): a missing directory should have no tests, got %d tests and error %v", len(missing), err)
+	}
+}
+
+func TestRunPackTests(t *testing.T) {
+	deployment := func(replicas int64) resource.ChildResource {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("apps/v1")
+		u.SetKind("Deployment")
+		u.SetName("cool-app")
+		u.SetNamespace("default")
+		_ = unstructured.SetNestedField(u.Object, replicas, "spec", "replicas")
+		return u
+	}
+	child := []PackTestChild{{APIVersion: "apps/v1", Kind: "Deployment", Name: "cool-app", Namespace: "default", Fields: map[string]interface{}{"spec.replicas": float64(3)}}}
+
+	cases := map[string]struct {
+		reason   string
+		rendered []resource.ChildResource
+		want     []string
+	}{
+		"Passed": {
+			reason:   "A test whose child resources are rendered with the expected fields should pass",
+			rendered: []resource.ChildResource{deployment(3)},
+		},
+		"FieldMismatch": {
+			reason:   "A field with another value should fail the test",
+			rendered: []resource.ChildResource{deployment(1)},
+			want:     []string{"Deployment default/cool-app: field spec.replicas is 1 instead of 3"},
+		},
+		"Missing": {
+			reason: "A child resource that is not rendered should fail the test",
+			want:   []string{"Deployment default/cool-app: " + msgChildMissing},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mgr := &runtimefake.Manager{
+				Client: &test.MockClient{},
+				Scheme: runtimefake.SchemeWith(&fake.MockResource{}),
+			}
+			r := NewReconciler(mgr, fake.MockParentGVK,
+				WithEngine(EngineFunc(func(_ resource.ParentResource) ([]resource.ChildResource, error) {
+					return tc.rendered, nil
+				})),
+				WithChildResourcePatcher(),
+			)
+			parent := &unstructured.Unstructured{}
+			parent.SetGroupVersionKind(fake.MockParentGVK)
+			parent.SetName("cool")
+			results := r.RunPackTests(context.Background(), []PackTest{{Name: "basic", Parent: parent, Children: child}})
+			if diff := cmp.Diff(tc.want, results[0].Failures); diff != "" {
+				t.Errorf("\nReason: %s\nRunPackTests(...): -want failures, +got failures:\n%s", tc.reason, diff)
+			}
+			if results[0].Err != nil {
+				t.Errorf("\nReason: %s\nRunPackTests(...): unexpected error: %s", tc.reason, results[0].Err)
+			}
+		})
+	}
+}
+
+func TestSelfTestJob(t *testing.T) {
+	job := SelfTestJob("cool-self-test", "ns", "image:v1", "controller", []string{"--stack-definition-name", "cool"})
+	want := []string{"--stack-definition-name", "cool", "--test", "--test-in-cluster"}
+	if diff := cmp.Diff(want, job.Spec.Template.Spec.Containers[0].Args); diff != "" {
+		t.Errorf("SelfTestJob(...): -want args, +got args:\n%s", diff)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+)
+
+// PausedConfigMapKey is the key of the pause ConfigMap whose value decides
+// whether the reconciliation is paused.
+const PausedConfigMapKey = "paused"
+
+const (
+	errGetPauseConfigMap = "cannot get pause configmap"
+	errParsePaused       = "cannot parse paused value of pause configmap"
+)
+
+// ReasonPaused is the reason of the Synced condition of a parent resource
+// whose reconciliation is paused.
+const ReasonPaused v1alpha1.ConditionReason = "ReconcilePaused"
+
+// Paused returns a condition that indicates the reconciliation of the parent
+// resource is paused.
+func Paused() v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:               v1alpha1.TypeSynced,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonPaused,
+	}
+}
+
+// NewStaticPauseSwitch returns a PauseSwitch that always returns the given
+// value.
+func NewStaticPauseSwitch(paused bool) PauseSwitchFunc {
+	return func(_ context.Context) (bool, error) {
+		return paused, nil
+	}
+}
+
+// NewConfigMapPauseSwitch returns a new *ConfigMapPauseSwitch that reads the
+// ConfigMap with given name.
+func NewConfigMapPauseSwitch(c client.Reader, nn types.NamespacedName) *ConfigMapPauseSwitch {
+	return &ConfigMapPauseSwitch{kube: c, name: nn}
+}
+
+// ConfigMapPauseSwitch is a PauseSwitch that is controlled by the
+// PausedConfigMapKey of a ConfigMap so that the reconciliation can be paused
+// and resumed at runtime without restarting the controller. A missing
+// ConfigMap or key means the reconciliation is not paused.
+type ConfigMapPauseSwitch struct {
+	kube client.Reader
+	name types.NamespacedName
+}
+
+// Paused returns whether the reconciliation is paused.
+func (p *ConfigMapPauseSwitch) Paused(ctx context.Context) (bool, error) {
+	cm := &corev1.ConfigMap{}
+	err := p.kube.Get(ctx, p.name, cm)
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, errGetPauseConfigMap)
+	}
+	val, ok := cm.Data[PausedConfigMapKey]
+	if !ok {
+		return false, nil
+	}
+	paused, err := strconv.ParseBool(val)
+	return paused, errors.Wrap(err, errParsePaused)
+}
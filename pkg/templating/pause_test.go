@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+var (
+	_ PauseSwitch = &ConfigMapPauseSwitch{}
+	_ PauseSwitch = NewStaticPauseSwitch(false)
+)
+
+func TestConfigMapPauseSwitch(t *testing.T) {
+	withData := func(data map[string]string) test.MockGetFn {
+		return func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+			obj.(*corev1.ConfigMap).Data = data
+			return nil
+		}
+	}
+	type want struct {
+		paused bool
+		err    error
+	}
+	cases := map[string]struct {
+		reason string
+		kube   client.Reader
+		want
+	}{
+		"NotFound": {
+			reason: "Reconciliation should not be paused if the configmap does not exist",
+			kube:   &test.MockClient{MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, ""))},
+		},
+		"Paused": {
+			reason: "Reconciliation should be paused if the paused key is true",
+			kube:   &test.MockClient{MockGet: withData(map[string]string{PausedConfigMapKey: "true"})},
+			want:   want{paused: true},
+		},
+		"Resumed": {
+			reason: "Reconciliation should not be paused if the paused key is false",
+			kube:   &test.MockClient{MockGet: withData(map[string]string{PausedConfigMapKey: "false"})},
+		},
+		"GetFailed": {
+			reason: "It should return error if the configmap cannot be fetched",
+			kube:   &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+			want:   want{err: errors.Wrap(errBoom, errGetPauseConfigMap)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			paused, err := NewConfigMapPauseSwitch(tc.kube, types.NamespacedName{Name: "pause"}).Paused(context.Background())
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nPaused(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.paused, paused); diff != "" {
+				t.Errorf("\nReason: %s\nPaused(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+
+	"github.com/crossplane/templating-controller/pkg/operations"
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// pipeline returns the operations.Pipeline that runs the render, patch and
+// apply stages of the Reconciler with its current configuration. The render
+// input is converted into the served version and does not carry the result
+// annotations, and the child resources are applied in the order of their
+// dependencies.
+func (r *Reconciler) pipeline() *operations.Pipeline {
+	return operations.NewPipeline(
+		operations.WithEngine(r.templating),
+		operations.WithChildResourcePatchers(r.children.ChildResourcePatcherChain...),
+		operations.WithMiddleware(r.middleware...),
+		operations.WithValuesProviders(r.values...),
+		operations.WithCompositeKinds(r.composites),
+		operations.WithInputPreparer(operations.InputPreparerFunc(func(ctx context.Context, cr resource.ParentResource) (resource.ParentResource, error) {
+			return r.convert(ctx, withoutResultAnnotations(cr))
+		})),
+		operations.WithPreApplyHooks(r.hooks.PreApply...),
+		operations.WithPostApplyHooks(r.hooks.PostApply...),
+		operations.WithApplier(operations.ApplierFunc(func(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) (operations.ApplyOutcome, error) {
+			o := operations.ApplyOutcome{}
+			var err error
+			o.Waiting, o.NotReady, o.Deferred, o.RequeueAfter, err = r.applyChildren(ctx, cr, list)
+			return o, err
+		})),
+	)
+}
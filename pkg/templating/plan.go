@@ -0,0 +1,248 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errPlan          = "cannot plan the changes of the child resources"
+	errPlanDeletion  = "cannot plan the deletions of the post-apply hooks"
+	errPlanNotParent = "parent resource cannot be copied"
+)
+
+// FieldDiff is a field of a child resource whose value in the cluster differs
+// from the rendered one. Current is nil if the field is not set in the
+// cluster.
+type FieldDiff struct {
+	Path    string      `json:"path"`
+	Current interface{} `json:"current,omitempty"`
+	Desired interface{} `json:"desired"`
+}
+
+// PlannedChange is what would be done to a child resource.
+type PlannedChange struct {
+	Child     resource.ChildReference `json:"child"`
+	Operation ChildOperation          `json:"operation"`
+
+	// Desired is the rendered child resource. It is nil for deletions.
+	Desired resource.ChildResource `json:"desired,omitempty"`
+
+	// Diff is the list of fields that an update would change.
+	Diff []FieldDiff `json:"diff,omitempty"`
+}
+
+// ApplyPlan is the list of changes that a reconcile of a parent resource would
+// make to its child resources.
+type ApplyPlan struct {
+	Changes []PlannedChange `json:"changes"`
+}
+
+// Count returns the number of child resources that need the given operation.
+func (p ApplyPlan) Count(op ChildOperation) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Operation == op {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary returns a human readable summary of the plan.
+func (p ApplyPlan) Summary() string {
+	s := fmt.Sprintf("%d child resources would be created, %d would be updated and %d are up to date",
+		p.Count(OperationCreate), p.Count(OperationUpdate), p.Count(OperationNone))
+	if n := p.Count(OperationDelete); n > 0 {
+		s = fmt.Sprintf("%s, %d would be deleted", s, n)
+	}
+	return s
+}
+
+// String returns the changes of the plan in a human readable form, with the
+// fields that the updates would change. The child resources that are up to
+// date are left out.
+func (p ApplyPlan) String() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s\n", p.Summary())
+	for _, c := range p.Changes {
+		switch c.Operation {
+		case OperationCreate:
+			fmt.Fprintf(b, "+ %s\n", describeReference(c.Child))
+		case OperationDelete:
+			fmt.Fprintf(b, "- %s\n", describeReference(c.Child))
+		case OperationUpdate:
+			fmt.Fprintf(b, "~ %s\n", describeReference(c.Child))
+			for _, d := range c.Diff {
+				fmt.Fprintf(b, "    %s: %v -> %v\n", d.Path, d.Current, d.Desired)
+			}
+		}
+	}
+	return b.String()
+}
+
+// Plan returns the changes that reconciling the given parent resource would
+// make to its child resources, without making them. The child resources are
+// rendered like Render does and compared with the ones in the cluster. The
+// child resources of a parent resource that is being deleted would be
+// deleted, and so would the objects that the post-apply hooks that are
+// DeletionPlanners would delete, e.g. the superseded generated objects.
+func (r *Reconciler) Plan(ctx context.Context, cr resource.ParentResource) (ApplyPlan, error) {
+	// The generated names are resolved in the status of the parent resource,
+	// which should not change.
+	cr, ok := cr.DeepCopyObject().(resource.ParentResource)
+	if !ok {
+		return ApplyPlan{}, errors.New(errPlanNotParent)
+	}
+	list, err := r.Render(ctx, cr)
+	if err != nil {
+		return ApplyPlan{}, err
+	}
+	if r.optional != nil {
+		if list, _, err = r.optional.Filter(list); err != nil {
+			return ApplyPlan{}, errors.Wrap(err, errFilterOptional)
+		}
+	}
+	if err := ResolveGeneratedNames(cr, list); err != nil {
+		return ApplyPlan{}, errors.Wrap(err, errResolveGeneratedNames)
+	}
+	return r.plan(ctx, cr, list)
+}
+
+// plan returns the changes that applying the given rendered child resources
+// would make.
+func (r *Reconciler) plan(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) (ApplyPlan, error) {
+	p := ApplyPlan{Changes: make([]PlannedChange, 0, len(list))}
+	if meta.WasDeleted(cr) {
+		for _, o := range WithoutOrphaned(WithoutUngenerated(list)) {
+			c, err := planChild(ctx, r.client, o)
+			if err != nil {
+				return ApplyPlan{}, errors.Wrap(err, errPlan)
+			}
+			if c.Operation != OperationCreate {
+				p.Changes = append(p.Changes, PlannedChange{Child: c.Child, Operation: OperationDelete})
+			}
+		}
+		return p, nil
+	}
+	for _, o := range list {
+		c, err := planChild(ctx, r.client, o)
+		if err != nil {
+			return ApplyPlan{}, errors.Wrap(err, errPlan)
+		}
+		p.Changes = append(p.Changes, c)
+	}
+	for _, h := range r.hooks.PostApply {
+		dp, ok := h.(DeletionPlanner)
+		if !ok {
+			continue
+		}
+		deleted, err := dp.PlanDeletion(ctx, cr, list)
+		if err != nil {
+			return ApplyPlan{}, errors.Wrap(err, errPlanDeletion)
+		}
+		for _, o := range deleted {
+			p.Changes = append(p.Changes, PlannedChange{Child: resource.ReferenceTo(o), Operation: OperationDelete})
+		}
+	}
+	return p, nil
+}
+
+// planChild returns what would be done to the given child resource if it was
+// applied.
+func planChild(ctx context.Context, kube client.Reader, desired resource.ChildResource) (PlannedChange, error) {
+	c := PlannedChange{Child: resource.ReferenceTo(desired), Operation: OperationCreate, Desired: desired}
+	if usesGenerateName(desired) {
+		return c, nil
+	}
+	current := desired.DeepCopyObject()
+	err := kube.Get(ctx, types.NamespacedName{Name: desired.GetName(), Namespace: desired.GetNamespace()}, current)
+	if kerrors.IsNotFound(err) {
+		return c, nil
+	}
+	if err != nil {
+		return PlannedChange{}, errors.Wrap(err, errGetChildResource)
+	}
+	d, dok := desired.(interface{ UnstructuredContent() map[string]interface{} })
+	cur, cok := current.(interface{ UnstructuredContent() map[string]interface{} })
+	if !dok || !cok {
+		c.Operation = OperationUpdate
+		return c, nil
+	}
+	c.Diff = DiffFields("", d.UnstructuredContent(), cur.UnstructuredContent())
+	c.Operation = OperationNone
+	if len(c.Diff) > 0 {
+		c.Operation = OperationUpdate
+	}
+	return c, nil
+}
+
+// DiffFields returns the fields in desired whose values differ from the ones in
+// current, under the given path. The fields that exist only in current, such
+// as the ones populated by the API server, are ignored, as in IsSubset.
+// Arrays are compared as a whole if their lengths differ.
+func DiffFields(path string, desired, current interface{}) []FieldDiff {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		c, ok := current.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var result []FieldDiff
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			result = append(result, DiffFields(p, d[k], c[k])...)
+		}
+		return result
+	case []interface{}:
+		c, ok := current.([]interface{})
+		if !ok || len(c) != len(d) {
+			break
+		}
+		var result []FieldDiff
+		for i := range d {
+			result = append(result, DiffFields(fmt.Sprintf("%s[%d]", path, i), d[i], c[i])...)
+		}
+		return result
+	default:
+		if IsSubset(desired, current) {
+			return nil
+		}
+	}
+	return []FieldDiff{{Path: path, Current: current, Desired: desired}}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	runtimefake "github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestDiffFields(t *testing.T) {
+	desired := map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"tier": "web"}},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"ports":    []interface{}{map[string]interface{}{"port": int64(80)}},
+			"args":     []interface{}{"a", "b"},
+		},
+	}
+	current := map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"tier": "web"}, "uid": "1"},
+		"spec": map[string]interface{}{
+			"ports": []interface{}{map[string]interface{}{"port": int64(8080), "protocol": "TCP"}},
+			"args":  []interface{}{"a"},
+		},
+	}
+	want := []FieldDiff{
+		{Path: "spec.args", Current: []interface{}{"a"}, Desired: []interface{}{"a", "b"}},
+		{Path: "spec.ports[0].port", Current: int64(8080), Desired: int64(80)},
+		{Path: "spec.replicas", Desired: int64(3)},
+	}
+	if diff := cmp.Diff(want, DiffFields("", desired, current)); diff != "" {
+		t.Errorf("DiffFields(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	newChild := func() *fake.MockResource {
+		return fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("web", namespace), fake.WithAdditionalLabels(map[string]string{"tier": "web"}))
+	}
+	child := newChild()
+	inCluster := func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+		obj.(metav1.Object).SetLabels(map[string]string{"tier": "db"})
+		return nil
+	}
+	deleted := fake.NewMockResource(fake.WithGVK(fake.MockParentGVK))
+	now := metav1.Now()
+	deleted.SetDeletionTimestamp(&now)
+
+	cases := map[string]struct {
+		reason string
+		parent resource.ParentResource
+		get    test.MockGetFn
+		want   ApplyPlan
+	}{
+		"Update": {
+			reason: "Child resources that differ from the cluster should be updated with the fields that differ.",
+			parent: fake.NewMockResource(fake.WithGVK(fake.MockParentGVK)),
+			get:    inCluster,
+			want: ApplyPlan{Changes: []PlannedChange{{
+				Child:     resource.ReferenceTo(child),
+				Operation: OperationUpdate,
+				Desired:   child,
+				Diff:      []FieldDiff{{Path: "metadata.labels.tier", Current: "db", Desired: "web"}},
+			}}},
+		},
+		"Create": {
+			reason: "Child resources that do not exist should be created.",
+			parent: fake.NewMockResource(fake.WithGVK(fake.MockParentGVK)),
+			get:    test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+			want:   ApplyPlan{Changes: []PlannedChange{{Child: resource.ReferenceTo(child), Operation: OperationCreate, Desired: child}}},
+		},
+		"Deleted": {
+			reason: "The existing child resources of a deleted parent resource should be deleted.",
+			parent: deleted,
+			get:    inCluster,
+			want:   ApplyPlan{Changes: []PlannedChange{{Child: resource.ReferenceTo(child), Operation: OperationDelete}}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mgr := &runtimefake.Manager{
+				Client: &test.MockClient{MockGet: tc.get},
+				Scheme: runtimefake.SchemeWith(&fake.MockResource{}),
+			}
+			r := NewReconciler(mgr, fake.MockParentGVK,
+				WithEngine(EngineFunc(func(_ resource.ParentResource) ([]resource.ChildResource, error) {
+					return []resource.ChildResource{newChild()}, nil
+				})),
+				WithChildResourcePatcher(),
+			)
+			got, err := r.Plan(context.Background(), tc.parent)
+			if err != nil {
+				t.Fatalf("\n%s\nPlan(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nPlan(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPlanChild(t *testing.T) {
+	type args struct {
+		kube    client.Reader
+		desired resource.ChildResource
+	}
+	type want struct {
+		op  ChildOperation
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"Create": {
+			reason: "Child resources that do not exist should be planned to be created",
+			args: args{
+				kube:    &test.MockClient{MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, ""))},
+				desired: fake.NewMockResource(),
+			},
+			want: want{
+				op: OperationCreate,
+			},
+		},
+		"Update": {
+			reason: "Child resources that differ from the desired state should be planned to be updated",
+			args: args{
+				kube: &test.MockClient{MockGet: func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+					obj.(*fake.MockResource).SetLabels(map[string]string{"olala": "old"})
+					return nil
+				}},
+				desired: fake.NewMockResource(fake.WithAdditionalLabels(map[string]string{"olala": "new"})),
+			},
+			want: want{
+				op: OperationUpdate,
+			},
+		},
+		"None": {
+			reason: "Child resources whose desired fields all exist in the cluster should be planned as up to date",
+			args: args{
+				kube: &test.MockClient{MockGet: func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+					obj.(*fake.MockResource).SetResourceVersion("1")
+					return nil
+				}},
+				desired: fake.NewMockResource(),
+			},
+			want: want{
+				op: OperationNone,
+			},
+		},
+		"GetFailed": {
+			reason: "It should return error if the child resource cannot be fetched",
+			args: args{
+				kube:    &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				desired: fake.NewMockResource(),
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGetChildResource),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c, err := planChild(context.Background(), tc.args.kube, tc.args.desired)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nplanChild(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.op, c.Operation); diff != "" {
+				t.Errorf("\nReason: %s\nplanChild(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
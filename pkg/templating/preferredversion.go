@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const errMapChildKind = "cannot find the API versions of the child resource kind"
+
+// selectorRequired are the kinds whose pod selector is required in apps/v1
+// while the older versions defaulted it to the labels of the pod template.
+var selectorRequired = map[schema.GroupKind]bool{
+	{Group: "apps", Kind: "Deployment"}:  true,
+	{Group: "apps", Kind: "DaemonSet"}:   true,
+	{Group: "apps", Kind: "ReplicaSet"}:  true,
+	{Group: "apps", Kind: "StatefulSet"}: true,
+}
+
+// NewPreferredVersionConverter returns a new *PreferredVersionConverter that
+// looks the API versions up in the given RESTMapper, which is usually backed
+// by the discovery of the cluster.
+func NewPreferredVersionConverter(m meta.RESTMapper) *PreferredVersionConverter {
+	return &PreferredVersionConverter{mapper: m}
+}
+
+// PreferredVersionConverter converts the child resources whose API version the
+// cluster no longer serves, e.g. apps/v1beta2 after an upgrade of Kubernetes,
+// to the preferred version of their API group so that the templates keep
+// working until they are rewritten. Only the API version is changed, except
+// for the pod selector of the apps/v1 workloads that is set to the labels of
+// their pod template if it is missing, as the older versions did. The child
+// resources of kinds that the cluster does not serve at all, e.g. of CRDs
+// that are not installed yet, and the ones that target remote clusters are
+// not changed.
+type PreferredVersionConverter struct {
+	mapper meta.RESTMapper
+}
+
+// Patch patches the child resources with information in resource.ParentResource.
+func (c *PreferredVersionConverter) Patch(_ resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	for _, o := range list {
+		if IsRemote(o) {
+			continue
+		}
+		gvk := o.GetObjectKind().GroupVersionKind()
+		_, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err == nil {
+			continue
+		}
+		if !meta.IsNoMatchError(err) {
+			return nil, errors.Wrapf(err, "%s %s", errMapChildKind, gvk.GroupKind())
+		}
+		preferred, err := c.mapper.RESTMapping(gvk.GroupKind())
+		if meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s %s", errMapChildKind, gvk.GroupKind())
+		}
+		o.GetObjectKind().SetGroupVersionKind(preferred.GroupVersionKind)
+		if u, ok := o.(interface{ UnstructuredContent() map[string]interface{} }); ok && selectorRequired[gvk.GroupKind()] {
+			defaultSelector(u.UnstructuredContent())
+		}
+	}
+	return list, nil
+}
+
+// defaultSelector sets the pod selector of the given workload to the labels
+// of its pod template if it has no selector.
+func defaultSelector(content map[string]interface{}) {
+	if _, ok, _ := unstructured.NestedFieldNoCopy(content, "spec", "selector"); ok {
+		return
+	}
+	labels, ok, _ := unstructured.NestedStringMap(content, "spec", "template", "metadata", "labels")
+	if !ok || len(labels) == 0 {
+		return
+	}
+	matchLabels := make(map[string]interface{}, len(labels))
+	for k, v := range labels {
+		matchLabels[k] = v
+	}
+	_ = unstructured.SetNestedMap(content, matchLabels, "spec", "selector", "matchLabels")
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+var _ ChildResourcePatcher = &PreferredVersionConverter{}
+
+func TestPreferredVersionConverter(t *testing.T) {
+	apps := schema.GroupVersion{Group: "apps", Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{apps, {Version: "v1"}})
+	mapper.Add(apps.WithKind("Deployment"), meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+
+	object := func(apiVersion, kind string, spec map[string]interface{}) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetName("cool")
+		return u
+	}
+	template := map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "cool"}}}
+	cases := map[string]struct {
+		reason string
+		child  *unstructured.Unstructured
+		want   *unstructured.Unstructured
+	}{
+		"Unserved": {
+			reason: "A child resource whose version is not served should be converted to the preferred version with its selector defaulted",
+			child:  object("apps/v1beta2", "Deployment", map[string]interface{}{"template": template}),
+			want: object("apps/v1", "Deployment", map[string]interface{}{
+				"template": template,
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "cool"}},
+			}),
+		},
+		"Served": {
+			reason: "A child resource whose version is served should not be changed",
+			child:  object("v1", "ConfigMap", nil),
+			want:   object("v1", "ConfigMap", nil),
+		},
+		"UnknownKind": {
+			reason: "A child resource whose kind is not served at all should not be changed",
+			child:  object("example.org/v1", "App", nil),
+			want:   object("example.org/v1", "App", nil),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := NewPreferredVersionConverter(mapper).Patch(nil, []resource.ChildResource{tc.child})
+			if err != nil {
+				t.Fatalf("\n%s\nPatch(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff([]resource.ChildResource{tc.want}, got); diff != "" {
+				t.Errorf("\n%s\nPatch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
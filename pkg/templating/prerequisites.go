@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// ReasonInstallPrerequisitesMissing is the reason of the Synced condition of
+// a parent resource whose child resources are of kinds that the cluster does
+// not serve.
+const ReasonInstallPrerequisitesMissing v1alpha1.ConditionReason = "InstallPrerequisitesMissing"
+
+// InstallPrerequisitesMissing returns a condition that indicates the child
+// resources of the parent resource were not applied because the cluster does
+// not serve the APIs of some of them.
+func InstallPrerequisitesMissing(err error) v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:               v1alpha1.TypeSynced,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonInstallPrerequisitesMissing,
+		Message:            err.Error(),
+	}
+}
+
+// WithMissingAPICheck returns a ReconcilerOption that checks before applying
+// that the cluster serves the kinds of all child resources, looking them up
+// in the given RESTMapper, and reports all missing APIs at once otherwise.
+func WithMissingAPICheck(m meta.RESTMapper) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		WithPreApplyHook(NewMissingAPIChecker(m))(reconciler)
+	}
+}
+
+// NewMissingAPIChecker returns a new *MissingAPIChecker that looks the kinds
+// up in the given RESTMapper, which is usually backed by the discovery of the
+// cluster.
+func NewMissingAPIChecker(m meta.RESTMapper) *MissingAPIChecker {
+	return &MissingAPIChecker{mapper: m}
+}
+
+// MissingAPIChecker is a pre-apply Hook that fails with a
+// *resource.MissingAPIError that lists all the APIs that the cluster does not
+// serve, e.g. because a CustomResourceDefinition is not installed or an API
+// version was removed, together with the child resources that need them, so
+// that they do not come up one apply failure at a time. The child resources
+// that target remote clusters are not checked.
+type MissingAPIChecker struct {
+	mapper meta.RESTMapper
+}
+
+// Run returns a *resource.MissingAPIError if the cluster does not serve the
+// kinds of some of the child resources.
+func (c *MissingAPIChecker) Run(_ context.Context, _ resource.ParentResource, list []resource.ChildResource) error {
+	missing := map[schema.GroupVersionKind][]string{}
+	for _, o := range list {
+		if IsRemote(o) {
+			continue
+		}
+		gvk := o.GetObjectKind().GroupVersionKind()
+		_, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if meta.IsNoMatchError(err) {
+			missing[gvk] = append(missing[gvk], o.GetName())
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "%s %s", errMapChildKind, gvk.GroupKind())
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	problems := make([]string, 0, len(missing))
+	for gvk, names := range missing {
+		sort.Strings(names)
+		problems = append(problems, fmt.Sprintf("%s %s is not served, needed by %s", gvk.GroupVersion(), gvk.Kind, strings.Join(names, ", ")))
+	}
+	sort.Strings(problems)
+	return &resource.MissingAPIError{Problems: problems}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+var _ Hook = &MissingAPIChecker{}
+
+func TestMissingAPIChecker(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+
+	object := func(apiVersion, kind, name string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetName(name)
+		return u
+	}
+	remote := object("example.org/v1", "App", "remote")
+	remote.SetAnnotations(map[string]string{TargetClusterAnnotationKey: "edge"})
+	cases := map[string]struct {
+		reason string
+		list   []resource.ChildResource
+		want   error
+	}{
+		"Served": {
+			reason: "Child resources of served kinds should pass",
+			list:   []resource.ChildResource{object("v1", "ConfigMap", "config")},
+		},
+		"Missing": {
+			reason: "All missing APIs should be reported with the child resources that need them",
+			list: []resource.ChildResource{
+				object("v1", "ConfigMap", "config"),
+				object("example.org/v1", "App", "web"),
+				object("database.example.org/v1beta1", "PostgreSQLInstance", "db"),
+				object("example.org/v1", "App", "api"),
+				remote,
+			},
+			want: &resource.MissingAPIError{Problems: []string{
+				"database.example.org/v1beta1 PostgreSQLInstance is not served, needed by db",
+				"example.org/v1 App is not served, needed by api, web",
+			}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := NewMissingAPIChecker(mapper).Run(context.TODO(), nil, tc.list)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRun(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
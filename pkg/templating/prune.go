@@ -0,0 +1,144 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const errMarkPruneCandidate = "cannot mark generated object as candidate for deletion"
+
+// TypePruneConfirmation is the type of the condition that lists the child
+// resources that are going to be pruned once the deletion is confirmed.
+const TypePruneConfirmation v1alpha1.ConditionType = "PruneConfirmation"
+
+// Reasons of the PruneConfirmation condition.
+const (
+	ReasonPrunePending   v1alpha1.ConditionReason = "PendingConfirmation"
+	ReasonNothingToPrune v1alpha1.ConditionReason = "NothingToPrune"
+)
+
+// PrunePending returns a condition that indicates the given child resources
+// are candidates for deletion that wait for the confirmation with the given
+// token.
+func PrunePending(candidates []resource.ChildReference, token string) v1alpha1.Condition {
+	names := make([]string, len(candidates))
+	for i, ref := range candidates {
+		names[i] = describeReference(ref)
+	}
+	sort.Strings(names)
+	return v1alpha1.Condition{
+		Type:               TypePruneConfirmation,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonPrunePending,
+		Message:            fmt.Sprintf("annotate with %s=%s to delete: %s", ConfirmPruneAnnotationKey, token, strings.Join(names, ", ")),
+	}
+}
+
+// NothingToPrune returns a condition that indicates there are no child
+// resources waiting to be pruned.
+func NothingToPrune() v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:               TypePruneConfirmation,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonNothingToPrune,
+	}
+}
+
+// PruneToken returns the token that confirms the deletion of exactly the
+// given candidates, so that a confirmation does not carry over to the
+// candidates of a later, possibly bad, change of the templates.
+func PruneToken(candidates []resource.ChildReference) string {
+	names := make([]string, len(candidates))
+	for i, ref := range candidates {
+		names[i] = fmt.Sprintf("%s/%s/%s/%s", ref.APIVersion, ref.Kind, ref.Namespace, ref.Name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(names, "\n"))))[:10]
+}
+
+// pruneConfirmation holds back the deletions of the pruned child resources
+// until they are confirmed, either with the token of the candidates in
+// ConfirmPruneAnnotationKey of the parent resource or by the grace period
+// elapsing since the child resources became candidates. A grace period of
+// zero waits for the confirmation annotation only.
+type pruneConfirmation struct {
+	grace time.Duration
+	now   func() time.Time
+}
+
+// confirmed splits the given candidates into the ones whose deletion is
+// confirmed and the ones that wait.
+func (p *pruneConfirmation) confirmed(cr resource.ParentResource, candidates []*unstructured.Unstructured) (confirmed, pending []*unstructured.Unstructured) {
+	refs := make([]resource.ChildReference, len(candidates))
+	for i, o := range candidates {
+		refs[i] = resource.ReferenceTo(o)
+	}
+	if len(candidates) > 0 && cr.GetAnnotations()[ConfirmPruneAnnotationKey] == PruneToken(refs) {
+		return candidates, nil
+	}
+	for _, o := range candidates {
+		since, err := time.Parse(time.RFC3339, o.GetAnnotations()[PruneCandidateSinceAnnotationKey])
+		if err == nil && p.grace > 0 && !p.now().Before(since.Add(p.grace)) {
+			confirmed = append(confirmed, o)
+			continue
+		}
+		pending = append(pending, o)
+	}
+	return confirmed, pending
+}
+
+// mark annotates the given pending candidates with the time they became
+// candidates, if they are not annotated yet, and reports them in the
+// PruneConfirmation condition of the given parent resource, keeping the given
+// number of its transitions in the condition history.
+func (p *pruneConfirmation) mark(ctx context.Context, kube client.Client, cr resource.ParentResource, pending []*unstructured.Unstructured, history int) error {
+	if len(pending) == 0 {
+		return resource.SetConditionsWithHistory(cr, history, NothingToPrune())
+	}
+	refs := make([]resource.ChildReference, len(pending))
+	for i, o := range pending {
+		refs[i] = resource.ReferenceTo(o)
+		if _, ok := o.GetAnnotations()[PruneCandidateSinceAnnotationKey]; ok {
+			continue
+		}
+		patch := client.MergeFrom(o.DeepCopy())
+		meta.AddAnnotations(o, map[string]string{PruneCandidateSinceAnnotationKey: p.now().UTC().Format(time.RFC3339)})
+		if err := kube.Patch(ctx, o, patch); client.IgnoreNotFound(err) != nil {
+			return errors.Wrap(err, errMarkPruneCandidate)
+		}
+	}
+	return resource.SetConditionsWithHistory(cr, history, PrunePending(refs, PruneToken(refs)))
+}
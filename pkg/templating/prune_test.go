@@ -0,0 +1,143 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestGeneratedObjectCollectorConfirmation(t *testing.T) {
+	now := time.Now()
+	owner := fake.NewMockResource(fake.WithUID("parent"))
+	generated := func(name string, since time.Duration) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+		u.SetName(name)
+		u.SetLabels(map[string]string{resource.GeneratedFromLabelKey: "cool-parameters"})
+		meta.AddOwnerReference(&u, meta.AsController(meta.ReferenceTo(owner, owner.GroupVersionKind())))
+		if since > 0 {
+			meta.AddAnnotations(&u, map[string]string{PruneCandidateSinceAnnotationKey: now.Add(-since).UTC().Format(time.RFC3339)})
+		}
+		return u
+	}
+	latest := generated("cool-parameters-aaaaaaaaaa", 0)
+	superseded := generated("cool-parameters-bbbbbbbbbb", 0)
+	token := PruneToken([]resource.ChildReference{resource.ReferenceTo(&superseded)})
+
+	type want struct {
+		deleted []string
+		marked  []string
+		reason  string
+	}
+	cases := map[string]struct {
+		reason   string
+		existing []unstructured.Unstructured
+		confirm  string
+		grace    time.Duration
+		want
+	}{
+		"Unconfirmed": {
+			reason:   "The candidates should be marked and listed instead of deleted",
+			existing: []unstructured.Unstructured{latest, superseded},
+			want:     want{marked: []string{"cool-parameters-bbbbbbbbbb"}, reason: string(ReasonPrunePending)},
+		},
+		"ConfirmedByToken": {
+			reason:   "The candidates should be deleted once the parent resource is annotated with their token",
+			existing: []unstructured.Unstructured{latest, superseded},
+			confirm:  token,
+			want:     want{deleted: []string{"cool-parameters-bbbbbbbbbb"}, reason: string(ReasonNothingToPrune)},
+		},
+		"OtherToken": {
+			reason:   "A token of other candidates should not confirm the deletion",
+			existing: []unstructured.Unstructured{latest, superseded},
+			confirm:  "0123456789",
+			want:     want{marked: []string{"cool-parameters-bbbbbbbbbb"}, reason: string(ReasonPrunePending)},
+		},
+		"GraceElapsed": {
+			reason:   "The candidates should be deleted once the grace period has elapsed",
+			existing: []unstructured.Unstructured{latest, generated("cool-parameters-bbbbbbbbbb", 2*time.Hour)},
+			grace:    time.Hour,
+			want:     want{deleted: []string{"cool-parameters-bbbbbbbbbb"}, reason: string(ReasonNothingToPrune)},
+		},
+		"GracePending": {
+			reason:   "The candidates that were marked within the grace period should wait",
+			existing: []unstructured.Unstructured{latest, generated("cool-parameters-bbbbbbbbbb", time.Minute)},
+			grace:    time.Hour,
+			want:     want{reason: string(ReasonPrunePending)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cr := fake.NewMockResource(fake.WithUID("parent"))
+			if tc.confirm != "" {
+				meta.AddAnnotations(cr, map[string]string{ConfirmPruneAnnotationKey: tc.confirm})
+			}
+			var deleted, marked []string
+			kube := &test.MockClient{
+				MockList: test.NewMockListFn(nil, func(obj runtime.Object) error {
+					l := obj.(*unstructured.UnstructuredList)
+					for _, u := range tc.existing {
+						l.Items = append(l.Items, *u.DeepCopy())
+					}
+					return nil
+				}),
+				MockDelete: func(_ context.Context, obj runtime.Object, _ ...client.DeleteOption) error {
+					deleted = append(deleted, obj.(metav1.Object).GetName())
+					return nil
+				},
+				MockPatch: func(_ context.Context, obj runtime.Object, _ client.Patch, _ ...client.PatchOption) error {
+					marked = append(marked, obj.(metav1.Object).GetName())
+					return nil
+				},
+			}
+			c := NewGeneratedObjectCollector(kube, 0)
+			c.RequireConfirmation(tc.grace)
+			c.confirmation.now = func() time.Time { return now }
+			if err := c.Run(context.Background(), cr, []resource.ChildResource{latest.DeepCopy()}); err != nil {
+				t.Errorf("\nReason: %s\nRun(...): unexpected error: %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.deleted, deleted); diff != "" {
+				t.Errorf("\nReason: %s\nRun(...): -want deleted, +got deleted:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.marked, marked); diff != "" {
+				t.Errorf("\nReason: %s\nRun(...): -want marked, +got marked:\n%s", tc.reason, diff)
+			}
+			cond, err := resource.GetCondition(cr, TypePruneConfirmation)
+			if err != nil {
+				t.Fatalf("GetCondition(...): %s", err)
+			}
+			if diff := cmp.Diff(tc.want.reason, string(cond.Reason)); diff != "" {
+				t.Errorf("\nReason: %s\nRun(...): -want condition reason, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
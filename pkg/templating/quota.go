@@ -0,0 +1,252 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errListQuotas         = "cannot list resource quotas"
+	errGetQuotaChild      = "cannot get child resource to check quota"
+	errParsePodTemplate   = "cannot parse pod template of child resource"
+	errParseQuotaReplicas = "cannot parse replicas of child resource"
+)
+
+// ReasonQuotaExceeded is the reason of the Synced condition of a parent
+// resource whose child resources would exceed the ResourceQuotas of their
+// namespaces.
+const ReasonQuotaExceeded v1alpha1.ConditionReason = "QuotaExceeded"
+
+// QuotaExceeded returns a condition that indicates the child resources of the
+// parent resource were not applied because they would exceed the
+// ResourceQuotas of their namespaces.
+func QuotaExceeded(err error) v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:               v1alpha1.TypeSynced,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonQuotaExceeded,
+		Message:            err.Error(),
+	}
+}
+
+// workloadPodTemplates are the paths of the pod templates of the workload
+// kinds whose pods are counted against the ResourceQuotas, and of the field
+// that decides how many pods they run. DaemonSets are not counted since the
+// number of their pods depends on the nodes.
+var workloadPodTemplates = map[schema.GroupKind]struct {
+	spec     []string
+	replicas []string
+}{
+	{Kind: "Pod"}:                        {spec: []string{"spec"}},
+	{Group: "apps", Kind: "Deployment"}:  {spec: []string{"spec", "template", "spec"}, replicas: []string{"spec", "replicas"}},
+	{Group: "apps", Kind: "ReplicaSet"}:  {spec: []string{"spec", "template", "spec"}, replicas: []string{"spec", "replicas"}},
+	{Group: "apps", Kind: "StatefulSet"}: {spec: []string{"spec", "template", "spec"}, replicas: []string{"spec", "replicas"}},
+	{Group: "batch", Kind: "Job"}:        {spec: []string{"spec", "template", "spec"}, replicas: []string{"spec", "parallelism"}},
+}
+
+// NewQuotaChecker returns a new *QuotaChecker that reads the ResourceQuotas
+// and the existing child resources with the given reader.
+func NewQuotaChecker(c client.Reader) *QuotaChecker {
+	return &QuotaChecker{kube: c}
+}
+
+// QuotaChecker is a pre-apply Hook that adds up the requests and limits of
+// the pods that the rendered workloads would create in every namespace and
+// fails if they exceed the headroom of a ResourceQuota of the namespace, so
+// that the apply does not stop half-way with pods that fail admission. Only
+// the workloads that do not exist yet are counted since the pods of the
+// existing ones are already part of the used quota. The quotas with scopes
+// are not checked.
+type QuotaChecker struct {
+	kube client.Reader
+}
+
+// Run returns a *resource.QuotaError if the child resources would exceed a
+// ResourceQuota.
+func (q *QuotaChecker) Run(ctx context.Context, _ resource.ParentResource, list []resource.ChildResource) error {
+	needed := map[string]corev1.ResourceList{}
+	for _, o := range list {
+		if IsRemote(o) || o.GetNamespace() == "" {
+			continue
+		}
+		req, err := q.requests(ctx, o)
+		if err != nil {
+			return err
+		}
+		if req == nil {
+			continue
+		}
+		if needed[o.GetNamespace()] == nil {
+			needed[o.GetNamespace()] = corev1.ResourceList{}
+		}
+		addResources(needed[o.GetNamespace()], req)
+	}
+	namespaces := make([]string, 0, len(needed))
+	for ns := range needed {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	var problems []string
+	for _, ns := range namespaces {
+		quotas := &corev1.ResourceQuotaList{}
+		if err := q.kube.List(ctx, quotas, client.InNamespace(ns)); err != nil {
+			return errors.Wrapf(err, "%s in namespace %s", errListQuotas, ns)
+		}
+		for _, rq := range quotas.Items {
+			if len(rq.Spec.Scopes) > 0 || rq.Spec.ScopeSelector != nil {
+				continue
+			}
+			problems = append(problems, exceeded(rq, needed[ns])...)
+		}
+	}
+	if len(problems) > 0 {
+		return &resource.QuotaError{Problems: problems}
+	}
+	return nil
+}
+
+// requests returns the resources that the pods of the given child resource
+// would be counted with, or nil if it is not a workload that does not exist
+// yet.
+func (q *QuotaChecker) requests(ctx context.Context, o resource.ChildResource) (corev1.ResourceList, error) {
+	gvk := o.GetObjectKind().GroupVersionKind()
+	paths, ok := workloadPodTemplates[gvk.GroupKind()]
+	if !ok {
+		return nil, nil
+	}
+	u, ok := o.(interface{ UnstructuredContent() map[string]interface{} })
+	if !ok {
+		return nil, nil
+	}
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(gvk)
+	err := q.kube.Get(ctx, types.NamespacedName{Name: o.GetName(), Namespace: o.GetNamespace()}, existing)
+	if err == nil {
+		return nil, nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return nil, errors.Wrap(err, errGetQuotaChild)
+	}
+	replicas := int64(1)
+	if paths.replicas != nil {
+		r, ok, err := unstructured.NestedInt64(u.UnstructuredContent(), paths.replicas...)
+		if err != nil {
+			return nil, errors.Wrap(err, errParseQuotaReplicas)
+		}
+		if ok {
+			replicas = r
+		}
+	}
+	content, _, err := unstructured.NestedMap(u.UnstructuredContent(), paths.spec...)
+	if err != nil {
+		return nil, errors.Wrap(err, errParsePodTemplate)
+	}
+	spec := corev1.PodSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, &spec); err != nil {
+		return nil, errors.Wrap(err, errParsePodTemplate)
+	}
+	result := corev1.ResourceList{corev1.ResourcePods: *apiresource.NewQuantity(replicas, apiresource.DecimalSI)}
+	for name, qty := range podResources(spec) {
+		result[name] = *apiresource.NewMilliQuantity(qty.MilliValue()*replicas, qty.Format)
+	}
+	return result, nil
+}
+
+// podResources returns the requests and limits that a pod with the given spec
+// is counted with, under the names that ResourceQuotas use for them. Like the
+// scheduler, it counts the largest of the init containers if that is more
+// than all containers together.
+func podResources(spec corev1.PodSpec) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for _, c := range spec.Containers {
+		addResources(result, containerResources(c))
+	}
+	for _, c := range spec.InitContainers {
+		for name, qty := range containerResources(c) {
+			if cur, ok := result[name]; !ok || qty.Cmp(cur) > 0 {
+				result[name] = qty
+			}
+		}
+	}
+	return result
+}
+
+func containerResources(c corev1.Container) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for name, qty := range c.Resources.Requests {
+		result[corev1.ResourceName("requests."+string(name))] = qty
+		// The quotas of cpu and memory without prefix limit the requests.
+		result[name] = qty
+	}
+	for name, qty := range c.Resources.Limits {
+		result[corev1.ResourceName("limits."+string(name))] = qty
+	}
+	return result
+}
+
+func addResources(into, from corev1.ResourceList) {
+	for name, qty := range from {
+		cur := into[name]
+		cur.Add(qty)
+		into[name] = cur
+	}
+}
+
+// exceeded returns the resources of the given quota whose headroom is less
+// than the given needed resources.
+func exceeded(rq corev1.ResourceQuota, needed corev1.ResourceList) []string {
+	names := make([]string, 0, len(rq.Spec.Hard))
+	for name := range rq.Spec.Hard {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	var result []string
+	for _, n := range names {
+		name := corev1.ResourceName(n)
+		need, ok := needed[name]
+		if !ok {
+			continue
+		}
+		left := rq.Spec.Hard[name].DeepCopy()
+		if used, ok := rq.Status.Used[name]; ok {
+			left.Sub(used)
+		}
+		if need.Cmp(left) > 0 {
+			result = append(result, fmt.Sprintf("namespace %s: quota %s: %s needs %s more but %s is left", rq.GetNamespace(), rq.GetName(), name, need.String(), left.String()))
+		}
+	}
+	return result
+}
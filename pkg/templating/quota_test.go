@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+var _ Hook = &QuotaChecker{}
+
+func TestQuotaChecker(t *testing.T) {
+	deployment := func(name string, replicas int64, cpu string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"replicas": replicas,
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{
+								"name":      "app",
+								"resources": map[string]interface{}{"requests": map[string]interface{}{"cpu": cpu}},
+							},
+						},
+					},
+				},
+			},
+		}}
+		u.SetAPIVersion("apps/v1")
+		u.SetKind("Deployment")
+		u.SetNamespace("apps")
+		u.SetName(name)
+		return u
+	}
+	quota := corev1.ResourceQuota{
+		Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+			corev1.ResourceRequestsCPU: apiresource.MustParse("2"),
+			corev1.ResourcePods:        apiresource.MustParse("10"),
+		}},
+		Status: corev1.ResourceQuotaStatus{Used: corev1.ResourceList{
+			corev1.ResourceRequestsCPU: apiresource.MustParse("1"),
+		}},
+	}
+	quota.SetName("compute")
+	quota.SetNamespace("apps")
+	kube := &test.MockClient{
+		MockGet: test.NewMockGetFn(nil, func(obj runtime.Object) error {
+			if obj.(*unstructured.Unstructured).GetName() == "existing" {
+				return nil
+			}
+			return kerrors.NewNotFound(schema.GroupResource{}, "")
+		}),
+		MockList: test.NewMockListFn(nil, func(obj runtime.Object) error {
+			obj.(*corev1.ResourceQuotaList).Items = []corev1.ResourceQuota{quota}
+			return nil
+		}),
+	}
+	cases := map[string]struct {
+		reason string
+		list   []resource.ChildResource
+		want   error
+	}{
+		"WithinQuota": {
+			reason: "Workloads that fit the headroom of the quota should pass",
+			list:   []resource.ChildResource{deployment("web", 2, "500m")},
+		},
+		"ExceedsQuota": {
+			reason: "Workloads whose pods together exceed the headroom of the quota should fail",
+			list:   []resource.ChildResource{deployment("web", 2, "500m"), deployment("worker", 1, "250m")},
+			want:   &resource.QuotaError{Problems: []string{"namespace apps: quota compute: requests.cpu needs 1250m more but 1 is left"}},
+		},
+		"ExistingWorkload": {
+			reason: "Workloads that already exist should not be counted since their pods are already in the used quota",
+			list:   []resource.ChildResource{deployment("existing", 10, "1")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := NewQuotaChecker(kube).Run(context.TODO(), nil, tc.list)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRun(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	errMapKind = "cannot map kind to its API resource"
+)
+
+// rbacVerbs are the verbs that the controller uses on the parent and the
+// child resources.
+var rbacVerbs = []string{"get", "list", "watch", "create", "update", "patch", "delete"}
+
+// RBACOptions configures the RBAC objects that GenerateRBAC generates.
+type RBACOptions struct {
+	// Name that the names of the generated objects start with.
+	Name string
+
+	// Namespaces to generate a Role for the namespaced kinds in. A
+	// ClusterRole is generated for them if none is given, to be bound in the
+	// namespaces that the controller deploys to or in the whole cluster.
+	Namespaces []string
+
+	// AggregationLabels are added to the generated ClusterRoles, e.g.
+	// rbac.authorization.k8s.io/aggregate-to-admin=true, so that they are
+	// aggregated into other ClusterRoles.
+	AggregationLabels map[string]string
+}
+
+// GenerateRBAC returns the ClusterRoles and Roles that grant the controller
+// of the given parent kind the least privilege it needs on the parent
+// resources and the given kinds of child resources, using the given mapper to
+// tell the API resources of the kinds and whether they are namespaced. The
+// cluster scoped kinds are granted by a ClusterRole named <name>-cluster and
+// the namespaced ones by Roles named <name>-namespaced in the namespaces of
+// the options, or by a ClusterRole of the same name if there are none. The
+// kinds whose templates are not known before render cannot be granted, so
+// they have to be given explicitly.
+func GenerateRBAC(o RBACOptions, m meta.RESTMapper, parent schema.GroupVersionKind, kinds ...schema.GroupVersionKind) ([]runtime.Object, error) {
+	namespaced := map[string]map[string]bool{}
+	cluster := map[string]map[string]bool{}
+	add := func(gvk schema.GroupVersionKind, subresources ...string) error {
+		mapping, err := m.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return errors.Wrapf(err, "%s: %s", errMapKind, gvk)
+		}
+		rules := cluster
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			rules = namespaced
+		}
+		if rules[gvk.Group] == nil {
+			rules[gvk.Group] = map[string]bool{}
+		}
+		rules[gvk.Group][mapping.Resource.Resource] = true
+		for _, s := range subresources {
+			rules[gvk.Group][mapping.Resource.Resource+"/"+s] = true
+		}
+		return nil
+	}
+	if err := add(parent, "status", "finalizers"); err != nil {
+		return nil, err
+	}
+	for _, gvk := range kinds {
+		if err := add(gvk); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []runtime.Object
+	if len(cluster) > 0 {
+		result = append(result, clusterRole(o.Name+"-cluster", o.AggregationLabels, policyRules(cluster)))
+	}
+	if len(namespaced) == 0 {
+		return result, nil
+	}
+	if len(o.Namespaces) == 0 {
+		return append(result, clusterRole(o.Name+"-namespaced", o.AggregationLabels, policyRules(namespaced))), nil
+	}
+	for _, ns := range o.Namespaces {
+		result = append(result, &rbacv1.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+			ObjectMeta: metav1.ObjectMeta{Name: o.Name + "-namespaced", Namespace: ns},
+			Rules:      policyRules(namespaced),
+		})
+	}
+	return result, nil
+}
+
+func clusterRole(name string, labels map[string]string, rules []rbacv1.PolicyRule) *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Rules:      rules,
+	}
+}
+
+// policyRules returns a rule per API group, sorted by group and resource so
+// that the generated objects do not change between runs.
+func policyRules(resources map[string]map[string]bool) []rbacv1.PolicyRule {
+	groups := make([]string, 0, len(resources))
+	for g := range resources {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	rules := make([]rbacv1.PolicyRule, 0, len(groups))
+	for _, g := range groups {
+		names := make([]string, 0, len(resources[g]))
+		for r := range resources[g] {
+			names = append(names, r)
+		}
+		sort.Strings(names)
+		rules = append(rules, rbacv1.PolicyRule{APIGroups: []string{g}, Resources: names, Verbs: rbacVerbs})
+	}
+	return rules
+}
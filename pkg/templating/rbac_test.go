@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestGenerateRBAC(t *testing.T) {
+	nsKind := schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	m := meta.NewDefaultRESTMapper(nil)
+	m.Add(fake.MockParentGVK, meta.RESTScopeNamespace)
+	m.Add(nsKind, meta.RESTScopeRoot)
+	m.Add(deployment, meta.RESTScopeNamespace)
+
+	cluster := []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: rbacVerbs}}
+	namespaced := []rbacv1.PolicyRule{
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: rbacVerbs},
+		{APIGroups: []string{fake.MockParentGVK.Group}, Resources: []string{"mockresources", "mockresources/finalizers", "mockresources/status"}, Verbs: rbacVerbs},
+	}
+	labels := map[string]string{"rbac.authorization.k8s.io/aggregate-to-admin": "true"}
+	clusterRole := func(name string, rules []rbacv1.PolicyRule) *rbacv1.ClusterRole {
+		return &rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Rules:      rules,
+		}
+	}
+
+	cases := map[string]struct {
+		reason string
+		o      RBACOptions
+		kinds  []schema.GroupVersionKind
+		want   []runtime.Object
+	}{
+		"ClusterRoles": {
+			reason: "Cluster scoped and namespaced kinds should be granted by separate ClusterRoles",
+			o:      RBACOptions{Name: "pack", AggregationLabels: labels},
+			kinds:  []schema.GroupVersionKind{deployment, nsKind},
+			want: []runtime.Object{
+				clusterRole("pack-cluster", cluster),
+				clusterRole("pack-namespaced", namespaced),
+			},
+		},
+		"Roles": {
+			reason: "Namespaced kinds should be granted by a Role in every given namespace",
+			o:      RBACOptions{Name: "pack", Namespaces: []string{"apps", "web"}},
+			kinds:  []schema.GroupVersionKind{deployment},
+			want: []runtime.Object{
+				&rbacv1.Role{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+					ObjectMeta: metav1.ObjectMeta{Name: "pack-namespaced", Namespace: "apps"},
+					Rules:      namespaced,
+				},
+				&rbacv1.Role{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+					ObjectMeta: metav1.ObjectMeta{Name: "pack-namespaced", Namespace: "web"},
+					Rules:      namespaced,
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := GenerateRBAC(tc.o, m, fake.MockParentGVK, tc.kinds...)
+			if err != nil {
+				t.Fatalf("GenerateRBAC(...): %s", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nGenerateRBAC(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errParseReadinessTimeout = "cannot parse readiness timeout"
+	errParseApplyTimeout     = "cannot parse apply timeout"
+	errApplyTimedOut         = "child resource was not applied in time"
+	errReadinessTimedOut     = "child resource did not become ready in time"
+
+	msgReadinessTimedOut = "child resources did not become ready in time"
+)
+
+// ReasonDegraded is the reason of the Ready condition of a parent resource
+// whose child resources did not become ready within their readiness timeout.
+const ReasonDegraded v1alpha1.ConditionReason = "Degraded"
+
+// Degraded returns a condition that indicates the parent resource is not
+// ready because some of its child resources did not become ready in time.
+func Degraded() v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:               v1alpha1.TypeReady,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonDegraded,
+	}
+}
+
+// ReadinessTimeout returns the readiness timeout that the given child resource
+// declares with ReadinessTimeoutAnnotationKey, or with
+// ApplyTimeoutAnnotationKey if it declares only that, or the given default if
+// it declares neither.
+func ReadinessTimeout(o metav1.Object, def time.Duration) (time.Duration, error) {
+	val, ok := o.GetAnnotations()[ReadinessTimeoutAnnotationKey]
+	if !ok {
+		if d, err := ApplyTimeout(o); err != nil || d != 0 {
+			return d, err
+		}
+		return def, nil
+	}
+	d, err := time.ParseDuration(val)
+	return d, errors.Wrap(err, errParseReadinessTimeout)
+}
+
+// ApplyTimeout returns the maximum time that the given child resource declares
+// with ApplyTimeoutAnnotationKey for its apply and for becoming ready after
+// it, or zero if it does not declare one. A child resource that exceeds it is
+// recorded as failed and makes the parent resource degraded, which also stops
+// the child resources that depend on it from waiting forever.
+func ApplyTimeout(o metav1.Object) (time.Duration, error) {
+	val, ok := o.GetAnnotations()[ApplyTimeoutAnnotationKey]
+	if !ok {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(val)
+	return d, errors.Wrap(err, errParseApplyTimeout)
+}
+
+// NewStatusReadinessChecker returns a new StatusReadinessChecker.
+func NewStatusReadinessChecker() StatusReadinessChecker {
+	return StatusReadinessChecker{}
+}
+
+// StatusReadinessChecker decides on the readiness of a child resource by its
+// status conditions. A child resource with a Ready condition is ready only if
+// that condition is true. Similarly, the Available condition of workloads like
+// Deployments and the Complete condition of Jobs are considered. The child
+// resources with none of these conditions are ready as soon as they exist.
+type StatusReadinessChecker struct{}
+
+// IsReady returns whether the given child resource is ready.
+func (s StatusReadinessChecker) IsReady(_ context.Context, o resource.ChildResource) (bool, error) {
+	u, ok := o.(interface{ UnstructuredContent() map[string]interface{} })
+	if !ok {
+		return true, nil
+	}
+	conditions, _, err := unstructured.NestedSlice(u.UnstructuredContent(), "status", "conditions")
+	if err != nil {
+		return false, err
+	}
+	for _, ct := range []string{"Ready", "Available", "Complete"} {
+		for _, c := range conditions {
+			m, ok := c.(map[string]interface{})
+			if !ok || m["type"] != ct {
+				continue
+			}
+			return m["status"] == string(corev1.ConditionTrue), nil
+		}
+	}
+	return true, nil
+}
+
+func newReadinessTracker(timeout time.Duration) *readinessTracker {
+	return &readinessTracker{
+		timeout: timeout,
+		since:   map[types.UID]map[string]time.Time{},
+		now:     time.Now,
+	}
+}
+
+// readinessTracker records since when the child resources of every parent
+// resource are not ready so that the ones that exceed their readiness timeout
+// can be reported. The records are kept in memory, so the timeouts start over
+// when the controller restarts.
+type readinessTracker struct {
+	timeout time.Duration
+	mu      sync.Mutex
+	since   map[types.UID]map[string]time.Time
+	now     func() time.Time
+}
+
+// Observe records whether the given child resource of the given parent
+// resource is ready.
+func (t *readinessTracker) Observe(cr resource.ParentResource, o resource.ChildResource, ready bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	children := t.since[cr.GetUID()]
+	if ready {
+		delete(children, ChildKey(o))
+		return
+	}
+	if children == nil {
+		children = map[string]time.Time{}
+		t.since[cr.GetUID()] = children
+	}
+	if _, ok := children[ChildKey(o)]; !ok {
+		children[ChildKey(o)] = t.now()
+	}
+}
+
+// Exceeded returns the ones among the given not ready child resources that
+// have not been ready for longer than their readiness timeout. A timeout of
+// zero means waiting forever.
+func (t *readinessTracker) Exceeded(cr resource.ParentResource, notReady []resource.ChildResource) ([]resource.ChildResource, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var result []resource.ChildResource
+	for _, o := range notReady {
+		timeout, err := ReadinessTimeout(o, t.timeout)
+		if err != nil {
+			return nil, err
+		}
+		since, ok := t.since[cr.GetUID()][ChildKey(o)]
+		if timeout == 0 || !ok || t.now().Sub(since) < timeout {
+			continue
+		}
+		result = append(result, o)
+	}
+	return result, nil
+}
+
+// Forget removes the records of the given parent resource.
+func (t *readinessTracker) Forget(cr resource.ParentResource) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.since, cr.GetUID())
+}
+
+// timedOutMessage returns the message of the Degraded condition that lists the
+// given child resources.
+func timedOutMessage(list []resource.ChildResource) string {
+	names := make([]string, len(list))
+	for i, o := range list {
+		names[i] = fmt.Sprintf("%s/%s", o.GetObjectKind().GroupVersionKind().Kind, o.GetName())
+	}
+	return fmt.Sprintf("%s: %s", msgReadinessTimedOut, strings.Join(names, ", "))
+}
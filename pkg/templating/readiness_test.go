@@ -0,0 +1,159 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ ReadinessChecker = StatusReadinessChecker{}
+)
+
+func withStatusCondition(ct, status string) fake.MockResourceOption {
+	return func(r *fake.MockResource) {
+		conditions, _, _ := unstructured.NestedSlice(r.Object, "status", "conditions")
+		conditions = append(conditions, map[string]interface{}{"type": ct, "status": status})
+		_ = unstructured.SetNestedSlice(r.Object, conditions, "status", "conditions")
+	}
+}
+
+func TestStatusReadinessChecker(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		o      resource.ChildResource
+		ready  bool
+	}{
+		"NoConditions": {
+			reason: "Child resources without conditions should be ready",
+			o:      fake.NewMockResource(),
+			ready:  true,
+		},
+		"ReadyFalse": {
+			reason: "Child resources with false Ready condition should not be ready",
+			o:      fake.NewMockResource(withStatusCondition("Ready", "False")),
+			ready:  false,
+		},
+		"ReadyPrecedesAvailable": {
+			reason: "Ready condition should be preferred over Available condition",
+			o:      fake.NewMockResource(withStatusCondition("Available", "False"), withStatusCondition("Ready", "True")),
+			ready:  true,
+		},
+		"Available": {
+			reason: "Child resources with true Available condition should be ready",
+			o:      fake.NewMockResource(withStatusCondition("Progressing", "False"), withStatusCondition("Available", "True")),
+			ready:  true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := NewStatusReadinessChecker().IsReady(context.Background(), tc.o)
+			if err != nil {
+				t.Errorf("\nReason: %s\nIsReady(...): unexpected error: %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.ready, got); diff != "" {
+				t.Errorf("\nReason: %s\nIsReady(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestReadinessTracker_Exceeded(t *testing.T) {
+	observed := time.Now()
+	database := fake.NewMockResource(fake.WithNamespaceName("database", "ns"), fake.WithAdditionalAnnotations(map[string]string{ReadinessTimeoutAnnotationKey: "30m"}))
+	deployment := fake.NewMockResource(fake.WithNamespaceName("deployment", "ns"))
+	certificate := fake.NewMockResource(fake.WithNamespaceName("certificate", "ns"), fake.WithAdditionalAnnotations(map[string]string{ApplyTimeoutAnnotationKey: "10m"}))
+	type args struct {
+		timeout time.Duration
+		now     time.Time
+		list    []resource.ChildResource
+	}
+	cases := map[string]struct {
+		reason string
+		args
+		want []resource.ChildResource
+	}{
+		"NoTimeout": {
+			reason: "Child resources should not time out if neither a default nor an annotation is given",
+			args: args{
+				now:  observed.Add(24 * time.Hour),
+				list: []resource.ChildResource{deployment},
+			},
+		},
+		"DefaultTimeout": {
+			reason: "Child resources without an annotation should time out after the default timeout",
+			args: args{
+				timeout: 2 * time.Minute,
+				now:     observed.Add(5 * time.Minute),
+				list:    []resource.ChildResource{deployment, database},
+			},
+			want: []resource.ChildResource{deployment},
+		},
+		"AnnotatedTimeout": {
+			reason: "Child resources with an annotation should time out after the annotated timeout",
+			args: args{
+				timeout: 2 * time.Minute,
+				now:     observed.Add(time.Hour),
+				list:    []resource.ChildResource{deployment, database},
+			},
+			want: []resource.ChildResource{deployment, database},
+		},
+		"ApplyTimeout": {
+			reason: "Child resources with only an apply timeout should time out after the apply timeout instead of the default",
+			args: args{
+				timeout: 2 * time.Minute,
+				now:     observed.Add(5 * time.Minute),
+				list:    []resource.ChildResource{deployment, certificate},
+			},
+			want: []resource.ChildResource{deployment},
+		},
+		"ApplyTimeoutExceeded": {
+			reason: "Child resources with only an apply timeout should time out once it is exceeded",
+			args: args{
+				now:  observed.Add(15 * time.Minute),
+				list: []resource.ChildResource{deployment, certificate},
+			},
+			want: []resource.ChildResource{certificate},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cr := fake.NewMockResource(fake.WithUID("foo"))
+			tr := newReadinessTracker(tc.args.timeout)
+			tr.now = func() time.Time { return observed }
+			for _, o := range tc.args.list {
+				tr.Observe(cr, o, false)
+			}
+			tr.now = func() time.Time { return tc.args.now }
+			got, err := tr.Exceeded(cr, tc.args.list)
+			if err != nil {
+				t.Errorf("\nReason: %s\nExceeded(...): unexpected error: %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\nReason: %s\nExceeded(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	rresource "github.com/crossplane/crossplane-runtime/pkg/resource"
@@ -37,6 +38,10 @@ import (
 	"github.com/crossplane/templating-controller/pkg/resource"
 )
 
+// FieldOwner is the default field manager that the child resources are applied
+// in the name of.
+const FieldOwner = "templating-controller"
+
 const (
 	reconcileTimeout = 1 * time.Minute
 
@@ -57,8 +62,30 @@ const (
 	errRemoveFinalizer       = "cannot remove finalizer from parent resource"
 	errApply                 = "apply failed"
 	errGetChildResource      = "could not get child resource"
+	errPreRenderHook         = "pre-render hook failed"
+	errPreApplyHook          = "pre-apply hook failed"
+	errPostApplyHook         = "post-apply hook failed"
+	errPreRenderJobs         = "pre-render hook jobs failed"
+	errDependencies          = "cannot resolve dependencies of child resources"
+	errReadinessCheck        = "cannot check readiness of child resource"
+	errReport                = "cannot report changes to child resources"
+	errRollback              = "cannot roll back to the requested revision"
+	errRecordRevision        = "cannot record revision"
+	errRenderInput           = "cannot prepare the input of the render"
+	errPauseSwitch           = "cannot check whether reconciliation is paused"
+	errPublish               = "cannot publish child resources"
+	errResolveGeneratedNames = "cannot resolve generated names of child resources"
+
+	msgWaitingForDeletion     = "waiting for deletion of child resources"
+	msgParentDeleted          = "child resources are deleted with the parent resource"
+	msgWaitingForDependencies = "waiting for dependencies of child resources to be ready"
+	msgWaitingForReadiness    = "waiting for child resources to be ready"
+	msgWaitingForHookJobs     = "waiting for hook jobs to complete"
+	msgReportOnly             = "report-only mode"
+	msgPublished              = "child resources are published"
 
-	msgWaitingForDeletion = "waiting for deletion of child resources"
+	reasonReportOnly event.Reason = "ReportOnly"
+	reasonPublished  event.Reason = "PublishedChildResources"
 )
 
 // ReconcilerOption is used to provide necessary changes to templating
@@ -73,6 +100,81 @@ func WithChildResourceDeleter(d ChildResourceDeleter) ReconcilerOption {
 	}
 }
 
+// WithPreDeletionStep returns a ReconcilerOption that adds the given
+// ChildResourceDeleters to be run before the configured ChildResourceDeleter,
+// e.g. to scale workloads down or to snapshot volumes. Every step blocks the
+// ones after it as long as it reports child resources that are still being
+// deleted.
+func WithPreDeletionStep(d ...ChildResourceDeleter) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		chain := append(ChildResourceDeleterChain{}, d...)
+		reconciler.children.ChildResourceDeleter = append(chain, reconciler.children.ChildResourceDeleter)
+	}
+}
+
+// WithChildFinalizer returns a ReconcilerOption that adds the given finalizer
+// to the child resources of the given kinds and runs the given cleanup Hook
+// with them once they are being deleted, before the finalizer is removed.
+func WithChildFinalizer(finalizer string, cleanup Hook, kinds ...schema.GroupKind) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		f := NewChildFinalizer(reconciler.clusters, finalizer, cleanup, kinds...)
+		WithAdditionalChildResourcePatcher(f)(reconciler)
+		WithPreDeletionStep(f)(reconciler)
+	}
+}
+
+// WithPatchStrategy returns a ReconcilerOption that makes the existing child
+// resources of the given kind be updated with the given PatchStrategy instead
+// of a JSON merge patch.
+func WithPatchStrategy(gk schema.GroupKind, s PatchStrategy) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		strategyApplicator(reconciler).Use(gk, s)
+	}
+}
+
+// WithPatchStrategyOverrides returns a ReconcilerOption that lets parent
+// resources and child resources choose the PatchStrategy of their child
+// resources, and of themselves, with the PatchStrategyAnnotationKey
+// annotation, overriding the one of their kind.
+func WithPatchStrategyOverrides() ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		strategyApplicator(reconciler)
+		WithAdditionalChildResourcePatcher(NewPatchStrategyPropagator())(reconciler)
+	}
+}
+
+// WithFieldManager returns a ReconcilerOption that makes the child resources
+// be applied in the name of the given field manager instead of
+// templating-controller, and the conflicts with other field managers be
+// forced, when they are applied server-side, only if force is true.
+func WithFieldManager(name string, force bool) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		strategyApplicator(reconciler).SetFieldManager(name, force)
+	}
+}
+
+// strategyApplicator returns the *StrategyApplicator of the reconciler,
+// replacing the default applicator with one if needed.
+func strategyApplicator(reconciler *Reconciler) *StrategyApplicator {
+	a, ok := reconciler.client.Applicator.(*StrategyApplicator)
+	if !ok {
+		a = NewStrategyApplicator(reconciler.clusters, FieldOwner)
+		reconciler.client.Applicator = a
+	}
+	return a
+}
+
+// WithMaintenanceWindows returns a ReconcilerOption that makes the existing
+// child resources of all parent resources not be changed during the given
+// maintenance windows. The parent resources can declare more windows with
+// MaintenanceWindowAnnotationKey. The given policy decides whether the child
+// resources may still be created and deleted during the windows of both.
+func WithMaintenanceWindows(p MaintenancePolicy, w ...MaintenanceWindow) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.maintenance = maintenanceSchedule{windows: w, policy: p}
+	}
+}
+
 // WithFinalizer returns a ReconcilerOption that changes the
 // Finalizer.
 func WithFinalizer(f rresource.Finalizer) ReconcilerOption {
@@ -89,6 +191,14 @@ func WithChildResourcePatcher(op ...ChildResourcePatcher) ReconcilerOption {
 	}
 }
 
+// WithAdditionalChildResourcePatcher returns a ReconcilerOption that appends
+// the given ChildResourcePatchers to the ones that are already configured.
+func WithAdditionalChildResourcePatcher(op ...ChildResourcePatcher) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.children.ChildResourcePatcherChain = append(reconciler.children.ChildResourcePatcherChain, op...)
+	}
+}
+
 // WithEngine returns a ReconcilerOption that changes the
 // templating engine.
 func WithEngine(eng Engine) ReconcilerOption {
@@ -97,6 +207,336 @@ func WithEngine(eng Engine) ReconcilerOption {
 	}
 }
 
+// WithPreRenderHook returns a ReconcilerOption that adds the given hooks to
+// the list of hooks that are run before the render.
+func WithPreRenderHook(h ...Hook) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.hooks.PreRender = append(reconciler.hooks.PreRender, h...)
+	}
+}
+
+// WithPreApplyHook returns a ReconcilerOption that adds the given hooks to
+// the list of hooks that are run with the rendered child resources right
+// before they are applied.
+func WithPreApplyHook(h ...Hook) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.hooks.PreApply = append(reconciler.hooks.PreApply, h...)
+	}
+}
+
+// WithNamespaceCreation returns a ReconcilerOption that makes the reconciler
+// create the namespaces that the child resources target if they do not exist
+// before applying the child resources.
+func WithNamespaceCreation() ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		WithPreApplyHook(NewNamespaceCreator(reconciler.clusters))(reconciler)
+	}
+}
+
+// WithAdmissionSimulation returns a ReconcilerOption that checks the child
+// resources before applying them for the problems that would make their apply
+// fail halfway, i.e. missing namespaces, invalid labels and selectors, and the
+// labels that the given rules require. It needs to come after
+// WithNamespaceCreation, if both are used, so that the namespaces are created
+// before they are checked.
+func WithAdmissionSimulation(rules ...LabelRule) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		WithPreApplyHook(NewAdmissionSimulator(reconciler.clusters, rules...))(reconciler)
+	}
+}
+
+// WithQuotaCheck returns a ReconcilerOption that makes the reconciler check
+// that the child resources would not exceed the ResourceQuotas of their
+// namespaces before applying them. The quotas are read from the local
+// cluster, so the child resources that target remote clusters are not
+// checked.
+func WithQuotaCheck() ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		WithPreApplyHook(NewQuotaChecker(reconciler.clusters))(reconciler)
+	}
+}
+
+// WithPostApplyHook returns a ReconcilerOption that adds the given hooks to
+// the list of hooks that are run after all child resources are applied. The
+// parent resource is reported as ready only if all of these hooks succeed.
+func WithPostApplyHook(h ...Hook) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.hooks.PostApply = append(reconciler.hooks.PostApply, h...)
+	}
+}
+
+// WithReadinessChecker returns a ReconcilerOption that changes the
+// ReadinessChecker that decides whether a child resource is ready to be
+// depended on.
+func WithReadinessChecker(rc ReadinessChecker) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.readiness = rc
+	}
+}
+
+// WithValuesProvider returns a ReconcilerOption that adds the given
+// ValuesProviders to the list of providers whose values are exposed to the
+// engine and the patchers under spec.parameters of the parent resource. The
+// values are never written to the parent resource in the API server.
+func WithValuesProvider(p ...ValuesProvider) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.values = append(reconciler.values, p...)
+	}
+}
+
+// WithReadinessTimeout returns a ReconcilerOption that changes how long a
+// child resource may stay not ready before the parent resource is marked as
+// degraded. Child resources can override it with ReadinessTimeoutAnnotationKey
+// annotation. A timeout of zero, the default, waits forever.
+func WithReadinessTimeout(d time.Duration) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.timeouts = newReadinessTracker(d)
+	}
+}
+
+// WithPauseSwitch returns a ReconcilerOption that changes the PauseSwitch
+// which is checked before every reconciliation. The parent resources are not
+// changed in any way other than getting a Paused condition while
+// reconciliation is paused, including the ones that are being deleted.
+func WithPauseSwitch(p PauseSwitch) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.pause = p
+	}
+}
+
+// WithConverter returns a ReconcilerOption that registers the Converters to
+// be run before the render of the parent resources whose spec was written in
+// the given older version of the parent API. The parent resources themselves
+// are never converted; only their copy given to the engine is.
+func WithConverter(version string, c ...Converter) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.conversions[version] = append(reconciler.conversions[version], c...)
+	}
+}
+
+// WithAuditSink returns a ReconcilerOption that makes the reconciler record
+// every change it applies to the child resources in the given AuditSink.
+func WithAuditSink(a AuditSink) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.audit = a
+	}
+}
+
+// WithSnapshotStore returns a ReconcilerOption that makes the reconciler save
+// the live manifest of every child resource to the given SnapshotStore before
+// it deletes it, e.g. to prune it, to recreate it or to roll it back. A child
+// resource whose snapshot cannot be saved is not deleted.
+func WithSnapshotStore(s SnapshotStore) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.clusters.SnapshotBeforeDelete(s)
+	}
+}
+
+// WithChildLifecycleHook returns a ReconcilerOption that appends the given
+// ChildLifecycleHooks to the ones that are called after a child resource is
+// created or updated, and after the child resources of a deleted parent
+// resource are all gone. The hooks are not called for the child resources
+// whose apply did not change them. Their errors are only logged.
+func WithChildLifecycleHook(h ...ChildLifecycleHook) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.lifecycle = append(reconciler.lifecycle, h...)
+	}
+}
+
+// WithMiddleware returns a ReconcilerOption that appends the given Middleware
+// to the ones that wrap the stages of the reconciliation. The first
+// Middleware is the outermost one.
+func WithMiddleware(m ...Middleware) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.middleware = append(reconciler.middleware, m...)
+	}
+}
+
+// WithNotifier returns a ReconcilerOption that makes the reconciler notify the
+// given Notifier about the outcomes of the reconciles.
+func WithNotifier(n Notifier) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.notifier = n
+	}
+}
+
+// WithStatusSubresourceDetection returns a ReconcilerOption that makes the
+// reconciler write the status of the parent resources by updating them as a
+// whole if the given StatusSubresourceDetector detects that the
+// CustomResourceDefinition of the parent kind does not enable the status
+// subresource.
+func WithStatusSubresourceDetection(d *StatusSubresourceDetector) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.client.Client = &statusFallbackClient{Client: reconciler.client.Client, detector: d}
+	}
+}
+
+// WithStatusCoalescing returns a ReconcilerOption that skips the status
+// updates of the parent resources whose status did not change in the
+// reconcile. It needs to come after WithStatusSubresourceDetection, if both
+// are used, so that the skipped writes include the fallback updates.
+func WithStatusCoalescing() ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		gvk := reconciler.newParentResource().GetObjectKind().GroupVersionKind()
+		reconciler.client.Client = newStatusCoalescingClient(reconciler.client.Client, gvk)
+	}
+}
+
+// WithControllerConfig returns a ReconcilerOption that makes the reconciler
+// use the settings of the ControllerConfig that the given store read last,
+// in place of the ones that the other options configured.
+func WithControllerConfig(s *ControllerConfigStore) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.config = s
+	}
+}
+
+// WithRenderVerification returns a ReconcilerOption that makes the reconciler
+// render every parent resource twice and fail its reconcile without applying
+// anything if the two renders differ.
+func WithRenderVerification() ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.verifyRenders = true
+	}
+}
+
+// WithRenderCache returns a ReconcilerOption that makes the reconciler look up
+// every render of the templates with the given digest in the given
+// RenderCache. Renders that are not cached yet are cached, and renders that
+// differ from the cached ones fail, so that the pre-renders of CI and the
+// child resources the controller applies are verified to be the same.
+func WithRenderCache(c RenderCache, source string) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.renderCache = c
+		reconciler.sourceDigest = source
+	}
+}
+
+// WithSourceDigest returns a ReconcilerOption that tells the reconciler the
+// digest of the templates it renders, which it reports with the render inputs
+// and keys the renders with.
+func WithSourceDigest(source string) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.sourceDigest = source
+	}
+}
+
+// WithRenderMetrics returns a ReconcilerOption that makes the reconciler
+// record the child resources it renders for every parent resource in the
+// given RenderMetrics.
+func WithRenderMetrics(m *RenderMetrics) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.renderMetrics = m
+	}
+}
+
+// WithControllerStatus returns a ReconcilerOption that makes the reconciler
+// record the outcome of every reconcile in the given ControllerStatus.
+func WithControllerStatus(s *ControllerStatus) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.status = s
+	}
+}
+
+// WithShard returns a ReconcilerOption that makes the reconciler skip the
+// parent resources that the given Shard does not own, e.g. the ones that were
+// requeued before the shard count changed.
+func WithShard(s *Shard) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.shard = s
+	}
+}
+
+// WithApplyBudget returns a ReconcilerOption that makes every reconcile of a
+// parent resource apply at most the given number of child resources. The
+// rest are applied by the next reconciles, which follow right away, and the
+// parent resource is unavailable until all of them are applied. Zero applies
+// all child resources in every reconcile.
+func WithApplyBudget(n int) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.budget = newApplyBudget(n)
+	}
+}
+
+// WithStrictOwnership returns a ReconcilerOption that makes the reconciler
+// update only the existing child resources that are controlled by their parent
+// resource or, if they have no controller, annotated with its UID by the
+// ParentUIDAnnotator it adds. Any other object with the name of a child
+// resource is left untouched and the parent resource gets an
+// OwnershipConflict condition that names its owner.
+func WithStrictOwnership() ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.strictOwnership = true
+		WithAdditionalChildResourcePatcher(NewParentUIDAnnotator())(reconciler)
+	}
+}
+
+// WithConditionHistory returns a ReconcilerOption that makes the reconciler
+// keep the given number of the latest condition transitions of every parent
+// resource in its status.conditionHistory. Zero disables the history.
+func WithConditionHistory(limit int) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.conditionHistory = limit
+	}
+}
+
+// WithCompositeKinds returns a ReconcilerOption that makes the reconciler
+// expand the rendered objects of the given composite kinds, e.g. the
+// Templates of OpenShift, into the objects they wrap before patching and
+// applying them, like it does for lists such as v1 List.
+func WithCompositeKinds(kinds resource.CompositeKinds) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.composites = kinds
+	}
+}
+
+// WithProgressiveRollout returns a ReconcilerOption that rolls the given
+// revision of the templates out to at most batch parent resources at a time,
+// pausing the rollout while any of them fails. The parent resources that were
+// rendered with another revision wait for their turn without any change to
+// their child resources. A batch size of zero disables the progressive rollout.
+func WithProgressiveRollout(revision string, batch int) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.rollout = newRolloutTracker(revision, batch)
+	}
+}
+
+// WithRevisionPinning returns a ReconcilerOption that lets the parent
+// resources pin their child resources to a revision of the templates in
+// spec.packVersion. The child resources of a parent resource that pins
+// another revision than the given current one are left untouched until the
+// pin is changed to the current revision, which upgrades it regardless of the
+// progressive rollout. The current and the applied revisions are reported in
+// status.availableTemplateRevision and status.templateRevision. It needs to
+// come after WithProgressiveRollout, if both are used.
+func WithRevisionPinning(revision string) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		if reconciler.rollout == nil {
+			reconciler.rollout = newRolloutTracker(revision, 0)
+		}
+		reconciler.rollout.pinning = true
+	}
+}
+
+// WithDrainer returns a ReconcilerOption that makes the reconciler report its
+// in-flight reconciles to the given Drainer, and stop starting new ones once
+// the Drainer is stopped.
+func WithDrainer(d *Drainer) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.drainer = d
+	}
+}
+
+// WithFairness returns a ReconcilerOption that caps the number of parent
+// resources of the same group, as returned by the given FairnessKeyFunc, that
+// are reconciled at the same time. The parent resources whose group is at its
+// limit are requeued shortly without occupying a worker.
+func WithFairness(key FairnessKeyFunc, limit int) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.limiter = newConcurrencyLimiter(key, limit)
+	}
+}
+
 // WithShortWait returns a ReconcilerOption that changes the wait
 // duration that determines after how much time another reconcile should be triggered
 // after an error pass.
@@ -115,6 +555,84 @@ func WithLongWait(d time.Duration) ReconcilerOption {
 	}
 }
 
+// WithRemoteCluster returns a ReconcilerOption that registers the client of a
+// remote cluster with given name so that child resources can target it with
+// TargetClusterAnnotationKey annotation.
+func WithRemoteCluster(name string, c client.Client) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.clusters.Register(name, c)
+	}
+}
+
+// WithImpersonation returns a ReconcilerOption that applies and deletes the
+// local child resources in the name of the ServiceAccount that their parent
+// resource names, using the clients that the given function returns for the
+// users of the ServiceAccounts. The parent resources that do not name one use
+// the ServiceAccount with given default name in their namespace.
+func WithImpersonation(defaultServiceAccount string, newClient func(user string) (client.Client, error)) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.clusters.Impersonate(newClient)
+		WithAdditionalChildResourcePatcher(NewImpersonationPatcher(defaultServiceAccount))(reconciler)
+	}
+}
+
+// WithSyncInterval returns a ReconcilerOption that changes the interval of
+// re-rendering and re-applying the child resources to correct their drift,
+// independent of how often the parent resource is requeued. The child
+// resources are always synced when the parent resource changes; an interval of
+// zero disables the periodic syncs entirely for environments where another
+// system owns the drift correction.
+func WithSyncInterval(d time.Duration) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.syncs = newSyncTracker(d)
+	}
+}
+
+// WithReportOnly returns a ReconcilerOption that makes the reconciler only
+// report what it would change in the child resources, via the conditions and
+// events of the parent resource, without writing any child resource, running
+// the pre-render hooks, rolling back or adding a finalizer to the parent
+// resource.
+func WithReportOnly() ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.reportOnly = true
+	}
+}
+
+// WithManifestPublisher returns a ReconcilerOption that makes the reconciler
+// publish the child resources with the given ManifestPublisher instead of
+// applying them, e.g. to let a GitOps pipeline apply them. The child resources
+// are unpublished when the parent resource is deleted.
+func WithManifestPublisher(p ManifestPublisher) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.publisher = p
+	}
+}
+
+// WithRecorder returns a ReconcilerOption that changes the event recorder.
+func WithRecorder(er event.Recorder) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.record = er
+	}
+}
+
+// WithRevisionStore returns a ReconcilerOption that changes the RevisionStore
+// used to store the child resources of every applied revision. Parent
+// resources can be rolled back only if a RevisionStore is configured.
+func WithRevisionStore(s RevisionStore) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.revisions.store = s
+	}
+}
+
+// WithRevisionHistoryLimit returns a ReconcilerOption that changes the number
+// of revisions kept in the history of a parent resource.
+func WithRevisionHistoryLimit(n int) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.revisions.limit = n
+	}
+}
+
 // WithLogger returns a ReconcilerOption that changes the logger.
 func WithLogger(l logging.Logger) ReconcilerOption {
 	return func(reconciler *Reconciler) {
@@ -130,8 +648,9 @@ func defaultCRChildren(c client.Client) crChildren {
 			NewNamespacePatcher(),
 			NewLabelPropagator(),
 			NewParentLabelSetAdder(),
+			NewNameMigrator(),
 		},
-		ChildResourceDeleter: NewAPIOrderedDeleter(c),
+		ChildResourceDeleter: ChildResourceDeleterChain{NewAPIOrderedDeleter(c)},
 	}
 }
 
@@ -140,27 +659,61 @@ type crChildren struct {
 	ChildResourceDeleter
 }
 
+func defaultCRHooks(c client.Client) crHooks {
+	return crHooks{
+		PostApply: HookChain{
+			NewJobCompletionHook(),
+			NewConnectionSecretPropagator(c),
+		},
+	}
+}
+
+type crRevisions struct {
+	store RevisionStore
+	limit int
+}
+
+type crHooks struct {
+	PreRender HookChain
+	PreApply  HookChain
+	PostApply HookChain
+}
+
 // NewReconciler returns a new templating reconciler that will reconcile
 // given GroupVersionKind.
 func NewReconciler(m manager.Manager, of schema.GroupVersionKind, options ...ReconcilerOption) *Reconciler {
+	return newReconciler(m.GetClient(), of, options...)
+}
+
+func newReconciler(c client.Client, of schema.GroupVersionKind, options ...ReconcilerOption) *Reconciler {
 	nr := func() resource.ParentResource {
 		u := &unstructured.Unstructured{}
 		u.SetGroupVersionKind(of)
 		return u
 	}
 
+	kube := NewClusterRouter(c)
 	r := &Reconciler{
 		client: rresource.ClientApplicator{
-			Client:     m.GetClient(),
-			Applicator: rresource.NewAPIPatchingApplicator(m.GetClient()),
+			Client:     kube,
+			Applicator: rresource.NewAPIPatchingApplicator(kube),
 		},
+		clusters:          kube,
 		newParentResource: nr,
 		shortWait:         defaultShortWait,
 		longWait:          defaultLongWait,
 		log:               logging.NewNopLogger(),
+		record:            event.NewNopRecorder(),
 		templating:        &NopEngine{},
-		finalizer:         rresource.NewAPIFinalizer(m.GetClient(), finalizer),
-		children:          defaultCRChildren(m.GetClient()),
+		finalizer:         rresource.NewAPIFinalizer(kube, finalizer),
+		children:          defaultCRChildren(kube),
+		hooks:             defaultCRHooks(kube),
+		readiness:         NewStatusReadinessChecker(),
+		pause:             NewStaticPauseSwitch(false),
+		conversions:       map[string]ConverterChain{},
+		syncs:             newSyncTracker(defaultLongWait),
+		timeouts:          newReadinessTracker(0),
+		revisions:         crRevisions{limit: defaultRevisionHistoryLimit},
 	}
 
 	for _, opt := range options {
@@ -173,26 +726,85 @@ func NewReconciler(m manager.Manager, of schema.GroupVersionKind, options ...Rec
 // is supplied.
 type Reconciler struct {
 	client            rresource.ClientApplicator
+	clusters          *ClusterRouter
 	newParentResource func() resource.ParentResource
 	shortWait         time.Duration
 	longWait          time.Duration
 	log               logging.Logger
+	record            event.Recorder
+	reportOnly        bool
+	strictOwnership   bool
+	conditionHistory  int
+	publisher         ManifestPublisher
 
-	templating Engine
-	finalizer  rresource.Finalizer
-	children   crChildren
+	templating    Engine
+	finalizer     rresource.Finalizer
+	children      crChildren
+	hooks         crHooks
+	readiness     ReadinessChecker
+	pause         PauseSwitch
+	maintenance   maintenanceSchedule
+	syncs         *syncTracker
+	timeouts      *readinessTracker
+	revisions     crRevisions
+	values        ValuesProviderChain
+	conversions   map[string]ConverterChain
+	audit         AuditSink
+	lifecycle     ChildLifecycleHookChain
+	middleware    MiddlewareChain
+	notifier      Notifier
+	renderMetrics *RenderMetrics
+	verifyRenders bool
+	renderCache   RenderCache
+	sourceDigest  string
+	config        *ControllerConfigStore
+	rollout       *rolloutTracker
+	limiter       *concurrencyLimiter
+	drainer       *Drainer
+	optional      *OptionalChildResourceFilter
+	results       *resultAnnotator
+	composites    resource.CompositeKinds
+	status        *ControllerStatus
+	shard         *Shard
+	budget        *applyBudget
 }
 
 // Reconcile is called by controller-runtime for reconciliation.
-func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) { // nolint:gocyclo
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	if r.drainer != nil {
+		done, ok := r.drainer.start()
+		if !ok {
+			r.log.Debug("Controller is shutting down, requeueing", "parent-resource", req)
+			return ctrl.Result{Requeue: true}, nil
+		}
+		defer done()
+	}
+	if r.config == nil {
+		return r.reconcile(req)
+	}
+	release, ok := r.config.limiter.Acquire(nil)
+	if !ok {
+		r.log.Debug("Too many parent resources are being reconciled, requeueing", "parent-resource", req)
+		return ctrl.Result{RequeueAfter: tinyWait}, nil
+	}
+	defer release()
+	return r.configured(r.config.Spec()).reconcile(req)
+}
+
+func (r *Reconciler) reconcile(req ctrl.Request) (result ctrl.Result, err error) { // nolint:gocyclo
 	// NOTE(muvaf): This method is well over our cyclomatic complexity goal.
 	// Be wary of adding additional complexity.
 
 	ctx, cancel := context.WithTimeout(context.Background(), reconcileTimeout)
 	defer cancel()
 	log := r.log.WithValues("parent-resource", req)
+	start := time.Now()
 
 	cr := r.newParentResource()
+	if r.status != nil {
+		done := r.status.Start(req.NamespacedName)
+		defer func() { done(cr, result, err) }()
+	}
 	if err := r.client.Get(ctx, req.NamespacedName, cr); err != nil {
 		// There's no need to requeue if the resource no longer exists. Otherwise
 		// we'll be requeued implicitly because we return an error.
@@ -200,59 +812,560 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) { // nolin
 		return reconcile.Result{Requeue: false}, errors.Wrap(client.IgnoreNotFound(err), errGetResource)
 	}
 
-	childResources, err := r.templating.Run(cr)
+	if r.shard != nil && !r.shard.Owns(cr) {
+		log.Debug("Parent resource is owned by another shard", "shard", r.shard.Index())
+		return reconcile.Result{Requeue: false}, nil
+	}
+
+	release, ok := r.limiter.Acquire(cr)
+	if !ok {
+		log.Debug("Too many parent resources of the same group are being reconciled, requeueing")
+		return ctrl.Result{RequeueAfter: tinyWait}, nil
+	}
+	defer release()
+
+	paused, err := r.pause.Paused(ctx)
+	if err != nil {
+		log.Info(errPauseSwitch, "error", err)
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errPauseSwitch))))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+	if paused {
+		log.Debug("Reconciliation is paused")
+		omitError(log, r.setConditions(cr, Paused()))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+
+	if r.reportOnly && meta.WasDeleted(cr) {
+		// The child resources are never applied in the report-only mode, so
+		// there is nothing to delete, but the finalizer may have been added
+		// before the mode was enabled and would block the deletion.
+		if err := r.finalizer.RemoveFinalizer(ctx, cr); client.IgnoreNotFound(err) != nil {
+			log.Info(errRemoveFinalizer, "error", err)
+			omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errRemoveFinalizer))))
+			return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+		}
+		r.syncs.Forget(cr)
+		return reconcile.Result{Requeue: false}, nil
+	}
+
+	if !meta.WasDeleted(cr) && !r.syncs.Due(cr) {
+		log.Debug("Skipping sync of child resources, sync interval has not elapsed")
+		return ctrl.Result{RequeueAfter: r.longWait}, nil
+	}
+
+	if !meta.WasDeleted(cr) && !r.rollout.Admit(cr) {
+		msg, pinned := r.rollout.Waiting(cr)
+		log.Debug(msg)
+		omitError(log, r.rollout.Report(cr))
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileSuccess().WithMessage(msg)))
+		wait := r.shortWait
+		if pinned {
+			// Only a change of its pin, which triggers a reconcile of its
+			// own, lets the parent resource go on.
+			wait = r.longWait
+		}
+		return ctrl.Result{RequeueAfter: wait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+
+	// The hooks and the initialization of values might make changes, which
+	// report-only mode does not.
+	values := r.values
+	if !r.reportOnly {
+		if err := r.hooks.PreRender.Run(ctx, cr, nil); err != nil {
+			log.Info(errPreRenderHook, "error", err)
+			omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errPreRenderHook))))
+			return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+		}
+		initialized, err := r.values.Initialize(ctx, cr)
+		if err != nil {
+			log.Info(errInitializeValues, "error", err)
+			omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(err)))
+			return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+		}
+		values = initialized
+	}
+
+	input, err := r.renderInputWith(ctx, cr, values)
+	if err != nil {
+		log.Info(errRenderInput, "error", err)
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errRenderInput))))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+
+	childResources, err := r.render(ctx, input)
 	if err != nil {
+		if resource.Classify(err) == resource.FailureUnknown {
+			err = &resource.RenderError{Err: err}
+		}
 		log.Info("Cannot run templating operation", "error", err)
-		omitError(log, resource.SetConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errTemplatingOperation))))
+		r.recordFailure(ctx, cr, err)
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errTemplatingOperation))))
 		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
 	}
 
-	childResources, err = r.children.Patch(cr, childResources)
+	if r.verifyRenders {
+		if err := r.verifyRender(input, childResources); err != nil {
+			log.Info(errVerifyRender, "error", err)
+			r.recordFailure(ctx, cr, err)
+			omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errVerifyRender))))
+			return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+		}
+	}
+
+	childResources, err = r.patch(ctx, input, childResources)
 	if err != nil {
 		log.Info("Cannot run patchers on the child resources", "error", err)
-		omitError(log, resource.SetConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errChildResourcePatchers))))
+		r.recordFailure(ctx, cr, err)
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errChildResourcePatchers))))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+
+	if r.renderCache != nil {
+		if err := r.cacheRender(ctx, input, childResources); err != nil {
+			log.Info(errCacheRender, "error", err)
+			r.recordFailure(ctx, cr, err)
+			omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errCacheRender))))
+			return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+		}
+	}
+
+	if r.optional != nil {
+		var skipped []resource.ChildResource
+		childResources, skipped, err = r.optional.Filter(childResources)
+		if err != nil {
+			log.Info(errFilterOptional, "error", err)
+			omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errFilterOptional))))
+			return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+		}
+		cond := OptionalChildResourcesApplied()
+		if len(skipped) > 0 {
+			log.Debug("Skipping optional child resources whose APIs are not served", "skipped", len(skipped))
+			cond = OptionalChildResourcesSkipped(skipped)
+		}
+		omitError(log, r.setConditions(cr, cond))
+	}
+
+	if r.renderMetrics != nil {
+		r.renderMetrics.Observe(cr, childResources)
+	}
+
+	if err := ResolveGeneratedNames(cr, childResources); err != nil {
+		log.Info(errResolveGeneratedNames, "error", err)
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errResolveGeneratedNames))))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+
+	if r.reportOnly {
+		return r.report(ctx, cr, childResources)
+	}
+
+	rolled, rolledBack, err := r.rollback(ctx, cr)
+	if err != nil {
+		log.Info(errRollback, "error", err)
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errRollback))))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+	if rolledBack {
+		childResources = rolled
+	}
+
+	if r.publisher != nil {
+		return r.publish(ctx, cr, childResources)
+	}
+
+	maintenanceEnd, inMaintenance, err := r.maintenance.activeUntil(cr, time.Now())
+	if err != nil {
+		log.Info(errMaintenanceWindow, "error", err)
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errMaintenanceWindow))))
 		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
 	}
 
 	if meta.WasDeleted(cr) {
-		deleting, err := r.children.Delete(ctx, cr, childResources)
+		if inMaintenance && !r.maintenance.policy.AllowDelete {
+			return r.applyInMaintenance(ctx, cr, nil, maintenanceEnd)
+		}
+		// The orphaned child resources are left to outlive the parent
+		// resource.
+		owned := WithoutOrphaned(WithoutUngenerated(childResources))
+		deleting, err := r.children.Delete(ctx, cr, owned)
 		if err != nil {
 			log.Info(errDeleter, "error", err)
-			omitError(log, resource.SetConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errDeleter))))
+			omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errDeleter))))
 			return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
 		}
 
 		if len(deleting) > 0 {
-			omitError(log, resource.SetConditions(cr, v1alpha1.ReconcileSuccess().WithMessage(msgWaitingForDeletion)))
+			omitError(log, r.setConditions(cr, v1alpha1.ReconcileSuccess().WithMessage(msgWaitingForDeletion)))
 			return ctrl.Result{RequeueAfter: tinyWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
 		}
 
 		if err := r.finalizer.RemoveFinalizer(ctx, cr); client.IgnoreNotFound(err) != nil {
 			log.Info(errRemoveFinalizer, "error", err)
-			omitError(log, resource.SetConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errRemoveFinalizer))))
+			omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errRemoveFinalizer))))
 			return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
 		}
+		if len(owned) > 0 {
+			r.notify(ctx, newNotification(cr, OutcomePruned, msgParentDeleted, references(owned)))
+			for _, o := range owned {
+				r.childChanged(ctx, cr, o, ChildDeleted)
+			}
+		}
+		r.syncs.Forget(cr)
+		r.timeouts.Forget(cr)
+		r.rollout.Forget(cr)
+		r.budget.Forget(cr)
+		if r.renderMetrics != nil {
+			r.renderMetrics.Forget(cr)
+		}
 		return reconcile.Result{Requeue: false}, nil
 	}
 
 	if err := r.finalizer.AddFinalizer(ctx, cr); err != nil {
 		log.Info(errAddFinalizer, "error", err)
-		omitError(log, resource.SetConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errAddFinalizer))))
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errAddFinalizer))))
 		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
 	}
 
-	for _, o := range childResources {
-		if err := r.client.Apply(ctx, o, rresource.MustBeControllableBy(cr.GetUID())); err != nil {
-			log.Info("Cannot apply the changes to the child resources", "error", err)
-			omitError(log, resource.SetConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, fmt.Sprintf("%s: %s/%s of type %s", errApply, o.GetName(), o.GetNamespace(), o.GetObjectKind().GroupVersionKind().String())))))
+	if inMaintenance {
+		return r.applyInMaintenance(ctx, cr, childResources, maintenanceEnd)
+	}
+
+	stages := r.pipeline()
+	if err := stages.PreApply(ctx, cr, childResources); err != nil {
+		log.Info(errPreApplyHook, "error", err)
+		r.recordFailure(ctx, cr, err)
+		cond := v1alpha1.ReconcileError(errors.Wrap(err, errPreApplyHook))
+		switch resource.Classify(err) {
+		case resource.FailureQuota:
+			cond = QuotaExceeded(err)
+		case resource.FailureMissingAPI:
+			cond = InstallPrerequisitesMissing(err)
+		}
+		omitError(log, r.setConditions(cr, cond))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+
+	if err := r.applyPreRenderJobs(ctx, cr, childResources); err != nil {
+		if IsHookJobPending(err) {
+			log.Debug(msgWaitingForHookJobs, "job", err.Error())
+			omitError(log, r.setConditions(cr, v1alpha1.ReconcileSuccess().WithMessage(msgWaitingForHookJobs), v1alpha1.Unavailable().WithMessage(err.Error())))
+			return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+		}
+		log.Info(errPreRenderJobs, "error", err)
+		r.recordFailure(ctx, cr, err)
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errPreRenderJobs)), v1alpha1.Unavailable()))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+
+	outcome, err := stages.ApplyChildren(ctx, cr, childResources)
+	waiting, notReady, deferred, hint := outcome.Waiting, outcome.NotReady, outcome.Deferred, outcome.RequeueAfter
+	if err != nil {
+		log.Info("Cannot apply the changes to the child resources", "error", err)
+		r.recordFailure(ctx, cr, err)
+		cond := v1alpha1.ReconcileError(err)
+		if resource.Classify(err) == resource.FailureOwnership {
+			cond = OwnershipConflict(err)
+		}
+		omitError(log, r.setConditions(cr, cond))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+
+	timedOut, err := r.timeouts.Exceeded(cr, notReady)
+	if err != nil {
+		log.Info(errReadinessCheck, "error", err)
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errReadinessCheck))))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+	if len(timedOut) > 0 {
+		log.Debug(msgReadinessTimedOut, "timed-out", len(timedOut))
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileSuccess(), Degraded().WithMessage(timedOutMessage(timedOut))))
+		return ctrl.Result{RequeueAfter: requeueAfter(hint, r.shortWait)}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+
+	if len(deferred) > 0 {
+		log.Debug(msgApplyBudgetSpent, "deferred", len(deferred))
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileSuccess().WithMessage(fmt.Sprintf("%s: %d left", msgApplyBudgetSpent, len(deferred))), v1alpha1.Unavailable()))
+		return ctrl.Result{RequeueAfter: tinyWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+
+	if len(waiting) > 0 {
+		log.Debug(msgWaitingForDependencies, "waiting", len(waiting))
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileSuccess().WithMessage(msgWaitingForDependencies), v1alpha1.Unavailable()))
+		return ctrl.Result{RequeueAfter: requeueAfter(hint, r.shortWait)}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+
+	if err := stages.PostApply(ctx, cr, childResources); err != nil {
+		if IsHookJobPending(err) {
+			log.Debug(msgWaitingForHookJobs, "job", err.Error())
+			omitError(log, r.setConditions(cr, v1alpha1.ReconcileSuccess().WithMessage(msgWaitingForHookJobs), v1alpha1.Unavailable().WithMessage(err.Error())))
 			return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
 		}
+		log.Info(errPostApplyHook, "error", err)
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errPostApplyHook)), v1alpha1.Unavailable()))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+	if err := r.recordRevision(ctx, cr, childResources); err != nil {
+		log.Info(errRecordRevision, "error", err)
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errRecordRevision))))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+	if err := r.stampResult(ctx, cr, childResources); err != nil {
+		// The annotations are informational, failing to write them should
+		// not fail the sync.
+		log.Info(errStampResult, "error", err)
+	}
+	if len(notReady) > 0 {
+		log.Debug(msgWaitingForReadiness, "not-ready", len(notReady))
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileSuccess(), v1alpha1.Unavailable().WithMessage(msgWaitingForReadiness)))
+		return ctrl.Result{RequeueAfter: requeueAfter(hint, r.shortWait)}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
 	}
 	log.Debug("Reconciliation finished with success")
-	omitError(log, resource.SetConditions(cr, v1alpha1.ReconcileSuccess()))
+	r.notifySuccess(ctx, cr, r.syncs.Unchanged(cr), start)
+	r.syncs.Synced(cr)
+	omitError(log, r.rollout.Done(cr))
+	omitError(log, r.setConditions(cr, v1alpha1.ReconcileSuccess(), v1alpha1.Available()))
+	return ctrl.Result{RequeueAfter: requeueAfter(hint, r.longWait)}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+}
+
+// recordFailure records a warning event whose reason is the kind of the
+// failure that caused the given error and notifies about the failure.
+func (r *Reconciler) recordFailure(ctx context.Context, cr resource.ParentResource, err error) {
+	r.notifyFailure(ctx, cr, err)
+	kind := resource.Classify(err)
+	if kind == resource.FailureUnknown {
+		return
+	}
+	r.record.Event(cr, event.Warning(event.Reason(kind), err))
+}
+
+// report sets the conditions of the parent resource and records an event with
+// the summary of what would be changed in the child resources.
+func (r *Reconciler) report(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) (ctrl.Result, error) {
+	log := r.log.WithValues("parent-resource", cr.GetName())
+	plan, err := r.plan(ctx, cr, list)
+	if err != nil {
+		log.Info(errReport, "error", err)
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errReport))))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+	for _, c := range plan.Changes {
+		log.Debug("Report of child resource", "operation", c.Operation, "name", c.Child.Name, "namespace", c.Child.Namespace, "kind", c.Child.Kind, "changed-fields", len(c.Diff))
+	}
+	msg := fmt.Sprintf("%s: %s", msgReportOnly, plan.Summary())
+	r.record.Event(cr, event.Normal(reasonReportOnly, msg))
+	omitError(log, r.setConditions(cr, v1alpha1.ReconcileSuccess().WithMessage(msg)))
 	return ctrl.Result{RequeueAfter: r.longWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
 }
 
+// publish publishes the child resources with the ManifestPublisher instead of
+// applying them. The finalizer is used to unpublish them once the parent
+// resource is deleted.
+func (r *Reconciler) publish(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) (ctrl.Result, error) {
+	log := r.log.WithValues("parent-resource", cr.GetName())
+	if meta.WasDeleted(cr) {
+		if _, err := r.publisher.Publish(ctx, cr, nil); err != nil {
+			log.Info(errPublish, "error", err)
+			omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errPublish))))
+			return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+		}
+		if err := r.finalizer.RemoveFinalizer(ctx, cr); client.IgnoreNotFound(err) != nil {
+			log.Info(errRemoveFinalizer, "error", err)
+			omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errRemoveFinalizer))))
+			return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+		}
+		r.syncs.Forget(cr)
+		return reconcile.Result{Requeue: false}, nil
+	}
+	if err := r.finalizer.AddFinalizer(ctx, cr); err != nil {
+		log.Info(errAddFinalizer, "error", err)
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errAddFinalizer))))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+	rev, err := r.publisher.Publish(ctx, cr, list)
+	if err != nil {
+		log.Info(errPublish, "error", err)
+		omitError(log, r.setConditions(cr, v1alpha1.ReconcileError(errors.Wrap(err, errPublish))))
+		return ctrl.Result{RequeueAfter: r.shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+	}
+	msg := fmt.Sprintf("%s at revision %s", msgPublished, rev)
+	r.record.Event(cr, event.Normal(reasonPublished, msg))
+	r.syncs.Synced(cr)
+	omitError(log, r.setConditions(cr, v1alpha1.ReconcileSuccess().WithMessage(msg)))
+	return ctrl.Result{RequeueAfter: r.longWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateResourceStatus)
+}
+
+// applyChildren applies the given child resources in the order of their
+// dependencies. The child resources whose dependencies are not ready yet are
+// not applied and returned as waiting so that they can be tried in the next
+// pass. The applied child resources that are not ready are returned, too, as
+// well as the shortest requeue-after hint that the applied child resources
+// carry, if any, which takes the place of the default wait. The child
+// resources that are left for the next pass once the apply budget is spent
+// are returned as deferred.
+func (r *Reconciler) applyChildren(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) (waiting, notReady, deferred []resource.ChildResource, hint time.Duration, err error) { // nolint:gocyclo
+	sorted, err := SortByDependencies(hooksLast(list))
+	if err != nil {
+		return nil, nil, nil, 0, errors.Wrap(err, errDependencies)
+	}
+	inv := newChildInventory(cr, list)
+	pass := r.budget.start(cr)
+	defer func() {
+		omitError(r.log, inv.write(cr))
+		r.recordApplySummary(cr, inv)
+		// A failed pass keeps what it applied so that the next pass does
+		// not start the round over.
+		pass.finish(err == nil && len(deferred) == 0)
+	}()
+	// SortByDependencies makes sure that the identifiers depended on are
+	// unique, so ready can be keyed by ChildID.
+	ready := map[string]bool{}
+	for _, o := range sorted {
+		if ok, done := pass.done(ChildKey(o)); done {
+			ready[ChildID(o)] = ok
+			if !ok {
+				notReady = append(notReady, o)
+			}
+			continue
+		}
+		if !dependenciesIn(o, ready) {
+			waiting = append(waiting, o)
+			continue
+		}
+		if pass.spent() {
+			deferred = append(deferred, o)
+			continue
+		}
+		if IsOneShot(o) {
+			ok, err := r.runOneShot(ctx, cr, o, inv)
+			if err != nil {
+				return nil, nil, nil, 0, applyError(o, err)
+			}
+			ready[ChildID(o)] = ok
+			pass.record(ChildKey(o), ok)
+			if !ok {
+				notReady = append(notReady, o)
+			}
+			continue
+		}
+		timeout, err := ApplyTimeout(o)
+		if err != nil {
+			return nil, nil, nil, 0, err
+		}
+		res, err := r.applyChildWithin(ctx, cr, o, timeout)
+		inv.record(o, res, err)
+		if err != nil {
+			return nil, nil, nil, 0, applyError(o, err)
+		}
+		ok, err := r.readiness.IsReady(ctx, o)
+		if err != nil {
+			return nil, nil, nil, 0, errors.Wrap(err, errReadinessCheck)
+		}
+		d, err := RequeueAfter(o)
+		if err != nil {
+			return nil, nil, nil, 0, err
+		}
+		hint = shortestRequeue(hint, d)
+		ready[ChildID(o)] = ok
+		pass.record(ChildKey(o), ok)
+		r.timeouts.Observe(cr, o, ok)
+		if !ok {
+			notReady = append(notReady, o)
+		}
+		if !ok && timeout != 0 {
+			exceeded, err := r.timeouts.Exceeded(cr, []resource.ChildResource{o})
+			if err != nil {
+				return nil, nil, nil, 0, errors.Wrap(err, errReadinessCheck)
+			}
+			if len(exceeded) > 0 {
+				inv.record(o, resource.ApplyFailed, errors.Errorf("%s: %s", errReadinessTimedOut, timeout))
+			}
+		}
+	}
+	return waiting, notReady, deferred, hint, nil
+}
+
+func applyError(o resource.ChildResource, err error) error {
+	return errors.Wrap(&resource.ApplyError{
+		GroupVersionKind: o.GetObjectKind().GroupVersionKind(),
+		Name:             o.GetName(),
+		Namespace:        o.GetNamespace(),
+		Err:              err,
+	}, errApply)
+}
+
+// applyChildWithin applies the given child resource, failing if it takes
+// longer than the given timeout. A timeout of zero leaves only the timeout of
+// the whole reconcile.
+func (r *Reconciler) applyChildWithin(ctx context.Context, cr resource.ParentResource, o resource.ChildResource, timeout time.Duration) (resource.ApplyResult, error) {
+	if timeout == 0 {
+		return r.applyChild(ctx, cr, o)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	res, err := r.applyChild(ctx, cr, o)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		err = errors.Wrapf(err, "%s: %s", errApplyTimedOut, timeout)
+	}
+	return res, err
+}
+
+// applyPreRenderJobs applies the Jobs annotated as pre-render hooks among the
+// given child resources, within the Middleware, before any other child
+// resource is applied. It returns a *HookJobPendingError if any of them has
+// not completed yet, so that the rest of the child resources are applied only
+// by a later reconcile, rendered after the Jobs have completed. The Jobs are
+// applied on their own, so they cannot depend on other child resources.
+func (r *Reconciler) applyPreRenderJobs(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) error {
+	h := NewPreRenderJobCompletionHook()
+	var jobs []resource.ChildResource
+	for _, o := range list {
+		if h.Hooks(o) {
+			jobs = append(jobs, o)
+		}
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+	_, err := r.middleware.Wrap(StageApply, func(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+		for _, o := range list {
+			timeout, err := ApplyTimeout(o)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := r.applyChildWithin(ctx, cr, o, timeout); err != nil {
+				return nil, applyError(o, err)
+			}
+		}
+		return list, nil
+	})(ctx, cr, jobs)
+	if err != nil {
+		return err
+	}
+	return h.Run(ctx, cr, jobs)
+}
+
+// hooksLast returns the given list with the child resources that are post-apply
+// hooks moved to the end, keeping the order of the rest intact.
+func hooksLast(list []resource.ChildResource) []resource.ChildResource {
+	result := make([]resource.ChildResource, 0, len(list))
+	var hooks []resource.ChildResource
+	for _, o := range list {
+		if IsPostApplyHook(o) {
+			hooks = append(hooks, o)
+			continue
+		}
+		result = append(result, o)
+	}
+	return append(result, hooks...)
+}
+
+// setConditions sets the given conditions on the given parent resource,
+// keeping the configured number of their transitions in its history.
+func (r *Reconciler) setConditions(cr resource.ParentResource, c ...v1alpha1.Condition) error {
+	return resource.SetConditionsWithHistory(cr, r.conditionHistory, c...)
+}
+
 func omitError(log logging.Logger, err error) {
 	if err != nil {
 		log.Info("Omitted the non-fatal error", "error", err)
@@ -47,6 +47,17 @@ var (
 	errBoom = fmt.Errorf("boom")
 )
 
+// mockInitializer is a ValuesInitializer that initializes its values with a
+// function.
+type mockInitializer struct {
+	ValuesProviderFunc
+	initialize func(ctx context.Context, cr resource.ParentResource) (map[string]interface{}, error)
+}
+
+func (m mockInitializer) InitializeValues(ctx context.Context, cr resource.ParentResource) (map[string]interface{}, error) {
+	return m.initialize(ctx, cr)
+}
+
 func withNewParentResourceFunc(f func() resource.ParentResource) ReconcilerOption {
 	return func(r *Reconciler) {
 		r.newParentResource = f
@@ -76,6 +87,33 @@ func TestReconcile(t *testing.T) {
 				err: errors.Wrap(errBoom, errGetResource),
 			},
 		},
+		"PreRenderHookFailed": {
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj runtime.Object) error {
+						got := obj.(*fake.MockResource)
+						gotCond, err := resource.GetCondition(got, v1alpha1.TypeSynced)
+						if err != nil {
+							t.Errorf("Reconcile(...): error getting condition\n%s", err.Error())
+						}
+						wantCond := v1alpha1.ReconcileError(errors.Wrap(errBoom, errPreRenderHook))
+						if diff := cmp.Diff(wantCond, gotCond); diff != "" {
+							t.Errorf("Reconcile(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+				},
+				opts: []ReconcilerOption{
+					WithPreRenderHook(HookFunc(func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) error {
+						return errBoom
+					})),
+				},
+			},
+			want: want{
+				result: reconcile.Result{RequeueAfter: defaultShortWait},
+			},
+		},
 		"TemplatingFailed": {
 			args: args{
 				kube: &test.MockClient{
@@ -103,6 +141,41 @@ func TestReconcile(t *testing.T) {
 				result: reconcile.Result{RequeueAfter: defaultShortWait},
 			},
 		},
+		"RenderNotDeterministic": {
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj runtime.Object) error {
+						got := obj.(*fake.MockResource)
+						gotCond, err := resource.GetCondition(got, v1alpha1.TypeSynced)
+						if err != nil {
+							t.Errorf("Reconcile(...): error getting condition\n%s", err.Error())
+						}
+						wantErr := &resource.RenderError{Err: errors.Errorf("%s: %s", errNonDeterministic, "MockChildResource cool (metadata.labels.renderedAt)")}
+						wantCond := v1alpha1.ReconcileError(errors.Wrap(wantErr, errVerifyRender))
+						if diff := cmp.Diff(wantCond, gotCond); diff != "" {
+							t.Errorf("Reconcile(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+				},
+				opts: func() []ReconcilerOption {
+					renders := 0
+					return []ReconcilerOption{
+						WithRenderVerification(),
+						WithEngine(EngineFunc(func(_ resource.ParentResource) ([]resource.ChildResource, error) {
+							renders++
+							o := fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("cool", ""))
+							o.SetLabels(map[string]string{"renderedAt": fmt.Sprint(renders)})
+							return []resource.ChildResource{o}, nil
+						})),
+					}
+				}(),
+			},
+			want: want{
+				result: reconcile.Result{RequeueAfter: defaultShortWait},
+			},
+		},
 		"ChildResourcePatchFailed": {
 			args: args{
 				kube: &test.MockClient{
@@ -339,6 +412,303 @@ func TestReconcile(t *testing.T) {
 				result: reconcile.Result{RequeueAfter: defaultShortWait},
 			},
 		},
+		"WaitingForDependencies": {
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    test.NewMockGetFn(nil),
+					MockUpdate: test.NewMockUpdateFn(nil),
+					MockPatch:  test.NewMockPatchFn(nil),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj runtime.Object) error {
+						got := obj.(*fake.MockResource)
+						gotCond, err := resource.GetCondition(got, v1alpha1.TypeSynced)
+						if err != nil {
+							t.Errorf("Reconcile(...): error getting condition\n%s", err.Error())
+						}
+						wantCond := v1alpha1.ReconcileSuccess().WithMessage(msgWaitingForDependencies)
+						if diff := cmp.Diff(wantCond, gotCond); diff != "" {
+							t.Errorf("Reconcile(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+				},
+				opts: []ReconcilerOption{
+					WithEngine(&NopEngine{}),
+					WithChildResourcePatcher(ChildResourcePatcherFunc(func(_ resource.ParentResource, _ []resource.ChildResource) ([]resource.ChildResource, error) {
+						return []resource.ChildResource{
+							fake.NewMockResource(withID("a", "b")),
+							fake.NewMockResource(withID("b", "")),
+						}, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFunc(func(_ context.Context, _ resource.ChildResource) (bool, error) {
+						return false, nil
+					})),
+				},
+			},
+			want: want{
+				result: reconcile.Result{RequeueAfter: defaultShortWait},
+			},
+		},
+		"PostApplyHookFailed": {
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    test.NewMockGetFn(nil),
+					MockUpdate: test.NewMockUpdateFn(nil),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj runtime.Object) error {
+						got := obj.(*fake.MockResource)
+						gotCond, err := resource.GetCondition(got, v1alpha1.TypeSynced)
+						if err != nil {
+							t.Errorf("Reconcile(...): error getting condition\n%s", err.Error())
+						}
+						wantCond := v1alpha1.ReconcileError(errors.Wrap(errBoom, errPostApplyHook))
+						if diff := cmp.Diff(wantCond, gotCond); diff != "" {
+							t.Errorf("Reconcile(...): -want, +got:\n%s", diff)
+						}
+						gotReady, err := resource.GetCondition(got, v1alpha1.TypeReady)
+						if err != nil {
+							t.Errorf("Reconcile(...): error getting condition\n%s", err.Error())
+						}
+						if diff := cmp.Diff(v1alpha1.Unavailable(), gotReady); diff != "" {
+							t.Errorf("Reconcile(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+				},
+				opts: []ReconcilerOption{
+					WithEngine(&NopEngine{}),
+					WithChildResourcePatcher(ChildResourcePatcherFunc(func(_ resource.ParentResource, _ []resource.ChildResource) ([]resource.ChildResource, error) {
+						return nil, nil
+					})),
+					WithPostApplyHook(HookFunc(func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) error {
+						return errBoom
+					})),
+				},
+			},
+			want: want{
+				result: reconcile.Result{RequeueAfter: defaultShortWait},
+			},
+		},
+		"PostApplyHookJobPending": {
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    test.NewMockGetFn(nil),
+					MockUpdate: test.NewMockUpdateFn(nil),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj runtime.Object) error {
+						got := obj.(*fake.MockResource)
+						gotCond, err := resource.GetCondition(got, v1alpha1.TypeSynced)
+						if err != nil {
+							t.Errorf("Reconcile(...): error getting condition\n%s", err.Error())
+						}
+						if diff := cmp.Diff(v1alpha1.ReconcileSuccess().WithMessage(msgWaitingForHookJobs), gotCond); diff != "" {
+							t.Errorf("Reconcile(...): a pending hook job should not be reported as an error: -want, +got:\n%s", diff)
+						}
+						gotReady, err := resource.GetCondition(got, v1alpha1.TypeReady)
+						if err != nil {
+							t.Errorf("Reconcile(...): error getting condition\n%s", err.Error())
+						}
+						wantReady := v1alpha1.Unavailable().WithMessage((&HookJobPendingError{Name: fakeName, Namespace: fakeNamespace}).Error())
+						if diff := cmp.Diff(wantReady, gotReady); diff != "" {
+							t.Errorf("Reconcile(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+				},
+				opts: []ReconcilerOption{
+					WithEngine(&NopEngine{}),
+					WithChildResourcePatcher(ChildResourcePatcherFunc(func(_ resource.ParentResource, _ []resource.ChildResource) ([]resource.ChildResource, error) {
+						return nil, nil
+					})),
+					WithPostApplyHook(HookFunc(func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) error {
+						return &HookJobPendingError{Name: fakeName, Namespace: fakeNamespace}
+					})),
+				},
+			},
+			want: want{
+				result: reconcile.Result{RequeueAfter: defaultShortWait},
+			},
+		},
+		"PreRenderHookJobPending": {
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    test.NewMockGetFn(nil),
+					MockUpdate: test.NewMockUpdateFn(nil),
+					MockPatch: test.NewMockPatchFn(nil, func(obj runtime.Object) error {
+						if obj.(metav1.Object).GetName() != fakeName {
+							t.Errorf("Reconcile(...): only the pre-render hook jobs should be applied until they complete")
+						}
+						return nil
+					}),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj runtime.Object) error {
+						got := obj.(*fake.MockResource)
+						gotCond, err := resource.GetCondition(got, v1alpha1.TypeSynced)
+						if err != nil {
+							t.Errorf("Reconcile(...): error getting condition\n%s", err.Error())
+						}
+						if diff := cmp.Diff(v1alpha1.ReconcileSuccess().WithMessage(msgWaitingForHookJobs), gotCond); diff != "" {
+							t.Errorf("Reconcile(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+				},
+				opts: []ReconcilerOption{
+					WithEngine(&NopEngine{}),
+					WithChildResourcePatcher(ChildResourcePatcherFunc(func(_ resource.ParentResource, _ []resource.ChildResource) ([]resource.ChildResource, error) {
+						return []resource.ChildResource{
+							fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("app", fakeNamespace)),
+							fake.NewMockResource(
+								fake.WithGVK(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}),
+								fake.WithNamespaceName(fakeName, fakeNamespace),
+								fake.WithAdditionalAnnotations(map[string]string{HookAnnotationKey: HookPreRenderValue}),
+							),
+						}, nil
+					})),
+				},
+			},
+			want: want{
+				result: reconcile.Result{RequeueAfter: defaultShortWait},
+			},
+		},
+		"Paused": {
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj runtime.Object) error {
+						got := obj.(*fake.MockResource)
+						gotCond, err := resource.GetCondition(got, v1alpha1.TypeSynced)
+						if err != nil {
+							t.Errorf("Reconcile(...): error getting condition\n%s", err.Error())
+						}
+						if diff := cmp.Diff(Paused(), gotCond); diff != "" {
+							t.Errorf("Reconcile(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+				},
+				opts: []ReconcilerOption{
+					WithPauseSwitch(NewStaticPauseSwitch(true)),
+					WithEngine(EngineFunc(func(_ resource.ParentResource) ([]resource.ChildResource, error) {
+						t.Errorf("Reconcile(...): unexpected render while paused")
+						return nil, nil
+					})),
+				},
+			},
+			want: want{
+				result: reconcile.Result{RequeueAfter: defaultShortWait},
+			},
+		},
+		"ReportOnly": {
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil),
+					MockUpdate: func(_ context.Context, _ runtime.Object, _ ...client.UpdateOption) error {
+						t.Errorf("Reconcile(...): unexpected update call in report-only mode")
+						return nil
+					},
+					MockPatch: func(_ context.Context, _ runtime.Object, _ client.Patch, _ ...client.PatchOption) error {
+						t.Errorf("Reconcile(...): unexpected patch call in report-only mode")
+						return nil
+					},
+					MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj runtime.Object) error {
+						got := obj.(*fake.MockResource)
+						gotCond, err := resource.GetCondition(got, v1alpha1.TypeSynced)
+						if err != nil {
+							t.Errorf("Reconcile(...): error getting condition\n%s", err.Error())
+						}
+						wantCond := v1alpha1.ReconcileSuccess().WithMessage(fmt.Sprintf("%s: %s", msgReportOnly, ApplyPlan{Changes: []PlannedChange{{Operation: OperationNone}}}.Summary()))
+						if diff := cmp.Diff(wantCond, gotCond); diff != "" {
+							t.Errorf("Reconcile(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+				},
+				opts: []ReconcilerOption{
+					WithReportOnly(),
+					WithPreRenderHook(HookFunc(func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) error {
+						t.Errorf("Reconcile(...): unexpected pre-render hook call in report-only mode")
+						return nil
+					})),
+					WithValuesProvider(mockInitializer{
+						ValuesProviderFunc: func(_ context.Context, _ resource.ParentResource) (map[string]interface{}, error) {
+							return nil, nil
+						},
+						initialize: func(_ context.Context, _ resource.ParentResource) (map[string]interface{}, error) {
+							t.Errorf("Reconcile(...): unexpected initialization of values in report-only mode")
+							return nil, nil
+						},
+					}),
+					WithEngine(&NopEngine{}),
+					WithChildResourcePatcher(ChildResourcePatcherFunc(func(_ resource.ParentResource, _ []resource.ChildResource) ([]resource.ChildResource, error) {
+						return []resource.ChildResource{fake.NewMockResource()}, nil
+					})),
+				},
+			},
+			want: want{
+				result: reconcile.Result{RequeueAfter: defaultLongWait},
+			},
+		},
+		"ReportOnlyDeleted": {
+			args: args{
+				kube: &test.MockClient{
+					MockGet: func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+						mobj, _ := obj.(metav1.Object)
+						now := metav1.Now()
+						mobj.SetDeletionTimestamp(&now)
+						return nil
+					},
+				},
+				opts: []ReconcilerOption{
+					WithReportOnly(),
+					WithEngine(EngineFunc(func(_ resource.ParentResource) ([]resource.ChildResource, error) {
+						t.Errorf("Reconcile(...): unexpected render of a deleted parent resource in report-only mode")
+						return nil, nil
+					})),
+					WithFinalizer(rresource.FinalizerFns{RemoveFinalizerFn: func(_ context.Context, _ rresource.Object) error {
+						return nil
+					}}),
+				},
+			},
+			want: want{
+				result: reconcile.Result{Requeue: false},
+			},
+		},
+		"Published": {
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    test.NewMockGetFn(nil),
+					MockUpdate: test.NewMockUpdateFn(nil),
+					MockPatch: func(_ context.Context, _ runtime.Object, _ client.Patch, _ ...client.PatchOption) error {
+						t.Errorf("Reconcile(...): unexpected patch call when publishing")
+						return nil
+					},
+					MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj runtime.Object) error {
+						got := obj.(*fake.MockResource)
+						gotCond, err := resource.GetCondition(got, v1alpha1.TypeSynced)
+						if err != nil {
+							t.Errorf("Reconcile(...): error getting condition\n%s", err.Error())
+						}
+						wantCond := v1alpha1.ReconcileSuccess().WithMessage(fmt.Sprintf("%s at revision %s", msgPublished, "cafe"))
+						if diff := cmp.Diff(wantCond, gotCond); diff != "" {
+							t.Errorf("Reconcile(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+				},
+				opts: []ReconcilerOption{
+					WithEngine(&NopEngine{}),
+					WithChildResourcePatcher(ChildResourcePatcherFunc(func(_ resource.ParentResource, _ []resource.ChildResource) ([]resource.ChildResource, error) {
+						return []resource.ChildResource{fake.NewMockResource()}, nil
+					})),
+					WithManifestPublisher(ManifestPublisherFunc(func(_ context.Context, _ resource.ParentResource, list []resource.ChildResource) (string, error) {
+						if len(list) != 1 {
+							t.Errorf("Reconcile(...): want 1 published child resource, got %d", len(list))
+						}
+						return "cafe", nil
+					})),
+				},
+			},
+			want: want{
+				result: reconcile.Result{RequeueAfter: defaultLongWait},
+			},
+		},
 		"Success": {
 			args: args{
 				kube: &test.MockClient{
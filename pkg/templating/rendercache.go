@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	renderCacheKeysDir    = "keys"
+	renderCacheObjectsDir = "objects"
+
+	errCacheRender      = "cannot verify the render against the render cache"
+	errRenderKey        = "cannot calculate the key of the render"
+	errReadRenderCache  = "cannot read the render cache"
+	errWriteRenderCache = "cannot write the render cache"
+	errRenderMismatch   = "child resources differ from the ones cached for the same templates and input"
+)
+
+// RenderKey returns the key of the render of the given input with the
+// templates whose digest is given. It covers the kind, the metadata that the
+// templates and patchers can see and the spec of the input, but not its
+// status or the metadata that changes with every write, so that the same
+// input rendered in CI and by the controller has the same key.
+func RenderKey(source string, input resource.ParentResource) (string, error) {
+	b, err := json.Marshal(map[string]interface{}{
+		"source":      source,
+		"kind":        input.GroupVersionKind().String(),
+		"name":        input.GetName(),
+		"namespace":   input.GetNamespace(),
+		"labels":      input.GetLabels(),
+		"annotations": input.GetAnnotations(),
+		"spec":        input.UnstructuredContent()["spec"],
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// NewDirectoryRenderCache returns a new *DirectoryRenderCache that stores the
+// renders in the given directory.
+func NewDirectoryRenderCache(dir string) *DirectoryRenderCache {
+	return &DirectoryRenderCache{dir: dir}
+}
+
+// DirectoryRenderCache is a RenderCache that is content-addressed in a
+// directory: the manifests of every render are written to objects/ in a file
+// named after their digest, and the digest is written to keys/ in a file named
+// after the key of the render. The directory can be shared with CI, e.g.
+// through a volume or an artifact, to verify its pre-renders against the ones
+// of the controller. The manifests of empty renders are not written.
+type DirectoryRenderCache struct {
+	dir string
+}
+
+// Get returns the digest of the render with the given key, if it is cached.
+func (c *DirectoryRenderCache) Get(_ context.Context, key string) (string, bool, error) {
+	b, err := ioutil.ReadFile(filepath.Join(c.dir, renderCacheKeysDir, key))
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimSpace(string(b)), true, nil
+}
+
+// Put caches the given child resources as the render with the given key and
+// returns their digest.
+func (c *DirectoryRenderCache) Put(_ context.Context, key string, list []resource.ChildResource) (string, error) {
+	digest, err := HashChildren(list)
+	if err != nil {
+		return "", errors.Wrap(err, errHashChildren)
+	}
+	if err := writeManifests(filepath.Join(c.dir, renderCacheObjectsDir, digest+".yaml"), list); err != nil {
+		return "", err
+	}
+	file := filepath.Join(c.dir, renderCacheKeysDir, key)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return "", err
+	}
+	// The key is written last and renamed into place so that readers never
+	// see a key whose manifests are missing or half written.
+	if err := ioutil.WriteFile(file+".tmp", []byte(digest+"\n"), 0644); err != nil {
+		return "", err
+	}
+	return digest, os.Rename(file+".tmp", file)
+}
+
+// cacheRender verifies the given child resources rendered from the given input
+// against the cached render with the same key, and caches them if there is
+// none yet.
+func (r *Reconciler) cacheRender(ctx context.Context, input resource.ParentResource, list []resource.ChildResource) error {
+	key, err := RenderKey(r.sourceDigest, input)
+	if err != nil {
+		return errors.Wrap(err, errRenderKey)
+	}
+	digest, err := HashChildren(list)
+	if err != nil {
+		return errors.Wrap(err, errHashChildren)
+	}
+	cached, ok, err := r.renderCache.Get(ctx, key)
+	if err != nil {
+		return errors.Wrap(err, errReadRenderCache)
+	}
+	if ok {
+		if cached != digest {
+			return &resource.RenderError{Err: errors.Errorf("%s: render %s has digest %s instead of %s", errRenderMismatch, key, digest, cached)}
+		}
+		return nil
+	}
+	_, err = r.renderCache.Put(ctx, key, list)
+	return errors.Wrap(err, errWriteRenderCache)
+}
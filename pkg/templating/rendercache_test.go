@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	runtimefake "github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ RenderCache = &DirectoryRenderCache{}
+)
+
+func TestRenderKey(t *testing.T) {
+	parent := func(spec, status string) resource.ParentResource {
+		return fake.NewMockResource(fake.FromYAML([]byte("spec: "+spec+"\nstatus: "+status)), fake.WithGVK(fake.MockParentGVK), fake.WithNamespaceName("cool", namespace))
+	}
+	base, err := RenderKey("rev", parent("{size: 1}", "{}"))
+	if err != nil {
+		t.Fatalf("RenderKey(...): %s", err)
+	}
+	cases := map[string]struct {
+		reason string
+		source string
+		input  resource.ParentResource
+		same   bool
+	}{
+		"StatusChanged": {
+			reason: "The status of the input should not change the key",
+			source: "rev",
+			input:  parent("{size: 1}", "{ready: true}"),
+			same:   true,
+		},
+		"SpecChanged": {
+			reason: "The spec of the input should change the key",
+			source: "rev",
+			input:  parent("{size: 2}", "{}"),
+		},
+		"SourceChanged": {
+			reason: "The revision of the templates should change the key",
+			source: "other-rev",
+			input:  parent("{size: 1}", "{}"),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := RenderKey(tc.source, tc.input)
+			if err != nil {
+				t.Fatalf("\nReason: %s\nRenderKey(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.same, got == base); diff != "" {
+				t.Errorf("\nReason: %s\nRenderKey(...): -want same key, +got same key:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRenderCache(t *testing.T) {
+	child := func(name string) resource.ChildResource {
+		return fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName(name, namespace))
+	}
+	cases := map[string]struct {
+		reason string
+		first  []resource.ChildResource
+		second []resource.ChildResource
+		want   resource.FailureKind
+	}{
+		"SameRender": {
+			reason: "A render that is the same as the cached one should be verified",
+			first:  []resource.ChildResource{child("cool")},
+			second: []resource.ChildResource{child("cool")},
+			want:   resource.FailureUnknown,
+		},
+		"DifferentRender": {
+			reason: "A render that differs from the cached one should fail",
+			first:  []resource.ChildResource{child("cool")},
+			second: []resource.ChildResource{child("uncool")},
+			want:   resource.FailureRender,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "rendercache")
+			if err != nil {
+				t.Fatalf("cannot create temporary directory: %s", err)
+			}
+			defer os.RemoveAll(dir) // nolint:errcheck
+
+			list := tc.first
+			mgr := &runtimefake.Manager{
+				Client: &test.MockClient{},
+				Scheme: runtimefake.SchemeWith(&fake.MockResource{}),
+			}
+			r := NewReconciler(mgr, fake.MockParentGVK,
+				WithEngine(EngineFunc(func(_ resource.ParentResource) ([]resource.ChildResource, error) {
+					return list, nil
+				})),
+				WithChildResourcePatcher(ChildResourcePatcherFunc(func(_ resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+					return list, nil
+				})),
+				WithRenderCache(NewDirectoryRenderCache(dir), "rev"),
+			)
+			parent := fake.NewMockResource(fake.WithGVK(fake.MockParentGVK), fake.WithNamespaceName("cool", namespace))
+			if _, err := r.Render(context.Background(), parent); err != nil {
+				t.Fatalf("\nReason: %s\nRender(...): first render: %s", tc.reason, err)
+			}
+			list = tc.second
+			_, err = r.Render(context.Background(), parent)
+			if diff := cmp.Diff(tc.want, resource.Classify(err)); diff != "" {
+				t.Errorf("\nReason: %s\nRender(...): -want failure kind, +got failure kind:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
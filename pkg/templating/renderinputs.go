@@ -0,0 +1,165 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/crossplane/templating-controller/pkg/operations"
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// RenderInputObject is an object of the cluster that was read to resolve the
+// input of a render, e.g. a ConfigMap a lookup refers to. The data of the
+// object is part of the values of the provider that read it.
+type RenderInputObject struct {
+	APIVersion      string `json:"apiVersion,omitempty"`
+	Kind            string `json:"kind"`
+	Namespace       string `json:"namespace,omitempty"`
+	Name            string `json:"name"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+
+	// Missing is true if the object did not exist, which may affect the
+	// render as much as its content.
+	Missing bool `json:"missing,omitempty"`
+}
+
+// ProvidedValues are the values a ValuesProvider supplied to a render and the
+// objects it read to supply them, in the order it read them.
+type ProvidedValues struct {
+	Provider string                 `json:"provider"`
+	Values   map[string]interface{} `json:"values,omitempty"`
+	Objects  []RenderInputObject    `json:"objects,omitempty"`
+}
+
+// RenderInputSet is everything that fed into the render of a parent resource,
+// so that the render can be reproduced outside of the controller. Rendering
+// Input with the templates of the Source revision gives the same child
+// resources as the controller.
+type RenderInputSet struct {
+	// Source is the digest of the templates, if the reconciler knows it.
+	Source string `json:"source,omitempty"`
+
+	// RenderKey is the key of the render in a RenderCache.
+	RenderKey string `json:"renderKey"`
+
+	// Parent is the parent resource as the engine sees it before the values
+	// are set, i.e. converted into the served version and without the
+	// annotations the reconciler sets with the result of the reconciles.
+	Parent resource.ParentResource `json:"parent"`
+
+	// Cluster are the facts about the cluster that the templates see under
+	// the capabilities parameter.
+	Cluster interface{} `json:"cluster,omitempty"`
+
+	// Values are the values of every ValuesProvider, in the order they are
+	// applied. The values of the latter ones take precedence. Note that they
+	// include the values of Secrets, e.g. the generated values.
+	Values []ProvidedValues `json:"values,omitempty"`
+
+	// Input is the resolved input that the engine and the patchers are given.
+	Input resource.ParentResource `json:"input"`
+}
+
+// RenderInputs resolves the input of the render of the given parent resource
+// like Render does and reports where every part of it came from. Like Render,
+// it does not write anything to the cluster.
+func (r *Reconciler) RenderInputs(ctx context.Context, cr resource.ParentResource) (*RenderInputSet, error) {
+	parent, err := r.renderInputWith(ctx, cr, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, errRenderInput)
+	}
+	s := &RenderInputSet{Source: r.sourceDigest, Parent: parent}
+	merged := map[string]interface{}{}
+	for _, p := range r.values {
+		rec := &readRecorder{}
+		vals, err := p.Values(withReadRecorder(ctx, rec), parent)
+		if err != nil {
+			return nil, errors.Wrap(errors.Wrap(err, errValuesProvider), errRenderInput)
+		}
+		pv := ProvidedValues{Provider: strings.TrimPrefix(fmt.Sprintf("%T", p), "*"), Values: map[string]interface{}{}, Objects: rec.objects}
+		for k, v := range vals {
+			merged[k] = v
+			if k == resource.CapabilitiesParameter {
+				s.Cluster = v
+				continue
+			}
+			pv.Values[k] = v
+		}
+		s.Values = append(s.Values, pv)
+	}
+	s.Input = parent
+	if len(r.values) != 0 {
+		if s.Input, err = operations.InputWithValues(parent, merged); err != nil {
+			return nil, errors.Wrap(err, errRenderInput)
+		}
+	}
+	if s.RenderKey, err = RenderKey(r.sourceDigest, s.Input); err != nil {
+		return nil, errors.Wrap(err, errRenderKey)
+	}
+	return s, nil
+}
+
+type readRecorderKey struct{}
+
+// withReadRecorder returns a context that makes the ClusterRouter record the
+// objects it reads in the given readRecorder.
+func withReadRecorder(ctx context.Context, rec *readRecorder) context.Context {
+	return context.WithValue(ctx, readRecorderKey{}, rec)
+}
+
+func readRecorderFrom(ctx context.Context) *readRecorder {
+	rec, _ := ctx.Value(readRecorderKey{}).(*readRecorder)
+	return rec
+}
+
+type readRecorder struct {
+	mu      sync.Mutex
+	objects []RenderInputObject
+}
+
+// record records the given object read with the given key and error. The
+// reads that fail for other reasons than a missing object fail the render
+// anyway.
+func (rec *readRecorder) record(key client.ObjectKey, obj runtime.Object, err error) {
+	if err != nil && !kerrors.IsNotFound(err) {
+		return
+	}
+	o := RenderInputObject{Namespace: key.Namespace, Name: key.Name, Missing: err != nil}
+	if gvk, gerr := apiutil.GVKForObject(obj, clientgoscheme.Scheme); gerr == nil {
+		o.APIVersion, o.Kind = gvk.GroupVersion().String(), gvk.Kind
+	} else {
+		o.Kind = strings.TrimPrefix(fmt.Sprintf("%T", obj), "*")
+	}
+	if m, ok := obj.(metav1.Object); ok && err == nil {
+		o.ResourceVersion = m.GetResourceVersion()
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.objects = append(rec.objects, o)
+}
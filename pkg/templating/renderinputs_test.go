@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+func TestRenderInputs(t *testing.T) {
+	kube := &test.MockClient{
+		MockGet: func(_ context.Context, key client.ObjectKey, obj runtime.Object) error {
+			if key.Name != "found" {
+				return kerrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, key.Name)
+			}
+			obj.(*corev1.ConfigMap).ResourceVersion = "3"
+			return nil
+		},
+	}
+	caps := map[string]interface{}{"platform": "linux/amd64"}
+	var r *Reconciler
+	lookup := ValuesProviderFunc(func(ctx context.Context, _ resource.ParentResource) (map[string]interface{}, error) {
+		for _, name := range []string{"found", "gone"} {
+			if err := r.clusters.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &corev1.ConfigMap{}); client.IgnoreNotFound(err) != nil {
+				return nil, err
+			}
+		}
+		return map[string]interface{}{"team": "a", resource.CapabilitiesParameter: caps}, nil
+	})
+	override := ValuesProviderFunc(func(_ context.Context, _ resource.ParentResource) (map[string]interface{}, error) {
+		return map[string]interface{}{"team": "b"}, nil
+	})
+	r = benchmarkReconciler(kube, WithValuesProvider(lookup, override), WithSourceDigest("digest"))
+
+	got, err := r.RenderInputs(context.Background(), benchmarkParent())
+	if err != nil {
+		t.Fatalf("RenderInputs(...): unexpected error: %s", err)
+	}
+	want := []ProvidedValues{
+		{
+			Provider: "templating.ValuesProviderFunc",
+			Values:   map[string]interface{}{"team": "a"},
+			Objects: []RenderInputObject{
+				{APIVersion: "v1", Kind: "ConfigMap", Namespace: namespace, Name: "found", ResourceVersion: "3"},
+				{APIVersion: "v1", Kind: "ConfigMap", Namespace: namespace, Name: "gone", Missing: true},
+			},
+		},
+		{
+			Provider: "templating.ValuesProviderFunc",
+			Values:   map[string]interface{}{"team": "b"},
+		},
+	}
+	if diff := cmp.Diff(want, got.Values); diff != "" {
+		t.Errorf("RenderInputs(...): every provider should be reported with the objects it read: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(caps, got.Cluster); diff != "" {
+		t.Errorf("RenderInputs(...): the capabilities should be reported as the facts about the cluster: -want, +got:\n%s", diff)
+	}
+	team, _, _ := unstructured.NestedString(got.Input.UnstructuredContent(), "spec", resource.ParametersField, "team")
+	if diff := cmp.Diff("b", team); diff != "" {
+		t.Errorf("RenderInputs(...): the values of the latter providers should take precedence in the input: -want, +got:\n%s", diff)
+	}
+	if _, ok, _ := unstructured.NestedFieldNoCopy(got.Parent.UnstructuredContent(), "spec", resource.ParametersField, "team"); ok {
+		t.Errorf("RenderInputs(...): the parent resource should be reported without the values")
+	}
+	key, _ := RenderKey("digest", got.Input)
+	if diff := cmp.Diff(key, got.RenderKey); diff != "" {
+		t.Errorf("RenderInputs(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"reflect"
+)
+
+// ChildOperation is the operation that is needed to bring a child resource in
+// the cluster to its desired state.
+type ChildOperation string
+
+// Child operations.
+const (
+	OperationCreate ChildOperation = "Create"
+	OperationUpdate ChildOperation = "Update"
+	OperationNone   ChildOperation = "None"
+	OperationDelete ChildOperation = "Delete"
+)
+
+// IsSubset returns true if all the fields in desired exist in current with the
+// same values. The fields that exist only in current, such as the ones
+// populated by the API server, are ignored.
+func IsSubset(desired, current interface{}) bool {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		c, ok := current.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range d {
+			if !IsSubset(v, c[k]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		c, ok := current.([]interface{})
+		if !ok || len(c) != len(d) {
+			return false
+		}
+		for i := range d {
+			if !IsSubset(d[i], c[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(desired, current)
+	}
+}
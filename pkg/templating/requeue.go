@@ -0,0 +1,71 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+)
+
+const (
+	errParseRequeueAfter = "cannot parse requeue-after hint"
+)
+
+// SetRequeueAfter suggests that the parent resource of the given child
+// resource be reconciled again after the given duration, e.g. for a cloud
+// resource that is known to take minutes to become ready. ChildResourcePatchers
+// and ReadinessCheckers can use it; the templates can set
+// RequeueAfterAnnotationKey directly.
+func SetRequeueAfter(o metav1.Object, d time.Duration) {
+	meta.AddAnnotations(o, map[string]string{RequeueAfterAnnotationKey: d.String()})
+}
+
+// RequeueAfter returns the duration that the given child resource suggests
+// with RequeueAfterAnnotationKey to reconcile its parent resource again
+// after, or zero if it does not suggest one.
+func RequeueAfter(o metav1.Object) (time.Duration, error) {
+	val, ok := o.GetAnnotations()[RequeueAfterAnnotationKey]
+	if !ok {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(val)
+	return d, errors.Wrap(err, errParseRequeueAfter)
+}
+
+// shortestRequeue returns the shortest of the given durations that are greater
+// than zero, or zero if there is none.
+func shortestRequeue(d ...time.Duration) time.Duration {
+	var result time.Duration
+	for _, v := range d {
+		if v > 0 && (result == 0 || v < result) {
+			result = v
+		}
+	}
+	return result
+}
+
+// requeueAfter returns the given hint if there is one, or the given default.
+func requeueAfter(hint, def time.Duration) time.Duration {
+	if hint > 0 {
+		return hint
+	}
+	return def
+}
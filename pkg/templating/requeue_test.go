@@ -0,0 +1,88 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestRequeueAfter(t *testing.T) {
+	_, errInvalid := time.ParseDuration("soon")
+	type want struct {
+		d   time.Duration
+		err error
+	}
+	cases := map[string]struct {
+		reason      string
+		annotations map[string]string
+		want        want
+	}{
+		"NoHint": {
+			reason: "Zero should be returned if the child resource does not suggest a requeue",
+		},
+		"Hint": {
+			reason:      "The suggested duration should be returned",
+			annotations: map[string]string{RequeueAfterAnnotationKey: "5m"},
+			want:        want{d: 5 * time.Minute},
+		},
+		"InvalidHint": {
+			reason:      "An error should be returned if the suggested duration cannot be parsed",
+			annotations: map[string]string{RequeueAfterAnnotationKey: "soon"},
+			want:        want{err: errors.Wrap(errInvalid, errParseRequeueAfter)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			d, err := RequeueAfter(fake.NewMockResource(fake.WithAdditionalAnnotations(tc.annotations)))
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nRequeueAfter(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.d, d); diff != "" {
+				t.Errorf("\nReason: %s\nRequeueAfter(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSetRequeueAfter(t *testing.T) {
+	o := fake.NewMockResource()
+	SetRequeueAfter(o, 5*time.Minute)
+	d, err := RequeueAfter(o)
+	if err != nil {
+		t.Fatalf("RequeueAfter(...): %s", err)
+	}
+	if diff := cmp.Diff(5*time.Minute, d); diff != "" {
+		t.Errorf("RequeueAfter(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestShortestRequeue(t *testing.T) {
+	if diff := cmp.Diff(time.Minute, shortestRequeue(0, 5*time.Minute, time.Minute)); diff != "" {
+		t.Errorf("shortestRequeue(...): -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(time.Duration(0), shortestRequeue(0, 0)); diff != "" {
+		t.Errorf("shortestRequeue(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errStampResult = "cannot annotate parent resource with the result of the reconcile"
+)
+
+// resultAnnotationKeys are the annotations that the reconciler sets on the
+// parent resources, which are not an input of the render.
+var resultAnnotationKeys = []string{LastAppliedHashAnnotationKey, LastSyncTimeAnnotationKey, TemplateRevisionAnnotationKey}
+
+// WithResultAnnotations returns a ReconcilerOption that annotates the parent
+// resources with the hash of the child resources, the time of the sync and
+// the given revision of the templates after every successful sync, so that
+// dashboards, smoke tests and promotion pipelines can key off them without
+// parsing the conditions. An empty revision omits the revision annotation.
+func WithResultAnnotations(revision string) ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.results = &resultAnnotator{revision: revision, now: time.Now}
+	}
+}
+
+type resultAnnotator struct {
+	revision string
+	now      func() time.Time
+}
+
+// stampResult patches the result annotations of the given parent resource
+// with the given applied child resources. Only the annotations are patched so
+// that the status that is being built up in the given parent resource is
+// kept until it is written.
+func (r *Reconciler) stampResult(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) error {
+	if r.results == nil {
+		return nil
+	}
+	hash, err := HashChildren(list)
+	if err != nil {
+		return errors.Wrap(err, errHashChildren)
+	}
+	a := map[string]string{
+		LastAppliedHashAnnotationKey: hash,
+		LastSyncTimeAnnotationKey:    r.results.now().UTC().Format(time.RFC3339),
+	}
+	if r.results.revision != "" {
+		a[TemplateRevisionAnnotationKey] = r.results.revision
+	}
+	stamped, ok := cr.DeepCopyObject().(resource.ParentResource)
+	if !ok {
+		return errors.New(errCopyParent)
+	}
+	patch := client.MergeFrom(stamped.DeepCopyObject())
+	meta.AddAnnotations(stamped, a)
+	if err := r.client.Patch(ctx, stamped, patch); err != nil {
+		return errors.Wrap(err, errStampResult)
+	}
+	meta.AddAnnotations(cr, a)
+	cr.SetResourceVersion(stamped.GetResourceVersion())
+	return nil
+}
+
+// withoutResultAnnotations returns the given parent resource if it does not
+// have any result annotations, or a copy without them, so that the time of
+// the last sync does not change the input of the render.
+func withoutResultAnnotations(cr resource.ParentResource) resource.ParentResource {
+	found := false
+	for _, k := range resultAnnotationKeys {
+		if _, ok := cr.GetAnnotations()[k]; ok {
+			found = true
+		}
+	}
+	if !found {
+		return cr
+	}
+	input, ok := cr.DeepCopyObject().(resource.ParentResource)
+	if !ok {
+		return cr
+	}
+	meta.RemoveAnnotations(input, resultAnnotationKeys...)
+	return input
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	runtimefake "github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestStampResult(t *testing.T) {
+	list := []resource.ChildResource{fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName("db", "ns"))}
+	hash, err := HashChildren(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	var patched map[string]string
+	mgr := &runtimefake.Manager{
+		Client: &test.MockClient{
+			MockPatch: func(_ context.Context, obj runtime.Object, _ client.Patch, _ ...client.PatchOption) error {
+				o := obj.(metav1.Object)
+				patched = o.GetAnnotations()
+				o.SetResourceVersion("2")
+				return nil
+			},
+		},
+		Scheme: runtimefake.SchemeWith(&fake.MockResource{}),
+	}
+	r := NewReconciler(mgr, fake.MockParentGVK, WithResultAnnotations("rev"))
+	r.results.now = func() time.Time { return now }
+
+	cr := fake.NewMockResource(fake.WithGVK(fake.MockParentGVK))
+	cr.SetResourceVersion("1")
+	cr.Object["status"] = map[string]interface{}{"templateRevision": "rev"}
+	if err := r.stampResult(context.Background(), cr, list); err != nil {
+		t.Fatalf("stampResult(...): %s", err)
+	}
+	want := map[string]string{
+		LastAppliedHashAnnotationKey:  hash,
+		LastSyncTimeAnnotationKey:     "2020-06-01T12:00:00Z",
+		TemplateRevisionAnnotationKey: "rev",
+	}
+	if diff := cmp.Diff(want, patched); diff != "" {
+		t.Errorf("stampResult(...): -want patched annotations, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(want, cr.GetAnnotations()); diff != "" {
+		t.Errorf("stampResult(...): -want annotations, +got:\n%s", diff)
+	}
+	if cr.GetResourceVersion() != "2" {
+		t.Errorf("stampResult(...): want the resource version of the patched parent resource, got %s", cr.GetResourceVersion())
+	}
+	if diff := cmp.Diff(map[string]interface{}{"templateRevision": "rev"}, cr.Object["status"]); diff != "" {
+		t.Errorf("stampResult(...): the status should be kept: -want, +got:\n%s", diff)
+	}
+}
+
+func TestResultAnnotationsNotInput(t *testing.T) {
+	cr := fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{"user": "value"}))
+	before := syncInput(cr)
+	stamped := fake.NewMockResource(fake.WithAdditionalAnnotations(map[string]string{
+		"user":                    "value",
+		LastSyncTimeAnnotationKey: "2020-06-01T12:00:00Z",
+	}))
+	if diff := cmp.Diff(before, syncInput(stamped)); diff != "" {
+		t.Errorf("syncInput(...): the result annotations should not change the sync input: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"user": "value"}, withoutResultAnnotations(stamped).GetAnnotations()); diff != "" {
+		t.Errorf("withoutResultAnnotations(...): -want, +got:\n%s", diff)
+	}
+	if _, ok := stamped.GetAnnotations()[LastSyncTimeAnnotationKey]; !ok {
+		t.Errorf("withoutResultAnnotations(...): the given parent resource should not be changed")
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	rresource "github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	defaultRevisionHistoryLimit = 10
+	revisionManifestsKey        = "manifests.yaml"
+
+	errGetRevisions     = "cannot get revision history of the parent resource"
+	errSetRevisions     = "cannot set revision history of the parent resource"
+	errSaveRevision     = "cannot save revision"
+	errLoadRevision     = "cannot load revision"
+	errDeleteRevision   = "cannot delete revision"
+	errParseRevision    = "cannot parse rollback revision annotation"
+	errNoRevisionStore  = "rollback requested but no revision store is configured"
+	errHashChildren     = "cannot calculate hash of child resources"
+	errDecodeManifests  = "cannot decode manifests of revision"
+	errRevisionNotFound = "revision is not found in the history"
+)
+
+// HashChildren returns a digest of the given child resources.
+func HashChildren(list []resource.ChildResource) (string, error) {
+	b, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// NewAPIConfigMapRevisionStore returns a new *APIConfigMapRevisionStore.
+// Revisions of cluster-scoped parent resources are stored in the given
+// namespace.
+func NewAPIConfigMapRevisionStore(c client.Client, namespace string) *APIConfigMapRevisionStore {
+	return &APIConfigMapRevisionStore{kube: c, namespace: namespace}
+}
+
+// APIConfigMapRevisionStore stores every revision in a ConfigMap controlled by
+// the parent resource, so that they are garbage collected together.
+type APIConfigMapRevisionStore struct {
+	kube      client.Client
+	namespace string
+}
+
+func (s *APIConfigMapRevisionStore) key(cr resource.ParentResource, rev int64) types.NamespacedName {
+	ns := cr.GetNamespace()
+	if ns == "" {
+		ns = s.namespace
+	}
+	return types.NamespacedName{
+		Name:      fmt.Sprintf("%s-%s-rev-%d", strings.ToLower(cr.GroupVersionKind().Kind), cr.GetName(), rev),
+		Namespace: ns,
+	}
+}
+
+// Save stores the given child resources as the given revision.
+func (s *APIConfigMapRevisionStore) Save(ctx context.Context, cr resource.ParentResource, rev int64, list []resource.ChildResource) error {
+	buf := &bytes.Buffer{}
+	for _, o := range list {
+		b, err := k8syaml.Marshal(o)
+		if err != nil {
+			return err
+		}
+		buf.WriteString("---\n")
+		buf.Write(b)
+	}
+	nn := s.key(cr, rev)
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: nn.Name, Namespace: nn.Namespace},
+		Data:       map[string]string{revisionManifestsKey: buf.String()},
+	}
+	meta.AddOwnerReference(cm, meta.AsController(meta.ReferenceTo(cr, cr.GroupVersionKind())))
+	return rresource.NewAPIPatchingApplicator(s.kube).Apply(ctx, cm)
+}
+
+// Load returns the child resources stored as the given revision.
+func (s *APIConfigMapRevisionStore) Load(ctx context.Context, cr resource.ParentResource, rev int64) ([]resource.ChildResource, error) {
+	cm := &corev1.ConfigMap{}
+	if err := s.kube.Get(ctx, s.key(cr, rev), cm); err != nil {
+		return nil, err
+	}
+	result, err := resource.Decode([]byte(cm.Data[revisionManifestsKey]))
+	return result, errors.Wrap(err, errDecodeManifests)
+}
+
+// Delete removes the given revision from the store.
+func (s *APIConfigMapRevisionStore) Delete(ctx context.Context, cr resource.ParentResource, rev int64) error {
+	nn := s.key(cr, rev)
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: nn.Name, Namespace: nn.Namespace}}
+	return client.IgnoreNotFound(s.kube.Delete(ctx, cm))
+}
+
+// rollbackRevision returns the revision that the parent resource is requested
+// to be rolled back to, or zero if there is no such request.
+func rollbackRevision(cr resource.ParentResource) (int64, error) {
+	val, ok := cr.GetAnnotations()[RollbackToAnnotationKey]
+	if !ok || val == "" {
+		return 0, nil
+	}
+	rev, err := strconv.ParseInt(val, 10, 64)
+	return rev, errors.Wrap(err, errParseRevision)
+}
+
+// rollback returns the child resources of the revision that the parent
+// resource is requested to be rolled back to. The returned bool is false if
+// there is no such request.
+func (r *Reconciler) rollback(ctx context.Context, cr resource.ParentResource) ([]resource.ChildResource, bool, error) {
+	rev, err := rollbackRevision(cr)
+	if err != nil || rev == 0 {
+		return nil, false, err
+	}
+	if r.revisions.store == nil {
+		return nil, false, errors.New(errNoRevisionStore)
+	}
+	revs, err := resource.GetRevisions(cr)
+	if err != nil {
+		return nil, false, errors.Wrap(err, errGetRevisions)
+	}
+	found := false
+	for _, rv := range revs {
+		found = found || rv.Revision == rev
+	}
+	if !found {
+		return nil, false, errors.Errorf("%s: %d", errRevisionNotFound, rev)
+	}
+	list, err := r.revisions.store.Load(ctx, cr, rev)
+	return list, true, errors.Wrap(err, errLoadRevision)
+}
+
+// recordRevision appends a new revision to the history of the parent resource
+// if the given child resources differ from the ones in the latest revision.
+// The oldest revisions are removed once the history limit is exceeded.
+func (r *Reconciler) recordRevision(ctx context.Context, cr resource.ParentResource, list []resource.ChildResource) error {
+	hash, err := HashChildren(list)
+	if err != nil {
+		return errors.Wrap(err, errHashChildren)
+	}
+	revs, err := resource.GetRevisions(cr)
+	if err != nil {
+		return errors.Wrap(err, errGetRevisions)
+	}
+	next := int64(1)
+	if len(revs) > 0 {
+		latest := revs[len(revs)-1]
+		if latest.Hash == hash {
+			return nil
+		}
+		next = latest.Revision + 1
+	}
+	if r.revisions.store != nil {
+		if err := r.revisions.store.Save(ctx, cr, next, list); err != nil {
+			return errors.Wrap(err, errSaveRevision)
+		}
+	}
+	rev := resource.Revision{
+		Revision:  next,
+		Hash:      hash,
+		AppliedAt: metav1.Now(),
+		Children:  make([]resource.ChildReference, len(list)),
+	}
+	for i, o := range list {
+		rev.Children[i] = resource.ReferenceTo(o)
+	}
+	revs = append(revs, rev)
+	for len(revs) > r.revisions.limit {
+		if r.revisions.store != nil {
+			if err := r.revisions.store.Delete(ctx, cr, revs[0].Revision); err != nil {
+				return errors.Wrap(err, errDeleteRevision)
+			}
+		}
+		revs = revs[1:]
+	}
+	return errors.Wrap(resource.SetRevisions(cr, revs), errSetRevisions)
+}
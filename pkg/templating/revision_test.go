@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ RevisionStore = &APIConfigMapRevisionStore{}
+)
+
+type mockRevisionStore struct {
+	saved   map[int64][]resource.ChildResource
+	deleted []int64
+}
+
+func (m *mockRevisionStore) Save(_ context.Context, _ resource.ParentResource, rev int64, list []resource.ChildResource) error {
+	m.saved[rev] = list
+	return nil
+}
+
+func (m *mockRevisionStore) Load(_ context.Context, _ resource.ParentResource, rev int64) ([]resource.ChildResource, error) {
+	return m.saved[rev], nil
+}
+
+func (m *mockRevisionStore) Delete(_ context.Context, _ resource.ParentResource, rev int64) error {
+	m.deleted = append(m.deleted, rev)
+	return nil
+}
+
+func TestRecordRevision(t *testing.T) {
+	first := []resource.ChildResource{fake.NewMockResource(fake.WithNamespaceName("first", ""))}
+	second := []resource.ChildResource{fake.NewMockResource(fake.WithNamespaceName("second", ""))}
+	third := []resource.ChildResource{fake.NewMockResource(fake.WithNamespaceName("third", ""))}
+
+	store := &mockRevisionStore{saved: map[int64][]resource.ChildResource{}}
+	r := &Reconciler{revisions: crRevisions{store: store, limit: 2}}
+	cr := fake.NewMockResource()
+	for _, list := range [][]resource.ChildResource{first, first, second, third} {
+		if err := r.recordRevision(context.Background(), cr, list); err != nil {
+			t.Fatalf("recordRevision(...): unexpected error: %s", err)
+		}
+	}
+	revs, err := resource.GetRevisions(cr)
+	if err != nil {
+		t.Fatalf("GetRevisions(...): unexpected error: %s", err)
+	}
+	var got []int64
+	for _, rv := range revs {
+		got = append(got, rv.Revision)
+	}
+	if diff := cmp.Diff([]int64{2, 3}, got); diff != "" {
+		t.Errorf("recordRevision(...): identical renders should not create new revisions and history should be limited: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff([]int64{1}, store.deleted); diff != "" {
+		t.Errorf("recordRevision(...): revisions removed from the history should be deleted from the store: -want, +got:\n%s", diff)
+	}
+
+	meta := cr.GetAnnotations()
+	meta[RollbackToAnnotationKey] = "2"
+	cr.SetAnnotations(meta)
+	rolled, ok, err := r.rollback(context.Background(), cr)
+	if err != nil || !ok {
+		t.Fatalf("rollback(...): unexpected result: %t, %v", ok, err)
+	}
+	if diff := cmp.Diff(second, rolled); diff != "" {
+		t.Errorf("rollback(...): -want, +got:\n%s", diff)
+	}
+
+	meta[RollbackToAnnotationKey] = "1"
+	cr.SetAnnotations(meta)
+	_, _, err = r.rollback(context.Background(), cr)
+	if diff := cmp.Diff(errors.Errorf("%s: %d", errRevisionNotFound, 1), err, test.EquateErrors()); diff != "" {
+		t.Errorf("rollback(...): revisions that are not in the history should not be loaded: -want, +got:\n%s", diff)
+	}
+}
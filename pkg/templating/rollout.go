@@ -0,0 +1,173 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	msgWaitingForRollout = "waiting for the progressive rollout of the new templates"
+	msgPinnedRevision    = "pinned to template revision %s while revision %s is available"
+)
+
+// HashDirectory returns a hash of the names and contents of all files in the
+// given folder, to be used as the revision of the templates in it.
+func HashDirectory(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+		defer f.Close() // nolint:errcheck
+		_, _ = h.Write([]byte(rel))
+		_, err = io.Copy(h, f)
+		return err
+	})
+	return fmt.Sprintf("%x", h.Sum(nil)), err
+}
+
+func newRolloutTracker(revision string, batch int) *rolloutTracker {
+	return &rolloutTracker{
+		revision:   revision,
+		batch:      batch,
+		inProgress: map[types.UID]bool{},
+		failed:     map[types.UID]bool{},
+	}
+}
+
+// rolloutTracker rolls a new revision of the templates out to the parent
+// resources progressively. At most batch parent resources are re-rendered
+// with the new templates at a time, and the rollout pauses as long as any of
+// them fails. The parent resources that wait for their turn keep their child
+// resources rendered with the old templates untouched. The progress is kept
+// in memory since the new templates always come with a restart of the
+// controller. If pinning is enabled, the parent resources that pin a revision
+// in spec.packVersion are rendered only if it is the current one, regardless
+// of the batch, so that they are upgraded when their pin is changed.
+type rolloutTracker struct {
+	revision string
+	batch    int
+	pinning  bool
+
+	mu         sync.Mutex
+	inProgress map[types.UID]bool
+	failed     map[types.UID]bool
+}
+
+// Admit returns whether the given parent resource can be rendered with the
+// current revision of the templates. Parent resources that have never been
+// rendered successfully are always admitted since there is nothing to keep.
+func (t *rolloutTracker) Admit(cr resource.ParentResource) bool {
+	if t == nil {
+		return true
+	}
+	if pin := t.pinned(cr); pin != "" {
+		return pin == t.revision
+	}
+	if t.batch <= 0 {
+		return true
+	}
+	rev := resource.GetTemplateRevision(cr)
+	if rev == "" || rev == t.revision {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.inProgress[cr.GetUID()] {
+		// The result of the previous attempt of this parent resource decides
+		// whether the rollout is paused.
+		synced, serr := resource.GetCondition(cr, v1alpha1.TypeSynced)
+		ready, rerr := resource.GetCondition(cr, v1alpha1.TypeReady)
+		t.failed[cr.GetUID()] = (serr == nil && synced.Reason == v1alpha1.ReasonReconcileError) ||
+			(rerr == nil && ready.Reason == ReasonDegraded)
+		return true
+	}
+	if len(t.failed) > 0 || len(t.inProgress) >= t.batch {
+		return false
+	}
+	t.inProgress[cr.GetUID()] = true
+	return true
+}
+
+// Waiting returns why the given parent resource that was not admitted waits,
+// and whether it waits for a change of itself rather than for the rollout.
+func (t *rolloutTracker) Waiting(cr resource.ParentResource) (string, bool) {
+	if pin := t.pinned(cr); pin != "" {
+		return fmt.Sprintf(msgPinnedRevision, pin, t.revision), true
+	}
+	return msgWaitingForRollout, false
+}
+
+// Report records the current revision of the templates as the one that is
+// available to the given parent resource.
+func (t *rolloutTracker) Report(cr resource.ParentResource) error {
+	if t == nil || t.revision == "" {
+		return nil
+	}
+	return resource.SetAvailableTemplateRevision(cr, t.revision)
+}
+
+// Done records that the child resources of the given parent resource are
+// rendered with the current revision of the templates and are available.
+func (t *rolloutTracker) Done(cr resource.ParentResource) error {
+	if t == nil || t.revision == "" {
+		return nil
+	}
+	t.Forget(cr)
+	if err := t.Report(cr); err != nil {
+		return err
+	}
+	return resource.SetTemplateRevision(cr, t.revision)
+}
+
+func (t *rolloutTracker) pinned(cr resource.ParentResource) string {
+	if !t.pinning {
+		return ""
+	}
+	return resource.GetPinnedTemplateRevision(cr)
+}
+
+// Forget removes the records of the given parent resource.
+func (t *rolloutTracker) Forget(cr resource.ParentResource) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.inProgress, cr.GetUID())
+	delete(t.failed, cr.GetUID())
+}
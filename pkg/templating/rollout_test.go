@@ -0,0 +1,113 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func renderedWith(rev string) fake.MockResourceOption {
+	return func(r *fake.MockResource) {
+		_ = resource.SetTemplateRevision(r, rev)
+	}
+}
+
+func TestRolloutTracker(t *testing.T) {
+	parent := func(uid, rev string) *fake.MockResource {
+		return fake.NewMockResource(fake.WithUID(types.UID(uid)), renderedWith(rev))
+	}
+	tr := newRolloutTracker("new", 1)
+
+	if !tr.Admit(parent("fresh", "")) {
+		t.Errorf("Admit(...): parent resources that were never rendered should be admitted")
+	}
+	if !tr.Admit(parent("current", "new")) {
+		t.Errorf("Admit(...): parent resources that are rendered with the current revision should be admitted")
+	}
+	first := parent("first", "old")
+	if !tr.Admit(first) {
+		t.Errorf("Admit(...): the first parent resource of the batch should be admitted")
+	}
+	if tr.Admit(parent("second", "old")) {
+		t.Errorf("Admit(...): parent resources beyond the batch size should wait")
+	}
+
+	_ = resource.SetConditions(first, v1alpha1.ReconcileError(errBoom))
+	if !tr.Admit(first) {
+		t.Errorf("Admit(...): a failing parent resource in progress should be retried")
+	}
+	if err := tr.Done(first); err != nil {
+		t.Fatalf("Done(...): unexpected error: %s", err)
+	}
+	if resource.GetTemplateRevision(first) != "new" {
+		t.Errorf("Done(...): the template revision should be recorded")
+	}
+	if !tr.Admit(parent("second", "old")) {
+		t.Errorf("Admit(...): the next parent resource should be admitted once the previous one is done")
+	}
+}
+
+func TestRolloutTrackerPaused(t *testing.T) {
+	tr := newRolloutTracker("new", 2)
+	first := fake.NewMockResource(fake.WithUID("first"), renderedWith("old"))
+	tr.Admit(first)
+	_ = resource.SetConditions(first, v1alpha1.ReconcileError(errBoom))
+	tr.Admit(first)
+	if tr.Admit(fake.NewMockResource(fake.WithUID("second"), renderedWith("old"))) {
+		t.Errorf("Admit(...): the rollout should be paused while a parent resource fails")
+	}
+}
+
+func TestRolloutTrackerPinning(t *testing.T) {
+	pinnedTo := func(rev string) fake.MockResourceOption {
+		return func(r *fake.MockResource) {
+			_ = unstructured.SetNestedField(r.Object, rev, "spec", "packVersion")
+		}
+	}
+	tr := newRolloutTracker("new", 1)
+	tr.pinning = true
+
+	held := fake.NewMockResource(fake.WithUID("held"), renderedWith("old"), pinnedTo("old"))
+	if tr.Admit(held) {
+		t.Errorf("Admit(...): parent resources pinned to another revision should wait")
+	}
+	if msg, pinned := tr.Waiting(held); !pinned || msg != fmt.Sprintf(msgPinnedRevision, "old", "new") {
+		t.Errorf("Waiting(...): want the pin to be reported, got %q", msg)
+	}
+	if !tr.Admit(fake.NewMockResource(fake.WithUID("first"), renderedWith("old"))) {
+		t.Errorf("Admit(...): the first parent resource of the batch should be admitted")
+	}
+	upgraded := fake.NewMockResource(fake.WithUID("upgraded"), renderedWith("old"), pinnedTo("new"))
+	if !tr.Admit(upgraded) {
+		t.Errorf("Admit(...): parent resources pinned to the current revision should be admitted regardless of the batch")
+	}
+	if err := tr.Done(upgraded); err != nil {
+		t.Fatalf("Done(...): unexpected error: %s", err)
+	}
+	if rev, _, _ := unstructured.NestedString(upgraded.Object, "status", "availableTemplateRevision"); rev != "new" {
+		t.Errorf("Done(...): the available template revision should be recorded, got %q", rev)
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// ParameterSchemaFile is the file in the resources directory of a pack that
+// holds the OpenAPI v3 schema of the spec of its parent resources.
+const ParameterSchemaFile = "schema.yaml"
+
+const (
+	errReadParameterSchema    = "cannot read parameters schema"
+	errParseParameterSchema   = "cannot parse parameters schema"
+	errInvalidParameterSchema = "parameters schema is not a valid OpenAPI v3 schema"
+	errInvalidParameters      = "parent resource does not match the parameters schema of the pack"
+)
+
+// LoadParameterSchema returns the *ParameterSchema in the given YAML file,
+// which is an OpenAPI v3 schema, in the same form as the ones of
+// CustomResourceDefinitions, that describes the spec of the parent resources
+// that the templates of the pack consume.
+func LoadParameterSchema(path string) (*ParameterSchema, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, errReadParameterSchema)
+	}
+	props := extv1.JSONSchemaProps{}
+	if err := yaml.UnmarshalStrict(data, &props); err != nil {
+		return nil, errors.Wrap(err, errParseParameterSchema)
+	}
+	return NewParameterSchema(props)
+}
+
+// NewParameterSchema returns a new *ParameterSchema with the given schema of
+// the spec of the parent resources.
+func NewParameterSchema(props extv1.JSONSchemaProps) (*ParameterSchema, error) {
+	internal := &apiextensions.JSONSchemaProps{}
+	if err := extv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(&props, internal, nil); err != nil {
+		return nil, errors.Wrap(err, errInvalidParameterSchema)
+	}
+	v, _, err := validation.NewSchemaValidator(&apiextensions.CustomResourceValidation{OpenAPIV3Schema: internal})
+	if err != nil {
+		return nil, errors.Wrap(err, errInvalidParameterSchema)
+	}
+	return &ParameterSchema{props: props, validate: func(spec interface{}) field.ErrorList {
+		return validation.ValidateCustomResource(field.NewPath("spec"), spec, v)
+	}}, nil
+}
+
+// ParameterSchema is the schema of the spec of the parent resources that a
+// pack ships so that the expectations of its templates and the schema of
+// its CustomResourceDefinition do not drift apart. It is a pre-render Hook
+// that rejects the parent resources that do not match it, which makes them
+// fail with the fields that are wrong instead of a render error.
+type ParameterSchema struct {
+	props    extv1.JSONSchemaProps
+	validate func(spec interface{}) field.ErrorList
+}
+
+// Run returns an error if the spec of the given parent resource does not
+// match the schema.
+func (s *ParameterSchema) Run(_ context.Context, cr resource.ParentResource, _ []resource.ChildResource) error {
+	return s.Validate(cr)
+}
+
+// Validate returns an error that lists the fields of the spec of the given
+// parent resource that do not match the schema.
+func (s *ParameterSchema) Validate(cr resource.ParentResource) error {
+	spec, ok := cr.UnstructuredContent()["spec"]
+	if !ok {
+		spec = map[string]interface{}{}
+	}
+	errs := s.validate(spec)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.Wrap(errs.ToAggregate(), errInvalidParameters)
+}
+
+// Validation returns the validation of a CustomResourceDefinition whose spec
+// is described by the schema, to generate the definitions of the parent
+// resources from.
+func (s *ParameterSchema) Validation() *extv1.CustomResourceValidation {
+	return CRDValidation(s, nil)
+}
+
+// CRDValidation returns the validation of a CustomResourceDefinition whose
+// spec is described by the given parameters schema and whose status has the
+// given status fields, to generate the definitions of the parent resources
+// from. Either of them can be nil, in which case the spec or the status
+// accepts any fields.
+func CRDValidation(params *ParameterSchema, status *StatusFields) *extv1.CustomResourceValidation {
+	spec := extv1.JSONSchemaProps{Type: "object", XPreserveUnknownFields: &preserveUnknownFields}
+	if params != nil {
+		spec = params.props
+	}
+	st := extv1.JSONSchemaProps{Type: "object", XPreserveUnknownFields: &preserveUnknownFields}
+	if status != nil {
+		st = status.Schema()
+	}
+	return &extv1.CustomResourceValidation{
+		OpenAPIV3Schema: &extv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]extv1.JSONSchemaProps{
+				"spec":   spec,
+				"status": st,
+			},
+		},
+	}
+}
+
+var preserveUnknownFields = true
+
+// Help returns a description of the fields of the spec, one per line with
+// their path, type and description, to be shown to the users of the pack.
+func (s *ParameterSchema) Help() string {
+	var lines []string
+	describe(&lines, "spec", s.props)
+	return strings.Join(lines, "\n")
+}
+
+// Fields returns the paths of all fields of the spec that the schema
+// describes, sorted, e.g. spec.parameters.port.
+func (s *ParameterSchema) Fields() []string {
+	var paths []string
+	collectFields(&paths, "spec", s.props)
+	sort.Strings(paths)
+	return paths
+}
+
+func collectFields(paths *[]string, path string, props extv1.JSONSchemaProps) {
+	for name, p := range props.Properties {
+		*paths = append(*paths, path+"."+name)
+		collectFields(paths, path+"."+name, p)
+	}
+}
+
+func describe(lines *[]string, path string, props extv1.JSONSchemaProps) {
+	names := make([]string, 0, len(props.Properties))
+	for name := range props.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	required := map[string]bool{}
+	for _, name := range props.Required {
+		required[name] = true
+	}
+	for _, name := range names {
+		p := props.Properties[name]
+		line := fmt.Sprintf("%s.%s (%s)", path, name, p.Type)
+		if required[name] {
+			line += " required"
+		}
+		if p.Description != "" {
+			line += ": " + p.Description
+		}
+		*lines = append(*lines, line)
+		describe(lines, path+"."+name, p)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ Hook = &ParameterSchema{}
+
+const parameterSchema = `
+type: object
+required:
+- region
+properties:
+  region:
+    type: string
+    description: Region to deploy the database to.
+    enum:
+    - us-east-1
+    - eu-west-1
+  storageGB:
+    type: integer
+    minimum: 20
+`
+
+func TestParameterSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schema")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir) // nolint:errcheck
+	path := filepath.Join(dir, ParameterSchemaFile)
+	if err := ioutil.WriteFile(path, []byte(parameterSchema), 0600); err != nil {
+		t.Fatalf("cannot write schema: %s", err)
+	}
+	s, err := LoadParameterSchema(path)
+	if err != nil {
+		t.Fatalf("LoadParameterSchema(...): %s", err)
+	}
+
+	cases := map[string]struct {
+		reason string
+		spec   string
+		valid  bool
+	}{
+		"Valid": {
+			reason: "A spec that matches the schema should be accepted",
+			spec:   "spec:\n  region: us-east-1\n  storageGB: 50\n",
+			valid:  true,
+		},
+		"MissingRequired": {
+			reason: "A spec without a required field should be rejected",
+			spec:   "spec:\n  storageGB: 50\n",
+		},
+		"OutOfRange": {
+			reason: "A spec with a value the schema does not allow should be rejected",
+			spec:   "spec:\n  region: mars-north-1\n  storageGB: 5\n",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cr := fake.NewMockResource(fake.FromYAML([]byte("apiVersion: mock.crossplane.io/v1alpha1\nkind: MockKind\n" + tc.spec)))
+			err := s.Run(context.TODO(), cr, nil)
+			if diff := cmp.Diff(tc.valid, err == nil); diff != "" {
+				t.Errorf("\nReason: %s\nRun(...): -want valid, +got valid:\n%s\nerror: %v", tc.reason, diff, err)
+			}
+		})
+	}
+
+	want := "spec.region (string) required: Region to deploy the database to.\nspec.storageGB (integer)"
+	if diff := cmp.Diff(want, s.Help()); diff != "" {
+		t.Errorf("Help(): -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"spec.region", "spec.storageGB"}, s.Fields()); diff != "" {
+		t.Errorf("Fields(): -want, +got:\n%s", diff)
+	}
+	if got := s.Validation().OpenAPIV3Schema.Properties["spec"].Required; len(got) != 1 || got[0] != "region" {
+		t.Errorf("Validation(): spec schema is not the parameters schema: %v", got)
+	}
+}
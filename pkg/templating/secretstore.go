@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	errReadVaultToken    = "cannot read vault token"
+	errRequestSecret     = "cannot request secret from vault"
+	errVaultResponse     = "vault responded with unexpected status code"
+	errDecodeVaultSecret = "cannot decode vault secret"
+	errReadSecretFiles   = "cannot read secret files"
+	errSecretPath        = "secret path is outside of the secrets directory"
+)
+
+// VaultTokenHeader is the header that the token of a VaultSecretStore is sent
+// with.
+const VaultTokenHeader = "X-Vault-Token"
+
+// NewVaultSecretStore returns a new *VaultSecretStore that reads the secrets
+// from the Vault server at the given address with the token in the given file.
+// The file is read for every request so that a rotated token is picked up.
+func NewVaultSecretStore(address, tokenFile string, c *http.Client) *VaultSecretStore {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return &VaultSecretStore{address: strings.TrimSuffix(address, "/"), tokenFile: tokenFile, client: c}
+}
+
+// VaultSecretStore is a SecretStore that reads the secrets from the KV secrets
+// engine of a Vault server, either version 1 or 2. The paths are the full API
+// paths, e.g. secret/data/db for version 2.
+type VaultSecretStore struct {
+	address   string
+	tokenFile string
+	client    *http.Client
+}
+
+type vaultSecret struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// GetSecret reads the secret at the given path.
+func (v *VaultSecretStore) GetSecret(ctx context.Context, path string) (map[string]string, error) {
+	token, err := ioutil.ReadFile(v.tokenFile)
+	if err != nil {
+		return nil, errors.Wrap(err, errReadVaultToken)
+	}
+	req, err := http.NewRequest(http.MethodGet, v.address+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, errRequestSecret)
+	}
+	req.Header.Set(VaultTokenHeader, strings.TrimSpace(string(token)))
+	resp, err := v.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, errRequestSecret)
+	}
+	defer resp.Body.Close() // nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("%s: %d", errVaultResponse, resp.StatusCode)
+	}
+	s := vaultSecret{}
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return nil, errors.Wrap(err, errDecodeVaultSecret)
+	}
+	data := s.Data
+	// Version 2 of the KV secrets engine nests the secret under data.data,
+	// next to its metadata.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	result := make(map[string]string, len(data))
+	for k, val := range data {
+		if str, ok := val.(string); ok {
+			result[k] = str
+			continue
+		}
+		result[k] = fmt.Sprint(val)
+	}
+	return result, nil
+}
+
+// NewFileSecretStore returns a new FileSecretStore that reads the secrets from
+// the given directory.
+func NewFileSecretStore(dir string) FileSecretStore {
+	return FileSecretStore{dir: dir}
+}
+
+// FileSecretStore is a SecretStore that reads the secrets from files mounted
+// into the controller, e.g. by the Secrets Store CSI driver from AWS Secrets
+// Manager. The path is a directory with a file per key.
+type FileSecretStore struct {
+	dir string
+}
+
+// GetSecret reads the files of the directory at the given path.
+func (f FileSecretStore) GetSecret(_ context.Context, path string) (map[string]string, error) {
+	root := filepath.Clean(f.dir)
+	dir := filepath.Join(root, filepath.FromSlash(path))
+	if dir != root && !strings.HasPrefix(dir, root+string(os.PathSeparator)) {
+		return nil, errors.Errorf("%s: %s", errSecretPath, path)
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, errReadSecretFiles)
+	}
+	result := make(map[string]string, len(infos))
+	for _, info := range infos {
+		// The CSI drivers and kubelet keep the files in hidden directories
+		// that the visible files are symlinks into.
+		if strings.HasPrefix(info.Name(), ".") || info.IsDir() {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, info.Name()))
+		if err != nil {
+			return nil, errors.Wrap(err, errReadSecretFiles)
+		}
+		result[info.Name()] = string(b)
+	}
+	return result, nil
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestVaultSecretStore(t *testing.T) {
+	type want struct {
+		data map[string]string
+		err  error
+	}
+	cases := map[string]struct {
+		reason string
+		status int
+		body   string
+		want
+	}{
+		"KVVersion2": {
+			reason: "The secret should be read from data.data of version 2 of the KV secrets engine",
+			status: http.StatusOK,
+			body:   `{"data":{"data":{"password":"s3cr3t","port":5432},"metadata":{"version":3}}}`,
+			want:   want{data: map[string]string{"password": "s3cr3t", "port": "5432"}},
+		},
+		"KVVersion1": {
+			reason: "The secret should be read from data of version 1 of the KV secrets engine",
+			status: http.StatusOK,
+			body:   `{"data":{"password":"s3cr3t"}}`,
+			want:   want{data: map[string]string{"password": "s3cr3t"}},
+		},
+		"NotFound": {
+			reason: "It should return error if Vault does not respond with OK",
+			status: http.StatusNotFound,
+			body:   `{"errors":[]}`,
+			want:   want{err: errors.Errorf("%s: %d", errVaultResponse, http.StatusNotFound)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/secret/data/db" || r.Header.Get(VaultTokenHeader) != "token" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+			dir, err := ioutil.TempDir("", "vault")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir) // nolint:errcheck
+			tokenFile := filepath.Join(dir, "token")
+			if err := ioutil.WriteFile(tokenFile, []byte("token\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			data, err := NewVaultSecretStore(srv.URL+"/", tokenFile, nil).GetSecret(context.Background(), "secret/data/db")
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nGetSecret(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.data, data); diff != "" {
+				t.Errorf("\nReason: %s\nGetSecret(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestFileSecretStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint:errcheck
+	if err := os.MkdirAll(filepath.Join(dir, "db", ".data"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "db", "password"), []byte("s3cr3t"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	type want struct {
+		data map[string]string
+		err  error
+	}
+	cases := map[string]struct {
+		reason string
+		path   string
+		want
+	}{
+		"Success": {
+			reason: "A file per key should be read, skipping the hidden directories",
+			path:   "db",
+			want:   want{data: map[string]string{"password": "s3cr3t"}},
+		},
+		"OutsideDirectory": {
+			reason: "It should return error if the path is outside of the directory",
+			path:   "../db",
+			want:   want{err: errors.Errorf("%s: %s", errSecretPath, "../db")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			data, err := NewFileSecretStore(dir).GetSecret(context.Background(), tc.path)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\nReason: %s\nGetSecret(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.data, data); diff != "" {
+				t.Errorf("\nReason: %s\nGetSecret(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
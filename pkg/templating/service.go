@@ -0,0 +1,255 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	// RenderPath is the path that RenderService serves renders at.
+	RenderPath = "/v1/render"
+
+	errDecodeRenderRequest = "cannot decode render request"
+	errMissingParent       = "render request has no parent resource"
+	errUnexpectedKind      = "parent resource is not of the kind this controller renders"
+	errUnknownPack         = "pack is not served by this controller"
+	errRemoteCaller        = "render service serves only local callers unless it requires authorization"
+	errMissingBearerToken  = "render request has no bearer token"
+	errReviewToken         = "cannot review bearer token"
+	errUnauthenticated     = "bearer token is not authenticated"
+	errReviewAccess        = "cannot review access to parent resource"
+	errUnauthorized        = "caller is not allowed to get the parent resource"
+
+	maxRenderRequestBytes = 4 << 20
+)
+
+// Render returns the child resources of the given parent resource rendered
+// and patched exactly as they are during reconciliation, without running the
+// hooks or applying them. The render is verified against, or stored in, the
+// RenderCache if one is configured. The values of the ValuesInitializers are
+// not initialized, so they are not written to the cluster.
+func (r *Reconciler) Render(ctx context.Context, cr resource.ParentResource) ([]resource.ChildResource, error) {
+	input, err := r.renderInput(ctx, cr)
+	if err != nil {
+		return nil, errors.Wrap(err, errRenderInput)
+	}
+	list, err := r.render(ctx, input)
+	if err != nil {
+		if resource.Classify(err) == resource.FailureUnknown {
+			err = &resource.RenderError{Err: err}
+		}
+		return nil, errors.Wrap(err, errTemplatingOperation)
+	}
+	list, err = r.patch(ctx, input, list)
+	if err != nil {
+		return nil, errors.Wrap(err, errChildResourcePatchers)
+	}
+	if r.renderCache != nil {
+		if err := r.cacheRender(ctx, input, list); err != nil {
+			return nil, errors.Wrap(err, errCacheRender)
+		}
+	}
+	return list, nil
+}
+
+// serviceRender returns the child resources of the given parent resource
+// rendered and patched like Render does, but without the values of the
+// SecretValuesProviders so that no secrets are read, written or returned for
+// the callers of the RenderService. The RenderCache is neither verified nor
+// written.
+func (r *Reconciler) serviceRender(ctx context.Context, cr resource.ParentResource) ([]resource.ChildResource, error) {
+	input, err := r.renderInputWith(ctx, cr, r.values.WithoutSecrets())
+	if err != nil {
+		return nil, errors.Wrap(err, errRenderInput)
+	}
+	list, err := r.render(ctx, input)
+	if err != nil {
+		if resource.Classify(err) == resource.FailureUnknown {
+			err = &resource.RenderError{Err: err}
+		}
+		return nil, errors.Wrap(err, errTemplatingOperation)
+	}
+	list, err = r.patch(ctx, input, list)
+	return list, errors.Wrap(err, errChildResourcePatchers)
+}
+
+// RenderRequest is the body of a render request.
+type RenderRequest struct {
+	// Parent is the parent resource to render the child resources of.
+	Parent *unstructured.Unstructured `json:"parent"`
+
+	// Pack is the name of the pack to render with. It is optional and, if
+	// given, has to be the one served by the controller.
+	Pack string `json:"pack,omitempty"`
+}
+
+// RenderResponse is the body of the response to a render request.
+type RenderResponse struct {
+	// Manifests are the rendered child resources.
+	Manifests []resource.ChildResource `json:"manifests,omitempty"`
+
+	// Error is the reason the render failed.
+	Error string `json:"error,omitempty"`
+
+	// FailureKind classifies the failure of the render.
+	FailureKind resource.FailureKind `json:"failureKind,omitempty"`
+}
+
+// NewRenderService returns a new *RenderService that renders with the given
+// Reconciler the parent resources of the given pack.
+func NewRenderService(r *Reconciler, pack string) *RenderService {
+	return &RenderService{reconciler: r, pack: pack}
+}
+
+// RenderService is an http.Handler that exposes the render pipeline of a
+// Reconciler so that CI pipelines and other controllers can render the child
+// resources of a parent resource like the controller does. It accepts a
+// RenderRequest POSTed to RenderPath and responds with a RenderResponse. The
+// values of the SecretValuesProviders are not part of its renders. Unless it
+// requires authorization, it serves only callers on the loopback interface.
+type RenderService struct {
+	reconciler *Reconciler
+	pack       string
+
+	kube     client.Client
+	resource schema.GroupResource
+}
+
+// RequireAuthorization makes the RenderService serve only the callers whose
+// bearer token the API server authenticates with a TokenReview and who are
+// allowed to get the posted parent resource, which is of the given resource,
+// according to a SubjectAccessReview.
+func (s *RenderService) RequireAuthorization(c client.Client, gr schema.GroupResource) *RenderService {
+	s.kube = c
+	s.resource = gr
+	return s
+}
+
+// authorize returns the HTTP status and the error to respond with if the
+// caller of the given request is not allowed to render the given parent
+// resource.
+func (s *RenderService) authorize(req *http.Request, cr resource.ParentResource) (int, error) {
+	if s.kube == nil {
+		host, _, err := net.SplitHostPort(req.RemoteAddr)
+		if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+			return http.StatusForbidden, errors.New(errRemoteCaller)
+		}
+		return http.StatusOK, nil
+	}
+	auth := req.Header.Get("Authorization")
+	token := strings.TrimPrefix(auth, "Bearer ")
+	if !strings.HasPrefix(auth, "Bearer ") || token == "" {
+		return http.StatusUnauthorized, errors.New(errMissingBearerToken)
+	}
+	tr := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	if err := s.kube.Create(req.Context(), tr); err != nil {
+		return http.StatusInternalServerError, errors.Wrap(err, errReviewToken)
+	}
+	if !tr.Status.Authenticated {
+		return http.StatusUnauthorized, errors.New(errUnauthenticated)
+	}
+	extra := make(map[string]authorizationv1.ExtraValue, len(tr.Status.User.Extra))
+	for k, v := range tr.Status.User.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	sar := &authorizationv1.SubjectAccessReview{Spec: authorizationv1.SubjectAccessReviewSpec{
+		ResourceAttributes: &authorizationv1.ResourceAttributes{
+			Namespace: cr.GetNamespace(),
+			Verb:      "get",
+			Group:     s.resource.Group,
+			Resource:  s.resource.Resource,
+			Name:      cr.GetName(),
+		},
+		User:   tr.Status.User.Username,
+		Groups: tr.Status.User.Groups,
+		UID:    tr.Status.User.UID,
+		Extra:  extra,
+	}}
+	if err := s.kube.Create(req.Context(), sar); err != nil {
+		return http.StatusInternalServerError, errors.Wrap(err, errReviewAccess)
+	}
+	if !sar.Status.Allowed {
+		return http.StatusForbidden, errors.New(errUnauthorized)
+	}
+	return http.StatusOK, nil
+}
+
+// ServeHTTP serves a render request.
+func (s *RenderService) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != RenderPath {
+		http.NotFound(w, req)
+		return
+	}
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeRenderResponse(w, http.StatusMethodNotAllowed, RenderResponse{Error: http.StatusText(http.StatusMethodNotAllowed)})
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxRenderRequestBytes))
+	if err != nil {
+		writeRenderResponse(w, http.StatusBadRequest, RenderResponse{Error: errors.Wrap(err, errDecodeRenderRequest).Error()})
+		return
+	}
+	rr := RenderRequest{}
+	if err := json.Unmarshal(body, &rr); err != nil {
+		writeRenderResponse(w, http.StatusBadRequest, RenderResponse{Error: errors.Wrap(err, errDecodeRenderRequest).Error()})
+		return
+	}
+	if rr.Parent == nil {
+		writeRenderResponse(w, http.StatusBadRequest, RenderResponse{Error: errMissingParent})
+		return
+	}
+	if rr.Pack != "" && rr.Pack != s.pack {
+		writeRenderResponse(w, http.StatusNotFound, RenderResponse{Error: errors.Errorf("%s: %s", errUnknownPack, rr.Pack).Error()})
+		return
+	}
+	if rr.Parent.GroupVersionKind() != s.reconciler.newParentResource().GroupVersionKind() {
+		writeRenderResponse(w, http.StatusBadRequest, RenderResponse{Error: errors.Errorf("%s: %s", errUnexpectedKind, rr.Parent.GroupVersionKind()).Error()})
+		return
+	}
+	if status, err := s.authorize(req, rr.Parent); err != nil {
+		writeRenderResponse(w, status, RenderResponse{Error: err.Error()})
+		return
+	}
+	list, err := s.reconciler.serviceRender(req.Context(), rr.Parent)
+	if err != nil {
+		writeRenderResponse(w, http.StatusUnprocessableEntity, RenderResponse{Error: err.Error(), FailureKind: resource.Classify(err)})
+		return
+	}
+	writeRenderResponse(w, http.StatusOK, RenderResponse{Manifests: list})
+}
+
+func writeRenderResponse(w http.ResponseWriter, status int, resp RenderResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
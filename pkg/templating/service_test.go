@@ -0,0 +1,236 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	runtimefake "github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ http.Handler = &RenderService{}
+)
+
+// secretValues is a ValuesProvider that provides secrets.
+type secretValues struct {
+	ValuesProviderFunc
+}
+
+func (secretValues) ProvidesSecrets() bool { return true }
+
+// reviewer returns a client that authenticates the given token as the given
+// user and allows the given user to get the parent resource named cool.
+func reviewer(token, user string) *test.MockClient {
+	return &test.MockClient{MockCreate: func(_ context.Context, obj runtime.Object, _ ...client.CreateOption) error {
+		switch o := obj.(type) {
+		case *authenticationv1.TokenReview:
+			if o.Spec.Token == token {
+				o.Status.Authenticated = true
+				o.Status.User.Username = user
+			}
+		case *authorizationv1.SubjectAccessReview:
+			a := o.Spec.ResourceAttributes
+			o.Status.Allowed = o.Spec.User == user && a.Verb == "get" && a.Resource == "mockresources" && a.Name == "cool"
+		}
+		return nil
+	}}
+}
+
+func TestRenderService(t *testing.T) {
+	parent := `{"apiVersion":"mock.parent.crossplane.io/v1alpha1","kind":"MockResource","metadata":{"name":"cool"}}`
+	type want struct {
+		status    int
+		manifests int
+		kind      resource.FailureKind
+	}
+	cases := map[string]struct {
+		reason string
+		method string
+		body   string
+		remote string
+		token  string
+		kube   *test.MockClient
+		engine Engine
+		opts   []ReconcilerOption
+		want   want
+	}{
+		"Rendered": {
+			reason: "The rendered and patched child resources should be returned",
+			method: http.MethodPost,
+			body:   `{"pack":"cool-pack","parent":` + parent + `}`,
+			want:   want{status: http.StatusOK, manifests: 1},
+		},
+		"RenderFailed": {
+			reason: "A failed render should be reported with its failure kind",
+			method: http.MethodPost,
+			body:   `{"parent":` + parent + `}`,
+			engine: EngineFunc(func(_ resource.ParentResource) ([]resource.ChildResource, error) {
+				return nil, errBoom
+			}),
+			want: want{status: http.StatusUnprocessableEntity, kind: resource.FailureRender},
+		},
+		"UnknownPack": {
+			reason: "Renders of other packs should be rejected",
+			method: http.MethodPost,
+			body:   `{"pack":"other-pack","parent":` + parent + `}`,
+			want:   want{status: http.StatusNotFound},
+		},
+		"UnexpectedKind": {
+			reason: "Parent resources of other kinds should be rejected",
+			method: http.MethodPost,
+			body:   `{"parent":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cool"}}}`,
+			want:   want{status: http.StatusBadRequest},
+		},
+		"MissingParent": {
+			reason: "Requests without a parent resource should be rejected",
+			method: http.MethodPost,
+			body:   `{}`,
+			want:   want{status: http.StatusBadRequest},
+		},
+		"RemoteCaller": {
+			reason: "Callers outside the loopback interface should be rejected unless authorization is required",
+			method: http.MethodPost,
+			body:   `{"parent":` + parent + `}`,
+			remote: "192.0.2.1:1234",
+			want:   want{status: http.StatusForbidden},
+		},
+		"MissingToken": {
+			reason: "Requests without a bearer token should be rejected if authorization is required",
+			method: http.MethodPost,
+			body:   `{"parent":` + parent + `}`,
+			kube:   reviewer("cool-token", "cool-user"),
+			want:   want{status: http.StatusUnauthorized},
+		},
+		"Unauthenticated": {
+			reason: "Requests whose bearer token is not authenticated should be rejected",
+			method: http.MethodPost,
+			body:   `{"parent":` + parent + `}`,
+			token:  "other-token",
+			kube:   reviewer("cool-token", "cool-user"),
+			want:   want{status: http.StatusUnauthorized},
+		},
+		"Unauthorized": {
+			reason: "Callers that are not allowed to get the parent resource should be rejected",
+			method: http.MethodPost,
+			body:   `{"parent":{"apiVersion":"mock.parent.crossplane.io/v1alpha1","kind":"MockResource","metadata":{"name":"other"}}}`,
+			token:  "cool-token",
+			kube:   reviewer("cool-token", "cool-user"),
+			want:   want{status: http.StatusForbidden},
+		},
+		"Authorized": {
+			reason: "Remote callers that are allowed to get the parent resource should be served",
+			method: http.MethodPost,
+			body:   `{"parent":` + parent + `}`,
+			remote: "192.0.2.1:1234",
+			token:  "cool-token",
+			kube:   reviewer("cool-token", "cool-user"),
+			want:   want{status: http.StatusOK, manifests: 1},
+		},
+		"SecretsSkipped": {
+			reason: "The values of the ValuesProviders that provide secrets should not be part of the render",
+			method: http.MethodPost,
+			body:   `{"parent":` + parent + `}`,
+			opts: []ReconcilerOption{WithValuesProvider(secretValues{ValuesProviderFunc(func(_ context.Context, _ resource.ParentResource) (map[string]interface{}, error) {
+				return nil, errBoom
+			})})},
+			want: want{status: http.StatusOK, manifests: 1},
+		},
+		"MiddlewareRun": {
+			reason: "The patch stage of the render should run within the Middleware",
+			method: http.MethodPost,
+			body:   `{"parent":` + parent + `}`,
+			opts: []ReconcilerOption{WithMiddleware(MiddlewareFunc(func(s Stage, next StageFunc) StageFunc {
+				if s != StagePatch {
+					return next
+				}
+				return func(_ context.Context, _ resource.ParentResource, _ []resource.ChildResource) ([]resource.ChildResource, error) {
+					return nil, errBoom
+				}
+			}))},
+			want: want{status: http.StatusUnprocessableEntity, kind: resource.FailureUnknown},
+		},
+		"WrongMethod": {
+			reason: "Only POST requests should be served",
+			method: http.MethodGet,
+			want:   want{status: http.StatusMethodNotAllowed},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			engine := tc.engine
+			if engine == nil {
+				engine = EngineFunc(func(_ resource.ParentResource) ([]resource.ChildResource, error) {
+					return []resource.ChildResource{fake.NewMockResource(fake.WithGVK(fake.MockChildGVK))}, nil
+				})
+			}
+			mgr := &runtimefake.Manager{
+				Client: &test.MockClient{},
+				Scheme: runtimefake.SchemeWith(&fake.MockResource{}),
+			}
+			opts := append([]ReconcilerOption{
+				WithEngine(engine),
+				WithChildResourcePatcher(ChildResourcePatcherFunc(func(_ resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+					return list, nil
+				})),
+			}, tc.opts...)
+			r := NewReconciler(mgr, fake.MockParentGVK, opts...)
+			s := NewRenderService(r, "cool-pack")
+			if tc.kube != nil {
+				s.RequireAuthorization(tc.kube, schema.GroupResource{Group: fake.MockParentGVK.Group, Resource: "mockresources"})
+			}
+			req := httptest.NewRequest(tc.method, RenderPath, strings.NewReader(tc.body))
+			req.RemoteAddr = "127.0.0.1:1234"
+			if tc.remote != "" {
+				req.RemoteAddr = tc.remote
+			}
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+
+			resp := struct {
+				Manifests   []json.RawMessage    `json:"manifests"`
+				FailureKind resource.FailureKind `json:"failureKind"`
+			}{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("\nReason: %s\nServeHTTP(...): cannot decode response: %s", tc.reason, err)
+			}
+			got := want{status: rec.Code, manifests: len(resp.Manifests), kind: resp.FailureKind}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\nReason: %s\nServeHTTP(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
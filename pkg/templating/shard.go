@@ -0,0 +1,130 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const (
+	// shardVirtualNodes is the number of points that every shard has on the
+	// hash ring, so that the parent resources are spread evenly.
+	shardVirtualNodes = 64
+
+	errInvalidShard      = "shard index must be at least zero and less than the shard count"
+	errShardFromHostname = "cannot tell the shard index from the hostname, which must end with -<index> as the names of StatefulSet pods do"
+)
+
+type ringPoint struct {
+	hash  uint64
+	shard int
+}
+
+// NewShard returns a new *Shard that owns the parent resources that a hash
+// ring of the given number of shards assigns to the shard with the given
+// index.
+func NewShard(index, count int) (*Shard, error) {
+	if index < 0 || index >= count {
+		return nil, errors.Errorf("%s: %d of %d", errInvalidShard, index, count)
+	}
+	s := &Shard{index: index, count: count, ring: make([]ringPoint, 0, count*shardVirtualNodes)}
+	for i := 0; i < count; i++ {
+		for v := 0; v < shardVirtualNodes; v++ {
+			s.ring = append(s.ring, ringPoint{hash: hashOf(fmt.Sprintf("shard-%d-%d", i, v)), shard: i})
+		}
+	}
+	sort.Slice(s.ring, func(i, j int) bool { return s.ring[i].hash < s.ring[j].hash })
+	return s, nil
+}
+
+// ShardIndexFromHostname returns the index of the shard that the replica
+// with the given hostname runs, which is the ordinal of the StatefulSet pods,
+// e.g. 2 for templating-controller-2.
+func ShardIndexFromHostname(hostname string) (int, error) {
+	i := strings.LastIndex(hostname, "-")
+	if i < 0 {
+		return 0, errors.Errorf("%s: %s", errShardFromHostname, hostname)
+	}
+	index, err := strconv.Atoi(hostname[i+1:])
+	if err != nil {
+		return 0, errors.Errorf("%s: %s", errShardFromHostname, hostname)
+	}
+	return index, nil
+}
+
+// Shard is the subset of the parent resources that a replica of the
+// controller reconciles when the parent resources are sharded across the
+// replicas, so that they scale out beyond what a single replica reconciles.
+// A parent resource is owned by the shard that the hash of its UID falls to
+// on a hash ring, so every replica with the same shard count agrees on the
+// owners, and changing the shard count moves only a part of the parent
+// resources to other shards. It is a predicate that drops the events of the
+// parent resources that other shards own.
+type Shard struct {
+	index int
+	count int
+	ring  []ringPoint
+}
+
+// Index returns the index of the shard.
+func (s *Shard) Index() int {
+	return s.index
+}
+
+// Owns returns true if the given parent resource is owned by the shard.
+func (s *Shard) Owns(o metav1.Object) bool {
+	h := hashOf(string(o.GetUID()))
+	i := sort.Search(len(s.ring), func(i int) bool { return s.ring[i].hash >= h })
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.ring[i].shard == s.index
+}
+
+// Create returns true if the created parent resource is owned by the shard.
+func (s *Shard) Create(e event.CreateEvent) bool {
+	return e.Meta != nil && s.Owns(e.Meta)
+}
+
+// Delete returns true if the deleted parent resource is owned by the shard.
+func (s *Shard) Delete(e event.DeleteEvent) bool {
+	return e.Meta != nil && s.Owns(e.Meta)
+}
+
+// Update returns true if the updated parent resource is owned by the shard.
+func (s *Shard) Update(e event.UpdateEvent) bool {
+	return e.MetaNew != nil && s.Owns(e.MetaNew)
+}
+
+// Generic returns true if the parent resource is owned by the shard.
+func (s *Shard) Generic(e event.GenericEvent) bool {
+	return e.Meta != nil && s.Owns(e.Meta)
+}
+
+func hashOf(s string) uint64 {
+	sum := sha256.Sum256([]byte(s))
+	return binary.BigEndian.Uint64(sum[:8])
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ predicate.Predicate = &Shard{}
+
+func TestShard(t *testing.T) {
+	if _, err := NewShard(3, 3); err == nil {
+		t.Errorf("NewShard(3, 3): want error for an index out of the shard count")
+	}
+	const count, parents = 3, 3000
+	shards := make([]*Shard, count)
+	for i := range shards {
+		s, err := NewShard(i, count)
+		if err != nil {
+			t.Fatalf("NewShard(%d, %d): %s", i, count, err)
+		}
+		shards[i] = s
+	}
+	owned := make([]int, count)
+	for p := 0; p < parents; p++ {
+		cr := fake.NewMockResource(fake.WithUID(types.UID(fmt.Sprintf("uid-%d", p))))
+		owners := 0
+		for i, s := range shards {
+			if s.Owns(cr) {
+				owners++
+				owned[i]++
+			}
+		}
+		if owners != 1 {
+			t.Fatalf("Owns(...): parent resource %d is owned by %d shards, want 1", p, owners)
+		}
+	}
+	for i, n := range owned {
+		// Every shard should own roughly a third of the parent resources.
+		if n < parents/count/2 || n > parents/count*2 {
+			t.Errorf("Owns(...): shard %d owns %d of %d parent resources", i, n, parents)
+		}
+	}
+
+	cr := fake.NewMockResource(fake.WithUID("uid-0"))
+	for _, s := range shards {
+		if got, want := s.Create(event.CreateEvent{Meta: cr, Object: cr}), s.Owns(cr); got != want {
+			t.Errorf("Create(...): shard %d: got %t, want %t", s.Index(), got, want)
+		}
+	}
+}
+
+func TestShardIndexFromHostname(t *testing.T) {
+	cases := map[string]struct {
+		reason   string
+		hostname string
+		want     int
+		err      error
+	}{
+		"StatefulSetPod": {
+			reason:   "The ordinal at the end of the hostname should be the index",
+			hostname: "templating-controller-2",
+			want:     2,
+		},
+		"NoOrdinal": {
+			reason:   "Hostnames without an ordinal should fail",
+			hostname: "templating-controller-7d4b9c",
+			err:      errors.Errorf("%s: %s", errShardFromHostname, "templating-controller-7d4b9c"),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ShardIndexFromHostname(tc.hostname)
+			if diff := cmp.Diff(tc.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nShardIndexFromHostname(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nShardIndexFromHostname(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	defaultSnapshotLimit = 5
+	redactedSnapshotData = "REDACTED"
+
+	errGetLiveChild           = "cannot get the live child resource to snapshot"
+	errSaveSnapshot           = "cannot snapshot child resource before deleting it"
+	errMarshalSnapshot        = "cannot marshal snapshot"
+	errGetSnapshotConfigMap   = "cannot get snapshot configmap"
+	errWriteSnapshotConfigMap = "cannot write snapshot configmap"
+	errGetSnapshotSecret      = "cannot get snapshot secret"
+	errWriteSnapshotSecret    = "cannot write snapshot secret"
+	errSendSnapshot           = "cannot send snapshot"
+	errSnapshotResponse       = "snapshot store responded with unexpected status"
+)
+
+// NewSnapshotClient returns a client.Client that saves the live manifest of
+// every object to the given SnapshotStore before it deletes it. An object
+// that cannot be saved is not deleted.
+func NewSnapshotClient(c client.Client, s SnapshotStore) client.Client {
+	return &snapshotClient{Client: c, store: s}
+}
+
+type snapshotClient struct {
+	client.Client
+	store SnapshotStore
+}
+
+func (c *snapshotClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if err := saveSnapshot(ctx, c.Client, c.store, obj); err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// saveSnapshot saves the live manifest of the given object to the given store.
+// The objects that do not exist anymore have nothing to save.
+func saveSnapshot(ctx context.Context, kube client.Reader, s SnapshotStore, obj runtime.Object) error {
+	o, ok := obj.(resource.ChildResource)
+	if !ok {
+		return nil
+	}
+	live, ok := o.DeepCopyObject().(resource.ChildResource)
+	if !ok {
+		return nil
+	}
+	err := kube.Get(ctx, types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()}, live)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, errGetLiveChild)
+	}
+	return errors.Wrap(s.Save(ctx, live), errSaveSnapshot)
+}
+
+// snapshotManifest returns the YAML manifest of the given object without the
+// fields that the API server sets, so that it can be applied to recover it.
+// The data of Secrets is redacted if requested, which keeps their keys but not
+// their values.
+func snapshotManifest(o resource.ChildResource, redact bool) ([]byte, error) {
+	var content map[string]interface{}
+	if u, ok := o.DeepCopyObject().(runtime.Unstructured); ok {
+		content = u.UnstructuredContent()
+	} else {
+		c, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+		if err != nil {
+			return nil, errors.Wrap(err, errMarshalSnapshot)
+		}
+		content = c
+	}
+	for _, f := range []string{"resourceVersion", "uid", "selfLink", "generation", "creationTimestamp", "managedFields"} {
+		unstructured.RemoveNestedField(content, "metadata", f)
+	}
+	if redact && isSecret(o) {
+		for _, f := range []string{"data", "stringData"} {
+			data, _, _ := unstructured.NestedMap(content, f)
+			for k := range data {
+				data[k] = redactedSnapshotData
+			}
+			if len(data) > 0 {
+				_ = unstructured.SetNestedMap(content, data, f)
+			}
+		}
+	}
+	b, err := yaml.Marshal(content)
+	return b, errors.Wrap(err, errMarshalSnapshot)
+}
+
+// isSecret returns true if the given object is a Secret.
+func isSecret(o resource.ChildResource) bool {
+	if _, ok := o.(*corev1.Secret); ok {
+		return true
+	}
+	return o.GetObjectKind().GroupVersionKind().GroupKind() == schema.GroupKind{Kind: "Secret"}
+}
+
+// snapshotOf returns a description of the given object that tells its
+// snapshots apart from the ones of other objects.
+func snapshotOf(o resource.ChildResource) string {
+	gk := o.GetObjectKind().GroupVersionKind().GroupKind()
+	return fmt.Sprintf("%s %s/%s", gk, o.GetNamespace(), o.GetName())
+}
+
+// NewConfigMapSnapshotStore returns a new *ConfigMapSnapshotStore that keeps
+// the given number of latest snapshots of every object in a ConfigMap in the
+// given namespace. A limit of zero uses the default.
+func NewConfigMapSnapshotStore(c client.Client, namespace string, limit int) *ConfigMapSnapshotStore {
+	if limit <= 0 {
+		limit = defaultSnapshotLimit
+	}
+	return &ConfigMapSnapshotStore{kube: c, namespace: namespace, limit: limit, now: time.Now}
+}
+
+// ConfigMapSnapshotStore is a SnapshotStore that keeps the snapshots of every
+// object in its own ConfigMap, annotated with SnapshotOfAnnotationKey and
+// named after the hash of the object, whose keys are the Unix times of the
+// snapshots. The snapshots of Secrets are kept in a Secret of the same name
+// instead so that they are only readable by the ones allowed to read Secrets.
+// Note that a ConfigMap holds up to 1 MiB, so the objects that are larger need
+// another store.
+type ConfigMapSnapshotStore struct {
+	kube      client.Client
+	namespace string
+	limit     int
+	now       func() time.Time
+}
+
+// Save adds the snapshot of the given object to its ConfigMap, or Secret,
+// dropping the oldest snapshots beyond the limit.
+func (s *ConfigMapSnapshotStore) Save(ctx context.Context, o resource.ChildResource) error {
+	b, err := snapshotManifest(o, false)
+	if err != nil {
+		return err
+	}
+	of := snapshotOf(o)
+	sum := sha256.Sum256([]byte(of))
+	nn := types.NamespacedName{Namespace: s.namespace, Name: fmt.Sprintf("snapshot-%x", sum[:5])}
+	key := fmt.Sprintf("%d.yaml", s.now().UnixNano())
+	if isSecret(o) {
+		return s.saveSecret(ctx, nn, of, key, b)
+	}
+	cm := &corev1.ConfigMap{}
+	err = s.kube.Get(ctx, nn, cm)
+	create := kerrors.IsNotFound(err)
+	if err != nil && !create {
+		return errors.Wrap(err, errGetSnapshotConfigMap)
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[key] = string(b)
+	keys := make([]string, 0, len(cm.Data))
+	for k := range cm.Data {
+		keys = append(keys, k)
+	}
+	for _, k := range s.expired(keys) {
+		delete(cm.Data, k)
+	}
+	if create {
+		cm.SetName(nn.Name)
+		cm.SetNamespace(nn.Namespace)
+		cm.SetAnnotations(map[string]string{SnapshotOfAnnotationKey: of})
+		return errors.Wrap(s.kube.Create(ctx, cm), errWriteSnapshotConfigMap)
+	}
+	return errors.Wrap(s.kube.Update(ctx, cm), errWriteSnapshotConfigMap)
+}
+
+// saveSecret adds the given snapshot of a Secret to the Secret with the given
+// name, dropping the oldest snapshots beyond the limit.
+func (s *ConfigMapSnapshotStore) saveSecret(ctx context.Context, nn types.NamespacedName, of, key string, b []byte) error {
+	sec := &corev1.Secret{}
+	err := s.kube.Get(ctx, nn, sec)
+	create := kerrors.IsNotFound(err)
+	if err != nil && !create {
+		return errors.Wrap(err, errGetSnapshotSecret)
+	}
+	if sec.Data == nil {
+		sec.Data = map[string][]byte{}
+	}
+	sec.Data[key] = b
+	keys := make([]string, 0, len(sec.Data))
+	for k := range sec.Data {
+		keys = append(keys, k)
+	}
+	for _, k := range s.expired(keys) {
+		delete(sec.Data, k)
+	}
+	if create {
+		sec.SetName(nn.Name)
+		sec.SetNamespace(nn.Namespace)
+		sec.SetAnnotations(map[string]string{SnapshotOfAnnotationKey: of})
+		return errors.Wrap(s.kube.Create(ctx, sec), errWriteSnapshotSecret)
+	}
+	return errors.Wrap(s.kube.Update(ctx, sec), errWriteSnapshotSecret)
+}
+
+// expired returns the ones of the given snapshot keys that are beyond the
+// limit, oldest first.
+func (s *ConfigMapSnapshotStore) expired(keys []string) []string {
+	// The keys have the same number of digits for centuries, so they sort
+	// by time.
+	sort.Strings(keys)
+	if len(keys) <= s.limit {
+		return nil
+	}
+	return keys[:len(keys)-s.limit]
+}
+
+// NewHTTPSnapshotStore returns a new *HTTPSnapshotStore that PUTs the
+// snapshots under the given base URL.
+func NewHTTPSnapshotStore(url string, c *http.Client) *HTTPSnapshotStore {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return &HTTPSnapshotStore{url: strings.TrimSuffix(url, "/"), client: c, now: time.Now}
+}
+
+// HTTPSnapshotStore is a SnapshotStore that PUTs every snapshot as YAML to
+// <url>/<kind.group>/<namespace>/<name>/<unix time>.yaml, e.g. to an object
+// storage bucket or a service in front of one. Cluster scoped objects use _
+// as namespace. The data of Secrets is redacted since it leaves the cluster, so
+// their snapshots cannot recover the values.
+type HTTPSnapshotStore struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+}
+
+// Save sends the snapshot of the given object.
+func (s *HTTPSnapshotStore) Save(ctx context.Context, o resource.ChildResource) error {
+	b, err := snapshotManifest(o, true)
+	if err != nil {
+		return err
+	}
+	ns := o.GetNamespace()
+	if ns == "" {
+		ns = "_"
+	}
+	gk := o.GetObjectKind().GroupVersionKind().GroupKind()
+	url := fmt.Sprintf("%s/%s/%s/%s/%d.yaml", s.url, strings.ToLower(gk.String()), ns, o.GetName(), s.now().Unix())
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrap(err, errSendSnapshot)
+	}
+	req.Header.Set("Content-Type", "application/yaml")
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, errSendSnapshot)
+	}
+	defer resp.Body.Close() // nolint:errcheck
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("%s: %d", errSnapshotResponse, resp.StatusCode)
+	}
+	return nil
+}
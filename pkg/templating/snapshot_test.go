@@ -0,0 +1,232 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+var (
+	_ SnapshotStore = &ConfigMapSnapshotStore{}
+	_ SnapshotStore = &HTTPSnapshotStore{}
+	_ SnapshotStore = SnapshotStoreFunc(nil)
+)
+
+func snapshotChild() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("ConfigMap")
+	u.SetNamespace(namespace)
+	u.SetName("cool")
+	return u
+}
+
+func TestSnapshotClient(t *testing.T) {
+	errBoom := errors.New("boom")
+	type want struct {
+		saved   bool
+		deleted bool
+		err     error
+	}
+	cases := map[string]struct {
+		reason string
+		get    error
+		save   error
+		want   want
+	}{
+		"Saved": {
+			reason: "The live object should be saved before it is deleted.",
+			want:   want{saved: true, deleted: true},
+		},
+		"AlreadyGone": {
+			reason: "An object that does not exist anymore has nothing to save.",
+			get:    kerrors.NewNotFound(schema.GroupResource{}, "cool"),
+			want:   want{deleted: true},
+		},
+		"SaveFailed": {
+			reason: "An object whose snapshot cannot be saved should not be deleted.",
+			save:   errBoom,
+			want:   want{saved: true, err: errors.Wrap(errBoom, errSaveSnapshot)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := want{}
+			kube := &test.MockClient{
+				MockGet: func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+					if tc.get != nil {
+						return tc.get
+					}
+					obj.(*unstructured.Unstructured).SetResourceVersion("7")
+					return nil
+				},
+				MockDelete: func(_ context.Context, _ runtime.Object, _ ...client.DeleteOption) error {
+					got.deleted = true
+					return nil
+				},
+			}
+			s := SnapshotStoreFunc(func(_ context.Context, o resource.ChildResource) error {
+				got.saved = o.GetResourceVersion() == "7"
+				return tc.save
+			})
+			got.err = NewSnapshotClient(kube, s).Delete(context.Background(), snapshotChild())
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{}), test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nDelete(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestConfigMapSnapshotStore(t *testing.T) {
+	var written *corev1.ConfigMap
+	kube := &test.MockClient{
+		MockGet: func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+			obj.(*corev1.ConfigMap).Data = map[string]string{"1.yaml": "first", "2.yaml": "second"}
+			return nil
+		},
+		MockUpdate: func(_ context.Context, obj runtime.Object, _ ...client.UpdateOption) error {
+			written = obj.(*corev1.ConfigMap)
+			return nil
+		},
+	}
+	s := NewConfigMapSnapshotStore(kube, namespace, 2)
+	s.now = func() time.Time { return time.Unix(0, 3) }
+	o := snapshotChild()
+	o.SetResourceVersion("7")
+	o.SetUID("some-uid")
+	if err := s.Save(context.Background(), o); err != nil {
+		t.Fatalf("Save(...): unexpected error: %s", err)
+	}
+	if _, ok := written.Data["1.yaml"]; ok {
+		t.Errorf("Save(...): the oldest snapshot should be dropped")
+	}
+	if _, ok := written.Data["2.yaml"]; !ok {
+		t.Errorf("Save(...): the snapshots within the limit should be kept")
+	}
+	if got := written.Data["3.yaml"]; !strings.Contains(got, "name: cool") || strings.Contains(got, "resourceVersion") || strings.Contains(got, "uid") {
+		t.Errorf("Save(...): the snapshot should be the manifest without server set fields, got:\n%s", got)
+	}
+}
+
+func TestConfigMapSnapshotStoreCreate(t *testing.T) {
+	var created *corev1.ConfigMap
+	kube := &test.MockClient{
+		MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "snapshot")),
+		MockCreate: test.NewMockCreateFn(nil, func(obj runtime.Object) error {
+			created = obj.(*corev1.ConfigMap)
+			return nil
+		}),
+	}
+	if err := NewConfigMapSnapshotStore(kube, namespace, 0).Save(context.Background(), snapshotChild()); err != nil {
+		t.Fatalf("Save(...): unexpected error: %s", err)
+	}
+	if created == nil {
+		t.Fatalf("Save(...): the snapshot configmap should be created if it does not exist")
+	}
+	if diff := cmp.Diff("ConfigMap "+namespace+"/cool", created.GetAnnotations()[SnapshotOfAnnotationKey]); diff != "" {
+		t.Errorf("Save(...): -want, +got:\n%s", diff)
+	}
+}
+
+func snapshotSecret() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("Secret")
+	u.SetNamespace(namespace)
+	u.SetName("cool")
+	_ = unstructured.SetNestedStringMap(u.Object, map[string]string{"password": "c2VjcmV0"}, "data")
+	return u
+}
+
+func TestConfigMapSnapshotStoreSecret(t *testing.T) {
+	var created *corev1.Secret
+	kube := &test.MockClient{
+		MockGet: func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+			if _, ok := obj.(*corev1.Secret); !ok {
+				t.Errorf("Save(...): the snapshot of a secret should not be kept in a %T", obj)
+			}
+			return kerrors.NewNotFound(schema.GroupResource{}, "snapshot")
+		},
+		MockCreate: test.NewMockCreateFn(nil, func(obj runtime.Object) error {
+			created, _ = obj.(*corev1.Secret)
+			return nil
+		}),
+	}
+	if err := NewConfigMapSnapshotStore(kube, namespace, 0).Save(context.Background(), snapshotSecret()); err != nil {
+		t.Fatalf("Save(...): unexpected error: %s", err)
+	}
+	if created == nil {
+		t.Fatalf("Save(...): the snapshot of a secret should be kept in a secret")
+	}
+	for _, b := range created.Data {
+		if !strings.Contains(string(b), "c2VjcmV0") {
+			t.Errorf("Save(...): the snapshot of a secret should keep its data, got:\n%s", b)
+		}
+	}
+}
+
+func TestHTTPSnapshotStore(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Method + " " + r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	s := NewHTTPSnapshotStore(srv.URL+"/", srv.Client())
+	s.now = func() time.Time { return time.Unix(42, 0) }
+	if err := s.Save(context.Background(), snapshotChild()); err != nil {
+		t.Fatalf("Save(...): unexpected error: %s", err)
+	}
+	if diff := cmp.Diff("PUT /configmap/"+namespace+"/cool/42.yaml", got); diff != "" {
+		t.Errorf("Save(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestHTTPSnapshotStoreSecret(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		got = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	if err := NewHTTPSnapshotStore(srv.URL, srv.Client()).Save(context.Background(), snapshotSecret()); err != nil {
+		t.Fatalf("Save(...): unexpected error: %s", err)
+	}
+	if strings.Contains(got, "c2VjcmV0") || !strings.Contains(got, "password: "+redactedSnapshotData) {
+		t.Errorf("Save(...): the data of a secret should be redacted, got:\n%s", got)
+	}
+}
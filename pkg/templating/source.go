@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rresource "github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+// Keys of the source status ConfigMap.
+const (
+	SourceStatusSourceKey    = "source"
+	SourceStatusRevisionKey  = "revision"
+	SourceStatusFetchTimeKey = "lastFetchTime"
+	SourceStatusErrorKey     = "lastError"
+)
+
+const (
+	errWriteSourceStatus = "cannot write source status"
+)
+
+// SourceStatus is the status of fetching the templates from their source.
+type SourceStatus struct {
+	// Source is where the templates are fetched from.
+	Source string
+
+	// Revision is the revision of the templates that are currently used.
+	Revision string
+
+	// LastFetchTime is when the templates were last fetched.
+	LastFetchTime time.Time
+
+	// LastError is the error of the last fetch, if it failed. Revision
+	// keeps pointing to the templates that are still in use in that case.
+	LastError error
+}
+
+// NewConfigMapSourceStatusWriter returns a new *ConfigMapSourceStatusWriter
+// that writes the ConfigMap with given name.
+func NewConfigMapSourceStatusWriter(c client.Client, nn types.NamespacedName) *ConfigMapSourceStatusWriter {
+	return &ConfigMapSourceStatusWriter{kube: c, name: nn}
+}
+
+// ConfigMapSourceStatusWriter reports the SourceStatus of the templates in a
+// ConfigMap so that operators can tell templates that failed to fetch apart
+// from a failed render at a glance, e.g. with kubectl describe.
+type ConfigMapSourceStatusWriter struct {
+	kube client.Client
+	name types.NamespacedName
+}
+
+// Write writes the given SourceStatus. An empty revision is not written so that
+// the revision in use is still shown after a failed fetch.
+func (w *ConfigMapSourceStatusWriter) Write(ctx context.Context, s SourceStatus) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: w.name.Name, Namespace: w.name.Namespace},
+		Data: map[string]string{
+			SourceStatusSourceKey:    s.Source,
+			SourceStatusFetchTimeKey: s.LastFetchTime.UTC().Format(time.RFC3339),
+			SourceStatusErrorKey:     "",
+		},
+	}
+	if s.LastError != nil {
+		cm.Data[SourceStatusErrorKey] = s.LastError.Error()
+	}
+	if s.Revision != "" {
+		cm.Data[SourceStatusRevisionKey] = s.Revision
+	}
+	return errors.Wrap(rresource.NewAPIPatchingApplicator(w.kube).Apply(ctx, cm), errWriteSourceStatus)
+}
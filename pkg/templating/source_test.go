@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestConfigMapSourceStatusWriter(t *testing.T) {
+	fetched := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	cases := map[string]struct {
+		reason string
+		status SourceStatus
+		want   map[string]string
+	}{
+		"Fetched": {
+			reason: "The revision of a successful fetch should be written",
+			status: SourceStatus{Source: "https://example.org/pack.git", Revision: "cafe", LastFetchTime: fetched},
+			want: map[string]string{
+				SourceStatusSourceKey:    "https://example.org/pack.git",
+				SourceStatusRevisionKey:  "cafe",
+				SourceStatusFetchTimeKey: "2020-06-01T10:00:00Z",
+				SourceStatusErrorKey:     "",
+			},
+		},
+		"FetchFailed": {
+			reason: "The error of a failed fetch should be written without touching the revision in use",
+			status: SourceStatus{Source: "https://example.org/pack.git", LastFetchTime: fetched, LastError: errBoom},
+			want: map[string]string{
+				SourceStatusSourceKey:    "https://example.org/pack.git",
+				SourceStatusFetchTimeKey: "2020-06-01T10:00:00Z",
+				SourceStatusErrorKey:     "boom",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var got map[string]string
+			kube := &test.MockClient{
+				MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+				MockCreate: func(_ context.Context, obj runtime.Object, _ ...client.CreateOption) error {
+					got = obj.(*corev1.ConfigMap).Data
+					return nil
+				},
+			}
+			w := NewConfigMapSourceStatusWriter(kube, types.NamespacedName{Name: "pack-source", Namespace: namespace})
+			if err := w.Write(context.Background(), tc.status); err != nil {
+				t.Fatalf("\nReason: %s\nWrite(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\nReason: %s\nWrite(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	errMapParentKind = "cannot find the resource of the parent kind"
+	errGetParentCRD  = "cannot get the CustomResourceDefinition of the parent kind"
+)
+
+var crdGroupVersionKind = schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition"}
+
+// NewStatusSubresourceDetector returns a new *StatusSubresourceDetector that
+// looks up the CustomResourceDefinition of the given kind.
+func NewStatusSubresourceDetector(c client.Reader, m meta.RESTMapper, of schema.GroupVersionKind) *StatusSubresourceDetector {
+	return &StatusSubresourceDetector{kube: c, mapper: m, gvk: of}
+}
+
+// StatusSubresourceDetector detects whether the CustomResourceDefinition of
+// a kind has the status subresource enabled, either for all its versions or
+// for the version of the kind.
+type StatusSubresourceDetector struct {
+	kube   client.Reader
+	mapper meta.RESTMapper
+	gvk    schema.GroupVersionKind
+
+	mu       sync.Mutex
+	detected bool
+	enabled  bool
+}
+
+// Enabled returns whether the status subresource is enabled. The result is
+// cached once it is detected. A kind that has no CustomResourceDefinition is
+// assumed to have the status subresource.
+func (d *StatusSubresourceDetector) Enabled(ctx context.Context) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.detected {
+		return d.enabled, nil
+	}
+	mapping, err := d.mapper.RESTMapping(d.gvk.GroupKind(), d.gvk.Version)
+	if err != nil {
+		return false, errors.Wrap(err, errMapParentKind)
+	}
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(crdGroupVersionKind)
+	err = d.kube.Get(ctx, types.NamespacedName{Name: mapping.Resource.Resource + "." + d.gvk.Group}, crd)
+	switch {
+	case kerrors.IsNotFound(err):
+		d.enabled = true
+	case err != nil:
+		return false, errors.Wrap(err, errGetParentCRD)
+	default:
+		d.enabled = hasStatusSubresource(crd.UnstructuredContent(), d.gvk.Version)
+	}
+	d.detected = true
+	return d.enabled, nil
+}
+
+func hasStatusSubresource(crd map[string]interface{}, version string) bool {
+	if _, ok, _ := unstructured.NestedFieldNoCopy(crd, "spec", "subresources", "status"); ok {
+		return true
+	}
+	versions, _, _ := unstructured.NestedSlice(crd, "spec", "versions")
+	for _, v := range versions {
+		m, ok := v.(map[string]interface{})
+		if !ok || m["name"] != version {
+			continue
+		}
+		if _, ok, _ := unstructured.NestedFieldNoCopy(m, "subresources", "status"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// statusFallbackClient is a client.Client whose status writes update the
+// whole object when the status subresource of the parent kind is not
+// enabled, since the API server rejects them otherwise while the status is
+// then stored as part of the object.
+type statusFallbackClient struct {
+	client.Client
+	detector *StatusSubresourceDetector
+}
+
+// Status returns a client.StatusWriter that falls back to the updates and
+// patches of the whole object.
+func (c *statusFallbackClient) Status() client.StatusWriter {
+	return &statusFallbackWriter{client: c.Client, detector: c.detector}
+}
+
+type statusFallbackWriter struct {
+	client   client.Client
+	detector *StatusSubresourceDetector
+}
+
+// Update updates the status of the given object. The status subresource is
+// used if it cannot be detected whether it is enabled.
+func (w *statusFallbackWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if enabled, err := w.detector.Enabled(ctx); err != nil || enabled {
+		return w.client.Status().Update(ctx, obj, opts...)
+	}
+	return w.client.Update(ctx, obj, opts...)
+}
+
+// Patch patches the status of the given object. The status subresource is
+// used if it cannot be detected whether it is enabled.
+func (w *statusFallbackWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if enabled, err := w.detector.Enabled(ctx); err != nil || enabled {
+		return w.client.Status().Patch(ctx, obj, patch, opts...)
+	}
+	return w.client.Patch(ctx, obj, patch, opts...)
+}
+
+// statusCoalescingClient is a client.Client that skips the status writes of
+// the parent resources whose status is the same as the one they were read
+// with, so that a parent resource in steady state causes no writes, and no
+// watch events by them, in its reconciles. The status that every parent
+// resource was read with last is kept until it is not found anymore.
+type statusCoalescingClient struct {
+	client.Client
+	gvk schema.GroupVersionKind
+
+	mu       sync.Mutex
+	observed map[types.NamespacedName][]byte
+}
+
+func newStatusCoalescingClient(c client.Client, of schema.GroupVersionKind) *statusCoalescingClient {
+	return &statusCoalescingClient{Client: c, gvk: of, observed: map[types.NamespacedName][]byte{}}
+}
+
+// Get retrieves the object and keeps the status it has if it is a parent
+// resource.
+func (c *statusCoalescingClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	err := c.Client.Get(ctx, key, obj)
+	if obj.GetObjectKind().GroupVersionKind() != c.gvk {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status, ok := statusOf(obj)
+	if err != nil || !ok {
+		delete(c.observed, key)
+		return err
+	}
+	c.observed[key] = status
+	return nil
+}
+
+// Status returns a client.StatusWriter that skips the writes that would not
+// change the status.
+func (c *statusCoalescingClient) Status() client.StatusWriter {
+	return &statusCoalescingWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+// unchanged returns whether the status of the given object is the one it was
+// read with.
+func (c *statusCoalescingClient) unchanged(obj runtime.Object) bool {
+	if obj.GetObjectKind().GroupVersionKind() != c.gvk {
+		return false
+	}
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	status, ok := statusOf(obj)
+	if !ok {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	observed, ok := c.observed[types.NamespacedName{Name: m.GetName(), Namespace: m.GetNamespace()}]
+	return ok && bytes.Equal(observed, status)
+}
+
+// record keeps the status of the given object after it was written.
+func (c *statusCoalescingClient) record(obj runtime.Object) {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	status, ok := statusOf(obj)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.observed[types.NamespacedName{Name: m.GetName(), Namespace: m.GetNamespace()}] = status
+}
+
+// statusOf returns the status of the given object in JSON form, which does
+// not depend on the Go types that the fields were set with.
+func statusOf(obj runtime.Object) ([]byte, bool) {
+	u, ok := obj.(interface{ UnstructuredContent() map[string]interface{} })
+	if !ok {
+		return nil, false
+	}
+	b, err := json.Marshal(u.UnstructuredContent()["status"])
+	return b, err == nil
+}
+
+type statusCoalescingWriter struct {
+	client.StatusWriter
+	client *statusCoalescingClient
+}
+
+// Update updates the status of the given object unless it is unchanged.
+func (w *statusCoalescingWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if w.client.unchanged(obj) {
+		return nil
+	}
+	if err := w.StatusWriter.Update(ctx, obj, opts...); err != nil {
+		return err
+	}
+	w.client.record(obj)
+	return nil
+}
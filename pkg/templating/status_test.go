@@ -0,0 +1,159 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestStatusSubresourceDetector(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1alpha1", Kind: "App"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gvk.GroupVersion()})
+	mapper.Add(gvk, meta.RESTScopeNamespace)
+	withCRD := func(spec map[string]interface{}) test.MockGetFn {
+		return func(_ context.Context, key client.ObjectKey, obj runtime.Object) error {
+			if key.Name != "apps.example.org" {
+				return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+			}
+			obj.(*unstructured.Unstructured).Object["spec"] = spec
+			return nil
+		}
+	}
+	cases := map[string]struct {
+		reason string
+		get    test.MockGetFn
+		want   bool
+		err    error
+	}{
+		"AllVersions": {
+			reason: "The status subresource of all versions should be detected",
+			get:    withCRD(map[string]interface{}{"subresources": map[string]interface{}{"status": map[string]interface{}{}}}),
+			want:   true,
+		},
+		"ThisVersion": {
+			reason: "The status subresource of the version of the kind should be detected",
+			get: withCRD(map[string]interface{}{"versions": []interface{}{
+				map[string]interface{}{"name": "v1alpha1", "subresources": map[string]interface{}{"status": map[string]interface{}{}}},
+			}}),
+			want: true,
+		},
+		"OtherVersion": {
+			reason: "The status subresource of other versions should not count",
+			get: withCRD(map[string]interface{}{"versions": []interface{}{
+				map[string]interface{}{"name": "v1alpha1"},
+				map[string]interface{}{"name": "v1beta1", "subresources": map[string]interface{}{"status": map[string]interface{}{}}},
+			}}),
+			want: false,
+		},
+		"NoCRD": {
+			reason: "A kind without a CustomResourceDefinition should be assumed to have the status subresource",
+			get:    test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+			want:   true,
+		},
+		"GetFailed": {
+			reason: "Errors getting the CustomResourceDefinition should be returned",
+			get:    test.NewMockGetFn(errBoom),
+			err:    errors.Wrap(errBoom, errGetParentCRD),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			d := NewStatusSubresourceDetector(&test.MockClient{MockGet: tc.get}, mapper, gvk)
+			got, err := d.Enabled(context.TODO())
+			if diff := cmp.Diff(tc.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nEnabled(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nEnabled(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestStatusFallbackClient(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1alpha1", Kind: "App"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gvk.GroupVersion()})
+	mapper.Add(gvk, meta.RESTScopeNamespace)
+	updated, statusUpdated := false, false
+	kube := &test.MockClient{
+		MockGet: test.NewMockGetFn(nil),
+		MockUpdate: func(_ context.Context, _ runtime.Object, _ ...client.UpdateOption) error {
+			updated = true
+			return nil
+		},
+		MockStatusUpdate: func(_ context.Context, _ runtime.Object, _ ...client.UpdateOption) error {
+			statusUpdated = true
+			return nil
+		},
+	}
+	c := &statusFallbackClient{Client: kube, detector: NewStatusSubresourceDetector(kube, mapper, gvk)}
+	if err := c.Status().Update(context.TODO(), &unstructured.Unstructured{}); err != nil {
+		t.Fatalf("Status().Update(...): %s", err)
+	}
+	if !updated || statusUpdated {
+		t.Errorf("Status().Update(...): want the whole object to be updated when the status subresource is not enabled")
+	}
+}
+
+func TestStatusCoalescingClient(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1alpha1", Kind: "App"}
+	writes := 0
+	kube := &test.MockClient{
+		MockGet: test.NewMockGetFn(nil, func(obj runtime.Object) error {
+			obj.(*unstructured.Unstructured).Object["status"] = map[string]interface{}{"phase": "Ready", "observedGeneration": int64(2)}
+			return nil
+		}),
+		MockStatusUpdate: func(_ context.Context, _ runtime.Object, _ ...client.UpdateOption) error {
+			writes++
+			return nil
+		},
+	}
+	c := newStatusCoalescingClient(kube, gvk)
+	cr := &unstructured.Unstructured{}
+	cr.SetGroupVersionKind(gvk)
+	cr.SetName("cool")
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "cool"}, cr); err != nil {
+		t.Fatalf("Get(...): %s", err)
+	}
+	// The status is set again with other Go types, as the converters do.
+	cr.Object["status"] = map[string]interface{}{"phase": "Ready", "observedGeneration": 2}
+	if err := c.Status().Update(context.TODO(), cr); err != nil {
+		t.Fatalf("Status().Update(...): %s", err)
+	}
+	if writes != 0 {
+		t.Errorf("Status().Update(...): want no write when the status did not change")
+	}
+	cr.Object["status"] = map[string]interface{}{"phase": "Failed"}
+	if err := c.Status().Update(context.TODO(), cr); err != nil {
+		t.Fatalf("Status().Update(...): %s", err)
+	}
+	if writes != 1 {
+		t.Errorf("Status().Update(...): want a write when the status changed")
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// StatusFieldsFile is the file in the resources directory of a pack that
+// declares the fields of the status of its parent resources that the
+// controller populates.
+const StatusFieldsFile = "status.yaml"
+
+// The render metadata that the status fields can be populated from.
+const (
+	// RenderChildResources is the number of rendered child resources.
+	RenderChildResources = "childResources"
+
+	// RenderTime is the time of the last render, in RFC 3339 form.
+	RenderTime = "renderTime"
+)
+
+const (
+	errReadStatusFields    = "cannot read status fields"
+	errParseStatusFields   = "cannot parse status fields"
+	errInvalidStatusField  = "invalid status field"
+	errReservedStatusField = "status field is written by the controller"
+	errStatusFieldSource   = "status field must be populated from either a child resource or the render metadata"
+	errStatusFieldType     = "value does not match the type of status field"
+	errSetStatusField      = "cannot set status field"
+)
+
+// reservedStatusFields are the fields of the status of the parent resources
+// that the controller writes itself.
+var reservedStatusFields = map[string]bool{
+	"conditions":                true,
+	"conditionHistory":          true,
+	"children":                  true,
+	"generatedNames":            true,
+	"templateRevision":          true,
+	"availableTemplateRevision": true,
+}
+
+// StatusFieldChild is the child resource and its field that a status field is
+// populated from.
+type StatusFieldChild struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	// Name of the child resource. The first rendered child resource of the
+	// kind is used if it is empty.
+	Name string `json:"name,omitempty"`
+
+	// FieldPath is the dotted path of the field of the child resource, whose
+	// numeric segments index lists, e.g. status.loadBalancer.ingress.0.ip.
+	FieldPath string `json:"fieldPath"`
+}
+
+// StatusField is a field of the status of the parent resources.
+type StatusField struct {
+	// Name of the field under status.
+	Name string `json:"name"`
+
+	// Type of the field, i.e. string, integer, number, boolean, object or
+	// array. Defaults to string.
+	Type string `json:"type,omitempty"`
+
+	// Description of the field in the schema of the parent resources.
+	Description string `json:"description,omitempty"`
+
+	// Child is the child resource field that the field is populated from.
+	Child *StatusFieldChild `json:"child,omitempty"`
+
+	// Render is the render metadata that the field is populated from, i.e.
+	// childResources or renderTime.
+	Render string `json:"render,omitempty"`
+}
+
+// StatusFieldsSpec is the content of the status fields file.
+type StatusFieldsSpec struct {
+	Fields []StatusField `json:"fields"`
+}
+
+// LoadStatusFields returns the *StatusFields declared in the given YAML file.
+func LoadStatusFields(path string) (*StatusFields, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, errReadStatusFields)
+	}
+	spec := StatusFieldsSpec{}
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, errors.Wrap(err, errParseStatusFields)
+	}
+	return NewStatusFields(spec.Fields...)
+}
+
+// NewStatusFields returns a new *StatusFields with the given fields.
+func NewStatusFields(fields ...StatusField) (*StatusFields, error) {
+	seen := map[string]bool{}
+	for i, f := range fields {
+		if f.Type == "" {
+			fields[i].Type = "string"
+		}
+		switch {
+		case f.Name == "" || strings.Contains(f.Name, "."):
+			return nil, errors.Errorf("%s: %q", errInvalidStatusField, f.Name)
+		case reservedStatusFields[f.Name] || seen[f.Name]:
+			return nil, errors.Errorf("%s: %s", errReservedStatusField, f.Name)
+		case (f.Child == nil) == (f.Render == ""):
+			return nil, errors.Errorf("%s: %s", errStatusFieldSource, f.Name)
+		case f.Render != "" && f.Render != RenderChildResources && f.Render != RenderTime:
+			return nil, errors.Errorf("%s: %s: unknown render metadata %q", errInvalidStatusField, f.Name, f.Render)
+		case f.Child != nil && (f.Child.Kind == "" || f.Child.FieldPath == ""):
+			return nil, errors.Errorf("%s: %s: child needs a kind and a field path", errInvalidStatusField, f.Name)
+		}
+		switch fields[i].Type {
+		case "string", "integer", "number", "boolean", "object", "array":
+		default:
+			return nil, errors.Errorf("%s: %s: unknown type %q", errInvalidStatusField, f.Name, f.Type)
+		}
+		seen[f.Name] = true
+	}
+	return &StatusFields{fields: fields, now: time.Now}, nil
+}
+
+// StatusFields are the fields of the status of the parent resources that a
+// pack declares, so that the outputs of the pack, e.g. the endpoint of a
+// database, are typed fields of the parent resources. It is a post-apply Hook
+// that populates the fields from the applied child resources and the render
+// metadata. The fields whose child resource does not have the field yet, e.g.
+// because it is not ready, are left out until it does.
+type StatusFields struct {
+	fields []StatusField
+	now    func() time.Time
+}
+
+// Run populates the status fields of the given parent resource.
+func (s *StatusFields) Run(_ context.Context, cr resource.ParentResource, list []resource.ChildResource) error {
+	for _, f := range s.fields {
+		v, ok := s.value(f, list)
+		if !ok {
+			unstructured.RemoveNestedField(cr.UnstructuredContent(), "status", f.Name)
+			continue
+		}
+		if !hasType(v, f.Type) {
+			return errors.Errorf("%s %s: %s", errStatusFieldType, f.Name, f.Type)
+		}
+		if err := unstructured.SetNestedField(cr.UnstructuredContent(), v, "status", f.Name); err != nil {
+			return errors.Wrapf(err, "%s %s", errSetStatusField, f.Name)
+		}
+	}
+	return nil
+}
+
+func (s *StatusFields) value(f StatusField, list []resource.ChildResource) (interface{}, bool) {
+	switch f.Render {
+	case RenderChildResources:
+		return int64(len(list)), true
+	case RenderTime:
+		return s.now().UTC().Format(time.RFC3339), true
+	}
+	for _, o := range list {
+		gvk := o.GetObjectKind().GroupVersionKind()
+		if gvk.Kind != f.Child.Kind || gvk.GroupVersion().String() != f.Child.APIVersion {
+			continue
+		}
+		if f.Child.Name != "" && o.GetName() != f.Child.Name {
+			continue
+		}
+		u, ok := o.(interface{ UnstructuredContent() map[string]interface{} })
+		if !ok {
+			return nil, false
+		}
+		return lookupField(u.UnstructuredContent(), strings.Split(f.Child.FieldPath, "."))
+	}
+	return nil, false
+}
+
+// lookupField returns a deep copy of the value at the given path of the
+// object.
+func lookupField(obj interface{}, path []string) (interface{}, bool) {
+	for _, segment := range path {
+		switch v := obj.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if obj, ok = v[segment]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			obj = v[i]
+		default:
+			return nil, false
+		}
+	}
+	if obj == nil {
+		return nil, false
+	}
+	return runtime.DeepCopyJSONValue(obj), true
+}
+
+func hasType(v interface{}, t string) bool {
+	switch v.(type) {
+	case string:
+		return t == "string"
+	case int64:
+		return t == "integer" || t == "number"
+	case float64:
+		return t == "number"
+	case bool:
+		return t == "boolean"
+	case map[string]interface{}:
+		return t == "object"
+	case []interface{}:
+		return t == "array"
+	}
+	return false
+}
+
+// Schema returns the OpenAPI v3 schema of the status of the parent resources,
+// which keeps the fields that the controller writes itself.
+func (s *StatusFields) Schema() extv1.JSONSchemaProps {
+	props := extv1.JSONSchemaProps{
+		Type:                   "object",
+		XPreserveUnknownFields: &preserveUnknownFields,
+		Properties:             map[string]extv1.JSONSchemaProps{},
+	}
+	for _, f := range s.fields {
+		p := extv1.JSONSchemaProps{Type: f.Type, Description: f.Description}
+		switch f.Type {
+		case "object":
+			p.XPreserveUnknownFields = &preserveUnknownFields
+		case "array":
+			p.Items = &extv1.JSONSchemaPropsOrArray{Schema: &extv1.JSONSchemaProps{XPreserveUnknownFields: &preserveUnknownFields}}
+		}
+		props.Properties[f.Name] = p
+	}
+	return props
+}
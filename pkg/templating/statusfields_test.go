@@ -0,0 +1,200 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ Hook = &StatusFields{}
+
+const statusFields = `
+fields:
+- name: endpoint
+  description: Address of the database.
+  child:
+    apiVersion: mock.child.crossplane.io/v1alpha1
+    kind: MockChildResource
+    fieldPath: status.addresses.0.ip
+- name: ports
+  type: array
+  child:
+    apiVersion: mock.child.crossplane.io/v1alpha1
+    kind: MockChildResource
+    name: cool
+    fieldPath: spec.ports
+- name: resources
+  type: integer
+  render: childResources
+`
+
+func TestLoadStatusFields(t *testing.T) {
+	dir, err := ioutil.TempDir("", "status")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir) // nolint:errcheck
+	path := filepath.Join(dir, StatusFieldsFile)
+	if err := ioutil.WriteFile(path, []byte(statusFields), 0600); err != nil {
+		t.Fatalf("cannot write status fields: %s", err)
+	}
+	s, err := LoadStatusFields(path)
+	if err != nil {
+		t.Fatalf("LoadStatusFields(...): %s", err)
+	}
+
+	want := extv1.JSONSchemaProps{
+		Type:                   "object",
+		XPreserveUnknownFields: &preserveUnknownFields,
+		Properties: map[string]extv1.JSONSchemaProps{
+			"endpoint":  {Type: "string", Description: "Address of the database."},
+			"ports":     {Type: "array", Items: &extv1.JSONSchemaPropsOrArray{Schema: &extv1.JSONSchemaProps{XPreserveUnknownFields: &preserveUnknownFields}}},
+			"resources": {Type: "integer"},
+		},
+	}
+	if diff := cmp.Diff(want, CRDValidation(nil, s).OpenAPIV3Schema.Properties["status"]); diff != "" {
+		t.Errorf("CRDValidation(...): -want status schema, +got:\n%s", diff)
+	}
+}
+
+func TestNewStatusFields(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		fields []StatusField
+		want   error
+	}{
+		"Reserved": {
+			reason: "Fields that the controller writes itself cannot be declared",
+			fields: []StatusField{{Name: "conditions", Render: RenderTime}},
+			want:   errors.Errorf("%s: %s", errReservedStatusField, "conditions"),
+		},
+		"NoSource": {
+			reason: "Fields must be populated from either a child resource or the render metadata",
+			fields: []StatusField{{Name: "endpoint"}},
+			want:   errors.Errorf("%s: %s", errStatusFieldSource, "endpoint"),
+		},
+		"UnknownType": {
+			reason: "Fields must have a type of the OpenAPI v3 schema",
+			fields: []StatusField{{Name: "endpoint", Type: "url", Render: RenderTime}},
+			want:   errors.Errorf("%s: %s: unknown type %q", errInvalidStatusField, "endpoint", "url"),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewStatusFields(tc.fields...)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nNewStatusFields(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestStatusFields(t *testing.T) {
+	child := func(name string, content map[string]interface{}) resource.ChildResource {
+		o := fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithNamespaceName(name, "apps"))
+		for k, v := range content {
+			o.Object[k] = v
+		}
+		return o
+	}
+	endpoint := StatusField{Name: "endpoint", Child: &StatusFieldChild{APIVersion: fake.MockChildGVK.GroupVersion().String(), Kind: fake.MockChildGVK.Kind, FieldPath: "status.addresses.0.ip"}}
+
+	type args struct {
+		fields []StatusField
+		list   []resource.ChildResource
+		status map[string]interface{}
+	}
+	type want struct {
+		status map[string]interface{}
+		err    error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ChildField": {
+			reason: "Fields should be populated from the fields of the child resources",
+			args: args{
+				fields: []StatusField{endpoint, {Name: "resources", Type: "integer", Render: RenderChildResources}, {Name: "renderedAt", Render: RenderTime}},
+				list: []resource.ChildResource{child("cool", map[string]interface{}{
+					"status": map[string]interface{}{"addresses": []interface{}{map[string]interface{}{"ip": "10.0.0.1"}}},
+				})},
+			},
+			want: want{
+				status: map[string]interface{}{"endpoint": "10.0.0.1", "resources": int64(1), "renderedAt": "2020-06-01T08:26:40Z"},
+			},
+		},
+		"NotYetAvailable": {
+			reason: "Fields whose child resource does not have the field yet should be left out",
+			args: args{
+				fields: []StatusField{endpoint},
+				list:   []resource.ChildResource{child("cool", nil)},
+				status: map[string]interface{}{"endpoint": "10.0.0.1"},
+			},
+			want: want{
+				status: map[string]interface{}{},
+			},
+		},
+		"WrongType": {
+			reason: "Values that do not match the type of their field should fail",
+			args: args{
+				fields: []StatusField{{Name: "resources", Type: "boolean", Render: RenderChildResources}},
+			},
+			want: want{
+				status: map[string]interface{}{},
+				err:    errors.Errorf("%s %s: %s", errStatusFieldType, "resources", "boolean"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, err := NewStatusFields(tc.args.fields...)
+			if err != nil {
+				t.Fatalf("NewStatusFields(...): %s", err)
+			}
+			s.now = func() time.Time { return time.Unix(1591000000, 0) }
+			cr := fake.NewMockResource()
+			cr.Object["status"] = map[string]interface{}{}
+			for k, v := range tc.args.status {
+				_ = unstructured.SetNestedField(cr.Object, v, "status", k)
+			}
+			err = s.Run(context.TODO(), cr, tc.args.list)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRun(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.status, cr.Object["status"]); diff != "" {
+				t.Errorf("\n%s\nRun(...): -want status, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	rresource "github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errUnknownPatchStrategy = "unknown patch strategy"
+	errNotObject            = "cannot access metadata of child resource"
+	errCreateChildResource  = "cannot create child resource"
+	errPatchChildResource   = "cannot patch child resource"
+)
+
+// PatchStrategy is the way a child resource that already exists is updated
+// to its rendered state.
+type PatchStrategy string
+
+// Patch strategies.
+const (
+	// PatchStrategyMerge sends the rendered child resource as a JSON merge
+	// patch. This is the default.
+	PatchStrategyMerge PatchStrategy = "merge"
+
+	// PatchStrategyStrategicMerge sends the rendered child resource as a
+	// strategic merge patch so that lists like containers are merged by
+	// their keys. It works only with the built-in kinds.
+	PatchStrategyStrategicMerge PatchStrategy = "strategic"
+
+	// PatchStrategyJSON sends a JSON patch that replaces every top-level
+	// field of the rendered child resource, failing if the child resource
+	// changed since it was read.
+	PatchStrategyJSON PatchStrategy = "json"
+
+	// PatchStrategyServerSideApply applies the rendered child resource with
+	// server-side apply, forcing the ownership of its fields.
+	PatchStrategyServerSideApply PatchStrategy = "apply"
+
+	// PatchStrategyReplace replaces the child resource with the rendered one.
+	PatchStrategyReplace PatchStrategy = "replace"
+)
+
+// ParsePatchStrategy returns the PatchStrategy with the given name.
+func ParsePatchStrategy(s string) (PatchStrategy, error) {
+	switch p := PatchStrategy(s); p {
+	case PatchStrategyMerge, PatchStrategyStrategicMerge, PatchStrategyJSON, PatchStrategyServerSideApply, PatchStrategyReplace:
+		return p, nil
+	}
+	return "", errors.Errorf("%s: %s", errUnknownPatchStrategy, s)
+}
+
+// NewStrategyApplicator returns a new *StrategyApplicator that uses the
+// given client, and the given field manager, to apply the child resources.
+// Conflicts of server-side apply are forced by default.
+func NewStrategyApplicator(c client.Client, fieldOwner string) *StrategyApplicator {
+	return &StrategyApplicator{
+		kube:       c,
+		fieldOwner: fieldOwner,
+		force:      true,
+		strategies: map[schema.GroupKind]PatchStrategy{},
+	}
+}
+
+// StrategyApplicator is a rresource.Applicator that updates the existing child
+// resources with the PatchStrategy configured for their kind. The kinds that
+// are not configured are updated with PatchStrategyMerge. The child resources
+// that are not replaced are created and patched in the name of the field
+// manager given to NewStrategyApplicator or SetFieldManager, which shows up in
+// their managedFields, and the conflicts of server-side apply are forced
+// unless SetFieldManager says otherwise. A child resource can override the
+// field manager and whether the conflicts are forced with the
+// FieldManagerAnnotationKey and ForceConflictsAnnotationKey annotations, and
+// the PatchStrategy of its kind with the PatchStrategyAnnotationKey
+// annotation.
+type StrategyApplicator struct {
+	kube       client.Client
+	fieldOwner string
+	force      bool
+	strategies map[schema.GroupKind]PatchStrategy
+}
+
+// Use makes the child resources of the given kind be updated with the given
+// PatchStrategy. It is not safe to call Use once the reconciler started.
+func (a *StrategyApplicator) Use(gk schema.GroupKind, s PatchStrategy) {
+	a.strategies[gk] = s
+}
+
+// SetFieldManager sets the field manager that the child resources are applied
+// in the name of, and whether the conflicts with other field managers are
+// forced when they are applied server-side. It is not safe to call
+// SetFieldManager once the reconciler started.
+func (a *StrategyApplicator) SetFieldManager(name string, force bool) {
+	a.fieldOwner = name
+	a.force = force
+}
+
+// fieldManagerFor returns the field manager of the given child resource and
+// whether its server-side apply conflicts are forced.
+func (a *StrategyApplicator) fieldManagerFor(o runtime.Object) (string, bool) {
+	name, force := a.fieldOwner, a.force
+	m, ok := o.(metav1.Object)
+	if !ok {
+		return name, force
+	}
+	if v := m.GetAnnotations()[FieldManagerAnnotationKey]; v != "" {
+		name = v
+	}
+	if v, err := strconv.ParseBool(m.GetAnnotations()[ForceConflictsAnnotationKey]); err == nil {
+		force = v
+	}
+	return name, force
+}
+
+// strategyFor returns the PatchStrategy of the given child resource, which is
+// the one it is annotated with, if any, or the one of its kind.
+func (a *StrategyApplicator) strategyFor(o runtime.Object) (PatchStrategy, error) {
+	if m, ok := o.(metav1.Object); ok {
+		if v := m.GetAnnotations()[PatchStrategyAnnotationKey]; v != "" {
+			return ParsePatchStrategy(v)
+		}
+	}
+	return a.strategies[o.GetObjectKind().GroupVersionKind().GroupKind()], nil
+}
+
+// Apply creates the given child resource if it does not exist, or updates it
+// with its PatchStrategy.
+func (a *StrategyApplicator) Apply(ctx context.Context, o runtime.Object, ao ...rresource.ApplyOption) error {
+	manager, force := a.fieldManagerFor(o)
+	strategy, err := a.strategyFor(o)
+	if err != nil {
+		return err
+	}
+	switch strategy {
+	case PatchStrategyReplace:
+		return rresource.NewAPIUpdatingApplicator(a.kube).Apply(ctx, o, ao...)
+	case PatchStrategyStrategicMerge:
+		return a.patch(ctx, o, manager, ao, func(_, desired runtime.Object) (client.Patch, []client.PatchOption, error) {
+			data, err := json.Marshal(desired)
+			return client.RawPatch(types.StrategicMergePatchType, data), nil, err
+		})
+	case PatchStrategyJSON:
+		return a.patch(ctx, o, manager, ao, func(current, desired runtime.Object) (client.Patch, []client.PatchOption, error) {
+			data, err := jsonPatch(current, desired)
+			return client.RawPatch(types.JSONPatchType, data), nil, err
+		})
+	case PatchStrategyServerSideApply:
+		return a.patch(ctx, o, manager, ao, func(_, _ runtime.Object) (client.Patch, []client.PatchOption, error) {
+			if force {
+				return client.Apply, []client.PatchOption{client.ForceOwnership}, nil
+			}
+			return client.Apply, nil, nil
+		})
+	default:
+		return a.patch(ctx, o, manager, ao, func(_, desired runtime.Object) (client.Patch, []client.PatchOption, error) {
+			data, err := json.Marshal(desired)
+			return client.RawPatch(types.MergePatchType, data), nil, err
+		})
+	}
+}
+
+// NewPatchStrategyPropagator returns a new PatchStrategyPropagator.
+func NewPatchStrategyPropagator() PatchStrategyPropagator {
+	return PatchStrategyPropagator{}
+}
+
+// PatchStrategyPropagator annotates the child resources of a parent resource
+// that is annotated with PatchStrategyAnnotationKey with the same PatchStrategy,
+// so that a fleet of parent resources can be migrated between strategies one
+// parent resource at a time without reconfiguring the controller. The child
+// resources whose templates choose a PatchStrategy keep theirs.
+type PatchStrategyPropagator struct{}
+
+// Patch annotates the child resources with the PatchStrategy of the parent
+// resource.
+func (p PatchStrategyPropagator) Patch(cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	val := cr.GetAnnotations()[PatchStrategyAnnotationKey]
+	if val == "" {
+		return list, nil
+	}
+	if _, err := ParsePatchStrategy(val); err != nil {
+		return nil, err
+	}
+	for _, o := range list {
+		if o.GetAnnotations()[PatchStrategyAnnotationKey] == "" {
+			meta.AddAnnotations(o, map[string]string{PatchStrategyAnnotationKey: val})
+		}
+	}
+	return list, nil
+}
+
+type patchFn func(current, desired runtime.Object) (client.Patch, []client.PatchOption, error)
+
+func (a *StrategyApplicator) patch(ctx context.Context, o runtime.Object, manager string, ao []rresource.ApplyOption, fn patchFn) error {
+	m, ok := o.(metav1.Object)
+	if !ok {
+		return errors.New(errNotObject)
+	}
+	current := o.DeepCopyObject()
+	err := a.kube.Get(ctx, types.NamespacedName{Name: m.GetName(), Namespace: m.GetNamespace()}, current)
+	if kerrors.IsNotFound(err) {
+		return errors.Wrap(a.kube.Create(ctx, o, client.FieldOwner(manager)), errCreateChildResource)
+	}
+	if err != nil {
+		return errors.Wrap(err, errGetChildResource)
+	}
+	for _, f := range ao {
+		if err := f(ctx, current, o); err != nil {
+			return err
+		}
+	}
+	p, opts, err := fn(current, o)
+	if err != nil {
+		return errors.Wrap(err, errMarshalChild)
+	}
+	return errors.Wrap(a.kube.Patch(ctx, o, p, append(opts, client.FieldOwner(manager))...), errPatchChildResource)
+}
+
+// jsonPatch returns a JSON patch that sets all top-level fields of the desired
+// object except its status, as well as the metadata that the templates
+// control. It tests the resource version of the current object first so that
+// the patch fails instead of overwriting concurrent changes.
+func jsonPatch(current, desired runtime.Object) ([]byte, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return nil, err
+	}
+	var ops []map[string]interface{}
+	if m, ok := current.(metav1.Object); ok && m.GetResourceVersion() != "" {
+		ops = append(ops, map[string]interface{}{"op": "test", "path": "/metadata/resourceVersion", "value": m.GetResourceVersion()})
+	}
+	keys := make([]string, 0, len(content))
+	for k := range content {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch k {
+		case "apiVersion", "kind", "status":
+		case "metadata":
+			md, _ := content[k].(map[string]interface{})
+			for _, f := range []string{"labels", "annotations", "ownerReferences", "finalizers"} {
+				if v, ok := md[f]; ok {
+					ops = append(ops, map[string]interface{}{"op": "add", "path": "/metadata/" + f, "value": v})
+				}
+			}
+		default:
+			ops = append(ops, map[string]interface{}{"op": "add", "path": "/" + k, "value": content[k]})
+		}
+	}
+	return json.Marshal(ops)
+}
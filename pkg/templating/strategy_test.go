@@ -0,0 +1,243 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rresource "github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var (
+	_ rresource.Applicator = &StrategyApplicator{}
+	_ ChildResourcePatcher = PatchStrategyPropagator{}
+)
+
+func TestStrategyApplicator(t *testing.T) {
+	type want struct {
+		err       error
+		patchType types.PatchType
+		updated   bool
+		created   bool
+	}
+	cases := map[string]struct {
+		reason      string
+		strategy    PatchStrategy
+		annotations map[string]string
+		get         error
+		want        want
+	}{
+		"Default": {
+			reason: "Kinds without a strategy should be updated with a JSON merge patch",
+			want:   want{patchType: types.MergePatchType},
+		},
+		"StrategicMerge": {
+			reason:   "A strategic merge patch should be sent for the kinds using that strategy",
+			strategy: PatchStrategyStrategicMerge,
+			want:     want{patchType: types.StrategicMergePatchType},
+		},
+		"JSON": {
+			reason:   "A JSON patch should be sent for the kinds using that strategy",
+			strategy: PatchStrategyJSON,
+			want:     want{patchType: types.JSONPatchType},
+		},
+		"ServerSideApply": {
+			reason:   "The child resource should be applied server-side for the kinds using that strategy",
+			strategy: PatchStrategyServerSideApply,
+			want:     want{patchType: types.ApplyPatchType},
+		},
+		"Replace": {
+			reason:   "The child resource should be replaced for the kinds using that strategy",
+			strategy: PatchStrategyReplace,
+			want:     want{updated: true},
+		},
+		"Annotated": {
+			reason:      "The strategy the child resource is annotated with should override the one of its kind",
+			strategy:    PatchStrategyStrategicMerge,
+			annotations: map[string]string{PatchStrategyAnnotationKey: string(PatchStrategyServerSideApply)},
+			want:        want{patchType: types.ApplyPatchType},
+		},
+		"UnknownAnnotated": {
+			reason:      "An unknown strategy the child resource is annotated with should fail",
+			annotations: map[string]string{PatchStrategyAnnotationKey: "yolo"},
+			want:        want{err: errors.Errorf("%s: %s", errUnknownPatchStrategy, "yolo")},
+		},
+		"Create": {
+			reason:   "The child resource should be created if it does not exist",
+			strategy: PatchStrategyStrategicMerge,
+			get:      kerrors.NewNotFound(schema.GroupResource{}, ""),
+			want:     want{created: true},
+		},
+		"GetFailed": {
+			reason:   "The error should be returned if the child resource cannot be fetched",
+			strategy: PatchStrategyJSON,
+			get:      errBoom,
+			want:     want{err: errors.Wrap(errBoom, errGetChildResource)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := want{}
+			kube := &test.MockClient{
+				MockGet: test.NewMockGetFn(tc.get),
+				MockPatch: func(_ context.Context, _ runtime.Object, p client.Patch, _ ...client.PatchOption) error {
+					got.patchType = p.Type()
+					return nil
+				},
+				MockUpdate: func(_ context.Context, _ runtime.Object, _ ...client.UpdateOption) error {
+					got.updated = true
+					return nil
+				},
+				MockCreate: func(_ context.Context, _ runtime.Object, _ ...client.CreateOption) error {
+					got.created = true
+					return nil
+				},
+			}
+			a := NewStrategyApplicator(kube, FieldOwner)
+			if tc.strategy != "" {
+				a.Use(fake.MockChildGVK.GroupKind(), tc.strategy)
+			}
+			got.err = a.Apply(context.Background(), fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithAdditionalAnnotations(tc.annotations)))
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors(), cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\nReason: %s\nApply(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestParsePatchStrategy(t *testing.T) {
+	if _, err := ParsePatchStrategy("json"); err != nil {
+		t.Errorf("ParsePatchStrategy(...): unexpected error: %s", err)
+	}
+	want := errors.Errorf("%s: %s", errUnknownPatchStrategy, "yolo")
+	_, err := ParsePatchStrategy("yolo")
+	if diff := cmp.Diff(want, err, test.EquateErrors()); diff != "" {
+		t.Errorf("ParsePatchStrategy(...): -want error, +got error:\n%s", diff)
+	}
+}
+
+func TestPatchStrategyPropagator(t *testing.T) {
+	type want struct {
+		strategies []string
+		err        error
+	}
+	cases := map[string]struct {
+		reason string
+		parent string
+		child  string
+		want   want
+	}{
+		"NotAnnotated": {
+			reason: "The child resources of parent resources without a strategy should be left untouched",
+			want:   want{strategies: []string{""}},
+		},
+		"Propagated": {
+			reason: "The strategy of the parent resource should be propagated to its child resources",
+			parent: string(PatchStrategyServerSideApply),
+			want:   want{strategies: []string{string(PatchStrategyServerSideApply)}},
+		},
+		"ChildAnnotated": {
+			reason: "The strategy that the templates chose for a child resource should be kept",
+			parent: string(PatchStrategyServerSideApply),
+			child:  string(PatchStrategyReplace),
+			want:   want{strategies: []string{string(PatchStrategyReplace)}},
+		},
+		"Unknown": {
+			reason: "An unknown strategy of the parent resource should fail",
+			parent: "yolo",
+			want:   want{err: errors.Errorf("%s: %s", errUnknownPatchStrategy, "yolo")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			annotated := func(v string) fake.MockResourceOption {
+				if v == "" {
+					return fake.WithAdditionalAnnotations(nil)
+				}
+				return fake.WithAdditionalAnnotations(map[string]string{PatchStrategyAnnotationKey: v})
+			}
+			cr := fake.NewMockResource(annotated(tc.parent))
+			list, err := NewPatchStrategyPropagator().Patch(cr, []resource.ChildResource{fake.NewMockResource(annotated(tc.child))})
+			got := want{err: err}
+			for _, o := range list {
+				got.strategies = append(got.strategies, o.GetAnnotations()[PatchStrategyAnnotationKey])
+			}
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors(), cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\nReason: %s\nPatch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestStrategyApplicatorFieldManager(t *testing.T) {
+	type want struct {
+		manager string
+		force   bool
+	}
+	cases := map[string]struct {
+		reason      string
+		annotations map[string]string
+		want        want
+	}{
+		"Configured": {
+			reason: "The configured field manager and conflict policy should be used",
+			want:   want{manager: "platform-packs", force: false},
+		},
+		"Annotated": {
+			reason:      "The annotations of the child resource should override the configured field manager and conflict policy",
+			annotations: map[string]string{FieldManagerAnnotationKey: "database-packs", ForceConflictsAnnotationKey: "true"},
+			want:        want{manager: "database-packs", force: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := want{}
+			kube := &test.MockClient{
+				MockGet: test.NewMockGetFn(nil),
+				MockPatch: func(_ context.Context, _ runtime.Object, _ client.Patch, opts ...client.PatchOption) error {
+					po := (&client.PatchOptions{}).ApplyOptions(opts)
+					got.manager = po.FieldManager
+					got.force = po.Force != nil && *po.Force
+					return nil
+				},
+			}
+			a := NewStrategyApplicator(kube, FieldOwner)
+			a.SetFieldManager("platform-packs", false)
+			a.Use(fake.MockChildGVK.GroupKind(), PatchStrategyServerSideApply)
+			o := fake.NewMockResource(fake.WithGVK(fake.MockChildGVK), fake.WithAdditionalAnnotations(tc.annotations))
+			if err := a.Apply(context.Background(), o); err != nil {
+				t.Fatalf("\nReason: %s\nApply(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\nReason: %s\nApply(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errMarshalChild = "cannot marshal child resource"
+	errUnknownField = "child resource has unknown fields"
+)
+
+// NewStrictSchemaValidator returns a new StrictSchemaValidator that validates
+// the child resources whose kinds are registered in the given scheme.
+func NewStrictSchemaValidator(s *runtime.Scheme) StrictSchemaValidator {
+	return StrictSchemaValidator{scheme: s}
+}
+
+// StrictSchemaValidator is a ChildResourcePatcher that rejects the child
+// resources with fields that their types do not have, e.g. replica instead of
+// replicas in a Deployment, which would otherwise be silently dropped by the
+// API server. Child resources whose kinds are not registered in the scheme,
+// such as custom resources, are not validated.
+type StrictSchemaValidator struct {
+	scheme *runtime.Scheme
+}
+
+// Patch returns error if any of the child resources has unknown fields.
+func (v StrictSchemaValidator) Patch(_ resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	for _, o := range list {
+		typed, err := v.scheme.New(o.GetObjectKind().GroupVersionKind())
+		if err != nil {
+			continue
+		}
+		ref := resource.ReferenceTo(o)
+		raw, err := json.Marshal(o)
+		if err != nil {
+			return nil, &resource.PatchError{Patcher: "StrictSchemaValidator", Target: &ref, Err: errors.Wrap(err, errMarshalChild)}
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(typed); err != nil {
+			return nil, &resource.PatchError{Patcher: "StrictSchemaValidator", Target: &ref, Err: errors.Wrap(err, errUnknownField)}
+		}
+	}
+	return list, nil
+}
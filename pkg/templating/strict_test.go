@@ -0,0 +1,74 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+var (
+	_ ChildResourcePatcher = StrictSchemaValidator{}
+)
+
+func TestStrictSchemaValidator(t *testing.T) {
+	deployment := func(specField string) resource.ChildResource {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "cool"},
+			"spec":       map[string]interface{}{specField: int64(3)},
+		}}
+	}
+	custom := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.org/v1",
+		"kind":       "Database",
+		"metadata":   map[string]interface{}{"name": "cool"},
+		"spec":       map[string]interface{}{"anything": "goes"},
+	}}
+	cases := map[string]struct {
+		reason  string
+		list    []resource.ChildResource
+		wantErr bool
+	}{
+		"Known": {
+			reason: "Child resources with only known fields should pass",
+			list:   []resource.ChildResource{deployment("replicas")},
+		},
+		"Unknown": {
+			reason:  "Child resources with unknown fields should be rejected",
+			list:    []resource.ChildResource{deployment("replica")},
+			wantErr: true,
+		},
+		"NotRegistered": {
+			reason: "Child resources whose kinds are not in the scheme should not be validated",
+			list:   []resource.ChildResource{custom},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewStrictSchemaValidator(clientgoscheme.Scheme).Patch(nil, tc.list)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("\nReason: %s\nPatch(...): want error %t, got %v", tc.reason, tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane/pkg/packages"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errListTracked    = "cannot list child resources with parent labels"
+	errGetOrphanOwner = "cannot get parent resource of child resource"
+	errDeleteOrphan   = "cannot delete orphaned child resource"
+)
+
+// NewOrphanSweeper returns a new *OrphanSweeper that looks up the parent
+// resources of given kind with the given reader and sweeps the child
+// resources of given kinds with the given client.
+func NewOrphanSweeper(parents client.Reader, children client.Client, parent schema.GroupVersionKind, kinds ...schema.GroupVersionKind) *OrphanSweeper {
+	return &OrphanSweeper{
+		parents:  parents,
+		children: children,
+		parent:   parent,
+		kinds:    kinds,
+		log:      logging.NewNopLogger(),
+	}
+}
+
+// OrphanSweeper deletes the child resources that carry the parent labels of
+// the parent resources of a kind, i.e. the ones added by ParentLabelSetAdder,
+// but whose parent resource no longer exists or was re-created since. These
+// are leaked when the finalizer of a parent resource is removed without the
+// controller, e.g. by a forced deletion, and the garbage collector of
+// Kubernetes cannot delete them, e.g. because they are cluster-scoped or in a
+// remote cluster.
+type OrphanSweeper struct {
+	parents    client.Reader
+	children   client.Client
+	parent     schema.GroupVersionKind
+	kinds      []schema.GroupVersionKind
+	reportOnly bool
+	log        logging.Logger
+}
+
+// SetReportOnly makes the sweeper only log the orphaned child resources
+// instead of deleting them.
+func (s *OrphanSweeper) SetReportOnly(reportOnly bool) {
+	s.reportOnly = reportOnly
+}
+
+// SetLogger changes the logger that the sweeper logs the orphaned child
+// resources with.
+func (s *OrphanSweeper) SetLogger(log logging.Logger) {
+	s.log = log
+}
+
+// Sweep deletes, or only reports, the orphaned child resources and returns
+// their references.
+func (s *OrphanSweeper) Sweep(ctx context.Context) ([]resource.ChildReference, error) {
+	var result []resource.ChildReference
+	for _, gvk := range s.kinds {
+		l := &unstructured.UnstructuredList{}
+		l.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := s.children.List(ctx, l, client.MatchingLabels{
+			packages.LabelParentGroup: s.parent.Group,
+			packages.LabelParentKind:  s.parent.Kind,
+		}); err != nil {
+			return result, errors.Wrap(err, errListTracked)
+		}
+		for i := range l.Items {
+			o := &l.Items[i]
+			// The child resources that were orphaned on purpose are meant
+			// to outlive their parent resource.
+			if IsOrphaned(o) {
+				continue
+			}
+			orphaned, err := s.orphaned(ctx, o)
+			if err != nil {
+				return result, err
+			}
+			if !orphaned {
+				continue
+			}
+			result = append(result, resource.ReferenceTo(o))
+			s.log.Info("Found orphaned child resource", "kind", o.GetKind(), "name", o.GetName(), "namespace", o.GetNamespace(), "report-only", s.reportOnly)
+			if s.reportOnly {
+				continue
+			}
+			if err := s.children.Delete(ctx, o); client.IgnoreNotFound(err) != nil {
+				return result, errors.Wrap(err, errDeleteOrphan)
+			}
+		}
+	}
+	return result, nil
+}
+
+// orphaned returns true if the parent resource that the given child resource
+// is labeled with does not exist, or if it is not the one that controls the
+// child resource.
+func (s *OrphanSweeper) orphaned(ctx context.Context, o *unstructured.Unstructured) (bool, error) {
+	labels := o.GetLabels()
+	name := labels[packages.LabelParentName]
+	if name == "" {
+		return false, nil
+	}
+	p := &unstructured.Unstructured{}
+	p.SetGroupVersionKind(s.parent)
+	err := s.parents.Get(ctx, types.NamespacedName{Name: name, Namespace: labels[packages.LabelParentNamespace]}, p)
+	if kerrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, errGetOrphanOwner)
+	}
+	ref := metav1.GetControllerOf(o)
+	return ref != nil && ref.Kind == s.parent.Kind && ref.UID != p.GetUID(), nil
+}
+
+// NewOrphanSweeperRunnable returns a manager.Runnable that runs the given
+// sweeper in the given interval until the manager stops. The failed sweeps
+// are only logged and retried in the next interval.
+func NewOrphanSweeperRunnable(s *OrphanSweeper, interval time.Duration) func(stop <-chan struct{}) error {
+	return func(stop <-chan struct{}) error {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return nil
+			case <-t.C:
+				ctx, cancel := context.WithTimeout(context.Background(), reconcileTimeout)
+				if _, err := s.Sweep(ctx); err != nil {
+					s.log.Info("Cannot sweep orphaned child resources", "error", err)
+				}
+				cancel()
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+	"github.com/crossplane/crossplane/pkg/packages"
+
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestOrphanSweeper(t *testing.T) {
+	parentGVK := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "App"}
+	live := fake.NewMockResource(fake.WithGVK(parentGVK), fake.WithNamespaceName("live", "ns"), fake.WithUID("live"))
+	tracked := func(name, parent string, controller types.UID) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+		u.SetName(name)
+		u.SetLabels(map[string]string{
+			packages.LabelParentGroup:     parentGVK.Group,
+			packages.LabelParentKind:      parentGVK.Kind,
+			packages.LabelParentNamespace: "ns",
+			packages.LabelParentName:      parent,
+		})
+		p := fake.NewMockResource(fake.WithGVK(parentGVK), fake.WithNamespaceName(parent, "ns"), fake.WithUID(controller))
+		meta.AddOwnerReference(&u, meta.AsController(meta.ReferenceTo(p, parentGVK)))
+		return u
+	}
+	orphaned := tracked("orphaned", "deleted", "deleted")
+	orphaned.SetAnnotations(map[string]string{OrphanAnnotationKey: OrphanTrueValue})
+	existing := []unstructured.Unstructured{
+		tracked("kept", "live", "live"),
+		tracked("gone", "deleted", "deleted"),
+		tracked("recreated", "live", "old"),
+		orphaned,
+	}
+	cases := map[string]struct {
+		reason     string
+		reportOnly bool
+		want       []string
+	}{
+		"Delete": {
+			reason: "The child resources whose parent resource is gone or re-created should be deleted, except the ones orphaned on purpose",
+			want:   []string{"gone", "recreated"},
+		},
+		"ReportOnly": {
+			reason:     "Nothing should be deleted in report-only mode",
+			reportOnly: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var deleted []string
+			parents := &test.MockClient{
+				MockGet: func(_ context.Context, key client.ObjectKey, obj runtime.Object) error {
+					if key.Name != live.GetName() {
+						return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+					}
+					obj.(metav1.Object).SetUID(live.GetUID())
+					return nil
+				},
+			}
+			children := &test.MockClient{
+				MockList: test.NewMockListFn(nil, func(obj runtime.Object) error {
+					obj.(*unstructured.UnstructuredList).Items = existing
+					return nil
+				}),
+				MockDelete: func(_ context.Context, obj runtime.Object, _ ...client.DeleteOption) error {
+					deleted = append(deleted, obj.(metav1.Object).GetName())
+					return nil
+				},
+			}
+			s := NewOrphanSweeper(parents, children, parentGVK, schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+			s.SetReportOnly(tc.reportOnly)
+			got, err := s.Sweep(context.Background())
+			if err != nil {
+				t.Errorf("\nReason: %s\nSweep(...): unexpected error: %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(2, len(got)); diff != "" {
+				t.Errorf("\nReason: %s\nSweep(...): -want orphans, +got orphans:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want, deleted); diff != "" {
+				t.Errorf("\nReason: %s\nSweep(...): -want deleted, +got deleted:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
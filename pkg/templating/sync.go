@@ -0,0 +1,105 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+type syncRecord struct {
+	input string
+	time  time.Time
+}
+
+// newSyncTracker returns a new *syncTracker with given interval. An interval
+// of zero disables the periodic syncs, i.e. the parent resources are synced
+// only when their input changes.
+func newSyncTracker(interval time.Duration) *syncTracker {
+	return &syncTracker{
+		interval: interval,
+		records:  map[types.UID]syncRecord{},
+		now:      time.Now,
+	}
+}
+
+// syncTracker keeps track of the last successful sync of every parent resource
+// so that the re-render and re-apply of children, i.e. the drift correction,
+// can happen in a different interval than the requeue of the parent resource.
+// The records are kept in memory, so all parent resources are synced once the
+// controller restarts.
+type syncTracker struct {
+	interval time.Duration
+	mu       sync.Mutex
+	records  map[types.UID]syncRecord
+	now      func() time.Time
+}
+
+// Due returns true if the given parent resource should be synced either
+// because its input has changed since the last sync or the sync interval has
+// elapsed.
+func (s *syncTracker) Due(cr resource.ParentResource) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[cr.GetUID()]
+	if !ok || rec.input != syncInput(cr) {
+		return true
+	}
+	return s.interval != 0 && s.now().Sub(rec.time) >= s.interval
+}
+
+// Unchanged returns true if the given parent resource has been synced before
+// and its input has not changed since then, i.e. the sync is only a periodic
+// one.
+func (s *syncTracker) Unchanged(cr resource.ParentResource) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[cr.GetUID()]
+	return ok && rec.input == syncInput(cr)
+}
+
+// Synced records that the given parent resource has been synced successfully.
+func (s *syncTracker) Synced(cr resource.ParentResource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[cr.GetUID()] = syncRecord{input: syncInput(cr), time: s.now()}
+}
+
+// Forget removes the record of the given parent resource so that it is synced
+// in the next reconcile.
+func (s *syncTracker) Forget(cr resource.ParentResource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, cr.GetUID())
+}
+
+// syncInput returns a digest of the parts of the parent resource that affect
+// the rendered child resources. The generation covers the spec while labels
+// and annotations are covered separately since their changes do not bump the
+// generation. The annotations that the reconciler sets with the result of the
+// syncs are left out so that setting them does not trigger another sync.
+func syncInput(cr resource.ParentResource) string {
+	b, _ := json.Marshal([]interface{}{cr.GetGeneration(), cr.GetLabels(), withoutResultAnnotations(cr).GetAnnotations()})
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
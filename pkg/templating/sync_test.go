@@ -0,0 +1,105 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+func TestSyncTracker_Due(t *testing.T) {
+	synced := time.Now()
+	type args struct {
+		interval time.Duration
+		now      time.Time
+		recorded resource.ParentResource
+		cr       resource.ParentResource
+	}
+	cases := map[string]struct {
+		reason string
+		args
+		due bool
+	}{
+		"NeverSynced": {
+			reason: "A parent resource that has never been synced should be due",
+			args: args{
+				interval: time.Minute,
+				now:      synced,
+				cr:       fake.NewMockResource(fake.WithUID("foo")),
+			},
+			due: true,
+		},
+		"IntervalNotElapsed": {
+			reason: "A parent resource should not be due before the interval elapses",
+			args: args{
+				interval: time.Minute,
+				now:      synced.Add(time.Second),
+				recorded: fake.NewMockResource(fake.WithUID("foo")),
+				cr:       fake.NewMockResource(fake.WithUID("foo")),
+			},
+			due: false,
+		},
+		"IntervalElapsed": {
+			reason: "A parent resource should be due once the interval elapses",
+			args: args{
+				interval: time.Minute,
+				now:      synced.Add(time.Minute),
+				recorded: fake.NewMockResource(fake.WithUID("foo")),
+				cr:       fake.NewMockResource(fake.WithUID("foo")),
+			},
+			due: true,
+		},
+		"InputChanged": {
+			reason: "A parent resource whose labels changed should be due regardless of the interval",
+			args: args{
+				interval: time.Minute,
+				now:      synced.Add(time.Second),
+				recorded: fake.NewMockResource(fake.WithUID("foo")),
+				cr:       fake.NewMockResource(fake.WithUID("foo"), fake.WithAdditionalLabels(map[string]string{"team": "olala"})),
+			},
+			due: true,
+		},
+		"PeriodicSyncDisabled": {
+			reason: "A parent resource should never be due for periodic sync if the interval is zero",
+			args: args{
+				interval: 0,
+				now:      synced.Add(24 * time.Hour),
+				recorded: fake.NewMockResource(fake.WithUID("foo")),
+				cr:       fake.NewMockResource(fake.WithUID("foo")),
+			},
+			due: false,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := newSyncTracker(tc.args.interval)
+			if tc.args.recorded != nil {
+				s.now = func() time.Time { return synced }
+				s.Synced(tc.args.recorded)
+			}
+			s.now = func() time.Time { return tc.args.now }
+			if diff := cmp.Diff(tc.due, s.Due(tc.args.cr)); diff != "" {
+				t.Errorf("\nReason: %s\nDue(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+	"strings"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+// WithUIDToken returns a ReconcilerOption that exposes the short identifier
+// derived from the UID of the parent resource to the templates as the
+// uidToken parameter and replaces resource.UIDTokenPlaceholder with it in the
+// rendered child resources.
+func WithUIDToken() ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		WithValuesProvider(ValuesProviderFunc(UIDTokenValues))(reconciler)
+		WithAdditionalChildResourcePatcher(NewUIDTokenSubstituter())(reconciler)
+	}
+}
+
+// UIDTokenValues returns the short identifier derived from the UID of the
+// given parent resource under the uidToken parameter. It has the same value
+// in every render of the parent resource, so the templates can use it in the
+// names of cloud resources that have to be globally unique.
+func UIDTokenValues(_ context.Context, cr resource.ParentResource) (map[string]interface{}, error) {
+	return map[string]interface{}{resource.UIDTokenParameter: resource.UIDToken(cr)}, nil
+}
+
+// NewUIDTokenSubstituter returns a new UIDTokenSubstituter.
+func NewUIDTokenSubstituter() UIDTokenSubstituter {
+	return UIDTokenSubstituter{}
+}
+
+// UIDTokenSubstituter is a ChildResourcePatcher that replaces
+// resource.UIDTokenPlaceholder in every string field of the child resources,
+// including their names, with the short identifier derived from the UID of
+// the parent resource. It works with the templates of every engine, e.g. for
+// the fields that Kustomize does not substitute vars in.
+type UIDTokenSubstituter struct{}
+
+// Patch patches the child resources with information in resource.ParentResource.
+func (s UIDTokenSubstituter) Patch(cr resource.ParentResource, list []resource.ChildResource) ([]resource.ChildResource, error) {
+	token := resource.UIDToken(cr)
+	for _, o := range list {
+		u, ok := o.(interface{ UnstructuredContent() map[string]interface{} })
+		if !ok {
+			continue
+		}
+		substitute(u.UnstructuredContent(), token)
+	}
+	return list, nil
+}
+
+func substitute(v interface{}, token string) interface{} {
+	switch t := v.(type) {
+	case string:
+		return strings.ReplaceAll(t, resource.UIDTokenPlaceholder, token)
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = substitute(e, token)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = substitute(e, token)
+		}
+	}
+	return v
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/resource/fake"
+)
+
+var _ ChildResourcePatcher = UIDTokenSubstituter{}
+
+func TestUIDTokenSubstituter(t *testing.T) {
+	parent := fake.NewMockResource(fake.WithUID("d8ba2a3c-4e2b-4b5a-9b4e-0d5c8a4f1e21"))
+	other := fake.NewMockResource(fake.WithUID("5f0c8b9e-8a7d-4c3b-a2e1-9f8e7d6c5b4a"))
+	token := resource.UIDToken(parent)
+	if token == resource.UIDToken(other) || len(token) != 8 {
+		t.Fatalf("UIDToken(...): %q is not a short token unique to the parent resource", token)
+	}
+
+	child := fake.NewMockResource(fake.FromYAML([]byte(`
+apiVersion: s3.example.org/v1
+kind: Bucket
+metadata:
+  name: assets-$(UID_TOKEN)
+spec:
+  tags:
+  - owner-$(UID_TOKEN)
+  region: us-east-1
+`)))
+	if _, err := NewUIDTokenSubstituter().Patch(parent, []resource.ChildResource{child}); err != nil {
+		t.Fatalf("Patch(...): %s", err)
+	}
+	if diff := cmp.Diff("assets-"+token, child.GetName()); diff != "" {
+		t.Errorf("Patch(...): -want name, +got name:\n%s", diff)
+	}
+	want := map[string]interface{}{"tags": []interface{}{"owner-" + token}, "region": "us-east-1"}
+	if diff := cmp.Diff(want, child.UnstructuredContent()["spec"]); diff != "" {
+		t.Errorf("Patch(...): -want spec, +got spec:\n%s", diff)
+	}
+}
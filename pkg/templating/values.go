@@ -0,0 +1,45 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templating
+
+import (
+	"context"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+)
+
+const (
+	errValuesProvider   = "cannot get values from values provider"
+	errInitializeValues = "cannot initialize values of values provider"
+	errCopyParent       = "cannot copy parent resource"
+)
+
+// renderInput returns the parent resource to be given to the engine and the
+// patchers, which is a copy of the given one converted into the served version
+// and decorated with the values of the configured ValuesProviders. The
+// annotations that the reconciler sets with the result of the reconciles are
+// not part of it.
+func (r *Reconciler) renderInput(ctx context.Context, cr resource.ParentResource) (resource.ParentResource, error) {
+	return r.pipeline().Input(ctx, cr)
+}
+
+// renderInputWith returns the render input of the given parent resource
+// decorated with the values of the given ValuesProviders instead of the
+// configured ones.
+func (r *Reconciler) renderInputWith(ctx context.Context, cr resource.ParentResource, values ValuesProviderChain) (resource.ParentResource, error) {
+	return r.pipeline().InputWith(ctx, cr, values)
+}
@@ -0,0 +1,315 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scale measures how the templating controller scales with the number
+// of parent resources and of their child resources against a real API server.
+package scale
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	runtimefake "github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+
+	"github.com/crossplane/templating-controller/pkg/resource"
+	"github.com/crossplane/templating-controller/pkg/templating"
+)
+
+const (
+	errNewClient     = "cannot create client"
+	errCreateParent  = "cannot create parent resource"
+	errReconcile     = "cannot reconcile parent resource"
+	defaultNamespace = "default"
+)
+
+// ParentGVK is the kind of the synthetic parent resources, which is defined
+// by the CRD in the crds directory.
+var ParentGVK = schema.GroupVersionKind{Group: "scale.templating.crossplane.io", Version: "v1alpha1", Kind: "ScaleParent"}
+
+// Config is the shape of a scale test.
+type Config struct {
+	// Parents is the number of parent resources.
+	Parents int
+
+	// ChildrenPerParent is the number of child resources that every parent
+	// resource renders.
+	ChildrenPerParent int
+
+	// Concurrency is the number of reconciles that run at the same time, like
+	// the workers of the controller.
+	Concurrency int
+
+	// Options configure the reconciler, e.g. to measure the concurrency and
+	// caching features.
+	Options []templating.ReconcilerOption
+}
+
+// Pass is the measurement of reconciling every parent resource once.
+type Pass struct {
+	// Duration is how long the pass took.
+	Duration time.Duration
+
+	// Writes are the writes to the API server during the pass, by verb.
+	Writes Writes
+
+	// AllocatedBytes is the memory allocated during the pass.
+	AllocatedBytes uint64
+}
+
+// Throughput returns the reconciles per second of the pass for the given
+// number of parent resources.
+func (p Pass) Throughput(parents int) float64 {
+	return float64(parents) / p.Duration.Seconds()
+}
+
+// Result is the measurement of a scale test.
+type Result struct {
+	// Initial is the pass that creates all child resources.
+	Initial Pass
+
+	// Steady is the pass after the initial one, when nothing changed and
+	// ideally nothing is written.
+	Steady Pass
+
+	// HeapBytes is the memory in use after both passes.
+	HeapBytes uint64
+}
+
+// String returns a human-readable summary of the result.
+func (r Result) String(c Config) string {
+	return fmt.Sprintf("%d parents with %d children: initial %s (%.1f/s, writes %s, %d bytes), steady %s (%.1f/s, writes %s, %d bytes), heap %d bytes",
+		c.Parents, c.ChildrenPerParent,
+		r.Initial.Duration, r.Initial.Throughput(c.Parents), r.Initial.Writes, r.Initial.AllocatedBytes,
+		r.Steady.Duration, r.Steady.Throughput(c.Parents), r.Steady.Writes, r.Steady.AllocatedBytes,
+		r.HeapBytes)
+}
+
+// Run creates the synthetic parent resources of the given Config in the API
+// server of the given rest.Config and reconciles each of them twice, calling
+// the reconciler directly so that the measurement does not depend on the
+// timing of watches and requeues. The CRD of ParentGVK has to be installed.
+func Run(ctx context.Context, cfg *rest.Config, c Config) (Result, error) {
+	s := kruntime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		return Result{}, errors.Wrap(err, errNewClient)
+	}
+	kube, err := client.New(cfg, client.Options{Scheme: s})
+	if err != nil {
+		return Result{}, errors.Wrap(err, errNewClient)
+	}
+	counting := NewCountingClient(kube)
+	mgr := &runtimefake.Manager{Client: counting, Scheme: s}
+	o := append([]templating.ReconcilerOption{templating.WithEngine(NewSyntheticEngine(c.ChildrenPerParent))}, c.Options...)
+	r := templating.NewReconciler(mgr, ParentGVK, o...)
+
+	names := make([]types.NamespacedName, c.Parents)
+	for i := range names {
+		p := &unstructured.Unstructured{}
+		p.SetGroupVersionKind(ParentGVK)
+		p.SetNamespace(defaultNamespace)
+		p.SetName(fmt.Sprintf("parent-%d", i))
+		if err := unstructured.SetNestedField(p.Object, int64(i), "spec", "index"); err != nil {
+			return Result{}, errors.Wrap(err, errCreateParent)
+		}
+		if err := kube.Create(ctx, p); err != nil {
+			return Result{}, errors.Wrap(err, errCreateParent)
+		}
+		names[i] = types.NamespacedName{Namespace: defaultNamespace, Name: p.GetName()}
+	}
+
+	res := Result{}
+	if res.Initial, err = pass(r, counting, names, c.Concurrency); err != nil {
+		return res, err
+	}
+	if res.Steady, err = pass(r, counting, names, c.Concurrency); err != nil {
+		return res, err
+	}
+	runtime.GC()
+	m := runtime.MemStats{}
+	runtime.ReadMemStats(&m)
+	res.HeapBytes = m.HeapAlloc
+	return res, nil
+}
+
+// pass reconciles every parent resource once in the given number of workers.
+func pass(r reconcile.Reconciler, c *CountingClient, names []types.NamespacedName, workers int) (Pass, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	before := runtime.MemStats{}
+	runtime.ReadMemStats(&before)
+	c.Reset()
+	start := time.Now()
+
+	var (
+		wg   sync.WaitGroup
+		once sync.Once
+		err  error
+		jobs = make(chan types.NamespacedName)
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for nn := range jobs {
+				if _, rerr := r.Reconcile(reconcile.Request{NamespacedName: nn}); rerr != nil {
+					once.Do(func() { err = errors.Wrapf(rerr, "%s %s", errReconcile, nn) })
+				}
+			}
+		}()
+	}
+	for _, nn := range names {
+		jobs <- nn
+	}
+	close(jobs)
+	wg.Wait()
+
+	p := Pass{Duration: time.Since(start), Writes: c.Writes()}
+	after := runtime.MemStats{}
+	runtime.ReadMemStats(&after)
+	p.AllocatedBytes = after.TotalAlloc - before.TotalAlloc
+	return p, err
+}
+
+// NewSyntheticEngine returns an Engine that renders the given number of
+// ConfigMaps for every parent resource.
+func NewSyntheticEngine(children int) templating.Engine {
+	return templating.EngineFunc(func(cr resource.ParentResource) ([]resource.ChildResource, error) {
+		list := make([]resource.ChildResource, children)
+		for i := range list {
+			u := &unstructured.Unstructured{}
+			u.SetAPIVersion("v1")
+			u.SetKind("ConfigMap")
+			u.SetName(fmt.Sprintf("%s-%d", cr.GetName(), i))
+			if err := unstructured.SetNestedField(u.Object, fmt.Sprintf("%d", i), "data", "index"); err != nil {
+				return nil, err
+			}
+			list[i] = u
+		}
+		return list, nil
+	})
+}
+
+// Writes are the numbers of writes to the API server by verb.
+type Writes struct {
+	Create       int64
+	Update       int64
+	Patch        int64
+	Delete       int64
+	StatusUpdate int64
+	StatusPatch  int64
+}
+
+// Total returns the number of all writes.
+func (w Writes) Total() int64 {
+	return w.Create + w.Update + w.Patch + w.Delete + w.StatusUpdate + w.StatusPatch
+}
+
+// String returns the writes in a compact form.
+func (w Writes) String() string {
+	return fmt.Sprintf("%d (create %d, update %d, patch %d, delete %d, status update %d, status patch %d)",
+		w.Total(), w.Create, w.Update, w.Patch, w.Delete, w.StatusUpdate, w.StatusPatch)
+}
+
+// NewCountingClient returns a new *CountingClient that counts the writes of
+// the given client.Client.
+func NewCountingClient(c client.Client) *CountingClient {
+	return &CountingClient{Client: c}
+}
+
+// CountingClient is a client.Client that counts the writes it makes to the
+// API server. It is safe for concurrent use.
+type CountingClient struct {
+	client.Client
+	writes Writes
+}
+
+// Writes returns the writes counted since the last Reset.
+func (c *CountingClient) Writes() Writes {
+	return Writes{
+		Create:       atomic.LoadInt64(&c.writes.Create),
+		Update:       atomic.LoadInt64(&c.writes.Update),
+		Patch:        atomic.LoadInt64(&c.writes.Patch),
+		Delete:       atomic.LoadInt64(&c.writes.Delete),
+		StatusUpdate: atomic.LoadInt64(&c.writes.StatusUpdate),
+		StatusPatch:  atomic.LoadInt64(&c.writes.StatusPatch),
+	}
+}
+
+// Reset sets all counts to zero.
+func (c *CountingClient) Reset() {
+	for _, n := range []*int64{&c.writes.Create, &c.writes.Update, &c.writes.Patch, &c.writes.Delete, &c.writes.StatusUpdate, &c.writes.StatusPatch} {
+		atomic.StoreInt64(n, 0)
+	}
+}
+
+// Create counts and makes a create.
+func (c *CountingClient) Create(ctx context.Context, obj kruntime.Object, opts ...client.CreateOption) error {
+	atomic.AddInt64(&c.writes.Create, 1)
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// Update counts and makes an update.
+func (c *CountingClient) Update(ctx context.Context, obj kruntime.Object, opts ...client.UpdateOption) error {
+	atomic.AddInt64(&c.writes.Update, 1)
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// Patch counts and makes a patch.
+func (c *CountingClient) Patch(ctx context.Context, obj kruntime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	atomic.AddInt64(&c.writes.Patch, 1)
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+// Delete counts and makes a delete.
+func (c *CountingClient) Delete(ctx context.Context, obj kruntime.Object, opts ...client.DeleteOption) error {
+	atomic.AddInt64(&c.writes.Delete, 1)
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// Status returns a client.StatusWriter that counts the writes of the status.
+func (c *CountingClient) Status() client.StatusWriter {
+	return &countingStatusWriter{StatusWriter: c.Client.Status(), writes: &c.writes}
+}
+
+type countingStatusWriter struct {
+	client.StatusWriter
+	writes *Writes
+}
+
+func (w *countingStatusWriter) Update(ctx context.Context, obj kruntime.Object, opts ...client.UpdateOption) error {
+	atomic.AddInt64(&w.writes.StatusUpdate, 1)
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func (w *countingStatusWriter) Patch(ctx context.Context, obj kruntime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	atomic.AddInt64(&w.writes.StatusPatch, 1)
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
@@ -0,0 +1,54 @@
+//go:build scale
+// +build scale
+
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scale
+
+import (
+	"context"
+	"flag"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// The scale test needs the binaries of envtest, see its documentation, and
+// runs only with the scale build tag, e.g.
+//
+//	go test -tags scale ./test/scale/... -parents 1000 -children 20
+var (
+	parents     = flag.Int("parents", 100, "number of parent resources")
+	children    = flag.Int("children", 10, "number of child resources per parent resource")
+	concurrency = flag.Int("concurrency", 1, "number of reconciles at the same time")
+)
+
+func TestScale(t *testing.T) {
+	env := &envtest.Environment{CRDDirectoryPaths: []string{"crds"}}
+	cfg, err := env.Start()
+	if err != nil {
+		t.Fatalf("cannot start envtest: %s", err)
+	}
+	defer env.Stop() // nolint:errcheck
+
+	c := Config{Parents: *parents, ChildrenPerParent: *children, Concurrency: *concurrency}
+	res, err := Run(context.Background(), cfg, c)
+	if err != nil {
+		t.Fatalf("Run(...): %s", err)
+	}
+	t.Log(res.String(c))
+}